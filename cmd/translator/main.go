@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/cli"
@@ -27,7 +28,14 @@ func main() {
 
 	// 执行命令
 	if err := rootCmd.Execute(); err != nil {
+		// 命令要求的退出码（如翻译被中断）在命令的 defer 执行完后才退出
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			_ = log.Sync()
+			os.Exit(exitErr.Code)
+		}
 		log.Error("执行命令失败", zap.Error(err))
+		_ = log.Sync()
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/eval"
+	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
+	"github.com/nerdneilsfield/go-translator-agent/internal/stats"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	// eval 命令相关标志
+	evalAlignment string
+	evalModel     string
+	evalStepSet   string
+	evalResults   string
+	evalWorst     int
+	evalNoRecord  bool
+)
+
+// NewEvalCommand 创建 eval 命令
+func NewEvalCommand() *cobra.Command {
+	evalCmd := &cobra.Command{
+		Use:   "eval [flags] <output_file> <reference_file>",
+		Short: "用参考译文计算 BLEU、chrF++ 和 TER",
+		Long: `把译文与人工参考译文对齐后计算语料级和每个节点的 BLEU、chrF++ 和 TER：
+
+- 两个文档的可翻译节点数相同时按节点编号对齐，否则拆分为句子后按内容相似度对齐
+- 分词对中日文按字切分，其他语言按空白和标点切分
+- 评分为 0-100，BLEU 和 chrF++ 越高越好，TER 越低越好
+
+结果默认记录到统计数据库，按模型和步骤集区分，用 'translator stats --evals' 查看分数随时间的变化和回退。
+
+用法示例：
+  translator eval output.md reference.md
+  translator eval --step-set quality --results eval.json output.md reference.md`,
+		Args: cobra.ExactArgs(2),
+		RunE: runEvalCommand,
+	}
+
+	evalCmd.Flags().StringVar(&evalAlignment, "alignment", eval.AlignAuto, "对齐方式 (auto, node, sentence)")
+	evalCmd.Flags().StringVar(&evalModel, "model", "", "记录的模型名称，默认为步骤集中使用的模型")
+	evalCmd.Flags().StringVar(&evalStepSet, "step-set", "", "记录的步骤集，默认为当前激活的步骤集")
+	evalCmd.Flags().StringVar(&evalResults, "results", "", "把每个节点的评分写入 JSON 文件")
+	evalCmd.Flags().IntVar(&evalWorst, "worst", 5, "显示评分最低的节点数")
+	evalCmd.Flags().BoolVar(&evalNoRecord, "no-record", false, "不把结果记录到统计数据库")
+
+	return evalCmd
+}
+
+// runEvalCommand 执行 eval 命令
+func runEvalCommand(cmd *cobra.Command, args []string) error {
+	log := logger.NewLoggerWithVerbose(debugMode, verboseMode)
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	updateConfigFromFlags(cmd, cfg)
+
+	evaluator := eval.NewEvaluator(cfg, log, eval.Options{Alignment: evalAlignment})
+	result, err := evaluator.Evaluate(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	stepSet := evalStepSet
+	if stepSet == "" {
+		stepSet = cfg.ActiveStepSet
+	}
+	model := evalModel
+	if model == "" {
+		model = eval.StepSetModels(cfg, stepSet)
+	}
+
+	fmt.Printf("评测完成：%d 段（%s 对齐，译文 %d 个节点，参考译文 %d 个节点）\n",
+		len(result.Segments), result.Alignment, result.OutputNodes, result.ReferenceNodes)
+	fmt.Printf("  BLEU   %6.2f\n  chrF++ %6.2f\n  TER    %6.2f\n", result.Corpus.BLEU, result.Corpus.ChrF, result.Corpus.TER)
+
+	if worst := result.Worst(evalWorst); evalWorst > 0 && len(worst) > 0 {
+		fmt.Printf("\n评分最低的 %d 段：\n", len(worst))
+		for _, segment := range worst {
+			fmt.Printf("  #%-4d BLEU %6.2f  chrF++ %6.2f  TER %6.2f  %s\n",
+				segment.ID, segment.BLEU, segment.ChrF, segment.TER, truncateText(segment.Hypothesis, 40))
+		}
+	}
+
+	if evalResults != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal evaluation results: %w", err)
+		}
+		if err := os.WriteFile(evalResults, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write evaluation results: %w", err)
+		}
+		fmt.Printf("\n结果: %s\n", evalResults)
+	}
+
+	if evalNoRecord {
+		return nil
+	}
+	db, err := stats.NewDatabase(getStatsPath(cfg), log)
+	if err != nil {
+		return fmt.Errorf("failed to initialize statistics database: %w", err)
+	}
+	if err := db.AddEvaluationRecord(result.Record(model, stepSet)); err != nil {
+		return fmt.Errorf("failed to record evaluation: %w", err)
+	}
+	log.Debug("recorded evaluation", zap.String("model", model), zap.String("step_set", stepSet))
+	return nil
+}
+
+// truncateText 把文本压缩为一行并截断到指定字符数
+func truncateText(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/experiment"
+	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	// experiment 命令相关标志
+	experimentVariants     []string
+	experimentJudge        string
+	experimentOutputDir    string
+	experimentReportFormat string
+	experimentMaxNodes     int
+)
+
+// NewExperimentCommand 创建 experiment 命令
+func NewExperimentCommand() *cobra.Command {
+	experimentCmd := &cobra.Command{
+		Use:   "experiment [flags] <sample_document>",
+		Short: "用样例文档对比多个步骤集或提示词模板变体",
+		Long: `把样例文档的每个节点分别用两个或更多变体翻译，并排比较译文：
+
+- 变体格式为 [名称=]步骤集[@模板目录]，如 basic、v2=basic@./prompts-v2
+- 每个变体用 ThreeStepTranslator 翻译（步骤集的前三个步骤依次作为初始翻译、反思和改进），不使用缓存
+- 译文按相似度、格式和推理标记启发式评分，指定 --judge 时再由评审模型并排打分（1-10）
+- 令牌数、成本和请求延迟来自提供商统计；提供商没有返回成本时按模型配置的价格估算
+
+结果写到输出目录：results.json 包含每个节点、每个变体的译文和评分，
+report.md 或 report.html 是对比报告。
+
+用法示例：
+  translator experiment --variant basic --variant quality sample.md
+  translator experiment --variant v1=basic --variant v2=basic@./prompts-v2 --judge gpt-4o --report-format html sample.md`,
+		Args: cobra.ExactArgs(1),
+		RunE: runExperimentCommand,
+	}
+
+	experimentCmd.Flags().StringArrayVar(&experimentVariants, "variant", nil, "实验变体 [名称=]步骤集[@模板目录]，至少两个")
+	experimentCmd.Flags().StringVar(&experimentJudge, "judge", "", "评审模型名称，为空时只使用启发式评分")
+	experimentCmd.Flags().StringVar(&experimentOutputDir, "output-dir", "", "结果目录，默认为样例文档旁的 <文件名>.experiment")
+	experimentCmd.Flags().StringVar(&experimentReportFormat, "report-format", experiment.ReportMarkdown, "报告格式 (markdown, html)")
+	experimentCmd.Flags().IntVar(&experimentMaxNodes, "max-nodes", 0, "最多翻译的节点数，0 表示全部")
+
+	return experimentCmd
+}
+
+// runExperimentCommand 执行 experiment 命令
+func runExperimentCommand(cmd *cobra.Command, args []string) error {
+	log := logger.NewLoggerWithVerbose(debugMode, verboseMode)
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	updateConfigFromFlags(cmd, cfg)
+
+	var variants []experiment.Variant
+	for _, spec := range experimentVariants {
+		variant, err := experiment.ParseVariant(spec)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, variant)
+	}
+
+	reportExt := ".md"
+	switch experimentReportFormat {
+	case experiment.ReportMarkdown, "md":
+	case experiment.ReportHTML:
+		reportExt = ".html"
+	default:
+		return fmt.Errorf("unsupported report format: %s", experimentReportFormat)
+	}
+
+	inputPath := args[0]
+	outputDir := experimentOutputDir
+	if outputDir == "" {
+		outputDir = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".experiment"
+	}
+
+	runner := experiment.NewRunner(cfg, log, experiment.Options{
+		Judge:       experimentJudge,
+		MaxNodes:    experimentMaxNodes,
+		Concurrency: cfg.Concurrency,
+	})
+	result, err := runner.Run(cmd.Context(), inputPath, variants)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	resultsPath := filepath.Join(outputDir, "results.json")
+	if err := writeExperimentFile(resultsPath, func(f *os.File) error { return experiment.WriteJSON(f, result) }); err != nil {
+		return err
+	}
+	reportPath := filepath.Join(outputDir, "report"+reportExt)
+	if err := writeExperimentFile(reportPath, func(f *os.File) error {
+		return experiment.WriteReport(f, result, experimentReportFormat)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("实验完成：%d 个节点，%d 个变体\n", len(result.Nodes), len(result.Variants))
+	for _, summary := range result.Variants {
+		fmt.Printf("  %-20s 启发式 %.2f", summary.Variant.Name, summary.HeuristicScore)
+		if result.Judge != "" {
+			fmt.Printf("  评审 %.2f  胜出 %d", summary.JudgeScore, summary.Wins)
+		}
+		fmt.Printf("  失败 %d  耗时 %s\n", summary.Failed, summary.Duration.Round(time.Millisecond))
+	}
+	fmt.Printf("结果: %s\n报告: %s\n", resultsPath, reportPath)
+	log.Debug("experiment finished", zap.String("output_dir", outputDir))
+	return nil
+}
+
+// writeExperimentFile 创建文件并写入内容
+func writeExperimentFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// prompts render 命令相关标志
+	renderStep        string
+	renderFormat      string
+	renderText        string
+	renderTranslation string
+	renderFeedback    string
+	renderContext     string
+)
+
+// NewPromptsCommand 创建 prompts 命令
+func NewPromptsCommand() *cobra.Command {
+	promptsCmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "管理和预览提示词模板",
+	}
+
+	renderCmd := &cobra.Command{
+		Use:   "render [file]",
+		Short: "预览步骤发送给模型的完整提示词",
+		Long: `用当前配置（步骤集、语言、--prompt-templates 模板目录和词汇表）渲染一个步骤的提示词，
+输出与翻译时发送给模型的内容完全一致。提示词输出到标准输出，使用的模板和版本输出到标准错误。
+
+片段文本来自 --text 或文件参数；文档格式默认按文件扩展名推断。
+反思和改进步骤可以用 --translation 和 --feedback 提供初始翻译和反思结果。
+
+用法示例：
+  translator prompts render --prompt-templates ./prompts --text "Hello, world"
+  translator prompts render --step reflection --translation "你好，世界" chunk.md
+  translator prompts render --step-set quality --format html --text "<p>Hi</p>"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runPromptsRender,
+	}
+	renderCmd.Flags().StringVar(&renderStep, "step", "", "步骤名称，默认为步骤集的第一个步骤")
+	renderCmd.Flags().StringVar(&renderFormat, "format", "", "文档格式（如 markdown、html），默认按文件扩展名推断")
+	renderCmd.Flags().StringVar(&renderText, "text", "", "要渲染的片段文本")
+	renderCmd.Flags().StringVar(&renderTranslation, "translation", "", "初始翻译（反思、改进步骤）")
+	renderCmd.Flags().StringVar(&renderFeedback, "feedback", "", "反思结果（改进步骤）")
+	renderCmd.Flags().StringVar(&renderContext, "context", "", "文档简介")
+
+	promptsCmd.AddCommand(renderCmd)
+	return promptsCmd
+}
+
+// runPromptsRender 执行 prompts render 命令
+func runPromptsRender(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	updateConfigFromFlags(cmd, cfg)
+	if provider != "" {
+		updateConfigForProvider(cfg, provider)
+	}
+
+	text := renderText
+	format := renderFormat
+	if len(args) == 1 {
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+		if text == "" {
+			text = string(content)
+		}
+		if format == "" {
+			format = detectFileFormat(args[0])
+		}
+	}
+	if text == "" {
+		return fmt.Errorf("no text to render: use --text or pass a file")
+	}
+
+	translationConfig := translation.NewConfigFromGlobal(cfg)
+	if len(translationConfig.Steps) == 0 {
+		return fmt.Errorf("step set '%s' not found or has no steps", cfg.ActiveStepSet)
+	}
+
+	index := 0
+	if renderStep != "" {
+		index = -1
+		for i, step := range translationConfig.Steps {
+			if strings.EqualFold(step.Name, renderStep) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("step '%s' not found in step set '%s'", renderStep, cfg.ActiveStepSet)
+		}
+	}
+
+	templates, err := translationConfig.LoadPromptTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	stepConfig := translationConfig.Steps[index]
+	stepConfig.Templates = templates
+	stepConfig.StepSet = cfg.ActiveStepSet
+
+	prompt, source, err := translation.RenderStepPrompt(&stepConfig,
+		renderStepInput(translationConfig, index, text, format))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "步骤: %s/%s  模板: %s  版本: %s\n",
+		cfg.ActiveStepSet, stepConfig.Name, source, templates.Version())
+	fmt.Println(prompt)
+	return nil
+}
+
+// renderStepInput 按翻译链的方式组装步骤输入：初始翻译处理原文，反思处理初始翻译，改进处理反思结果
+func renderStepInput(cfg *translation.Config, index int, text, format string) translation.StepInput {
+	input := translation.StepInput{
+		Text:           text,
+		SourceLanguage: cfg.SourceLanguage,
+		TargetLanguage: cfg.TargetLanguage,
+		Context: map[string]string{
+			"source_language": cfg.SourceLanguage,
+			"target_language": cfg.TargetLanguage,
+			"original_text":   text,
+		},
+	}
+	if cfg.Country != "" {
+		input.Context["country"] = cfg.Country
+	}
+	if format != "" {
+		input.Context["format"] = format
+	}
+	if renderContext != "" {
+		input.Context["document_brief"] = renderContext
+	}
+
+	if index >= 1 {
+		input.Text = renderTranslation
+		input.Context["translation"] = renderTranslation
+		input.Context["initial_translation"] = renderTranslation
+	}
+	if index >= 2 {
+		input.Text = renderFeedback
+		input.Context["reflection"] = renderFeedback
+		input.Context["feedback"] = renderFeedback
+	}
+	input.Context["text"] = input.Text
+	return input
+}
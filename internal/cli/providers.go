@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	// providers probe 命令相关标志
+	probeTimeout time.Duration
+)
+
+// NewProvidersCommand 创建 providers 命令
+func NewProvidersCommand() *cobra.Command {
+	providersCmd := &cobra.Command{
+		Use:   "providers",
+		Short: "管理和检查配置的翻译提供商",
+	}
+
+	probeCmd := &cobra.Command{
+		Use:   "probe",
+		Short: "探测配置的提供商：健康检查、可用模型和支持的语言",
+		Long: `对步骤集中用到的每个提供商执行健康检查，并查询服务端的模型或语言列表：
+
+- openai：/models，确认配置的模型可用
+- ollama：/api/tags，确认模型已拉取
+- deepl：/languages，获取支持的源语言和目标语言
+- libretranslate：/languages，获取支持的源语言和目标语言
+- llamacpp：/props，获取上下文长度
+
+然后检查每个步骤集的源语言和目标语言是否被各步骤的提供商支持。默认探测全部步骤集，
+指定 --step-set 时只探测该步骤集。有提供商不可用或语言不被支持时返回非零退出码。
+
+用法示例：
+  translator providers probe
+  translator providers probe --step-set deepl --target German`,
+		Args: cobra.NoArgs,
+		RunE: runProvidersProbe,
+	}
+	probeCmd.Flags().DurationVar(&probeTimeout, "timeout", 30*time.Second, "探测每个提供商的超时时间")
+
+	providersCmd.AddCommand(probeCmd)
+	return providersCmd
+}
+
+// runProvidersProbe 执行 providers probe 命令
+func runProvidersProbe(cmd *cobra.Command, args []string) error {
+	log := logger.NewLoggerWithVerbose(debugMode, verboseMode)
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	updateConfigFromFlags(cmd, cfg)
+	if provider != "" {
+		updateConfigForProvider(cfg, provider)
+	}
+
+	var stepSets []string
+	if cmd.Flags().Changed("step-set") || provider != "" {
+		stepSets = []string{cfg.ActiveStepSet}
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), probeTimeout)
+	defer cancel()
+
+	providerManager := translation.NewProviderManager(translation.NewConfigFromGlobal(cfg), log)
+	report := providerManager.Probe(ctx, stepSets...)
+	if len(report.Providers) == 0 {
+		fmt.Println("没有需要探测的提供商")
+		return nil
+	}
+
+	printProbeReport(report, cfg)
+
+	unhealthy := 0
+	for _, probe := range report.Providers {
+		if probe.Err != nil || !probe.Result.Healthy() {
+			unhealthy++
+		}
+	}
+	// 无法映射为语言代码的语言名称只提示，不算作问题
+	languageIssues := 0
+	for _, issue := range report.LanguageIssues {
+		if !issue.Unknown {
+			languageIssues++
+		}
+	}
+	if unhealthy > 0 || languageIssues > 0 {
+		log.Debug("提供商探测发现问题",
+			zap.Int("unhealthy", unhealthy),
+			zap.Int("language_issues", languageIssues))
+		return fmt.Errorf("%d provider(s) unavailable, %d language issue(s)", unhealthy, languageIssues)
+	}
+	return nil
+}
+
+// printProbeReport 显示探测结果
+func printProbeReport(report *translation.ProbeReport, cfg *config.Config) {
+	fmt.Printf("探测 %d 个提供商（%s → %s）\n", len(report.Providers), cfg.SourceLang, cfg.TargetLang)
+
+	for _, probe := range report.Providers {
+		fmt.Printf("\n%s / %s", probe.Provider, probe.Model)
+		if probe.ModelID != "" && probe.ModelID != probe.Model {
+			fmt.Printf(" (%s)", probe.ModelID)
+		}
+		fmt.Println()
+		fmt.Printf("  步骤: %v\n", probe.Steps)
+
+		if probe.Err != nil {
+			fmt.Printf("  状态: ❌ 无法创建提供商: %v\n", probe.Err)
+			continue
+		}
+		result := probe.Result
+		if result.Healthy() {
+			fmt.Println("  状态: ✅ 可用")
+		} else {
+			fmt.Printf("  状态: ❌ 不可用: %v\n", result.HealthError)
+		}
+
+		switch {
+		case result.ModelsError != nil:
+			fmt.Printf("  模型: ⚠️  获取失败: %v\n", result.ModelsError)
+		case result.Models != nil && probe.ModelAvailable():
+			fmt.Printf("  模型: %d 个可用，包含 %s\n", len(result.Models), probe.ModelID)
+		case result.Models != nil:
+			fmt.Printf("  模型: ⚠️  %d 个可用，不包含 %s\n", len(result.Models), probe.ModelID)
+		}
+
+		switch {
+		case result.LanguagesError != nil:
+			fmt.Printf("  语言: ⚠️  获取失败: %v\n", result.LanguagesError)
+		case result.SourceLanguages != nil || result.TargetLanguages != nil:
+			fmt.Printf("  语言: 源语言 %d 种，目标语言 %d 种\n", len(result.SourceLanguages), len(result.TargetLanguages))
+		}
+
+		if result.ContextWindow > 0 {
+			fmt.Printf("  上下文长度: %d tokens\n", result.ContextWindow)
+		}
+		if result.Capabilities.MaxTextLength > 0 {
+			fmt.Printf("  最大文本长度: %d 字符\n", result.Capabilities.MaxTextLength)
+		}
+	}
+
+	var unsupported, unknown []translation.LanguageIssue
+	for _, issue := range report.LanguageIssues {
+		if issue.Unknown {
+			unknown = append(unknown, issue)
+		} else {
+			unsupported = append(unsupported, issue)
+		}
+	}
+	role := func(issue translation.LanguageIssue) string {
+		if issue.Target {
+			return "目标语言"
+		}
+		return "源语言"
+	}
+	if len(unsupported) > 0 {
+		fmt.Printf("\n发现 %d 处不被支持的语言：\n", len(unsupported))
+		for _, issue := range unsupported {
+			fmt.Printf("  - [%s/%s] %s 不支持%s %s\n", issue.StepSet, issue.Step, issue.Provider, role(issue), issue.Language)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("\n%d 处语言名称无法映射为语言代码，未能确认是否支持：\n", len(unknown))
+		for _, issue := range unknown {
+			fmt.Printf("  - [%s/%s] %s 的%s %s\n", issue.StepSet, issue.Step, issue.Provider, role(issue), issue.Language)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
+	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// NewResumeCommand 创建 resume 命令
+func NewResumeCommand() *cobra.Command {
+	resumeCmd := &cobra.Command{
+		Use:   "resume [flags] <session-id>",
+		Short: "恢复中断的翻译会话",
+		Long: `恢复被中断或部分失败的翻译会话。
+
+每次翻译都会把节点的译文和各步骤的输出保存到会话中（位于缓存目录）。
+恢复时重新解析源文件并确认其未被修改，已完成的节点直接使用保存的译文，
+只翻译未完成和失败的节点，最后写入原来的输出文件。
+
+用法示例：
+  translator resume file-1718000000000000000              # 恢复会话
+  translator resume --config config.yaml file-1718000000  # 使用指定配置恢复`,
+		Args: cobra.ExactArgs(1),
+		RunE: runResumeCommand,
+	}
+
+	return resumeCmd
+}
+
+// runResumeCommand 执行 resume 命令
+func runResumeCommand(cmd *cobra.Command, args []string) error {
+	sessionID := args[0]
+
+	tempLog := logger.NewLoggerWithVerbose(debugMode, verboseMode)
+	defer func() {
+		_ = tempLog.Sync()
+	}()
+
+	coordinator, log, err := newTranslationCoordinator(cmd, tempLog)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	session, err := coordinator.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+
+	// 输入经过预处理时，原始文件需要以相同方式重新预处理
+	inputPath := session.InputPath
+	if session.SourcePath != "" && session.SourcePath != session.InputPath {
+		if _, err := os.Stat(session.SourcePath); err != nil {
+			return fmt.Errorf("source file of session %s is not available: %w", sessionID, err)
+		}
+		preparedPath, cleanup, err := prepareTranslationInput(session.SourcePath, tempLog)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		inputPath = preparedPath
+	}
+
+	log.Info("恢复翻译会话",
+		zap.String("会话", sessionID),
+		zap.String("源文件", session.SourcePath),
+		zap.String("输出文件", session.OutputPath))
+
+	ctx, stopInterruptHandling := withInterruptHandling(cmd.Context(), shutdownGracePeriod, log, coordinator.PauseActiveSessions)
+	defer stopInterruptHandling()
+
+	result, err := coordinator.ResumeSessionFrom(ctx, sessionID, inputPath)
+	if errors.Is(err, translator.ErrTranslationInterrupted) {
+		printInterruptedResult(result, log)
+		return exitError(cmd, interruptedExitCode, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resume session %s: %w", sessionID, err)
+	}
+
+	printTranslationResult(coordinator, result, log)
+	printConsistencyReport(coordinator)
+	return nil
+}
@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 	"github.com/nerdneilsfield/go-translator-agent/internal/formatfix"
@@ -13,6 +15,7 @@ import (
 	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
 	"github.com/nerdneilsfield/go-translator-agent/internal/preformat"
 	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -46,6 +49,27 @@ var (
 	providers    []string // 可用的提供商列表
 	showConfig   bool     // 显示当前配置
 
+	// 中断处理
+	shutdownGracePeriod time.Duration // 中断后等待进行中请求完成的时间
+
+	// 文档简介相关标志
+	documentBrief     bool   // 翻译前生成文档简介
+	documentBriefFile string // 用户提供的文档简介文件
+
+	// 风格指南相关标志
+	styleProfile string // 风格配置名称或 YAML 文件路径
+
+	// 区域格式改写相关标志
+	adaptLocale       bool   // 按目标区域设置改写数字、日期、时间、单位和货币
+	adaptTargetLocale string // 区域格式改写的目标区域设置
+
+	// 批量翻译协议
+	batchProtocol string // markers 或 json
+
+	validateLanguages bool // 翻译前检查提供商是否支持源语言和目标语言
+
+	promptTemplatesDir string // 提示词模板目录
+
 	// 翻译后处理相关标志
 	enablePostProcessing      bool   // 启用翻译后处理
 	glossaryPath              string // 词汇表文件路径
@@ -76,9 +100,11 @@ func NewRootCommand(version, commit, buildDate string) *cobra.Command {
   - openai: OpenAI GPT 模型
   - deepl: DeepL 专业翻译
   - google: Google Translate
+  - google-v3: Google Cloud Translation v3 (服务账号认证)
   - deeplx: DeepLX (免费 DeepL 替代)
   - libretranslate: LibreTranslate (开源)
-  - ollama: Ollama 本地大语言模型`,
+  - ollama: Ollama 本地大语言模型
+  - llamacpp: llama.cpp server 本地模型 (离线环境)`,
 		Version: fmt.Sprintf("%s (commit %s, built %s)", version, commit, buildDate),
 		Args: func(cmd *cobra.Command, args []string) error {
 			// 对于特殊的标志命令，不需要参数
@@ -98,7 +124,7 @@ func NewRootCommand(version, commit, buildDate string) *cobra.Command {
 			}
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// 初始化临时日志（用于加载配置）
 			tempLog := logger.NewLoggerWithVerbose(debugMode, verboseMode)
 			defer func() {
@@ -107,42 +133,42 @@ func NewRootCommand(version, commit, buildDate string) *cobra.Command {
 
 			if showVersion {
 				fmt.Printf("翻译工具 %s (commit %s, built %s)\n", version, commit, buildDate)
-				return
+				return nil
 			}
 
 			// 列出可用的提供商
 			if listProviders() {
 				fmt.Println("支持的翻译提供商:")
-				providers := []string{"openai", "deepl", "google", "deeplx", "libretranslate"}
+				providers := []string{"openai", "deepl", "google", "google-v3", "deeplx", "libretranslate", "ollama", "llamacpp"}
 				for _, p := range providers {
 					fmt.Printf("  - %s\n", p)
 				}
-				return
+				return nil
 			}
 
 			// 处理其他列表命令
 			if listModels || listStepSets || listFormats || listCache || listFormatFixers || showConfig {
 				handleListCommands(cmd, args, tempLog)
-				return
+				return nil
 			}
 
 			// 处理格式检查模式
 			if checkFormatOnly {
 				handleFormatCheckOnly(cmd, args, tempLog)
-				return
+				return nil
 			}
 
 			// 处理预演模式
 			if dryRun {
 				handleDryRun(cmd, args, tempLog)
-				return
+				return nil
 			}
 
 			// 获取输入和输出文件路径
 			if len(args) < 2 {
 				tempLog.Error("缺少输入或输出文件参数")
 				fmt.Println("使用方法: translator [flags] input_file output_file")
-				os.Exit(1)
+				return exitError(cmd, 1, errors.New("missing input or output file"))
 			}
 
 			inputPath := args[0]
@@ -156,142 +182,56 @@ func NewRootCommand(version, commit, buildDate string) *cobra.Command {
 				_, err := formatterManager.FormatFile(inputPath, inputPath, nil)
 				if err != nil {
 					tempLog.Error("格式化文件失败", zap.Error(err))
-					os.Exit(1)
+					return exitError(cmd, 1, err)
 				}
 				tempLog.Info("格式化完成", zap.String("文件", inputPath))
-				return
-			}
-
-			// 在翻译之前先格式化文件
-			_, err := formatterManager.FormatFile(inputPath, inputPath, nil)
-			if err != nil {
-				tempLog.Error("文件格式化失败，无法继续翻译",
-					zap.String("文件", inputPath),
-					zap.Error(err))
-				os.Exit(1)
+				return nil
 			}
-			tempLog.Info("文件格式化完成", zap.String("文件", inputPath))
 
-			// 创建预处理器并处理文件
-			preFormatter := preformat.NewPreFormatter(tempLog)
-			preformattedPath, err := preFormatter.ProcessFile(inputPath)
+			// 格式化并预处理文本格式的输入
+			translationInputPath, cleanup, err := prepareTranslationInput(inputPath, tempLog)
 			if err != nil {
-				tempLog.Error("文件预处理失败，无法继续翻译",
-					zap.String("文件", inputPath),
-					zap.Error(err))
-				os.Exit(1)
+				return exitError(cmd, 1, err)
 			}
-			tempLog.Info("文件预处理完成",
-				zap.String("原文件", inputPath),
-				zap.String("预处理文件", preformattedPath))
-
-			// 确保在程序结束时清理临时文件
-			defer func() {
-				if cleanupErr := preFormatter.CleanupTempFile(preformattedPath); cleanupErr != nil {
-					tempLog.Warn("清理预处理临时文件失败", zap.Error(cleanupErr))
-				}
-			}()
+			defer cleanup()
 
-			// 使用预处理后的文件作为翻译输入
-			translationInputPath := preformattedPath
-
-			// 加载配置
-			cfg, err := config.LoadConfig(cfgFile)
+			coordinator, log, err := newTranslationCoordinator(cmd, tempLog)
 			if err != nil {
-				tempLog.Error("加载配置失败", zap.Error(err))
-				os.Exit(1)
+				return exitError(cmd, 1, err)
 			}
-
-			// 根据配置创建详细日志
-			detailedLogConfig := logger.DetailedLogConfig{
-				EnableDetailedLog: cfg.EnableDetailedLog,
-				LogLevel:          cfg.LogLevel,
-				ConsoleLogLevel:   cfg.ConsoleLogLevel,
-				NormalLogFile:     cfg.NormalLogFile,
-				DetailedLogFile:   cfg.DetailedLogFile,
-				Debug:             cfg.Debug || debugMode,
-				Verbose:           cfg.Verbose || verboseMode,
-			}
-
-			loggerWrapper := logger.NewDetailedLogger(detailedLogConfig)
-			log := loggerWrapper.GetZapLogger()
 			defer func() {
 				_ = log.Sync()
 			}()
 
-			// 处理预定义翻译（暂时不使用）
-			if predefinedTranslationsPath != "" {
-				_, err = config.LoadPredefinedTranslations(predefinedTranslationsPath)
-				if err != nil {
-					log.Error("加载预定义翻译失败", zap.Error(err))
-					os.Exit(1)
-				}
-			}
-
-			// 使用命令行参数覆盖配置
-			updateConfigFromFlags(cmd, cfg)
-
-			// 如果指定了提供商，更新配置
-			if provider != "" {
-				log.Info("使用指定的翻译提供商", zap.String("provider", provider))
-				// 可以在这里设置特定的步骤集或模型配置来使用指定的提供商
-				updateConfigForProvider(cfg, provider)
-			}
-
-			// 创建缓存目录（如果不存在）
-			if cfg.UseCache {
-				if err := os.MkdirAll(cfg.CacheDir, 0o755); err != nil {
-					log.Error("创建缓存目录失败", zap.Error(err))
-					os.Exit(1)
-				}
-			}
-
-			// 使用 Translation Coordinator 进行翻译
-			log.Info("使用 Translation Coordinator")
-
-			// 使用与 stats 命令一致的路径
-			progressPath := cfg.CacheDir
-			if progressPath == "" {
-				progressPath = "/tmp/.translator-progress"
-			}
-
-			coordinator, err := translator.NewTranslationCoordinator(cfg, log, progressPath)
-			if err != nil {
-				log.Error("创建 Translation Coordinator 失败", zap.Error(err))
-				os.Exit(1)
-			}
-
 			// 如果启用流式输出，设置相关配置
 			if streamOutput {
 				log.Info("流式输出已启用")
 				// TODO: 实现流式输出支持
 			}
 
-			// 直接使用 coordinator 翻译文件 (使用预处理后的文件)
-			ctx := cmd.Context()
+			// 直接使用 coordinator 翻译文件 (使用预处理后的文件)，记录原始文件以便恢复会话
+			ctx, stopInterruptHandling := withInterruptHandling(
+				translator.WithSourceFile(cmd.Context(), inputPath), shutdownGracePeriod, log, coordinator.PauseActiveSessions)
+			defer stopInterruptHandling()
+
 			result, err := coordinator.TranslateFile(ctx, translationInputPath, outputPath)
+			if errors.Is(err, translator.ErrTranslationInterrupted) {
+				printInterruptedResult(result, log)
+				return exitError(cmd, interruptedExitCode, err)
+			}
 			if err != nil {
 				log.Error("翻译文件失败", zap.Error(err))
-				os.Exit(1)
+				if result != nil {
+					if _, sessionErr := coordinator.GetSession(result.DocID); sessionErr == nil {
+						fmt.Printf("翻译已中断，可以使用 translator resume %s 继续\n", result.DocID)
+					}
+				}
+				return exitError(cmd, 1, err)
 			}
 
-			// 显示翻译结果
-			log.Info("翻译完成",
-				zap.String("输入文件", result.InputFile),
-				zap.String("输出文件", result.OutputFile),
-				// zap.String("源语言", result.SourceLanguage),
-				// zap.String("目标语言", result.TargetLanguage),
-				zap.Int("总节点", result.TotalNodes),
-				zap.Int("完成节点", result.CompletedNodes),
-				zap.Int("失败节点", result.FailedNodes),
-				zap.Float64("进度", result.Progress),
-				zap.Duration("耗时", result.Duration),
-			)
-
-			// 如果有失败节点，显示详细信息
-			if result.FailedNodes > 0 {
-				coordinator.PrintDetailedTranslationSummary(result)
-			}
+			printTranslationResult(coordinator, result, log)
+			printConsistencyReport(coordinator)
+			return nil
 		},
 	}
 
@@ -299,18 +239,218 @@ func NewRootCommand(version, commit, buildDate string) *cobra.Command {
 	addGlobalFlags(rootCmd)
 
 	// 添加新的标志
-	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "指定翻译提供商 (openai, deepl, google, deeplx, libretranslate)")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "指定翻译提供商 (openai, deepl, google, google-v3, deeplx, libretranslate, ollama, llamacpp)")
 	rootCmd.PersistentFlags().BoolVar(&streamOutput, "stream", false, "启用流式输出 (实时显示翻译进度)")
 	rootCmd.PersistentFlags().StringSliceVar(&providers, "list-providers", nil, "列出支持的翻译提供商")
 	rootCmd.PersistentFlags().BoolVar(&showConfig, "show-config", false, "显示当前配置信息")
+	rootCmd.PersistentFlags().DurationVar(&shutdownGracePeriod, "shutdown-grace", 30*time.Second, "中断后等待进行中的翻译请求完成的时间，超时后取消请求；再次中断时保存进度后立即退出")
 
 	// 添加子命令
 	rootCmd.AddCommand(NewStatsCommand())
 	rootCmd.AddCommand(NewFormatCommand())
+	rootCmd.AddCommand(NewResumeCommand())
+	rootCmd.AddCommand(NewSiteCommand())
+	rootCmd.AddCommand(NewProvidersCommand())
+	rootCmd.AddCommand(NewPromptsCommand())
+	rootCmd.AddCommand(NewExperimentCommand())
+	rootCmd.AddCommand(NewEvalCommand())
 
 	return rootCmd
 }
 
+// prepareTranslationInput 格式化并预处理文本格式的输入文件，返回实际翻译的输入文件和清理函数。
+// 二进制文档（如 DOCX、PPTX）直接交给处理器
+func prepareTranslationInput(inputPath string, tempLog *zap.Logger) (string, func(), error) {
+	// 使用原始文件作为翻译输入，文本格式会替换为预处理后的文件
+	if !isTextFormatInput(inputPath) {
+		return inputPath, func() {}, nil
+	}
+
+	// 在翻译之前先格式化文件
+	formatterManager := formatter.NewManager(tempLog)
+	_, err := formatterManager.FormatFile(inputPath, inputPath, nil)
+	if err != nil {
+		tempLog.Error("文件格式化失败，无法继续翻译",
+			zap.String("文件", inputPath),
+			zap.Error(err))
+		return "", nil, err
+	}
+	tempLog.Info("文件格式化完成", zap.String("文件", inputPath))
+
+	// 创建预处理器并处理文件
+	preFormatter := preformat.NewPreFormatter(tempLog)
+	preformattedPath, err := preFormatter.ProcessFile(inputPath)
+	if err != nil {
+		tempLog.Error("文件预处理失败，无法继续翻译",
+			zap.String("文件", inputPath),
+			zap.Error(err))
+		return "", nil, err
+	}
+	tempLog.Info("文件预处理完成",
+		zap.String("原文件", inputPath),
+		zap.String("预处理文件", preformattedPath))
+
+	// 确保在程序结束时清理临时文件
+	cleanup := func() {
+		if cleanupErr := preFormatter.CleanupTempFile(preformattedPath); cleanupErr != nil {
+			tempLog.Warn("清理预处理临时文件失败", zap.Error(cleanupErr))
+		}
+	}
+
+	// 使用预处理后的文件作为翻译输入
+	return preformattedPath, cleanup, nil
+}
+
+// newTranslationCoordinator 加载配置、应用命令行参数并创建 Translation Coordinator，
+// 同时返回根据配置创建的详细日志
+func newTranslationCoordinator(cmd *cobra.Command, tempLog *zap.Logger) (*translator.TranslationCoordinator, *zap.Logger, error) {
+	// 加载配置
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		tempLog.Error("加载配置失败", zap.Error(err))
+		return nil, nil, err
+	}
+
+	// 根据配置创建详细日志
+	detailedLogConfig := logger.DetailedLogConfig{
+		EnableDetailedLog: cfg.EnableDetailedLog,
+		LogLevel:          cfg.LogLevel,
+		ConsoleLogLevel:   cfg.ConsoleLogLevel,
+		NormalLogFile:     cfg.NormalLogFile,
+		DetailedLogFile:   cfg.DetailedLogFile,
+		Debug:             cfg.Debug || debugMode,
+		Verbose:           cfg.Verbose || verboseMode,
+	}
+
+	loggerWrapper := logger.NewDetailedLogger(detailedLogConfig)
+	log := loggerWrapper.GetZapLogger()
+
+	// 处理预定义翻译（暂时不使用）
+	if predefinedTranslationsPath != "" {
+		_, err = config.LoadPredefinedTranslations(predefinedTranslationsPath)
+		if err != nil {
+			log.Error("加载预定义翻译失败", zap.Error(err))
+			return nil, log, err
+		}
+	}
+
+	// 使用命令行参数覆盖配置
+	updateConfigFromFlags(cmd, cfg)
+
+	// 如果指定了提供商，更新配置
+	if provider != "" {
+		log.Info("使用指定的翻译提供商", zap.String("provider", provider))
+		// 可以在这里设置特定的步骤集或模型配置来使用指定的提供商
+		updateConfigForProvider(cfg, provider)
+	}
+
+	// 创建缓存目录（如果不存在）
+	if cfg.UseCache {
+		if err := os.MkdirAll(cfg.CacheDir, 0o755); err != nil {
+			log.Error("创建缓存目录失败", zap.Error(err))
+			return nil, log, err
+		}
+	}
+
+	// 确认提供商支持源语言和目标语言
+	if cfg.ValidateLanguages {
+		providerManager := translation.NewProviderManager(translation.NewConfigFromGlobal(cfg), log)
+		if err := providerManager.ValidateLanguages(cmd.Context(), cfg.ActiveStepSet); err != nil {
+			log.Error("提供商不支持配置的语言，可以使用 translator providers probe 查看详情", zap.Error(err))
+			return nil, log, err
+		}
+	}
+
+	// 使用 Translation Coordinator 进行翻译
+	log.Info("使用 Translation Coordinator")
+
+	// 使用与 stats 命令一致的路径
+	progressPath := cfg.CacheDir
+	if progressPath == "" {
+		progressPath = "/tmp/.translator-progress"
+	}
+
+	coordinator, err := translator.NewTranslationCoordinator(cfg, log, progressPath)
+	if err != nil {
+		log.Error("创建 Translation Coordinator 失败", zap.Error(err))
+		return nil, log, err
+	}
+
+	return coordinator, log, nil
+}
+
+// printTranslationResult 显示翻译结果
+func printTranslationResult(coordinator *translator.TranslationCoordinator, result *translator.TranslationResult, log *zap.Logger) {
+	log.Info("翻译完成",
+		zap.String("会话", result.DocID),
+		zap.String("输入文件", result.InputFile),
+		zap.String("输出文件", result.OutputFile),
+		// zap.String("源语言", result.SourceLanguage),
+		// zap.String("目标语言", result.TargetLanguage),
+		zap.Int("总节点", result.TotalNodes),
+		zap.Int("完成节点", result.CompletedNodes),
+		zap.Int("失败节点", result.FailedNodes),
+		zap.Float64("进度", result.Progress),
+		zap.Duration("耗时", result.Duration),
+	)
+
+	// 如果有失败节点，显示详细信息，失败的节点可以通过恢复会话重新翻译
+	if result.FailedNodes > 0 {
+		coordinator.PrintDetailedTranslationSummary(result)
+		fmt.Printf("可以使用 translator resume %s 重新翻译失败的节点\n", result.DocID)
+	}
+
+	printStyleViolations(result)
+}
+
+// printStyleViolations 显示译文中违反风格配置的地方
+func printStyleViolations(result *translator.TranslationResult) {
+	if len(result.StyleViolations) == 0 {
+		return
+	}
+
+	rules := map[string]string{
+		translator.StyleRuleQuoteMarks:     "引号",
+		translator.StyleRuleUnits:          "单位",
+		translator.StyleRuleForbiddenTerms: "禁用词",
+		translator.StyleRuleKeepTerms:      "保留名称",
+	}
+	fmt.Printf("\n%s 中发现 %d 处违反风格配置：\n", result.OutputFile, len(result.StyleViolations))
+	for _, violation := range result.StyleViolations {
+		fmt.Printf("  - [%s] %s: %q（%s）\n", rules[violation.Rule], violation.Location, violation.Text, violation.Detail)
+	}
+}
+
+// printConsistencyReport 显示本次运行中未能避免的不一致译法
+func printConsistencyReport(coordinator *translator.TranslationCoordinator) {
+	inconsistencies := coordinator.Consistency().Inconsistencies()
+	if len(inconsistencies) == 0 {
+		return
+	}
+
+	fmt.Printf("\n发现 %d 处不一致的译法：\n", len(inconsistencies))
+	for _, inconsistency := range inconsistencies {
+		kind := "片段"
+		if inconsistency.Kind == translator.InconsistencyTerm {
+			kind = "术语"
+		}
+		fmt.Printf("  - [%s] %q\n", kind, inconsistency.Source)
+		fmt.Printf("      %s: %q\n", inconsistency.ExpectedLocation, inconsistency.Expected)
+		fmt.Printf("      %s: %q\n", inconsistency.Location, inconsistency.Actual)
+	}
+}
+
+// printInterruptedResult 显示被中断的翻译结果
+func printInterruptedResult(result *translator.TranslationResult, log *zap.Logger) {
+	log.Warn("翻译已中断，已写入部分译文",
+		zap.String("会话", result.DocID),
+		zap.String("输出文件", result.OutputFile),
+		zap.Int("总节点", result.TotalNodes),
+		zap.Int("完成节点", result.CompletedNodes))
+	fmt.Printf("翻译已中断：部分译文已写入 %s，未翻译的内容保留原文并加上标记。\n", result.OutputFile)
+	fmt.Printf("可以使用 translator resume %s 继续翻译\n", result.DocID)
+}
+
 // listProviders 检查是否需要列出提供商
 func listProviders() bool {
 	return len(providers) > 0 || os.Getenv("LIST_PROVIDERS") == "true"
@@ -479,6 +619,42 @@ func updateConfigFromFlags(cmd *cobra.Command, cfg *config.Config) {
 	if cmd.Flags().Changed("mt-cleanup") {
 		cfg.MachineTranslationCleanup = machineTranslationCleanup
 	}
+
+	// 文档简介相关配置更新，提供简介文件时同时启用
+	if cmd.Flags().Changed("brief") {
+		cfg.DocumentBrief.Enabled = documentBrief
+	}
+	if cmd.Flags().Changed("brief-file") {
+		cfg.DocumentBrief.File = documentBriefFile
+		cfg.DocumentBrief.Enabled = documentBriefFile != ""
+	}
+
+	// 风格指南相关配置更新，命令行指定的风格配置用于所有文件
+	if cmd.Flags().Changed("style") {
+		cfg.Style.Profile = styleProfile
+		cfg.Style.ByFormat = nil
+	}
+
+	// 区域格式改写相关配置更新，指定目标区域设置时同时启用
+	if cmd.Flags().Changed("adapt-locale") {
+		cfg.LocaleAdaptation.Enabled = adaptLocale
+	}
+	if cmd.Flags().Changed("target-locale") {
+		cfg.LocaleAdaptation.TargetLocale = adaptTargetLocale
+		cfg.LocaleAdaptation.Enabled = adaptTargetLocale != ""
+	}
+
+	if cmd.Flags().Changed("batch-protocol") {
+		cfg.BatchProtocol = batchProtocol
+	}
+
+	if cmd.Flags().Changed("validate-languages") {
+		cfg.ValidateLanguages = validateLanguages
+	}
+
+	if cmd.Flags().Changed("prompt-templates") {
+		cfg.PromptTemplatesDir = promptTemplatesDir
+	}
 }
 
 // updateConfigForProvider 根据指定的提供商更新配置
@@ -536,10 +712,14 @@ func getDefaultModelForProvider(provider string) string {
 		return "deepl"
 	case "google":
 		return "google-translate"
+	case "google-v3":
+		return "nmt"
 	case "deeplx":
 		return "deeplx"
 	case "libretranslate":
 		return "libretranslate"
+	case "llamacpp":
+		return "local"
 	default:
 		return "gpt-3.5-turbo"
 	}
@@ -584,6 +764,26 @@ func addGlobalFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().BoolVar(&terminologyConsistency, "terminology-consistency", true, "启用术语一致性检查")
 	rootCmd.PersistentFlags().BoolVar(&mixedLanguageSpacing, "mixed-language-spacing", true, "启用中英文混排空格优化")
 	rootCmd.PersistentFlags().BoolVar(&machineTranslationCleanup, "mt-cleanup", true, "启用机器翻译痕迹清理")
+
+	// 文档简介相关标志
+	rootCmd.PersistentFlags().BoolVar(&documentBrief, "brief", false, "翻译前生成文档简介（主题、领域、语气、读者、关键实体）并加入每个翻译请求，简介保存为可编辑的 .brief.txt 文件")
+	rootCmd.PersistentFlags().StringVar(&documentBriefFile, "brief-file", "", "使用指定文件中的文档简介，不再生成")
+
+	// 风格指南相关标志
+	rootCmd.PersistentFlags().StringVar(&styleProfile, "style", "", "使用的风格配置，配置中 style.profiles 的名称或 YAML 文件路径")
+
+	// 区域格式改写相关标志
+	rootCmd.PersistentFlags().BoolVar(&adaptLocale, "adapt-locale", false, "翻译后按目标区域设置改写数字、日期、时间、计量单位和货币金额（不经过模型）")
+	rootCmd.PersistentFlags().StringVar(&adaptTargetLocale, "target-locale", "", "区域格式改写的目标区域设置（如 de-DE、en-GB），设置后同时启用改写")
+
+	// 批量翻译协议
+	rootCmd.PersistentFlags().StringVar(&batchProtocol, "batch-protocol", "markers", "批量翻译协议：markers（节点标记）或 json（支持结构化输出的提供商发送 [{id, text}] 并校验返回的 [{id, translation}]）")
+
+	// 提供商检查
+	rootCmd.PersistentFlags().BoolVar(&validateLanguages, "validate-languages", true, "翻译前向能列出语言的提供商（DeepL、LibreTranslate）确认支持源语言和目标语言")
+
+	// 提示词模板
+	rootCmd.PersistentFlags().StringVar(&promptTemplatesDir, "prompt-templates", "", "提示词模板目录（text/template 格式的 .tmpl 文件），可按步骤集和文档格式覆盖")
 }
 
 // handleListFormatFixers 处理列出格式修复器命令
@@ -711,6 +911,10 @@ func detectFileFormat(filePath string) string {
 	switch ext {
 	case ".md", ".markdown":
 		return "markdown"
+	case ".mdx":
+		return "mdx"
+	case ".ipynb":
+		return "ipynb"
 	case ".txt":
 		return "text"
 	case ".html", ".htm":
@@ -719,11 +923,47 @@ func detectFileFormat(filePath string) string {
 		return "epub"
 	case ".tex":
 		return "latex"
+	case ".docx", ".doc":
+		return "docx"
+	case ".pptx":
+		return "pptx"
+	case ".xlsx":
+		return "xlsx"
+	case ".odt":
+		return "odt"
+	case ".odp":
+		return "odp"
+	case ".srt":
+		return "srt"
+	case ".vtt":
+		return "vtt"
+	case ".ass", ".ssa":
+		return "ass"
+	case ".po", ".pot":
+		return "po"
+	case ".json":
+		return "i18n-json"
+	case ".yml", ".yaml":
+		return "i18n-yaml"
+	case ".strings":
+		return "strings"
+	case ".xcstrings":
+		return "xcstrings"
 	default:
 		return "text"
 	}
 }
 
+// isTextFormatInput 判断输入文件是否为需要格式化和预处理的文本格式
+func isTextFormatInput(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md", ".markdown", ".txt", ".text", ".html", ".htm", ".epub", ".tex", ".latex":
+		return true
+	default:
+		return false
+	}
+}
+
 // getSeverityIcon 获取严重性图标
 func getSeverityIcon(severity formatfix.Severity) string {
 	switch severity {
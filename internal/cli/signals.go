@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// interruptedExitCode 翻译被中断时的退出码
+const interruptedExitCode = 130
+
+// ExitError 要求以指定退出码结束进程的错误。命令通过 RunE 返回它，
+// 由 main 在所有 defer 执行完后调用 os.Exit
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error 实现 error 接口
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitError 返回以 code 结束进程的错误。错误信息已经显示过，cobra 不再打印错误和用法
+func exitError(cmd *cobra.Command, code int, err error) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &ExitError{Code: code, Err: err}
+}
+
+// exitProcess 结束进程，测试中替换
+var exitProcess = os.Exit
+
+// withInterruptHandling 设置 Ctrl-C / SIGTERM 处理，返回翻译使用的上下文和清理函数。
+// 第一次中断停止调度新的节点组，进行中的请求在宽限期内完成，超时后被取消，
+// 随后保存进度会话并写入部分译文；第二次中断调用 saveSessions 尽力保存进度会话后立即结束进程，
+// 不再组装和写入译文。saveSessions 可以为 nil
+func withInterruptHandling(parent context.Context, gracePeriod time.Duration, log *zap.Logger, saveSessions func()) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Warn("收到中断信号，停止调度新的翻译请求",
+				zap.String("signal", sig.String()),
+				zap.Duration("宽限期", gracePeriod))
+			fmt.Fprintf(os.Stderr, "\n正在等待进行中的翻译请求完成（最多 %s），完成后保存进度并写入部分译文。再次按 Ctrl-C 保存进度后立即退出\n", gracePeriod)
+			close(stop)
+		case <-done:
+			return
+		}
+
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-signals:
+				log.Error("再次收到中断信号，保存进度后立即退出")
+				if saveSessions != nil {
+					saveSessions()
+				}
+				_ = log.Sync()
+				exitProcess(interruptedExitCode)
+				return
+			case <-timer.C:
+				log.Warn("宽限期已过，取消进行中的翻译请求")
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	cleanup := func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+
+	return translator.WithStopSignal(ctx, stop), cleanup
+}
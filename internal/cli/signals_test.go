@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestExitError 测试命令通过 RunE 返回退出码，cobra 不打印错误和用法
+func TestExitError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "interrupted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitError(cmd, interruptedExitCode, translator.ErrTranslationInterrupted)
+		},
+	}
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetErr(&output)
+	cmd.SetArgs(nil)
+
+	err := cmd.Execute()
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 130, exitErr.Code)
+	assert.ErrorIs(t, err, translator.ErrTranslationInterrupted)
+	assert.Empty(t, output.String())
+}
+
+// interrupt 向当前进程发送 Ctrl-C
+func interrupt(t *testing.T) {
+	t.Helper()
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(os.Interrupt))
+}
+
+// TestInterruptHandling 测试第一次中断停止调度、宽限期内不取消请求，第二次中断保存进度后立即退出
+func TestInterruptHandling(t *testing.T) {
+	exited := make(chan int, 1)
+	exitProcess = func(code int) { exited <- code }
+	defer func() { exitProcess = os.Exit }()
+
+	saved := 0
+	ctx, cleanup := withInterruptHandling(context.Background(), time.Hour, zap.NewNop(), func() { saved++ })
+	defer cleanup()
+
+	interrupt(t)
+	require.Eventually(t, func() bool { return translator.StopRequested(ctx) }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, ctx.Err(), "in-flight requests keep running during the grace period")
+
+	interrupt(t)
+	select {
+	case code := <-exited:
+		assert.Equal(t, interruptedExitCode, code)
+	case <-time.After(time.Second):
+		t.Fatal("second interrupt did not exit")
+	}
+	assert.Equal(t, 1, saved)
+}
+
+// TestInterruptGracePeriod 测试宽限期过后取消进行中的请求
+func TestInterruptGracePeriod(t *testing.T) {
+	ctx, cleanup := withInterruptHandling(context.Background(), 20*time.Millisecond, zap.NewNop(), nil)
+	defer cleanup()
+
+	interrupt(t)
+	require.Eventually(t, func() bool { return ctx.Err() != nil }, time.Second, 10*time.Millisecond)
+	assert.True(t, translator.StopRequested(ctx))
+}
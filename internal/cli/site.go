@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/logger"
+	"github.com/nerdneilsfield/go-translator-agent/internal/site"
+	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	// site 命令相关标志
+	siteGenerator    string
+	siteLocale       string
+	siteSourceLocale string
+	siteForce        bool
+)
+
+// NewSiteCommand 创建 site 命令
+func NewSiteCommand() *cobra.Command {
+	siteCmd := &cobra.Command{
+		Use:   "site [flags] <site_root>",
+		Short: "按静态站点的多语言目录结构翻译整个站点",
+		Long: `翻译 Hugo、Docusaurus 或 MkDocs 站点的全部源语言内容，并把译文写到生成器
+约定的本地化位置：
+
+- Hugo：content/<源语言>/ 目录结构时写到 content/<目标语言>/，否则写为 page.<目标语言>.md
+- Docusaurus：写到 i18n/<目标语言>/docusaurus-plugin-content-{docs/current,blog,pages}/
+- MkDocs（mkdocs-static-i18n）：docs/<源语言>/ 目录结构时写到 docs/<目标语言>/，否则写为 page.<目标语言>.md
+
+生成器根据站点配置文件自动识别，目标区域设置默认取自配置中的 i18n.target_locale
+或目标语言，并按站点配置中声明的语言写法（如 zh-Hans）调整。
+
+译文中指向站内其他页面的相对链接会改写为指向对应的本地化页面。翻译记录保存在站点根目录的
+.translator-site.json 中：源文件未修改的译文和不是由本工具生成的本地化文件不会被重新翻译，
+使用 --force 可以全部重新翻译。
+
+用法示例：
+  translator site ./my-site --target Chinese              # 自动识别生成器
+  translator site --generator hugo --locale zh ./blog     # 指定生成器和区域设置
+  translator site --dry-run ./docs-site                   # 只列出将要翻译的文件`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSiteCommand,
+	}
+
+	siteCmd.Flags().StringVar(&siteGenerator, "generator", "", "静态站点生成器 (hugo, docusaurus, mkdocs)，默认自动识别")
+	siteCmd.Flags().StringVar(&siteLocale, "locale", "", "目标区域设置，默认由配置推导")
+	siteCmd.Flags().StringVar(&siteSourceLocale, "source-locale", "", "源区域设置，默认由配置推导")
+	siteCmd.Flags().BoolVar(&siteForce, "force", false, "重新翻译所有文件，包括未修改的和已本地化的文件")
+
+	return siteCmd
+}
+
+// runSiteCommand 执行 site 命令
+func runSiteCommand(cmd *cobra.Command, args []string) error {
+	root := args[0]
+
+	tempLog := logger.NewLoggerWithVerbose(debugMode, verboseMode)
+	defer func() {
+		_ = tempLog.Sync()
+	}()
+
+	generator := site.Generator(siteGenerator)
+	if generator == "" {
+		detected, err := site.DetectGenerator(root)
+		if err != nil {
+			return fmt.Errorf("%w, use --generator to specify it", err)
+		}
+		generator = detected
+	}
+
+	// 区域设置默认取自配置
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	updateConfigFromFlags(cmd, cfg)
+
+	targetLocale := siteLocale
+	if targetLocale == "" {
+		targetLocale = cfg.I18n.TargetLocale
+		if targetLocale == "" {
+			targetLocale = cfg.TargetLang
+		}
+		targetLocale = site.ResolveLocale(root, generator, targetLocale)
+	}
+	sourceLocale := siteSourceLocale
+	if sourceLocale == "" {
+		sourceLocale = cfg.I18n.SourceLocale
+		if sourceLocale == "" {
+			sourceLocale = cfg.SourceLang
+		}
+		sourceLocale = site.ResolveLocale(root, generator, sourceLocale)
+	}
+
+	layout, err := site.NewLayout(root, generator, sourceLocale, targetLocale)
+	if err != nil {
+		return err
+	}
+
+	log := tempLog
+	var coordinator *translator.TranslationCoordinator
+	var translate site.TranslateFileFunc
+	if !dryRun {
+		var detailedLog *zap.Logger
+		coordinator, detailedLog, err = newTranslationCoordinator(cmd, tempLog)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = detailedLog.Sync()
+		}()
+		log = detailedLog
+
+		translate = func(ctx context.Context, inputPath, outputPath string) error {
+			result, err := coordinator.TranslateFile(translator.WithSourceFile(ctx, inputPath), inputPath, outputPath)
+			if errors.Is(err, translator.ErrTranslationInterrupted) {
+				printInterruptedResult(result, log)
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			printTranslationResult(coordinator, result, log)
+			return nil
+		}
+	}
+
+	log.Info("翻译站点",
+		zap.String("站点", root),
+		zap.String("生成器", string(generator)),
+		zap.String("源区域设置", sourceLocale),
+		zap.String("目标区域设置", targetLocale))
+
+	var saveSessions func()
+	if coordinator != nil {
+		saveSessions = coordinator.PauseActiveSessions
+	}
+	ctx, stopInterruptHandling := withInterruptHandling(cmd.Context(), shutdownGracePeriod, log, saveSessions)
+	defer stopInterruptHandling()
+
+	report, err := site.NewTranslator(layout, translate, log).Run(ctx, site.Options{
+		Force:  siteForce,
+		DryRun: dryRun,
+	})
+	if report != nil {
+		printSiteReport(report, layout)
+	}
+	if coordinator != nil {
+		printConsistencyReport(coordinator)
+	}
+	if errors.Is(err, translator.ErrTranslationInterrupted) {
+		fmt.Println("站点翻译已中断，重新运行相同的命令即可继续")
+		return exitError(cmd, interruptedExitCode, err)
+	}
+	if err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d file(s) failed to translate", len(report.Failed))
+	}
+	return nil
+}
+
+// printSiteReport 显示站点翻译结果
+func printSiteReport(report *site.Report, layout *site.Layout) {
+	action := "已翻译"
+	if dryRun {
+		action = "将翻译"
+	}
+	for _, source := range report.Translated {
+		fmt.Printf("%s: %s -> %s\n", action, source, layout.TargetPath(source))
+	}
+	for source, err := range report.Failed {
+		fmt.Printf("失败: %s: %v\n", source, err)
+	}
+	fmt.Printf("\n%s %d 个文件，%d 个未修改，%d 个已本地化，%d 个失败\n",
+		action, len(report.Translated), len(report.UpToDate), len(report.Localized), len(report.Failed))
+}
@@ -57,6 +57,9 @@ Examples:
   # Show detailed node processing statistics
   translator stats --nodes
 
+  # Show reference evaluation history per model and step set
+  translator stats --evals
+
   # Export statistics to JSON
   translator stats --export stats.json
 
@@ -81,6 +84,7 @@ Examples:
 	statsCmd.Flags().Bool("formats", false, "Show only file format statistics")
 	statsCmd.Flags().Bool("performance", false, "Show only performance statistics")
 	statsCmd.Flags().Bool("nodes", false, "Show detailed node processing statistics")
+	statsCmd.Flags().Bool("evals", false, "Show reference evaluation history (BLEU, chrF++, TER)")
 
 	return statsCmd
 }
@@ -140,6 +144,7 @@ func runStatsCommand(cmd *cobra.Command, args []string) error {
 	showFormats, _ := cmd.Flags().GetBool("formats")
 	showPerformance, _ := cmd.Flags().GetBool("performance")
 	showNodes, _ := cmd.Flags().GetBool("nodes")
+	showEvals, _ := cmd.Flags().GetBool("evals")
 
 	// 显示统计信息
 	if showCache {
@@ -164,6 +169,11 @@ func runStatsCommand(cmd *cobra.Command, args []string) error {
 		return showNodeStats(db)
 	}
 
+	if showEvals {
+		visualizer.ShowEvaluations(recentLimit)
+		return nil
+	}
+
 	// 默认显示概览和最近翻译
 	visualizer.ShowOverview()
 
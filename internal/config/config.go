@@ -24,6 +24,9 @@ type ModelConfig struct {
 	IsReasoning      bool     `mapstructure:"is_reasoning"`       // 是否是推理模型
 	ReasoningTags    []string `mapstructure:"reasoning_tags"`     // 推理过程标记（如 ["<think>", "</think>"]）
 	IsLLM            bool     `mapstructure:"is_llm"`             // 是否是LLM模型（支持复杂推理和对话）
+
+	// Options 提供商专用选项（如 Google v3 的 location、glossary），键由各提供商定义
+	Options map[string]string `mapstructure:"options"`
 }
 
 // Deprecated: Use StepConfigV2 instead
@@ -55,6 +58,80 @@ type SmartNodeSplittingConfig struct {
 	OverlapRatio         float64 `mapstructure:"overlap_ratio"`           // 重叠比例（0.0-0.3）
 }
 
+// PPTXConfig PPTX 文档处理配置
+type PPTXConfig struct {
+	OverflowMode      string  `mapstructure:"overflow_mode"`      // 译文溢出处理模式: "none"、"shrink"（缩小字号）或 "resize"（扩大文本框）
+	OverflowThreshold float64 `mapstructure:"overflow_threshold"` // 译文与原文宽度比超过该值时才处理溢出
+	MinFontScale      float64 `mapstructure:"min_font_scale"`     // shrink 模式下的最小字号缩放比例（0-1）
+}
+
+// XLSXConfig XLSX 表格处理配置
+type XLSXConfig struct {
+	SkipSheets          []string `mapstructure:"skip_sheets"`           // 不翻译的工作表名称
+	SkipColumns         []string `mapstructure:"skip_columns"`          // 不翻译的列，如 "A" 或 "Sheet1!A"
+	ColumnMappings      []string `mapstructure:"column_mappings"`       // 列映射模式，如 "B:C" 表示读取 B 列原文、写入 C 列译文
+	TranslateSheetNames bool     `mapstructure:"translate_sheet_names"` // 是否翻译工作表名称
+	TranslateComments   bool     `mapstructure:"translate_comments"`    // 是否翻译单元格批注
+}
+
+// SubtitleConfig 字幕（SRT/WebVTT/ASS）处理配置
+type SubtitleConfig struct {
+	MergeSentences    bool    `mapstructure:"merge_sentences"`      // 是否将组成同一句子的相邻字幕合并翻译
+	MaxMergeGap       float64 `mapstructure:"max_merge_gap"`        // 合并字幕之间允许的最大间隔（秒）
+	MaxCharsPerLine   int     `mapstructure:"max_chars_per_line"`   // 每行最大字符数（东亚字符计为2），0 表示不限制
+	MaxLines          int     `mapstructure:"max_lines"`            // 每条字幕最大行数，0 表示不限制
+	MaxCharsPerSecond float64 `mapstructure:"max_chars_per_second"` // 最大阅读速度（字符/秒），0 表示不限制
+}
+
+// I18nConfig 软件本地化字符串文件（PO、i18next JSON、Rails YAML、Android、Apple）处理配置
+type I18nConfig struct {
+	SourceLocale string `mapstructure:"source_locale"` // 源区域设置（如 en），为空时由 source_lang 推导
+	TargetLocale string `mapstructure:"target_locale"` // 目标区域设置（如 zh-CN、pt-BR），为空时由 target_lang 推导
+}
+
+// FrontMatterConfig Markdown 文档开头的 YAML/TOML/JSON front matter 处理配置
+type FrontMatterConfig struct {
+	TranslateKeys []string `mapstructure:"translate_keys"` // 需要翻译的键，支持点号分隔的嵌套键（如 params.subtitle），其他键原样保留
+	LanguageKey   string   `mapstructure:"language_key"`   // 设置为目标语言的键（如 lang、locale），为空时不修改
+}
+
+// MDXConfig MDX 文档处理配置
+type MDXConfig struct {
+	TranslateProps []string `mapstructure:"translate_props"` // 需要翻译的 JSX 字符串属性名（如 title、label），为空时不翻译任何属性
+}
+
+// NotebookConfig Jupyter notebook 处理配置
+type NotebookConfig struct {
+	TranslateCodeComments bool `mapstructure:"translate_code_comments"` // 是否翻译代码单元格中的注释
+}
+
+// ConsistencyConfig 跨文件一致性配置：一次运行中翻译的所有文件共享术语和短片段的译法
+type ConsistencyConfig struct {
+	Enabled          bool `mapstructure:"enabled"`            // 是否启用跨文件一致性记录
+	MaxSegmentLength int  `mapstructure:"max_segment_length"` // 记录的短片段（标题、界面标签、图注等）的最大字符数
+	MaxTermWords     int  `mapstructure:"max_term_words"`     // 不超过该词数的短片段同时作为术语，在其他文本中出现时也保持一致
+	MaxPromptEntries int  `mapstructure:"max_prompt_entries"` // 每个翻译请求的提示词中最多加入的已有译法数
+}
+
+// DocumentBriefConfig 文档简介配置：翻译前先让模型概括文档的主题、领域、语气、读者和关键实体，
+// 并把简介加入每个翻译请求的提示词
+type DocumentBriefConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`          // 是否启用文档简介
+	File           string `mapstructure:"file"`             // 用户提供的简介文件，设置后所有文档都使用该简介，不再生成
+	Dir            string `mapstructure:"dir"`              // 生成的简介文件保存目录，为空时保存在输入文件旁
+	MaxSourceChars int    `mapstructure:"max_source_chars"` // 生成简介时最多发送的原文字符数
+}
+
+// LocaleAdaptationConfig 区域格式改写配置：翻译后不经过模型，把原文中的数字、日期、时间、
+// 计量单位和货币金额按目标区域设置的 CLDR 约定改写
+type LocaleAdaptationConfig struct {
+	Enabled         bool   `mapstructure:"enabled"`           // 是否启用区域格式改写
+	SourceLocale    string `mapstructure:"source_locale"`     // 源区域设置，为空时依次使用 i18n.source_locale 和 source_lang
+	TargetLocale    string `mapstructure:"target_locale"`     // 目标区域设置，为空时依次使用 i18n.target_locale 和 target_lang，并按 country 补上地区
+	ConvertUnits    bool   `mapstructure:"convert_units"`     // 是否在公制和英制之间换算计量单位
+	FullWidthDigits bool   `mapstructure:"full_width_digits"` // 中日韩目标语言是否使用全角数字
+}
+
 // Config 保存翻译器的所有配置
 type Config struct {
 	SourceLang        string                     `mapstructure:"source_lang"`
@@ -100,6 +177,9 @@ type Config struct {
 	KeepIntermediateFiles   bool                   `mapstructure:"keep_intermediate_files"`   // 是否保留中间文件（如EPUB解压的临时文件夹）
 	SaveDebugInfo           bool                   `mapstructure:"save_debug_info"`           // 是否保存调试信息到 JSON 文件
 	ChunkSize               int                    `mapstructure:"chunk_size"`                // 分块大小
+	BatchProtocol           string                 `mapstructure:"batch_protocol"`            // 批量翻译协议：markers（节点标记）或 json（结构化输出）
+	ValidateLanguages       bool                   `mapstructure:"validate_languages"`        // 翻译前检查源语言和目标语言是否被提供商支持
+	PromptTemplatesDir      string                 `mapstructure:"prompt_templates_dir"`      // 提示词模板目录，为空时使用内置提示词
 	RetryAttempts           int                    `mapstructure:"retry_attempts"`            // 重试次数
 	Metadata                map[string]interface{} `mapstructure:"metadata"`                  // 元数据
 
@@ -128,6 +208,39 @@ type Config struct {
 	// HTML/EPUB 处理配置
 	HTMLProcessingMode string `mapstructure:"html_processing_mode"` // HTML处理模式: "markdown" 或 "native"，默认 "markdown"
 
+	// PPTX 处理配置
+	PPTX PPTXConfig `mapstructure:"pptx"` // PPTX 文档处理配置
+
+	// XLSX 处理配置
+	XLSX XLSXConfig `mapstructure:"xlsx"` // XLSX 表格处理配置
+
+	// 字幕处理配置
+	Subtitle SubtitleConfig `mapstructure:"subtitle"` // 字幕处理配置
+
+	// 本地化字符串文件处理配置
+	I18n I18nConfig `mapstructure:"i18n"` // 本地化字符串文件处理配置
+
+	// Markdown front matter 处理配置
+	FrontMatter FrontMatterConfig `mapstructure:"front_matter"` // front matter 处理配置
+
+	// MDX 处理配置
+	MDX MDXConfig `mapstructure:"mdx"` // MDX 文档处理配置
+
+	// Jupyter notebook 处理配置
+	Notebook NotebookConfig `mapstructure:"notebook"` // Jupyter notebook 处理配置
+
+	// 跨文件一致性配置
+	Consistency ConsistencyConfig `mapstructure:"consistency"` // 跨文件一致性配置
+
+	// 文档简介配置
+	DocumentBrief DocumentBriefConfig `mapstructure:"document_brief"` // 文档简介配置
+
+	// 风格指南配置
+	Style StyleConfig `mapstructure:"style"` // 风格指南配置
+
+	// 区域格式改写配置
+	LocaleAdaptation LocaleAdaptationConfig `mapstructure:"locale_adaptation"` // 区域格式改写配置
+
 	// 统计配置
 	EnableStats       bool   `mapstructure:"enable_stats"`        // 是否启用统计功能
 	StatsDBPath       string `mapstructure:"stats_db_path"`       // 统计数据库路径
@@ -274,6 +387,8 @@ func NewDefaultConfig() *Config {
 		KeepIntermediateFiles:   false, // 默认不保留中间文件
 		SaveDebugInfo:           false,
 		ChunkSize:               2000, // 默认分块大小2000字符
+		BatchProtocol:           "markers",
+		ValidateLanguages:       true,
 
 		// 统计配置默认值
 		EnableStats:       true,                                           // 默认启用统计
@@ -286,6 +401,60 @@ func NewDefaultConfig() *Config {
 		// HTML/EPUB 处理配置
 		HTMLProcessingMode: "markdown", // 默认使用markdown模式
 
+		// PPTX 处理配置
+		PPTX: PPTXConfig{
+			OverflowMode:      "none", // 默认不调整文本框
+			OverflowThreshold: 1.3,    // 译文宽度超过原文30%时处理
+			MinFontScale:      0.5,    // 最多缩小到50%
+		},
+
+		// XLSX 处理配置
+		XLSX: XLSXConfig{
+			TranslateSheetNames: true, // 默认翻译工作表名称
+			TranslateComments:   true, // 默认翻译批注
+		},
+
+		// 字幕处理配置
+		Subtitle: SubtitleConfig{
+			MergeSentences:    true, // 默认合并跨字幕的句子
+			MaxMergeGap:       1.0,  // 间隔超过1秒的字幕不合并
+			MaxCharsPerLine:   42,   // 每行最多42个字符
+			MaxLines:          2,    // 每条字幕最多2行
+			MaxCharsPerSecond: 17,   // 每秒最多17个字符
+		},
+
+		// Markdown front matter 处理配置
+		FrontMatter: FrontMatterConfig{
+			TranslateKeys: []string{"title", "description", "summary", "tags"}, // 默认只翻译这些键
+		},
+
+		// 跨文件一致性配置
+		Consistency: ConsistencyConfig{
+			Enabled:          true, // 默认启用
+			MaxSegmentLength: 80,   // 80个字符以内的单行文本作为短片段
+			MaxTermWords:     4,    // 4个词以内的短片段同时作为术语
+			MaxPromptEntries: 30,   // 每个请求最多加入30条已有译法
+		},
+
+		// 文档简介配置
+		DocumentBrief: DocumentBriefConfig{
+			Enabled:        false, // 默认不启用，生成简介需要额外的模型调用
+			MaxSourceChars: 12000, // 最多发送12000个字符的原文
+		},
+
+		// 风格指南配置
+		Style: StyleConfig{
+			ByFormat: map[string]string{},
+			Profiles: map[string]StyleProfile{},
+			Check:    true, // 使用风格配置时默认检查违规
+		},
+
+		// 区域格式改写配置
+		LocaleAdaptation: LocaleAdaptationConfig{
+			Enabled:      false, // 默认不启用
+			ConvertUnits: true,  // 启用时默认换算计量单位
+		},
+
 		// 智能节点分割配置
 		SmartNodeSplitting: SmartNodeSplittingConfig{
 			EnableSmartSplitting: true, // 默认启用智能分割
@@ -551,6 +720,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("keep_intermediate_files", false)
 	v.SetDefault("save_debug_info", false)
 	v.SetDefault("chunk_size", 2000)
+	v.SetDefault("batch_protocol", "markers")
+	v.SetDefault("validate_languages", true)
+	v.SetDefault("prompt_templates_dir", "")
 	v.SetDefault("retry_attempts", 3)
 
 	// 格式修复默认配置
@@ -567,6 +739,52 @@ func setDefaults(v *viper.Viper) {
 	// HTML/EPUB 处理配置
 	v.SetDefault("html_processing_mode", "markdown") // 默认使用markdown模式处理HTML
 
+	// PPTX 处理配置
+	v.SetDefault("pptx.overflow_mode", "none")   // 默认不调整文本框
+	v.SetDefault("pptx.overflow_threshold", 1.3) // 译文宽度超过原文30%时处理
+	v.SetDefault("pptx.min_font_scale", 0.5)     // 最多缩小到50%
+
+	// XLSX 处理配置
+	v.SetDefault("xlsx.translate_sheet_names", true) // 默认翻译工作表名称
+	v.SetDefault("xlsx.translate_comments", true)    // 默认翻译批注
+
+	// 字幕处理配置
+	v.SetDefault("subtitle.merge_sentences", true)      // 默认合并跨字幕的句子
+	v.SetDefault("subtitle.max_merge_gap", 1.0)         // 间隔超过1秒的字幕不合并
+	v.SetDefault("subtitle.max_chars_per_line", 42)     // 每行最多42个字符
+	v.SetDefault("subtitle.max_lines", 2)               // 每条字幕最多2行
+	v.SetDefault("subtitle.max_chars_per_second", 17.0) // 每秒最多17个字符
+
+	// Markdown front matter 处理配置
+	v.SetDefault("front_matter.translate_keys", []string{"title", "description", "summary", "tags"}) // 默认只翻译这些键
+
+	// MDX 和 Jupyter notebook 处理配置
+	v.SetDefault("mdx.translate_props", []string{})         // 默认不翻译 JSX 属性
+	v.SetDefault("notebook.translate_code_comments", false) // 默认不翻译代码注释
+
+	// 跨文件一致性配置
+	v.SetDefault("consistency.enabled", true)          // 默认启用
+	v.SetDefault("consistency.max_segment_length", 80) // 80个字符以内的单行文本作为短片段
+	v.SetDefault("consistency.max_term_words", 4)      // 4个词以内的短片段同时作为术语
+	v.SetDefault("consistency.max_prompt_entries", 30) // 每个请求最多加入30条已有译法
+
+	// 文档简介配置
+	v.SetDefault("document_brief.enabled", false)          // 默认不启用
+	v.SetDefault("document_brief.file", "")                // 默认为每个文档生成简介
+	v.SetDefault("document_brief.dir", "")                 // 默认保存在输入文件旁
+	v.SetDefault("document_brief.max_source_chars", 12000) // 最多发送12000个字符的原文
+
+	// 风格指南配置
+	v.SetDefault("style.profile", "") // 默认不使用风格配置
+	v.SetDefault("style.check", true) // 使用风格配置时默认检查违规
+
+	// 区域格式改写配置
+	v.SetDefault("locale_adaptation.enabled", false)           // 默认不启用
+	v.SetDefault("locale_adaptation.source_locale", "")        // 默认由源语言推导
+	v.SetDefault("locale_adaptation.target_locale", "")        // 默认由目标语言和国家推导
+	v.SetDefault("locale_adaptation.convert_units", true)      // 默认换算计量单位
+	v.SetDefault("locale_adaptation.full_width_digits", false) // 默认使用半角数字
+
 	// 智能节点分割配置
 	v.SetDefault("smart_node_splitting.enable_smart_splitting", true)  // 默认启用智能分割
 	v.SetDefault("smart_node_splitting.max_node_size_threshold", 1500) // 超过1500字符才进行分割
@@ -615,6 +833,9 @@ func structToMap(config *Config) map[string]interface{} {
 		"keep_intermediate_files":   config.KeepIntermediateFiles,
 		"save_debug_info":           config.SaveDebugInfo,
 		"chunk_size":                config.ChunkSize,
+		"batch_protocol":            config.BatchProtocol,
+		"validate_languages":        config.ValidateLanguages,
+		"prompt_templates_dir":      config.PromptTemplatesDir,
 		"retry_attempts":            config.RetryAttempts,
 		"metadata":                  config.Metadata,
 
@@ -632,6 +853,37 @@ func structToMap(config *Config) map[string]interface{} {
 		// HTML/EPUB 处理配置
 		"html_processing_mode": config.HTMLProcessingMode,
 
+		// PPTX 处理配置
+		"pptx": config.PPTX,
+
+		// XLSX 处理配置
+		"xlsx": config.XLSX,
+
+		// 字幕处理配置
+		"subtitle": config.Subtitle,
+
+		// 本地化字符串文件处理配置
+		"i18n": config.I18n,
+
+		// Markdown front matter 处理配置
+		"front_matter": config.FrontMatter,
+
+		// MDX 和 Jupyter notebook 处理配置
+		"mdx":      config.MDX,
+		"notebook": config.Notebook,
+
+		// 跨文件一致性配置
+		"consistency": config.Consistency,
+
+		// 文档简介配置
+		"document_brief": config.DocumentBrief,
+
+		// 风格指南配置
+		"style": config.Style,
+
+		// 区域格式改写配置
+		"locale_adaptation": config.LocaleAdaptation,
+
 		// 智能节点分割配置
 		"smart_node_splitting": config.SmartNodeSplitting,
 	}
@@ -138,6 +138,26 @@ func validateStepSetConfigV2(config *StepSetConfigV2) error {
 		}
 	}
 
+	switch config.Mode {
+	case "", "three_step":
+	case "review":
+		if len(config.Steps) < 2 {
+			return fmt.Errorf("review mode needs a reviewer step after the initial translation step")
+		}
+	default:
+		return fmt.Errorf("unknown step set mode: %s", config.Mode)
+	}
+
+	switch config.Candidates.Selector {
+	case "", "heuristic":
+	case "llm":
+		if config.Candidates.SelectorModel == "" {
+			return fmt.Errorf("llm candidate selector needs a selector_model")
+		}
+	default:
+		return fmt.Errorf("unknown candidate selector: %s", config.Candidates.Selector)
+	}
+
 	return nil
 }
 
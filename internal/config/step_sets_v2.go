@@ -19,6 +19,13 @@ type StepSetConfigV2 struct {
 	Steps             []StepConfigV2 `mapstructure:"steps" json:"steps"`                             // 灵活的步骤列表
 	FastModeThreshold int            `mapstructure:"fast_mode_threshold" json:"fast_mode_threshold"` // 快速模式阈值
 
+	// 迭代审校：mode 为 "review" 时第二、三个步骤作为审校和编辑，循环到没有 major 问题或达到上限
+	Mode   string       `mapstructure:"mode" json:"mode,omitempty"`
+	Review ReviewConfig `mapstructure:"review" json:"review,omitempty"`
+
+	// 多候选生成：每个节点生成多个候选译文，由选择器选出最佳译文，落选的候选保留在节点元数据中供审校参考
+	Candidates CandidatesConfig `mapstructure:"candidates" json:"candidates,omitempty"`
+
 	// 兼容旧格式
 	Legacy             bool        `mapstructure:"legacy" json:"legacy,omitempty"`                           // 是否是旧格式
 	InitialTranslation *StepConfig `mapstructure:"initial_translation" json:"initial_translation,omitempty"` // 兼容旧格式
@@ -26,6 +33,24 @@ type StepSetConfigV2 struct {
 	Improvement        *StepConfig `mapstructure:"improvement" json:"improvement,omitempty"`                 // 兼容旧格式
 }
 
+// ReviewConfig 迭代审校配置
+type ReviewConfig struct {
+	MaxIterations int     `mapstructure:"max_iterations" json:"max_iterations,omitempty"` // 最大审校轮数，默认 3
+	MaxCost       float64 `mapstructure:"max_cost" json:"max_cost,omitempty"`             // 累计成本上限，0 表示不限制
+	MaxTokens     int     `mapstructure:"max_tokens" json:"max_tokens,omitempty"`         // 累计 token 上限，0 表示不限制
+}
+
+// CandidatesConfig 多候选生成配置。第一个候选按步骤集原样翻译，其余候选的初始翻译步骤
+// 依次改用 models 中的模型和 temperatures 中的温度；两者都为空时使用步骤集中其他 LLM 模型，
+// 没有其他模型时逐个提高初始翻译的温度
+type CandidatesConfig struct {
+	Count         int       `mapstructure:"count" json:"count,omitempty"`                   // 每个节点的候选数，小于 2 时不启用
+	Models        []string  `mapstructure:"models" json:"models,omitempty"`                 // 额外候选的初始翻译模型，与温度组合使用
+	Temperatures  []float64 `mapstructure:"temperatures" json:"temperatures,omitempty"`     // 额外候选的初始翻译温度，与模型组合使用
+	Selector      string    `mapstructure:"selector" json:"selector,omitempty"`             // heuristic（默认）或 llm
+	SelectorModel string    `mapstructure:"selector_model" json:"selector_model,omitempty"` // llm 选择器使用的模型
+}
+
 // ToStepConfigV2 将旧格式的 StepConfig 转换为新格式
 func (s StepConfig) ToStepConfigV2() StepConfigV2 {
 	return StepConfigV2{
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StyleConfig 风格指南配置：把目标语言的风格指南整理为可复用的风格配置，
+// 按运行或文件类型选择，加入每个翻译步骤的提示词
+type StyleConfig struct {
+	Profile  string                  `mapstructure:"profile"`   // 默认使用的风格配置，名称或 YAML 文件路径
+	ByFormat map[string]string       `mapstructure:"by_format"` // 按文件格式（如 markdown）或扩展名（如 .md）选择风格配置
+	Profiles map[string]StyleProfile `mapstructure:"profiles"`  // 命名的风格配置
+	Check    bool                    `mapstructure:"check"`     // 翻译后检查可以确定的风格违规（引号、单位、禁用词等）
+}
+
+// StyleProfile 目标语言的风格配置
+type StyleProfile struct {
+	Name               string   `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	Formality          string   `mapstructure:"formality" yaml:"formality" json:"formality,omitempty"`                            // 语体，如 formal、informal 或“使用您”
+	QuoteMarks         string   `mapstructure:"quote_marks" yaml:"quote_marks" json:"quote_marks,omitempty"`                      // 引号，开引号和闭引号，如 “” 或 «»
+	NestedQuoteMarks   string   `mapstructure:"nested_quote_marks" yaml:"nested_quote_marks" json:"nested_quote_marks,omitempty"` // 嵌套引号，如 ‘’
+	DecimalSeparator   string   `mapstructure:"decimal_separator" yaml:"decimal_separator" json:"decimal_separator,omitempty"`    // 小数点
+	ThousandsSeparator string   `mapstructure:"thousands_separator" yaml:"thousands_separator" json:"thousands_separator,omitempty"`
+	DateFormat         string   `mapstructure:"date_format" yaml:"date_format" json:"date_format,omitempty"`             // 日期格式，如 YYYY年M月D日
+	Units              string   `mapstructure:"units" yaml:"units" json:"units,omitempty"`                               // 计量单位：metric 或 imperial
+	KeepTerms          []string `mapstructure:"keep_terms" yaml:"keep_terms" json:"keep_terms,omitempty"`                // 保持原样不翻译的产品名等
+	ForbiddenTerms     []string `mapstructure:"forbidden_terms" yaml:"forbidden_terms" json:"forbidden_terms,omitempty"` // 译文中不允许出现的词，如正式语体下的“你”
+	Rules              []string `mapstructure:"rules" yaml:"rules" json:"rules,omitempty"`                               // 其他规则
+}
+
+// 计量单位
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// ProfileFor 返回文件使用的风格配置：先按格式和扩展名查找，再使用默认风格配置。
+// 没有配置时返回 nil
+func (c StyleConfig) ProfileFor(format, path string) (*StyleProfile, error) {
+	name := c.Profile
+	if byFormat, ok := c.ByFormat[format]; ok && format != "" {
+		name = byFormat
+	} else if byExt, ok := c.ByFormat[strings.ToLower(filepath.Ext(path))]; ok {
+		name = byExt
+	}
+	if name == "" {
+		return nil, nil
+	}
+	return c.Resolve(name)
+}
+
+// Resolve 按名称查找风格配置，名称不存在时作为 YAML 文件路径读取
+func (c StyleConfig) Resolve(nameOrPath string) (*StyleProfile, error) {
+	if profile, ok := c.Profiles[nameOrPath]; ok {
+		if profile.Name == "" {
+			profile.Name = nameOrPath
+		}
+		return &profile, profile.Validate()
+	}
+	ext := strings.ToLower(filepath.Ext(nameOrPath))
+	if ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("style profile %q not found", nameOrPath)
+	}
+	return LoadStyleProfile(nameOrPath)
+}
+
+// LoadStyleProfile 从 YAML 文件读取风格配置
+func LoadStyleProfile(path string) (*StyleProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read style profile: %w", err)
+	}
+	var profile StyleProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse style profile %s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &profile, profile.Validate()
+}
+
+// Validate 检查风格配置
+func (p *StyleProfile) Validate() error {
+	if p.QuoteMarks != "" && utf8.RuneCountInString(p.QuoteMarks) != 2 {
+		return fmt.Errorf("style profile %s: quote_marks must be an opening and a closing mark, got %q", p.Name, p.QuoteMarks)
+	}
+	if p.NestedQuoteMarks != "" && utf8.RuneCountInString(p.NestedQuoteMarks) != 2 {
+		return fmt.Errorf("style profile %s: nested_quote_marks must be an opening and a closing mark, got %q", p.Name, p.NestedQuoteMarks)
+	}
+	if p.Units != "" && p.Units != UnitsMetric && p.Units != UnitsImperial {
+		return fmt.Errorf("style profile %s: units must be %s or %s, got %q", p.Name, UnitsMetric, UnitsImperial, p.Units)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package document
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// MaxConstraintRetranslations is the number of rounds translations that break
+// the processor constraints are re-translated more concisely
+const MaxConstraintRetranslations = 2
+
+// RetranslateFunc re-translates the given blocks under ctx, which carries the
+// concise retranslation note, and returns the new translations by block index.
+// Blocks that failed are left out.
+type RetranslateFunc func(ctx context.Context, blockIndexes []int) map[int]string
+
+// EnforceTranslationConstraints checks translations (by block index) against
+// the checker and re-translates the violating blocks more concisely, for at
+// most MaxConstraintRetranslations rounds. A retranslation is only kept when it
+// is shorter than the translation it replaces. translations is updated in place.
+func EnforceTranslationConstraints(ctx context.Context, checker TranslationConstraintChecker, doc *Document, translations map[int]string, retranslate RetranslateFunc, logger *zap.Logger) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	retryCtx := WithTranslationNotes(ctx, ConciseRetranslationNote)
+
+	for round := 1; round <= MaxConstraintRetranslations; round++ {
+		var violating []int
+		for index := 0; index < len(doc.Blocks); index++ {
+			translated, ok := translations[index]
+			if !ok {
+				continue
+			}
+			violation := checker.CheckTranslation(doc, doc.Blocks[index], translated)
+			if violation == "" {
+				continue
+			}
+			logger.Debug("translation violates format constraints",
+				zap.Int("blockIndex", index),
+				zap.String("violation", violation))
+			violating = append(violating, index)
+		}
+
+		if len(violating) == 0 {
+			return
+		}
+
+		logger.Info("re-translating blocks that violate format constraints",
+			zap.Int("round", round),
+			zap.Int("blocks", len(violating)))
+
+		retried := retranslate(retryCtx, violating)
+		for _, index := range violating {
+			if text, ok := retried[index]; ok && text != "" &&
+				textDisplayWidth(text) < textDisplayWidth(translations[index]) {
+				translations[index] = text
+			}
+		}
+	}
+}
@@ -171,36 +171,27 @@ func (e *DocxTextExtractor) MergeAdjacentRuns(para *Paragraph) []MergedRun {
 		return nil
 	}
 
-	var merged []MergedRun
-	var currentMerge *MergedRun
-
-	for i, run := range para.Runs {
-		text := e.extractRunText(&run)
-		if text == "" && run.Drawing == nil {
-			continue // Skip empty runs without drawings
-		}
-
-		// Check if we can merge with current
-		if currentMerge != nil && e.canMergeRuns(&para.Runs[currentMerge.EndIndex], &run) {
-			currentMerge.Text += text
-			currentMerge.EndIndex = i
-		} else {
-			// Start new merge
-			if currentMerge != nil {
-				merged = append(merged, *currentMerge)
-			}
-			currentMerge = &MergedRun{
-				Text:       text,
-				Properties: run.Properties,
-				StartIndex: i,
-				EndIndex:   i,
-			}
+	spans := mergeRunSpans(len(para.Runs),
+		func(i int) bool {
+			// Skip empty runs without drawings
+			return e.extractRunText(&para.Runs[i]) == "" && para.Runs[i].Drawing == nil
+		},
+		func(last, next int) bool {
+			return e.canMergeRuns(&para.Runs[last], &para.Runs[next])
+		})
+
+	merged := make([]MergedRun, 0, len(spans))
+	for _, span := range spans {
+		var text strings.Builder
+		for i := span.start; i <= span.end; i++ {
+			text.WriteString(e.extractRunText(&para.Runs[i]))
 		}
-	}
-
-	// Add final merge
-	if currentMerge != nil {
-		merged = append(merged, *currentMerge)
+		merged = append(merged, MergedRun{
+			Text:       text.String(),
+			Properties: para.Runs[span.start].Properties,
+			StartIndex: span.start,
+			EndIndex:   span.end,
+		})
 	}
 
 	return merged
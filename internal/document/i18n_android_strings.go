@@ -0,0 +1,253 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// androidResource is the location of an Android string resource
+type androidResource struct {
+	start  int64  // offset after the start tag
+	end    int64  // offset of the end tag
+	quoted bool   // the value is wrapped in double quotes
+	indent string // indentation of <item> elements of plurals
+}
+
+// androidPlurals is the location of a <plurals> element
+type androidPlurals struct {
+	androidResource
+	items map[string]androidResource // items by quantity
+}
+
+// androidStringsCodec reads and writes Android string resources: <string>,
+// <string-array> items and <plurals>. Resources marked translatable="false"
+// are skipped, and xliff:g spans are kept as placeholders.
+type androidStringsCodec struct{}
+
+func (c *androidStringsCodec) parse(data []byte) ([]*I18nEntry, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var entries []*I18nEntry
+	var comment string
+	var plurals *I18nEntry
+	var arrayName string
+	arrayIndex := 0
+
+	for {
+		before := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.Comment:
+			comment = strings.TrimSpace(string(t))
+		case xml.StartElement:
+			name := qualifiedName(t.Name)
+			if attrValue(t, "translatable") == "false" && name != "item" {
+				if _, err := readAndroidResource(decoder, data); err != nil {
+					return nil, err
+				}
+				comment = ""
+				continue
+			}
+
+			switch {
+			case name == "string" || (name == "item" && arrayName != ""):
+				resource, err := readAndroidResource(decoder, data)
+				if err != nil {
+					return nil, err
+				}
+				key := attrValue(t, "name")
+				if name == "item" {
+					key = fmt.Sprintf("%s[%d]", arrayName, arrayIndex)
+					arrayIndex++
+				}
+				source := decodeAndroidString(string(data[resource.start:resource.end]), resource.quoted)
+				entries = append(entries, &I18nEntry{Key: key, Comment: comment, Source: source, anchor: resource})
+				comment = ""
+			case name == "item" && plurals != nil:
+				itemStart := before
+				resource, err := readAndroidResource(decoder, data)
+				if err != nil {
+					return nil, err
+				}
+				anchor := plurals.anchor.(*androidPlurals)
+				if anchor.indent == "" {
+					anchor.indent = jsonLineIndent(data, int(itemStart))
+				}
+				quantity := attrValue(t, "quantity")
+				anchor.items[quantity] = resource
+				plurals.PluralSource[quantity] = decodeAndroidString(string(data[resource.start:resource.end]), resource.quoted)
+			case name == "string-array":
+				arrayName = attrValue(t, "name")
+				arrayIndex = 0
+			case name == "plurals":
+				plurals = &I18nEntry{
+					Key:          attrValue(t, "name"),
+					Comment:      comment,
+					PluralSource: make(map[string]string),
+					anchor: &androidPlurals{
+						androidResource: androidResource{start: decoder.InputOffset()},
+						items:           make(map[string]androidResource),
+					},
+				}
+				comment = ""
+			}
+		case xml.EndElement:
+			switch qualifiedName(t.Name) {
+			case "string-array":
+				arrayName = ""
+			case "plurals":
+				if plurals != nil {
+					plurals.anchor.(*androidPlurals).end = before
+					if _, ok := plurals.PluralSource["other"]; ok {
+						entries = append(entries, plurals)
+					}
+				}
+				plurals = nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+// readAndroidResource reads the content of the element just started
+func readAndroidResource(decoder *xml.Decoder, data []byte) (androidResource, error) {
+	resource := androidResource{start: decoder.InputOffset()}
+	depth := 1
+	for depth > 0 {
+		before := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			return resource, err
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+			resource.end = before
+		}
+	}
+
+	value := strings.TrimSpace(string(data[resource.start:resource.end]))
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) && !strings.HasSuffix(value, `\"`) {
+		offset := int64(strings.Index(string(data[resource.start:resource.end]), `"`))
+		resource.start += offset + 1
+		resource.end = resource.start + int64(len(value)) - 2
+		resource.quoted = true
+	}
+	return resource, nil
+}
+
+// androidEscapePattern matches backslash escapes of Android strings
+var androidEscapePattern = regexp.MustCompile(`\\(u[0-9a-fA-F]{4}|.)`)
+
+// decodeAndroidString resolves the backslash escapes of a resource value.
+// Markup and XML entities are kept as they are.
+func decodeAndroidString(value string, quoted bool) string {
+	if !quoted {
+		// Unquoted values collapse whitespace
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	return androidEscapePattern.ReplaceAllStringFunc(value, func(escape string) string {
+		switch escape[1] {
+		case 'n':
+			return "\n"
+		case 't':
+			return "\t"
+		case 'u':
+			var r rune
+			fmt.Sscanf(escape[2:], "%04x", &r)
+			return string(r)
+		default:
+			return escape[1:]
+		}
+	})
+}
+
+// encodeAndroidString escapes a translated value. Quotes and apostrophes
+// outside markup are escaped, and bare ampersands become entities.
+func encodeAndroidString(value string, quoted bool) string {
+	var builder strings.Builder
+	inTag := false
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch == '<':
+			inTag = true
+			builder.WriteByte(ch)
+		case ch == '>':
+			inTag = false
+			builder.WriteByte(ch)
+		case inTag:
+			builder.WriteByte(ch)
+		case ch == '\\':
+			builder.WriteString(`\\`)
+		case ch == '\n':
+			builder.WriteString(`\n`)
+		case ch == '\t':
+			builder.WriteString(`\t`)
+		case ch == '"':
+			builder.WriteString(`\"`)
+		case ch == '\'' && !quoted:
+			builder.WriteString(`\'`)
+		case ch == '@' && i == 0, ch == '?' && i == 0:
+			builder.WriteByte('\\')
+			builder.WriteByte(ch)
+		case ch == '&' && !xmlEntityPattern.MatchString(value[i:]):
+			builder.WriteString("&amp;")
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return builder.String()
+}
+
+// xmlEntityPattern matches an XML entity at the start of a string
+var xmlEntityPattern = regexp.MustCompile(`^&(?:[A-Za-z]+|#\d+|#x[0-9a-fA-F]+);`)
+
+func (c *androidStringsCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	var replacements []byteRangeReplacement
+
+	for index, translation := range translations {
+		switch anchor := entries[index].anchor.(type) {
+		case androidResource:
+			replacements = append(replacements, byteRangeReplacement{
+				start: anchor.start,
+				end:   anchor.end,
+				text:  encodeAndroidString(translation.Text, anchor.quoted),
+			})
+		case *androidPlurals:
+			// Rewrite the items with the target quantities
+			closing := jsonLineIndent(data, int(anchor.end))
+			var items strings.Builder
+			for _, category := range sortPluralCategories(translation.Forms) {
+				quoted := anchor.items[category].quoted
+				value := encodeAndroidString(translation.Forms[category], quoted)
+				if quoted {
+					value = `"` + value + `"`
+				}
+				fmt.Fprintf(&items, "\n%s<item quantity=%q>%s</item>", anchor.indent, category, value)
+			}
+			items.WriteString("\n" + closing)
+			replacements = append(replacements, byteRangeReplacement{
+				start: anchor.start,
+				end:   anchor.end,
+				text:  items.String(),
+			})
+		}
+	}
+
+	return applyByteRangeReplacements(data, replacements), nil
+}
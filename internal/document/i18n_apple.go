@@ -0,0 +1,415 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// appleStringsValue is the location of a value in a .strings file
+type appleStringsValue struct {
+	start int // offset after the opening quote
+	end   int // offset of the closing quote
+}
+
+// appleStringsCodec reads and writes Apple .strings files of
+// /* comment */ "key" = "value"; pairs. UTF-16 files are converted to UTF-8
+// while parsing and written back as UTF-16.
+type appleStringsCodec struct{}
+
+func (c *appleStringsCodec) parse(data []byte) ([]*I18nEntry, error) {
+	text, _ := decodeAppleStrings(data)
+
+	var entries []*I18nEntry
+	var comment string
+	pos := 0
+	for {
+		pos = skipAppleStringsSpace(text, pos, &comment)
+		if pos >= len(text) {
+			break
+		}
+
+		key, _, keyEnd, err := readAppleStringsToken(text, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos = skipAppleStringsSpace(text, keyEnd, nil)
+		if pos >= len(text) || text[pos] != '=' {
+			// A key without a value uses the key as its value
+			if pos < len(text) && text[pos] == ';' {
+				pos++
+			}
+			comment = ""
+			continue
+		}
+		pos = skipAppleStringsSpace(text, pos+1, nil)
+
+		value, valueStart, valueEnd, err := readAppleStringsToken(text, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos = skipAppleStringsSpace(text, valueEnd, nil)
+		if pos < len(text) && text[pos] == ';' {
+			pos++
+		}
+
+		if text[valueStart] == '"' {
+			entries = append(entries, &I18nEntry{
+				Key:     key,
+				Comment: comment,
+				Source:  value,
+				anchor:  appleStringsValue{start: valueStart + 1, end: valueEnd - 1},
+			})
+		}
+		comment = ""
+	}
+	return entries, nil
+}
+
+// skipAppleStringsSpace skips whitespace and comments, collecting the last
+// comment when comment is not nil
+func skipAppleStringsSpace(text string, pos int, comment *string) int {
+	for pos < len(text) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(text[pos])):
+			pos++
+		case strings.HasPrefix(text[pos:], "/*"):
+			end := strings.Index(text[pos+2:], "*/")
+			if end < 0 {
+				return len(text)
+			}
+			if comment != nil {
+				*comment = strings.TrimSpace(text[pos+2 : pos+2+end])
+			}
+			pos += end + 4
+		case strings.HasPrefix(text[pos:], "//"):
+			end := strings.IndexByte(text[pos:], '\n')
+			if end < 0 {
+				return len(text)
+			}
+			if comment != nil {
+				*comment = strings.TrimSpace(text[pos+2 : pos+end])
+			}
+			pos += end + 1
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// readAppleStringsToken reads a quoted or bare token
+func readAppleStringsToken(text string, pos int) (value string, start, end int, err error) {
+	if text[pos] != '"' {
+		end = pos
+		for end < len(text) && !strings.ContainsRune(" \t\r\n=;", rune(text[end])) {
+			end++
+		}
+		return text[pos:end], pos, end, nil
+	}
+
+	var builder strings.Builder
+	for i := pos + 1; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			return builder.String(), pos, i + 1, nil
+		case '\\':
+			if i+1 >= len(text) {
+				break
+			}
+			i++
+			switch text[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'U', 'u':
+				if i+4 < len(text) {
+					if code, err := strconv.ParseUint(text[i+1:i+5], 16, 32); err == nil {
+						builder.WriteRune(rune(code))
+						i += 4
+						continue
+					}
+				}
+				builder.WriteByte(text[i])
+			default:
+				builder.WriteByte(text[i])
+			}
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return "", pos, len(text), fmt.Errorf("unterminated string at offset %d", pos)
+}
+
+// encodeAppleString escapes a value of a .strings file
+func encodeAppleString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return replacer.Replace(value)
+}
+
+// decodeAppleStrings converts UTF-16 content to UTF-8, reporting the byte order
+func decodeAppleStrings(data []byte) (string, string) {
+	var order string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = "le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = "be"
+	default:
+		return string(data), ""
+	}
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		if order == "le" {
+			units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+		} else {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		}
+	}
+	return string(utf16.Decode(units)), order
+}
+
+// encodeAppleStrings converts UTF-8 content back to the original encoding
+func encodeAppleStrings(text, order string) []byte {
+	if order == "" {
+		return []byte(text)
+	}
+
+	runes := make([]rune, 0, utf8.RuneCountInString(text))
+	for _, r := range text {
+		runes = append(runes, r)
+	}
+	data := []byte{0xFF, 0xFE}
+	if order == "be" {
+		data = []byte{0xFE, 0xFF}
+	}
+	for _, unit := range utf16.Encode(runes) {
+		if order == "le" {
+			data = append(data, byte(unit), byte(unit>>8))
+		} else {
+			data = append(data, byte(unit>>8), byte(unit))
+		}
+	}
+	return data
+}
+
+func (c *appleStringsCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	text, order := decodeAppleStrings(data)
+
+	var replacements []byteRangeReplacement
+	for index, translation := range translations {
+		if anchor, ok := entries[index].anchor.(appleStringsValue); ok {
+			replacements = append(replacements, byteRangeReplacement{
+				start: int64(anchor.start),
+				end:   int64(anchor.end),
+				text:  encodeAppleString(translation.Text),
+			})
+		}
+	}
+
+	return encodeAppleStrings(string(applyByteRangeReplacements([]byte(text), replacements)), order), nil
+}
+
+// xcstringsCodec reads and writes Xcode string catalogs. Translations are
+// added as the target localization; low-confidence ones are marked
+// needs_review.
+type xcstringsCodec struct {
+	targetLocale string
+}
+
+// xcstringsCatalog returns the strings of a catalog and its source language
+func xcstringsCatalog(data []byte) (map[string]interface{}, map[string]interface{}, string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var catalog map[string]interface{}
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, nil, "", err
+	}
+	strs, _ := catalog["strings"].(map[string]interface{})
+	sourceLanguage, _ := catalog["sourceLanguage"].(string)
+	return catalog, strs, sourceLanguage, nil
+}
+
+// targetLanguage returns the catalog language code of the target locale
+func (c *xcstringsCodec) targetLanguage() string {
+	return normalizeLocaleCode(c.targetLocale)
+}
+
+func (c *xcstringsCodec) parse(data []byte) ([]*I18nEntry, error) {
+	_, strs, sourceLanguage, err := xcstringsCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(strs))
+	for key := range strs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []*I18nEntry
+	for _, key := range keys {
+		item, _ := strs[key].(map[string]interface{})
+		if shouldTranslate, ok := item["shouldTranslate"].(bool); ok && !shouldTranslate {
+			continue
+		}
+		localizations, _ := item["localizations"].(map[string]interface{})
+		if target, ok := localizations[c.targetLanguage()].(map[string]interface{}); ok {
+			if state := xcstringsState(target); state == "translated" {
+				continue
+			}
+		}
+
+		entry := &I18nEntry{Key: key, Source: key, anchor: key}
+		entry.Comment, _ = item["comment"].(string)
+		if source, ok := localizations[sourceLanguage].(map[string]interface{}); ok {
+			if value, ok := xcstringsValue(source); ok {
+				entry.Source = value
+			}
+			if forms := xcstringsPluralForms(source); forms != nil {
+				entry.PluralSource = forms
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// xcstringsValue returns the value of a localization's string unit
+func xcstringsValue(localization map[string]interface{}) (string, bool) {
+	unit, _ := localization["stringUnit"].(map[string]interface{})
+	value, ok := unit["value"].(string)
+	return value, ok
+}
+
+// xcstringsState returns the state of a localization's string unit
+func xcstringsState(localization map[string]interface{}) string {
+	unit, _ := localization["stringUnit"].(map[string]interface{})
+	state, _ := unit["state"].(string)
+	return state
+}
+
+// xcstringsPluralForms returns the plural variations of a localization
+func xcstringsPluralForms(localization map[string]interface{}) map[string]string {
+	variations, _ := localization["variations"].(map[string]interface{})
+	plural, _ := variations["plural"].(map[string]interface{})
+	if len(plural) == 0 {
+		return nil
+	}
+	forms := make(map[string]string)
+	for category, variation := range plural {
+		if value, ok := xcstringsValue(variation.(map[string]interface{})); ok && isPluralCategory(category) {
+			forms[category] = value
+		}
+	}
+	if _, ok := forms["other"]; !ok {
+		return nil
+	}
+	return forms
+}
+
+// xcstringsUnit builds a string unit
+func xcstringsUnit(value string, lowConfidence bool) map[string]interface{} {
+	state := "translated"
+	if lowConfidence {
+		state = "needs_review"
+	}
+	return map[string]interface{}{
+		"stringUnit": map[string]interface{}{"state": state, "value": value},
+	}
+}
+
+func (c *xcstringsCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	if len(translations) == 0 {
+		return data, nil
+	}
+	catalog, strs, _, err := xcstringsCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, translation := range translations {
+		key, _ := entries[index].anchor.(string)
+		item, ok := strs[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		localizations, ok := item["localizations"].(map[string]interface{})
+		if !ok {
+			localizations = make(map[string]interface{})
+			item["localizations"] = localizations
+		}
+
+		if entries[index].IsPlural() {
+			plural := make(map[string]interface{})
+			for category, form := range translation.Forms {
+				plural[category] = xcstringsUnit(form, translation.LowConfidence)
+			}
+			localizations[c.targetLanguage()] = map[string]interface{}{
+				"variations": map[string]interface{}{"plural": plural},
+			}
+		} else {
+			localizations[c.targetLanguage()] = xcstringsUnit(translation.Text, translation.LowConfidence)
+		}
+	}
+
+	var buffer bytes.Buffer
+	writeXcstringsJSON(&buffer, catalog, "")
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+// writeXcstringsJSON writes JSON the way Xcode formats string catalogs:
+// sorted keys, two-space indentation and " : " separators
+func writeXcstringsJSON(buffer *bytes.Buffer, value interface{}, indent string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buffer.WriteString("{\n\n" + indent + "}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buffer.WriteString("{\n")
+		for i, key := range keys {
+			buffer.WriteString(indent + "  " + encodeJSONString(key) + " : ")
+			writeXcstringsJSON(buffer, v[key], indent+"  ")
+			if i < len(keys)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "}")
+	case []interface{}:
+		buffer.WriteString("[\n")
+		for i, item := range v {
+			buffer.WriteString(indent + "  ")
+			writeXcstringsJSON(buffer, item, indent+"  ")
+			if i < len(v)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "]")
+	case string:
+		buffer.WriteString(encodeJSONString(v))
+	case json.Number:
+		buffer.WriteString(v.String())
+	case bool:
+		buffer.WriteString(strconv.FormatBool(v))
+	case nil:
+		buffer.WriteString("null")
+	}
+}
@@ -0,0 +1,257 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonStringValue is a string value of a JSON document with its location
+type jsonStringValue struct {
+	path  []string // keys from the root, array indexes as numbers
+	start int      // offset of the opening quote
+	end   int      // offset after the closing quote
+	value string
+}
+
+// jsonScanner finds the string values of a JSON document
+type jsonScanner struct {
+	data    []byte
+	pos     int
+	strings []jsonStringValue
+}
+
+// scanJSON validates a JSON document and records its string values
+func scanJSON(data []byte) (*jsonScanner, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("invalid JSON")
+	}
+	scanner := &jsonScanner{data: data}
+	scanner.skipSpace()
+	if err := scanner.value(nil); err != nil {
+		return nil, err
+	}
+	return scanner, nil
+}
+
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.pos]) >= 0 {
+		s.pos++
+	}
+	// Skip a UTF-8 byte order mark
+	if bytes.HasPrefix(s.data[s.pos:], []byte("\xEF\xBB\xBF")) {
+		s.pos += 3
+		s.skipSpace()
+	}
+}
+
+// value scans the value at the current position
+func (s *jsonScanner) value(path []string) error {
+	if s.pos >= len(s.data) {
+		return fmt.Errorf("unexpected end of JSON")
+	}
+
+	switch s.data[s.pos] {
+	case '{':
+		s.pos++
+		for {
+			s.skipSpace()
+			if s.data[s.pos] == '}' {
+				break
+			}
+			if s.data[s.pos] == ',' {
+				s.pos++
+				s.skipSpace()
+			}
+			key, err := s.stringToken()
+			if err != nil {
+				return err
+			}
+			s.skipSpace()
+			s.pos++ // ':'
+			s.skipSpace()
+			if err := s.value(appendPath(path, key)); err != nil {
+				return err
+			}
+		}
+		s.pos++
+	case '[':
+		s.pos++
+		for index := 0; ; index++ {
+			s.skipSpace()
+			if s.data[s.pos] == ']' {
+				break
+			}
+			if s.data[s.pos] == ',' {
+				s.pos++
+				s.skipSpace()
+			}
+			if err := s.value(appendPath(path, fmt.Sprint(index))); err != nil {
+				return err
+			}
+		}
+		s.pos++
+	case '"':
+		start := s.pos
+		value, err := s.stringToken()
+		if err != nil {
+			return err
+		}
+		s.strings = append(s.strings, jsonStringValue{path: path, start: start, end: s.pos, value: value})
+	default:
+		// Numbers, booleans and null
+		for s.pos < len(s.data) && strings.IndexByte(",]} \t\r\n", s.data[s.pos]) < 0 {
+			s.pos++
+		}
+	}
+	return nil
+}
+
+// stringToken reads a quoted string at the current position
+func (s *jsonScanner) stringToken() (string, error) {
+	start := s.pos
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var value string
+			err := json.Unmarshal(s.data[start:s.pos], &value)
+			return value, err
+		}
+	}
+	return "", fmt.Errorf("unterminated JSON string")
+}
+
+// appendPath returns path extended by key without sharing the backing array
+func appendPath(path []string, key string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), key)
+}
+
+// encodeJSONString quotes a string the way hand-written JSON files do,
+// without escaping HTML characters
+func encodeJSONString(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// i18nJSONPlural is the location of an i18next plural group
+type i18nJSONPlural struct {
+	base   string                     // key without the plural suffix
+	values map[string]jsonStringValue // values by category
+}
+
+// i18nJSONCodec reads and writes i18next style JSON files: nested objects
+// of strings, with plural forms as key_one, key_other and so on
+type i18nJSONCodec struct{}
+
+func (c *i18nJSONCodec) parse(data []byte) ([]*I18nEntry, error) {
+	scanner, err := scanJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*I18nEntry
+	plurals := make(map[string]*I18nEntry)
+	for _, value := range scanner.strings {
+		key := strings.Join(value.path, ".")
+		if len(value.path) > 0 {
+			last := value.path[len(value.path)-1]
+			if base, category, ok := splitPluralKey(last); ok {
+				parent := value.path[:len(value.path)-1]
+				groupKey := strings.Join(appendPath(parent, base), ".")
+				entry, exists := plurals[groupKey]
+				if !exists {
+					entry = &I18nEntry{
+						Key:          groupKey,
+						PluralSource: make(map[string]string),
+						anchor:       &i18nJSONPlural{base: base, values: make(map[string]jsonStringValue)},
+					}
+					plurals[groupKey] = entry
+					entries = append(entries, entry)
+				}
+				entry.PluralSource[category] = value.value
+				entry.anchor.(*i18nJSONPlural).values[category] = value
+				continue
+			}
+		}
+
+		entries = append(entries, &I18nEntry{Key: key, Source: value.value, anchor: value})
+	}
+	return entries, nil
+}
+
+// splitPluralKey splits an i18next plural key such as "item_one"
+func splitPluralKey(key string) (base, category string, ok bool) {
+	index := strings.LastIndexByte(key, '_')
+	if index <= 0 || !isPluralCategory(key[index+1:]) {
+		return "", "", false
+	}
+	return key[:index], key[index+1:], true
+}
+
+func (c *i18nJSONCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	var replacements []byteRangeReplacement
+
+	for index, translation := range translations {
+		switch anchor := entries[index].anchor.(type) {
+		case jsonStringValue:
+			replacements = append(replacements, byteRangeReplacement{
+				start: int64(anchor.start),
+				end:   int64(anchor.end),
+				text:  encodeJSONString(translation.Text),
+			})
+		case *i18nJSONPlural:
+			// Forms of the source that the target language does not use keep
+			// the "other" translation; missing ones are added after the group.
+			var last jsonStringValue
+			for category, value := range anchor.values {
+				text, ok := translation.Forms[category]
+				if !ok {
+					text = translation.Forms["other"]
+				}
+				replacements = append(replacements, byteRangeReplacement{
+					start: int64(value.start),
+					end:   int64(value.end),
+					text:  encodeJSONString(text),
+				})
+				if value.end > last.end {
+					last = value
+				}
+			}
+
+			indent := jsonLineIndent(data, last.start)
+			var added strings.Builder
+			for _, category := range sortPluralCategories(translation.Forms) {
+				if _, exists := anchor.values[category]; exists {
+					continue
+				}
+				fmt.Fprintf(&added, ",\n%s%s: %s", indent, encodeJSONString(anchor.base+"_"+category), encodeJSONString(translation.Forms[category]))
+			}
+			if added.Len() > 0 {
+				replacements = append(replacements, byteRangeReplacement{
+					start: int64(last.end),
+					end:   int64(last.end),
+					text:  added.String(),
+				})
+			}
+		}
+	}
+
+	return applyByteRangeReplacements(data, replacements), nil
+}
+
+// jsonLineIndent returns the indentation of the line containing offset
+func jsonLineIndent(data []byte, offset int) string {
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := lineStart
+	for end < offset && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[lineStart:end])
+}
@@ -0,0 +1,278 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CLDR plural categories in canonical order
+var cldrPluralOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// languageCodes maps language names used in the configuration to BCP 47 codes
+var languageCodes = map[string]string{
+	"chinese":             "zh-CN",
+	"chinese_simplified":  "zh-CN",
+	"simplified chinese":  "zh-CN",
+	"chinese_traditional": "zh-TW",
+	"traditional chinese": "zh-TW",
+	"english":             "en",
+	"spanish":             "es",
+	"french":              "fr",
+	"german":              "de",
+	"japanese":            "ja",
+	"korean":              "ko",
+	"portuguese":          "pt",
+	"russian":             "ru",
+	"italian":             "it",
+	"dutch":               "nl",
+	"polish":              "pl",
+	"ukrainian":           "uk",
+	"czech":               "cs",
+	"arabic":              "ar",
+	"hebrew":              "he",
+	"turkish":             "tr",
+	"vietnamese":          "vi",
+	"thai":                "th",
+	"indonesian":          "id",
+	"hindi":               "hi",
+	"swedish":             "sv",
+}
+
+// NormalizeLocale converts a language name or locale such as "Chinese" or
+// "zh_CN" to a BCP 47 code
+func NormalizeLocale(lang string) string {
+	return normalizeLocaleCode(lang)
+}
+
+// normalizeLocaleCode converts a language name or locale such as "Chinese",
+// "zh_CN" or "pt-br" to a BCP 47 code
+func normalizeLocaleCode(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if code, ok := languageCodes[strings.ToLower(lang)]; ok {
+		return code
+	}
+
+	parts := strings.Split(strings.ReplaceAll(lang, "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// baseLanguage returns the language subtag of a locale code
+func baseLanguage(locale string) string {
+	base, _, _ := strings.Cut(normalizeLocaleCode(locale), "-")
+	return base
+}
+
+// gettextPluralRule is the Plural-Forms header of a language with the CLDR
+// category of each msgstr index
+type gettextPluralRule struct {
+	header     string
+	categories []string
+}
+
+var (
+	pluralOtherOnly = gettextPluralRule{"nplurals=1; plural=0;", []string{"other"}}
+	pluralOneOther  = gettextPluralRule{"nplurals=2; plural=(n != 1);", []string{"one", "other"}}
+	pluralFrench    = gettextPluralRule{"nplurals=2; plural=(n > 1);", []string{"one", "other"}}
+	pluralSlavic    = gettextPluralRule{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "many"}}
+	pluralSerbian   = gettextPluralRule{pluralSlavic.header, []string{"one", "few", "other"}}
+	pluralPolish    = gettextPluralRule{"nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "many"}}
+	pluralCzech     = gettextPluralRule{"nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;", []string{"one", "few", "other"}}
+	pluralRomanian  = gettextPluralRule{"nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);", []string{"one", "few", "other"}}
+	pluralArabic    = gettextPluralRule{"nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);", []string{"zero", "one", "two", "few", "many", "other"}}
+	pluralHebrew    = gettextPluralRule{"nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);", []string{"one", "two", "other"}}
+)
+
+// gettextPluralRules maps base languages to their gettext plural rule.
+// Languages not listed use the one/other rule.
+var gettextPluralRules = map[string]gettextPluralRule{
+	"zh": pluralOtherOnly, "ja": pluralOtherOnly, "ko": pluralOtherOnly, "vi": pluralOtherOnly,
+	"th": pluralOtherOnly, "id": pluralOtherOnly, "ms": pluralOtherOnly, "lo": pluralOtherOnly,
+	"my": pluralOtherOnly, "km": pluralOtherOnly,
+	"fr": pluralFrench, "pt-BR": pluralFrench, "hy": pluralFrench,
+	"ru": pluralSlavic, "uk": pluralSlavic, "be": pluralSlavic,
+	"sr": pluralSerbian, "hr": pluralSerbian, "bs": pluralSerbian,
+	"pl": pluralPolish,
+	"cs": pluralCzech, "sk": pluralCzech,
+	"ro": pluralRomanian,
+	"ar": pluralArabic,
+	"he": pluralHebrew,
+}
+
+// cldrPluralCategories maps base languages to their CLDR cardinal categories
+// where they differ from the gettext rule
+var cldrPluralCategories = map[string][]string{
+	"fr": {"one", "many", "other"},
+	"es": {"one", "many", "other"},
+	"it": {"one", "many", "other"},
+	"pt": {"one", "many", "other"},
+	"ru": {"one", "few", "many", "other"},
+	"uk": {"one", "few", "many", "other"},
+	"be": {"one", "few", "many", "other"},
+	"pl": {"one", "few", "many", "other"},
+	"cs": {"one", "few", "many", "other"},
+	"sk": {"one", "few", "many", "other"},
+	"lt": {"one", "few", "many", "other"},
+	"sr": {"one", "few", "other"},
+	"hr": {"one", "few", "other"},
+	"bs": {"one", "few", "other"},
+	"lv": {"zero", "one", "other"},
+	"sl": {"one", "two", "few", "other"},
+	"ga": {"one", "two", "few", "many", "other"},
+	"cy": {"zero", "one", "two", "few", "many", "other"},
+}
+
+// gettextPluralRuleFor returns the gettext plural rule of a locale
+func gettextPluralRuleFor(locale string) gettextPluralRule {
+	if rule, ok := gettextPluralRules[normalizeLocaleCode(locale)]; ok {
+		return rule
+	}
+	if rule, ok := gettextPluralRules[baseLanguage(locale)]; ok {
+		return rule
+	}
+	return pluralOneOther
+}
+
+// PluralCategories returns the CLDR cardinal plural categories of a locale
+func PluralCategories(locale string) []string {
+	if categories, ok := cldrPluralCategories[baseLanguage(locale)]; ok {
+		return categories
+	}
+	categories := gettextPluralRuleFor(locale).categories
+	if categories[len(categories)-1] != "other" {
+		categories = append(append([]string{}, categories...), "other")
+	}
+	return categories
+}
+
+// isPluralCategory reports whether name is a CLDR plural category
+func isPluralCategory(name string) bool {
+	for _, category := range cldrPluralOrder {
+		if name == category {
+			return true
+		}
+	}
+	return false
+}
+
+// sortPluralCategories returns the categories of forms in CLDR order
+func sortPluralCategories(forms map[string]string) []string {
+	var categories []string
+	for _, category := range cldrPluralOrder {
+		if _, ok := forms[category]; ok {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// icuPluralHeaderPattern matches the head of an ICU plural message
+var icuPluralHeaderPattern = regexp.MustCompile(`^\s*\{\s*[A-Za-z_][\w.]*\s*,\s*plural\s*,(?:\s*offset:\d+)?`)
+
+// BuildICUPlural renders plural forms as an ICU plural message, the shape
+// translation models know best for plurals
+func BuildICUPlural(forms map[string]string) string {
+	var builder strings.Builder
+	builder.WriteString("{count, plural,")
+	for _, category := range sortPluralCategories(forms) {
+		// Pad the form so single-word forms do not look like ICU arguments
+		fmt.Fprintf(&builder, " %s { %s }", category, forms[category])
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// ParseICUPlural extracts the plural forms of an ICU plural message
+func ParseICUPlural(message string) (map[string]string, error) {
+	loc := icuPluralHeaderPattern.FindStringIndex(message)
+	if loc == nil {
+		return nil, fmt.Errorf("not an ICU plural message")
+	}
+
+	forms := make(map[string]string)
+	rest := message[loc[1]:]
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			return nil, fmt.Errorf("unterminated ICU plural message")
+		}
+		if rest[0] == '}' {
+			break
+		}
+
+		open := strings.IndexByte(rest, '{')
+		if open <= 0 {
+			return nil, fmt.Errorf("missing plural selector")
+		}
+		selector := strings.TrimSpace(rest[:open])
+		if strings.HasPrefix(selector, "=") {
+			switch selector {
+			case "=0":
+				selector = "zero"
+			case "=1":
+				selector = "one"
+			case "=2":
+				selector = "two"
+			}
+		}
+		if !isPluralCategory(selector) {
+			return nil, fmt.Errorf("unknown plural selector %q", selector)
+		}
+
+		depth, end := 0, -1
+		for i := open; i < len(rest); i++ {
+			switch rest[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth == 0 {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unbalanced braces in plural form %q", selector)
+		}
+		forms[selector] = strings.TrimSpace(rest[open+1 : end])
+		rest = rest[end+1:]
+	}
+
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("ICU plural message without forms")
+	}
+	return forms, nil
+}
+
+// fillPluralForms returns forms for every category, borrowing "other" (or the
+// last available form) for missing ones. complete is false when any form was borrowed.
+func fillPluralForms(forms map[string]string, categories []string) (filled map[string]string, complete bool) {
+	fallback, ok := forms["other"]
+	if !ok {
+		for _, category := range sortPluralCategories(forms) {
+			fallback = forms[category]
+		}
+	}
+
+	filled = make(map[string]string, len(categories))
+	complete = true
+	for _, category := range categories {
+		if form, ok := forms[category]; ok {
+			filled[category] = form
+			continue
+		}
+		filled[category] = fallback
+		complete = false
+	}
+	return filled, complete
+}
@@ -0,0 +1,360 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// poEntry is the location of a gettext message in the original file
+type poEntry struct {
+	start        int      // offset of the first line of the entry
+	msgstrStart  int      // offset of the first msgstr line
+	end          int      // offset after the last line of the entry
+	flagsStart   int      // offset of the "#," line, -1 when missing
+	flagsEnd     int      // offset after the "#," line
+	flags        []string // flags of the entry
+	keywordStart int      // offset of the first msgctxt, msgid or "#|" line
+	newline      string   // line ending used by the file
+}
+
+// poCodec reads and writes gettext PO and POT files. Messages without a
+// translation and fuzzy messages are translated; translations that came
+// from a low-confidence path are marked fuzzy.
+type poCodec struct {
+	targetLocale string
+}
+
+// poRawEntry holds the fields of a PO entry while parsing
+type poRawEntry struct {
+	poEntry
+	comments     []string
+	msgctxt      *string
+	msgid        string
+	msgidPlural  *string
+	msgstr       map[int]string
+	obsolete     bool
+	hasContent   bool
+	currentField string
+}
+
+func (c *poCodec) parse(data []byte) ([]*I18nEntry, error) {
+	raws, err := parsePOEntries(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*I18nEntry
+	for _, raw := range raws {
+		if raw.obsolete || raw.msgid == "" {
+			continue
+		}
+
+		fuzzy := false
+		for _, flag := range raw.flags {
+			fuzzy = fuzzy || flag == "fuzzy"
+		}
+		translated := false
+		for _, value := range raw.msgstr {
+			translated = translated || value != ""
+		}
+		if translated && !fuzzy {
+			continue
+		}
+
+		entry := &I18nEntry{
+			Key:     raw.msgid,
+			Comment: strings.Join(raw.comments, "\n"),
+			Source:  raw.msgid,
+			anchor:  raw.poEntry,
+		}
+		if raw.msgctxt != nil {
+			entry.Context = *raw.msgctxt
+		}
+		if raw.msgidPlural != nil {
+			entry.PluralSource = map[string]string{"one": raw.msgid, "other": *raw.msgidPlural}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parsePOEntries splits a PO file into entries
+func parsePOEntries(data []byte) ([]*poRawEntry, error) {
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	var entries []*poRawEntry
+	var current *poRawEntry
+	finish := func(end int) {
+		if current != nil && current.hasContent {
+			current.end = end
+			entries = append(entries, current)
+		}
+		current = nil
+	}
+
+	offset := 0
+	for lineNumber := 1; offset < len(data); lineNumber++ {
+		lineEnd := bytes.IndexByte(data[offset:], '\n')
+		next := len(data)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(string(data[offset:next]), "\r\n")
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			finish(offset)
+			offset = next
+			continue
+		}
+
+		if current == nil {
+			current = &poRawEntry{
+				poEntry: poEntry{start: offset, flagsStart: -1, keywordStart: -1, newline: newline},
+				msgstr:  make(map[int]string),
+			}
+		} else if current.msgstrStart > 0 && !strings.HasPrefix(trimmed, "msgstr") && !strings.HasPrefix(trimmed, `"`) {
+			// A new entry starts without a separating blank line
+			finish(offset)
+			current = &poRawEntry{
+				poEntry: poEntry{start: offset, flagsStart: -1, keywordStart: -1, newline: newline},
+				msgstr:  make(map[int]string),
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "#~"):
+			current.obsolete = true
+			current.hasContent = true
+		case strings.HasPrefix(trimmed, "#,"):
+			current.flagsStart, current.flagsEnd = offset, next
+			for _, flag := range strings.Split(trimmed[2:], ",") {
+				if flag = strings.TrimSpace(flag); flag != "" {
+					current.flags = append(current.flags, flag)
+				}
+			}
+		case strings.HasPrefix(trimmed, "#|"):
+			if current.keywordStart < 0 {
+				current.keywordStart = offset
+			}
+		case strings.HasPrefix(trimmed, "#.") || trimmed == "#" || strings.HasPrefix(trimmed, "# "):
+			// Extracted and translator comments are context for the translator
+			if comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(trimmed, "#."), "#")); comment != "" {
+				current.comments = append(current.comments, comment)
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			// References and other comments
+		case strings.HasPrefix(trimmed, `"`):
+			value, err := unquotePOString(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			current.appendValue(value)
+		default:
+			keyword, rest, _ := strings.Cut(trimmed, " ")
+			value, err := unquotePOString(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if current.keywordStart < 0 {
+				current.keywordStart = offset
+			}
+			if strings.HasPrefix(keyword, "msgstr") && current.msgstrStart == 0 {
+				current.msgstrStart = offset
+			}
+			current.currentField = keyword
+			current.hasContent = true
+			current.appendValue(value)
+		}
+		offset = next
+	}
+	finish(len(data))
+
+	return entries, nil
+}
+
+// appendValue appends a string to the field being read
+func (e *poRawEntry) appendValue(value string) {
+	switch field := e.currentField; {
+	case field == "msgctxt":
+		if e.msgctxt == nil {
+			e.msgctxt = new(string)
+		}
+		*e.msgctxt += value
+	case field == "msgid":
+		e.msgid += value
+	case field == "msgid_plural":
+		if e.msgidPlural == nil {
+			e.msgidPlural = new(string)
+		}
+		*e.msgidPlural += value
+	case field == "msgstr":
+		e.msgstr[0] += value
+	case strings.HasPrefix(field, "msgstr["):
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(field, "msgstr["), "]"))
+		if err == nil {
+			e.msgstr[index] += value
+		}
+	}
+}
+
+// unquotePOString decodes a quoted PO string
+func unquotePOString(quoted string) (string, error) {
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return "", fmt.Errorf("invalid PO string %s", quoted)
+	}
+
+	var builder strings.Builder
+	for i := 1; i < len(quoted)-1; i++ {
+		if quoted[i] != '\\' || i+1 >= len(quoted)-1 {
+			builder.WriteByte(quoted[i])
+			continue
+		}
+		i++
+		switch quoted[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		default:
+			builder.WriteByte(quoted[i])
+		}
+	}
+	return builder.String(), nil
+}
+
+// quotePOString encodes a PO string
+func quotePOString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// formatPOField writes a PO keyword with its value, splitting multi-line
+// values after each newline the way gettext tools do
+func formatPOField(keyword, value, newline string) string {
+	lines := strings.SplitAfter(value, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		return keyword + " " + quotePOString(value) + newline
+	}
+
+	var builder strings.Builder
+	builder.WriteString(keyword + ` ""` + newline)
+	for _, line := range lines {
+		builder.WriteString(quotePOString(line) + newline)
+	}
+	return builder.String()
+}
+
+func (c *poCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	var replacements []byteRangeReplacement
+	rule := gettextPluralRuleFor(c.targetLocale)
+
+	for index, translation := range translations {
+		entry := entries[index]
+		anchor, ok := entry.anchor.(poEntry)
+		if !ok {
+			continue
+		}
+
+		var msgstr strings.Builder
+		if entry.IsPlural() {
+			forms, _ := fillPluralForms(translation.Forms, rule.categories)
+			for i, category := range rule.categories {
+				msgstr.WriteString(formatPOField(fmt.Sprintf("msgstr[%d]", i), forms[category], anchor.newline))
+			}
+		} else {
+			msgstr.WriteString(formatPOField("msgstr", translation.Text, anchor.newline))
+		}
+		replacements = append(replacements, byteRangeReplacement{
+			start: int64(anchor.msgstrStart),
+			end:   int64(anchor.end),
+			text:  msgstr.String(),
+		})
+
+		// Mark low-confidence translations fuzzy and clear the flag otherwise
+		var flags []string
+		for _, flag := range anchor.flags {
+			if flag != "fuzzy" {
+				flags = append(flags, flag)
+			}
+		}
+		if translation.LowConfidence {
+			flags = append([]string{"fuzzy"}, flags...)
+		}
+		flagsLine := ""
+		if len(flags) > 0 {
+			flagsLine = "#, " + strings.Join(flags, ", ") + anchor.newline
+		}
+		if anchor.flagsStart >= 0 {
+			replacements = append(replacements, byteRangeReplacement{start: int64(anchor.flagsStart), end: int64(anchor.flagsEnd), text: flagsLine})
+		} else if flagsLine != "" {
+			replacements = append(replacements, byteRangeReplacement{start: int64(anchor.keywordStart), end: int64(anchor.keywordStart), text: flagsLine})
+		}
+	}
+
+	if header := c.renderHeader(data, rule); header != nil {
+		replacements = append(replacements, *header)
+	}
+
+	return applyByteRangeReplacements(data, replacements), nil
+}
+
+var (
+	poLanguageHeaderPattern    = regexp.MustCompile(`"Language: ([^"\\]*)(?:\\n)?"`)
+	poPluralFormsHeaderPattern = regexp.MustCompile(`"Plural-Forms: ([^"\\]*)(?:\\n)?"`)
+	poNPluralsPattern          = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+)
+
+// renderHeader sets the Language and Plural-Forms headers for the target locale.
+// An existing Plural-Forms header is kept when the file is already in the
+// target locale and the header has as many forms as the rendered entries.
+func (c *poCodec) renderHeader(data []byte, rule gettextPluralRule) *byteRangeReplacement {
+	raws, err := parsePOEntries(data)
+	if err != nil || len(raws) == 0 || raws[0].msgid != "" || raws[0].msgctxt != nil || raws[0].msgstrStart == 0 {
+		return nil
+	}
+	header := raws[0]
+	section := string(data[header.msgstrStart:header.end])
+	if !strings.HasSuffix(section, "\n") {
+		section += header.newline
+	}
+
+	sameLocale := false
+	language := fmt.Sprintf(`"Language: %s\n"`, strings.ReplaceAll(normalizeLocaleCode(c.targetLocale), "-", "_"))
+	if match := poLanguageHeaderPattern.FindStringSubmatch(section); match != nil {
+		sameLocale = strings.TrimSpace(match[1]) != "" && normalizeLocaleCode(match[1]) == normalizeLocaleCode(c.targetLocale)
+		section = poLanguageHeaderPattern.ReplaceAllLiteralString(section, language)
+	} else {
+		section += language + header.newline
+	}
+
+	pluralForms := fmt.Sprintf(`"Plural-Forms: %s\n"`, rule.header)
+	if match := poPluralFormsHeaderPattern.FindStringSubmatch(section); match != nil {
+		if !sameLocale || poNPlurals(match[1]) != poNPlurals(rule.header) {
+			section = poPluralFormsHeaderPattern.ReplaceAllLiteralString(section, pluralForms)
+		}
+	} else {
+		section += pluralForms + header.newline
+	}
+
+	return &byteRangeReplacement{start: int64(header.msgstrStart), end: int64(header.end), text: section}
+}
+
+// poNPlurals returns the nplurals value of a Plural-Forms header, or "" when it has none
+func poNPlurals(pluralForms string) string {
+	if match := poNPluralsPattern.FindStringSubmatch(pluralForms); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -0,0 +1,312 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+// I18nEntry is one translatable message of a localisation string file
+type I18nEntry struct {
+	Key          string            // message key (the msgid for gettext)
+	Context      string            // disambiguating context such as msgctxt
+	Comment      string            // comments left for translators
+	Source       string            // source text of a singular message
+	PluralSource map[string]string // source forms by CLDR category, nil for singular messages
+	anchor       interface{}       // codec specific location of the message
+}
+
+// IsPlural reports whether the entry has plural forms
+func (e *I18nEntry) IsPlural() bool {
+	return e.PluralSource != nil
+}
+
+// i18nTranslation is the rendered translation of an entry
+type i18nTranslation struct {
+	Text          string            // translation of a singular message
+	Forms         map[string]string // translated forms of a plural message
+	LowConfidence bool              // the translation needs review
+}
+
+// i18nCodec reads and writes the messages of one file format
+type i18nCodec interface {
+	// parse returns the messages that need translation
+	parse(data []byte) ([]*I18nEntry, error)
+
+	// render writes translations into the original file
+	render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error)
+}
+
+// I18nProcessor processes software localisation string files: gettext PO,
+// i18next JSON, Rails YAML, Android string resources and Apple strings
+// files and catalogs. Only values are translated, never keys.
+type I18nProcessor struct {
+	opts         ProcessorOptions
+	logger       *zap.Logger
+	format       Format
+	sourceLocale string
+	targetLocale string
+	codec        i18nCodec
+	protector    pkgdoc.ContentProtector
+}
+
+// NewI18nProcessor creates a new localisation processor for the given format
+func NewI18nProcessor(opts ProcessorOptions, logger *zap.Logger, format Format) (*I18nProcessor, error) {
+	sourceLocale, targetLocale := getI18nLocalesFromOptions(opts)
+
+	var codec i18nCodec
+	switch format {
+	case FormatPO:
+		codec = &poCodec{targetLocale: targetLocale}
+	case FormatI18nJSON:
+		codec = &i18nJSONCodec{}
+	case FormatI18nYAML:
+		codec = &i18nYAMLCodec{sourceLocale: sourceLocale, targetLocale: targetLocale}
+	case FormatAndroidStrings:
+		codec = &androidStringsCodec{}
+	case FormatAppleStrings:
+		codec = &appleStringsCodec{}
+	case FormatXCStrings:
+		codec = &xcstringsCodec{targetLocale: targetLocale}
+	default:
+		return nil, fmt.Errorf("unsupported localisation format: %s", format)
+	}
+
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	return &I18nProcessor{
+		opts:         opts,
+		logger:       logger,
+		format:       format,
+		sourceLocale: sourceLocale,
+		targetLocale: targetLocale,
+		codec:        codec,
+		protector:    pkgdoc.GetProtectorForFormat("i18n"),
+	}, nil
+}
+
+// Parse parses a localisation file into a Document with one block per
+// message. Plural messages become a single ICU plural block.
+func (p *I18nProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	entries, err := p.codec.parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p.format, err)
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("%s-%d", p.format, time.Now().Unix()),
+		Format: p.format,
+		Metadata: DocumentMetadata{
+			Language:     p.sourceLocale,
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	for i, entry := range entries {
+		source := entry.Source
+		if entry.IsPlural() {
+			source = BuildICUPlural(entry.PluralSource)
+		}
+		if !isTranslatableCellText(source) {
+			continue
+		}
+
+		doc.Blocks = append(doc.Blocks, &BaseBlock{
+			Type:         BlockTypeParagraph,
+			Content:      source,
+			Translatable: true,
+			Metadata: BlockMetadata{
+				Attributes: map[string]interface{}{
+					"i18nEntry":                 i,
+					"i18nSource":                source,
+					TranslationContextAttribute: p.entryContext(entry),
+				},
+			},
+		})
+	}
+
+	// Store the original file for later rendering
+	doc.Metadata.CustomFields["i18nData"] = data
+	doc.Metadata.CustomFields["i18nEntries"] = entries
+
+	p.logger.Debug("parsed localisation file",
+		zap.String("format", string(p.format)),
+		zap.Int("entries", len(entries)),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// entryContext describes an entry for the translation prompt
+func (p *I18nProcessor) entryContext(entry *I18nEntry) string {
+	var parts []string
+	if entry.Key != "" && p.format != FormatPO {
+		parts = append(parts, fmt.Sprintf("key %q", entry.Key))
+	}
+	if entry.Context != "" {
+		parts = append(parts, fmt.Sprintf("context %q", entry.Context))
+	}
+	if entry.Comment != "" {
+		parts = append(parts, "translator note: "+strings.Join(strings.Fields(entry.Comment), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Process processes the document through translation
+func (p *I18nProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	ctx = WithTranslationNotes(ctx, p.TranslationNotes())
+
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		blockCtx := ctx
+		if note, _ := block.GetMetadata().Attributes[TranslationContextAttribute].(string); note != "" {
+			blockCtx = WithTranslationNotes(ctx, "Context of this message: "+note)
+		}
+
+		translatedText, err := translator(blockCtx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate message",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+		block.SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// Render writes the translated messages back into the original file.
+// Untranslated messages are left as they are.
+func (p *I18nProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	data, ok := doc.Metadata.CustomFields["i18nData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original %s data not found in document metadata", p.format)
+	}
+	entries, ok := doc.Metadata.CustomFields["i18nEntries"].([]*I18nEntry)
+	if !ok {
+		return fmt.Errorf("%s entries not found in document metadata", p.format)
+	}
+
+	translations := make(map[int]*i18nTranslation)
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		index, ok := attrs["i18nEntry"].(int)
+		if !ok || index < 0 || index >= len(entries) {
+			continue
+		}
+		source, _ := attrs["i18nSource"].(string)
+		if block.GetContent() == source {
+			continue
+		}
+
+		translation := p.buildTranslation(entries[index], source, block.GetContent())
+		if lowConfidence, _ := attrs[LowConfidenceAttribute].(bool); lowConfidence {
+			translation.LowConfidence = true
+		}
+		translations[index] = translation
+	}
+
+	rendered, err := p.codec.render(data, entries, translations)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", p.format, err)
+	}
+	_, err = output.Write(rendered)
+	return err
+}
+
+// buildTranslation checks a translated message and splits plural messages
+// into the target locale's forms. Lost placeholders and missing plural forms
+// mark the translation as low confidence.
+func (p *I18nProcessor) buildTranslation(entry *I18nEntry, source, translated string) *i18nTranslation {
+	translation := &i18nTranslation{Text: translated}
+	if !sameI18nPlaceholders(source, translated) {
+		p.logger.Debug("translation changed placeholders",
+			zap.String("key", entry.Key),
+			zap.String("translation", translated))
+		translation.LowConfidence = true
+	}
+
+	if !entry.IsPlural() {
+		return translation
+	}
+
+	forms, err := ParseICUPlural(translated)
+	if err != nil {
+		p.logger.Debug("translation is not an ICU plural message",
+			zap.String("key", entry.Key),
+			zap.Error(err))
+		forms = map[string]string{"other": strings.TrimSpace(translated)}
+	}
+	filled, complete := fillPluralForms(forms, PluralCategories(p.targetLocale))
+	translation.Forms = filled
+	translation.LowConfidence = translation.LowConfidence || err != nil || !complete
+	return translation
+}
+
+// GetFormat returns the format type
+func (p *I18nProcessor) GetFormat() Format {
+	return p.format
+}
+
+// ProtectContent protects ICU, printf and template placeholders
+func (p *I18nProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	return p.protector.ProtectContent(text, pp)
+}
+
+// TranslationNotes explains the message syntax and the target plural rules
+func (p *I18nProcessor) TranslationNotes() string {
+	return "The text is user interface strings of a software product; keep translations short and consistent. " +
+		"Keep placeholders such as {name}, {{count}}, %s, %1$d and %{count} unchanged. " +
+		fmt.Sprintf("Plural messages are written in ICU syntax ({count, plural, one { ... } other { ... }}); "+
+			"answer with an ICU plural message that has exactly the forms %s for the target language.",
+			strings.Join(PluralCategories(p.targetLocale), ", "))
+}
+
+// i18nPlaceholderPattern matches placeholders that must survive translation
+var i18nPlaceholderPattern = regexp.MustCompile(`\{\{-?\s*[^{}]+?\s*\}\}|%\{[A-Za-z_]\w*\}|%<[A-Za-z_]\w*>[a-zA-Z]|%\([A-Za-z_]\w*\)[a-zA-Z]|%(?:\d+\$)?[-+ 0#]*\d*(?:\.\d+)?(?:ll|l|h)?[sdifuxXoeEgGc@]|\{[A-Za-z_0-9][\w.]*(?:\s*,\s*[a-z]+)?\}`)
+
+// sameI18nPlaceholders reports whether every placeholder of the source is
+// kept in the translation. Plural forms may repeat or merge placeholders,
+// so only their presence is compared.
+func sameI18nPlaceholders(source, translated string) bool {
+	kept := make(map[string]bool)
+	for _, placeholder := range i18nPlaceholderPattern.FindAllString(translated, -1) {
+		kept[placeholder] = true
+	}
+	for _, placeholder := range i18nPlaceholderPattern.FindAllString(source, -1) {
+		if !kept[placeholder] {
+			return false
+		}
+	}
+	return true
+}
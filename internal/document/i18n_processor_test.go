@@ -0,0 +1,368 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testPO = `msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: \n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#. Button label
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+#, fuzzy, c-format
+msgid "Delete %s?"
+msgstr "Old guess %s?"
+
+#: src/files.c:10
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "Done"
+msgstr "Fertig"
+`
+
+const testI18nJSON = `{
+  "title": "Welcome, {{name}}!",
+  "nav": {
+    "home": "Home",
+    "count": 3
+  },
+  "item_one": "{{count}} item",
+  "item_other": "{{count}} items"
+}
+`
+
+const testI18nYAML = `en:
+  # Shown on the dashboard
+  greeting: "Hello %{name}"
+  inbox:
+    one: "%{count} message"
+    other: "%{count} messages"
+`
+
+const testAndroidStrings = `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:xliff="urn:oasis:names:tc:xliff:document:1.2">
+    <string name="app_name" translatable="false">Demo</string>
+    <!-- Greeting on the home screen -->
+    <string name="welcome">Welcome, <xliff:g id="user">%1$s</xliff:g>!</string>
+    <string-array name="days">
+        <item>Monday</item>
+    </string-array>
+    <plurals name="songs">
+        <item quantity="one">%d song</item>
+        <item quantity="other">%d songs</item>
+    </plurals>
+</resources>
+`
+
+const testAppleStrings = `/* Title of the main window */
+"main.title" = "Documents";
+"quote" = "Say \"hi\"";
+`
+
+const testXCStrings = `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "Cancel" : {
+      "comment" : "Dismiss button"
+    },
+    "Done" : {
+      "localizations" : {
+        "zh-CN" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "完成"
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+
+// parseTestI18n parses a localisation file for the given target language
+func parseTestI18n(t *testing.T, format Format, input, target string) (*I18nProcessor, *Document) {
+	t.Helper()
+
+	processor, err := NewI18nProcessor(ProcessorOptions{Metadata: map[string]interface{}{
+		"source_language": "English",
+		"target_language": target,
+	}}, zap.NewNop(), format)
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", format, err)
+	}
+	return processor, doc
+}
+
+// renderTestI18n renders a document with the translations keyed by source text
+func renderTestI18n(t *testing.T, processor *I18nProcessor, doc *Document, translations map[string]string) string {
+	t.Helper()
+
+	for _, block := range doc.Blocks {
+		if translated, ok := translations[block.GetContent()]; ok {
+			block.SetContent(translated)
+		}
+	}
+	var output bytes.Buffer
+	if err := processor.Render(context.Background(), doc, &output); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	return output.String()
+}
+
+// blockContents returns the content of every block
+func blockContents(doc *Document) []string {
+	var contents []string
+	for _, block := range doc.Blocks {
+		contents = append(contents, block.GetContent())
+	}
+	return contents
+}
+
+func TestI18nProcessor(t *testing.T) {
+	t.Run("POTranslatesEmptyAndFuzzyEntries", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatPO, testPO, "Russian")
+
+		expected := []string{"Open", "Delete %s?", "{count, plural, one { %d file } other { %d files }}"}
+		if got := blockContents(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Fatalf("Expected blocks %q, got %q", expected, got)
+		}
+		if context := doc.Blocks[0].GetMetadata().Attributes[TranslationContextAttribute]; context != `context "menu"; translator note: Button label` {
+			t.Errorf("Expected msgctxt and comment as context, got %q", context)
+		}
+
+		doc.Blocks[1].GetMetadata().Attributes[LowConfidenceAttribute] = true
+		output := renderTestI18n(t, processor, doc, map[string]string{
+			"Open":                     "Открыть",
+			"Delete %s?":               "Удалить %s?",
+			doc.Blocks[2].GetContent(): "{count, plural, one {%d файл} few {%d файла} many {%d файлов} other {%d файла}}",
+		})
+
+		for _, want := range []string{
+			`"Language: ru\n"`,
+			`"Plural-Forms: nplurals=3;`,
+			"msgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"Открыть\"\n",
+			"#, fuzzy, c-format\nmsgid \"Delete %s?\"\nmsgstr \"Удалить %s?\"\n",
+			"msgstr[0] \"%d файл\"\nmsgstr[1] \"%d файла\"\nmsgstr[2] \"%d файлов\"\n",
+			"msgid \"Done\"\nmsgstr \"Fertig\"\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("POClearsFuzzyWhenConfident", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatPO, testPO, "German")
+		output := renderTestI18n(t, processor, doc, map[string]string{"Delete %s?": "%s löschen?"})
+		if !strings.Contains(output, "#, c-format\nmsgid \"Delete %s?\"\nmsgstr \"%s löschen?\"\n") {
+			t.Errorf("Expected fuzzy flag removed, got:\n%s", output)
+		}
+	})
+
+	t.Run("POKeepsPluralFormsOfTargetLocale", func(t *testing.T) {
+		custom := `"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);\n"`
+		input := strings.Replace(testPO, `"Language: \n"`, `"Language: ru_RU\n"`+"\n"+custom, 1)
+
+		processor, doc := parseTestI18n(t, FormatPO, input, "ru-RU")
+		if output := renderTestI18n(t, processor, doc, nil); !strings.Contains(output, custom) {
+			t.Errorf("Expected Plural-Forms of the target locale kept, got:\n%s", output)
+		}
+
+		processor, doc = parseTestI18n(t, FormatPO, input, "Polish")
+		output := renderTestI18n(t, processor, doc, nil)
+		if strings.Contains(output, custom) || !strings.Contains(output, `"Language: pl\n"`) {
+			t.Errorf("Expected Plural-Forms replaced for another locale, got:\n%s", output)
+		}
+	})
+
+	t.Run("I18nextJSONKeepsKeysAndAddsPluralForms", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatI18nJSON, testI18nJSON, "Polish")
+		if len(doc.Blocks) != 3 {
+			t.Fatalf("Expected 3 blocks, got %q", blockContents(doc))
+		}
+
+		output := renderTestI18n(t, processor, doc, map[string]string{
+			"Welcome, {{name}}!": "Witaj, {{name}}!",
+			"Home":               "Strona główna",
+			doc.Blocks[2].GetContent(): "{count, plural, one { {{count}} element } few { {{count}} elementy } " +
+				"many { {{count}} elementów } other { {{count}} elementu }}",
+		})
+
+		expected := `{
+  "title": "Witaj, {{name}}!",
+  "nav": {
+    "home": "Strona główna",
+    "count": 3
+  },
+  "item_one": "{{count}} element",
+  "item_other": "{{count}} elementu",
+  "item_few": "{{count}} elementy",
+  "item_many": "{{count}} elementów"
+}
+`
+		if output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("RailsYAMLRenamesLocale", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatI18nYAML, testI18nYAML, "Japanese")
+		if context := doc.Blocks[0].GetMetadata().Attributes[TranslationContextAttribute]; context != `key "en.greeting"; translator note: Shown on the dashboard` {
+			t.Errorf("Expected key and comment as context, got %q", context)
+		}
+
+		output := renderTestI18n(t, processor, doc, map[string]string{
+			"Hello %{name}":            "こんにちは、%{name}",
+			doc.Blocks[1].GetContent(): "{count, plural, other { %{count} 件のメッセージ }}",
+		})
+		for _, want := range []string{"ja:\n", `greeting: "こんにちは、%{name}"`, "inbox:\n    other: \"%{count} 件のメッセージ\""} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "one:") {
+			t.Errorf("Expected forms unused by the target to be dropped, got:\n%s", output)
+		}
+	})
+
+	t.Run("AndroidSkipsUntranslatableResources", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatAndroidStrings, testAndroidStrings, "French")
+		expected := []string{
+			`Welcome, <xliff:g id="user">%1$s</xliff:g>!`,
+			"Monday",
+			"{count, plural, one { %d song } other { %d songs }}",
+		}
+		if got := blockContents(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Fatalf("Expected blocks %q, got %q", expected, got)
+		}
+
+		output := renderTestI18n(t, processor, doc, map[string]string{
+			expected[0]: `Bienvenue, <xliff:g id="user">%1$s</xliff:g> ! C'est "génial"`,
+			expected[1]: "Lundi",
+			expected[2]: "{count, plural, one {%d chanson} many {%d de chansons} other {%d chansons}}",
+		})
+		for _, want := range []string{
+			`<string name="app_name" translatable="false">Demo</string>`,
+			`<string name="welcome">Bienvenue, <xliff:g id="user">%1$s</xliff:g> ! C\'est \"génial\"</string>`,
+			"<item>Lundi</item>",
+			"<plurals name=\"songs\">\n        <item quantity=\"one\">%d chanson</item>\n" +
+				"        <item quantity=\"many\">%d de chansons</item>\n        <item quantity=\"other\">%d chansons</item>\n    </plurals>",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("AppleStringsEscapesValues", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatAppleStrings, testAppleStrings, "German")
+		if got := blockContents(doc); strings.Join(got, "|") != `Documents|Say "hi"` {
+			t.Fatalf("Expected unescaped values, got %q", got)
+		}
+
+		output := renderTestI18n(t, processor, doc, map[string]string{"Documents": "Dokumente", `Say "hi"`: `Sag „hallo" `})
+		expected := "/* Title of the main window */\n\"main.title\" = \"Dokumente\";\n\"quote\" = \"Sag „hallo\\\" \";\n"
+		if output != expected {
+			t.Errorf("Expected %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("AppleStringsUTF16", func(t *testing.T) {
+		input := encodeAppleStrings(`"a" = "Apple";`, "le")
+		processor, doc := parseTestI18n(t, FormatAppleStrings, string(input), "German")
+		output := renderTestI18n(t, processor, doc, map[string]string{"Apple": "Apfel"})
+		if text, order := decodeAppleStrings([]byte(output)); text != `"a" = "Apfel";` || order != "le" {
+			t.Errorf("Expected UTF-16LE output, got %q (%s)", text, order)
+		}
+	})
+
+	t.Run("XCStringsAddsTargetLocalization", func(t *testing.T) {
+		processor, doc := parseTestI18n(t, FormatXCStrings, testXCStrings, "Chinese")
+		if got := blockContents(doc); strings.Join(got, "|") != "Cancel" {
+			t.Fatalf("Expected only untranslated keys, got %q", got)
+		}
+
+		doc.Blocks[0].GetMetadata().Attributes[LowConfidenceAttribute] = true
+		output := renderTestI18n(t, processor, doc, map[string]string{"Cancel": "取消"})
+		want := `    "Cancel" : {
+      "comment" : "Dismiss button",
+      "localizations" : {
+        "zh-CN" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "取消"
+          }
+        }
+      }
+    },`
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain:\n%s\ngot:\n%s", want, output)
+		}
+	})
+
+	t.Run("MissingPlaceholderIsLowConfidence", func(t *testing.T) {
+		processor, _ := parseTestI18n(t, FormatAppleStrings, testAppleStrings, "German")
+		entry := &I18nEntry{Key: "k", Source: "Hi %@"}
+		if !processor.buildTranslation(entry, "Hi %@", "Hallo").LowConfidence {
+			t.Error("Expected lost placeholder to be low confidence")
+		}
+		if processor.buildTranslation(entry, "Hi %@", "Hallo %@").LowConfidence {
+			t.Error("Expected kept placeholder to be confident")
+		}
+	})
+}
+
+func TestI18nPlurals(t *testing.T) {
+	t.Run("Categories", func(t *testing.T) {
+		for locale, expected := range map[string]string{
+			"Chinese": "other",
+			"en":      "one|other",
+			"fr":      "one|many|other",
+			"ru_RU":   "one|few|many|other",
+			"ar":      "zero|one|two|few|many|other",
+		} {
+			if got := strings.Join(PluralCategories(locale), "|"); got != expected {
+				t.Errorf("Expected %s categories %s, got %s", locale, expected, got)
+			}
+		}
+	})
+
+	t.Run("ParseICUPlural", func(t *testing.T) {
+		forms, err := ParseICUPlural("{n, plural, =0 {none} one {# {thing}} other {# things}}")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		if forms["zero"] != "none" || forms["one"] != "# {thing}" || forms["other"] != "# things" {
+			t.Errorf("Unexpected forms %q", forms)
+		}
+		if _, err := ParseICUPlural("plain text"); err == nil {
+			t.Error("Expected an error for a non-plural message")
+		}
+	})
+
+	t.Run("FillPluralForms", func(t *testing.T) {
+		filled, complete := fillPluralForms(map[string]string{"one": "a", "other": "b"}, []string{"one", "few", "other"})
+		if complete || filled["few"] != "b" {
+			t.Errorf("Expected missing form borrowed from other, got %q (%v)", filled, complete)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// i18nYAMLCodec reads and writes Rails style YAML locale files. A single top
+// level key naming the source locale is renamed to the target locale, and
+// mappings of plural categories are translated as plural messages.
+type i18nYAMLCodec struct {
+	sourceLocale string
+	targetLocale string
+}
+
+// yamlPath locates a node by mapping keys and sequence indexes
+type yamlPath []string
+
+func (c *i18nYAMLCodec) parse(data []byte) ([]*I18nEntry, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var entries []*I18nEntry
+	var walk func(node *yaml.Node, path yamlPath, comment string)
+	walk = func(node *yaml.Node, path yamlPath, comment string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path, comment)
+			}
+		case yaml.MappingNode:
+			if forms, ok := yamlPluralForms(node); ok {
+				entries = append(entries, &I18nEntry{
+					Key:          strings.Join(path, "."),
+					Comment:      comment,
+					PluralSource: forms,
+					anchor:       path,
+				})
+				return
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				walk(node.Content[i+1], append(append(yamlPath{}, path...), key.Value), yamlComment(key))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, append(append(yamlPath{}, path...), strconv.Itoa(i)), yamlComment(child))
+			}
+		case yaml.ScalarNode:
+			if node.Tag == "!!str" {
+				entries = append(entries, &I18nEntry{
+					Key:     strings.Join(path, "."),
+					Comment: comment,
+					Source:  node.Value,
+					anchor:  path,
+				})
+			}
+		}
+	}
+	walk(&root, nil, "")
+
+	return entries, nil
+}
+
+// yamlComment returns the comment written above a node
+func yamlComment(node *yaml.Node) string {
+	var lines []string
+	for _, line := range strings.Split(node.HeadComment, "\n") {
+		if line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlPluralForms returns the forms of a mapping of plural categories to strings
+func yamlPluralForms(node *yaml.Node) (map[string]string, bool) {
+	forms := make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !isPluralCategory(key.Value) || value.Kind != yaml.ScalarNode || value.Tag != "!!str" {
+			return nil, false
+		}
+		forms[key.Value] = value.Value
+	}
+	_, hasOther := forms["other"]
+	return forms, hasOther
+}
+
+// findYAMLNode returns the node at path
+func findYAMLNode(root *yaml.Node, path yamlPath) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, part := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == part {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(part); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+func (c *i18nYAMLCodec) render(data []byte, entries []*I18nEntry, translations map[int]*i18nTranslation) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return data, nil
+	}
+
+	for index, translation := range translations {
+		path, ok := entries[index].anchor.(yamlPath)
+		if !ok {
+			continue
+		}
+		node := findYAMLNode(&root, path)
+		if node == nil {
+			continue
+		}
+
+		if !entries[index].IsPlural() {
+			node.Value = translation.Text
+			continue
+		}
+
+		// Rebuild the plural mapping with the target categories, keeping an
+		// explicit zero form as Rails uses it for the count of 0
+		forms := make(map[string]string, len(translation.Forms)+1)
+		for category, form := range translation.Forms {
+			forms[category] = form
+		}
+		if _, ok := entries[index].PluralSource["zero"]; ok {
+			if _, translated := forms["zero"]; !translated {
+				forms["zero"] = translation.Forms["other"]
+			}
+		}
+		style := node.Content[len(node.Content)-1].Style
+		var content []*yaml.Node
+		for _, category := range sortPluralCategories(forms) {
+			content = append(content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: category},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: forms[category], Style: style})
+		}
+		node.Content = content
+	}
+
+	c.renameLocaleRoot(&root)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// renameLocaleRoot renames a single top level key naming the source locale
+func (c *i18nYAMLCodec) renameLocaleRoot(root *yaml.Node) {
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode || len(mapping.Content) != 2 || c.targetLocale == "" {
+		return
+	}
+	key := mapping.Content[0]
+	if key.Kind != yaml.ScalarNode || len(key.Value) > 10 || c.sourceLocale == "" {
+		return
+	}
+	if baseLanguage(key.Value) == baseLanguage(c.sourceLocale) {
+		key.Value = normalizeLocaleCode(c.targetLocale)
+	}
+}
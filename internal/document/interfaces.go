@@ -5,6 +5,7 @@ package document
 import (
 	"context"
 	"io"
+	"strings"
 )
 
 // Processor 定义文档处理器的核心接口
@@ -56,6 +57,72 @@ type Chunker interface {
 // TranslateFunc 翻译函数类型
 type TranslateFunc func(ctx context.Context, text string) (string, error)
 
+// TranslationNotesProvider 可选接口：处理器为整个文档提供附加的翻译提示词说明
+// （如字幕约束、本地化字符串的复数规则）
+type TranslationNotesProvider interface {
+	// TranslationNotes 返回加入翻译提示词的说明
+	TranslationNotes() string
+}
+
+// TranslationConstraintChecker 可选接口：处理器在提示词中声明译文约束（如字幕的行长和阅读速度），
+// 并在翻译后校验译文，违反约束的块会被要求更简洁地重译
+type TranslationConstraintChecker interface {
+	TranslationNotesProvider
+
+	// CheckTranslation 校验块的译文，返回违反约束的描述，空字符串表示通过
+	CheckTranslation(doc *Document, block Block, translated string) string
+}
+
+// UntranslatedMarker 可选接口：翻译被中断时，处理器为保留原文的块加上明显的未翻译标记。
+// 标记会破坏结构的格式（如本地化字符串、字幕）不实现该接口，未翻译的块直接保留原文
+type UntranslatedMarker interface {
+	// MarkUntranslated 返回带有未翻译标记的块内容
+	MarkUntranslated(block Block, content string) string
+}
+
+// ConciseRetranslationNote 要求更简洁重译时附加的提示词说明
+const ConciseRetranslationNote = "A previous translation of this text broke the constraints above. " +
+	"Translate it again more concisely, dropping filler words but keeping the meaning."
+
+// translationNotesKey 翻译链从上下文读取附加提示词说明时使用的键
+const translationNotesKey = "_additional_notes"
+
+// WithTranslationNotes 将附加说明放入上下文，翻译链会把它加入提示词的附加说明中
+func WithTranslationNotes(ctx context.Context, notes string) context.Context {
+	if notes == "" {
+		return ctx
+	}
+	if existing, ok := ctx.Value(translationNotesKey).(string); ok && existing != "" {
+		notes = existing + "\n" + notes
+	}
+	return context.WithValue(ctx, translationNotesKey, notes)
+}
+
+// documentBriefKey 翻译链从上下文读取文档简介时使用的键
+const documentBriefKey = "_document_brief"
+
+// WithDocumentBrief 将文档简介放入上下文，翻译链会把它加入每个请求的提示词
+func WithDocumentBrief(ctx context.Context, brief string) context.Context {
+	brief = strings.TrimSpace(brief)
+	if brief == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, documentBriefKey, brief)
+}
+
+// 块属性和节点元数据中的约定键
+const (
+	// TranslationContextAttribute 块属性：该块的翻译上下文（如 msgctxt、译者注释），
+	// 提取节点时复制到节点元数据的 NodeTranslationContextKey
+	TranslationContextAttribute = "translationContext"
+
+	// LowConfidenceAttribute 块属性和节点元数据：译文被明确标记为低置信度，渲染时可据此标记（如 PO 的 fuzzy）
+	LowConfidenceAttribute = "lowConfidence"
+
+	// NodeTranslationContextKey 节点元数据：该节点的翻译上下文
+	NodeTranslationContextKey = "translation_context"
+)
+
 // ProcessorFactory 处理器工厂函数
 type ProcessorFactory func(opts ProcessorOptions) (Processor, error)
 
@@ -0,0 +1,767 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	// markdownInlineTagPattern matches a single inline tag of translated text
+	markdownInlineTagPattern = regexp.MustCompile(`<r(\d+)>|</r(\d+)>|<x(\d+)\s*/>`)
+
+	// markdownAdmonitionPattern matches the opening line of an admonition:
+	// MkDocs "!!! note", collapsible "??? note", container "::: note" and
+	// GitHub or Obsidian alerts "[!NOTE]"
+	markdownAdmonitionPattern = regexp.MustCompile(`^(?:(?:!!!|\?\?\?\+?)\s*([\w-]+)|:::+\s*([\w-]+)|\[!(\w+)\][+-]?)`)
+
+	// markdownMkDocsAdmonitionPattern matches an MkDocs admonition whose body
+	// is indented below it
+	markdownMkDocsAdmonitionPattern = regexp.MustCompile(`^(?:!!!|\?\?\?\+?)\s*([\w-]+)`)
+
+	// markdownContainerClosePattern matches the closing line of a ::: container
+	markdownContainerClosePattern = regexp.MustCompile(`^:::+\s*$`)
+)
+
+// markdownInlineKind distinguishes the inline nodes of a translatable block
+type markdownInlineKind int
+
+const (
+	markdownInlineText markdownInlineKind = iota
+	// markdownInlineGroup wraps translatable text in markup, such as emphasis or link text
+	markdownInlineGroup
+	// markdownInlineOpaque is kept verbatim, such as inline code, math or HTML
+	markdownInlineOpaque
+)
+
+// markdownInline is an inline node of a translatable block. Groups keep the
+// markup around their children, opaque nodes their full source.
+type markdownInline struct {
+	kind markdownInlineKind
+	id   int
+	text string
+	// start is the source position of an opaque node
+	start    int
+	open     string
+	close    string
+	children []*markdownInline
+}
+
+// markdownSpan locates a translatable block in the Markdown source
+type markdownSpan struct {
+	start int
+	end   int
+	// text is the extracted text with inline tags
+	text string
+	// prefix continues the block's container markup after a line break
+	prefix string
+	// singleLine blocks (headings, table cells) cannot contain line breaks
+	singleLine bool
+	tableCell  bool
+	inlines    []*markdownInline
+}
+
+// markdownBlock is a block found by the extractor
+type markdownBlock struct {
+	blockType    BlockType
+	markdownType string
+	level        int
+	language     string
+	admonition   string
+	content      string
+	position     int
+	span         *markdownSpan
+}
+
+// markdownScope carries container information down the block tree
+type markdownScope struct {
+	blockType    BlockType
+	markdownType string
+	admonition   string
+}
+
+// markdownOffset maps a run of parsed bytes back to the original source
+type markdownOffset struct {
+	parsed   int
+	original int
+	length   int
+}
+
+// markdownSource is the byte slice an AST was parsed from. Admonition bodies
+// are parsed from their dedented lines, so offsets map positions back. text
+// holds the bytes at the same positions as data that extracted text is read
+// from; it differs from data where MDX syntax was masked before parsing.
+type markdownSource struct {
+	data     []byte
+	text     []byte
+	original []byte
+	offsets  []markdownOffset
+}
+
+// position maps a position in data to the original source. Ends map to the
+// end of the previous line at line boundaries, starts to the next line.
+func (s *markdownSource) position(p int, isEnd bool) int {
+	if s.offsets == nil {
+		return p
+	}
+	for i, offset := range s.offsets {
+		last := i == len(s.offsets)-1
+		if p < offset.parsed+offset.length || (isEnd && p == offset.parsed+offset.length) || last {
+			return offset.original + p - offset.parsed
+		}
+	}
+	return p
+}
+
+// MarkdownExtractor extracts translatable blocks from Markdown using the
+// goldmark AST, keeping inline markup as <rN>…</rN> and <xN/> tags
+type MarkdownExtractor struct {
+	parser parser.Parser
+}
+
+// NewMarkdownExtractor creates an extractor supporting GFM, footnotes and math
+func NewMarkdownExtractor() *MarkdownExtractor {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			mathjax.MathJax,
+		),
+	)
+	return &MarkdownExtractor{parser: md.Parser()}
+}
+
+// NewMDXExtractor creates an extractor for MDX, which has no indented code
+// blocks so that content nested in JSX elements may be indented
+func NewMDXExtractor() *MarkdownExtractor {
+	codeBlockParser := reflect.TypeOf(parser.NewCodeBlockParser())
+	paragraphParser := reflect.TypeOf(parser.NewParagraphParser())
+	var blockParsers []util.PrioritizedValue
+	for _, blockParser := range parser.DefaultBlockParsers() {
+		switch reflect.TypeOf(blockParser.Value) {
+		case codeBlockParser:
+			continue
+		case paragraphParser:
+			blockParser.Value = mdxParagraphParser{blockParser.Value.(parser.BlockParser)}
+		}
+		blockParsers = append(blockParsers, blockParser)
+	}
+
+	md := goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(blockParsers...),
+			parser.WithInlineParsers(parser.DefaultInlineParsers()...),
+			parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+		)),
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			mathjax.MathJax,
+		),
+	)
+	return &MarkdownExtractor{parser: md.Parser()}
+}
+
+// mdxParagraphParser opens paragraphs on indented lines, which would start
+// a code block in Markdown
+type mdxParagraphParser struct {
+	parser.BlockParser
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser
+func (mdxParagraphParser) CanAcceptIndentedLine() bool {
+	return true
+}
+
+// Extract returns the blocks of the Markdown body starting at bodyStart, after
+// any front matter, in source order
+func (e *MarkdownExtractor) Extract(data []byte, bodyStart int) []*markdownBlock {
+	return e.ExtractMasked(data, data, bodyStart)
+}
+
+// ExtractMasked parses masked, a copy of data of the same length in which
+// syntax foreign to Markdown has been replaced, and reads block text from data
+func (e *MarkdownExtractor) ExtractMasked(data, masked []byte, bodyStart int) []*markdownBlock {
+	source := &markdownSource{data: masked[bodyStart:], text: data[bodyStart:], original: data}
+	if bodyStart > 0 {
+		source.offsets = []markdownOffset{{parsed: 0, original: bodyStart, length: len(source.data)}}
+	}
+	root := e.parser.Parse(text.NewReader(source.data))
+
+	var blocks []*markdownBlock
+	e.walk(root, source, markdownScope{blockType: BlockTypeParagraph, markdownType: "paragraph"}, &blocks)
+
+	// Footnote definitions are collected at the end of the AST
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].position < blocks[j].position
+	})
+	return blocks
+}
+
+// walk extracts the blocks below a container node
+func (e *MarkdownExtractor) walk(node ast.Node, source *markdownSource, scope markdownScope, blocks *[]*markdownBlock) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Heading:
+			e.addTextBlock(n, source, markdownScope{blockType: BlockTypeHeading, markdownType: "heading", admonition: scope.admonition}, n.Level, blocks)
+		case *ast.Paragraph, *ast.TextBlock:
+			// An MkDocs admonition indents its body, which parses as a code block
+			if code, ok := n.NextSibling().(*ast.CodeBlock); ok && n.Lines().Len() == 1 {
+				line := n.Lines().At(0)
+				if match := markdownMkDocsAdmonitionPattern.FindSubmatch(bytes.TrimSpace(line.Value(source.data))); match != nil {
+					e.walkAdmonitionBody(code, source, strings.ToLower(string(match[1])), blocks)
+					child = code
+					continue
+				}
+			}
+			e.addTextBlock(n, source, scope, 0, blocks)
+		case *ast.List:
+			e.walk(n, source, markdownScope{blockType: BlockTypeList, markdownType: "list", admonition: scope.admonition}, blocks)
+		case *ast.Blockquote:
+			quoteScope := markdownScope{blockType: BlockTypeQuote, markdownType: "quote", admonition: scope.admonition}
+			if first := n.FirstChild(); first != nil && first.Lines().Len() > 0 {
+				line := first.Lines().At(0)
+				if admonition := markdownAdmonition(line.Value(source.data)); admonition != "" {
+					quoteScope.markdownType = "admonition"
+					quoteScope.admonition = admonition
+				}
+			}
+			e.walk(n, source, quoteScope, blocks)
+		case *extast.Table:
+			for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+				for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+					e.addTextBlock(cell, source, markdownScope{blockType: BlockTypeTable, markdownType: "table", admonition: scope.admonition}, 0, blocks)
+				}
+			}
+		case *extast.FootnoteList, *extast.Footnote:
+			e.walk(n, source, markdownScope{blockType: BlockTypeParagraph, markdownType: "footnote", admonition: scope.admonition}, blocks)
+		case *ast.FencedCodeBlock:
+			block := e.verbatimBlock(n, source, BlockTypeCode, "code", blocks)
+			block.language = string(n.Language(source.data))
+		case *ast.CodeBlock:
+			e.verbatimBlock(n, source, BlockTypeCode, "code", blocks)
+		case *ast.HTMLBlock:
+			e.verbatimBlock(n, source, BlockTypeHTML, "html", blocks)
+		case *mathjax.MathBlock:
+			e.verbatimBlock(n, source, BlockTypeMath, "math", blocks)
+		default:
+			if child.Type() == ast.TypeBlock && child.HasChildren() {
+				e.walk(child, source, scope, blocks)
+			}
+		}
+	}
+}
+
+// walkAdmonitionBody parses the indented body of an MkDocs admonition
+func (e *MarkdownExtractor) walkAdmonitionBody(code *ast.CodeBlock, source *markdownSource, admonition string, blocks *[]*markdownBlock) {
+	body := &markdownSource{original: source.original}
+	lines := code.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		body.offsets = append(body.offsets, markdownOffset{
+			parsed:   len(body.data),
+			original: source.position(line.Start, false),
+			length:   line.Len(),
+		})
+		body.data = append(body.data, line.Value(source.data)...)
+		body.text = append(body.text, line.Value(source.text)...)
+	}
+
+	root := e.parser.Parse(text.NewReader(body.data))
+	e.walk(root, body, markdownScope{blockType: BlockTypeParagraph, markdownType: "admonition", admonition: admonition}, blocks)
+}
+
+// verbatimBlock records a block that is not translated
+func (e *MarkdownExtractor) verbatimBlock(node ast.Node, source *markdownSource, blockType BlockType, markdownType string, blocks *[]*markdownBlock) *markdownBlock {
+	block := &markdownBlock{blockType: blockType, markdownType: markdownType}
+	lines := node.Lines()
+	var content bytes.Buffer
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		content.Write(line.Value(source.text))
+	}
+	block.content = strings.TrimRight(content.String(), "\n")
+
+	if lines.Len() > 0 {
+		block.position = source.position(lines.At(0).Start, false)
+	} else if len(*blocks) > 0 {
+		block.position = (*blocks)[len(*blocks)-1].position
+	}
+	*blocks = append(*blocks, block)
+	return block
+}
+
+// addTextBlock records a block of inline content. Admonition marker lines at
+// the start and container closing lines at the end are left untranslated.
+func (e *MarkdownExtractor) addTextBlock(node ast.Node, source *markdownSource, scope markdownScope, level int, blocks *[]*markdownBlock) {
+	lines := node.Lines()
+	skipStart, skipEnd := -1, len(source.data)+1
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		admonition := markdownAdmonition(line.Value(source.data))
+		if admonition == "" {
+			break
+		}
+		skipStart = line.Stop
+		if scope.admonition == "" {
+			scope.admonition = admonition
+			scope.markdownType = "admonition"
+		}
+	}
+	for i := lines.Len() - 1; i >= 0; i-- {
+		line := lines.At(i)
+		if !markdownContainerClosePattern.Match(bytes.TrimSpace(line.Value(source.data))) {
+			break
+		}
+		skipEnd = line.Start
+	}
+
+	cursor := 0
+	if lines.Len() > 0 {
+		cursor = lines.At(0).Start
+	}
+	spans := &markdownSpans{src: source.data, ranges: make(map[ast.Node][2]int)}
+	spans.measureChildren(node, cursor)
+
+	var children []ast.Node
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		r, ok := spans.ranges[child]
+		if !ok || r[1] <= skipStart || r[0] >= skipEnd || (r[0] == r[1] && child.Kind() == ast.KindText) {
+			continue
+		}
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		return
+	}
+
+	writer := &markdownInlineWriter{source: source, spans: spans}
+	inlines := writer.inlines(children)
+	if strings.TrimSpace(markdownPlainText(inlines)) == "" {
+		return
+	}
+
+	var builder strings.Builder
+	writeMarkdownInlineText(&builder, inlines)
+
+	start := source.position(spans.ranges[children[0]][0], false)
+	end := source.position(spans.ranges[children[len(children)-1]][1], true)
+	span := &markdownSpan{
+		start:      start,
+		end:        end,
+		text:       builder.String(),
+		prefix:     markdownLinePrefix(source.original, start),
+		singleLine: scope.blockType == BlockTypeHeading || scope.blockType == BlockTypeTable,
+		tableCell:  scope.blockType == BlockTypeTable,
+		inlines:    inlines,
+	}
+
+	*blocks = append(*blocks, &markdownBlock{
+		blockType:    scope.blockType,
+		markdownType: scope.markdownType,
+		level:        level,
+		admonition:   scope.admonition,
+		content:      span.text,
+		position:     start,
+		span:         span,
+	})
+}
+
+// markdownAdmonition returns the lower case type of an admonition marker line
+func markdownAdmonition(line []byte) string {
+	match := markdownAdmonitionPattern.FindSubmatch(bytes.TrimSpace(line))
+	if match == nil {
+		return ""
+	}
+	for _, group := range match[1:] {
+		if len(group) > 0 {
+			return strings.ToLower(string(group))
+		}
+	}
+	return ""
+}
+
+// markdownLinePrefix returns the container markup written after line breaks
+// inside a block starting at start: quote markers are kept and list markers
+// become indentation
+func markdownLinePrefix(source []byte, start int) string {
+	lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+	prefix := []byte(string(source[lineStart:start]))
+	for i, c := range prefix {
+		if c != '>' && c != ' ' && c != '\t' {
+			prefix[i] = ' '
+		}
+	}
+	return string(prefix)
+}
+
+// markdownSpans computes the source ranges of inline nodes. goldmark only
+// records segments for text, so markup is measured around the children.
+type markdownSpans struct {
+	src    []byte
+	ranges map[ast.Node][2]int
+}
+
+// measureChildren measures the children of node, searching from cursor
+func (s *markdownSpans) measureChildren(node ast.Node, cursor int) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if start, end, ok := s.measure(child, cursor); ok {
+			s.ranges[child] = [2]int{start, end}
+			cursor = end
+		}
+	}
+}
+
+// measure returns the source range of an inline node
+func (s *markdownSpans) measure(node ast.Node, cursor int) (int, int, bool) {
+	switch n := node.(type) {
+	case *ast.Text:
+		return n.Segment.Start, n.Segment.Stop, true
+	case *ast.RawHTML:
+		if n.Segments.Len() == 0 {
+			return 0, 0, false
+		}
+		return n.Segments.At(0).Start, n.Segments.At(n.Segments.Len() - 1).Stop, true
+	case *ast.AutoLink:
+		label := n.Label(s.src)
+		index := bytes.Index(s.src[cursor:], label)
+		if index < 0 {
+			return 0, 0, false
+		}
+		start, end := cursor+index, cursor+index+len(label)
+		if start > 0 && s.src[start-1] == '<' && end < len(s.src) && s.src[end] == '>' {
+			start, end = start-1, end+1
+		}
+		return start, end, true
+	case *extast.FootnoteLink:
+		return s.search(cursor, "[^", "]")
+	case *extast.TaskCheckBox:
+		return s.search(cursor, "[", "]")
+	}
+
+	s.measureChildren(node, cursor)
+	first, firstOK := s.ranges[node.FirstChild()]
+	last, lastOK := s.ranges[node.LastChild()]
+
+	switch n := node.(type) {
+	case *ast.Emphasis:
+		if firstOK && lastOK {
+			return first[0] - n.Level, last[1] + n.Level, true
+		}
+	case *extast.Strikethrough:
+		if firstOK && lastOK {
+			width := s.countBefore(first[0], '~', 2)
+			return first[0] - width, last[1] + width, true
+		}
+	case *ast.Link, *ast.Image:
+		opener := "["
+		if node.Kind() == ast.KindImage {
+			opener = "!["
+		}
+		if firstOK && lastOK {
+			return first[0] - len(opener), s.linkEnd(last[1]), true
+		}
+		// Empty link text
+		start, closing, ok := s.search(cursor, opener, "]")
+		if !ok {
+			return 0, 0, false
+		}
+		return start, s.linkEnd(closing - 1), true
+	case *ast.CodeSpan:
+		if firstOK && lastOK {
+			start := first[0]
+			for start > 0 && s.src[start-1] == ' ' {
+				start--
+			}
+			width := s.countBefore(start, '`', len(s.src))
+			end := last[1]
+			for end < len(s.src) && s.src[end] == ' ' {
+				end++
+			}
+			return start - width, end + width, true
+		}
+	case *mathjax.InlineMath:
+		if firstOK && lastOK {
+			if first[0] >= 2 && string(s.src[first[0]-2:first[0]]) == `\(` {
+				return first[0] - 2, last[1] + 2, true
+			}
+			width := s.countBefore(first[0], '$', 2)
+			return first[0] - width, last[1] + width, true
+		}
+	default:
+		if firstOK && lastOK {
+			return first[0], last[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+// search finds opener after cursor and returns the range up to closer
+func (s *markdownSpans) search(cursor int, opener, closer string) (int, int, bool) {
+	start := bytes.Index(s.src[cursor:], []byte(opener))
+	if start < 0 {
+		return 0, 0, false
+	}
+	start += cursor
+	end := bytes.Index(s.src[start+len(opener):], []byte(closer))
+	if end < 0 {
+		return 0, 0, false
+	}
+	return start, start + len(opener) + end + len(closer), true
+}
+
+// countBefore counts up to limit repetitions of c ending at position
+func (s *markdownSpans) countBefore(position int, c byte, limit int) int {
+	count := 0
+	for position-count > 0 && count < limit && s.src[position-count-1] == c {
+		count++
+	}
+	return count
+}
+
+// linkEnd returns the end of a link whose text ends at position: the
+// closing bracket followed by an inline destination or a reference label
+func (s *markdownSpans) linkEnd(position int) int {
+	if position >= len(s.src) || s.src[position] != ']' {
+		return position
+	}
+	position++
+	if position >= len(s.src) {
+		return position
+	}
+
+	switch s.src[position] {
+	case '(':
+		depth := 0
+		for i := position; i < len(s.src); i++ {
+			switch s.src[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+	case '[':
+		if end := bytes.IndexByte(s.src[position:], ']'); end >= 0 {
+			return position + end + 1
+		}
+	}
+	return position
+}
+
+// markdownInlineWriter converts measured inline nodes to markdownInline
+type markdownInlineWriter struct {
+	source  *markdownSource
+	spans   *markdownSpans
+	counter int
+}
+
+// inlines converts sibling nodes, turning the bytes between them into line
+// breaks, text or opaque nodes so nothing of the source is lost
+func (w *markdownInlineWriter) inlines(nodes []ast.Node) []*markdownInline {
+	var result []*markdownInline
+	previous := -1
+	for _, node := range nodes {
+		r := w.spans.ranges[node]
+		if previous >= 0 && r[0] > previous {
+			result = append(result, w.gap(previous, r[0])...)
+		}
+		result = append(result, w.inline(node, r))
+		previous = r[1]
+	}
+	return result
+}
+
+// gap converts the source between two inline nodes
+func (w *markdownInlineWriter) gap(start, end int) []*markdownInline {
+	gap := string(w.source.text[start:end])
+	newline := strings.IndexByte(gap, '\n')
+	switch {
+	case newline >= 0:
+		// A hard line break keeps its trailing spaces or backslash
+		var result []*markdownInline
+		if marker := gap[:newline]; marker == `\` || strings.HasPrefix(marker, "  ") {
+			result = append(result, w.opaque(start, start+newline))
+		}
+		return append(result, &markdownInline{kind: markdownInlineText, text: "\n"})
+	case strings.TrimSpace(gap) == "" || gap == `\`:
+		return []*markdownInline{{kind: markdownInlineText, text: gap}}
+	default:
+		return []*markdownInline{w.opaque(start, end)}
+	}
+}
+
+// opaque creates a numbered node keeping the source between start and end
+func (w *markdownInlineWriter) opaque(start, end int) *markdownInline {
+	w.counter++
+	return &markdownInline{
+		kind:  markdownInlineOpaque,
+		id:    w.counter,
+		text:  string(w.source.text[start:end]),
+		start: w.source.position(start, false),
+	}
+}
+
+// inline converts a node with its measured range
+func (w *markdownInlineWriter) inline(node ast.Node, r [2]int) *markdownInline {
+	switch node.Kind() {
+	case ast.KindText:
+		return &markdownInline{kind: markdownInlineText, text: string(w.source.text[r[0]:r[1]])}
+	case ast.KindEmphasis, extast.KindStrikethrough, ast.KindLink:
+		first, firstOK := w.spans.ranges[node.FirstChild()]
+		last, lastOK := w.spans.ranges[node.LastChild()]
+		if !firstOK || !lastOK {
+			break
+		}
+		w.counter++
+		group := &markdownInline{
+			kind:  markdownInlineGroup,
+			id:    w.counter,
+			open:  string(w.source.text[r[0]:first[0]]),
+			close: string(w.source.text[last[1]:r[1]]),
+		}
+		var children []ast.Node
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			if _, ok := w.spans.ranges[child]; ok {
+				children = append(children, child)
+			}
+		}
+		group.children = w.inlines(children)
+		return group
+	}
+	return w.opaque(r[0], r[1])
+}
+
+// writeMarkdownInlineText writes inline nodes with inline tags
+func writeMarkdownInlineText(builder *strings.Builder, inlines []*markdownInline) {
+	for _, inline := range inlines {
+		switch inline.kind {
+		case markdownInlineText:
+			builder.WriteString(inline.text)
+		case markdownInlineGroup:
+			fmt.Fprintf(builder, "<r%d>", inline.id)
+			writeMarkdownInlineText(builder, inline.children)
+			fmt.Fprintf(builder, "</r%d>", inline.id)
+		case markdownInlineOpaque:
+			fmt.Fprintf(builder, "<x%d/>", inline.id)
+		}
+	}
+}
+
+// markdownPlainText returns the text of inline nodes without markup
+func markdownPlainText(inlines []*markdownInline) string {
+	var builder strings.Builder
+	for _, inline := range inlines {
+		switch inline.kind {
+		case markdownInlineText:
+			builder.WriteString(inline.text)
+		case markdownInlineGroup:
+			builder.WriteString(markdownPlainText(inline.children))
+		}
+	}
+	return builder.String()
+}
+
+// buildMarkdownText renders translated text of a block back to Markdown,
+// restoring the markup of its inline tags
+func buildMarkdownText(span *markdownSpan, translatedText string) string {
+	nodes := make(map[int]*markdownInline)
+	var index func([]*markdownInline)
+	index = func(inlines []*markdownInline) {
+		for _, inline := range inlines {
+			if inline.kind != markdownInlineText {
+				nodes[inline.id] = inline
+				index(inline.children)
+			}
+		}
+	}
+	index(span.inlines)
+
+	var builder strings.Builder
+	used := make(map[int]bool)
+	var open []*markdownInline
+	cursor := 0
+
+	for _, loc := range markdownInlineTagPattern.FindAllStringSubmatchIndex(translatedText, -1) {
+		builder.WriteString(buildMarkdownPlain(span, translatedText[cursor:loc[0]]))
+		cursor = loc[1]
+
+		switch {
+		case loc[2] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[2]:loc[3]])
+			if node, ok := nodes[id]; ok && node.kind == markdownInlineGroup && !used[id] {
+				builder.WriteString(node.open)
+				open = append(open, node)
+				used[id] = true
+			}
+		case loc[4] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[4]:loc[5]])
+			// Close the matching group together with any left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i].id == id {
+					for j := len(open) - 1; j >= i; j-- {
+						builder.WriteString(open[j].close)
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case loc[6] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[6]:loc[7]])
+			if node, ok := nodes[id]; ok && node.kind == markdownInlineOpaque && !used[id] {
+				builder.WriteString(node.text)
+				used[id] = true
+			}
+		}
+	}
+	builder.WriteString(buildMarkdownPlain(span, translatedText[cursor:]))
+
+	for i := len(open) - 1; i >= 0; i-- {
+		builder.WriteString(open[i].close)
+	}
+
+	return builder.String()
+}
+
+// buildMarkdownPlain continues container markup after line breaks and
+// escapes pipes inside table cells
+func buildMarkdownPlain(span *markdownSpan, text string) string {
+	if span.tableCell {
+		var builder strings.Builder
+		for i := 0; i < len(text); i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				builder.WriteString(text[i : i+2])
+				i++
+				continue
+			}
+			if text[i] == '|' {
+				builder.WriteByte('\\')
+			}
+			builder.WriteByte(text[i])
+		}
+		text = builder.String()
+	}
+
+	if span.singleLine {
+		return strings.ReplaceAll(text, "\n", " ")
+	}
+	return strings.ReplaceAll(text, "\n", "\n"+span.prefix)
+}
@@ -0,0 +1,730 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFrontMatterTranslateKeys are translated unless configured otherwise
+var defaultFrontMatterTranslateKeys = []string{"title", "description", "summary", "tags"}
+
+var (
+	// tomlKeyPattern matches the key of a TOML key/value line up to the value
+	tomlKeyPattern = regexp.MustCompile(`^\s*((?:[A-Za-z0-9_-]+|"(?:[^"\\]|\\.)*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"(?:[^"\\]|\\.)*"|'[^']*'))*)\s*=\s*`)
+
+	// tomlKeyPartPattern matches one part of a dotted TOML key
+	tomlKeyPartPattern = regexp.MustCompile(`[A-Za-z0-9_-]+|"(?:[^"\\]|\\.)*"|'[^']*'`)
+)
+
+// FrontMatterOptions controls how Markdown front matter is translated
+type FrontMatterOptions struct {
+	// TranslateKeys lists the keys whose string values (or lists of strings)
+	// are translated, with dots separating nested keys. Everything else is
+	// kept verbatim.
+	TranslateKeys []string
+	// LanguageKey, such as lang or locale, is set to Language when not empty
+	LanguageKey string
+	Language    string
+}
+
+// markdownFrontMatter is the YAML (---), TOML (+++) or JSON front matter
+// at the start of a Markdown document
+type markdownFrontMatter struct {
+	format string
+	// contentStart and contentEnd enclose the front matter inside its delimiters
+	contentStart int
+	contentEnd   int
+	// end is where the Markdown body begins
+	end      int
+	values   []*markdownValue
+	language *byteRangeReplacement
+}
+
+// markdownValue is a translatable string outside the Markdown text, such as
+// a front matter value or a JSX string prop. encode writes a translation in
+// the quoting style of the original value.
+type markdownValue struct {
+	key    string
+	start  int
+	end    int
+	text   string
+	encode func(string) string
+}
+
+// detectMarkdownFrontMatter finds front matter at the start of data
+func detectMarkdownFrontMatter(data []byte) *markdownFrontMatter {
+	start := 0
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		start = 3
+	}
+
+	delimiters := []struct {
+		format  string
+		opening string
+		closing []string
+	}{
+		{"yaml", "---", []string{"---", "..."}},
+		{"toml", "+++", []string{"+++"}},
+	}
+	for _, delimiter := range delimiters {
+		openingEnd := markdownLineEnd(data, start)
+		if strings.TrimRight(string(data[start:openingEnd]), " \t\r") != delimiter.opening || openingEnd == len(data) {
+			continue
+		}
+		for pos := openingEnd + 1; pos < len(data); {
+			lineEnd := markdownLineEnd(data, pos)
+			line := strings.TrimRight(string(data[pos:lineEnd]), " \t\r")
+			for _, closing := range delimiter.closing {
+				if line == closing {
+					return &markdownFrontMatter{
+						format:       delimiter.format,
+						contentStart: openingEnd + 1,
+						contentEnd:   pos,
+						end:          min(lineEnd+1, len(data)),
+					}
+				}
+			}
+			pos = lineEnd + 1
+		}
+		return nil
+	}
+
+	// Hugo JSON front matter is an object on the first lines
+	if start < len(data) && data[start] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data[start:]))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil
+		}
+		end := start + int(decoder.InputOffset())
+		lineEnd := markdownLineEnd(data, end)
+		if strings.TrimSpace(string(data[end:lineEnd])) != "" {
+			return nil
+		}
+		return &markdownFrontMatter{
+			format:       "json",
+			contentStart: start,
+			contentEnd:   end,
+			end:          min(lineEnd+1, len(data)),
+		}
+	}
+
+	return nil
+}
+
+// markdownLineEnd returns the position of the newline ending the line at pos
+func markdownLineEnd(data []byte, pos int) int {
+	if index := bytes.IndexByte(data[pos:], '\n'); index >= 0 {
+		return pos + index
+	}
+	return len(data)
+}
+
+// parse locates the translatable values and the language key
+func (f *markdownFrontMatter) parse(data []byte, options FrontMatterOptions) error {
+	content := data[f.contentStart:f.contentEnd]
+	var err error
+	switch f.format {
+	case "yaml":
+		err = f.parseYAML(content, options)
+	case "toml":
+		err = f.parseTOML(content, options)
+	case "json":
+		err = f.parseJSON(content, options)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s front matter: %w", f.format, err)
+	}
+
+	// Positions were found relative to the content
+	for _, value := range f.values {
+		value.start += f.contentStart
+		value.end += f.contentStart
+	}
+	if f.language != nil {
+		f.language.start += int64(f.contentStart)
+		f.language.end += int64(f.contentStart)
+	}
+	return nil
+}
+
+func (f *markdownFrontMatter) parseYAML(content []byte, options FrontMatterOptions) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	mapping := root.Content[0]
+
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	// yaml.v3 reports 1-based lines and columns counted in characters
+	offset := func(node *yaml.Node) int {
+		if node.Line < 1 || node.Line > len(lineStarts) {
+			return -1
+		}
+		pos := lineStarts[node.Line-1]
+		for column := 1; column < node.Column && pos < len(content); column++ {
+			_, size := utf8.DecodeRune(content[pos:])
+			pos += size
+		}
+		return pos
+	}
+
+	for _, key := range options.TranslateKeys {
+		keyNode, value := yamlMappingLookup(mapping, strings.Split(key, "."))
+		if value == nil {
+			continue
+		}
+		keyIndent := keyNode.Column - 1
+
+		scalars := []*yaml.Node{value}
+		flow := false
+		if value.Kind == yaml.SequenceNode {
+			scalars = value.Content
+			flow = value.Style&yaml.FlowStyle != 0
+			// Items of block sequences are not continued on further lines
+			keyIndent = len(content)
+		}
+		for _, scalar := range scalars {
+			if scalar.Kind != yaml.ScalarNode || scalar.Tag != "!!str" {
+				continue
+			}
+			start := offset(scalar)
+			if start < 0 {
+				continue
+			}
+			if start, end, encode, ok := yamlScalarRange(content, scalar, start, keyIndent, flow); ok {
+				f.values = append(f.values, &markdownValue{key: key, start: start, end: end, text: scalar.Value, encode: encode})
+			}
+		}
+	}
+
+	if options.LanguageKey == "" || options.Language == "" {
+		return nil
+	}
+	keyNode, value := yamlMappingLookup(mapping, []string{options.LanguageKey})
+	if value == nil {
+		line := fmt.Sprintf("%s: %s\n", options.LanguageKey, yamlPlainOrQuoted(options.Language, false))
+		f.language = &byteRangeReplacement{start: int64(len(content)), end: int64(len(content)), text: line}
+		return nil
+	}
+	if start := offset(value); value.Kind == yaml.ScalarNode && value.Tag == "!!str" && start >= 0 {
+		if start, end, encode, ok := yamlScalarRange(content, value, start, keyNode.Column-1, false); ok {
+			f.language = &byteRangeReplacement{start: int64(start), end: int64(end), text: encode(options.Language)}
+		}
+	}
+	return nil
+}
+
+// yamlMappingLookup returns the key and value nodes at a path of mapping keys
+func yamlMappingLookup(mapping *yaml.Node, path []string) (*yaml.Node, *yaml.Node) {
+	var keyNode, value *yaml.Node
+	node := mapping
+	for _, part := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		keyNode, value = nil, nil
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				keyNode, value = node.Content[i], node.Content[i+1]
+				break
+			}
+		}
+		if value == nil {
+			return nil, nil
+		}
+		node = value
+	}
+	return keyNode, value
+}
+
+// yamlScalarRange returns the source range of a scalar starting at start and
+// an encoder keeping its style. Plain scalars may continue on lines indented
+// deeper than keyIndent; block scalars replace only their content lines.
+func yamlScalarRange(content []byte, node *yaml.Node, start, keyIndent int, flow bool) (int, int, func(string) string, bool) {
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return start, i + 1, yamlDoubleQuoted, true
+			}
+		}
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			if content[i] != '\'' {
+				continue
+			}
+			if i+1 < len(content) && content[i+1] == '\'' {
+				i++
+				continue
+			}
+			return start, i + 1, func(text string) string {
+				if strings.Contains(text, "\n") {
+					return yamlDoubleQuoted(text)
+				}
+				return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+			}, true
+		}
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		first := markdownLineEnd(content, start) + 1
+		indent, end := -1, first
+		for pos := first; pos < len(content); {
+			lineEnd := markdownLineEnd(content, pos)
+			line := content[pos:lineEnd]
+			if len(bytes.TrimSpace(line)) > 0 {
+				lineIndent := len(line) - len(bytes.TrimLeft(line, " "))
+				if indent < 0 {
+					indent = lineIndent
+				}
+				if lineIndent < indent || lineIndent <= keyIndent {
+					break
+				}
+				end = lineEnd
+			}
+			pos = lineEnd + 1
+		}
+		if indent < 0 {
+			return 0, 0, nil, false
+		}
+		padding := strings.Repeat(" ", indent)
+		return first, end, func(text string) string {
+			lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = padding + line
+				}
+			}
+			return strings.Join(lines, "\n")
+		}, true
+	default:
+		end := yamlPlainEnd(content, start, flow)
+		if !flow {
+			for pos := markdownLineEnd(content, start) + 1; pos < len(content); {
+				lineEnd := markdownLineEnd(content, pos)
+				line := content[pos:lineEnd]
+				trimmed := bytes.TrimLeft(line, " ")
+				lineIndent := len(line) - len(trimmed)
+				if len(bytes.TrimSpace(trimmed)) == 0 || lineIndent <= keyIndent || trimmed[0] == '#' {
+					break
+				}
+				end = yamlPlainEnd(content, pos+lineIndent, false)
+				pos = lineEnd + 1
+			}
+		}
+		return start, end, func(text string) string {
+			return yamlPlainOrQuoted(text, flow)
+		}, true
+	}
+	return 0, 0, nil, false
+}
+
+// yamlPlainEnd returns the end of a plain scalar on the line at start
+func yamlPlainEnd(content []byte, start int, flow bool) int {
+	lineEnd := markdownLineEnd(content, start)
+	end := start
+	for ; end < lineEnd; end++ {
+		c := content[end]
+		if c == '#' && end > start && (content[end-1] == ' ' || content[end-1] == '\t') {
+			break
+		}
+		if flow && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+	}
+	return start + len(bytes.TrimRight(content[start:end], " \t\r"))
+}
+
+// yamlPlainOrQuoted writes text plain when it reads back as the same string
+func yamlPlainOrQuoted(text string, flow bool) string {
+	if text == "" || strings.TrimSpace(text) != text || strings.ContainsAny(text, "\n\t\r") ||
+		strings.ContainsAny(text[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(text, ": ") || strings.Contains(text, " #") || strings.HasSuffix(text, ":") ||
+		(flow && strings.ContainsAny(text, ",[]{}")) {
+		return yamlDoubleQuoted(text)
+	}
+
+	var decoded map[string]interface{}
+	if err := yaml.Unmarshal([]byte("v: "+text), &decoded); err != nil {
+		return yamlDoubleQuoted(text)
+	}
+	if value, ok := decoded["v"].(string); !ok || value != text {
+		return yamlDoubleQuoted(text)
+	}
+	return text
+}
+
+// yamlDoubleQuoted writes a double-quoted YAML scalar; Go escapes are valid YAML escapes
+func yamlDoubleQuoted(text string) string {
+	return strconv.Quote(text)
+}
+
+func (f *markdownFrontMatter) parseTOML(content []byte, options FrontMatterOptions) error {
+	var decoded map[string]interface{}
+	if _, err := toml.Decode(string(content), &decoded); err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool)
+	for _, key := range options.TranslateKeys {
+		wanted[key] = true
+	}
+
+	var table []string
+	arrayTable := false
+	firstTable := -1
+	languageFound := false
+
+	for pos := 0; pos < len(content); {
+		lineEnd := markdownLineEnd(content, pos)
+		line := content[pos:lineEnd]
+		trimmed := bytes.TrimSpace(line)
+
+		switch {
+		case len(trimmed) == 0 || trimmed[0] == '#':
+			pos = lineEnd + 1
+			continue
+		case trimmed[0] == '[':
+			if firstTable < 0 {
+				firstTable = pos
+			}
+			arrayTable = bytes.HasPrefix(trimmed, []byte("[["))
+			header := trimmed
+			if end := bytes.IndexByte(trimmed, ']'); end >= 0 {
+				header = trimmed[:end]
+			}
+			table = tomlKeyParts(string(bytes.TrimLeft(header, "[")))
+			pos = lineEnd + 1
+			continue
+		}
+
+		match := tomlKeyPattern.FindSubmatchIndex(line)
+		if match == nil {
+			pos = lineEnd + 1
+			continue
+		}
+		keyParts := tomlKeyParts(string(line[match[2]:match[3]]))
+		valueStart := pos + match[1]
+		valueEnd := tomlValueEnd(content, valueStart)
+
+		if !arrayTable {
+			key := strings.Join(append(append([]string{}, table...), keyParts...), ".")
+			if wanted[key] {
+				f.addTOMLValues(content, key, valueStart, valueEnd)
+			}
+			if options.LanguageKey != "" && options.Language != "" && len(table) == 0 && key == options.LanguageKey {
+				languageFound = true
+				f.language = &byteRangeReplacement{start: int64(valueStart), end: int64(valueEnd), text: tomlBasicString(options.Language, false)}
+			}
+		}
+		pos = markdownLineEnd(content, valueEnd) + 1
+	}
+
+	if options.LanguageKey != "" && options.Language != "" && !languageFound {
+		// Top level keys must come before the first table
+		insertAt := len(content)
+		if firstTable >= 0 {
+			insertAt = firstTable
+		}
+		line := fmt.Sprintf("%s = %s\n", options.LanguageKey, tomlBasicString(options.Language, false))
+		f.language = &byteRangeReplacement{start: int64(insertAt), end: int64(insertAt), text: line}
+	}
+	return nil
+}
+
+// addTOMLValues records a string value or the strings of an array
+func (f *markdownFrontMatter) addTOMLValues(content []byte, key string, start, end int) {
+	if content[start] != '[' {
+		f.addTOMLString(content, key, start, end)
+		return
+	}
+
+	for pos := start + 1; pos < end; {
+		switch c := content[pos]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',':
+			pos++
+		case c == '#':
+			pos = markdownLineEnd(content, pos)
+		case c == ']':
+			return
+		default:
+			elementEnd := tomlValueEnd(content, pos)
+			if elementEnd <= pos {
+				return
+			}
+			f.addTOMLString(content, key, pos, elementEnd)
+			pos = elementEnd
+		}
+	}
+}
+
+// addTOMLString records a TOML string, keeping its quoting style
+func (f *markdownFrontMatter) addTOMLString(content []byte, key string, start, end int) {
+	raw := string(content[start:end])
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		return
+	}
+
+	var decoded map[string]interface{}
+	if _, err := toml.Decode("v = "+raw, &decoded); err != nil {
+		return
+	}
+	text, ok := decoded["v"].(string)
+	if !ok {
+		return
+	}
+
+	var encode func(string) string
+	switch {
+	case strings.HasPrefix(raw, `"""`):
+		encode = func(text string) string { return tomlBasicString(text, true) }
+	case strings.HasPrefix(raw, `'''`):
+		encode = func(text string) string {
+			if strings.Contains(text, "'''") {
+				return tomlBasicString(text, true)
+			}
+			return "'''" + text + "'''"
+		}
+	case raw[0] == '\'':
+		encode = func(text string) string {
+			if strings.ContainsAny(text, "'\n") {
+				return tomlBasicString(text, false)
+			}
+			return "'" + text + "'"
+		}
+	default:
+		encode = func(text string) string { return tomlBasicString(text, false) }
+	}
+
+	f.values = append(f.values, &markdownValue{key: key, start: start, end: end, text: text, encode: encode})
+}
+
+// tomlKeyParts splits a dotted TOML key, unquoting quoted parts
+func tomlKeyParts(key string) []string {
+	var parts []string
+	for _, part := range tomlKeyPartPattern.FindAllString(key, -1) {
+		switch part[0] {
+		case '"':
+			if unquoted, err := strconv.Unquote(part); err == nil {
+				part = unquoted
+			} else {
+				part = strings.Trim(part, `"`)
+			}
+		case '\'':
+			part = strings.Trim(part, "'")
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// tomlValueEnd returns the end of the TOML value starting at start
+func tomlValueEnd(content []byte, start int) int {
+	rest := content[start:]
+	switch {
+	case bytes.HasPrefix(rest, []byte(`"""`)), bytes.HasPrefix(rest, []byte(`'''`)):
+		quote := rest[:3]
+		for i := 3; i < len(rest); i++ {
+			if quote[0] == '"' && rest[i] == '\\' {
+				i++
+				continue
+			}
+			if bytes.HasPrefix(rest[i:], quote) {
+				// Up to two quotes may directly precede the closing delimiter
+				end := i + 3
+				for end < len(rest) && end-i < 5 && rest[end] == quote[0] {
+					end++
+				}
+				return start + end
+			}
+		}
+		return len(content)
+	case len(rest) > 0 && rest[0] == '"':
+		for i := 1; i < len(rest) && rest[i] != '\n'; i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+			case '"':
+				return start + i + 1
+			}
+		}
+		return markdownLineEnd(content, start)
+	case len(rest) > 0 && rest[0] == '\'':
+		if end := bytes.IndexByte(rest[1:], '\''); end >= 0 {
+			return start + end + 2
+		}
+		return markdownLineEnd(content, start)
+	case len(rest) > 0 && (rest[0] == '[' || rest[0] == '{'):
+		depth := 0
+		for i := 0; i < len(rest); {
+			switch rest[i] {
+			case '"', '\'':
+				i = tomlValueEnd(content, start+i) - start
+				continue
+			case '#':
+				i = markdownLineEnd(content, start+i) - start
+				continue
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return start + i + 1
+				}
+			}
+			i++
+		}
+		return len(content)
+	default:
+		end := 0
+		for end < len(rest) && !strings.ContainsRune("\n#,]}", rune(rest[end])) {
+			end++
+		}
+		return start + len(bytes.TrimRight(rest[:end], " \t\r"))
+	}
+}
+
+// tomlBasicString writes a basic TOML string, or a multi-line one
+func tomlBasicString(text string, multiline bool) string {
+	var builder strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '"':
+			builder.WriteString(`\"`)
+		case r == '\n' && multiline:
+			builder.WriteRune(r)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&builder, `\u%04X`, r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	if multiline {
+		return `"""` + builder.String() + `"""`
+	}
+	return `"` + builder.String() + `"`
+}
+
+// jsonFrame tracks an object or array while walking JSON tokens
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+}
+
+func (f *markdownFrontMatter) parseJSON(content []byte, options FrontMatterOptions) error {
+	wanted := make(map[string]bool)
+	for _, key := range options.TranslateKeys {
+		wanted[key] = true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var stack []*jsonFrame
+	languageFound := false
+
+tokens:
+	for {
+		before := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			switch value {
+			case '{', '[':
+				stack = append(stack, &jsonFrame{object: value == '{', expectKey: value == '{'})
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 && stack[len(stack)-1].object {
+					stack[len(stack)-1].expectKey = true
+				}
+			}
+			if len(stack) == 0 {
+				break tokens
+			}
+			continue
+		case string:
+			if top != nil && top.object && top.expectKey {
+				top.key = value
+				top.expectKey = false
+				continue
+			}
+
+			var path []string
+			for _, frame := range stack {
+				if frame.object {
+					path = append(path, frame.key)
+				}
+			}
+			key := strings.Join(path, ".")
+			start := before + bytes.IndexByte(content[before:], '"')
+			end := int(decoder.InputOffset())
+			if wanted[key] {
+				f.values = append(f.values, &markdownValue{key: key, start: start, end: end, text: value, encode: jsonString})
+			}
+			if len(stack) == 1 && options.LanguageKey != "" && options.Language != "" && key == options.LanguageKey {
+				languageFound = true
+				f.language = &byteRangeReplacement{start: int64(start), end: int64(end), text: jsonString(options.Language)}
+			}
+		}
+		if top != nil && top.object {
+			top.expectKey = true
+		}
+	}
+
+	if options.LanguageKey != "" && options.Language != "" && !languageFound {
+		// Insert the key first, indented like the existing keys
+		open := bytes.IndexByte(content, '{') + 1
+		firstKey := bytes.IndexByte(content[open:], '"')
+		entry := jsonString(options.LanguageKey) + ": " + jsonString(options.Language)
+		if firstKey < 0 {
+			f.language = &byteRangeReplacement{start: int64(open), end: int64(open), text: entry}
+		} else {
+			f.language = &byteRangeReplacement{start: int64(open), end: int64(open), text: string(content[open:open+firstKey]) + entry + ","}
+		}
+	}
+	return nil
+}
+
+// jsonString writes a JSON string without escaping HTML characters
+func jsonString(text string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(text)
+	return strings.TrimRight(buffer.String(), "\n")
+}
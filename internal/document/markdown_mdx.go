@@ -0,0 +1,423 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+)
+
+// mdxProp is a translatable string prop of a JSX element
+type mdxProp struct {
+	component string
+	value     *markdownValue
+}
+
+// mdxScanner finds the syntax MDX adds to Markdown: ESM import and export
+// statements, JSX elements and {expressions}
+type mdxScanner struct {
+	src    []byte
+	masked []byte
+	props  map[string]bool
+	found  []*mdxProp
+}
+
+// maskMDX returns a copy of content in which the MDX syntax of the body is
+// replaced by HTML of the same length, so the Markdown parser keeps it as
+// opaque HTML blocks and inline HTML, together with the string props listed
+// in props. Statements and elements on lines of their own become processing
+// instructions, inline elements open tags and inline expressions code spans.
+func maskMDX(content []byte, bodyStart int, props []string) ([]byte, []*mdxProp) {
+	s := &mdxScanner{
+		src:    content,
+		masked: append([]byte(nil), content...),
+		props:  make(map[string]bool, len(props)),
+	}
+	for _, prop := range props {
+		s.props[prop] = true
+	}
+
+	fence := ""
+	for pos := bodyStart; pos < len(s.src); {
+		end := markdownLineEnd(s.src, pos)
+		line := bytes.TrimLeft(s.src[pos:end], " \t>")
+		start := end - len(line)
+
+		switch {
+		case fence != "":
+			if bytes.HasPrefix(line, []byte(fence)) && len(bytes.TrimSpace(bytes.TrimLeft(line, fence[:1]))) == 0 {
+				fence = ""
+			}
+		case bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~")):
+			fence = string(line[:len(line)-len(bytes.TrimLeft(line, string(line[:1])))])
+		case bytes.HasPrefix(line, []byte("$$")):
+			if !bytes.Contains(line[2:], []byte("$$")) {
+				fence = "$$"
+			}
+		case start == pos && mdxIsESM(line):
+			// An ESM block runs up to the next blank line
+			for end < len(s.src) {
+				next := markdownLineEnd(s.src, end+1)
+				if len(bytes.TrimSpace(s.src[end+1:next])) == 0 {
+					break
+				}
+				end = next
+			}
+			s.mask(pos, end, "<?", "?>")
+		default:
+			end = s.scanLine(start, end)
+		}
+		pos = end + 1
+	}
+
+	return s.masked, s.found
+}
+
+// mdxIsESM reports whether a line starts an import or export statement
+func mdxIsESM(line []byte) bool {
+	for _, keyword := range []string{"import", "export"} {
+		if bytes.HasPrefix(line, []byte(keyword)) && len(line) > len(keyword) {
+			switch line[len(keyword)] {
+			case ' ', '\t', '{', '*':
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanLine masks the MDX syntax of the line from start to end and returns the
+// end of the last line scanned, as elements and expressions may span lines
+func (s *mdxScanner) scanLine(start, end int) int {
+	if flowEnd, ok := s.scanFlow(start); ok {
+		return markdownLineEnd(s.src, flowEnd)
+	}
+
+	for i := start; i < end; i++ {
+		switch s.src[i] {
+		case '\\':
+			i++
+		case '`', '$':
+			i = s.skipInlineRun(i) - 1
+		case '<':
+			tagEnd, props, ok := s.scanTag(i)
+			if !ok {
+				continue
+			}
+			s.mask(i, tagEnd, "<", ">")
+			s.found = append(s.found, props...)
+			i = tagEnd - 1
+			end = max(end, markdownLineEnd(s.src, i))
+		case '{':
+			expressionEnd, ok := s.scanExpression(i)
+			if !ok {
+				continue
+			}
+			s.mask(i, expressionEnd, "`", "`")
+			i = expressionEnd - 1
+			end = max(end, markdownLineEnd(s.src, i))
+		}
+	}
+	return end
+}
+
+// scanFlow masks elements and expressions that fill their lines on their own
+// and returns where they end
+func (s *mdxScanner) scanFlow(start int) (int, bool) {
+	var props []*mdxProp
+	end := start
+	for {
+		if end >= len(s.src) {
+			return 0, false
+		}
+		var next int
+		var ok bool
+		switch s.src[end] {
+		case '<':
+			var tagProps []*mdxProp
+			next, tagProps, ok = s.scanTag(end)
+			props = append(props, tagProps...)
+		case '{':
+			next, ok = s.scanExpression(end)
+		}
+		if !ok {
+			return 0, false
+		}
+		end = next
+
+		rest := end
+		for rest < len(s.src) && (s.src[rest] == ' ' || s.src[rest] == '\t' || s.src[rest] == '\r') {
+			rest++
+		}
+		if rest == len(s.src) || s.src[rest] == '\n' {
+			break
+		}
+		end = rest
+	}
+
+	if !s.mask(start, end, "<?", "?>") {
+		return 0, false
+	}
+	s.found = append(s.found, props...)
+	return end, true
+}
+
+// skipInlineRun skips a code span or inline math starting at i, or the run
+// of backticks or dollar signs if it is not closed within the paragraph
+func (s *mdxScanner) skipInlineRun(i int) int {
+	c := s.src[i]
+	width := 0
+	for i+width < len(s.src) && s.src[i+width] == c {
+		width++
+	}
+	limit := len(s.src)
+	if paragraphEnd := bytes.Index(s.src[i:], []byte("\n\n")); paragraphEnd >= 0 {
+		limit = i + paragraphEnd
+	}
+
+	for j := i + width; j < limit; j++ {
+		if s.src[j] != c {
+			continue
+		}
+		run := 0
+		for j+run < limit && s.src[j+run] == c {
+			run++
+		}
+		if run == width {
+			return j + run
+		}
+		j += run - 1
+	}
+	return i + width
+}
+
+// scanTag parses a JSX tag starting at i and returns its end together with
+// the configured string props of an opening tag
+func (s *mdxScanner) scanTag(i int) (int, []*mdxProp, bool) {
+	j := i + 1
+	closing := false
+	if j < len(s.src) && s.src[j] == '/' {
+		closing = true
+		j++
+	}
+	// Fragments
+	if j < len(s.src) && s.src[j] == '>' {
+		return j + 1, nil, true
+	}
+	if j >= len(s.src) || !mdxIsNameStart(s.src[j]) {
+		return 0, nil, false
+	}
+	nameStart := j
+	for j < len(s.src) && mdxIsNameChar(s.src[j]) {
+		j++
+	}
+	name := string(s.src[nameStart:j])
+
+	var props []*mdxProp
+	for {
+		j = s.skipSpace(j)
+		if j >= len(s.src) {
+			return 0, nil, false
+		}
+		switch c := s.src[j]; {
+		case c == '>':
+			return j + 1, props, true
+		case c == '/':
+			if j+1 < len(s.src) && s.src[j+1] == '>' {
+				return j + 2, props, true
+			}
+			return 0, nil, false
+		case c == '{':
+			// Spread attributes
+			end, ok := s.scanExpression(j)
+			if !ok {
+				return 0, nil, false
+			}
+			j = end
+		case mdxIsNameStart(c):
+			attributeStart := j
+			for j < len(s.src) && mdxIsNameChar(s.src[j]) {
+				j++
+			}
+			attribute := string(s.src[attributeStart:j])
+
+			k := s.skipSpace(j)
+			if k >= len(s.src) || s.src[k] != '=' {
+				continue
+			}
+			k = s.skipSpace(k + 1)
+			if k >= len(s.src) {
+				return 0, nil, false
+			}
+			switch quote := s.src[k]; quote {
+			case '"', '\'':
+				closeQuote := bytes.IndexByte(s.src[k+1:], quote)
+				if closeQuote < 0 {
+					return 0, nil, false
+				}
+				j = k + 1 + closeQuote + 1
+				if !closing && s.props[attribute] {
+					props = append(props, &mdxProp{component: name, value: &markdownValue{
+						key:    attribute,
+						start:  k,
+						end:    j,
+						text:   string(s.src[k+1 : j-1]),
+						encode: jsxAttributeString(quote),
+					}})
+				}
+			case '{':
+				end, ok := s.scanExpression(k)
+				if !ok {
+					return 0, nil, false
+				}
+				j = end
+			default:
+				return 0, nil, false
+			}
+		default:
+			return 0, nil, false
+		}
+	}
+}
+
+// scanExpression returns the end of the braced JavaScript expression starting
+// at i, skipping strings and comments
+func (s *mdxScanner) scanExpression(i int) (int, bool) {
+	depth := 0
+	for j := i; j < len(s.src); j++ {
+		switch c := s.src[j]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j + 1, true
+			}
+		case '"', '\'', '`':
+			for j++; j < len(s.src) && s.src[j] != c; j++ {
+				if s.src[j] == '\\' {
+					j++
+				}
+			}
+		case '/':
+			if j+1 >= len(s.src) {
+				continue
+			}
+			switch s.src[j+1] {
+			case '/':
+				j = markdownLineEnd(s.src, j)
+			case '*':
+				end := bytes.Index(s.src[j+2:], []byte("*/"))
+				if end < 0 {
+					return 0, false
+				}
+				j += 2 + end + 1
+			}
+		}
+	}
+	return 0, false
+}
+
+// skipSpace skips whitespace including line breaks
+func (s *mdxScanner) skipSpace(i int) int {
+	for i < len(s.src) && (s.src[i] == ' ' || s.src[i] == '\t' || s.src[i] == '\r' || s.src[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// mask replaces the syntax between start and end with open, filler and close.
+// Line breaks and indentation are kept so the surrounding Markdown structure
+// is unchanged. It reports false if the range is too short.
+func (s *mdxScanner) mask(start, end int, open, close string) bool {
+	if end-start < len(open)+len(close) {
+		return false
+	}
+	for i := start; i < end; i++ {
+		switch s.masked[i] {
+		case '\n', '\r', ' ', '\t':
+		default:
+			s.masked[i] = 'x'
+		}
+	}
+	copy(s.masked[start:], open)
+	copy(s.masked[end-len(close):], close)
+	return true
+}
+
+// mdxIsNameStart reports whether c can start a JSX element or prop name
+func mdxIsNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+// mdxIsNameChar reports whether c can continue a JSX element or prop name,
+// including member expressions and namespaces
+func mdxIsNameChar(c byte) bool {
+	return mdxIsNameStart(c) || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ':'
+}
+
+// jsxAttributeString quotes a translated prop in the original quotes, or the
+// other quotes if the translation contains them. JSX decodes HTML entities in
+// string props, so quotes of both kinds are escaped as entities.
+func jsxAttributeString(quote byte) func(string) string {
+	return func(text string) string {
+		other := byte('"')
+		if quote == '"' {
+			other = '\''
+		}
+		switch {
+		case strings.IndexByte(text, quote) < 0:
+			return string(quote) + text + string(quote)
+		case strings.IndexByte(text, other) < 0:
+			return string(other) + text + string(other)
+		}
+		entity := "&#39;"
+		if quote == '"' {
+			entity = "&quot;"
+		}
+		return string(quote) + strings.ReplaceAll(text, string(quote), entity) + string(quote)
+	}
+}
+
+// withValues returns span with the replacements that fall inside its opaque
+// inline nodes, such as the props of an inline JSX element, applied to their
+// source. Replacements applied are marked in used.
+func (s *markdownSpan) withValues(values []byteRangeReplacement, used []bool) *markdownSpan {
+	if len(values) == 0 {
+		return s
+	}
+
+	var rewrite func([]*markdownInline) []*markdownInline
+	rewrite = func(inlines []*markdownInline) []*markdownInline {
+		result := make([]*markdownInline, len(inlines))
+		for i, inline := range inlines {
+			result[i] = inline
+			switch inline.kind {
+			case markdownInlineGroup:
+				group := *inline
+				group.children = rewrite(inline.children)
+				result[i] = &group
+			case markdownInlineOpaque:
+				var inner []byteRangeReplacement
+				for j, value := range values {
+					if int(value.start) >= inline.start && int(value.end) <= inline.start+len(inline.text) {
+						inner = append(inner, byteRangeReplacement{
+							start: value.start - int64(inline.start),
+							end:   value.end - int64(inline.start),
+							text:  value.text,
+						})
+						used[j] = true
+					}
+				}
+				if len(inner) > 0 {
+					opaque := *inline
+					opaque.text = string(applyByteRangeReplacements([]byte(inline.text), inner))
+					result[i] = &opaque
+				}
+			}
+		}
+		return result
+	}
+
+	rewritten := *s
+	rewritten.inlines = rewrite(s.inlines)
+	return &rewritten
+}
@@ -0,0 +1,358 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testMarkdown = `---
+title: Guide
+---
+# Getting *started*
+
+Run ` + "`make`" + ` to build $x^2$ [the docs](https://example.com "Docs")[^1].
+Line two<br>
+
+- First item
+  - Nested **item**
+    continued
+
+> [!NOTE]
+> Quoted text
+
+!!! warning "Careful"
+
+    Admonition body
+
+| Name | Value |
+|------|-------|
+| Size | ` + "`42`" + ` |
+
+` + "```go\nfmt.Println(\"code\")\n```" + `
+
+[^1]: A footnote
+`
+
+func TestMarkdownProcessor(t *testing.T) {
+	ctx := context.Background()
+
+	processor, err := NewMarkdownProcessor(ProcessorOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(ctx, strings.NewReader(testMarkdown))
+	if err != nil {
+		t.Fatalf("Failed to parse markdown: %v", err)
+	}
+
+	// Unchanged blocks render back byte for byte
+	var unchanged bytes.Buffer
+	if err := processor.Render(ctx, doc, &unchanged); err != nil {
+		t.Fatalf("Failed to render markdown: %v", err)
+	}
+	if unchanged.String() != testMarkdown {
+		t.Fatalf("Expected lossless round trip, got %q", unchanged.String())
+	}
+
+	translations := map[string]string{
+		"Guide":                    "指南",
+		"Getting <r1>started</r1>": "<r1>入门</r1>指南",
+		"Run <x1/> to build <x2/> <r3>the docs</r3><x4/>.\nLine two<x5/>": "运行 <x1/> 构建 <x2/> <r3>文档</r3><x4/>。\n第二行<x5/>",
+		"First item":                      "第一项",
+		"Nested <r1>item</r1>\ncontinued": "嵌套<r1>项目</r1>\n续行",
+		"Quoted text":                     "引用文本",
+		"Admonition body":                 "提示正文",
+		"Name":                            "名称",
+		"Value":                           "值|单位",
+		"Size":                            "大小",
+		"A footnote":                      "一条脚注",
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+		sources = append(sources, block.GetContent())
+		translated, ok := translations[block.GetContent()]
+		if !ok {
+			t.Errorf("Unexpected block %q", block.GetContent())
+			continue
+		}
+		block.SetContent(translated)
+	}
+	if len(sources) != len(translations) {
+		t.Fatalf("Expected %d translatable blocks, got %q", len(translations), sources)
+	}
+
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		switch block.GetContent() {
+		case "<r1>入门</r1>指南":
+			if block.GetType() != BlockTypeHeading || block.GetMetadata().Level != 1 {
+				t.Errorf("Expected level 1 heading, got %v", block.GetType())
+			}
+		case "引用文本":
+			if attrs["admonition"] != "note" {
+				t.Errorf("Expected note admonition, got %v", attrs["admonition"])
+			}
+		case "提示正文":
+			if attrs["admonition"] != "warning" {
+				t.Errorf("Expected warning admonition, got %v", attrs["admonition"])
+			}
+		case "一条脚注":
+			if attrs["markdownType"] != "footnote" {
+				t.Errorf("Expected footnote block, got %v", attrs["markdownType"])
+			}
+		}
+	}
+
+	renderer, _ := NewMarkdownProcessor(ProcessorOptions{}, zap.NewNop())
+	var output bytes.Buffer
+	if err := renderer.Render(ctx, doc, &output); err != nil {
+		t.Fatalf("Failed to render markdown: %v", err)
+	}
+
+	expected := `---
+title: 指南
+---
+# *入门*指南
+
+运行 ` + "`make`" + ` 构建 $x^2$ [文档](https://example.com "Docs")[^1]。
+第二行<br>
+
+- 第一项
+  - 嵌套**项目**
+    续行
+
+> [!NOTE]
+> 引用文本
+
+!!! warning "Careful"
+
+    提示正文
+
+| 名称 | 值\|单位 |
+|------|-------|
+| 大小 | ` + "`42`" + ` |
+
+` + "```go\nfmt.Println(\"code\")\n```" + `
+
+[^1]: 一条脚注
+`
+	if output.String() != expected {
+		t.Errorf("Expected rendered markdown:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
+
+func TestBuildMarkdownText(t *testing.T) {
+	processor, _ := NewMarkdownProcessor(ProcessorOptions{}, zap.NewNop())
+	doc, err := processor.Parse(context.Background(), strings.NewReader("> Some *very **strong*** `code`\n> text\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse markdown: %v", err)
+	}
+	span := doc.Blocks[0].GetMetadata().Attributes["markdownSpan"].(*markdownSpan)
+	if span.text != "Some <r1>very <r2>strong</r2></r1> <x3/>\ntext" {
+		t.Fatalf("Unexpected block text %q", span.text)
+	}
+
+	// Groups left open are closed, unknown tags dropped, line breaks continue the quote
+	got := buildMarkdownText(span, "<r1>非常<r2>强</r1> <x3/><x9/>\n文本")
+	expected := "*非常**强*** `code`\n> 文本"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	translations := map[string]string{
+		"Getting started": "入门: 指南",
+		"How to install":  "如何安装",
+		"setup":           "安装",
+		"guide":           "指南",
+		"A multi\nline\n": "多行\n摘要\n",
+		"Body text.":      "正文。",
+		"Nested subtitle": "嵌套副标题",
+		"It's \"quoted\"": "它是“引用”",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "YAML",
+			input:    "---\ntitle: Getting started\ndescription: \"How to install\"\nslug: getting-started\ndate: 2024-01-01\nweight: 10\naliases: [/old]\ntags: [setup, 'guide']\nsummary: |\n  A multi\n  line\nparams:\n  subtitle: Nested subtitle # kept comment\n---\nBody text.\n",
+			expected: "---\ntitle: \"入门: 指南\"\ndescription: \"如何安装\"\nslug: getting-started\ndate: 2024-01-01\nweight: 10\naliases: [/old]\ntags: [安装, '指南']\nsummary: |\n  多行\n  摘要\nparams:\n  subtitle: 嵌套副标题 # kept comment\nlang: zh-CN\n---\n正文。\n",
+		},
+		{
+			name:     "TOML",
+			input:    "+++\ntitle = \"Getting started\"\nslug = \"getting-started\"\ntags = [\"setup\", 'guide']\nlang = \"en\"\n\n[params]\nsubtitle = 'Nested subtitle'\n+++\nBody text.\n",
+			expected: "+++\ntitle = \"入门: 指南\"\nslug = \"getting-started\"\ntags = [\"安装\", '指南']\nlang = \"zh-CN\"\n\n[params]\nsubtitle = '嵌套副标题'\n+++\n正文。\n",
+		},
+		{
+			name:     "JSON",
+			input:    "{\n  \"title\": \"Getting started\",\n  \"weight\": 3,\n  \"params\": {\"subtitle\": \"It's \\\"quoted\\\"\"},\n  \"tags\": [\"setup\", \"guide\"]\n}\n\nBody text.\n",
+			expected: "{\n  \"lang\": \"zh-CN\",\n  \"title\": \"入门: 指南\",\n  \"weight\": 3,\n  \"params\": {\"subtitle\": \"它是“引用”\"},\n  \"tags\": [\"安装\", \"指南\"]\n}\n\n正文。\n",
+		},
+	}
+
+	opts := ProcessorOptions{Metadata: map[string]interface{}{
+		"target_language":             "zh-CN",
+		"front_matter_translate_keys": []string{"title", "description", "summary", "tags", "params.subtitle"},
+		"front_matter_language_key":   "lang",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			processor, _ := NewMarkdownProcessor(opts, zap.NewNop())
+			doc, err := processor.Parse(ctx, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to parse markdown: %v", err)
+			}
+
+			for _, block := range doc.Blocks {
+				if !block.IsTranslatable() {
+					continue
+				}
+				translated, ok := translations[block.GetContent()]
+				if !ok {
+					t.Errorf("Unexpected block %q", block.GetContent())
+					continue
+				}
+				block.SetContent(translated)
+			}
+
+			var output bytes.Buffer
+			if err := processor.Render(ctx, doc, &output); err != nil {
+				t.Fatalf("Failed to render markdown: %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, output.String())
+			}
+		})
+	}
+}
+
+const testMDX = `---
+title: Guide
+---
+import { Tabs, TabItem } from '@theme/Tabs'
+export const meta = {
+  author: 'me',
+}
+
+# Hello {props.name}
+
+<Card title="Card title" href="/x">
+
+Inside **card** content.
+
+</Card>
+
+Click <Badge label="New feature" color={"red"} /> to start. {/* a comment */}
+
+<Tabs>
+  <TabItem value="a" label='It"s first'>
+    Tab text
+  </TabItem>
+</Tabs>
+
+` + "```jsx\nconst x = <div title=\"no\">{1}</div>\n```" + `
+`
+
+func TestMDXProcessor(t *testing.T) {
+	ctx := context.Background()
+
+	opts := ProcessorOptions{Metadata: map[string]interface{}{
+		"mdx_translate_props": []string{"title", "label"},
+	}}
+	processor, err := NewMDXProcessor(opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(ctx, strings.NewReader(testMDX))
+	if err != nil {
+		t.Fatalf("Failed to parse MDX: %v", err)
+	}
+	if doc.Format != FormatMDX {
+		t.Errorf("Expected MDX format, got %s", doc.Format)
+	}
+
+	var unchanged bytes.Buffer
+	if err := processor.Render(ctx, doc, &unchanged); err != nil {
+		t.Fatalf("Failed to render MDX: %v", err)
+	}
+	if unchanged.String() != testMDX {
+		t.Fatalf("Expected lossless round trip, got %q", unchanged.String())
+	}
+
+	translations := map[string]string{
+		"Guide":                         "指南",
+		"Hello <x1/>":                   "你好 <x1/>",
+		"Card title":                    "卡片标题",
+		"Inside <r1>card</r1> content.": "<r1>卡片</r1>内的内容。",
+		"Click <x1/> to start. <x2/>":   "点击 <x1/> 开始。<x2/>",
+		"New feature":                   "新功能",
+		"It\"s first":                   "第'一\"个",
+		"Tab text":                      "标签页文本",
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+		sources = append(sources, block.GetContent())
+		translated, ok := translations[block.GetContent()]
+		if !ok {
+			t.Errorf("Unexpected block %q", block.GetContent())
+			continue
+		}
+		block.SetContent(translated)
+	}
+	if len(sources) != len(translations) {
+		t.Fatalf("Expected %d translatable blocks, got %q", len(translations), sources)
+	}
+
+	var output bytes.Buffer
+	if err := processor.Render(ctx, doc, &output); err != nil {
+		t.Fatalf("Failed to render MDX: %v", err)
+	}
+
+	expected := `---
+title: 指南
+---
+import { Tabs, TabItem } from '@theme/Tabs'
+export const meta = {
+  author: 'me',
+}
+
+# 你好 {props.name}
+
+<Card title="卡片标题" href="/x">
+
+**卡片**内的内容。
+
+</Card>
+
+点击 <Badge label="新功能" color={"red"} /> 开始。{/* a comment */}
+
+<Tabs>
+  <TabItem value="a" label='第&#39;一"个'>
+    标签页文本
+  </TabItem>
+</Tabs>
+
+` + "```jsx\nconst x = <div title=\"no\">{1}</div>\n```" + `
+`
+	if output.String() != expected {
+		t.Errorf("Expected rendered MDX:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
@@ -0,0 +1,504 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+// NotebookOptions configures the Jupyter notebook processor
+type NotebookOptions struct {
+	TranslateCodeComments bool // translate line comments in code cells
+}
+
+// notebookCommentMarkers maps kernel languages to their line comment marker
+var notebookCommentMarkers = map[string]string{
+	"python": "#", "r": "#", "julia": "#", "ruby": "#", "perl": "#",
+	"bash": "#", "sh": "#", "powershell": "#",
+	"javascript": "//", "typescript": "//", "java": "//", "kotlin": "//", "scala": "//",
+	"c": "//", "c++": "//", "cpp": "//", "c#": "//", "csharp": "//",
+	"go": "//", "rust": "//", "swift": "//", "dart": "//",
+	"sql": "--", "lua": "--", "haskell": "--",
+	"matlab": "%", "octave": "%",
+}
+
+// notebookDirectivePattern matches comments that are tool directives rather
+// than prose, such as shebangs, encoding lines, cell markers and linter hints
+var notebookDirectivePattern = regexp.MustCompile(`^(?:!|-\*-|%%|In\[|noqa|type:|pragma|pylint:|fmt:|isort:|mypy:|eslint|@ts-|nolint|prettier-ignore)`)
+
+// notebookCell locates the source of a cell in the notebook JSON
+type notebookCell struct {
+	cellType string
+	// start and end enclose the JSON value of the cell's source
+	start int
+	end   int
+	// text is the decoded source
+	text string
+	// lines is set when the source is stored as an array of lines, written
+	// one per line with indent, or on one line if indent is empty
+	lines       bool
+	indent      string
+	closeIndent string
+}
+
+// encode writes source text in the JSON representation of the original
+func (c *notebookCell) encode(text string) string {
+	if !c.lines {
+		return jsonString(text)
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return "[]"
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('[')
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteByte(',')
+			if c.indent == "" {
+				builder.WriteByte(' ')
+			}
+		}
+		if c.indent != "" {
+			builder.WriteString("\n" + c.indent)
+		}
+		builder.WriteString(jsonString(line))
+	}
+	if c.indent != "" {
+		builder.WriteString("\n" + c.closeIndent)
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+// NotebookProcessor processes Jupyter notebooks. Markdown cells are
+// translated like Markdown documents and, if enabled, line comments of code
+// cells. Only the sources of changed cells are rewritten, so outputs,
+// metadata and execution counts stay byte-identical.
+type NotebookProcessor struct {
+	opts      ProcessorOptions
+	logger    *zap.Logger
+	options   NotebookOptions
+	extractor *MarkdownExtractor
+	protector pkgdoc.ContentProtector
+}
+
+// NewNotebookProcessor creates a new Jupyter notebook processor
+func NewNotebookProcessor(opts ProcessorOptions, logger *zap.Logger) (*NotebookProcessor, error) {
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	return &NotebookProcessor{
+		opts:      opts,
+		logger:    logger,
+		options:   getNotebookOptionsFromOptions(opts),
+		extractor: NewMarkdownExtractor(),
+		protector: pkgdoc.GetProtectorForFormat("markdown"),
+	}, nil
+}
+
+// Parse parses a notebook into a Document. Each block records the index of
+// its cell and its location in the cell source.
+func (p *NotebookProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	cells, language, err := parseNotebook(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notebook: %w", err)
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("%s-%d", FormatNotebook, time.Now().Unix()),
+		Format: FormatNotebook,
+		Metadata: DocumentMetadata{
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	marker, knownLanguage := notebookCommentMarkers[language]
+	if p.options.TranslateCodeComments && !knownLanguage {
+		p.logger.Warn("unknown notebook language, code comments are not translated",
+			zap.String("language", language))
+	}
+
+	for i, cell := range cells {
+		switch cell.cellType {
+		case "markdown":
+			for _, block := range p.extractor.Extract([]byte(cell.text), 0) {
+				if block.span == nil {
+					continue
+				}
+				notebookBlock := newMarkdownBlock(block)
+				notebookBlock.GetMetadata().Attributes["notebookCell"] = i
+				doc.Blocks = append(doc.Blocks, notebookBlock)
+			}
+		case "code":
+			if !p.options.TranslateCodeComments || !knownLanguage {
+				continue
+			}
+			for _, span := range notebookCodeComments(cell.text, marker) {
+				doc.Blocks = append(doc.Blocks, &BaseBlock{
+					Type:         BlockTypeCustom,
+					Content:      span.text,
+					Translatable: true,
+					Metadata: BlockMetadata{
+						Language: language,
+						Attributes: map[string]interface{}{
+							"markdownType":              "code_comment",
+							"markdownSpan":              span,
+							"notebookCell":              i,
+							TranslationContextAttribute: fmt.Sprintf("comment in a %s code cell of a Jupyter notebook", language),
+						},
+					},
+				})
+			}
+		}
+	}
+
+	// Store the original notebook for later rendering
+	doc.Metadata.CustomFields["notebookData"] = data
+	doc.Metadata.CustomFields["notebookCells"] = cells
+
+	p.logger.Debug("parsed notebook",
+		zap.Int("cells", len(cells)),
+		zap.String("language", language),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// Process processes the document through translation
+func (p *NotebookProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		translatedText, err := translator(ctx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate block",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+		block.SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// Render writes the translated cell sources back into the original notebook
+func (p *NotebookProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	data, ok := doc.Metadata.CustomFields["notebookData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original notebook data not found in document metadata")
+	}
+	cells, ok := doc.Metadata.CustomFields["notebookCells"].([]*notebookCell)
+	if !ok {
+		return fmt.Errorf("notebook cells not found in document metadata")
+	}
+
+	cellBlocks := make(map[int][]Block)
+	for _, block := range doc.Blocks {
+		if index, ok := block.GetMetadata().Attributes["notebookCell"].(int); ok && index >= 0 && index < len(cells) {
+			cellBlocks[index] = append(cellBlocks[index], block)
+		}
+	}
+
+	var replacements []byteRangeReplacement
+	for index, blocks := range cellBlocks {
+		cell := cells[index]
+		text := string(renderMarkdownSource([]byte(cell.text), blocks, nil))
+		if text == cell.text {
+			continue
+		}
+		replacements = append(replacements, byteRangeReplacement{
+			start: int64(cell.start),
+			end:   int64(cell.end),
+			text:  cell.encode(text),
+		})
+	}
+
+	_, err := output.Write(applyByteRangeReplacements(data, replacements))
+	return err
+}
+
+// MarkUntranslated marks untranslated Markdown with an invisible HTML
+// comment; code comments are left as they are
+func (p *NotebookProcessor) MarkUntranslated(block Block, content string) string {
+	if block.GetMetadata().Attributes["markdownType"] == "code_comment" {
+		return content
+	}
+	return "<!-- UNTRANSLATED -->\n" + content
+}
+
+// GetFormat returns the format type
+func (p *NotebookProcessor) GetFormat() Format {
+	return FormatNotebook
+}
+
+// ProtectContent protects inline tags and Markdown syntax
+func (p *NotebookProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	for _, pattern := range inlineRunTagProtectPatterns {
+		text = pp.ProtectPattern(text, pattern)
+	}
+	return p.protector.ProtectContent(text, pp)
+}
+
+// parseNotebook locates the cell sources of a notebook and returns them with
+// the lower case kernel language
+func parseNotebook(data []byte) ([]*notebookCell, string, error) {
+	var header struct {
+		Metadata struct {
+			KernelSpec struct {
+				Language string `json:"language"`
+			} `json:"kernelspec"`
+			LanguageInfo struct {
+				Name string `json:"name"`
+			} `json:"language_info"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, "", err
+	}
+	language := header.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = header.Metadata.KernelSpec.Language
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return nil, "", err
+	}
+
+	var cells []*notebookCell
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		if key != "cells" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		if err := expectJSONDelim(decoder, '['); err != nil {
+			return nil, "", err
+		}
+		for decoder.More() {
+			cell, err := parseNotebookCell(decoder, data)
+			if err != nil {
+				return nil, "", err
+			}
+			cells = append(cells, cell)
+		}
+		if err := expectJSONDelim(decoder, ']'); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return cells, strings.ToLower(language), nil
+}
+
+// parseNotebookCell reads one cell object, recording where its source is
+func parseNotebookCell(decoder *json.Decoder, data []byte) (*notebookCell, error) {
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	cell := &notebookCell{}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "cell_type":
+			if err := decoder.Decode(&cell.cellType); err != nil {
+				return nil, err
+			}
+		case "source":
+			// The value starts after the colon following the key
+			start := int(decoder.InputOffset())
+			for start < len(data) && (data[start] == ':' || data[start] == ' ' || data[start] == '\t' || data[start] == '\r' || data[start] == '\n') {
+				start++
+			}
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, err
+			}
+			cell.start, cell.end = start, int(decoder.InputOffset())
+			if err := cell.decodeSource(raw); err != nil {
+				return nil, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := expectJSONDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	return cell, nil
+}
+
+// decodeSource decodes a source stored as a string or an array of lines
+func (c *notebookCell) decodeSource(raw json.RawMessage) error {
+	if len(raw) == 0 || raw[0] != '[' {
+		return json.Unmarshal(raw, &c.text)
+	}
+
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return err
+	}
+	c.text = strings.Join(lines, "")
+	c.lines = true
+
+	if first := bytes.IndexByte(raw, '\n'); first >= 0 {
+		indent := raw[first+1:]
+		c.indent = string(indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))])
+		last := bytes.LastIndexByte(raw, '\n')
+		c.closeIndent = string(bytes.TrimRight(raw[last+1:], "]"))
+	}
+	return nil
+}
+
+// expectJSONDelim reads the next token and checks it is the delimiter
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, got %v", delim, token)
+	}
+	return nil
+}
+
+// notebookCodeComments finds the line comments of code outside strings.
+// Consecutive comment lines of the same indentation form one span, trailing
+// comments after code a single line span each.
+func notebookCodeComments(code, marker string) []*markdownSpan {
+	var spans []*markdownSpan
+	var group *markdownSpan
+	var quote string
+
+	for lineStart := 0; lineStart < len(code); {
+		lineEnd := strings.IndexByte(code[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(code)
+		} else {
+			lineEnd += lineStart
+		}
+
+		var comment int
+		comment, quote = notebookFindComment(code[lineStart:lineEnd], marker, quote)
+		if comment < 0 {
+			group = nil
+			lineStart = lineEnd + 1
+			continue
+		}
+
+		comment += lineStart
+		textStart := comment + len(marker)
+		separator := ""
+		if textStart < lineEnd && code[textStart] == ' ' {
+			separator = " "
+			textStart++
+		}
+		textEnd := lineStart + len(strings.TrimRight(code[lineStart:lineEnd], " \t\r"))
+		text := code[textStart:max(textStart, textEnd)]
+		indent := code[lineStart:comment]
+		fullLine := strings.TrimSpace(indent) == ""
+
+		switch {
+		case notebookDirectivePattern.MatchString(text) || !strings.ContainsFunc(text, unicode.IsLetter):
+			group = nil
+		case fullLine && group != nil && group.prefix == indent+marker+separator:
+			group.end = textEnd
+			group.text += "\n" + text
+		case fullLine:
+			group = &markdownSpan{start: textStart, end: textEnd, text: text, prefix: indent + marker + separator}
+			spans = append(spans, group)
+		default:
+			group = nil
+			spans = append(spans, &markdownSpan{start: textStart, end: textEnd, text: text, singleLine: true})
+		}
+		lineStart = lineEnd + 1
+	}
+
+	return spans
+}
+
+// notebookFindComment returns the position of the comment marker in line,
+// or -1, and the quote of a multi-line string still open at its end. Python
+// triple quoted strings may span lines, other strings end with their line.
+func notebookFindComment(line, marker, quote string) (int, string) {
+	for i := 0; i < len(line); i++ {
+		if quote != "" {
+			switch {
+			case line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], quote):
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], marker):
+			return i, ""
+		case c == '"' || c == '\'' || c == '`':
+			quote = string(c)
+			if marker == "#" && strings.HasPrefix(line[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+		}
+	}
+
+	if len(quote) == 3 {
+		return -1, quote
+	}
+	return -1, ""
+}
@@ -0,0 +1,129 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": [
+    "# Data *analysis*\n",
+    "\n",
+    "Load the ` + "`csv`" + ` file."
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "metadata": {"tags": ["setup"]},
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": ["# not a comment\n"]
+    }
+   ],
+   "source": [
+    "# Load the data\n",
+    "# from disk\n",
+    "df = load(\"a # b\")  # read it\n",
+    "s = \"\"\"\n",
+    "# inside a string\n",
+    "\"\"\"\n",
+    "# noqa: E501"
+   ]
+  },
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": "Done."
+  }
+ ],
+ "metadata": {
+  "kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"},
+  "language_info": {"name": "python"}
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestNotebookProcessor(t *testing.T) {
+	ctx := context.Background()
+
+	opts := ProcessorOptions{Metadata: map[string]interface{}{
+		"notebook_translate_code_comments": true,
+	}}
+	processor, err := NewNotebookProcessor(opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(ctx, strings.NewReader(testNotebook))
+	if err != nil {
+		t.Fatalf("Failed to parse notebook: %v", err)
+	}
+
+	translations := map[string]string{
+		"Data <r1>analysis</r1>":   "数据<r1>分析</r1>",
+		"Load the <x1/> file.":     "加载 <x1/> 文件。",
+		"Load the data\nfrom disk": "从磁盘\n加载数据\n（示例）",
+		"read it":                  "读取\n它",
+		"Done.":                    "完成。",
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		sources = append(sources, block.GetContent())
+		translated, ok := translations[block.GetContent()]
+		if !ok {
+			t.Errorf("Unexpected block %q", block.GetContent())
+			continue
+		}
+		block.SetContent(translated)
+	}
+	if len(sources) != len(translations) {
+		t.Fatalf("Expected %d blocks, got %q", len(translations), sources)
+	}
+
+	renderer, _ := NewNotebookProcessor(opts, zap.NewNop())
+	var output bytes.Buffer
+	if err := renderer.Render(ctx, doc, &output); err != nil {
+		t.Fatalf("Failed to render notebook: %v", err)
+	}
+
+	expected := strings.NewReplacer(
+		`    "# Data *analysis*\n",
+    "\n",
+    "Load the `+"`csv`"+` file."`, `    "# 数据*分析*\n",
+    "\n",
+    "加载 `+"`csv`"+` 文件。"`,
+		`    "# Load the data\n",
+    "# from disk\n",
+    "df = load(\"a # b\")  # read it\n",`, `    "# 从磁盘\n",
+    "# 加载数据\n",
+    "# （示例）\n",
+    "df = load(\"a # b\")  # 读取 它\n",`,
+		`"source": "Done."`, `"source": "完成。"`,
+	).Replace(testNotebook)
+	if output.String() != expected {
+		t.Errorf("Expected rendered notebook:\n%s\ngot:\n%s", expected, output.String())
+	}
+
+	// Code comments are left alone unless enabled
+	processor, _ = NewNotebookProcessor(ProcessorOptions{}, zap.NewNop())
+	doc, err = processor.Parse(ctx, strings.NewReader(testNotebook))
+	if err != nil {
+		t.Fatalf("Failed to parse notebook: %v", err)
+	}
+	if len(doc.Blocks) != 3 {
+		t.Errorf("Expected 3 Markdown blocks, got %d", len(doc.Blocks))
+	}
+}
@@ -0,0 +1,319 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+const (
+	odfMimetypeEntry = "mimetype"
+	odfContentPart   = "content.xml"
+	odfStylesPart    = "styles.xml"
+)
+
+// OdfProcessor processes OpenDocument text (ODT) and presentation (ODP) documents
+type OdfProcessor struct {
+	opts      ProcessorOptions
+	logger    *zap.Logger
+	format    Format
+	extractor *OdfTextExtractor
+	protector pkgdoc.ContentProtector
+}
+
+// NewOdfProcessor creates a new OpenDocument processor for the given format
+func NewOdfProcessor(opts ProcessorOptions, logger *zap.Logger, format Format) (*OdfProcessor, error) {
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	return &OdfProcessor{
+		opts:      opts,
+		logger:    logger,
+		format:    format,
+		extractor: NewOdfTextExtractor(logger),
+		protector: pkgdoc.GetProtectorForFormat("text"),
+	}, nil
+}
+
+// Parse parses an ODT/ODP file into a Document
+func (p *OdfProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p.format, err)
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("%s-%d", p.format, time.Now().Unix()),
+		Format: p.format,
+		Metadata: DocumentMetadata{
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	parts := make(map[string]*OdfPart)
+	foundContent := false
+
+	// Headers and footers come first, as they do on the printed page
+	for _, name := range []string{odfStylesPart, odfContentPart} {
+		file := findZipFile(zipReader, name)
+		if file == nil {
+			continue
+		}
+		foundContent = foundContent || name == odfContentPart
+
+		partData, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		part, err := p.extractor.ScanPart(name, partData)
+		if err != nil {
+			return nil, err
+		}
+		parts[name] = part
+
+		for paraIndex, para := range part.Paragraphs {
+			text := p.extractor.ExtractParagraphText(para)
+			if text == "" {
+				continue
+			}
+
+			blockType := BlockTypeParagraph
+			if para.Heading {
+				blockType = BlockTypeHeading
+			}
+			doc.Blocks = append(doc.Blocks, &BaseBlock{
+				Type:         blockType,
+				Content:      text,
+				Translatable: true,
+				Metadata: BlockMetadata{
+					Level: para.Level,
+					Attributes: map[string]interface{}{
+						"odfPart":      name,
+						"odfParagraph": paraIndex,
+						"odfSource":    text,
+					},
+				},
+			})
+		}
+	}
+
+	if !foundContent {
+		return nil, fmt.Errorf("%s not found, not a valid OpenDocument file", odfContentPart)
+	}
+
+	// Store ODF data for later rendering
+	doc.Metadata.CustomFields["odfData"] = data
+	doc.Metadata.CustomFields["odfParts"] = parts
+
+	p.logger.Debug("parsed OpenDocument",
+		zap.String("format", string(p.format)),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// Process processes the document through translation
+func (p *OdfProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		translatedText, err := translator(ctx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate block",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+
+		block.SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// Render renders the document back to ODT/ODP, writing the mimetype entry
+// first and uncompressed as the OpenDocument packaging rules require
+func (p *OdfProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	odfData, ok := doc.Metadata.CustomFields["odfData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original %s data not found in document metadata", p.format)
+	}
+	parts, ok := doc.Metadata.CustomFields["odfParts"].(map[string]*OdfPart)
+	if !ok {
+		return fmt.Errorf("%s part index not found in document metadata", p.format)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(odfData), int64(len(odfData)))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", p.format, err)
+	}
+
+	// Collect translated text per part and paragraph
+	translations := make(map[string]map[int]string)
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		name, ok1 := attrs["odfPart"].(string)
+		paraIndex, ok2 := attrs["odfParagraph"].(int)
+		if !ok1 || !ok2 {
+			continue
+		}
+		if source, _ := attrs["odfSource"].(string); block.GetContent() == source {
+			continue
+		}
+		if translations[name] == nil {
+			translations[name] = make(map[int]string)
+		}
+		translations[name][paraIndex] = block.GetContent()
+	}
+
+	zipWriter := zip.NewWriter(output)
+
+	mimetype := []byte("application/vnd.oasis.opendocument.text")
+	if p.format == FormatODP {
+		mimetype = []byte("application/vnd.oasis.opendocument.presentation")
+	}
+	if file := findZipFile(zipReader, odfMimetypeEntry); file != nil {
+		if mimetype, err = readZipFile(file); err != nil {
+			return fmt.Errorf("failed to read %s: %w", odfMimetypeEntry, err)
+		}
+	}
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: odfMimetypeEntry, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", odfMimetypeEntry, err)
+	}
+	if _, err := writer.Write(mimetype); err != nil {
+		return fmt.Errorf("failed to write %s: %w", odfMimetypeEntry, err)
+	}
+
+	for _, file := range zipReader.File {
+		if file.Name == odfMimetypeEntry {
+			continue
+		}
+
+		part, hasPart := parts[file.Name]
+		if !hasPart || len(translations[file.Name]) == 0 {
+			if err := zipWriter.Copy(file); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		original, err := readZipFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		renderer := &odfPartRenderer{
+			extractor:    p.extractor,
+			part:         part,
+			data:         original,
+			translations: translations[file.Name],
+			rendered:     make(map[int]string),
+		}
+		if err := writeZipEntry(zipWriter, file, renderer.renderRange(0, int64(len(original)))); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// GetFormat returns the format type
+func (p *OdfProcessor) GetFormat() Format {
+	return p.format
+}
+
+// ProtectContent protects inline span tags and common patterns
+func (p *OdfProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	for _, pattern := range inlineRunTagProtectPatterns {
+		text = pp.ProtectPattern(text, pattern)
+	}
+
+	return p.protector.ProtectContent(text, pp)
+}
+
+// odfPartRenderer renders a part with translated paragraphs. Paragraphs can
+// nest (footnotes, text boxes), so opaque elements are rendered recursively.
+type odfPartRenderer struct {
+	extractor    *OdfTextExtractor
+	part         *OdfPart
+	data         []byte
+	translations map[int]string
+	rendered     map[int]string
+}
+
+// renderRange returns data[start:end] with the outermost translated
+// paragraphs inside the range replaced
+func (r *odfPartRenderer) renderRange(start, end int64) []byte {
+	var replacements []byteRangeReplacement
+	coveredUntil := int64(-1)
+
+	for paraIndex, para := range r.part.Paragraphs {
+		if para.Start < start || para.End > end || para.Start < coveredUntil {
+			continue
+		}
+		if _, ok := r.translations[paraIndex]; !ok {
+			continue
+		}
+		replacements = append(replacements, byteRangeReplacement{
+			start: para.Start - start,
+			end:   para.End - start,
+			text:  r.renderParagraph(paraIndex),
+		})
+		coveredUntil = para.End
+	}
+
+	fragment := append([]byte{}, r.data[start:end]...)
+	return applyByteRangeReplacements(fragment, replacements)
+}
+
+// renderParagraph renders a translated paragraph
+func (r *odfPartRenderer) renderParagraph(paraIndex int) string {
+	if rendered, ok := r.rendered[paraIndex]; ok {
+		return rendered
+	}
+
+	para := r.part.Paragraphs[paraIndex]
+	rendered := r.extractor.BuildParagraph(para, r.translations[paraIndex], func(node *OdfInline) string {
+		return string(r.renderRange(node.Start, node.End))
+	})
+	r.rendered[paraIndex] = rendered
+	return rendered
+}
+
+// findZipFile returns the zip entry with the given name
+func findZipFile(zipReader *zip.Reader, name string) *zip.File {
+	for _, file := range zipReader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
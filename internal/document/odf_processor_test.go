@@ -0,0 +1,132 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testOdtContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text>` +
+	`<text:h text:style-name="H1" text:outline-level="1">Introduction</text:h>` +
+	`<text:p text:style-name="P1">Hello <text:span text:style-name="T1">bold</text:span> world<text:note text:id="n1" text:note-class="footnote"><text:note-citation>1</text:note-citation><text:note-body><text:p>A footnote</text:p></text:note-body></text:note></text:p>` +
+	`<text:p text:style-name="P2">12345</text:p>` +
+	`</office:text></office:body></office:document-content>`
+
+const testOdtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:styles><style:style style:name="P1"/></office:styles><office:master-styles><style:master-page style:name="Standard"><style:header><text:p>Company report</text:p></style:header><style:footer><text:p>Page <text:page-number>1</text:page-number></text:p></style:footer></style:master-page></office:master-styles></office:document-styles>`
+
+// buildTestOdt creates a minimal ODT archive in memory
+func buildTestOdt(t *testing.T) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	files := []struct {
+		name    string
+		content string
+		method  uint16
+	}{
+		{"mimetype", "application/vnd.oasis.opendocument.text", zip.Store},
+		{"content.xml", testOdtContent, zip.Deflate},
+		{"styles.xml", testOdtStyles, zip.Deflate},
+		{"META-INF/manifest.xml", `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"/>`, zip.Deflate},
+	}
+	for _, file := range files {
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: file.name, Method: file.method})
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestOdfProcessor(t *testing.T) {
+	ctx := context.Background()
+
+	processor, err := NewOdfProcessor(ProcessorOptions{}, zap.NewNop(), FormatODT)
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(ctx, bytes.NewReader(buildTestOdt(t)))
+	if err != nil {
+		t.Fatalf("Failed to parse ODT: %v", err)
+	}
+
+	translations := map[string]string{
+		"Company report":                 "公司报告",
+		"Page <x1/>":                     "第 <x1/> 页",
+		"Introduction":                   "简介",
+		"Hello <r1>bold</r1> world<x2/>": "你好<r1>粗体</r1>世界<x2/>",
+		"A footnote":                     "一条脚注",
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		sources = append(sources, block.GetContent())
+		if translated, ok := translations[block.GetContent()]; ok {
+			block.SetContent(translated)
+		}
+	}
+
+	expected := []string{"Company report", "Page <x1/>", "Introduction", "Hello <r1>bold</r1> world<x2/>", "A footnote", "12345"}
+	if strings.Join(sources, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Expected blocks %q, got %q", expected, sources)
+	}
+	if doc.Blocks[2].GetType() != BlockTypeHeading || doc.Blocks[2].GetMetadata().Level != 1 {
+		t.Errorf("Expected level 1 heading block, got %v", doc.Blocks[2].GetType())
+	}
+
+	renderer, _ := NewOdfProcessor(ProcessorOptions{}, zap.NewNop(), FormatODT)
+	var output bytes.Buffer
+	if err := renderer.Render(ctx, doc, &output); err != nil {
+		t.Fatalf("Failed to render ODT: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open rendered archive: %v", err)
+	}
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("Expected stored mimetype as first entry, got %s (method %d)", first.Name, first.Method)
+	}
+
+	content := readTestZipPart(t, output.Bytes(), "content.xml")
+	for _, want := range []string{
+		`<text:h text:style-name="H1" text:outline-level="1">简介</text:h>`,
+		`<text:p text:style-name="P1">你好<text:span text:style-name="T1">粗体</text:span>世界<text:note text:id="n1" text:note-class="footnote"><text:note-citation>1</text:note-citation><text:note-body><text:p>一条脚注</text:p></text:note-body></text:note></text:p>`,
+		`<text:p text:style-name="P2">12345</text:p>`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected content to contain %q, got %s", want, content)
+		}
+	}
+
+	styles := readTestZipPart(t, output.Bytes(), "styles.xml")
+	for _, want := range []string{
+		`<style:header><text:p>公司报告</text:p></style:header>`,
+		`<style:footer><text:p>第 <text:page-number>1</text:page-number> 页</text:p></style:footer>`,
+	} {
+		if !strings.Contains(styles, want) {
+			t.Errorf("Expected styles to contain %q, got %s", want, styles)
+		}
+	}
+}
+
+func TestOdfBuildText(t *testing.T) {
+	got := buildOdfText("a  b\tc\nd & e")
+	expected := `a <text:s/>b<text:tab/>c<text:line-break/>d &amp; e`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,404 @@
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// OdfInlineKind distinguishes the children of an ODF paragraph
+type OdfInlineKind int
+
+const (
+	// OdfInlineText is character data, including expanded text:s/text:tab/text:line-break
+	OdfInlineText OdfInlineKind = iota
+	// OdfInlineGroup is a text:span or text:a wrapping translatable content
+	OdfInlineGroup
+	// OdfInlineOpaque is an element kept verbatim (notes, frames, fields, bookmarks)
+	OdfInlineOpaque
+)
+
+// OdfInline is a node of a paragraph's inline tree
+type OdfInline struct {
+	Kind     OdfInlineKind
+	ID       int
+	Text     string
+	StartTag string
+	EndTag   string
+	Start    int64
+	End      int64
+	Children []*OdfInline
+}
+
+// OdfParagraph is a text:p or text:h element and its byte range in the part
+type OdfParagraph struct {
+	Start    int64
+	End      int64
+	StartTag string
+	EndTag   string
+	Heading  bool
+	Level    int
+	Children []*OdfInline
+}
+
+// OdfPart is a scanned content.xml or styles.xml part
+type OdfPart struct {
+	Name       string
+	Paragraphs []*OdfParagraph
+}
+
+// odfHeaderFooterElements are the master page children translated from styles.xml
+var odfHeaderFooterElements = map[string]bool{
+	"header": true, "header-left": true, "header-first": true,
+	"footer": true, "footer-left": true, "footer-first": true,
+}
+
+// odfInlineTagPattern tokenises translated text into nested span tags and opaque markers
+var odfInlineTagPattern = regexp.MustCompile(`<r(\d+)>|</r(\d+)>|<x(\d+)\s*/>`)
+
+// odfWhitespacePattern matches whitespace runs, which ODF collapses to one space
+var odfWhitespacePattern = regexp.MustCompile(`[ \t\r\n]+`)
+
+// OdfTextExtractor handles text extraction and manipulation for ODF parts
+type OdfTextExtractor struct {
+	logger *zap.Logger
+}
+
+// NewOdfTextExtractor creates a new ODF text extractor
+func NewOdfTextExtractor(logger *zap.Logger) *OdfTextExtractor {
+	return &OdfTextExtractor{logger: logger}
+}
+
+// odfScanFrame tracks an open element while scanning a part
+type odfScanFrame struct {
+	kind  string // paragraph, group, opaque, scope or other
+	start int64
+	para  *OdfParagraph
+	node  *OdfInline
+}
+
+// ScanPart collects the paragraphs of a part. In content.xml every paragraph
+// under office:body is collected, in styles.xml only those of headers and footers.
+func (e *OdfTextExtractor) ScanPart(name string, data []byte) (*OdfPart, error) {
+	part := &OdfPart{Name: name}
+	isStyles := strings.HasSuffix(name, "styles.xml")
+
+	var stack []*odfScanFrame
+	scopeDepth := 0
+
+	// container returns the innermost paragraph, group or opaque frame
+	container := func() *odfScanFrame {
+		for i := len(stack) - 1; i >= 0; i-- {
+			switch stack[i].kind {
+			case "paragraph", "group", "opaque":
+				return stack[i]
+			}
+		}
+		return nil
+	}
+
+	appendChild := func(frame *odfScanFrame, node *OdfInline) {
+		if frame.para != nil {
+			frame.para.Children = append(frame.para.Children, node)
+		} else {
+			frame.node.Children = append(frame.node.Children, node)
+		}
+	}
+
+	appendText := func(frame *odfScanFrame, text string) {
+		var children *[]*OdfInline
+		if frame.para != nil {
+			children = &frame.para.Children
+		} else {
+			children = &frame.node.Children
+		}
+		if n := len(*children); n > 0 && (*children)[n-1].Kind == OdfInlineText {
+			(*children)[n-1].Text += text
+			return
+		}
+		*children = append(*children, &OdfInline{Kind: OdfInlineText, Text: text})
+	}
+
+	err := walkXML(data, func(tok xml.Token, s, end int64, depth int) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			tag := qualifiedName(t.Name)
+			frame := &odfScanFrame{kind: "other", start: s}
+			parent := container()
+
+			switch {
+			case (tag == "office:body" && !isStyles) || (isStyles && t.Name.Space == "style" && odfHeaderFooterElements[t.Name.Local]):
+				frame.kind = "scope"
+				scopeDepth++
+			case scopeDepth > 0 && (tag == "text:p" || tag == "text:h") && (parent == nil || parent.kind == "opaque"):
+				frame.kind = "paragraph"
+				frame.para = &OdfParagraph{Start: s, StartTag: string(data[s:end]), Heading: tag == "text:h"}
+				if frame.para.Heading {
+					frame.para.Level, _ = strconv.Atoi(odfAttrValue(t, "outline-level"))
+					if frame.para.Level == 0 {
+						frame.para.Level = 1
+					}
+				}
+			case parent == nil || parent.kind == "opaque":
+				// Outside paragraphs, or inside an opaque element
+			case tag == "text:span" || tag == "text:a":
+				frame.kind = "group"
+				frame.node = &OdfInline{Kind: OdfInlineGroup, StartTag: string(data[s:end]), Start: s}
+				appendChild(parent, frame.node)
+			case tag == "text:s":
+				count, _ := strconv.Atoi(odfAttrValue(t, "c"))
+				if count < 1 {
+					count = 1
+				}
+				appendText(parent, strings.Repeat(" ", count))
+			case tag == "text:tab":
+				appendText(parent, "\t")
+			case tag == "text:line-break":
+				appendText(parent, "\n")
+			default:
+				frame.kind = "opaque"
+				frame.node = &OdfInline{Kind: OdfInlineOpaque, Start: s}
+				appendChild(parent, frame.node)
+			}
+			stack = append(stack, frame)
+
+		case xml.CharData:
+			if frame := container(); frame != nil && frame.kind != "opaque" {
+				text := odfWhitespacePattern.ReplaceAllString(string(t), " ")
+				if text != "" {
+					appendText(frame, text)
+				}
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return fmt.Errorf("unbalanced element %s", qualifiedName(t.Name))
+			}
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			switch frame.kind {
+			case "scope":
+				scopeDepth--
+			case "paragraph":
+				frame.para.End = end
+				frame.para.EndTag = string(data[s:end])
+				if s == end {
+					// Self-closing paragraph holds no text
+					return nil
+				}
+				part.Paragraphs = append(part.Paragraphs, frame.para)
+			case "group":
+				frame.node.End = end
+				frame.node.EndTag = string(data[s:end])
+				if s == end {
+					// Empty span, keep it verbatim
+					frame.node.Kind = OdfInlineOpaque
+					frame.node.StartTag = ""
+				}
+			case "opaque":
+				frame.node.End = end
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", name, err)
+	}
+
+	// Nested paragraphs (notes, text boxes) close before their parents
+	sort.Slice(part.Paragraphs, func(i, j int) bool {
+		return part.Paragraphs[i].Start < part.Paragraphs[j].Start
+	})
+
+	for _, para := range part.Paragraphs {
+		assignOdfInlineIDs(para.Children, new(int))
+	}
+
+	return part, nil
+}
+
+// assignOdfInlineIDs numbers groups and opaque nodes in document order
+func assignOdfInlineIDs(nodes []*OdfInline, counter *int) {
+	for _, node := range nodes {
+		if node.Kind == OdfInlineText {
+			continue
+		}
+		*counter++
+		node.ID = *counter
+		assignOdfInlineIDs(node.Children, counter)
+	}
+}
+
+// ExtractParagraphText returns the text of a paragraph for translation.
+// Spans become <rN>…</rN> and opaque elements <xN/>, like placeholders in
+// HTMLPlaceholderManager; a paragraph without text yields "".
+func (e *OdfTextExtractor) ExtractParagraphText(para *OdfParagraph) string {
+	if strings.TrimSpace(odfPlainText(para.Children)) == "" {
+		return ""
+	}
+
+	var builder strings.Builder
+	writeOdfInlineText(&builder, para.Children)
+	return strings.TrimSpace(builder.String())
+}
+
+// writeOdfInlineText writes nodes with inline tags
+func writeOdfInlineText(builder *strings.Builder, nodes []*OdfInline) {
+	for _, node := range nodes {
+		switch node.Kind {
+		case OdfInlineText:
+			builder.WriteString(node.Text)
+		case OdfInlineGroup:
+			fmt.Fprintf(builder, "<r%d>", node.ID)
+			writeOdfInlineText(builder, node.Children)
+			fmt.Fprintf(builder, "</r%d>", node.ID)
+		case OdfInlineOpaque:
+			fmt.Fprintf(builder, "<x%d/>", node.ID)
+		}
+	}
+}
+
+// odfPlainText returns the text of nodes without markup
+func odfPlainText(nodes []*OdfInline) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		switch node.Kind {
+		case OdfInlineText:
+			builder.WriteString(node.Text)
+		case OdfInlineGroup:
+			builder.WriteString(odfPlainText(node.Children))
+		}
+	}
+	return builder.String()
+}
+
+// BuildParagraph renders a paragraph with translated text. renderOpaque returns
+// the XML of an opaque node, allowing nested paragraphs inside it to be translated.
+func (e *OdfTextExtractor) BuildParagraph(para *OdfParagraph, translatedText string, renderOpaque func(node *OdfInline) string) string {
+	nodes := make(map[int]*OdfInline)
+	var index func([]*OdfInline)
+	index = func(children []*OdfInline) {
+		for _, node := range children {
+			if node.Kind != OdfInlineText {
+				nodes[node.ID] = node
+				index(node.Children)
+			}
+		}
+	}
+	index(para.Children)
+
+	var builder strings.Builder
+	builder.WriteString(para.StartTag)
+
+	used := make(map[int]bool)
+	var open []*OdfInline
+	cursor := 0
+
+	for _, loc := range odfInlineTagPattern.FindAllStringSubmatchIndex(translatedText, -1) {
+		builder.WriteString(buildOdfText(translatedText[cursor:loc[0]]))
+		cursor = loc[1]
+
+		switch {
+		case loc[2] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[2]:loc[3]])
+			if node, ok := nodes[id]; ok && node.Kind == OdfInlineGroup {
+				builder.WriteString(node.StartTag)
+				open = append(open, node)
+				used[id] = true
+			}
+		case loc[4] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[4]:loc[5]])
+			// Close the matching group together with any left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i].ID == id {
+					for j := len(open) - 1; j >= i; j-- {
+						builder.WriteString(open[j].EndTag)
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case loc[6] >= 0:
+			id, _ := strconv.Atoi(translatedText[loc[6]:loc[7]])
+			if node, ok := nodes[id]; ok && node.Kind == OdfInlineOpaque && !used[id] {
+				builder.WriteString(renderOpaque(node))
+				used[id] = true
+			}
+		}
+	}
+	builder.WriteString(buildOdfText(translatedText[cursor:]))
+
+	for i := len(open) - 1; i >= 0; i-- {
+		builder.WriteString(open[i].EndTag)
+	}
+
+	// Notes, frames and bookmarks must survive even if the translation dropped them
+	ids := make([]int, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if node := nodes[id]; node.Kind == OdfInlineOpaque && !used[id] {
+			builder.WriteString(renderOpaque(node))
+		}
+	}
+
+	builder.WriteString(para.EndTag)
+	return builder.String()
+}
+
+// buildOdfText escapes text and encodes whitespace ODF would otherwise collapse
+func buildOdfText(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	var builder strings.Builder
+	var segment strings.Builder
+	flushSegment := func() {
+		_ = xml.EscapeText(&builder, []byte(segment.String()))
+		segment.Reset()
+	}
+
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			flushSegment()
+			builder.WriteString("<text:line-break/>")
+		}
+		for j, field := range strings.Split(part, "\t") {
+			if j > 0 {
+				flushSegment()
+				builder.WriteString("<text:tab/>")
+			}
+			for k, word := range strings.Split(field, "  ") {
+				// Every double space keeps one literal space plus one text:s
+				if k > 0 {
+					segment.WriteString(" ")
+					flushSegment()
+					builder.WriteString("<text:s/>")
+				}
+				segment.WriteString(word)
+			}
+		}
+	}
+	flushSegment()
+
+	return builder.String()
+}
+
+// odfAttrValue returns the value of an attribute by local name, ignoring its prefix
+func odfAttrValue(element xml.StartElement, local string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+)
+
+var (
+	// inlineRunTagPattern matches the inline run tags used in extracted text:
+	// <rN>…</rN> wraps a formatting run, <xN/> marks an element kept verbatim
+	inlineRunTagPattern = regexp.MustCompile(`(?s)<r(\d+)>(.*?)</r\d+>|<x(\d+)\s*/>`)
+
+	// inlineRunTagProtectPatterns protect the inline run tags during translation
+	inlineRunTagProtectPatterns = []string{
+		`</?r\d+>`,
+		`<x\d+\s*/>`,
+	}
+)
+
+// byteRangeReplacement replaces a byte range of an XML part
+type byteRangeReplacement struct {
+	start int64
+	end   int64
+	text  string
+}
+
+// applyByteRangeReplacements splices replacements into the original bytes,
+// leaving everything outside the replaced ranges byte-identical
+func applyByteRangeReplacements(original []byte, replacements []byteRangeReplacement) []byte {
+	// Apply from the end so earlier offsets stay valid
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+
+	result := original
+	for _, r := range replacements {
+		var buffer bytes.Buffer
+		buffer.Grow(len(result) + len(r.text))
+		buffer.Write(result[:r.start])
+		buffer.WriteString(r.text)
+		buffer.Write(result[r.end:])
+		result = buffer.Bytes()
+	}
+
+	return result
+}
+
+// readZipFile reads the full content of a zip entry
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// writeZipEntry writes modified content under the original entry's name and method
+func writeZipEntry(zipWriter *zip.Writer, file *zip.File, content []byte) error {
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     file.Name,
+		Method:   file.Method,
+		Modified: file.Modified,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", file.Name, err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// xmlTokenHandler receives raw tokens with their byte ranges
+type xmlTokenHandler func(tok xml.Token, start, end int64, depth int) error
+
+// walkXML walks raw tokens of a part, reporting byte offsets and element depth
+func walkXML(data []byte, handler xmlTokenHandler) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		start := decoder.InputOffset()
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		end := decoder.InputOffset()
+
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+			if err := handler(tok, start, end, depth); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if err := handler(tok, start, end, depth); err != nil {
+				return err
+			}
+			depth--
+		default:
+			if err := handler(tok, start, end, depth); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// qualifiedName returns prefix:local for a raw token name
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// attrValue returns the value of an unprefixed attribute
+func attrValue(element xml.StartElement, local string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == local && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
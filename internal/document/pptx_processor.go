@@ -0,0 +1,349 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+// PPTX overflow handling modes
+const (
+	PptxOverflowNone   = "none"   // leave text bodies untouched
+	PptxOverflowShrink = "shrink" // shrink text on overflow (normAutofit)
+	PptxOverflowResize = "resize" // resize shape to fit text (spAutoFit)
+)
+
+// pptxPartPatterns maps part kinds to the zip entries that hold translatable text
+var pptxPartPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{kind: "slide", pattern: regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)},
+	{kind: "notes", pattern: regexp.MustCompile(`^ppt/notesSlides/notesSlide(\d+)\.xml$`)},
+	{kind: "layout", pattern: regexp.MustCompile(`^ppt/slideLayouts/slideLayout(\d+)\.xml$`)},
+	{kind: "chart", pattern: regexp.MustCompile(`^ppt/charts/chart(\d+)\.xml$`)},
+}
+
+// PptxOverflowOptions controls how grown text bodies are fitted back into shapes
+type PptxOverflowOptions struct {
+	Mode           string  // none, shrink or resize
+	ThresholdRatio float64 // apply when translated width / source width exceeds this
+	MinFontScale   float64 // lower bound for shrink mode, 0-1
+}
+
+// PptxProcessor processes PPTX format documents
+type PptxProcessor struct {
+	opts      ProcessorOptions
+	logger    *zap.Logger
+	extractor *PptxTextExtractor
+	protector pkgdoc.ContentProtector
+	overflow  PptxOverflowOptions
+}
+
+// NewPptxProcessor creates a new PPTX processor
+func NewPptxProcessor(opts ProcessorOptions, logger *zap.Logger) (*PptxProcessor, error) {
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	return &PptxProcessor{
+		opts:      opts,
+		logger:    logger,
+		extractor: NewPptxTextExtractor(logger),
+		protector: pkgdoc.GetProtectorForFormat("text"),
+		overflow:  getPptxOverflowFromOptions(opts),
+	}, nil
+}
+
+// pptxEntry is a zip entry selected for translation
+type pptxEntry struct {
+	file   *zip.File
+	kind   string
+	order  int
+	number int
+}
+
+// Parse parses a PPTX file into a Document
+func (p *PptxProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PPTX: %w", err)
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("pptx-%d", time.Now().Unix()),
+		Format: FormatPPTX,
+		Metadata: DocumentMetadata{
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	var parts []*PptxPart
+	for _, entry := range p.selectEntries(zipReader) {
+		partData, err := readZipFile(entry.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.file.Name, err)
+		}
+
+		part, err := p.extractor.ScanPart(entry.file.Name, entry.kind, partData)
+		if err != nil {
+			return nil, err
+		}
+		partIndex := len(parts)
+		parts = append(parts, part)
+
+		for paraIndex, para := range part.Paragraphs {
+			text := p.extractor.ExtractParagraphText(para)
+			if text == "" {
+				continue
+			}
+
+			blockType := BlockTypeParagraph
+			level := 0
+			if para.Placeholder == "title" || para.Placeholder == "ctrTitle" || entry.kind == "chart" {
+				blockType = BlockTypeHeading
+				level = 1
+			}
+
+			doc.Blocks = append(doc.Blocks, &BaseBlock{
+				Type:         blockType,
+				Content:      text,
+				Translatable: true,
+				Metadata: BlockMetadata{
+					Level: level,
+					Attributes: map[string]interface{}{
+						"pptxPart":      partIndex,
+						"pptxParagraph": paraIndex,
+						"pptxKind":      entry.kind,
+						"pptxSource":    text,
+					},
+				},
+			})
+		}
+	}
+
+	// Store PPTX data for later rendering
+	doc.Metadata.CustomFields["pptxData"] = data
+	doc.Metadata.CustomFields["pptxParts"] = parts
+
+	p.logger.Debug("parsed PPTX document",
+		zap.Int("parts", len(parts)),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// Process processes the document through translation
+func (p *PptxProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		translatedText, err := translator(ctx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate block",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+
+		block.SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// Render renders the document back to PPTX format
+func (p *PptxProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	pptxData, ok := doc.Metadata.CustomFields["pptxData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original PPTX data not found in document metadata")
+	}
+	parts, ok := doc.Metadata.CustomFields["pptxParts"].([]*PptxPart)
+	if !ok {
+		return fmt.Errorf("PPTX part index not found in document metadata")
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(pptxData), int64(len(pptxData)))
+	if err != nil {
+		return fmt.Errorf("failed to read PPTX: %w", err)
+	}
+
+	// Collect translated text per part and paragraph
+	translations := make(map[string]map[int]pptxTranslation)
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		partIndex, ok1 := attrs["pptxPart"].(int)
+		paraIndex, ok2 := attrs["pptxParagraph"].(int)
+		if !ok1 || !ok2 || partIndex < 0 || partIndex >= len(parts) {
+			continue
+		}
+		source, _ := attrs["pptxSource"].(string)
+		if block.GetContent() == source {
+			continue
+		}
+
+		name := parts[partIndex].Name
+		if translations[name] == nil {
+			translations[name] = make(map[int]pptxTranslation)
+		}
+		translations[name][paraIndex] = pptxTranslation{source: source, text: block.GetContent()}
+	}
+
+	partsByName := make(map[string]*PptxPart, len(parts))
+	for _, part := range parts {
+		partsByName[part.Name] = part
+	}
+
+	zipWriter := zip.NewWriter(output)
+	for _, file := range zipReader.File {
+		part, hasPart := partsByName[file.Name]
+		if !hasPart || len(translations[file.Name]) == 0 {
+			if err := zipWriter.Copy(file); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		original, err := readZipFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		updated := p.renderPart(part, original, translations[file.Name])
+
+		if err := writeZipEntry(zipWriter, file, updated); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// GetFormat returns the format type
+func (p *PptxProcessor) GetFormat() Format {
+	return FormatPPTX
+}
+
+// ProtectContent protects inline run tags and common patterns
+func (p *PptxProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	for _, pattern := range inlineRunTagProtectPatterns {
+		text = pp.ProtectPattern(text, pattern)
+	}
+
+	return p.protector.ProtectContent(text, pp)
+}
+
+// pptxTranslation pairs a paragraph's source text with its translation
+type pptxTranslation struct {
+	source string
+	text   string
+}
+
+// renderPart splices translated paragraphs and fitted body properties into a part
+func (p *PptxProcessor) renderPart(part *PptxPart, original []byte, translated map[int]pptxTranslation) []byte {
+	var replacements []byteRangeReplacement
+	sourceWidth := make(map[int]int)
+	targetWidth := make(map[int]int)
+
+	for paraIndex, tr := range translated {
+		if paraIndex < 0 || paraIndex >= len(part.Paragraphs) {
+			continue
+		}
+		para := part.Paragraphs[paraIndex]
+		replacements = append(replacements, byteRangeReplacement{
+			start: para.Start,
+			end:   para.End,
+			text:  p.extractor.BuildParagraph(para, tr.text),
+		})
+
+		if para.BodyIndex >= 0 {
+			sourceWidth[para.BodyIndex] += textDisplayWidth(p.extractor.PlainText(tr.source))
+			targetWidth[para.BodyIndex] += textDisplayWidth(p.extractor.PlainText(tr.text))
+		}
+	}
+
+	if p.overflow.Mode != PptxOverflowNone && (part.Kind == "slide" || part.Kind == "layout") {
+		for bodyIndex, srcWidth := range sourceWidth {
+			body := part.Bodies[bodyIndex]
+			if srcWidth == 0 || body.BodyPr == "" {
+				continue
+			}
+			ratio := float64(targetWidth[bodyIndex]) / float64(srcWidth)
+			if ratio <= p.overflow.ThresholdRatio {
+				continue
+			}
+
+			p.logger.Debug("text body grew beyond threshold, applying autofit",
+				zap.String("part", part.Name),
+				zap.Float64("ratio", ratio),
+				zap.String("mode", p.overflow.Mode))
+
+			replacements = append(replacements, byteRangeReplacement{
+				start: body.Start,
+				end:   body.End,
+				text:  p.extractor.ApplyOverflowFit(body.BodyPr, p.overflow.Mode, ratio, p.overflow.MinFontScale),
+			})
+		}
+	}
+
+	return applyByteRangeReplacements(original, replacements)
+}
+
+// selectEntries returns translatable parts ordered by kind and number
+func (p *PptxProcessor) selectEntries(zipReader *zip.Reader) []pptxEntry {
+	var entries []pptxEntry
+	for _, file := range zipReader.File {
+		for order, candidate := range pptxPartPatterns {
+			match := candidate.pattern.FindStringSubmatch(file.Name)
+			if match == nil {
+				continue
+			}
+			number, _ := strconv.Atoi(match[1])
+			entries = append(entries, pptxEntry{
+				file:   file,
+				kind:   candidate.kind,
+				order:  order,
+				number: number,
+			})
+			break
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].order != entries[j].order {
+			return entries[i].order < entries[j].order
+		}
+		return entries[i].number < entries[j].number
+	})
+
+	return entries
+}
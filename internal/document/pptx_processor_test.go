@@ -0,0 +1,249 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testPptxSlide = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld><p:spTree>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title 1"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:txBody><a:bodyPr/><a:lstStyle/><a:p><a:r><a:rPr lang="en-US"/><a:t>Quarterly </a:t></a:r><a:r><a:rPr lang="en-US" dirty="0"/><a:t>Report</a:t></a:r></a:p></p:txBody></p:sp>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Content 2"/><p:cNvSpPr/><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr><p:txBody><a:bodyPr><a:normAutofit/></a:bodyPr><a:lstStyle/><a:p><a:pPr lvl="1"/><a:r><a:rPr lang="en-US"/><a:t>Revenue is </a:t></a:r><a:r><a:rPr lang="en-US" b="1"/><a:t>up</a:t></a:r><a:fld id="{1}" type="slidenum"><a:rPr lang="en-US"/><a:t>1</a:t></a:fld><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld></p:sld>`
+
+const testPptxNotes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:notes xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld><p:spTree><p:sp><p:txBody><a:bodyPr/><a:p><a:r><a:rPr lang="en-US"/><a:t>Speak slowly &amp; clearly</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:notes>`
+
+const testPptxLayout = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:sldLayout xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld><p:spTree>` +
+	`<p:sp><p:nvSpPr><p:nvPr><p:ph type="body"/></p:nvPr></p:nvSpPr><p:txBody><a:bodyPr/><a:p><a:r><a:rPr lang="en-US"/><a:t>Click to edit</a:t></a:r></a:p></p:txBody></p:sp>` +
+	`<p:sp><p:nvSpPr><p:nvPr/></p:nvSpPr><p:txBody><a:bodyPr/><a:p><a:r><a:rPr lang="en-US"/><a:t>Decoration</a:t></a:r></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld></p:sldLayout>`
+
+const testPptxChart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><c:chart><c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>Sales</a:t></a:r></a:p></c:rich></c:tx></c:title><c:plotArea><c:catAx><c:txPr><a:bodyPr/><a:p><a:r><a:t>Axis</a:t></a:r></a:p></c:txPr></c:catAx></c:plotArea></c:chart></c:chartSpace>`
+
+// buildTestPptx creates a minimal PPTX archive in memory
+func buildTestPptx(t *testing.T) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0"?><Types/>`},
+		{"ppt/slides/slide1.xml", testPptxSlide},
+		{"ppt/notesSlides/notesSlide1.xml", testPptxNotes},
+		{"ppt/slideLayouts/slideLayout1.xml", testPptxLayout},
+		{"ppt/charts/chart1.xml", testPptxChart},
+	}
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// readTestZipPart reads a part from a rendered zip-based document
+func readTestZipPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open rendered archive: %v", err)
+	}
+	for _, file := range reader.File {
+		if file.Name == name {
+			content, err := readZipFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", name, err)
+			}
+			return string(content)
+		}
+	}
+	t.Fatalf("Part %s not found", name)
+	return ""
+}
+
+func TestPptxProcessor(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	t.Run("ParseExtractsSlidesNotesLayoutsAndCharts", func(t *testing.T) {
+		processor, err := NewPptxProcessor(ProcessorOptions{}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create processor: %v", err)
+		}
+
+		doc, err := processor.Parse(ctx, bytes.NewReader(buildTestPptx(t)))
+		if err != nil {
+			t.Fatalf("Failed to parse PPTX: %v", err)
+		}
+
+		var contents []string
+		for _, block := range doc.Blocks {
+			contents = append(contents, block.GetContent())
+		}
+
+		expected := []string{
+			"Quarterly Report",
+			"<r1>Revenue is </r1><r2>up</r2><x3/>",
+			"Speak slowly & clearly",
+			"Click to edit",
+			"Sales",
+		}
+		if strings.Join(contents, "|") != strings.Join(expected, "|") {
+			t.Errorf("Expected blocks %q, got %q", expected, contents)
+		}
+
+		if doc.Blocks[0].GetType() != BlockTypeHeading {
+			t.Errorf("Expected title placeholder to be a heading, got %s", doc.Blocks[0].GetType())
+		}
+	})
+
+	t.Run("RenderPreservesFormatting", func(t *testing.T) {
+		processor, _ := NewPptxProcessor(ProcessorOptions{}, logger)
+		doc, err := processor.Parse(ctx, bytes.NewReader(buildTestPptx(t)))
+		if err != nil {
+			t.Fatalf("Failed to parse PPTX: %v", err)
+		}
+
+		translations := map[string]string{
+			"Quarterly Report":                     "季度报告",
+			"<r1>Revenue is </r1><r2>up</r2><x3/>": "<r1>收入</r1><r2>上升</r2><x3/>",
+			"Speak slowly & clearly":               "慢慢说 & 说清楚",
+			"Click to edit":                        "点击编辑",
+			"Sales":                                "销售",
+		}
+		_, err = processor.Process(ctx, doc, func(ctx context.Context, text string) (string, error) {
+			return translations[text], nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to process PPTX: %v", err)
+		}
+
+		// Render with a fresh processor, as the coordinator does
+		renderer, _ := NewPptxProcessor(ProcessorOptions{}, logger)
+		var output bytes.Buffer
+		if err := renderer.Render(ctx, doc, &output); err != nil {
+			t.Fatalf("Failed to render PPTX: %v", err)
+		}
+
+		slide := readTestZipPart(t, output.Bytes(), "ppt/slides/slide1.xml")
+		for _, want := range []string{
+			`<a:p><a:r><a:rPr lang="en-US"/><a:t>季度报告</a:t></a:r></a:p>`,
+			`<a:pPr lvl="1"/><a:r><a:rPr lang="en-US"/><a:t>收入</a:t></a:r><a:r><a:rPr lang="en-US" b="1"/><a:t>上升</a:t></a:r><a:fld id="{1}" type="slidenum">`,
+			`<a:endParaRPr lang="en-US"/></a:p>`,
+			`<a:bodyPr><a:normAutofit/></a:bodyPr>`,
+		} {
+			if !strings.Contains(slide, want) {
+				t.Errorf("Expected slide to contain %q, got %s", want, slide)
+			}
+		}
+
+		notes := readTestZipPart(t, output.Bytes(), "ppt/notesSlides/notesSlide1.xml")
+		if !strings.Contains(notes, "<a:t>慢慢说 &amp; 说清楚</a:t>") {
+			t.Errorf("Expected escaped notes text, got %s", notes)
+		}
+
+		layout := readTestZipPart(t, output.Bytes(), "ppt/slideLayouts/slideLayout1.xml")
+		if !strings.Contains(layout, "点击编辑") || !strings.Contains(layout, "Decoration") {
+			t.Errorf("Expected only placeholder text translated in layout, got %s", layout)
+		}
+
+		chart := readTestZipPart(t, output.Bytes(), "ppt/charts/chart1.xml")
+		if !strings.Contains(chart, "销售") || !strings.Contains(chart, "<a:t>Axis</a:t>") {
+			t.Errorf("Expected only chart title translated, got %s", chart)
+		}
+
+		contentTypes := readTestZipPart(t, output.Bytes(), "[Content_Types].xml")
+		if contentTypes != `<?xml version="1.0"?><Types/>` {
+			t.Errorf("Expected untouched parts to be copied verbatim, got %s", contentTypes)
+		}
+	})
+
+	t.Run("ShrinkOnOverflow", func(t *testing.T) {
+		opts := ProcessorOptions{Metadata: map[string]interface{}{
+			"pptx_overflow_mode":      PptxOverflowShrink,
+			"pptx_overflow_threshold": 1.2,
+		}}
+		processor, _ := NewPptxProcessor(opts, logger)
+		doc, err := processor.Parse(ctx, bytes.NewReader(buildTestPptx(t)))
+		if err != nil {
+			t.Fatalf("Failed to parse PPTX: %v", err)
+		}
+
+		doc.Blocks[0].SetContent("Vierteljährlicher Geschäftsbericht")
+
+		var output bytes.Buffer
+		if err := processor.Render(ctx, doc, &output); err != nil {
+			t.Fatalf("Failed to render PPTX: %v", err)
+		}
+
+		slide := readTestZipPart(t, output.Bytes(), "ppt/slides/slide1.xml")
+		if !strings.Contains(slide, `<a:bodyPr><a:normAutofit fontScale="`) {
+			t.Errorf("Expected normAutofit with font scale, got %s", slide)
+		}
+	})
+}
+
+func TestPptxTextExtractor(t *testing.T) {
+	extractor := NewPptxTextExtractor(zap.NewNop())
+
+	t.Run("MergeAdjacentRunsIgnoresProofingAttributes", func(t *testing.T) {
+		para := &PptxParagraph{
+			Runs: []PptxRun{
+				{Kind: PptxRunText, Properties: `<a:rPr lang="en-US"/>`, Text: "Hello "},
+				{Kind: PptxRunText, Properties: `<a:rPr lang="de-DE" dirty="0"/>`, Text: "world"},
+				{Kind: PptxRunText, Properties: `<a:rPr lang="en-US" i="1"/>`, Text: "!"},
+			},
+		}
+
+		merged := extractor.MergeAdjacentRuns(para)
+		if len(merged) != 2 {
+			t.Fatalf("Expected 2 merged runs, got %d", len(merged))
+		}
+		if merged[0].Text != "Hello world" {
+			t.Errorf("Expected %q, got %q", "Hello world", merged[0].Text)
+		}
+	})
+
+	t.Run("BuildParagraphFallsBackToProportionalSplit", func(t *testing.T) {
+		para := &PptxParagraph{
+			StartTag: "<a:p>",
+			Runs: []PptxRun{
+				{Kind: PptxRunText, Properties: `<a:rPr/>`, Text: "abcd"},
+				{Kind: PptxRunText, Properties: `<a:rPr b="1"/>`, Text: "efgh"},
+			},
+		}
+
+		result := extractor.BuildParagraph(para, "12345678")
+		expected := `<a:p><a:r><a:rPr/><a:t>1234</a:t></a:r><a:r><a:rPr b="1"/><a:t>5678</a:t></a:r></a:p>`
+		if result != expected {
+			t.Errorf("Expected %q, got %q", expected, result)
+		}
+	})
+
+	t.Run("ApplyOverflowFitReplacesExistingAutofit", func(t *testing.T) {
+		result := extractor.ApplyOverflowFit(`<a:bodyPr wrap="square"><a:spAutoFit/></a:bodyPr>`, PptxOverflowShrink, 2, 0.6)
+		expected := `<a:bodyPr wrap="square"><a:normAutofit fontScale="60000"/></a:bodyPr>`
+		if result != expected {
+			t.Errorf("Expected %q, got %q", expected, result)
+		}
+	})
+}
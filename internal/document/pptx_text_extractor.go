@@ -0,0 +1,553 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// PptxRunKind distinguishes text runs from elements that must be kept verbatim
+type PptxRunKind int
+
+const (
+	// PptxRunText is an <a:r> run carrying translatable text
+	PptxRunText PptxRunKind = iota
+	// PptxRunOpaque is any other paragraph child (<a:br>, <a:fld>, math, ...)
+	PptxRunOpaque
+)
+
+// PptxRun represents a direct child of an <a:p> paragraph
+type PptxRun struct {
+	Kind       PptxRunKind
+	Properties string // raw <a:rPr> element of a text run
+	Text       string // decoded <a:t> content of a text run
+	Raw        string // verbatim XML of an opaque element
+}
+
+// PptxParagraph represents a DrawingML <a:p> paragraph and its byte range in the part
+type PptxParagraph struct {
+	Start       int64
+	End         int64
+	StartTag    string
+	Properties  string // raw <a:pPr>
+	EndProps    string // raw <a:endParaRPr>
+	Runs        []PptxRun
+	BodyIndex   int    // index into PptxPart.Bodies, -1 when not inside a shape text body
+	Placeholder string // placeholder type of the enclosing shape, empty when not a placeholder
+}
+
+// PptxTextBody represents the <a:bodyPr> of a shape text body
+type PptxTextBody struct {
+	Start  int64
+	End    int64
+	BodyPr string
+}
+
+// PptxPart is a scanned slide, notes slide, slide layout or chart part
+type PptxPart struct {
+	Name       string
+	Kind       string
+	Paragraphs []*PptxParagraph
+	Bodies     []*PptxTextBody
+}
+
+// PptxMergedRun represents adjacent paragraph children merged for translation
+type PptxMergedRun struct {
+	Kind       PptxRunKind
+	Text       string
+	Properties string
+	Raw        string
+	StartIndex int
+	EndIndex   int
+}
+
+var (
+	// pptxVolatileAttrPattern matches run attributes that do not affect rendering
+	pptxVolatileAttrPattern = regexp.MustCompile(`\s(?:lang|altLang|dirty|err|noProof|smtClean|smtId|bmk)="[^"]*"`)
+	// pptxAutofitPattern matches the autofit children of <a:bodyPr>
+	pptxAutofitPattern = regexp.MustCompile(`(?s)<a:(?:noAutofit|normAutofit|spAutoFit)(?:\s[^>]*)?(?:/>|>.*?</a:(?:noAutofit|normAutofit|spAutoFit)>)`)
+)
+
+// PptxTextExtractor handles text extraction and manipulation for PPTX parts
+type PptxTextExtractor struct {
+	logger *zap.Logger
+}
+
+// NewPptxTextExtractor creates a new PPTX text extractor
+func NewPptxTextExtractor(logger *zap.Logger) *PptxTextExtractor {
+	return &PptxTextExtractor{logger: logger}
+}
+
+// pptxScanFrame tracks an open element while scanning a part
+type pptxScanFrame struct {
+	name  string
+	start int64
+}
+
+// ScanPart scans a part and collects its translatable paragraphs.
+// Slides and notes contribute every shape and table paragraph, layouts only
+// placeholder shapes, and charts only title paragraphs.
+func (e *PptxTextExtractor) ScanPart(name, kind string, data []byte) (*PptxPart, error) {
+	part := &PptxPart{Name: name, Kind: kind}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []pptxScanFrame
+
+	var (
+		para        *PptxParagraph
+		paraDepth   int
+		run         *PptxRun
+		inText      bool
+		bodyIndex   = -1
+		bodyDepth   = -1
+		titleDepth  = -1
+		shapeDepth  = -1
+		placeholder string
+		hasPh       bool
+	)
+
+	for {
+		start := decoder.InputOffset()
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", name, err)
+		}
+		end := decoder.InputOffset()
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			tag := qualifiedName(t.Name)
+			stack = append(stack, pptxScanFrame{name: tag, start: start})
+			depth := len(stack)
+
+			switch {
+			case para != nil:
+				if run != nil && tag == "a:t" {
+					inText = true
+				}
+				if depth == paraDepth+1 && tag == "a:r" {
+					run = &PptxRun{Kind: PptxRunText}
+				}
+			case tag == "p:sp" || tag == "p:graphicFrame":
+				shapeDepth = depth
+				placeholder = ""
+				hasPh = false
+			case tag == "p:ph" && shapeDepth > 0:
+				hasPh = true
+				placeholder = attrValue(t, "type")
+				if placeholder == "" {
+					placeholder = "body"
+				}
+			case tag == "p:txBody" || tag == "a:txBody":
+				part.Bodies = append(part.Bodies, &PptxTextBody{})
+				bodyIndex = len(part.Bodies) - 1
+				bodyDepth = depth
+			case tag == "c:title":
+				titleDepth = depth
+			case tag == "a:p" && e.acceptParagraph(kind, bodyDepth, titleDepth, hasPh):
+				para = &PptxParagraph{
+					Start:     start,
+					StartTag:  string(data[start:end]),
+					BodyIndex: bodyIndex,
+				}
+				if kind == "chart" {
+					para.BodyIndex = -1
+				}
+				if hasPh {
+					para.Placeholder = placeholder
+				}
+				paraDepth = depth
+			}
+
+		case xml.CharData:
+			if inText && run != nil {
+				run.Text += string(t)
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("failed to scan %s: unbalanced element %s", name, qualifiedName(t.Name))
+			}
+			frame := stack[len(stack)-1]
+			depth := len(stack)
+			stack = stack[:len(stack)-1]
+			raw := string(data[frame.start:end])
+
+			switch {
+			case para != nil && depth == paraDepth:
+				para.End = end
+				if strings.HasSuffix(para.StartTag, "/>") {
+					para.StartTag = strings.TrimSuffix(para.StartTag, "/>") + ">"
+				}
+				part.Paragraphs = append(part.Paragraphs, para)
+				para = nil
+			case para != nil && depth == paraDepth+1:
+				switch frame.name {
+				case "a:pPr":
+					para.Properties = raw
+				case "a:endParaRPr":
+					para.EndProps = raw
+				case "a:r":
+					if run != nil {
+						para.Runs = append(para.Runs, *run)
+					}
+					run = nil
+				default:
+					para.Runs = append(para.Runs, PptxRun{Kind: PptxRunOpaque, Raw: raw})
+				}
+			case para != nil && run != nil && depth == paraDepth+2:
+				if frame.name == "a:rPr" {
+					run.Properties = raw
+				}
+				if frame.name == "a:t" {
+					inText = false
+				}
+			case para == nil && frame.name == "a:bodyPr" && bodyIndex >= 0 && depth == bodyDepth+1:
+				body := part.Bodies[bodyIndex]
+				body.Start = frame.start
+				body.End = end
+				body.BodyPr = raw
+			case depth == bodyDepth:
+				bodyDepth = -1
+				bodyIndex = -1
+			case depth == titleDepth:
+				titleDepth = -1
+			case depth == shapeDepth:
+				shapeDepth = -1
+				placeholder = ""
+				hasPh = false
+			}
+		}
+	}
+
+	return part, nil
+}
+
+// acceptParagraph decides whether a paragraph in the current context is translatable
+func (e *PptxTextExtractor) acceptParagraph(kind string, bodyDepth, titleDepth int, hasPh bool) bool {
+	switch kind {
+	case "chart":
+		return titleDepth > 0
+	case "layout":
+		return bodyDepth > 0 && hasPh
+	default:
+		return bodyDepth > 0
+	}
+}
+
+// MergeAdjacentRuns merges text runs with identical formatting using the same
+// span grouping as DocxTextExtractor.MergeAdjacentRuns. Opaque elements are never merged.
+func (e *PptxTextExtractor) MergeAdjacentRuns(para *PptxParagraph) []PptxMergedRun {
+	if para == nil || len(para.Runs) == 0 {
+		return nil
+	}
+
+	spans := mergeRunSpans(len(para.Runs),
+		func(i int) bool {
+			// Skip empty text runs
+			return para.Runs[i].Kind == PptxRunText && para.Runs[i].Text == ""
+		},
+		func(last, next int) bool {
+			prev, run := para.Runs[last], para.Runs[next]
+			return prev.Kind == PptxRunText && run.Kind == PptxRunText &&
+				e.compareRunProperties(prev.Properties, run.Properties)
+		})
+
+	merged := make([]PptxMergedRun, 0, len(spans))
+	for _, span := range spans {
+		first := para.Runs[span.start]
+		var text strings.Builder
+		for i := span.start; i <= span.end; i++ {
+			text.WriteString(para.Runs[i].Text)
+		}
+		merged = append(merged, PptxMergedRun{
+			Kind:       first.Kind,
+			Text:       text.String(),
+			Properties: first.Properties,
+			Raw:        first.Raw,
+			StartIndex: span.start,
+			EndIndex:   span.end,
+		})
+	}
+
+	return merged
+}
+
+// compareRunProperties compares two raw <a:rPr> elements ignoring proofing attributes
+func (e *PptxTextExtractor) compareRunProperties(p1, p2 string) bool {
+	return canonicalRunProperties(p1) == canonicalRunProperties(p2)
+}
+
+// ExtractParagraphText returns the text of a paragraph for translation.
+// A paragraph with a single formatting run yields plain text; otherwise every
+// merged run is wrapped in <rN>…</rN> and every opaque element becomes <xN/>.
+func (e *PptxTextExtractor) ExtractParagraphText(para *PptxParagraph) string {
+	merged := e.MergeAdjacentRuns(para)
+	if !hasPptxText(merged) {
+		return ""
+	}
+
+	if len(merged) == 1 {
+		return merged[0].Text
+	}
+
+	var builder strings.Builder
+	for i, mr := range merged {
+		if mr.Kind == PptxRunText {
+			fmt.Fprintf(&builder, "<r%d>%s</r%d>", i+1, mr.Text, i+1)
+		} else {
+			fmt.Fprintf(&builder, "<x%d/>", i+1)
+		}
+	}
+	return builder.String()
+}
+
+// BuildParagraph renders the paragraph XML with translated text
+func (e *PptxTextExtractor) BuildParagraph(para *PptxParagraph, translatedText string) string {
+	merged := e.MergeAdjacentRuns(para)
+
+	var builder strings.Builder
+	builder.WriteString(para.StartTag)
+	builder.WriteString(para.Properties)
+
+	if len(merged) == 1 {
+		builder.WriteString(buildPptxRun(merged[0].Properties, translatedText))
+	} else if inlineRunTagPattern.MatchString(translatedText) {
+		e.writeTaggedRuns(&builder, merged, translatedText)
+	} else {
+		e.logger.Debug("inline run tags lost in translation, splitting proportionally",
+			zap.Int("runs", len(merged)))
+		e.writeSplitRuns(&builder, merged, translatedText)
+	}
+
+	builder.WriteString(para.EndProps)
+	builder.WriteString("</a:p>")
+	return builder.String()
+}
+
+// writeTaggedRuns rebuilds runs from <rN>/<xN/> tags in translated order
+func (e *PptxTextExtractor) writeTaggedRuns(builder *strings.Builder, merged []PptxMergedRun, translatedText string) {
+	used := make(map[int]bool)
+	lastProps := firstPptxTextProperties(merged)
+
+	writeLoose := func(text string) {
+		if text != "" {
+			builder.WriteString(buildPptxRun(lastProps, text))
+		}
+	}
+
+	cursor := 0
+	for _, loc := range inlineRunTagPattern.FindAllStringSubmatchIndex(translatedText, -1) {
+		writeLoose(translatedText[cursor:loc[0]])
+		cursor = loc[1]
+
+		if loc[2] >= 0 {
+			index, _ := strconv.Atoi(translatedText[loc[2]:loc[3]])
+			text := translatedText[loc[4]:loc[5]]
+			if index >= 1 && index <= len(merged) && merged[index-1].Kind == PptxRunText {
+				lastProps = merged[index-1].Properties
+				used[index] = true
+			}
+			builder.WriteString(buildPptxRun(lastProps, text))
+			continue
+		}
+
+		index, _ := strconv.Atoi(translatedText[loc[6]:loc[7]])
+		if index >= 1 && index <= len(merged) && merged[index-1].Kind == PptxRunOpaque && !used[index] {
+			builder.WriteString(merged[index-1].Raw)
+			used[index] = true
+		}
+	}
+	writeLoose(translatedText[cursor:])
+
+	// Opaque elements such as fields must survive even if the translation dropped them
+	for i, mr := range merged {
+		if mr.Kind == PptxRunOpaque && !used[i+1] {
+			builder.WriteString(mr.Raw)
+		}
+	}
+}
+
+// writeSplitRuns distributes untagged text across text runs proportionally
+func (e *PptxTextExtractor) writeSplitRuns(builder *strings.Builder, merged []PptxMergedRun, translatedText string) {
+	var textRuns []PptxMergedRun
+	for _, mr := range merged {
+		if mr.Kind == PptxRunText {
+			textRuns = append(textRuns, mr)
+		}
+	}
+
+	parts := e.SplitTranslatedText(translatedText, textRuns)
+	textIndex := 0
+	for _, mr := range merged {
+		if mr.Kind == PptxRunOpaque {
+			builder.WriteString(mr.Raw)
+			continue
+		}
+		if textIndex < len(parts) && parts[textIndex] != "" {
+			builder.WriteString(buildPptxRun(mr.Properties, parts[textIndex]))
+		}
+		textIndex++
+	}
+}
+
+// SplitTranslatedText splits translated text back to the merged run structure
+func (e *PptxTextExtractor) SplitTranslatedText(translatedText string, mergedRuns []PptxMergedRun) []string {
+	if len(mergedRuns) == 0 {
+		return nil
+	}
+
+	totalLength := 0
+	for _, mr := range mergedRuns {
+		totalLength += len([]rune(mr.Text))
+	}
+	if totalLength == 0 {
+		return nil
+	}
+
+	result := make([]string, len(mergedRuns))
+	translatedRunes := []rune(translatedText)
+	position := 0
+
+	for i, mr := range mergedRuns {
+		proportion := float64(len([]rune(mr.Text))) / float64(totalLength)
+		newLength := int(float64(len(translatedRunes)) * proportion)
+
+		if i == len(mergedRuns)-1 || position+newLength > len(translatedRunes) {
+			newLength = len(translatedRunes) - position
+		}
+
+		if newLength > 0 {
+			result[i] = string(translatedRunes[position : position+newLength])
+			position += newLength
+		}
+	}
+
+	return result
+}
+
+// PlainText strips inline run tags from extracted or translated text
+func (e *PptxTextExtractor) PlainText(text string) string {
+	return inlineRunTagPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := inlineRunTagPattern.FindStringSubmatch(match)
+		return sub[2]
+	})
+}
+
+// ApplyOverflowFit rewrites <a:bodyPr> so a grown text body still fits its shape.
+// "shrink" sets normAutofit with a font scale, "resize" lets the shape grow.
+func (e *PptxTextExtractor) ApplyOverflowFit(bodyPr, mode string, ratio, minFontScale float64) string {
+	var fit string
+	switch mode {
+	case "shrink":
+		scale := 1 / ratio
+		if scale < minFontScale {
+			scale = minFontScale
+		}
+		fit = fmt.Sprintf(`<a:normAutofit fontScale="%d"/>`, int(scale*100000))
+	case "resize":
+		fit = `<a:spAutoFit/>`
+	default:
+		return bodyPr
+	}
+
+	if strings.HasSuffix(bodyPr, "/>") {
+		return strings.TrimSuffix(bodyPr, "/>") + ">" + fit + "</a:bodyPr>"
+	}
+
+	bodyPr = pptxAutofitPattern.ReplaceAllString(bodyPr, "")
+
+	// Autofit comes right after the optional prstTxWarp in the schema sequence
+	if idx := strings.Index(bodyPr, "<a:prstTxWarp"); idx >= 0 {
+		if end := strings.Index(bodyPr[idx:], "</a:prstTxWarp>"); end >= 0 {
+			insert := idx + end + len("</a:prstTxWarp>")
+			return bodyPr[:insert] + fit + bodyPr[insert:]
+		}
+		if end := strings.Index(bodyPr[idx:], "/>"); end >= 0 {
+			insert := idx + end + len("/>")
+			return bodyPr[:insert] + fit + bodyPr[insert:]
+		}
+	}
+
+	insert := strings.Index(bodyPr, ">") + 1
+	return bodyPr[:insert] + fit + bodyPr[insert:]
+}
+
+// buildPptxRun renders text as one or more runs, turning newlines into <a:br>
+func buildPptxRun(props, text string) string {
+	var builder strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			builder.WriteString("<a:br>" + props + "</a:br>")
+		}
+		if line == "" {
+			continue
+		}
+		builder.WriteString("<a:r>")
+		builder.WriteString(props)
+		builder.WriteString("<a:t>")
+		_ = xml.EscapeText(&builder, []byte(line))
+		builder.WriteString("</a:t></a:r>")
+	}
+	return builder.String()
+}
+
+// canonicalRunProperties normalises a raw <a:rPr> for comparison
+func canonicalRunProperties(props string) string {
+	props = pptxVolatileAttrPattern.ReplaceAllString(props, "")
+	switch props {
+	case "<a:rPr/>", "<a:rPr></a:rPr>":
+		return ""
+	}
+	return props
+}
+
+// firstPptxTextProperties returns the properties of the first text run
+func firstPptxTextProperties(merged []PptxMergedRun) string {
+	for _, mr := range merged {
+		if mr.Kind == PptxRunText {
+			return mr.Properties
+		}
+	}
+	return ""
+}
+
+// hasPptxText reports whether merged runs contain non-blank text
+func hasPptxText(merged []PptxMergedRun) bool {
+	for _, mr := range merged {
+		if mr.Kind == PptxRunText && strings.TrimSpace(mr.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// textDisplayWidth estimates rendered width, counting East Asian wide characters twice
+func textDisplayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) && isWideRune(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isWideRune reports whether r is an East Asian wide character
+func isWideRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) ||
+		(r >= 0xFF00 && r <= 0xFFEF) || (r >= 0x3000 && r <= 0x303F)
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -14,12 +15,14 @@ type Registry struct {
 	mu         sync.RWMutex
 	processors map[Format]ProcessorFactory
 	extensions map[string]Format
+	filenames  map[string]Format
 }
 
 // globalRegistry 全局注册表实例
 var globalRegistry = &Registry{
 	processors: make(map[Format]ProcessorFactory),
 	extensions: make(map[string]Format),
+	filenames:  make(map[string]Format),
 }
 
 // Register 注册处理器
@@ -32,6 +35,11 @@ func RegisterExtension(ext string, format Format) {
 	globalRegistry.RegisterExtension(ext, format)
 }
 
+// RegisterFilename 注册文件名映射（优先于扩展名）
+func RegisterFilename(name string, format Format) {
+	globalRegistry.RegisterFilename(name, format)
+}
+
 // GetProcessor 获取处理器
 func GetProcessor(format Format, opts ProcessorOptions) (Processor, error) {
 	return globalRegistry.GetProcessor(format, opts)
@@ -70,6 +78,14 @@ func (r *Registry) RegisterExtension(ext string, format Format) {
 	r.extensions[ext] = format
 }
 
+// RegisterFilename 注册文件名映射，用于扩展名不足以区分格式的文件（如 Android 的 strings.xml）
+func (r *Registry) RegisterFilename(name string, format Format) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.filenames[strings.ToLower(name)] = format
+}
+
 // GetProcessor 获取指定格式的处理器
 func (r *Registry) GetProcessor(format Format, opts ProcessorOptions) (Processor, error) {
 	r.mu.RLock()
@@ -88,7 +104,10 @@ func (r *Registry) GetProcessorByExtension(filename string, opts ProcessorOption
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
 
 	r.mu.RLock()
-	format, exists := r.extensions[ext]
+	format, exists := r.filenames[strings.ToLower(filepath.Base(filename))]
+	if !exists {
+		format, exists = r.extensions[ext]
+	}
 	r.mu.RUnlock()
 
 	if !exists {
@@ -148,6 +167,158 @@ func getHTMLProcessingModeFromOptions(opts ProcessorOptions) HTMLProcessingMode
 	return HTMLModeMarkdown // 默认使用markdown模式
 }
 
+// getPptxOverflowFromOptions 从ProcessorOptions中获取PPTX文本溢出处理配置
+func getPptxOverflowFromOptions(opts ProcessorOptions) PptxOverflowOptions {
+	overflow := PptxOverflowOptions{
+		Mode:           PptxOverflowNone,
+		ThresholdRatio: 1.3,
+		MinFontScale:   0.5,
+	}
+	if opts.Metadata == nil {
+		return overflow
+	}
+
+	if mode, ok := opts.Metadata["pptx_overflow_mode"].(string); ok {
+		switch mode {
+		case PptxOverflowShrink, PptxOverflowResize:
+			overflow.Mode = mode
+		default:
+			overflow.Mode = PptxOverflowNone
+		}
+	}
+	if ratio, ok := opts.Metadata["pptx_overflow_threshold"].(float64); ok && ratio > 0 {
+		overflow.ThresholdRatio = ratio
+	}
+	if scale, ok := opts.Metadata["pptx_min_font_scale"].(float64); ok && scale > 0 && scale <= 1 {
+		overflow.MinFontScale = scale
+	}
+	return overflow
+}
+
+// getSubtitleOptionsFromOptions 从ProcessorOptions中获取字幕处理配置
+func getSubtitleOptionsFromOptions(opts ProcessorOptions) SubtitleOptions {
+	options := SubtitleOptions{
+		MergeSentences:    true,
+		MaxMergeGap:       time.Second,
+		MaxMergeCues:      4,
+		MaxCharsPerLine:   42,
+		MaxLines:          2,
+		MaxCharsPerSecond: 17,
+	}
+	if opts.Metadata == nil {
+		return options
+	}
+
+	if merge, ok := opts.Metadata["subtitle_merge_sentences"].(bool); ok {
+		options.MergeSentences = merge
+	}
+	if gap, ok := opts.Metadata["subtitle_max_merge_gap"].(float64); ok && gap >= 0 {
+		options.MaxMergeGap = time.Duration(gap * float64(time.Second))
+	}
+	if cues, ok := opts.Metadata["subtitle_max_merge_cues"].(int); ok && cues > 0 {
+		options.MaxMergeCues = cues
+	}
+	if width, ok := opts.Metadata["subtitle_max_chars_per_line"].(int); ok && width >= 0 {
+		options.MaxCharsPerLine = width
+	}
+	if lines, ok := opts.Metadata["subtitle_max_lines"].(int); ok && lines >= 0 {
+		options.MaxLines = lines
+	}
+	if rate, ok := opts.Metadata["subtitle_max_chars_per_second"].(float64); ok && rate >= 0 {
+		options.MaxCharsPerSecond = rate
+	}
+	return options
+}
+
+// getI18nLocalesFromOptions 从ProcessorOptions中获取本地化文件的源和目标区域设置，
+// 未显式配置时由源语言和目标语言推导
+func getI18nLocalesFromOptions(opts ProcessorOptions) (source, target string) {
+	source, target = "en", ""
+	if lang, ok := opts.Metadata["source_language"].(string); ok && lang != "" {
+		source = normalizeLocaleCode(lang)
+	}
+	if lang, ok := opts.Metadata["target_language"].(string); ok && lang != "" {
+		target = normalizeLocaleCode(lang)
+	}
+	if locale, ok := opts.Metadata["i18n_source_locale"].(string); ok && locale != "" {
+		source = normalizeLocaleCode(locale)
+	}
+	if locale, ok := opts.Metadata["i18n_target_locale"].(string); ok && locale != "" {
+		target = normalizeLocaleCode(locale)
+	}
+	return source, target
+}
+
+// getXlsxOptionsFromOptions 从ProcessorOptions中获取XLSX处理配置
+func getXlsxOptionsFromOptions(opts ProcessorOptions) XlsxOptions {
+	options := XlsxOptions{
+		TranslateSheetNames: true,
+		TranslateComments:   true,
+	}
+	if opts.Metadata == nil {
+		return options
+	}
+
+	if sheets, ok := opts.Metadata["xlsx_skip_sheets"].([]string); ok {
+		options.SkipSheets = sheets
+	}
+	if columns, ok := opts.Metadata["xlsx_skip_columns"].([]string); ok {
+		options.SkipColumns = columns
+	}
+	if mappings, ok := opts.Metadata["xlsx_column_mappings"].([]string); ok {
+		options.ColumnMappings = mappings
+	}
+	if translate, ok := opts.Metadata["xlsx_translate_sheet_names"].(bool); ok {
+		options.TranslateSheetNames = translate
+	}
+	if translate, ok := opts.Metadata["xlsx_translate_comments"].(bool); ok {
+		options.TranslateComments = translate
+	}
+	return options
+}
+
+// getFrontMatterOptionsFromOptions 从ProcessorOptions中获取Markdown front matter处理配置，
+// 目标语言沿用本地化文件的目标区域设置
+func getFrontMatterOptionsFromOptions(opts ProcessorOptions) FrontMatterOptions {
+	options := FrontMatterOptions{
+		TranslateKeys: defaultFrontMatterTranslateKeys,
+	}
+	if opts.Metadata == nil {
+		return options
+	}
+
+	if keys, ok := opts.Metadata["front_matter_translate_keys"].([]string); ok && keys != nil {
+		options.TranslateKeys = keys
+	}
+	if key, ok := opts.Metadata["front_matter_language_key"].(string); ok {
+		options.LanguageKey = key
+	}
+	_, options.Language = getI18nLocalesFromOptions(opts)
+	return options
+}
+
+// getMDXTranslatePropsFromOptions 从ProcessorOptions中获取需要翻译的 JSX 字符串属性名
+func getMDXTranslatePropsFromOptions(opts ProcessorOptions) []string {
+	if opts.Metadata == nil {
+		return nil
+	}
+	props, _ := opts.Metadata["mdx_translate_props"].([]string)
+	return props
+}
+
+// getNotebookOptionsFromOptions 从ProcessorOptions中获取Jupyter notebook处理配置
+func getNotebookOptionsFromOptions(opts ProcessorOptions) NotebookOptions {
+	var options NotebookOptions
+	if opts.Metadata == nil {
+		return options
+	}
+
+	if translate, ok := opts.Metadata["notebook_translate_code_comments"].(bool); ok {
+		options.TranslateCodeComments = translate
+	}
+	return options
+}
+
 // init 初始化默认扩展名映射和处理器注册
 func init() {
 	// 注册处理器工厂
@@ -156,6 +327,16 @@ func init() {
 		return NewMarkdownProcessor(opts, logger)
 	})
 
+	Register(FormatMDX, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewMDXProcessor(opts, logger)
+	})
+
+	Register(FormatNotebook, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewNotebookProcessor(opts, logger)
+	})
+
 	Register(FormatText, func(opts ProcessorOptions) (Processor, error) {
 		logger := getLoggerFromOptions(opts)
 		return NewTextProcessor(opts, logger)
@@ -188,11 +369,46 @@ func init() {
 		return NewDocxProcessor(opts, logger)
 	})
 
+	Register(FormatPPTX, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewPptxProcessor(opts, logger)
+	})
+
+	Register(FormatXLSX, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewXlsxProcessor(opts, logger)
+	})
+
+	Register(FormatODT, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewOdfProcessor(opts, logger, FormatODT)
+	})
+
+	Register(FormatODP, func(opts ProcessorOptions) (Processor, error) {
+		logger := getLoggerFromOptions(opts)
+		return NewOdfProcessor(opts, logger, FormatODP)
+	})
+
+	for _, format := range []Format{FormatSRT, FormatVTT, FormatASS} {
+		Register(format, func(opts ProcessorOptions) (Processor, error) {
+			logger := getLoggerFromOptions(opts)
+			return NewSubtitleProcessor(opts, logger, format)
+		})
+	}
+
+	for _, format := range []Format{FormatPO, FormatI18nJSON, FormatI18nYAML, FormatAndroidStrings, FormatAppleStrings, FormatXCStrings} {
+		Register(format, func(opts ProcessorOptions) (Processor, error) {
+			logger := getLoggerFromOptions(opts)
+			return NewI18nProcessor(opts, logger, format)
+		})
+	}
+
 	// Markdown
 	RegisterExtension(".md", FormatMarkdown)
 	RegisterExtension(".markdown", FormatMarkdown)
 	RegisterExtension(".mdown", FormatMarkdown)
 	RegisterExtension(".mkd", FormatMarkdown)
+	RegisterExtension(".mdx", FormatMDX)
 
 	// Text
 	RegisterExtension(".txt", FormatText)
@@ -218,6 +434,37 @@ func init() {
 	RegisterExtension(".docx", FormatDOCX)
 	RegisterExtension(".doc", FormatDOCX)
 
+	// PPTX
+	RegisterExtension(".pptx", FormatPPTX)
+
+	// XLSX
+	RegisterExtension(".xlsx", FormatXLSX)
+
+	// Jupyter notebooks
+	RegisterExtension(".ipynb", FormatNotebook)
+
+	// OpenDocument
+	RegisterExtension(".odt", FormatODT)
+	RegisterExtension(".odp", FormatODP)
+
+	// Subtitles
+	RegisterExtension(".srt", FormatSRT)
+	RegisterExtension(".vtt", FormatVTT)
+	RegisterExtension(".ass", FormatASS)
+	RegisterExtension(".ssa", FormatASS)
+
+	// Localisation string files
+	RegisterExtension(".po", FormatPO)
+	RegisterExtension(".pot", FormatPO)
+	RegisterExtension(".json", FormatI18nJSON)
+	RegisterExtension(".yml", FormatI18nYAML)
+	RegisterExtension(".yaml", FormatI18nYAML)
+	RegisterExtension(".strings", FormatAppleStrings)
+	RegisterExtension(".xcstrings", FormatXCStrings)
+	RegisterFilename("strings.xml", FormatAndroidStrings)
+	RegisterFilename("plurals.xml", FormatAndroidStrings)
+	RegisterFilename("arrays.xml", FormatAndroidStrings)
+
 	// TextBundle
 	RegisterExtension(".textbundle", FormatTextBundle)
 
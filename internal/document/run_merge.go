@@ -0,0 +1,27 @@
+package document
+
+// runSpan is a range of consecutive runs merged into one, by run index
+type runSpan struct {
+	start int
+	end   int
+}
+
+// mergeRunSpans groups consecutive runs with identical formatting for the DOCX
+// and PPTX extractors. Runs for which skip returns true are left out of the
+// result; a run joins the current span when canMerge accepts it after the last
+// run of that span. Skipped runs carry no text, so a span's text is the
+// concatenation of every run between start and end.
+func mergeRunSpans(count int, skip func(i int) bool, canMerge func(last, next int) bool) []runSpan {
+	var spans []runSpan
+	for i := 0; i < count; i++ {
+		if skip(i) {
+			continue
+		}
+		if n := len(spans); n > 0 && canMerge(spans[n-1].end, i) {
+			spans[n-1].end = i
+			continue
+		}
+		spans = append(spans, runSpan{start: i, end: i})
+	}
+	return spans
+}
@@ -0,0 +1,308 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SubtitleCue is a single timed subtitle and the byte range of its text
+type SubtitleCue struct {
+	ID        string // SRT number, WebVTT identifier or ASS line number
+	Start     time.Duration
+	End       time.Duration
+	TextStart int64
+	TextEnd   int64
+	Lines     []string // raw text lines, ASS \N breaks already split
+	Prefix    string   // leading style tags, kept verbatim
+	Suffix    string   // trailing style tags, kept verbatim
+	Body      string   // text between Prefix and Suffix with lines joined
+}
+
+// subtitleLine is a line of a subtitle file without its line terminator
+type subtitleLine struct {
+	start int64
+	end   int64
+	text  string
+}
+
+var (
+	// subtitleTagPattern matches HTML-like tags (SRT, WebVTT) and ASS override blocks
+	subtitleTagPattern = regexp.MustCompile(`<[^<>\n]+>|\{[^{}\n]*\}`)
+	// assLineBreakPattern matches ASS hard and soft line breaks
+	assLineBreakPattern = regexp.MustCompile(`\\[Nn]`)
+)
+
+// splitSubtitleLines splits data into lines, recording byte offsets
+func splitSubtitleLines(data []byte) []subtitleLine {
+	var lines []subtitleLine
+	start := 0
+	// Skip a UTF-8 byte order mark
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		start = 3
+	}
+	for start < len(data) {
+		end := bytes.IndexByte(data[start:], '\n')
+		next := len(data)
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += start
+			next = end + 1
+		}
+		contentEnd := end
+		if contentEnd > start && data[contentEnd-1] == '\r' {
+			contentEnd--
+		}
+		lines = append(lines, subtitleLine{
+			start: int64(start),
+			end:   int64(contentEnd),
+			text:  string(data[start:contentEnd]),
+		})
+		start = next
+	}
+	return lines
+}
+
+// ParseTimedSubtitles parses SRT or WebVTT cues. Blocks without a timing line,
+// the WEBVTT header and NOTE, STYLE and REGION blocks are skipped.
+func ParseTimedSubtitles(data []byte, webvtt bool) []*SubtitleCue {
+	lines := splitSubtitleLines(data)
+	var cues []*SubtitleCue
+
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i].text) == "" {
+			i++
+			continue
+		}
+
+		// A block runs until the next blank line
+		j := i
+		for j < len(lines) && strings.TrimSpace(lines[j].text) != "" {
+			j++
+		}
+		block := lines[i:j]
+		i = j
+
+		if webvtt {
+			first := strings.TrimSpace(block[0].text)
+			if strings.HasPrefix(first, "WEBVTT") || strings.HasPrefix(first, "NOTE") ||
+				first == "STYLE" || first == "REGION" {
+				continue
+			}
+		}
+
+		timingIndex := -1
+		for k := 0; k < len(block) && k < 2; k++ {
+			if strings.Contains(block[k].text, "-->") {
+				timingIndex = k
+				break
+			}
+		}
+		if timingIndex < 0 {
+			continue
+		}
+
+		start, end, err := parseSubtitleTiming(block[timingIndex].text)
+		if err != nil {
+			continue
+		}
+
+		cue := &SubtitleCue{Start: start, End: end}
+		if timingIndex == 1 {
+			cue.ID = strings.TrimSpace(block[0].text)
+		}
+		textLines := block[timingIndex+1:]
+		if len(textLines) == 0 {
+			cue.TextStart = block[timingIndex].end
+			cue.TextEnd = block[timingIndex].end
+		} else {
+			cue.TextStart = textLines[0].start
+			cue.TextEnd = textLines[len(textLines)-1].end
+			for _, line := range textLines {
+				cue.Lines = append(cue.Lines, line.text)
+			}
+		}
+		cue.Prefix, cue.Body, cue.Suffix = splitSubtitleTags(joinSubtitleLines(cue.Lines))
+		cues = append(cues, cue)
+	}
+
+	return cues
+}
+
+// ParseASSSubtitles parses the Dialogue lines of an ASS/SSA [Events] section.
+// Only the Text field is exposed; all other fields are kept verbatim.
+func ParseASSSubtitles(data []byte) ([]*SubtitleCue, error) {
+	lines := splitSubtitleLines(data)
+	var cues []*SubtitleCue
+	inEvents := false
+	var format []string
+
+	for lineIndex, line := range lines {
+		text := strings.TrimSpace(line.text)
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			inEvents = strings.EqualFold(text, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line.text, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Format":
+			format = nil
+			for _, field := range strings.Split(value, ",") {
+				format = append(format, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case "Dialogue":
+			if len(format) == 0 || format[len(format)-1] != "text" {
+				return nil, fmt.Errorf("line %d: missing or invalid Format line in [Events]", lineIndex+1)
+			}
+
+			// The Text field is last and may itself contain commas
+			fields := strings.SplitN(value, ",", len(format))
+			if len(fields) < len(format) {
+				continue
+			}
+			cue := &SubtitleCue{ID: strconv.Itoa(lineIndex + 1)}
+			for k, name := range format[:len(format)-1] {
+				switch name {
+				case "start":
+					cue.Start, _ = parseSubtitleTimestamp(strings.TrimSpace(fields[k]))
+				case "end":
+					cue.End, _ = parseSubtitleTimestamp(strings.TrimSpace(fields[k]))
+				}
+			}
+
+			textField := fields[len(fields)-1]
+			cue.TextStart = line.end - int64(len(textField))
+			cue.TextEnd = line.end
+			cue.Lines = assLineBreakPattern.Split(textField, -1)
+			cue.Prefix, cue.Body, cue.Suffix = splitSubtitleTags(joinSubtitleLines(cue.Lines))
+			cues = append(cues, cue)
+		}
+	}
+
+	return cues, nil
+}
+
+// parseSubtitleTiming parses a "start --> end [settings]" timing line
+func parseSubtitleTiming(line string) (time.Duration, time.Duration, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[1] != "-->" {
+		return 0, 0, fmt.Errorf("invalid timing line %q", line)
+	}
+	start, err := parseSubtitleTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseSubtitleTimestamp(fields[2])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseSubtitleTimestamp parses SRT (00:00:01,500), WebVTT (00:01.500) and
+// ASS (0:00:01.50) timestamps
+func parseSubtitleTimestamp(value string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(value, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	total := seconds
+	multiplier := 60.0
+	for k := len(parts) - 2; k >= 0; k-- {
+		n, err := strconv.Atoi(parts[k])
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", value, err)
+		}
+		total += float64(n) * multiplier
+		multiplier *= 60
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+// splitSubtitleTags separates the style tags wrapping a cue from its text
+func splitSubtitleTags(text string) (prefix, body, suffix string) {
+	body = text
+	for {
+		loc := subtitleTagPattern.FindStringIndex(body)
+		if loc == nil || loc[0] != 0 {
+			break
+		}
+		prefix += body[:loc[1]]
+		body = body[loc[1]:]
+	}
+
+	matches := subtitleTagPattern.FindAllStringIndex(body, -1)
+	for k := len(matches) - 1; k >= 0 && matches[k][1] == len(body); k-- {
+		suffix = body[matches[k][0]:] + suffix
+		body = body[:matches[k][0]]
+	}
+	return prefix, body, suffix
+}
+
+// joinSubtitleLines joins the display lines of a cue into running text.
+// Lines starting a new speaker with a dash keep their line break.
+func joinSubtitleLines(lines []string) string {
+	text := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case text == "":
+			text = line
+		case strings.HasPrefix(subtitleTagPattern.ReplaceAllString(line, ""), "-"):
+			text += "\n" + line
+		default:
+			text = joinSubtitleText(text, line)
+		}
+	}
+	return text
+}
+
+// joinSubtitleText joins two pieces of running text, without a space between
+// East Asian characters
+func joinSubtitleText(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	plainA := []rune(subtitleTagPattern.ReplaceAllString(a, ""))
+	plainB := []rune(subtitleTagPattern.ReplaceAllString(b, ""))
+	if len(plainA) > 0 && len(plainB) > 0 && isWideRune(plainA[len(plainA)-1]) && isWideRune(plainB[0]) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// subtitleTextWidth returns the display width of text without style tags
+func subtitleTextWidth(text string) int {
+	return textDisplayWidth(subtitleTagPattern.ReplaceAllString(text, ""))
+}
+
+// endsSubtitleSentence reports whether text ends a sentence
+func endsSubtitleSentence(text string) bool {
+	plain := strings.TrimRightFunc(subtitleTagPattern.ReplaceAllString(text, ""), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"'”’」』)]）`, r)
+	})
+	if plain == "" {
+		return true
+	}
+	runes := []rune(plain)
+	return strings.ContainsRune(".!?。！？…♪", runes[len(runes)-1])
+}
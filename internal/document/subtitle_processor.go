@@ -0,0 +1,554 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+// SubtitleOptions controls cue merging and the subtitle reading constraints.
+// Widths count East Asian characters as two.
+type SubtitleOptions struct {
+	MergeSentences    bool          // merge consecutive cues that form one sentence
+	MaxMergeGap       time.Duration // largest pause between cues that are merged
+	MaxMergeCues      int           // largest number of cues merged into one unit
+	MaxCharsPerLine   int           // 0 disables the line length constraint
+	MaxLines          int           // 0 disables the line count constraint
+	MaxCharsPerSecond float64       // 0 disables the reading speed constraint
+}
+
+// SubtitleProcessor processes SRT, WebVTT and ASS/SSA subtitles
+type SubtitleProcessor struct {
+	opts      ProcessorOptions
+	logger    *zap.Logger
+	format    Format
+	options   SubtitleOptions
+	protector pkgdoc.ContentProtector
+}
+
+// NewSubtitleProcessor creates a new subtitle processor for the given format
+func NewSubtitleProcessor(opts ProcessorOptions, logger *zap.Logger, format Format) (*SubtitleProcessor, error) {
+	switch format {
+	case FormatSRT, FormatVTT, FormatASS:
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %s", format)
+	}
+
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	return &SubtitleProcessor{
+		opts:      opts,
+		logger:    logger,
+		format:    format,
+		options:   getSubtitleOptionsFromOptions(opts),
+		protector: pkgdoc.GetProtectorForFormat("text"),
+	}, nil
+}
+
+// Parse parses a subtitle file into a Document. Each block is a translation
+// unit of one or more consecutive cues.
+func (p *SubtitleProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	var cues []*SubtitleCue
+	switch p.format {
+	case FormatASS:
+		cues, err = ParseASSSubtitles(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subtitles: %w", err)
+		}
+	default:
+		cues = ParseTimedSubtitles(data, p.format == FormatVTT)
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("%s-%d", p.format, time.Now().Unix()),
+		Format: p.format,
+		Metadata: DocumentMetadata{
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	for _, unit := range p.groupCues(cues) {
+		text := ""
+		for _, index := range unit {
+			if text != "" && strings.HasPrefix(cues[index].Body, "-") {
+				text += "\n" + cues[index].Body
+			} else {
+				text = joinSubtitleText(text, cues[index].Body)
+			}
+		}
+		doc.Blocks = append(doc.Blocks, &BaseBlock{
+			Type:         BlockTypeParagraph,
+			Content:      text,
+			Translatable: true,
+			Metadata: BlockMetadata{
+				Attributes: map[string]interface{}{
+					"subtitleCues":   unit,
+					"subtitleSource": text,
+				},
+			},
+		})
+	}
+
+	// Store subtitle data for later rendering
+	doc.Metadata.CustomFields["subtitleData"] = data
+	doc.Metadata.CustomFields["subtitleCues"] = cues
+
+	p.logger.Debug("parsed subtitles",
+		zap.String("format", string(p.format)),
+		zap.Int("cues", len(cues)),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// groupCues groups consecutive cues that continue the same sentence
+func (p *SubtitleProcessor) groupCues(cues []*SubtitleCue) [][]int {
+	var units [][]int
+	var current []int
+
+	for i, cue := range cues {
+		if !isTranslatableCellText(cue.Body) {
+			if len(current) > 0 {
+				units = append(units, current)
+				current = nil
+			}
+			continue
+		}
+
+		if len(current) > 0 {
+			last := cues[current[len(current)-1]]
+			if !p.options.MergeSentences || len(current) >= p.options.MaxMergeCues ||
+				endsSubtitleSentence(last.Body) || cue.Start-last.End > p.options.MaxMergeGap {
+				units = append(units, current)
+				current = nil
+			}
+		}
+		current = append(current, i)
+	}
+	if len(current) > 0 {
+		units = append(units, current)
+	}
+	return units
+}
+
+// Process processes the document through translation, re-translating units
+// that break the subtitle constraints with a request to be concise
+func (p *SubtitleProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	ctx = WithTranslationNotes(ctx, p.TranslationNotes())
+
+	translations := make(map[int]string)
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		translatedText, err := translator(ctx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate block",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+		translations[i] = translatedText
+	}
+
+	EnforceTranslationConstraints(ctx, p, doc, translations, func(retryCtx context.Context, blockIndexes []int) map[int]string {
+		retried := make(map[int]string, len(blockIndexes))
+		for _, i := range blockIndexes {
+			if text, err := translator(retryCtx, doc.Blocks[i].GetContent()); err == nil {
+				retried[i] = text
+			}
+		}
+		return retried
+	}, p.logger)
+
+	for i, translatedText := range translations {
+		doc.Blocks[i].SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// Render renders the document back to the subtitle format. Timing lines,
+// cue numbers and style tags wrapping each cue are kept as they are.
+func (p *SubtitleProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	data, ok := doc.Metadata.CustomFields["subtitleData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original %s data not found in document metadata", p.format)
+	}
+	cues, ok := doc.Metadata.CustomFields["subtitleCues"].([]*SubtitleCue)
+	if !ok {
+		return fmt.Errorf("%s cue index not found in document metadata", p.format)
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	lineBreak := newline
+	if p.format == FormatASS {
+		lineBreak = `\N`
+	}
+
+	var replacements []byteRangeReplacement
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		if source, _ := attrs["subtitleSource"].(string); block.GetContent() == source {
+			continue
+		}
+		unit := p.blockCues(cues, block)
+		if unit == nil {
+			continue
+		}
+
+		for i, part := range p.distribute(block.GetContent(), unit) {
+			cue := unit[i]
+			lines := p.wrapLines(part, cue)
+			replacements = append(replacements, byteRangeReplacement{
+				start: cue.TextStart,
+				end:   cue.TextEnd,
+				text:  cue.Prefix + strings.Join(lines, lineBreak) + cue.Suffix,
+			})
+		}
+	}
+
+	_, err := output.Write(applyByteRangeReplacements(data, replacements))
+	return err
+}
+
+// GetFormat returns the format type
+func (p *SubtitleProcessor) GetFormat() Format {
+	return p.format
+}
+
+// ProtectContent protects style tags and common patterns
+func (p *SubtitleProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	text = pp.ProtectPattern(text, subtitleTagPattern.String())
+	if p.format == FormatASS {
+		text = pp.ProtectPattern(text, `\\h`)
+	}
+
+	return p.protector.ProtectContent(text, pp)
+}
+
+// TranslationNotes describes the subtitle constraints for the translation prompt
+func (p *SubtitleProcessor) TranslationNotes() string {
+	var limits []string
+	if p.options.MaxCharsPerLine > 0 {
+		limits = append(limits, fmt.Sprintf("at most %d characters per line", p.options.MaxCharsPerLine))
+	}
+	if p.options.MaxLines > 0 {
+		limits = append(limits, fmt.Sprintf("at most %d lines per subtitle", p.options.MaxLines))
+	}
+	if p.options.MaxCharsPerSecond > 0 {
+		limits = append(limits, fmt.Sprintf("a reading speed of at most %g characters per second", p.options.MaxCharsPerSecond))
+	}
+
+	notes := "The text is subtitles; a segment may span several consecutive subtitles. " +
+		"Translate naturally and concisely, and keep style tags such as <i> or {\\an8} unchanged."
+	if len(limits) > 0 {
+		notes += " The translation must fit " + strings.Join(limits, ", ") +
+			" (East Asian characters count as two)."
+	}
+	return notes
+}
+
+// CheckTranslation redistributes a translated unit over its cues and reports
+// the cues that break the line length, line count or reading speed limits
+func (p *SubtitleProcessor) CheckTranslation(doc *Document, block Block, translated string) string {
+	cues, ok := doc.Metadata.CustomFields["subtitleCues"].([]*SubtitleCue)
+	if !ok {
+		return ""
+	}
+	unit := p.blockCues(cues, block)
+	if unit == nil {
+		return ""
+	}
+
+	var violations []string
+	for i, part := range p.distribute(translated, unit) {
+		cue := unit[i]
+		lines := p.wrapLines(part, cue)
+
+		if p.options.MaxLines > 0 && len(lines) > p.options.MaxLines {
+			violations = append(violations, fmt.Sprintf("cue %s needs %d lines (max %d)", cue.ID, len(lines), p.options.MaxLines))
+		}
+		if p.options.MaxCharsPerLine > 0 {
+			for _, line := range lines {
+				if width := subtitleTextWidth(line); width > p.options.MaxCharsPerLine {
+					violations = append(violations, fmt.Sprintf("cue %s has a %d character line (max %d)", cue.ID, width, p.options.MaxCharsPerLine))
+					break
+				}
+			}
+		}
+		if duration := (cue.End - cue.Start).Seconds(); p.options.MaxCharsPerSecond > 0 && duration > 0 {
+			if rate := float64(subtitleTextWidth(part)) / duration; rate > p.options.MaxCharsPerSecond {
+				violations = append(violations, fmt.Sprintf("cue %s needs %.1f characters per second (max %g)", cue.ID, rate, p.options.MaxCharsPerSecond))
+			}
+		}
+	}
+	return strings.Join(violations, "; ")
+}
+
+// blockCues returns the cues of a translation unit
+func (p *SubtitleProcessor) blockCues(cues []*SubtitleCue, block Block) []*SubtitleCue {
+	indexes, ok := block.GetMetadata().Attributes["subtitleCues"].([]int)
+	if !ok || len(indexes) == 0 {
+		return nil
+	}
+	unit := make([]*SubtitleCue, 0, len(indexes))
+	for _, index := range indexes {
+		if index < 0 || index >= len(cues) {
+			return nil
+		}
+		unit = append(unit, cues[index])
+	}
+	return unit
+}
+
+// distribute splits a translated unit over its cues in proportion to the
+// width of the original cue texts
+func (p *SubtitleProcessor) distribute(text string, cues []*SubtitleCue) []string {
+	if len(cues) == 1 {
+		return []string{strings.TrimSpace(text)}
+	}
+	weights := make([]float64, len(cues))
+	for i, cue := range cues {
+		weights[i] = float64(subtitleTextWidth(cue.Body))
+	}
+	return splitSubtitleText(text, weights)
+}
+
+// wrapLines breaks the text of a cue into balanced display lines. Without a
+// line length limit the original number of lines is kept.
+func (p *SubtitleProcessor) wrapLines(text string, cue *SubtitleCue) []string {
+	var lines []string
+	for _, segment := range strings.Split(text, "\n") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		width := subtitleTextWidth(segment)
+		count := 1
+		if p.options.MaxCharsPerLine > 0 {
+			count = (width + p.options.MaxCharsPerLine - 1) / p.options.MaxCharsPerLine
+		} else if !strings.Contains(text, "\n") && len(cue.Lines) > 1 {
+			count = len(cue.Lines)
+		}
+		words := len(tokenizeSubtitleText(segment))
+		if count > words {
+			count = words
+		}
+		if count < 1 {
+			count = 1
+		}
+
+		for {
+			parts := splitSubtitleText(segment, equalSubtitleWeights(count))
+			if count >= words || p.options.MaxCharsPerLine <= 0 || fitsSubtitleWidth(parts, p.options.MaxCharsPerLine) {
+				for _, part := range parts {
+					if part != "" {
+						lines = append(lines, part)
+					}
+				}
+				break
+			}
+			count++
+		}
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// subtitleWord is an unbreakable piece of subtitle text
+type subtitleWord struct {
+	text          string
+	spaceBefore   bool
+	newlineBefore bool
+}
+
+// tokenizeSubtitleText splits text into words. East Asian characters are
+// words of their own; style tags and punctuation stick to the adjacent word.
+func tokenizeSubtitleText(text string) []subtitleWord {
+	var words []subtitleWord
+	var current strings.Builder
+	visible, wide := false, false
+	spaceBefore, newlineBefore := false, false
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, subtitleWord{text: current.String(), spaceBefore: spaceBefore, newlineBefore: newlineBefore})
+			current.Reset()
+			spaceBefore, newlineBefore = false, false
+		}
+		visible, wide = false, false
+	}
+
+	tags := subtitleTagPattern.FindAllStringIndex(text, -1)
+	for i := 0; i < len(text); {
+		if len(tags) > 0 && tags[0][0] == i {
+			current.WriteString(text[i:tags[0][1]])
+			i = tags[0][1]
+			tags = tags[1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			if len(words) > 0 {
+				spaceBefore = true
+				newlineBefore = newlineBefore || r == '\n'
+			}
+		case unicode.IsPunct(r):
+			current.WriteRune(r)
+		case isWideRune(r):
+			if visible {
+				flush()
+			}
+			current.WriteRune(r)
+			visible, wide = true, true
+		default:
+			if wide {
+				flush()
+			}
+			current.WriteRune(r)
+			visible = true
+		}
+	}
+	flush()
+	return words
+}
+
+// splitSubtitleText splits text into len(weights) parts whose widths follow
+// the weights, preferring breaks after punctuation. Parts may be empty when
+// the text has fewer words than parts.
+func splitSubtitleText(text string, weights []float64) []string {
+	parts := make([]string, len(weights))
+	words := tokenizeSubtitleText(text)
+	if len(weights) == 0 {
+		return parts
+	}
+
+	// cumulative[i] is the width of words[:i] including separating spaces
+	cumulative := make([]float64, len(words)+1)
+	for i, word := range words {
+		width := float64(subtitleTextWidth(word.text))
+		if i > 0 && word.spaceBefore {
+			width++
+		}
+		cumulative[i+1] = cumulative[i] + width
+	}
+	total := cumulative[len(words)]
+
+	weightSum := 0.0
+	for _, weight := range weights {
+		weightSum += weight
+	}
+	if weightSum <= 0 {
+		weights = equalSubtitleWeights(len(weights))
+		weightSum = float64(len(weights))
+	}
+
+	boundaries := make([]int, 0, len(weights)+1)
+	boundaries = append(boundaries, 0)
+	accumulated := 0.0
+	for k := 0; k < len(weights)-1; k++ {
+		accumulated += weights[k]
+		target := total * accumulated / weightSum
+		previous := boundaries[len(boundaries)-1]
+
+		// Leave at least one word for each remaining part
+		low, high := previous+1, len(words)-(len(weights)-1-k)
+		if low > high {
+			boundaries = append(boundaries, min(low, len(words)))
+			continue
+		}
+
+		// Breaking after punctuation is worth a quarter of a part's width
+		bonus := total / float64(len(weights)) / 4
+		best, bestCost := low, math.Inf(1)
+		for i := low; i <= high; i++ {
+			cost := math.Abs(cumulative[i] - target)
+			if last, _ := utf8.DecodeLastRuneInString(subtitleTagPattern.ReplaceAllString(words[i-1].text, "")); unicode.IsPunct(last) {
+				cost -= bonus
+			}
+			if cost < bestCost {
+				best, bestCost = i, cost
+			}
+		}
+		boundaries = append(boundaries, best)
+	}
+	boundaries = append(boundaries, len(words))
+
+	for k := range parts {
+		var builder strings.Builder
+		for i := boundaries[k]; i < boundaries[k+1]; i++ {
+			word := words[i]
+			if i > boundaries[k] {
+				switch {
+				case word.newlineBefore:
+					builder.WriteString("\n")
+				case word.spaceBefore:
+					builder.WriteString(" ")
+				}
+			}
+			builder.WriteString(word.text)
+		}
+		parts[k] = builder.String()
+	}
+	return parts
+}
+
+// equalSubtitleWeights returns n equal weights
+func equalSubtitleWeights(n int) []float64 {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights
+}
+
+// fitsSubtitleWidth reports whether every line fits the width
+func fitsSubtitleWidth(lines []string, maxWidth int) bool {
+	for _, line := range lines {
+		if subtitleTextWidth(line) > maxWidth {
+			return false
+		}
+	}
+	return true
+}
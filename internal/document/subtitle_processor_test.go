@@ -0,0 +1,201 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testSRT = "1\r\n00:00:01,000 --> 00:00:03,000\r\n<i>Welcome to the conference,</i>\r\n\r\n" +
+	"2\r\n00:00:03,200 --> 00:00:05,000\r\nwhere we talk about\r\n\r\n" +
+	"3\r\n00:00:05,100 --> 00:00:07,000\r\nsubtitles.\r\n\r\n" +
+	"4\r\n00:00:09,000 --> 00:00:10,000\r\n♪ ♪\r\n"
+
+const testVTT = "WEBVTT\n\nNOTE speaker notes\n\nintro\n00:01.000 --> 00:04.000 align:start\n<v Ann>Hello everyone.\n\n00:05.000 --> 00:08.000\nThanks for\ncoming today!\n"
+
+const testASS = "[Script Info]\nTitle: Talk\n\n[Events]\n" +
+	"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+	"Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,{\\an8}Good morning,\\Nfriends!\n" +
+	"Comment: 0,0:00:04.00,0:00:05.00,Default,,0,0,0,,Not shown\n"
+
+// parseTestSubtitles parses subtitles with the given metadata
+func parseTestSubtitles(t *testing.T, format Format, input string, metadata map[string]interface{}) (*SubtitleProcessor, *Document) {
+	t.Helper()
+
+	processor, err := NewSubtitleProcessor(ProcessorOptions{Metadata: metadata}, zap.NewNop(), format)
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", format, err)
+	}
+	return processor, doc
+}
+
+// renderTestSubtitles renders a document with a new processor
+func renderTestSubtitles(t *testing.T, format Format, doc *Document, metadata map[string]interface{}) string {
+	t.Helper()
+
+	renderer, _ := NewSubtitleProcessor(ProcessorOptions{Metadata: metadata}, zap.NewNop(), format)
+	var output bytes.Buffer
+	if err := renderer.Render(context.Background(), doc, &output); err != nil {
+		t.Fatalf("Failed to render %s: %v", format, err)
+	}
+	return output.String()
+}
+
+func TestSubtitleProcessor(t *testing.T) {
+	t.Run("SRTMergesSentenceAcrossCues", func(t *testing.T) {
+		_, doc := parseTestSubtitles(t, FormatSRT, testSRT, nil)
+
+		if len(doc.Blocks) != 1 {
+			t.Fatalf("Expected 1 merged block, got %d", len(doc.Blocks))
+		}
+		expected := "Welcome to the conference, where we talk about subtitles."
+		if got := doc.Blocks[0].GetContent(); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+
+		doc.Blocks[0].SetContent("欢迎来到大会，我们在这里讨论字幕。")
+		output := renderTestSubtitles(t, FormatSRT, doc, nil)
+
+		for _, want := range []string{
+			"1\r\n00:00:01,000 --> 00:00:03,000\r\n<i>欢迎来到大会，</i>\r\n\r\n",
+			"2\r\n00:00:03,200 --> 00:00:05,000\r\n",
+			"3\r\n00:00:05,100 --> 00:00:07,000\r\n",
+			"字幕。\r\n\r\n4\r\n00:00:09,000 --> 00:00:10,000\r\n♪ ♪\r\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected output to contain %q, got %q", want, output)
+			}
+		}
+		if plain := strings.NewReplacer("\r\n", "", "<i>", "", "</i>", "").Replace(output); !strings.Contains(plain, "我们在这里讨论") {
+			t.Errorf("Expected translation spread over cues, got %q", output)
+		}
+	})
+
+	t.Run("SRTWithoutMerging", func(t *testing.T) {
+		_, doc := parseTestSubtitles(t, FormatSRT, testSRT, map[string]interface{}{
+			"subtitle_merge_sentences": false,
+		})
+		if len(doc.Blocks) != 3 {
+			t.Fatalf("Expected 3 blocks, got %d", len(doc.Blocks))
+		}
+		if got := doc.Blocks[0].GetContent(); got != "Welcome to the conference," {
+			t.Errorf("Expected styling tags outside the text, got %q", got)
+		}
+	})
+
+	t.Run("WebVTTKeepsIdentifiersAndSettings", func(t *testing.T) {
+		_, doc := parseTestSubtitles(t, FormatVTT, testVTT, nil)
+
+		var sources []string
+		for _, block := range doc.Blocks {
+			sources = append(sources, block.GetContent())
+		}
+		expected := []string{"Hello everyone.", "Thanks for coming today!"}
+		if strings.Join(sources, "|") != strings.Join(expected, "|") {
+			t.Fatalf("Expected blocks %q, got %q", expected, sources)
+		}
+
+		doc.Blocks[0].SetContent("大家好。")
+		doc.Blocks[1].SetContent("感谢大家今天的到来！")
+		output := renderTestSubtitles(t, FormatVTT, doc, nil)
+
+		expectedOutput := "WEBVTT\n\nNOTE speaker notes\n\nintro\n00:01.000 --> 00:04.000 align:start\n<v Ann>大家好。\n\n" +
+			"00:05.000 --> 00:08.000\n感谢大家今天的到来！\n"
+		if output != expectedOutput {
+			t.Errorf("Expected %q, got %q", expectedOutput, output)
+		}
+	})
+
+	t.Run("ASSKeepsOverrideTagsAndFields", func(t *testing.T) {
+		_, doc := parseTestSubtitles(t, FormatASS, testASS, nil)
+
+		if len(doc.Blocks) != 1 || doc.Blocks[0].GetContent() != "Good morning, friends!" {
+			t.Fatalf("Expected one dialogue block, got %d", len(doc.Blocks))
+		}
+
+		doc.Blocks[0].SetContent("朋友们，早上好！")
+		output := renderTestSubtitles(t, FormatASS, doc, map[string]interface{}{
+			"subtitle_max_chars_per_line": 10,
+		})
+
+		want := "Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,{\\an8}朋友们，\\N早上好！\n"
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, output)
+		}
+		if !strings.Contains(output, "Comment: 0,0:00:04.00,0:00:05.00,Default,,0,0,0,,Not shown\n") {
+			t.Errorf("Expected comment line untouched, got %q", output)
+		}
+	})
+
+	t.Run("CheckTranslationReportsViolations", func(t *testing.T) {
+		processor, doc := parseTestSubtitles(t, FormatVTT, testVTT, nil)
+
+		if violation := processor.CheckTranslation(doc, doc.Blocks[0], "大家好。"); violation != "" {
+			t.Errorf("Expected no violation, got %q", violation)
+		}
+		long := strings.Repeat("非常", 30)
+		violation := processor.CheckTranslation(doc, doc.Blocks[0], long)
+		if !strings.Contains(violation, "lines") || !strings.Contains(violation, "characters per second") {
+			t.Errorf("Expected line count and reading speed violations, got %q", violation)
+		}
+	})
+
+	t.Run("ProcessRetranslatesConcisely", func(t *testing.T) {
+		processor, doc := parseTestSubtitles(t, FormatVTT, testVTT, nil)
+
+		var notes []string
+		translator := func(ctx context.Context, text string) (string, error) {
+			note, _ := ctx.Value(translationNotesKey).(string)
+			notes = append(notes, note)
+			if strings.Contains(note, ConciseRetranslationNote) {
+				return "谢谢！", nil
+			}
+			return strings.Repeat("非常感谢", 20), nil
+		}
+
+		if _, err := processor.Process(context.Background(), doc, translator); err != nil {
+			t.Fatalf("Failed to process: %v", err)
+		}
+		if got := doc.Blocks[1].GetContent(); got != "谢谢！" {
+			t.Errorf("Expected concise retranslation, got %q", got)
+		}
+		if len(notes) == 0 || !strings.Contains(notes[0], "at most 42 characters per line") {
+			t.Errorf("Expected constraints in translation notes, got %q", notes)
+		}
+	})
+}
+
+func TestSubtitleHelpers(t *testing.T) {
+	t.Run("ParseTimestamp", func(t *testing.T) {
+		for value, expected := range map[string]time.Duration{
+			"00:00:01,500": 1500 * time.Millisecond,
+			"01:02.250":    62250 * time.Millisecond,
+			"1:00:00.50":   time.Hour + 500*time.Millisecond,
+		} {
+			got, err := parseSubtitleTimestamp(value)
+			if err != nil || got != expected {
+				t.Errorf("Expected %s -> %v, got %v (%v)", value, expected, got, err)
+			}
+		}
+	})
+
+	t.Run("SplitProportionally", func(t *testing.T) {
+		parts := splitSubtitleText("one two three four", []float64{1, 1})
+		if strings.Join(parts, "|") != "one two|three four" {
+			t.Errorf("Expected balanced split, got %q", parts)
+		}
+
+		parts = splitSubtitleText("<i>short</i>", []float64{1, 1, 1})
+		if len(parts) != 3 || parts[0] != "<i>short</i>" || parts[1] != "" {
+			t.Errorf("Expected surplus parts to be empty, got %q", parts)
+		}
+	})
+}
@@ -216,6 +216,11 @@ func (p *TextProcessor) Render(ctx context.Context, doc *Document, output io.Wri
 	return err
 }
 
+// MarkUntranslated 在未翻译的段落前加上标记
+func (p *TextProcessor) MarkUntranslated(block Block, content string) string {
+	return "[UNTRANSLATED] " + content
+}
+
 // GetFormat 返回处理器支持的格式
 func (p *TextProcessor) GetFormat() Format {
 	return FormatText
@@ -132,10 +132,8 @@ func (p *TextBundleProcessor) Parse(ctx context.Context, input io.Reader) (*Docu
 
 	// Update document metadata
 	doc.Format = FormatTextBundle
-	doc.Metadata.CustomFields = map[string]interface{}{
-		"bundlePath": bundlePath,
-		"bundleInfo": bundleInfo,
-	}
+	doc.Metadata.CustomFields["bundlePath"] = bundlePath
+	doc.Metadata.CustomFields["bundleInfo"] = bundleInfo
 
 	// Load assets as resources
 	assetsPath := filepath.Join(bundlePath, "assets")
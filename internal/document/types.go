@@ -8,16 +8,31 @@ import (
 type Format string
 
 const (
-	FormatMarkdown   Format = "markdown"
-	FormatText       Format = "text"
-	FormatHTML       Format = "html"
-	FormatEPUB       Format = "epub"
-	FormatLaTeX      Format = "latex"
-	FormatPDF        Format = "pdf"
-	FormatDOCX       Format = "docx"
-	FormatTextBundle Format = "textbundle"
-	FormatTextPack   Format = "textpack"
-	FormatUnknown    Format = "unknown"
+	FormatMarkdown       Format = "markdown"
+	FormatMDX            Format = "mdx"
+	FormatNotebook       Format = "ipynb"
+	FormatText           Format = "text"
+	FormatHTML           Format = "html"
+	FormatEPUB           Format = "epub"
+	FormatLaTeX          Format = "latex"
+	FormatPDF            Format = "pdf"
+	FormatDOCX           Format = "docx"
+	FormatPPTX           Format = "pptx"
+	FormatXLSX           Format = "xlsx"
+	FormatODT            Format = "odt"
+	FormatODP            Format = "odp"
+	FormatSRT            Format = "srt"
+	FormatVTT            Format = "vtt"
+	FormatASS            Format = "ass"
+	FormatPO             Format = "po"
+	FormatI18nJSON       Format = "i18n-json"
+	FormatI18nYAML       Format = "i18n-yaml"
+	FormatAndroidStrings Format = "android-strings"
+	FormatAppleStrings   Format = "strings"
+	FormatXCStrings      Format = "xcstrings"
+	FormatTextBundle     Format = "textbundle"
+	FormatTextPack       Format = "textpack"
+	FormatUnknown        Format = "unknown"
 )
 
 // Document 表示一个文档
@@ -36,6 +51,9 @@ type Document struct {
 
 	// Resources 文档资源（图片、样式等）
 	Resources map[string]Resource
+
+	// Brief 文档简介（主题、领域、语气、读者和关键实体），翻译时加入每个请求的提示词
+	Brief string
 }
 
 // DocumentMetadata 文档元数据
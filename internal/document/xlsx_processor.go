@@ -0,0 +1,997 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgdoc "github.com/nerdneilsfield/go-translator-agent/pkg/document"
+	"go.uber.org/zap"
+)
+
+const (
+	xlsxWorkbookPart      = "xl/workbook.xml"
+	xlsxWorkbookRelsPart  = "xl/_rels/workbook.xml.rels"
+	xlsxAppPropertiesPart = "docProps/app.xml"
+	xlsxChartsPrefix      = "xl/charts/"
+	xlsxPivotCachePrefix  = "xl/pivotCache/pivotCacheDefinition"
+
+	xlsxRelTypeSharedStrings = "/sharedStrings"
+	xlsxRelTypeComments      = "/comments"
+)
+
+var (
+	// xlsxFormulaPattern matches formula elements in a worksheet
+	xlsxFormulaPattern = regexp.MustCompile(`(?s)(<f(?:\s[^>]*)?>)(.*?)(</f>)`)
+	// xlsxSheetFormulaPatterns match every worksheet element holding a formula that may
+	// reference another sheet: cell formulas, data validations and conditional formatting
+	xlsxSheetFormulaPatterns = []*regexp.Regexp{
+		xlsxFormulaPattern,
+		regexp.MustCompile(`(?s)(<formula(?:\s[^>]*)?>)(.*?)(</formula>)`),
+		regexp.MustCompile(`(?s)(<formula1(?:\s[^>]*)?>)(.*?)(</formula1>)`),
+		regexp.MustCompile(`(?s)(<formula2(?:\s[^>]*)?>)(.*?)(</formula2>)`),
+		regexp.MustCompile(`(?s)(<xm:f(?:\s[^>]*)?>)(.*?)(</xm:f>)`),
+	}
+	// xlsxChartFormulaPatterns match series and label references in chart parts
+	xlsxChartFormulaPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)(<c:f(?:\s[^>]*)?>)(.*?)(</c:f>)`),
+		regexp.MustCompile(`(?s)(<cx:f(?:\s[^>]*)?>)(.*?)(</cx:f>)`),
+	}
+	// xlsxPivotSourcePattern matches the sheet attribute of a pivot cache source
+	xlsxPivotSourcePattern = regexp.MustCompile(`(<worksheetSource\b[^>]*\ssheet=")([^"]*)(")`)
+	// xlsxDefinedNamePattern matches defined names in the workbook
+	xlsxDefinedNamePattern = regexp.MustCompile(`(?s)(<definedName(?:\s[^>]*)?>)(.*?)(</definedName>)`)
+	// xlsxSheetNameAttrPattern matches the name attribute of a <sheet> element
+	xlsxSheetNameAttrPattern = regexp.MustCompile(`\sname="[^"]*"`)
+	// xlsxUniqueCountPattern matches the uniqueCount attribute of <sst>
+	xlsxUniqueCountPattern = regexp.MustCompile(`uniqueCount="(\d+)"`)
+
+	xlsxTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xlsxAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// XlsxOptions controls which cells of a workbook are translated
+type XlsxOptions struct {
+	SkipSheets          []string // sheet names that are left untouched
+	SkipColumns         []string // columns to skip, "A" for every sheet or "Sheet1!A"
+	ColumnMappings      []string // column mapping mode, "B:C" or "Sheet1!B:C" translates B into C
+	TranslateSheetNames bool     // translate sheet tab names
+	TranslateComments   bool     // translate cell comments
+}
+
+// xlsxColumnMapping translates the source column into the target column
+type xlsxColumnMapping struct {
+	Source int
+	Target int
+}
+
+// XlsxSheet is a worksheet with its scanned rows and comments
+type XlsxSheet struct {
+	Entry        *XlsxSheetEntry
+	Part         string
+	Rows         []*XlsxRow
+	CommentsPart string
+	Comments     []*XlsxComment
+	Skipped      bool
+	Mappings     []xlsxColumnMapping
+}
+
+// xlsxCellLocation addresses a cell by sheet, row and cell index
+type xlsxCellLocation struct {
+	Sheet int
+	Row   int
+	Cell  int
+}
+
+// XlsxSharedUsage records which cells reference a shared string
+type XlsxSharedUsage struct {
+	Translatable []xlsxCellLocation
+	Skipped      int
+}
+
+// XlsxWorkbook is the scanned state of a workbook kept between Parse and Render
+type XlsxWorkbook struct {
+	SharedStringsPart string
+	SharedStrings     []*XlsxRichText
+	SharedStringsEnd  int64
+	SharedUsage       map[int]*XlsxSharedUsage
+	Sheets            []*XlsxSheet
+}
+
+// XlsxProcessor processes XLSX format documents
+type XlsxProcessor struct {
+	opts      ProcessorOptions
+	logger    *zap.Logger
+	extractor *XlsxTextExtractor
+	protector pkgdoc.ContentProtector
+	options   XlsxOptions
+}
+
+// NewXlsxProcessor creates a new XLSX processor
+func NewXlsxProcessor(opts ProcessorOptions, logger *zap.Logger) (*XlsxProcessor, error) {
+	// Set defaults
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 2000
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = 100
+	}
+
+	options := getXlsxOptionsFromOptions(opts)
+	for _, mapping := range options.ColumnMappings {
+		if _, _, ok := parseColumnMapping(mapping); !ok {
+			return nil, fmt.Errorf("invalid column mapping %q, expected \"B:C\" or \"Sheet1!B:C\"", mapping)
+		}
+	}
+
+	return &XlsxProcessor{
+		opts:      opts,
+		logger:    logger,
+		extractor: NewXlsxTextExtractor(logger),
+		protector: pkgdoc.GetProtectorForFormat("text"),
+		options:   options,
+	}, nil
+}
+
+// Parse parses an XLSX file into a Document
+func (p *XlsxProcessor) Parse(ctx context.Context, input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+
+	workbook, err := p.scanWorkbook(files)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		ID:     fmt.Sprintf("xlsx-%d", time.Now().Unix()),
+		Format: FormatXLSX,
+		Metadata: DocumentMetadata{
+			CreatedAt:    time.Now(),
+			CustomFields: make(map[string]interface{}),
+		},
+		Blocks:    []Block{},
+		Resources: make(map[string]Resource),
+	}
+
+	emittedShared := make(map[int]bool)
+	for sheetIndex, sheet := range workbook.Sheets {
+		if sheet.Skipped {
+			continue
+		}
+
+		if p.options.TranslateSheetNames && isTranslatableCellText(sheet.Entry.Name) {
+			p.addBlock(doc, BlockTypeHeading, sheet.Entry.Name, map[string]interface{}{
+				"xlsxKind":  "sheetName",
+				"xlsxSheet": sheetIndex,
+			})
+		}
+
+		for rowIndex, row := range sheet.Rows {
+			for cellIndex, cell := range row.Cells {
+				if len(sheet.Mappings) > 0 {
+					p.addMappingBlocks(doc, workbook, sheetIndex, rowIndex, cellIndex)
+					continue
+				}
+				if cell.HasFormula || p.isColumnSkipped(sheet.Entry.Name, cell.Column) {
+					continue
+				}
+
+				switch cell.Type {
+				case "s":
+					index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+					if err != nil || emittedShared[index] || workbook.SharedUsage[index] == nil {
+						continue
+					}
+					emittedShared[index] = true
+					text := p.extractor.ExtractText(workbook.SharedStrings[index])
+					if isTranslatableCellText(text) {
+						p.addBlock(doc, BlockTypeParagraph, text, map[string]interface{}{
+							"xlsxKind":  "shared",
+							"xlsxIndex": index,
+						})
+					}
+				case "inlineStr":
+					if cell.Inline == nil {
+						continue
+					}
+					text := p.extractor.ExtractText(cell.Inline)
+					if isTranslatableCellText(text) {
+						p.addBlock(doc, BlockTypeParagraph, text, map[string]interface{}{
+							"xlsxKind":  "inline",
+							"xlsxSheet": sheetIndex,
+							"xlsxRow":   rowIndex,
+							"xlsxCell":  cellIndex,
+						})
+					}
+				}
+			}
+		}
+
+		for commentIndex, comment := range sheet.Comments {
+			text := p.extractor.ExtractText(comment.Text)
+			if isTranslatableCellText(text) {
+				p.addBlock(doc, BlockTypeParagraph, text, map[string]interface{}{
+					"xlsxKind":  "comment",
+					"xlsxSheet": sheetIndex,
+					"xlsxIndex": commentIndex,
+				})
+			}
+		}
+	}
+
+	// Store XLSX data for later rendering
+	doc.Metadata.CustomFields["xlsxData"] = data
+	doc.Metadata.CustomFields["xlsxWorkbook"] = workbook
+
+	p.logger.Debug("parsed XLSX document",
+		zap.Int("sheets", len(workbook.Sheets)),
+		zap.Int("sharedStrings", len(workbook.SharedStrings)),
+		zap.Int("blocks", len(doc.Blocks)))
+
+	return doc, nil
+}
+
+// Process processes the document through translation
+func (p *XlsxProcessor) Process(ctx context.Context, doc *Document, translator TranslateFunc) (*Document, error) {
+	for i, block := range doc.Blocks {
+		if !block.IsTranslatable() {
+			continue
+		}
+
+		translatedText, err := translator(ctx, block.GetContent())
+		if err != nil {
+			p.logger.Warn("failed to translate block",
+				zap.Int("index", i),
+				zap.Error(err))
+			continue
+		}
+
+		block.SetContent(translatedText)
+	}
+
+	return doc, nil
+}
+
+// xlsxRenderState collects translated content per target before splicing
+type xlsxRenderState struct {
+	shared   map[int]string
+	inline   map[xlsxCellLocation]string
+	mappings map[int][]xlsxMappingTranslation
+	comments map[int]map[int]string
+	renames  map[string]string
+	names    map[int]string
+}
+
+// xlsxMappingTranslation is a translated source cell for column mapping mode
+type xlsxMappingTranslation struct {
+	location xlsxCellLocation
+	target   int
+	text     string
+}
+
+// Render renders the document back to XLSX format
+func (p *XlsxProcessor) Render(ctx context.Context, doc *Document, output io.Writer) error {
+	xlsxData, ok := doc.Metadata.CustomFields["xlsxData"].([]byte)
+	if !ok {
+		return fmt.Errorf("original XLSX data not found in document metadata")
+	}
+	workbook, ok := doc.Metadata.CustomFields["xlsxWorkbook"].(*XlsxWorkbook)
+	if !ok {
+		return fmt.Errorf("XLSX workbook index not found in document metadata")
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(xlsxData), int64(len(xlsxData)))
+	if err != nil {
+		return fmt.Errorf("failed to read XLSX: %w", err)
+	}
+
+	state := p.collectTranslations(doc, workbook)
+
+	modified := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		original, err := readZipFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		if updated, changed := p.renderPart(file.Name, original, workbook, state); changed {
+			modified[file.Name] = updated
+		}
+	}
+
+	zipWriter := zip.NewWriter(output)
+	for _, file := range zipReader.File {
+		if content, ok := modified[file.Name]; ok {
+			if err := writeZipEntry(zipWriter, file, content); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := zipWriter.Copy(file); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// GetFormat returns the format type
+func (p *XlsxProcessor) GetFormat() Format {
+	return FormatXLSX
+}
+
+// ProtectContent protects inline run tags and common patterns
+func (p *XlsxProcessor) ProtectContent(text string, patternProtector interface{}) string {
+	pp, ok := patternProtector.(pkgdoc.PatternProtector)
+	if !ok {
+		p.logger.Warn("invalid pattern protector type, skipping protection")
+		return text
+	}
+
+	for _, pattern := range inlineRunTagProtectPatterns {
+		text = pp.ProtectPattern(text, pattern)
+	}
+
+	return p.protector.ProtectContent(text, pp)
+}
+
+// scanWorkbook scans the workbook, shared strings, worksheets and comments
+func (p *XlsxProcessor) scanWorkbook(files map[string]*zip.File) (*XlsxWorkbook, error) {
+	workbookFile, ok := files[xlsxWorkbookPart]
+	if !ok {
+		return nil, fmt.Errorf("%s not found, not a valid XLSX file", xlsxWorkbookPart)
+	}
+	workbookData, err := readZipFile(workbookFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", xlsxWorkbookPart, err)
+	}
+	entries, err := p.extractor.ScanWorkbookSheets(workbookData)
+	if err != nil {
+		return nil, err
+	}
+
+	rels, err := readRelationships(files, xlsxWorkbookRelsPart)
+	if err != nil {
+		return nil, err
+	}
+
+	workbook := &XlsxWorkbook{SharedUsage: make(map[int]*XlsxSharedUsage)}
+
+	// Shared strings
+	for _, rel := range rels {
+		if strings.HasSuffix(rel.Type, xlsxRelTypeSharedStrings) {
+			workbook.SharedStringsPart = resolvePartPath(xlsxWorkbookPart, rel.Target)
+		}
+	}
+	if file, ok := files[workbook.SharedStringsPart]; ok {
+		sstData, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		workbook.SharedStrings, workbook.SharedStringsEnd, err = p.extractor.ScanSharedStrings(sstData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range entries {
+		rel, ok := rels[entry.RelID]
+		if !ok {
+			p.logger.Warn("sheet relationship not found", zap.String("sheet", entry.Name))
+			continue
+		}
+		sheet := &XlsxSheet{
+			Entry:    entry,
+			Part:     resolvePartPath(xlsxWorkbookPart, rel.Target),
+			Skipped:  p.isSheetSkipped(entry.Name),
+			Mappings: p.mappingsForSheet(entry.Name),
+		}
+		if err := p.scanSheet(files, workbook, sheet); err != nil {
+			return nil, err
+		}
+		workbook.Sheets = append(workbook.Sheets, sheet)
+	}
+
+	return workbook, nil
+}
+
+// scanSheet scans a worksheet and its comments and records shared string usage
+func (p *XlsxProcessor) scanSheet(files map[string]*zip.File, workbook *XlsxWorkbook, sheet *XlsxSheet) error {
+	file, ok := files[sheet.Part]
+	if !ok {
+		return fmt.Errorf("worksheet %s not found", sheet.Part)
+	}
+	sheetData, err := readZipFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", sheet.Part, err)
+	}
+	sheet.Rows, err = p.extractor.ScanWorksheet(sheetData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sheet.Part, err)
+	}
+
+	sheetIndex := len(workbook.Sheets)
+	for rowIndex, row := range sheet.Rows {
+		for cellIndex, cell := range row.Cells {
+			if cell.Type != "s" {
+				continue
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+			if err != nil || index < 0 || index >= len(workbook.SharedStrings) {
+				continue
+			}
+
+			usage := workbook.SharedUsage[index]
+			if usage == nil {
+				usage = &XlsxSharedUsage{}
+				workbook.SharedUsage[index] = usage
+			}
+
+			// Mapped sheets write translations elsewhere, the shared source stays as is
+			if sheet.Skipped || len(sheet.Mappings) > 0 || cell.HasFormula ||
+				p.isColumnSkipped(sheet.Entry.Name, cell.Column) {
+				usage.Skipped++
+				continue
+			}
+			usage.Translatable = append(usage.Translatable, xlsxCellLocation{Sheet: sheetIndex, Row: rowIndex, Cell: cellIndex})
+		}
+	}
+
+	if !p.options.TranslateComments || sheet.Skipped {
+		return nil
+	}
+
+	sheetRels, err := readRelationships(files, relationshipsPartFor(sheet.Part))
+	if err != nil {
+		return err
+	}
+	for _, rel := range sheetRels {
+		if !strings.HasSuffix(rel.Type, xlsxRelTypeComments) {
+			continue
+		}
+		sheet.CommentsPart = resolvePartPath(sheet.Part, rel.Target)
+		commentsFile, ok := files[sheet.CommentsPart]
+		if !ok {
+			continue
+		}
+		commentsData, err := readZipFile(commentsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", sheet.CommentsPart, err)
+		}
+		comments, err := p.extractor.ScanComments(commentsData)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sheet.CommentsPart, err)
+		}
+		for _, comment := range comments {
+			if column, _, ok := parseCellRef(comment.Ref); ok && p.isColumnSkipped(sheet.Entry.Name, column) {
+				continue
+			}
+			sheet.Comments = append(sheet.Comments, comment)
+		}
+	}
+
+	return nil
+}
+
+// addMappingBlocks adds a block when the cell is a mapped source column
+func (p *XlsxProcessor) addMappingBlocks(doc *Document, workbook *XlsxWorkbook, sheetIndex, rowIndex, cellIndex int) {
+	sheet := workbook.Sheets[sheetIndex]
+	cell := sheet.Rows[rowIndex].Cells[cellIndex]
+	if cell.HasFormula {
+		return
+	}
+
+	var text string
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(workbook.SharedStrings) {
+			return
+		}
+		text = p.extractor.PlainText(workbook.SharedStrings[index])
+	case "inlineStr":
+		if cell.Inline == nil {
+			return
+		}
+		text = p.extractor.PlainText(cell.Inline)
+	default:
+		return
+	}
+	if !isTranslatableCellText(text) {
+		return
+	}
+
+	for _, mapping := range sheet.Mappings {
+		if mapping.Source != cell.Column {
+			continue
+		}
+		p.addBlock(doc, BlockTypeParagraph, text, map[string]interface{}{
+			"xlsxKind":         "mapping",
+			"xlsxSheet":        sheetIndex,
+			"xlsxRow":          rowIndex,
+			"xlsxCell":         cellIndex,
+			"xlsxTargetColumn": mapping.Target,
+		})
+	}
+}
+
+// addBlock appends a translatable block remembering its source text
+func (p *XlsxProcessor) addBlock(doc *Document, blockType BlockType, text string, attrs map[string]interface{}) {
+	attrs["xlsxSource"] = text
+	level := 0
+	if blockType == BlockTypeHeading {
+		level = 1
+	}
+	doc.Blocks = append(doc.Blocks, &BaseBlock{
+		Type:         blockType,
+		Content:      text,
+		Translatable: true,
+		Metadata: BlockMetadata{
+			Level:      level,
+			Attributes: attrs,
+		},
+	})
+}
+
+// collectTranslations groups translated blocks by their target
+func (p *XlsxProcessor) collectTranslations(doc *Document, workbook *XlsxWorkbook) *xlsxRenderState {
+	state := &xlsxRenderState{
+		shared:   make(map[int]string),
+		inline:   make(map[xlsxCellLocation]string),
+		mappings: make(map[int][]xlsxMappingTranslation),
+		comments: make(map[int]map[int]string),
+		renames:  make(map[string]string),
+		names:    make(map[int]string),
+	}
+
+	usedNames := make(map[string]bool)
+	for _, sheet := range workbook.Sheets {
+		usedNames[strings.ToLower(sheet.Entry.Name)] = true
+	}
+
+	for _, block := range doc.Blocks {
+		attrs := block.GetMetadata().Attributes
+		kind, _ := attrs["xlsxKind"].(string)
+		source, _ := attrs["xlsxSource"].(string)
+		text := block.GetContent()
+		if text == source || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		sheetIndex, _ := attrs["xlsxSheet"].(int)
+		rowIndex, _ := attrs["xlsxRow"].(int)
+		cellIndex, _ := attrs["xlsxCell"].(int)
+		index, _ := attrs["xlsxIndex"].(int)
+		location := xlsxCellLocation{Sheet: sheetIndex, Row: rowIndex, Cell: cellIndex}
+
+		switch kind {
+		case "shared":
+			state.shared[index] = text
+		case "inline":
+			state.inline[location] = text
+		case "mapping":
+			target, _ := attrs["xlsxTargetColumn"].(int)
+			state.mappings[sheetIndex] = append(state.mappings[sheetIndex], xlsxMappingTranslation{
+				location: location,
+				target:   target,
+				text:     text,
+			})
+		case "comment":
+			if state.comments[sheetIndex] == nil {
+				state.comments[sheetIndex] = make(map[int]string)
+			}
+			state.comments[sheetIndex][index] = text
+		case "sheetName":
+			name := uniqueSheetName(sanitizeSheetName(text), usedNames)
+			if name == "" {
+				continue
+			}
+			usedNames[strings.ToLower(name)] = true
+			state.names[sheetIndex] = name
+			state.renames[workbook.Sheets[sheetIndex].Entry.Name] = name
+		}
+	}
+
+	return state
+}
+
+// renderPart returns the updated content of a part and whether it changed
+func (p *XlsxProcessor) renderPart(name string, original []byte, workbook *XlsxWorkbook, state *xlsxRenderState) ([]byte, bool) {
+	if name == workbook.SharedStringsPart && len(state.shared) > 0 {
+		return p.renderSharedStrings(original, workbook, state), true
+	}
+
+	if name == xlsxWorkbookPart && len(state.renames) > 0 {
+		return p.renderWorkbook(original, workbook, state), true
+	}
+
+	if name == xlsxAppPropertiesPart && len(state.renames) > 0 {
+		content := string(original)
+		for oldName, newName := range state.renames {
+			content = strings.ReplaceAll(content,
+				"<vt:lpstr>"+xlsxTextEscaper.Replace(oldName)+"</vt:lpstr>",
+				"<vt:lpstr>"+xlsxTextEscaper.Replace(newName)+"</vt:lpstr>")
+		}
+		return []byte(content), true
+	}
+
+	if len(state.renames) > 0 && strings.HasSuffix(name, ".xml") {
+		if strings.HasPrefix(name, xlsxChartsPrefix) {
+			result := original
+			for _, pattern := range xlsxChartFormulaPatterns {
+				result = renameFormulaReferences(result, pattern, state.renames)
+			}
+			return result, !bytes.Equal(result, original)
+		}
+		if strings.HasPrefix(name, xlsxPivotCachePrefix) {
+			result := renamePivotSources(original, state.renames)
+			return result, !bytes.Equal(result, original)
+		}
+	}
+
+	for sheetIndex, sheet := range workbook.Sheets {
+		if name == sheet.Part {
+			return p.renderSheet(original, workbook, sheetIndex, state)
+		}
+		if name == sheet.CommentsPart && len(state.comments[sheetIndex]) > 0 {
+			var replacements []byteRangeReplacement
+			for commentIndex, text := range state.comments[sheetIndex] {
+				rt := sheet.Comments[commentIndex].Text
+				replacements = append(replacements, byteRangeReplacement{
+					start: rt.InnerStart,
+					end:   rt.InnerEnd,
+					text:  p.extractor.BuildRichText(rt, text),
+				})
+			}
+			return applyByteRangeReplacements(original, replacements), true
+		}
+	}
+
+	return original, false
+}
+
+// renderSharedStrings replaces translated shared strings and appends copies for
+// strings that are also referenced by skipped cells
+func (p *XlsxProcessor) renderSharedStrings(original []byte, workbook *XlsxWorkbook, state *xlsxRenderState) []byte {
+	var replacements []byteRangeReplacement
+	var appended strings.Builder
+	added := 0
+
+	for _, index := range sortedKeys(state.shared) {
+		rt := workbook.SharedStrings[index]
+		content := p.extractor.BuildRichText(rt, state.shared[index])
+		if usage := workbook.SharedUsage[index]; usage != nil && usage.Skipped > 0 {
+			appended.WriteString("<si>" + content + "</si>")
+			added++
+			continue
+		}
+		replacements = append(replacements, byteRangeReplacement{start: rt.InnerStart, end: rt.InnerEnd, text: content})
+	}
+
+	if added > 0 {
+		replacements = append(replacements, byteRangeReplacement{
+			start: workbook.SharedStringsEnd,
+			end:   workbook.SharedStringsEnd,
+			text:  appended.String(),
+		})
+	}
+
+	result := applyByteRangeReplacements(original, replacements)
+	if added > 0 {
+		if loc := xlsxUniqueCountPattern.FindSubmatchIndex(result); loc != nil {
+			count, _ := strconv.Atoi(string(result[loc[2]:loc[3]]))
+			updated := fmt.Sprintf(`uniqueCount="%d"`, count+added)
+			result = append(append(append([]byte{}, result[:loc[0]]...), updated...), result[loc[1]:]...)
+		}
+	}
+	return result
+}
+
+// splitSharedIndexes returns the index of the appended copy for each shared
+// string that must not be translated in place
+func (p *XlsxProcessor) splitSharedIndexes(workbook *XlsxWorkbook, state *xlsxRenderState) map[int]int {
+	split := make(map[int]int)
+	next := len(workbook.SharedStrings)
+	for _, index := range sortedKeys(state.shared) {
+		if usage := workbook.SharedUsage[index]; usage != nil && usage.Skipped > 0 {
+			split[index] = next
+			next++
+		}
+	}
+	return split
+}
+
+// renderSheet applies inline string, shared string copy, column mapping and
+// sheet rename changes to a worksheet
+func (p *XlsxProcessor) renderSheet(original []byte, workbook *XlsxWorkbook, sheetIndex int, state *xlsxRenderState) ([]byte, bool) {
+	sheet := workbook.Sheets[sheetIndex]
+	var replacements []byteRangeReplacement
+
+	for location, text := range state.inline {
+		if location.Sheet != sheetIndex {
+			continue
+		}
+		rt := sheet.Rows[location.Row].Cells[location.Cell].Inline
+		replacements = append(replacements, byteRangeReplacement{
+			start: rt.InnerStart,
+			end:   rt.InnerEnd,
+			text:  p.extractor.BuildRichText(rt, text),
+		})
+	}
+
+	for index, newIndex := range p.splitSharedIndexes(workbook, state) {
+		for _, location := range workbook.SharedUsage[index].Translatable {
+			if location.Sheet != sheetIndex {
+				continue
+			}
+			cell := sheet.Rows[location.Row].Cells[location.Cell]
+			replacements = append(replacements, byteRangeReplacement{
+				start: cell.ValueStart,
+				end:   cell.ValueEnd,
+				text:  fmt.Sprintf("<v>%d</v>", newIndex),
+			})
+		}
+	}
+
+	for _, mapping := range state.mappings[sheetIndex] {
+		if replacement, ok := p.mappingReplacement(sheet, mapping); ok {
+			replacements = append(replacements, replacement)
+		}
+	}
+
+	if len(replacements) == 0 && len(state.renames) == 0 {
+		return original, false
+	}
+
+	result := applyByteRangeReplacements(original, replacements)
+	if len(state.renames) > 0 {
+		for _, pattern := range xlsxSheetFormulaPatterns {
+			result = renameFormulaReferences(result, pattern, state.renames)
+		}
+	}
+	return result, true
+}
+
+// mappingReplacement writes a translated source cell into the target column,
+// replacing an existing target cell or inserting one in column order
+func (p *XlsxProcessor) mappingReplacement(sheet *XlsxSheet, mapping xlsxMappingTranslation) (byteRangeReplacement, bool) {
+	row := sheet.Rows[mapping.location.Row]
+	source := row.Cells[mapping.location.Cell]
+	ref := fmt.Sprintf("%s%d", columnName(mapping.target), row.Number)
+
+	for _, cell := range row.Cells {
+		if cell.Column == mapping.target {
+			if cell.HasFormula {
+				p.logger.Warn("column mapping target holds a formula, leaving it untouched",
+					zap.String("sheet", sheet.Entry.Name),
+					zap.String("cell", cell.Ref))
+				return byteRangeReplacement{}, false
+			}
+			return byteRangeReplacement{
+				start: cell.Start,
+				end:   cell.End,
+				text:  p.extractor.BuildInlineStringCell(ref, cell.Style, mapping.text),
+			}, true
+		}
+	}
+
+	position := row.CloseStart
+	for _, cell := range row.Cells {
+		if cell.Column > mapping.target {
+			position = cell.Start
+			break
+		}
+	}
+	if position < 0 {
+		return byteRangeReplacement{}, false
+	}
+
+	return byteRangeReplacement{
+		start: position,
+		end:   position,
+		text:  p.extractor.BuildInlineStringCell(ref, source.Style, mapping.text),
+	}, true
+}
+
+// renderWorkbook renames translated sheets and updates defined names
+func (p *XlsxProcessor) renderWorkbook(original []byte, workbook *XlsxWorkbook, state *xlsxRenderState) []byte {
+	var replacements []byteRangeReplacement
+	for sheetIndex, name := range state.names {
+		entry := workbook.Sheets[sheetIndex].Entry
+		tag := xlsxSheetNameAttrPattern.ReplaceAllLiteralString(entry.Tag, ` name="`+xlsxAttrEscaper.Replace(name)+`"`)
+		replacements = append(replacements, byteRangeReplacement{start: entry.TagStart, end: entry.TagEnd, text: tag})
+	}
+
+	result := applyByteRangeReplacements(original, replacements)
+	return renameFormulaReferences(result, xlsxDefinedNamePattern, state.renames)
+}
+
+// isSheetSkipped reports whether a sheet is excluded by configuration
+func (p *XlsxProcessor) isSheetSkipped(name string) bool {
+	for _, skipped := range p.options.SkipSheets {
+		if strings.EqualFold(strings.TrimSpace(skipped), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isColumnSkipped reports whether a column is excluded by configuration
+func (p *XlsxProcessor) isColumnSkipped(sheetName string, column int) bool {
+	for _, spec := range p.options.SkipColumns {
+		sheet, letters := splitSheetPrefix(spec)
+		if sheet != "" && !strings.EqualFold(sheet, sheetName) {
+			continue
+		}
+		if columnIndex(letters) == column {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingsForSheet returns the column mappings that apply to a sheet
+func (p *XlsxProcessor) mappingsForSheet(sheetName string) []xlsxColumnMapping {
+	var mappings []xlsxColumnMapping
+	for _, spec := range p.options.ColumnMappings {
+		sheet, mapping, ok := parseColumnMapping(spec)
+		if !ok || (sheet != "" && !strings.EqualFold(sheet, sheetName)) {
+			continue
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings
+}
+
+// parseColumnMapping parses "B:C" or "Sheet1!B:C"
+func parseColumnMapping(spec string) (string, xlsxColumnMapping, bool) {
+	sheet, columns := splitSheetPrefix(spec)
+	parts := strings.Split(columns, ":")
+	if len(parts) != 2 {
+		return "", xlsxColumnMapping{}, false
+	}
+	mapping := xlsxColumnMapping{
+		Source: columnIndex(strings.TrimSpace(parts[0])),
+		Target: columnIndex(strings.TrimSpace(parts[1])),
+	}
+	if mapping.Source == 0 || mapping.Target == 0 || mapping.Source == mapping.Target {
+		return "", xlsxColumnMapping{}, false
+	}
+	return sheet, mapping, true
+}
+
+// splitSheetPrefix splits "Sheet1!B" into the sheet name and the remainder
+func splitSheetPrefix(spec string) (string, string) {
+	spec = strings.TrimSpace(spec)
+	if idx := strings.LastIndex(spec, "!"); idx >= 0 {
+		return strings.Trim(strings.TrimSpace(spec[:idx]), "'"), spec[idx+1:]
+	}
+	return "", spec
+}
+
+// uniqueSheetName appends a counter when a translated name collides with another sheet
+func uniqueSheetName(name string, used map[string]bool) string {
+	if name == "" || !used[strings.ToLower(name)] {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		base := []rune(name)
+		if len(base)+len([]rune(suffix)) > 31 {
+			base = base[:31-len([]rune(suffix))]
+		}
+		candidate := string(base) + suffix
+		if !used[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// renameFormulaReferences rewrites sheet references inside matched formula elements
+func renameFormulaReferences(data []byte, pattern *regexp.Regexp, renames map[string]string) []byte {
+	escaped := make(map[string]string, len(renames))
+	for oldName, newName := range renames {
+		escaped[xlsxTextEscaper.Replace(oldName)] = xlsxTextEscaper.Replace(newName)
+		// some writers escape apostrophes in element text
+		if strings.Contains(oldName, "'") {
+			escaped[strings.ReplaceAll(xlsxTextEscaper.Replace(oldName), "'", "&apos;")] = xlsxTextEscaper.Replace(newName)
+		}
+	}
+
+	return pattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		sub := pattern.FindSubmatch(match)
+		formula := renameSheetReferences(string(sub[2]), escaped)
+		return []byte(string(sub[1]) + formula + string(sub[3]))
+	})
+}
+
+// renamePivotSources rewrites the source sheet of pivot cache definitions
+func renamePivotSources(data []byte, renames map[string]string) []byte {
+	return xlsxPivotSourcePattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		sub := xlsxPivotSourcePattern.FindSubmatch(match)
+		name := html.UnescapeString(string(sub[2]))
+		newName, ok := renames[name]
+		if !ok {
+			return match
+		}
+		return []byte(string(sub[1]) + xlsxAttrEscaper.Replace(newName) + string(sub[3]))
+	})
+}
+
+// xlsxRelationship is an entry of a .rels part
+type xlsxRelationship struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+// readRelationships reads a .rels part, returning an empty map when it is absent
+func readRelationships(files map[string]*zip.File, name string) (map[string]xlsxRelationship, error) {
+	rels := make(map[string]xlsxRelationship)
+	file, ok := files[name]
+	if !ok {
+		return rels, nil
+	}
+
+	data, err := readZipFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	var parsed struct {
+		Relationships []xlsxRelationship `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	for _, rel := range parsed.Relationships {
+		rels[rel.ID] = rel
+	}
+	return rels, nil
+}
+
+// relationshipsPartFor returns the .rels part of a package part
+func relationshipsPartFor(part string) string {
+	return path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+}
+
+// resolvePartPath resolves a relationship target relative to its source part
+func resolvePartPath(source, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(source), target)
+}
+
+// sortedKeys returns map keys in ascending order
+func sortedKeys(m map[int]string) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,266 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testXlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Products" sheetId="1" r:id="rId1"/><sheet name="Internal" sheetId="2" r:id="rId2"/></sheets><definedNames><definedName name="Items">Products!$A$1:$A$3</definedName></definedNames></workbook>`
+
+const testXlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/><Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/></Relationships>`
+
+const testXlsxSharedStrings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="5" uniqueCount="4"><si><t>Apple</t></si><si><r><rPr><b/></rPr><t>Fresh</t></r><r><t xml:space="preserve"> fruit</t></r></si><si><t>2024-01-01</t></si><si><t>Shared label</t></si></sst>`
+
+const testXlsxSheet1 = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+	`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1"><v>42</v></c></row>` +
+	`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="inlineStr"><is><t>Inline note</t></is></c><c r="C2" t="str"><f>CONCAT(A1,"x")</f><v>Applex</v></c></row>` +
+	`<row r="3"><c r="A3" t="s"><v>3</v></c><c r="D3" t="s"><v>3</v></c></row>` +
+	`</sheetData></worksheet>`
+
+const testXlsxSheet2 = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Secret</t></is></c><c r="B1"><f>Products!A1</f><v>0</v></c></row></sheetData></worksheet>`
+
+const testXlsxSheet1Rels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="../comments1.xml"/></Relationships>`
+
+const testXlsxComments = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<comments xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><authors><author>Ann</author></authors><commentList><comment ref="A1" authorId="0"><text><t>Check price</t></text></comment></commentList></comments>`
+
+// buildTestXlsx creates a minimal XLSX archive in memory
+func buildTestXlsx(t *testing.T) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"xl/workbook.xml", testXlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", testXlsxWorkbookRels},
+		{"xl/sharedStrings.xml", testXlsxSharedStrings},
+		{"xl/worksheets/sheet1.xml", testXlsxSheet1},
+		{"xl/worksheets/sheet2.xml", testXlsxSheet2},
+		{"xl/worksheets/_rels/sheet1.xml.rels", testXlsxSheet1Rels},
+		{"xl/comments1.xml", testXlsxComments},
+	}
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// translateTestXlsx parses, translates with a fixed table and renders a workbook
+func translateTestXlsx(t *testing.T, opts ProcessorOptions, translations map[string]string) ([]string, []byte) {
+	t.Helper()
+	ctx := context.Background()
+
+	processor, err := NewXlsxProcessor(opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	doc, err := processor.Parse(ctx, bytes.NewReader(buildTestXlsx(t)))
+	if err != nil {
+		t.Fatalf("Failed to parse XLSX: %v", err)
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		sources = append(sources, block.GetContent())
+		if translated, ok := translations[block.GetContent()]; ok {
+			block.SetContent(translated)
+		}
+	}
+
+	renderer, _ := NewXlsxProcessor(opts, zap.NewNop())
+	var output bytes.Buffer
+	if err := renderer.Render(ctx, doc, &output); err != nil {
+		t.Fatalf("Failed to render XLSX: %v", err)
+	}
+	return sources, output.Bytes()
+}
+
+func TestXlsxProcessor(t *testing.T) {
+	t.Run("TranslatesStringsAndSkipsOtherCells", func(t *testing.T) {
+		opts := ProcessorOptions{Metadata: map[string]interface{}{
+			"xlsx_skip_sheets":  []string{"Internal"},
+			"xlsx_skip_columns": []string{"Products!D"},
+		}}
+		sources, output := translateTestXlsx(t, opts, map[string]string{
+			"Products":                      "产品",
+			"Apple":                         "苹果",
+			"<r1>Fresh</r1><r2> fruit</r2>": "<r1>新鲜</r1><r2>水果</r2>",
+			"Inline note":                   "内联备注",
+			"Shared label":                  "共享标签",
+			"Check price":                   "核对价格",
+		})
+
+		expected := []string{"Products", "Apple", "<r1>Fresh</r1><r2> fruit</r2>", "Inline note", "Shared label", "Check price"}
+		if strings.Join(sources, "|") != strings.Join(expected, "|") {
+			t.Errorf("Expected blocks %q, got %q", expected, sources)
+		}
+
+		sst := readTestZipPart(t, output, "xl/sharedStrings.xml")
+		for _, want := range []string{
+			`<si><t>苹果</t></si>`,
+			`<si><r><rPr><b/></rPr><t>新鲜</t></r><r><t>水果</t></r></si>`,
+			`<si><t>2024-01-01</t></si>`,
+			// Shared label is also used by the skipped column D, so it is copied
+			`<si><t>Shared label</t></si><si><t>共享标签</t></si></sst>`,
+			`uniqueCount="5"`,
+		} {
+			if !strings.Contains(sst, want) {
+				t.Errorf("Expected shared strings to contain %q, got %s", want, sst)
+			}
+		}
+
+		sheet := readTestZipPart(t, output, "xl/worksheets/sheet1.xml")
+		for _, want := range []string{
+			`<c r="B2" t="inlineStr"><is><t>内联备注</t></is></c>`,
+			`<c r="A3" t="s"><v>4</v></c><c r="D3" t="s"><v>3</v></c>`,
+			`<f>CONCAT(A1,"x")</f>`,
+		} {
+			if !strings.Contains(sheet, want) {
+				t.Errorf("Expected sheet to contain %q, got %s", want, sheet)
+			}
+		}
+
+		internal := readTestZipPart(t, output, "xl/worksheets/sheet2.xml")
+		if !strings.Contains(internal, "<t>Secret</t>") || !strings.Contains(internal, "<f>'产品'!A1</f>") {
+			t.Errorf("Expected skipped sheet text kept and formula reference renamed, got %s", internal)
+		}
+
+		workbook := readTestZipPart(t, output, "xl/workbook.xml")
+		if !strings.Contains(workbook, `<sheet name="产品" sheetId="1" r:id="rId1"/>`) ||
+			!strings.Contains(workbook, `'产品'!$A$1:$A$3`) {
+			t.Errorf("Expected renamed sheet and defined name, got %s", workbook)
+		}
+
+		comments := readTestZipPart(t, output, "xl/comments1.xml")
+		if !strings.Contains(comments, "<text><t>核对价格</t></text>") {
+			t.Errorf("Expected translated comment, got %s", comments)
+		}
+	})
+
+	t.Run("ColumnMappingWritesTargetColumn", func(t *testing.T) {
+		opts := ProcessorOptions{Metadata: map[string]interface{}{
+			"xlsx_column_mappings":       []string{"Products!A:B"},
+			"xlsx_translate_sheet_names": false,
+			"xlsx_translate_comments":    false,
+			"xlsx_skip_sheets":           []string{"Internal"},
+		}}
+		sources, output := translateTestXlsx(t, opts, map[string]string{
+			"Apple":        "苹果",
+			"Shared label": "共享标签",
+		})
+
+		expected := []string{"Apple", "Shared label"}
+		if strings.Join(sources, "|") != strings.Join(expected, "|") {
+			t.Errorf("Expected blocks %q, got %q", expected, sources)
+		}
+
+		sheet := readTestZipPart(t, output, "xl/worksheets/sheet1.xml")
+		for _, want := range []string{
+			`<c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>苹果</t></is></c><c r="C1">`,
+			`<c r="A3" t="s"><v>3</v></c><c r="B3" t="inlineStr"><is><t>共享标签</t></is></c><c r="D3"`,
+		} {
+			if !strings.Contains(sheet, want) {
+				t.Errorf("Expected sheet to contain %q, got %s", want, sheet)
+			}
+		}
+
+		sst := readTestZipPart(t, output, "xl/sharedStrings.xml")
+		if sst != testXlsxSharedStrings {
+			t.Errorf("Expected shared strings untouched in mapping mode, got %s", sst)
+		}
+	})
+
+	t.Run("InvalidColumnMapping", func(t *testing.T) {
+		opts := ProcessorOptions{Metadata: map[string]interface{}{
+			"xlsx_column_mappings": []string{"B"},
+		}}
+		if _, err := NewXlsxProcessor(opts, zap.NewNop()); err == nil {
+			t.Error("Expected error for invalid column mapping")
+		}
+	})
+}
+
+func TestXlsxHelpers(t *testing.T) {
+	t.Run("ColumnConversion", func(t *testing.T) {
+		for letters, index := range map[string]int{"A": 1, "Z": 26, "AA": 27, "AB": 28} {
+			if got := columnIndex(letters); got != index {
+				t.Errorf("Expected %s -> %d, got %d", letters, index, got)
+			}
+			if got := columnName(index); got != letters {
+				t.Errorf("Expected %d -> %s, got %s", index, letters, got)
+			}
+		}
+	})
+
+	t.Run("RenameSheetReferences", func(t *testing.T) {
+		renames := map[string]string{"Data": "数据", "My Sheet": "我的表"}
+		formula := "SUM(Data!A1:A3)+'My Sheet'!B2+OtherData!C1"
+		expected := "SUM('数据'!A1:A3)+'我的表'!B2+OtherData!C1"
+		if got := renameSheetReferences(formula, renames); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("RenameReferencesOutsideCells", func(t *testing.T) {
+		p, err := NewXlsxProcessor(ProcessorOptions{}, zap.NewNop())
+		if err != nil {
+			t.Fatalf("Failed to create processor: %v", err)
+		}
+		workbook := &XlsxWorkbook{}
+		state := &xlsxRenderState{renames: map[string]string{"Data": "My Sheet", "Bob's": "O'Neil"}}
+
+		chart := `<c:chartSpace><c:val><c:numRef><c:f>Data!$B$2:$B$5</c:f></c:numRef></c:val><c:tx><c:f>'Bob&apos;s'!$A$1</c:f></c:tx></c:chartSpace>`
+		got, changed := p.renderPart("xl/charts/chart1.xml", []byte(chart), workbook, state)
+		expected := `<c:chartSpace><c:val><c:numRef><c:f>'My Sheet'!$B$2:$B$5</c:f></c:numRef></c:val><c:tx><c:f>'O''Neil'!$A$1</c:f></c:tx></c:chartSpace>`
+		if !changed || string(got) != expected {
+			t.Errorf("Expected chart references renamed, got %s", got)
+		}
+
+		pivot := `<pivotCacheDefinition><cacheSource type="worksheet"><worksheetSource ref="A1:C5" sheet="Data"/></cacheSource></pivotCacheDefinition>`
+		got, changed = p.renderPart("xl/pivotCache/pivotCacheDefinition1.xml", []byte(pivot), workbook, state)
+		if !changed || !strings.Contains(string(got), `sheet="My Sheet"`) {
+			t.Errorf("Expected pivot cache source renamed, got %s", got)
+		}
+
+		other := `<pivotCacheDefinition><cacheSource type="worksheet"><worksheetSource ref="A1:C5" sheet="Other"/></cacheSource></pivotCacheDefinition>`
+		if _, changed := p.renderPart("xl/pivotCache/pivotCacheDefinition2.xml", []byte(other), workbook, state); changed {
+			t.Error("Expected pivot cache of an unrenamed sheet left untouched")
+		}
+
+		validation := `<worksheet><dataValidations><dataValidation type="list"><formula1>Data!$A$1:$A$3</formula1></dataValidation></dataValidations></worksheet>`
+		rendered := renameFormulaReferences([]byte(validation), xlsxSheetFormulaPatterns[2], state.renames)
+		if !strings.Contains(string(rendered), "<formula1>'My Sheet'!$A$1:$A$3</formula1>") {
+			t.Errorf("Expected data validation reference renamed, got %s", rendered)
+		}
+	})
+
+	t.Run("SanitizeSheetName", func(t *testing.T) {
+		got := sanitizeSheetName("Q1/Q2: [Report]")
+		if strings.ContainsAny(got, "/:[]") {
+			t.Errorf("Expected invalid characters removed, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,588 @@
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// XlsxRun represents a child of a rich text container (<si>, <is>, comment <text>)
+type XlsxRun struct {
+	Properties string // raw <rPr> of a formatted run
+	Text       string // decoded <t> content
+	Raw        string // verbatim XML of a non-text element (<rPh>, <phoneticPr>)
+	Phonetic   bool   // phonetic run that no longer applies after translation
+}
+
+// IsText reports whether the run carries text
+func (r XlsxRun) IsText() bool {
+	return r.Raw == ""
+}
+
+// XlsxRichText is a rich text container and the byte range of its content
+type XlsxRichText struct {
+	InnerStart int64
+	InnerEnd   int64
+	Runs       []XlsxRun
+}
+
+// XlsxCell represents a worksheet <c> element
+type XlsxCell struct {
+	Ref        string
+	Column     int
+	Row        int
+	Type       string
+	Style      string
+	Start      int64
+	End        int64
+	HasFormula bool
+	Value      string
+	ValueStart int64
+	ValueEnd   int64
+	Inline     *XlsxRichText
+}
+
+// XlsxRow represents a worksheet <row> element
+type XlsxRow struct {
+	Number     int
+	CloseStart int64 // offset of </row>, where missing cells are appended
+	Cells      []*XlsxCell
+}
+
+// XlsxComment represents a legacy cell comment
+type XlsxComment struct {
+	Ref  string
+	Text *XlsxRichText
+}
+
+// XlsxSheetEntry is a <sheet> element of the workbook
+type XlsxSheetEntry struct {
+	Name     string
+	RelID    string
+	TagStart int64
+	TagEnd   int64
+	Tag      string
+}
+
+var (
+	// xlsxCellRefPattern splits a cell reference into column letters and row number
+	xlsxCellRefPattern = regexp.MustCompile(`^\$?([A-Za-z]+)\$?(\d+)$`)
+	// xlsxInvalidSheetNameChars are characters Excel rejects in sheet names
+	xlsxInvalidSheetNameChars = regexp.MustCompile(`[\[\]:*?/\\]`)
+)
+
+// XlsxTextExtractor handles text extraction and manipulation for XLSX parts
+type XlsxTextExtractor struct {
+	logger *zap.Logger
+}
+
+// NewXlsxTextExtractor creates a new XLSX text extractor
+func NewXlsxTextExtractor(logger *zap.Logger) *XlsxTextExtractor {
+	return &XlsxTextExtractor{logger: logger}
+}
+
+// ScanRichText parses the content of a rich text container between start and end
+func (e *XlsxTextExtractor) ScanRichText(data []byte, start, end int64) (*XlsxRichText, error) {
+	rt := &XlsxRichText{InnerStart: start, InnerEnd: end}
+	fragment := data[start:end]
+
+	var (
+		run       *XlsxRun
+		inText    bool
+		elemStart int64
+		rPrStart  int64
+	)
+
+	err := walkXML(fragment, func(tok xml.Token, s, e int64, depth int) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case depth == 1 && t.Name.Local == "t":
+				run = &XlsxRun{}
+				inText = true
+			case depth == 1 && t.Name.Local == "r":
+				run = &XlsxRun{}
+			case depth == 1:
+				elemStart = s
+			case depth == 2 && run != nil && t.Name.Local == "rPr":
+				rPrStart = s
+			case depth == 2 && run != nil && t.Name.Local == "t":
+				inText = true
+			}
+		case xml.CharData:
+			if inText && run != nil {
+				run.Text += string(t)
+			}
+		case xml.EndElement:
+			switch {
+			case depth == 1 && (t.Name.Local == "t" || t.Name.Local == "r"):
+				if run != nil {
+					rt.Runs = append(rt.Runs, *run)
+				}
+				run = nil
+				inText = false
+			case depth == 1:
+				rt.Runs = append(rt.Runs, XlsxRun{
+					Raw:      string(fragment[elemStart:e]),
+					Phonetic: t.Name.Local == "rPh",
+				})
+			case depth == 2 && run != nil && t.Name.Local == "rPr":
+				run.Properties = string(fragment[rPrStart:e])
+			case depth == 2 && t.Name.Local == "t":
+				inText = false
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+// ScanSharedStrings parses xl/sharedStrings.xml and returns the string items
+// together with the offset of </sst>, where new items can be appended
+func (e *XlsxTextExtractor) ScanSharedStrings(data []byte) ([]*XlsxRichText, int64, error) {
+	var items []*XlsxRichText
+	var innerStart, closeStart int64
+
+	err := walkXML(data, func(tok xml.Token, s, end int64, depth int) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "si" && depth == 2 {
+				innerStart = end
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == "si" && depth == 2:
+				rt, err := e.ScanRichText(data, innerStart, s)
+				if err != nil {
+					return err
+				}
+				items = append(items, rt)
+			case t.Name.Local == "sst" && depth == 1:
+				closeStart = s
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan shared strings: %w", err)
+	}
+
+	return items, closeStart, nil
+}
+
+// ScanWorksheet parses the rows and cells of a worksheet part
+func (e *XlsxTextExtractor) ScanWorksheet(data []byte) ([]*XlsxRow, error) {
+	var (
+		rows          []*XlsxRow
+		row           *XlsxRow
+		cell          *XlsxCell
+		inValue       bool
+		isInnerStart  int64
+		lastRowNumber int
+		lastColumn    int
+	)
+
+	err := walkXML(data, func(tok xml.Token, s, end int64, depth int) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "row" && cell == nil:
+				number, _ := strconv.Atoi(attrValue(t, "r"))
+				if number == 0 {
+					number = lastRowNumber + 1
+				}
+				lastRowNumber = number
+				lastColumn = 0
+				row = &XlsxRow{Number: number}
+			case t.Name.Local == "c" && row != nil && cell == nil:
+				cell = &XlsxCell{
+					Ref:   attrValue(t, "r"),
+					Type:  attrValue(t, "t"),
+					Style: attrValue(t, "s"),
+					Start: s,
+					Row:   row.Number,
+				}
+				if column, rowNumber, ok := parseCellRef(cell.Ref); ok {
+					cell.Column = column
+					cell.Row = rowNumber
+				} else {
+					cell.Column = lastColumn + 1
+					cell.Ref = fmt.Sprintf("%s%d", columnName(cell.Column), row.Number)
+				}
+				lastColumn = cell.Column
+			case cell != nil && t.Name.Local == "f":
+				cell.HasFormula = true
+			case cell != nil && t.Name.Local == "v":
+				cell.ValueStart = s
+				inValue = true
+			case cell != nil && t.Name.Local == "is":
+				isInnerStart = end
+			}
+		case xml.CharData:
+			if inValue && cell != nil {
+				cell.Value += string(t)
+			}
+		case xml.EndElement:
+			switch {
+			case cell != nil && t.Name.Local == "v":
+				cell.ValueEnd = end
+				inValue = false
+			case cell != nil && t.Name.Local == "is":
+				rt, err := e.ScanRichText(data, isInnerStart, s)
+				if err != nil {
+					return err
+				}
+				cell.Inline = rt
+			case cell != nil && t.Name.Local == "c":
+				cell.End = end
+				row.Cells = append(row.Cells, cell)
+				cell = nil
+			case row != nil && t.Name.Local == "row":
+				row.CloseStart = s
+				if s == end {
+					// Self-closing row, nothing can be appended inside it
+					row.CloseStart = -1
+				}
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan worksheet: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ScanComments parses a legacy comments part
+func (e *XlsxTextExtractor) ScanComments(data []byte) ([]*XlsxComment, error) {
+	var comments []*XlsxComment
+	var current *XlsxComment
+	var innerStart int64
+
+	err := walkXML(data, func(tok xml.Token, s, end int64, depth int) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "comment":
+				current = &XlsxComment{Ref: attrValue(t, "ref")}
+			case current != nil && t.Name.Local == "text":
+				innerStart = end
+			}
+		case xml.EndElement:
+			switch {
+			case current != nil && t.Name.Local == "text":
+				rt, err := e.ScanRichText(data, innerStart, s)
+				if err != nil {
+					return err
+				}
+				current.Text = rt
+			case current != nil && t.Name.Local == "comment":
+				if current.Text != nil {
+					comments = append(comments, current)
+				}
+				current = nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ScanWorkbookSheets returns the <sheet> elements of xl/workbook.xml
+func (e *XlsxTextExtractor) ScanWorkbookSheets(data []byte) ([]*XlsxSheetEntry, error) {
+	var sheets []*XlsxSheetEntry
+
+	err := walkXML(data, func(tok xml.Token, s, end int64, depth int) error {
+		if t, ok := tok.(xml.StartElement); ok && t.Name.Local == "sheet" {
+			entry := &XlsxSheetEntry{
+				Name:     attrValue(t, "name"),
+				TagStart: s,
+				TagEnd:   end,
+				Tag:      string(data[s:end]),
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "id" && attr.Name.Space != "" {
+					entry.RelID = attr.Value
+				}
+			}
+			sheets = append(sheets, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan workbook: %w", err)
+	}
+
+	return sheets, nil
+}
+
+// MergeAdjacentRuns merges text runs with identical formatting.
+// Phonetic runs and trailing elements are not part of the result.
+func (e *XlsxTextExtractor) MergeAdjacentRuns(rt *XlsxRichText) []XlsxRun {
+	var merged []XlsxRun
+	for _, run := range rt.Runs {
+		if !run.IsText() || run.Text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Properties == run.Properties {
+			merged[n-1].Text += run.Text
+			continue
+		}
+		merged = append(merged, XlsxRun{Properties: run.Properties, Text: run.Text})
+	}
+	return merged
+}
+
+// PlainText returns the concatenated text of a rich text container
+func (e *XlsxTextExtractor) PlainText(rt *XlsxRichText) string {
+	var builder strings.Builder
+	for _, run := range rt.Runs {
+		if run.IsText() {
+			builder.WriteString(run.Text)
+		}
+	}
+	return builder.String()
+}
+
+// ExtractText returns the text of a rich text container for translation.
+// A single formatting run yields plain text, otherwise runs are wrapped in <rN>…</rN>.
+func (e *XlsxTextExtractor) ExtractText(rt *XlsxRichText) string {
+	merged := e.MergeAdjacentRuns(rt)
+	if len(merged) == 0 {
+		return ""
+	}
+	if len(merged) == 1 {
+		return merged[0].Text
+	}
+
+	var builder strings.Builder
+	for i, run := range merged {
+		fmt.Fprintf(&builder, "<r%d>%s</r%d>", i+1, run.Text, i+1)
+	}
+	return builder.String()
+}
+
+// BuildRichText renders the content of a rich text container with translated text
+func (e *XlsxTextExtractor) BuildRichText(rt *XlsxRichText, translatedText string) string {
+	merged := e.MergeAdjacentRuns(rt)
+
+	var runs []XlsxRun
+	switch {
+	case len(merged) <= 1:
+		props := ""
+		if len(merged) == 1 {
+			props = merged[0].Properties
+		}
+		runs = []XlsxRun{{Properties: props, Text: translatedText}}
+	case inlineRunTagPattern.MatchString(translatedText):
+		runs = e.taggedRuns(merged, translatedText)
+	default:
+		e.logger.Debug("inline run tags lost in translation, splitting proportionally",
+			zap.Int("runs", len(merged)))
+		parts := e.SplitTranslatedText(translatedText, merged)
+		for i, part := range parts {
+			if part != "" {
+				runs = append(runs, XlsxRun{Properties: merged[i].Properties, Text: part})
+			}
+		}
+	}
+
+	var builder strings.Builder
+	if len(runs) == 1 && runs[0].Properties == "" {
+		builder.WriteString(buildXlsxText(runs[0].Text))
+	} else {
+		for _, run := range runs {
+			builder.WriteString("<r>")
+			builder.WriteString(run.Properties)
+			builder.WriteString(buildXlsxText(run.Text))
+			builder.WriteString("</r>")
+		}
+	}
+
+	// Phonetic guides describe the source text, other trailing elements are kept
+	for _, run := range rt.Runs {
+		if !run.IsText() && !run.Phonetic {
+			builder.WriteString(run.Raw)
+		}
+	}
+
+	return builder.String()
+}
+
+// taggedRuns rebuilds runs from <rN> tags in translated order
+func (e *XlsxTextExtractor) taggedRuns(merged []XlsxRun, translatedText string) []XlsxRun {
+	var runs []XlsxRun
+	lastProps := merged[0].Properties
+
+	appendRun := func(props, text string) {
+		if text != "" {
+			runs = append(runs, XlsxRun{Properties: props, Text: text})
+		}
+	}
+
+	cursor := 0
+	for _, loc := range inlineRunTagPattern.FindAllStringSubmatchIndex(translatedText, -1) {
+		appendRun(lastProps, translatedText[cursor:loc[0]])
+		cursor = loc[1]
+		if loc[2] < 0 {
+			continue // <xN/> tags do not occur in spreadsheet text
+		}
+
+		index, _ := strconv.Atoi(translatedText[loc[2]:loc[3]])
+		if index >= 1 && index <= len(merged) {
+			lastProps = merged[index-1].Properties
+		}
+		appendRun(lastProps, translatedText[loc[4]:loc[5]])
+	}
+	appendRun(lastProps, translatedText[cursor:])
+
+	return runs
+}
+
+// SplitTranslatedText splits translated text back to the merged run structure
+func (e *XlsxTextExtractor) SplitTranslatedText(translatedText string, merged []XlsxRun) []string {
+	totalLength := 0
+	for _, run := range merged {
+		totalLength += len([]rune(run.Text))
+	}
+	if totalLength == 0 {
+		return nil
+	}
+
+	result := make([]string, len(merged))
+	translatedRunes := []rune(translatedText)
+	position := 0
+
+	for i, run := range merged {
+		proportion := float64(len([]rune(run.Text))) / float64(totalLength)
+		newLength := int(float64(len(translatedRunes)) * proportion)
+
+		if i == len(merged)-1 || position+newLength > len(translatedRunes) {
+			newLength = len(translatedRunes) - position
+		}
+
+		if newLength > 0 {
+			result[i] = string(translatedRunes[position : position+newLength])
+			position += newLength
+		}
+	}
+
+	return result
+}
+
+// BuildInlineStringCell renders a cell holding an inline string
+func (e *XlsxTextExtractor) BuildInlineStringCell(ref, style, text string) string {
+	var builder strings.Builder
+	builder.WriteString(`<c r="`)
+	builder.WriteString(ref)
+	builder.WriteString(`"`)
+	if style != "" {
+		builder.WriteString(` s="`)
+		builder.WriteString(style)
+		builder.WriteString(`"`)
+	}
+	builder.WriteString(` t="inlineStr"><is>`)
+	builder.WriteString(buildXlsxText(text))
+	builder.WriteString(`</is></c>`)
+	return builder.String()
+}
+
+// buildXlsxText renders a <t> element, preserving significant whitespace
+func buildXlsxText(text string) string {
+	var builder strings.Builder
+	if text != strings.TrimSpace(text) || strings.Contains(text, "\n") {
+		builder.WriteString(`<t xml:space="preserve">`)
+	} else {
+		builder.WriteString("<t>")
+	}
+	_ = xml.EscapeText(&builder, []byte(text))
+	builder.WriteString("</t>")
+	return builder.String()
+}
+
+// isTranslatableCellText reports whether a string cell holds words rather than
+// numbers, dates or codes stored as text
+func isTranslatableCellText(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeSheetName makes a translated sheet name acceptable to Excel
+func sanitizeSheetName(name string) string {
+	name = xlsxInvalidSheetNameChars.ReplaceAllString(name, " ")
+	name = strings.Trim(strings.TrimSpace(name), "'")
+	if runes := []rune(name); len(runes) > 31 {
+		name = strings.TrimSpace(string(runes[:31]))
+	}
+	return name
+}
+
+// quoteSheetName returns the quoted form used in formulas and defined names
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// renameSheetReferences rewrites sheet references in formula text
+func renameSheetReferences(formula string, renames map[string]string) string {
+	for oldName, newName := range renames {
+		quotedNew := quoteSheetName(newName) + "!"
+		formula = strings.ReplaceAll(formula, quoteSheetName(oldName)+"!", quotedNew)
+
+		pattern := regexp.MustCompile(`(^|[^A-Za-z0-9_.'])` + regexp.QuoteMeta(oldName) + `!`)
+		formula = pattern.ReplaceAllString(formula, "${1}"+strings.ReplaceAll(quotedNew, "$", "$$"))
+	}
+	return formula
+}
+
+// parseCellRef parses a cell reference such as "C5" into column and row numbers
+func parseCellRef(ref string) (int, int, bool) {
+	match := xlsxCellRefPattern.FindStringSubmatch(ref)
+	if match == nil {
+		return 0, 0, false
+	}
+	row, _ := strconv.Atoi(match[2])
+	return columnIndex(match[1]), row, true
+}
+
+// columnIndex converts column letters to a 1-based index ("A" -> 1, "AB" -> 28)
+func columnIndex(letters string) int {
+	index := 0
+	for _, r := range strings.ToUpper(letters) {
+		if r < 'A' || r > 'Z' {
+			return 0
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index
+}
+
+// columnName converts a 1-based column index to letters
+func columnName(index int) string {
+	var letters []byte
+	for index > 0 {
+		index--
+		letters = append([]byte{byte('A' + index%26)}, letters...)
+		index /= 26
+	}
+	return string(letters)
+}
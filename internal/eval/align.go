@@ -0,0 +1,178 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 对齐方式
+const (
+	AlignAuto     = "auto"     // 节点数相同时按节点编号对齐，否则按句子对齐
+	AlignNode     = "node"     // 按节点编号对齐
+	AlignSentence = "sentence" // 按句子对齐
+)
+
+// mergeCost 句子对齐中二对一、一对二的额外代价
+const mergeCost = 0.1
+
+// Segment 一对对齐的译文和参考译文
+type Segment struct {
+	ID         int    `json:"id"`
+	Hypothesis string `json:"hypothesis"`
+	Reference  string `json:"reference"`
+}
+
+// Align 按对齐方式把译文节点与参考译文节点配对，返回实际使用的对齐方式
+func Align(hypotheses, references []string, method string) ([]Segment, string, error) {
+	switch method {
+	case AlignAuto, "":
+		if len(hypotheses) == len(references) {
+			return AlignNodes(hypotheses, references), AlignNode, nil
+		}
+		return AlignSentences(hypotheses, references), AlignSentence, nil
+	case AlignNode:
+		if len(hypotheses) != len(references) {
+			return nil, "", fmt.Errorf("cannot align by node: output has %d nodes, reference has %d", len(hypotheses), len(references))
+		}
+		return AlignNodes(hypotheses, references), AlignNode, nil
+	case AlignSentence:
+		return AlignSentences(hypotheses, references), AlignSentence, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported alignment: %s", method)
+	}
+}
+
+// AlignNodes 按节点编号一一配对
+func AlignNodes(hypotheses, references []string) []Segment {
+	segments := make([]Segment, len(hypotheses))
+	for i := range hypotheses {
+		segments[i] = Segment{ID: i + 1, Hypothesis: hypotheses[i], Reference: references[i]}
+	}
+	return segments
+}
+
+// AlignSentences 把两边的节点拆成句子后按内容相似度和长度动态规划对齐，
+// 允许一对一、一对零、零对一、二对一和一对二，没有对应句子的一侧为空字符串
+func AlignSentences(hypotheses, references []string) []Segment {
+	var hyp, ref []string
+	for _, text := range hypotheses {
+		hyp = append(hyp, SplitSentences(text)...)
+	}
+	for _, text := range references {
+		ref = append(ref, SplitSentences(text)...)
+	}
+
+	type step struct{ h, r int }
+	beads := []step{{1, 1}, {1, 0}, {0, 1}, {2, 1}, {1, 2}}
+	// 代价按对齐的句子数加权：跳过一句为 0.5，一对一为 1-相似度，二对一为 1.5×(1-相似度)+mergeCost
+	beadCost := func(h, r []string) float64 {
+		weight := float64(len(h)+len(r)) / 2
+		switch {
+		case len(h) == 0 || len(r) == 0:
+			return weight
+		case len(h) == 1 && len(r) == 1:
+			return 1 - similarity(h[0], r[0])
+		default:
+			return weight*(1-similarity(strings.Join(h, " "), strings.Join(r, " "))) + mergeCost
+		}
+	}
+
+	rows, cols := len(hyp)+1, len(ref)+1
+	cost := make([]float64, rows*cols)
+	back := make([]step, rows*cols)
+	for i := range cost {
+		cost[i] = -1
+	}
+	cost[0] = 0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if cost[i*cols+j] < 0 {
+				continue
+			}
+			for _, b := range beads {
+				ni, nj := i+b.h, j+b.r
+				if ni >= rows || nj >= cols {
+					continue
+				}
+				c := cost[i*cols+j] + beadCost(hyp[i:ni], ref[j:nj])
+				if cur := cost[ni*cols+nj]; cur < 0 || c < cur {
+					cost[ni*cols+nj] = c
+					back[ni*cols+nj] = b
+				}
+			}
+		}
+	}
+
+	var segments []Segment
+	for i, j := len(hyp), len(ref); i > 0 || j > 0; {
+		b := back[i*cols+j]
+		segments = append(segments, Segment{
+			Hypothesis: strings.Join(hyp[i-b.h:i], " "),
+			Reference:  strings.Join(ref[j-b.r:j], " "),
+		})
+		i, j = i-b.h, j-b.r
+	}
+	for left, right := 0, len(segments)-1; left < right; left, right = left+1, right-1 {
+		segments[left], segments[right] = segments[right], segments[left]
+	}
+	for i := range segments {
+		segments[i].ID = i + 1
+	}
+	return segments
+}
+
+// SplitSentences 按句末标点和换行拆分句子；西文句末标点后须有空白，中日文句末标点直接断句
+func SplitSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	emit := func(end int) {
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			emit(i + 1)
+		case strings.ContainsRune("。！？；", r):
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune("”」』）)\"'", runes[end]) {
+				end++
+			}
+			emit(end)
+			i = end - 1
+		case strings.ContainsRune(".!?", r):
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune(".!?\"')”", runes[end]) {
+				end++
+			}
+			if end == len(runes) || unicode.IsSpace(runes[end]) {
+				emit(end)
+				i = end - 1
+			}
+		}
+	}
+	emit(len(runes))
+	return sentences
+}
+
+// similarity 句子相似度（0-1）：字符二元组的 Dice 系数与长度比的加权和
+func similarity(a, b string) float64 {
+	ca, cb := charsWithoutSpace(a), charsWithoutSpace(b)
+	if len(ca) == 0 || len(cb) == 0 {
+		return 0
+	}
+	n := 2
+	if len(ca) < 2 || len(cb) < 2 {
+		n = 1
+	}
+	matches, totalA, totalB := ngramOverlap(ngrams(ca, n), ngrams(cb, n))
+	dice := 2 * float64(matches) / float64(totalA+totalB)
+	lengthRatio := float64(min(len(ca), len(cb))) / float64(max(len(ca), len(cb)))
+	return 0.7*dice + 0.3*lengthRatio
+}
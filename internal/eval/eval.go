@@ -0,0 +1,198 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/internal/stats"
+	"go.uber.org/zap"
+)
+
+// SegmentScore 一个对齐段的评分
+type SegmentScore struct {
+	Segment
+	Scores
+}
+
+// Result 评测结果
+type Result struct {
+	Output         string         `json:"output"`
+	Reference      string         `json:"reference"`
+	Format         string         `json:"format"`
+	SourceLanguage string         `json:"source_language"`
+	TargetLanguage string         `json:"target_language"`
+	Alignment      string         `json:"alignment"`
+	OutputNodes    int            `json:"output_nodes"`
+	ReferenceNodes int            `json:"reference_nodes"`
+	Corpus         Scores         `json:"corpus"`
+	Segments       []SegmentScore `json:"segments"`
+	Timestamp      time.Time      `json:"timestamp"`
+}
+
+// Options 评测选项
+type Options struct {
+	Alignment string // auto、node 或 sentence
+}
+
+// Evaluator 用参考译文评测译文
+type Evaluator struct {
+	config  *config.Config
+	logger  *zap.Logger
+	options Options
+}
+
+// NewEvaluator 创建评测器
+func NewEvaluator(cfg *config.Config, logger *zap.Logger, options Options) *Evaluator {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if options.Alignment == "" {
+		options.Alignment = AlignAuto
+	}
+	return &Evaluator{config: cfg, logger: logger, options: options}
+}
+
+// Evaluate 解析译文和参考译文，对齐节点后计算语料级和每一段的 BLEU、chrF++ 和 TER
+func (e *Evaluator) Evaluate(ctx context.Context, outputPath, referencePath string) (*Result, error) {
+	format, hypotheses, err := e.extractNodes(ctx, outputPath)
+	if err != nil {
+		return nil, err
+	}
+	_, references, err := e.extractNodes(ctx, referencePath)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, alignment, err := Align(hypotheses, references, e.options.Alignment)
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Debug("aligned output with reference",
+		zap.String("alignment", alignment),
+		zap.Int("output_nodes", len(hypotheses)),
+		zap.Int("reference_nodes", len(references)),
+		zap.Int("segments", len(segments)))
+
+	hyp := make([]string, len(segments))
+	ref := make([]string, len(segments))
+	for i, segment := range segments {
+		hyp[i], ref[i] = segment.Hypothesis, segment.Reference
+	}
+	corpus, scores := ScoreSegments(hyp, ref)
+
+	result := &Result{
+		Output:         outputPath,
+		Reference:      referencePath,
+		Format:         format,
+		SourceLanguage: e.config.SourceLang,
+		TargetLanguage: e.config.TargetLang,
+		Alignment:      alignment,
+		OutputNodes:    len(hypotheses),
+		ReferenceNodes: len(references),
+		Corpus:         corpus,
+		Segments:       make([]SegmentScore, len(segments)),
+		Timestamp:      time.Now(),
+	}
+	for i, segment := range segments {
+		result.Segments[i] = SegmentScore{Segment: segment, Scores: scores[i]}
+	}
+	return result, nil
+}
+
+// extractNodes 解析文档并返回可翻译节点的文本
+func (e *Evaluator) extractNodes(ctx context.Context, path string) (string, []string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	processor, err := document.GetProcessorByExtension(path, document.ProcessorOptions{
+		ChunkSize:    e.config.ChunkSize,
+		ChunkOverlap: 100,
+		Metadata: map[string]interface{}{
+			"source_language": e.config.SourceLang,
+			"target_language": e.config.TargetLang,
+			"logger":          e.logger,
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get document processor for %s: %w", path, err)
+	}
+	doc, err := processor.Parse(ctx, bytes.NewReader(content))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var nodes []string
+	for _, block := range doc.Blocks {
+		if block.IsTranslatable() && strings.TrimSpace(block.GetContent()) != "" {
+			nodes = append(nodes, block.GetContent())
+		}
+	}
+	if len(nodes) == 0 {
+		return "", nil, fmt.Errorf("no translatable nodes found in %s", path)
+	}
+	return string(doc.Format), nodes, nil
+}
+
+// Worst 返回按 chrF++ 从低到高排序的前 n 个段
+func (r *Result) Worst(n int) []SegmentScore {
+	sorted := make([]SegmentScore, len(r.Segments))
+	copy(sorted, r.Segments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ChrF < sorted[j].ChrF
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// Record 把评测结果转换为统计数据库中的评测记录
+func (r *Result) Record(model, stepSet string) *stats.EvaluationRecord {
+	return &stats.EvaluationRecord{
+		ID:             fmt.Sprintf("eval-%d", r.Timestamp.UnixNano()),
+		Timestamp:      r.Timestamp,
+		OutputFile:     r.Output,
+		ReferenceFile:  r.Reference,
+		SourceLanguage: r.SourceLanguage,
+		TargetLanguage: r.TargetLanguage,
+		Format:         r.Format,
+		Model:          model,
+		StepSet:        stepSet,
+		Alignment:      r.Alignment,
+		Segments:       len(r.Segments),
+		BLEU:           r.Corpus.BLEU,
+		ChrF:           r.Corpus.ChrF,
+		TER:            r.Corpus.TER,
+		Metadata: map[string]interface{}{
+			"output_nodes":    r.OutputNodes,
+			"reference_nodes": r.ReferenceNodes,
+		},
+	}
+}
+
+// StepSetModels 返回步骤集中使用的模型名称（去重，按步骤顺序用 "+" 连接），用于区分评测记录
+func StepSetModels(cfg *config.Config, stepSet string) string {
+	set, ok := cfg.StepSets[stepSet]
+	if !ok {
+		return ""
+	}
+	var models []string
+	seen := make(map[string]bool)
+	for _, step := range set.Steps {
+		if step.ModelName == "" || seen[step.ModelName] {
+			continue
+		}
+		seen[step.ModelName] = true
+		models = append(models, step.ModelName)
+	}
+	return strings.Join(models, "+")
+}
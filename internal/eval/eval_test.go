@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"我", "喜", "欢", "Go", "语", "言", "。"}, Tokenize("我喜欢Go语言。"))
+	assert.Equal(t, []string{"It", "costs", "3.14", "dollars", "!"}, Tokenize("It costs 3.14 dollars!"))
+	assert.Equal(t, []string{"日", "本", "語", "の", "テ", "キ", "ス", "ト"}, Tokenize("日本語のテキスト"))
+	assert.Equal(t, []string{"한국어", "문장"}, Tokenize("한국어 문장"))
+}
+
+func TestScoreSegments(t *testing.T) {
+	corpus, segments := ScoreSegments([]string{"the cat sat on the mat"}, []string{"the cat sat on a mat"})
+	require.Len(t, segments, 1)
+	// 精确率 5/6、3/5、2/4、1/3，长度相同没有长度惩罚
+	assert.InDelta(t, 53.7285, corpus.BLEU, 1e-3)
+	assert.Equal(t, corpus, segments[0])
+
+	identical, _ := ScoreSegments([]string{"我喜欢猫。"}, []string{"我喜欢猫。"})
+	assert.Equal(t, Scores{BLEU: 100, ChrF: 100, TER: 0}, identical)
+
+	empty, _ := ScoreSegments([]string{""}, []string{"我喜欢猫。"})
+	assert.Zero(t, empty.BLEU)
+	assert.Zero(t, empty.ChrF)
+	assert.Equal(t, 100.0, empty.TER)
+
+	// 没有任何相同的词时 BLEU 为 0
+	for hyp, ref := range map[string]string{"hello world": "goodbye moon", "abc": "xyz"} {
+		corpus, segments := ScoreSegments([]string{hyp}, []string{ref})
+		assert.Zero(t, corpus.BLEU, hyp)
+		assert.Zero(t, segments[0].BLEU, hyp)
+	}
+
+	// 单句评分使用有效阶数，语料级使用完整的 4 阶
+	corpus, segments = ScoreSegments([]string{"good morning"}, []string{"good morning"})
+	assert.Equal(t, 100.0, segments[0].BLEU)
+	assert.Zero(t, corpus.BLEU)
+}
+
+func TestChrF(t *testing.T) {
+	// 字符 1-gram P=1 R=2/3，2-gram P=1 R=1/2，词 1-gram P=R=0
+	scores, _ := ScoreSegments([]string{"ab"}, []string{"abc"})
+	assert.InDelta(t, 42.4242, scores.ChrF, 1e-3)
+}
+
+func TestTER(t *testing.T) {
+	// 一次移位即可与参考译文一致
+	scores, _ := ScoreSegments([]string{"b c a"}, []string{"a b c"})
+	assert.InDelta(t, 100.0/3, scores.TER, 1e-9)
+
+	// 移位加一次替换，不区分大小写
+	scores, _ = ScoreSegments([]string{"Quickly the fox jumped"}, []string{"the fox jumps quickly"})
+	assert.InDelta(t, 50.0, scores.TER, 1e-9)
+
+	// 语料级 TER 为总编辑次数除以参考译文总词数
+	corpus, _ := ScoreSegments([]string{"a b c", "x"}, []string{"a b c", "y"})
+	assert.InDelta(t, 25.0, corpus.TER, 1e-9)
+
+	// 长段落中移动一个词组只计一次移位
+	words := make([]string, 600)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	moved := slices.Concat(words[:10], words[15:40], words[10:15], words[40:])
+	scores, _ = ScoreSegments([]string{strings.Join(moved, " ")}, []string{strings.Join(words, " ")})
+	assert.InDelta(t, 100.0/600, scores.TER, 1e-9)
+
+	// 长度相差悬殊时编辑距离的带宽仍能到达终点
+	scores, _ = ScoreSegments([]string{"w0 w199"}, []string{strings.Join(words[:200], " ")})
+	assert.InDelta(t, 99.0, scores.TER, 1e-9)
+}
+
+func TestSplitSentences(t *testing.T) {
+	assert.Equal(t, []string{"我喜欢猫。", "你呢？", "“好的！”"}, SplitSentences("我喜欢猫。你呢？“好的！”"))
+	assert.Equal(t, []string{"It costs 3.14 dollars.", "Really?"}, SplitSentences("It costs 3.14 dollars. Really?"))
+	assert.Equal(t, []string{"Title", "Body text"}, SplitSentences("Title\nBody text"))
+}
+
+func TestAlign(t *testing.T) {
+	hyp := []string{"我喜欢猫。我也喜欢狗。今天天气很好。"}
+	ref := []string{"我喜欢猫。", "我也很喜欢狗。", "今天天气不错。"}
+
+	segments, method, err := Align(hyp, ref, AlignAuto)
+	require.NoError(t, err)
+	assert.Equal(t, AlignSentence, method)
+	assert.Equal(t, []Segment{
+		{ID: 1, Hypothesis: "我喜欢猫。", Reference: "我喜欢猫。"},
+		{ID: 2, Hypothesis: "我也喜欢狗。", Reference: "我也很喜欢狗。"},
+		{ID: 3, Hypothesis: "今天天气很好。", Reference: "今天天气不错。"},
+	}, segments)
+
+	// 缺少的句子与空字符串对齐
+	segments = AlignSentences([]string{"我喜欢猫。今天天气很好。"}, ref)
+	require.Len(t, segments, 3)
+	assert.Equal(t, "", segments[1].Hypothesis)
+	assert.Equal(t, "我也很喜欢狗。", segments[1].Reference)
+
+	// 一句译文对应两句参考译文
+	segments = AlignSentences([]string{"我也喜欢狗，今天天气很好。"}, []string{"我也很喜欢狗。今天天气不错。"})
+	require.Len(t, segments, 1)
+	assert.Equal(t, "我也很喜欢狗。 今天天气不错。", segments[0].Reference)
+
+	segments, method, err = Align([]string{"a", "b"}, []string{"c", "d"}, AlignAuto)
+	require.NoError(t, err)
+	assert.Equal(t, AlignNode, method)
+	assert.Equal(t, Segment{ID: 2, Hypothesis: "b", Reference: "d"}, segments[1])
+
+	_, _, err = Align(hyp, ref, AlignNode)
+	assert.ErrorContains(t, err, "output has 1 nodes, reference has 3")
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output.md")
+	reference := filepath.Join(dir, "reference.md")
+	require.NoError(t, os.WriteFile(output, []byte("# 入门\n\n安装这个工具。\n\n在文件上运行它。\n"), 0o644))
+	require.NoError(t, os.WriteFile(reference, []byte("# 入门\n\n安装该工具。\n\n对文件运行它。\n"), 0o644))
+
+	cfg := &config.Config{SourceLang: "English", TargetLang: "Chinese", ChunkSize: 2000}
+	result, err := NewEvaluator(cfg, zap.NewNop(), Options{}).Evaluate(context.Background(), output, reference)
+	require.NoError(t, err)
+	assert.Equal(t, "markdown", result.Format)
+	assert.Equal(t, AlignNode, result.Alignment)
+	require.Len(t, result.Segments, 3)
+	assert.Equal(t, 100.0, result.Segments[0].ChrF)
+	assert.Greater(t, result.Corpus.BLEU, 0.0)
+	assert.Less(t, result.Corpus.BLEU, 100.0)
+	worst := result.Worst(2)
+	require.Len(t, worst, 2)
+	assert.LessOrEqual(t, worst[0].ChrF, worst[1].ChrF)
+	assert.NotEqual(t, 1, worst[0].ID, "the heading matches the reference exactly")
+
+	// 评测记录写入统计数据库，按模型和步骤集查询
+	db, err := stats.NewDatabase(filepath.Join(dir, "statistics.json"), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, db.AddEvaluationRecord(result.Record("gpt-4o", "basic")))
+	require.NoError(t, db.AddEvaluationRecord(result.Record("claude", "basic")))
+
+	records := db.GetEvaluations("gpt-4o", "basic")
+	require.Len(t, records, 1)
+	assert.Equal(t, result.Corpus.ChrF, records[0].ChrF)
+	assert.Equal(t, 3, records[0].Segments)
+	assert.Len(t, db.GetEvaluations("", "basic"), 2)
+}
+
+func TestStepSetModels(t *testing.T) {
+	cfg := &config.Config{StepSets: map[string]config.StepSetConfigV2{
+		"quality": {Steps: []config.StepConfigV2{{ModelName: "gpt-4o"}, {ModelName: "claude"}, {ModelName: "gpt-4o"}}},
+	}}
+	assert.Equal(t, "gpt-4o+claude", StepSetModels(cfg, "quality"))
+	assert.Equal(t, "", StepSetModels(cfg, "missing"))
+}
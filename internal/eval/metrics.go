@@ -0,0 +1,504 @@
+package eval
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// 评分参数，与 sacreBLEU 的默认值一致
+const (
+	bleuMaxOrder  = 4  // BLEU 最大 n-gram 阶数
+	chrfCharOrder = 6  // chrF++ 字符 n-gram 阶数
+	chrfWordOrder = 2  // chrF++ 词 n-gram 阶数
+	chrfBeta      = 2  // chrF++ 中召回率的权重
+	terMaxShift   = 10 // TER 一次移位的最大词数
+	terMaxShiftTo = 50 // TER 移位的最大距离
+
+	terBeamWidth          = 25   // TER 编辑距离只计算对角线附近的带宽，与 tercom 一致
+	terMaxShiftCandidates = 1000 // TER 每轮最多尝试的移位数
+)
+
+// Scores 译文评分，均为 0-100；BLEU 和 chrF++ 越高越好，TER 越低越好
+type Scores struct {
+	BLEU float64 `json:"bleu"`
+	ChrF float64 `json:"chrf"`
+	TER  float64 `json:"ter"`
+}
+
+// Tokenize 把文本切分为词：汉字、平假名和片假名每个字符单独成词，
+// 其他文字按空白和标点切分，标点单独成词，数字中的小数点和千分位保留在数字内
+func Tokenize(text string) []string {
+	runes := []rune(text)
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			word = append(word, r)
+		case unicode.IsSpace(r):
+			flush()
+		case (r == '.' || r == ',') && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]):
+			word = append(word, r)
+		default:
+			flush()
+			tokens = append(tokens, string(r))
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isCJK 判断是否为不以空格分词的中日文字符
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+// segmentStats 一对译文和参考译文的充分统计量，语料级评分由各段统计量相加后计算
+type segmentStats struct {
+	bleuMatches [bleuMaxOrder]int
+	bleuTotals  [bleuMaxOrder]int
+	hypLen      int
+	refLen      int
+
+	chrfHyp   [chrfCharOrder + chrfWordOrder]int
+	chrfRef   [chrfCharOrder + chrfWordOrder]int
+	chrfMatch [chrfCharOrder + chrfWordOrder]int
+
+	terEdits  int
+	terRefLen int
+}
+
+// add 累加统计量
+func (s *segmentStats) add(other *segmentStats) {
+	for n := range s.bleuMatches {
+		s.bleuMatches[n] += other.bleuMatches[n]
+		s.bleuTotals[n] += other.bleuTotals[n]
+	}
+	s.hypLen += other.hypLen
+	s.refLen += other.refLen
+	for n := range s.chrfHyp {
+		s.chrfHyp[n] += other.chrfHyp[n]
+		s.chrfRef[n] += other.chrfRef[n]
+		s.chrfMatch[n] += other.chrfMatch[n]
+	}
+	s.terEdits += other.terEdits
+	s.terRefLen += other.terRefLen
+}
+
+// computeStats 计算一对译文和参考译文的统计量
+func computeStats(hypothesis, reference string) *segmentStats {
+	stats := &segmentStats{}
+	hypTokens, refTokens := Tokenize(hypothesis), Tokenize(reference)
+
+	// BLEU
+	stats.hypLen, stats.refLen = len(hypTokens), len(refTokens)
+	for n := 1; n <= bleuMaxOrder; n++ {
+		matches, total, _ := ngramOverlap(ngrams(hypTokens, n), ngrams(refTokens, n))
+		stats.bleuMatches[n-1], stats.bleuTotals[n-1] = matches, total
+	}
+
+	// chrF++：字符 n-gram 忽略空白，词 n-gram 使用同样的分词
+	hypChars, refChars := charsWithoutSpace(hypothesis), charsWithoutSpace(reference)
+	for n := 1; n <= chrfCharOrder; n++ {
+		matches, hypTotal, refTotal := ngramOverlap(ngrams(hypChars, n), ngrams(refChars, n))
+		stats.chrfMatch[n-1], stats.chrfHyp[n-1], stats.chrfRef[n-1] = matches, hypTotal, refTotal
+	}
+	for n := 1; n <= chrfWordOrder; n++ {
+		i := chrfCharOrder + n - 1
+		matches, hypTotal, refTotal := ngramOverlap(ngrams(hypTokens, n), ngrams(refTokens, n))
+		stats.chrfMatch[i], stats.chrfHyp[i], stats.chrfRef[i] = matches, hypTotal, refTotal
+	}
+
+	// TER 不区分大小写
+	stats.terEdits = terEdits(lowerTokens(hypTokens), lowerTokens(refTokens))
+	stats.terRefLen = len(refTokens)
+	return stats
+}
+
+// scores 由统计量计算评分，effectiveOrder 为 true 时 BLEU 只计入译文长度足够的阶数（用于单句评分）
+func (s *segmentStats) scores(effectiveOrder bool) Scores {
+	return Scores{BLEU: s.bleu(effectiveOrder), ChrF: s.chrf(), TER: s.ter()}
+}
+
+// bleu 计算 BLEU，没有匹配的阶数使用 sacreBLEU 的 exp 平滑；没有任何 1-gram 匹配时为 0。
+// 语料级使用完整的 4 阶，单句评分使用有效阶数，只计入译文长度足够的阶数
+func (s *segmentStats) bleu(effectiveOrder bool) float64 {
+	if s.hypLen == 0 || s.refLen == 0 || s.bleuMatches[0] == 0 {
+		return 0
+	}
+
+	logSum := 0.0
+	order := 0
+	smooth := 1.0
+	for n := 0; n < bleuMaxOrder; n++ {
+		if s.bleuTotals[n] == 0 {
+			if effectiveOrder {
+				break
+			}
+			return 0
+		}
+		order++
+		if s.bleuMatches[n] == 0 {
+			smooth *= 2
+			logSum += math.Log(1 / (smooth * float64(s.bleuTotals[n])))
+		} else {
+			logSum += math.Log(float64(s.bleuMatches[n]) / float64(s.bleuTotals[n]))
+		}
+	}
+
+	brevity := 1.0
+	if s.hypLen < s.refLen {
+		brevity = math.Exp(1 - float64(s.refLen)/float64(s.hypLen))
+	}
+	return 100 * brevity * math.Exp(logSum/float64(order))
+}
+
+// chrf 计算 chrF++：各阶精确率和召回率分别取平均后计算 F-beta
+func (s *segmentStats) chrf() float64 {
+	var precision, recall float64
+	order := 0
+	for n := range s.chrfHyp {
+		if s.chrfHyp[n] == 0 || s.chrfRef[n] == 0 {
+			continue
+		}
+		order++
+		precision += float64(s.chrfMatch[n]) / float64(s.chrfHyp[n])
+		recall += float64(s.chrfMatch[n]) / float64(s.chrfRef[n])
+	}
+	if order == 0 {
+		return 0
+	}
+	precision /= float64(order)
+	recall /= float64(order)
+	if precision+recall == 0 {
+		return 0
+	}
+	factor := float64(chrfBeta * chrfBeta)
+	return 100 * (1 + factor) * precision * recall / (factor*precision + recall)
+}
+
+// ter 计算 TER：编辑次数（含移位）除以参考译文词数
+func (s *segmentStats) ter() float64 {
+	if s.terRefLen == 0 {
+		if s.terEdits == 0 {
+			return 0
+		}
+		return 100
+	}
+	return 100 * float64(s.terEdits) / float64(s.terRefLen)
+}
+
+// ScoreSegments 计算语料级评分和每一段的评分，hypotheses 与 references 一一对应
+func ScoreSegments(hypotheses, references []string) (Scores, []Scores) {
+	corpus := &segmentStats{}
+	segments := make([]Scores, len(hypotheses))
+	for i := range hypotheses {
+		stats := computeStats(hypotheses[i], references[i])
+		segments[i] = stats.scores(true)
+		corpus.add(stats)
+	}
+	return corpus.scores(false), segments
+}
+
+// ngrams 统计 n-gram 出现次数
+func ngrams(tokens []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(tokens); i++ {
+		counts[strings.Join(tokens[i:i+n], "\x00")]++
+	}
+	return counts
+}
+
+// ngramOverlap 返回截断后的匹配数以及译文和参考译文的 n-gram 总数
+func ngramOverlap(hyp, ref map[string]int) (matches, hypTotal, refTotal int) {
+	for gram, count := range hyp {
+		hypTotal += count
+		if refCount, ok := ref[gram]; ok {
+			matches += min(count, refCount)
+		}
+	}
+	for _, count := range ref {
+		refTotal += count
+	}
+	return matches, hypTotal, refTotal
+}
+
+// charsWithoutSpace 把文本拆成不含空白的字符序列
+func charsWithoutSpace(text string) []string {
+	var chars []string
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			chars = append(chars, string(r))
+		}
+	}
+	return chars
+}
+
+// lowerTokens 把词转为小写
+func lowerTokens(tokens []string) []string {
+	lowered := make([]string, len(tokens))
+	for i, token := range tokens {
+		lowered[i] = strings.ToLower(token)
+	}
+	return lowered
+}
+
+// terEdits 计算 TER 编辑次数：贪心地执行能减少编辑距离的移位（每次移位计 1 次编辑），
+// 直到没有收益，再加上剩余的插入、删除和替换次数。与 tercom 一样只移动含未对齐词的片段，
+// 每轮最多尝试 terMaxShiftCandidates 个移位
+func terEdits(hyp, ref []string) int {
+	refPositions := make(map[string][]int)
+	for i, token := range ref {
+		refPositions[token] = append(refPositions[token], i)
+	}
+
+	shifts := 0
+	for {
+		matrix := newTerMatrix(hyp, ref)
+		distance, alignment := matrix.distance(), matrix.align()
+		best, bestGain := []string(nil), 0
+		candidates := 0
+	search:
+		for start := range hyp {
+			for length := 1; length <= terMaxShift && start+length <= len(hyp); length++ {
+				if alignment.matchedHyp(start, length) {
+					continue
+				}
+				for _, refStart := range refPositions[hyp[start]] {
+					if refStart+length > len(ref) || !equalTokens(hyp[start:start+length], ref[refStart:refStart+length]) || alignment.matchedRef(refStart, length) {
+						continue
+					}
+					for _, target := range alignment.shiftTargets(refStart) {
+						if target >= start && target <= start+length || abs(target-start) > terMaxShiftTo {
+							continue
+						}
+						if candidates == terMaxShiftCandidates {
+							break search
+						}
+						candidates++
+						shifted := shiftTokens(hyp, start, length, target)
+						newDistance := matrix.shiftedDistance(shifted, min(start, target))
+						if gain := distance - newDistance - 1; gain > bestGain {
+							best, bestGain = shifted, gain
+						}
+					}
+				}
+			}
+		}
+		if best == nil {
+			return distance + shifts
+		}
+		hyp = best
+		shifts++
+	}
+}
+
+// terAlignment 编辑距离的对齐结果
+type terAlignment struct {
+	hypMatched []bool // 译文词是否与参考译文词精确对齐
+	refMatched []bool // 参考译文词是否与译文词精确对齐
+	hypPos     []int  // 参考译文词在译文中对应的位置（移位的目标位置）
+}
+
+// matchedHyp 判断译文片段是否已全部精确对齐
+func (a *terAlignment) matchedHyp(start, length int) bool {
+	for i := start; i < start+length; i++ {
+		if !a.hypMatched[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchedRef 判断参考译文片段是否已全部精确对齐
+func (a *terAlignment) matchedRef(start, length int) bool {
+	for i := start; i < start+length; i++ {
+		if !a.refMatched[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shiftTargets 把片段移到参考译文 refStart 处时可选的译文位置：
+// 对应词之前、之后，以及参考译文前一个词对应位置之后
+func (a *terAlignment) shiftTargets(refStart int) []int {
+	targets := []int{a.hypPos[refStart], a.hypPos[refStart] + 1, 0}
+	if refStart > 0 {
+		targets[2] = a.hypPos[refStart-1] + 1
+	}
+	var unique []int
+	for _, target := range targets {
+		if target <= len(a.hypMatched) && !slices.Contains(unique, target) {
+			unique = append(unique, target)
+		}
+	}
+	return unique
+}
+
+// terMatrix 词级 Levenshtein 距离矩阵。与 tercom 一样只计算对角线附近 terBeamWidth 宽的带内的单元格，
+// 长度相差悬殊时放宽带宽以保证终点可达
+type terMatrix struct {
+	hyp, ref []string
+	beam     int
+	dp       []int
+	scratch  [2][]int // shiftedDistance 使用的两行
+}
+
+// terUnreachable 带外单元格的距离
+const terUnreachable = math.MaxInt32 / 2
+
+// newTerMatrix 计算 hyp 与 ref 的编辑距离矩阵
+func newTerMatrix(hyp, ref []string) *terMatrix {
+	m := &terMatrix{hyp: hyp, ref: ref, beam: terBeamWidth}
+	if len(hyp) > 0 {
+		m.beam = max(m.beam, (len(ref)+len(hyp)-1)/len(hyp))
+	}
+	cols := len(ref) + 1
+	m.dp = make([]int, (len(hyp)+1)*cols)
+	for j := 0; j < cols; j++ {
+		m.dp[j] = j
+	}
+	for i := 1; i <= len(hyp); i++ {
+		m.fillRow(hyp, i, m.dp[(i-1)*cols:i*cols], m.dp[i*cols:(i+1)*cols])
+	}
+	return m
+}
+
+// band 返回第 i 行计算的列范围
+func (m *terMatrix) band(i int) (int, int) {
+	if i == 0 {
+		return 0, len(m.ref)
+	}
+	diagonal := i * len(m.ref) / len(m.hyp)
+	return max(0, diagonal-m.beam), min(len(m.ref), diagonal+m.beam)
+}
+
+// cell 返回一行中第 j 列的距离，带外为 terUnreachable
+func (m *terMatrix) cell(row []int, i, j int) int {
+	if lo, hi := m.band(i); j < lo || j > hi {
+		return terUnreachable
+	}
+	return row[j]
+}
+
+// fillRow 由上一行计算 hyp 第 i 行带内的距离
+func (m *terMatrix) fillRow(hyp []string, i int, prev, row []int) {
+	lo, hi := m.band(i)
+	for j := lo; j <= hi; j++ {
+		if j == 0 {
+			row[0] = i
+			continue
+		}
+		cost := 1
+		if hyp[i-1] == m.ref[j-1] {
+			cost = 0
+		}
+		left := terUnreachable
+		if j > lo {
+			left = row[j-1]
+		}
+		row[j] = min(m.cell(prev, i-1, j-1)+cost, m.cell(prev, i-1, j)+1, left+1)
+	}
+}
+
+// distance 返回编辑距离
+func (m *terMatrix) distance() int {
+	return m.dp[len(m.dp)-1]
+}
+
+// shiftedDistance 计算与 hyp 等长、前 prefix 个词相同的 shifted 与 ref 的编辑距离，
+// 复用矩阵的前 prefix 行，其余行只保留两行
+func (m *terMatrix) shiftedDistance(shifted []string, prefix int) int {
+	cols := len(m.ref) + 1
+	if prefix >= len(shifted) {
+		return m.distance()
+	}
+	prev := m.dp[prefix*cols : (prefix+1)*cols]
+	if m.scratch[0] == nil {
+		m.scratch = [2][]int{make([]int, cols), make([]int, cols)}
+	}
+	for i := prefix + 1; i <= len(shifted); i++ {
+		row := m.scratch[i%2]
+		m.fillRow(shifted, i, prev, row)
+		prev = row
+	}
+	return prev[len(m.ref)]
+}
+
+// align 回溯编辑距离矩阵得到对齐
+func (m *terMatrix) align() *terAlignment {
+	hyp, ref := m.hyp, m.ref
+	cols := len(ref) + 1
+	at := func(i, j int) int {
+		return m.cell(m.dp[i*cols:(i+1)*cols], i, j)
+	}
+
+	alignment := &terAlignment{
+		hypMatched: make([]bool, len(hyp)),
+		refMatched: make([]bool, len(ref)),
+		hypPos:     make([]int, len(ref)),
+	}
+	i, j := len(hyp), len(ref)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && hyp[i-1] == ref[j-1] && at(i, j) == at(i-1, j-1):
+			alignment.hypMatched[i-1], alignment.refMatched[j-1] = true, true
+			alignment.hypPos[j-1] = i - 1
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && at(i, j) == at(i-1, j-1)+1:
+			alignment.hypPos[j-1] = i - 1
+			i, j = i-1, j-1
+		case j > 0 && at(i, j) == at(i, j-1)+1:
+			alignment.hypPos[j-1] = i
+			j--
+		default:
+			i--
+		}
+	}
+	return alignment
+}
+
+// shiftTokens 把 tokens[start:start+length] 移到 target 位置之前
+func shiftTokens(tokens []string, start, length, target int) []string {
+	phrase := tokens[start : start+length]
+	rest := make([]string, 0, len(tokens)-length)
+	rest = append(rest, tokens[:start]...)
+	rest = append(rest, tokens[start+length:]...)
+	if target > start {
+		target -= length
+	}
+	shifted := make([]string, 0, len(tokens))
+	shifted = append(shifted, rest[:target]...)
+	shifted = append(shifted, phrase...)
+	return append(shifted, rest[target:]...)
+}
+
+// equalTokens 判断两个词序列是否相同
+func equalTokens(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,426 @@
+package experiment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/stats"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"go.uber.org/zap"
+)
+
+// Variant 实验变体：一个步骤集，可选地使用另一套提示词模板
+type Variant struct {
+	Name               string `json:"name"`
+	StepSet            string `json:"step_set"`
+	PromptTemplatesDir string `json:"prompt_templates_dir,omitempty"`
+}
+
+// ParseVariant 解析变体描述 "[名称=]步骤集[@模板目录]"，名称默认为描述本身
+func ParseVariant(spec string) (Variant, error) {
+	spec = strings.TrimSpace(spec)
+	variant := Variant{Name: spec}
+	if name, rest, ok := strings.Cut(spec, "="); ok {
+		variant.Name = strings.TrimSpace(name)
+		spec = strings.TrimSpace(rest)
+	}
+	variant.StepSet, variant.PromptTemplatesDir, _ = strings.Cut(spec, "@")
+	if variant.Name == "" || variant.StepSet == "" {
+		return variant, fmt.Errorf("invalid variant %q: expected [name=]step_set[@templates_dir]", spec)
+	}
+	return variant, nil
+}
+
+// NodeOutput 一个变体对一个节点的翻译结果
+type NodeOutput struct {
+	Translation string        `json:"translation"`
+	Error       string        `json:"error,omitempty"`
+	Latency     time.Duration `json:"latency"`
+	Score       Score         `json:"score"`
+}
+
+// NodeResult 一个节点在各变体下的结果
+type NodeResult struct {
+	ID      int                    `json:"id"`
+	Source  string                 `json:"source"`
+	Outputs map[string]*NodeOutput `json:"outputs"` // 变体名称 -> 结果
+	Winner  string                 `json:"winner,omitempty"`
+}
+
+// VariantSummary 变体的汇总结果，令牌、成本和请求延迟来自提供商统计
+type VariantSummary struct {
+	Variant        Variant       `json:"variant"`
+	Nodes          int           `json:"nodes"`
+	Failed         int           `json:"failed"`
+	HeuristicScore float64       `json:"heuristic_score"` // 平均启发式评分（0-1）
+	JudgeScore     float64       `json:"judge_score"`     // 平均评审评分（0-10），未启用评审时为 0
+	Wins           int           `json:"wins"`            // 评审评分最高的节点数
+	Requests       int64         `json:"requests"`
+	TokensIn       int64         `json:"tokens_in"`
+	TokensOut      int64         `json:"tokens_out"`
+	Cost           float64       `json:"cost"`
+	CostEstimated  bool          `json:"cost_estimated"`  // 提供商没有返回成本，按模型配置的价格估算
+	RequestLatency time.Duration `json:"request_latency"` // 平均请求延迟
+	NodeLatency    time.Duration `json:"node_latency"`    // 平均每个节点的翻译耗时
+	Duration       time.Duration `json:"duration"`        // 变体的总耗时
+}
+
+// Result 实验结果
+type Result struct {
+	Document       string            `json:"document"`
+	Format         string            `json:"format"`
+	SourceLanguage string            `json:"source_language"`
+	TargetLanguage string            `json:"target_language"`
+	Judge          string            `json:"judge,omitempty"`
+	StartTime      time.Time         `json:"start_time"`
+	Variants       []*VariantSummary `json:"variants"`
+	Nodes          []*NodeResult     `json:"nodes"`
+}
+
+// Options 实验选项
+type Options struct {
+	Judge       string // 评审模型名称，为空时只使用启发式评分
+	MaxNodes    int    // 最多翻译的节点数，0 表示全部
+	Concurrency int    // 每个变体并行翻译的节点数
+}
+
+// Runner 在同一份样例文档上运行多个变体并评分
+type Runner struct {
+	config  *config.Config
+	logger  *zap.Logger
+	options Options
+}
+
+// NewRunner 创建实验运行器
+func NewRunner(cfg *config.Config, logger *zap.Logger, options Options) *Runner {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	return &Runner{config: cfg, logger: logger, options: options}
+}
+
+// Run 解析样例文档，用 ThreeStepTranslator 按每个变体翻译全部节点，然后评分
+func (r *Runner) Run(ctx context.Context, inputPath string, variants []Variant) (*Result, error) {
+	if len(variants) < 2 {
+		return nil, fmt.Errorf("at least two variants are required, got %d", len(variants))
+	}
+	seen := make(map[string]bool)
+	for _, v := range variants {
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		seen[v.Name] = true
+		if _, ok := r.config.StepSets[v.StepSet]; !ok {
+			return nil, fmt.Errorf("variant %q: step set %q not found", v.Name, v.StepSet)
+		}
+	}
+
+	var judge *Judge
+	if r.options.Judge != "" {
+		var err error
+		if judge, err = r.newJudge(r.options.Judge); err != nil {
+			return nil, err
+		}
+	}
+
+	format, sources, err := r.extractNodes(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if r.options.MaxNodes > 0 && len(sources) > r.options.MaxNodes {
+		sources = sources[:r.options.MaxNodes]
+	}
+
+	result := &Result{
+		Document:       inputPath,
+		Format:         format,
+		SourceLanguage: r.config.SourceLang,
+		TargetLanguage: r.config.TargetLang,
+		Judge:          r.options.Judge,
+		StartTime:      time.Now(),
+	}
+	for i, source := range sources {
+		result.Nodes = append(result.Nodes, &NodeResult{ID: i + 1, Source: source, Outputs: make(map[string]*NodeOutput)})
+	}
+
+	ctx = translation.WithDocumentFormat(ctx, format)
+	for _, v := range variants {
+		summary, err := r.runVariant(ctx, v, result.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", v.Name, err)
+		}
+		result.Variants = append(result.Variants, summary)
+	}
+
+	if judge != nil {
+		r.judgeNodes(ctx, judge, result)
+	}
+	summarize(result)
+	return result, nil
+}
+
+// extractNodes 用文档处理器解析样例文档，返回文档格式和可翻译节点的原文
+func (r *Runner) extractNodes(inputPath string) (string, []string, error) {
+	content, err := os.ReadFile(inputPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read sample document: %w", err)
+	}
+
+	processor, err := document.GetProcessorByExtension(inputPath, document.ProcessorOptions{
+		ChunkSize:    r.config.ChunkSize,
+		ChunkOverlap: 100,
+		Metadata: map[string]interface{}{
+			"source_language": r.config.SourceLang,
+			"target_language": r.config.TargetLang,
+			"logger":          r.logger,
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get document processor: %w", err)
+	}
+	doc, err := processor.Parse(context.Background(), bytes.NewReader(content))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse sample document: %w", err)
+	}
+
+	var sources []string
+	for _, block := range doc.Blocks {
+		if block.IsTranslatable() && strings.TrimSpace(block.GetContent()) != "" {
+			sources = append(sources, block.GetContent())
+		}
+	}
+	if len(sources) == 0 {
+		return "", nil, fmt.Errorf("no translatable nodes found in %s", inputPath)
+	}
+	return string(doc.Format), sources, nil
+}
+
+// runVariant 用变体的 ThreeStepTranslator 翻译全部节点，节点结果写入 nodes
+func (r *Runner) runVariant(ctx context.Context, v Variant, nodes []*NodeResult) (*VariantSummary, error) {
+	statsManager := stats.NewStatsManager("", r.logger)
+	translator, err := r.newTranslator(v, statsManager)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("running experiment variant",
+		zap.String("variant", v.Name),
+		zap.String("step_set", v.StepSet),
+		zap.Int("nodes", len(nodes)))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.options.Concurrency)
+	startTime := time.Now()
+	for _, node := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(node *NodeResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			nodeStart := time.Now()
+			translated, err := translator.TranslateText(ctx, node.Source)
+			output := &NodeOutput{Translation: translated, Latency: time.Since(nodeStart)}
+			if err != nil {
+				output.Error = err.Error()
+			}
+			output.Score = HeuristicScore(node.Source, translated)
+			mu.Lock()
+			node.Outputs[v.Name] = output
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	summary := &VariantSummary{Variant: v, Nodes: len(nodes), Duration: time.Since(startTime)}
+	var totalLatency time.Duration
+	for _, ps := range statsManager.GetAllStats() {
+		summary.Requests += ps.TotalRequests
+		summary.TokensIn += ps.TotalTokensIn
+		summary.TokensOut += ps.TotalTokensOut
+		totalLatency += ps.TotalLatency
+		if ps.TotalCost > 0 {
+			summary.Cost += ps.TotalCost
+		} else if model, ok := r.config.ModelConfigs[ps.ModelName]; ok {
+			// 价格按每 1M token 配置
+			estimated := (float64(ps.TotalTokensIn)*model.InputTokenPrice + float64(ps.TotalTokensOut)*model.OutputTokenPrice) / 1e6
+			if estimated > 0 {
+				summary.Cost += estimated
+				summary.CostEstimated = true
+			}
+		}
+	}
+	if summary.Requests > 0 {
+		summary.RequestLatency = totalLatency / time.Duration(summary.Requests)
+	}
+	return summary, nil
+}
+
+// newTranslator 按变体的步骤集创建 ThreeStepTranslator，前三个步骤依次作为初始翻译、反思和改进，
+// 每个提供商都经过统计中间件以记录令牌、成本和延迟
+func (r *Runner) newTranslator(v Variant, statsManager *stats.StatsManager) (*translation.ThreeStepTranslator, error) {
+	stepSet := r.config.StepSets[v.StepSet]
+
+	metadata := map[string]interface{}{}
+	if r.config.Country != "" {
+		metadata["country"] = r.config.Country
+	}
+	if stepSet.FastModeThreshold > 0 {
+		metadata["fast_mode_threshold"] = stepSet.FastModeThreshold
+	}
+	translationConfig := &translation.Config{
+		SourceLanguage:     r.config.SourceLang,
+		TargetLanguage:     r.config.TargetLang,
+		ModelConfigs:       r.config.ModelConfigs,
+		GlossaryPath:       r.config.GlossaryPath,
+		PromptTemplatesDir: r.config.PromptTemplatesDir,
+		Metadata:           metadata,
+	}
+	if v.PromptTemplatesDir != "" {
+		translationConfig.PromptTemplatesDir = v.PromptTemplatesDir
+	}
+	providerManager := translation.NewProviderManager(translationConfig, r.logger)
+
+	// 按 "提供商/模型" 区分提供商，同一提供商的不同模型互不覆盖
+	providerMap := make(map[string]translation.Provider)
+	var steps [3]translation.StepConfig
+	for i, step := range stepSet.Steps {
+		if i >= len(steps) {
+			break
+		}
+		if step.ModelName == "raw" || step.ModelName == "none" {
+			continue
+		}
+		key := step.Provider + "/" + step.ModelName
+		if _, exists := providerMap[key]; !exists {
+			provider, err := providerManager.CreateProvider(step.Provider, step.ModelName)
+			if err != nil {
+				return nil, fmt.Errorf("step '%s': %w", step.Name, err)
+			}
+			if full, ok := provider.(providers.Provider); ok {
+				provider = stats.NewStatisticsMiddleware(full, statsManager, step.Provider, step.ModelName)
+			}
+			providerMap[key] = translation.NewProviderAdapter(provider)
+		}
+		modelConfig := r.config.ModelConfigs[step.ModelName]
+		steps[i] = translation.StepConfig{
+			Name:        step.Name,
+			Provider:    key,
+			Model:       modelConfig.ModelID,
+			Temperature: float32(step.Temperature),
+			MaxTokens:   step.MaxTokens,
+			InputPrice:  modelConfig.InputTokenPrice,
+			OutputPrice: modelConfig.OutputTokenPrice,
+		}
+	}
+	if steps[0].Provider == "" {
+		return nil, fmt.Errorf("step set %q has no initial translation step", v.StepSet)
+	}
+
+	translator := translation.NewThreeStepTranslator(translationConfig, providerMap, &translation.StepSetConfig{
+		Name:        v.StepSet,
+		Initial:     steps[0],
+		Reflection:  steps[1],
+		Improvement: steps[2],
+		Mode:        stepSet.Mode,
+		Review: translation.ReviewConfig{
+			MaxIterations: stepSet.Review.MaxIterations,
+			MaxCost:       stepSet.Review.MaxCost,
+			MaxTokens:     stepSet.Review.MaxTokens,
+		},
+	})
+
+	templates, err := translationConfig.LoadPromptTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	translator.GetPromptBuilder().WithTemplates(templates, v.StepSet, "")
+	return translator, nil
+}
+
+// summarize 计算各变体的平均评分、失败数和胜出次数
+func summarize(result *Result) {
+	for _, summary := range result.Variants {
+		var heuristic, judge, latency float64
+		judged := 0
+		for _, node := range result.Nodes {
+			output := node.Outputs[summary.Variant.Name]
+			if output == nil {
+				continue
+			}
+			if output.Error != "" {
+				summary.Failed++
+			}
+			heuristic += output.Score.Heuristic
+			latency += float64(output.Latency)
+			if output.Score.Judged {
+				judge += output.Score.Judge
+				judged++
+			}
+			if node.Winner == summary.Variant.Name {
+				summary.Wins++
+			}
+		}
+		if len(result.Nodes) > 0 {
+			summary.HeuristicScore = heuristic / float64(len(result.Nodes))
+			summary.NodeLatency = time.Duration(latency / float64(len(result.Nodes)))
+		}
+		if judged > 0 {
+			summary.JudgeScore = judge / float64(judged)
+		}
+	}
+}
+
+// variantNames 返回结果中的变体名称，按运行顺序
+func (r *Result) variantNames() []string {
+	names := make([]string, len(r.Variants))
+	for i, summary := range r.Variants {
+		names[i] = summary.Variant.Name
+	}
+	return names
+}
+
+// sortedOutputs 按变体顺序返回节点的结果
+func (n *NodeResult) sortedOutputs(names []string) []*NodeOutput {
+	outputs := make([]*NodeOutput, 0, len(names))
+	for _, name := range names {
+		outputs = append(outputs, n.Outputs[name])
+	}
+	return outputs
+}
+
+// bestVariant 返回评审评分唯一最高的变体，没有评审或并列时返回空
+func bestVariant(node *NodeResult, names []string) string {
+	type scored struct {
+		name  string
+		score float64
+	}
+	var scores []scored
+	for _, name := range names {
+		if output := node.Outputs[name]; output != nil && output.Score.Judged {
+			scores = append(scores, scored{name, output.Score.Judge})
+		}
+	}
+	if len(scores) == 0 {
+		return ""
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	if len(scores) > 1 && scores[0].score == scores[1].score {
+		return ""
+	}
+	return scores[0].name
+}
@@ -0,0 +1,195 @@
+package experiment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newOllamaServer 模拟 Ollama：按模型返回固定的译文，judge 模型返回评分
+func newOllamaServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		reply := map[string]interface{}{"model": req.Model, "done": true, "prompt_eval_count": 10, "eval_count": 5}
+		switch req.Model {
+		case "judge":
+			reply["response"] = `{"scores": [{"candidate": "A", "score": 8, "reason": "accurate"}, {"candidate": "B", "score": 5, "reason": "stiff"}]}`
+		case "qwen":
+			reply["response"] = "通义译文"
+		default:
+			reply["response"] = "骆驼译文"
+		}
+		_ = json.NewEncoder(w).Encode(reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newExperimentConfig(baseURL string) *config.Config {
+	return &config.Config{
+		SourceLang: "English",
+		TargetLang: "Chinese",
+		ChunkSize:  2000,
+		ModelConfigs: map[string]config.ModelConfig{
+			"llama": {Name: "llama", ModelID: "llama", APIType: "ollama", BaseURL: baseURL, InputTokenPrice: 1, OutputTokenPrice: 2},
+			"qwen":  {Name: "qwen", ModelID: "qwen", APIType: "ollama", BaseURL: baseURL},
+			"judge": {Name: "judge", ModelID: "judge", APIType: "ollama", BaseURL: baseURL},
+		},
+		StepSets: map[string]config.StepSetConfigV2{
+			"llama": {ID: "llama", Steps: []config.StepConfigV2{{Name: "initial", Provider: "ollama", ModelName: "llama"}}},
+			"qwen":  {ID: "qwen", Steps: []config.StepConfigV2{{Name: "initial", Provider: "ollama", ModelName: "qwen"}}},
+		},
+	}
+}
+
+func writeSample(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "sample.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Getting started\n\nInstall the tool.\n\nRun it on a file.\n"), 0o644))
+	return path
+}
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Variant
+	}{
+		{"basic", Variant{Name: "basic", StepSet: "basic"}},
+		{"basic@./prompts-v2", Variant{Name: "basic@./prompts-v2", StepSet: "basic", PromptTemplatesDir: "./prompts-v2"}},
+		{"v2=basic@./prompts-v2", Variant{Name: "v2", StepSet: "basic", PromptTemplatesDir: "./prompts-v2"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVariant(tt.spec)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := ParseVariant("v2=")
+	assert.Error(t, err)
+}
+
+func TestHeuristicScore(t *testing.T) {
+	assert.Equal(t, 1.0, HeuristicScore("**Install** the tool.", "**安装**工具。").Heuristic)
+
+	score := HeuristicScore("Install the tool now.", "Install the tool now.")
+	assert.Equal(t, []string{IssueTooSimilar}, score.Issues)
+	assert.InDelta(t, 0.5, score.Heuristic, 1e-9)
+
+	score = HeuristicScore("Use `go build` to compile.", "使用 go build 编译。")
+	assert.Contains(t, score.Issues, IssueFormat)
+
+	assert.Equal(t, []string{IssueEmpty}, HeuristicScore("Hello", "  ").Issues)
+}
+
+func TestParseJudgeScores(t *testing.T) {
+	scores, err := parseJudgeScores("Here you go:\n```json\n{\"scores\": [{\"candidate\": \"b\", \"score\": 4}, {\"candidate\": \"A\", \"score\": 9, \"reason\": \"ok\"}]}\n```", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, scores[0].Score)
+	assert.Equal(t, "ok", scores[0].Reason)
+	assert.Equal(t, "B", scores[1].Candidate)
+
+	_, err = parseJudgeScores(`{"scores": [{"candidate": "A", "score": 9}]}`, 2)
+	assert.ErrorContains(t, err, "no score for candidate B")
+
+	_, err = parseJudgeScores(`{"scores": [{"candidate": "A", "score": 42}]}`, 1)
+	assert.ErrorContains(t, err, "out of range")
+
+	_, err = parseJudgeScores("looks great", 1)
+	assert.Error(t, err)
+}
+
+func TestParseBatchJudgeScores(t *testing.T) {
+	segments := []JudgeSegment{
+		{ID: 3, Candidates: []string{"a", "b"}},
+		{ID: 7, Candidates: []string{"a", "b"}},
+		{ID: 9, Candidates: []string{"a"}},
+	}
+	response := "```json\n" + `{"segments": [
+		{"segment": 3, "scores": [{"candidate": "A", "score": 4}, {"candidate": "B", "score": 8, "reason": "better"}]},
+		{"segment": 7, "scores": [{"candidate": "A", "score": 6}]},
+		{"segment": 9, "scores": [{"candidate": "A", "score": 11}]},
+		{"segment": 42, "scores": [{"candidate": "A", "score": 5}]}
+	]}` + "\n```"
+	scores, err := parseBatchJudgeScores(response, segments)
+	require.NoError(t, err)
+
+	// 评分不完整、超出范围或未请求的片段不出现在结果中
+	require.Len(t, scores, 1)
+	assert.Equal(t, 8.0, scores[3][1].Score)
+	assert.Equal(t, "better", scores[3][1].Reason)
+
+	_, err = parseBatchJudgeScores("no idea", segments)
+	assert.Error(t, err)
+}
+
+func TestRunnerRun(t *testing.T) {
+	server := newOllamaServer(t)
+	runner := NewRunner(newExperimentConfig(server.URL), zap.NewNop(), Options{Judge: "judge", MaxNodes: 2, Concurrency: 2})
+
+	result, err := runner.Run(context.Background(), writeSample(t), []Variant{
+		{Name: "llama", StepSet: "llama"},
+		{Name: "qwen", StepSet: "qwen"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "markdown", result.Format)
+	require.Len(t, result.Nodes, 2)
+	require.Len(t, result.Variants, 2)
+
+	for _, node := range result.Nodes {
+		assert.Equal(t, "骆驼译文", node.Outputs["llama"].Translation)
+		assert.Equal(t, "通义译文", node.Outputs["qwen"].Translation)
+		assert.Equal(t, 8.0, node.Outputs["llama"].Score.Judge)
+		assert.Equal(t, "llama", node.Winner)
+	}
+
+	llama := result.Variants[0]
+	assert.Equal(t, 2, llama.Wins)
+	assert.Equal(t, 8.0, llama.JudgeScore)
+	assert.Equal(t, int64(2), llama.Requests)
+	assert.Equal(t, int64(20), llama.TokensIn)
+	assert.Equal(t, int64(10), llama.TokensOut)
+	assert.True(t, llama.CostEstimated)
+	assert.InDelta(t, 40e-6, llama.Cost, 1e-12, "20 tokens in at 1/M plus 10 tokens out at 2/M")
+	assert.Zero(t, result.Variants[1].Cost, "qwen has no prices configured")
+
+	var markdown, html, results bytes.Buffer
+	require.NoError(t, WriteReport(&markdown, result, ReportMarkdown))
+	assert.Contains(t, markdown.String(), "| llama | llama | default | 1.00 | 8.00 | 2 | 0 | 2 | 20 / 10 | ~0.000040 |")
+	assert.Contains(t, markdown.String(), "**llama** 🏆 — heuristic 1.00, judge 8/10")
+
+	require.NoError(t, WriteReport(&html, result, ReportHTML))
+	assert.Contains(t, html.String(), `<td class="winner">`)
+	assert.Contains(t, html.String(), "通义译文")
+
+	require.NoError(t, WriteJSON(&results, result))
+	var decoded Result
+	require.NoError(t, json.Unmarshal(results.Bytes(), &decoded))
+	assert.Equal(t, "通义译文", decoded.Nodes[0].Outputs["qwen"].Translation)
+}
+
+func TestRunnerRunValidatesVariants(t *testing.T) {
+	runner := NewRunner(newExperimentConfig("http://localhost:1"), nil, Options{})
+	sample := writeSample(t)
+
+	_, err := runner.Run(context.Background(), sample, []Variant{{Name: "llama", StepSet: "llama"}})
+	assert.ErrorContains(t, err, "at least two variants")
+
+	_, err = runner.Run(context.Background(), sample, []Variant{{Name: "a", StepSet: "llama"}, {Name: "b", StepSet: "missing"}})
+	assert.ErrorContains(t, err, `step set "missing" not found`)
+
+	_, err = runner.Run(context.Background(), sample, []Variant{{Name: "a", StepSet: "llama"}, {Name: "a", StepSet: "qwen"}})
+	assert.ErrorContains(t, err, "duplicate variant name")
+}
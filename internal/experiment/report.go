@@ -0,0 +1,207 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// 报告格式
+const (
+	ReportMarkdown = "markdown"
+	ReportHTML     = "html"
+)
+
+// WriteJSON 以 JSON 写出完整结果（每个节点、每个变体的译文和评分）
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteReport 按格式写出对比报告
+func WriteReport(w io.Writer, result *Result, format string) error {
+	switch format {
+	case ReportMarkdown, "md", "":
+		return WriteMarkdown(w, result)
+	case ReportHTML:
+		return WriteHTML(w, result)
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+// WriteMarkdown 写出 Markdown 对比报告：变体汇总表和每个节点的并排译文
+func WriteMarkdown(w io.Writer, result *Result) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Translation experiment: %s\n\n", result.Document)
+	fmt.Fprintf(&b, "- Languages: %s → %s\n", result.SourceLanguage, result.TargetLanguage)
+	fmt.Fprintf(&b, "- Format: %s, %d nodes\n", result.Format, len(result.Nodes))
+	if result.Judge != "" {
+		fmt.Fprintf(&b, "- Judge: %s\n", result.Judge)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n\n", result.StartTime.Format(time.RFC3339))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Variant | Step set | Templates | Heuristic | Judge | Wins | Failed | Requests | Tokens in/out | Cost | Latency/request | Latency/node | Total |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, s := range result.Variants {
+		fmt.Fprintf(&b, "| %s | %s | %s | %.2f | %s | %s | %d | %d | %d / %d | %s | %s | %s | %s |\n",
+			markdownCell(s.Variant.Name), markdownCell(s.Variant.StepSet), markdownCell(templatesLabel(s.Variant)),
+			s.HeuristicScore, judgeLabel(result, s.JudgeScore), winsLabel(result, s.Wins), s.Failed, s.Requests,
+			s.TokensIn, s.TokensOut, costLabel(s), formatDuration(s.RequestLatency), formatDuration(s.NodeLatency), formatDuration(s.Duration))
+	}
+
+	names := result.variantNames()
+	b.WriteString("\n## Nodes\n")
+	for _, node := range result.Nodes {
+		fmt.Fprintf(&b, "\n### Node %d\n\n", node.ID)
+		b.WriteString("**Source**\n\n")
+		writeFenced(&b, node.Source)
+		for i, output := range node.sortedOutputs(names) {
+			name := names[i]
+			if output == nil {
+				continue
+			}
+			fmt.Fprintf(&b, "\n**%s**", name)
+			if name == node.Winner {
+				b.WriteString(" 🏆")
+			}
+			fmt.Fprintf(&b, " — %s\n\n", outputLabel(output))
+			if output.Error != "" {
+				fmt.Fprintf(&b, "> Error: %s\n", output.Error)
+				continue
+			}
+			writeFenced(&b, output.Translation)
+			if output.Score.JudgeReason != "" {
+				fmt.Fprintf(&b, "\n> %s\n", output.Score.JudgeReason)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlReport HTML 报告模板
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration":  formatDuration,
+	"cost":      costLabel,
+	"templates": templatesLabel,
+	"outputs":   func(n *NodeResult, names []string) []*NodeOutput { return n.sortedOutputs(names) },
+	"label":     outputLabel,
+	"index1":    func(names []string, i int) string { return names[i] },
+	"judge":     judgeLabel,
+	"wins":      winsLabel,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Translation experiment: {{.Result.Document}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 6px 8px; vertical-align: top; text-align: left; }
+th { background: #f4f4f4; }
+pre { white-space: pre-wrap; word-break: break-word; margin: 0; font-family: inherit; }
+.meta { color: #666; font-size: 0.85em; margin-bottom: 4px; }
+.winner { background: #eef8ee; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Translation experiment</h1>
+<p>{{.Result.Document}} · {{.Result.SourceLanguage}} → {{.Result.TargetLanguage}} · {{.Result.Format}}, {{len .Result.Nodes}} nodes{{if .Result.Judge}} · judge: {{.Result.Judge}}{{end}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Variant</th><th>Step set</th><th>Templates</th><th>Heuristic</th><th>Judge</th><th>Wins</th><th>Failed</th><th>Requests</th><th>Tokens in/out</th><th>Cost</th><th>Latency/request</th><th>Latency/node</th><th>Total</th></tr>
+{{range .Result.Variants}}<tr><td>{{.Variant.Name}}</td><td>{{.Variant.StepSet}}</td><td>{{templates .Variant}}</td><td>{{printf "%.2f" .HeuristicScore}}</td><td>{{judge $.Result .JudgeScore}}</td><td>{{wins $.Result .Wins}}</td><td>{{.Failed}}</td><td>{{.Requests}}</td><td>{{.TokensIn}} / {{.TokensOut}}</td><td>{{cost .}}</td><td>{{duration .RequestLatency}}</td><td>{{duration .NodeLatency}}</td><td>{{duration .Duration}}</td></tr>
+{{end}}</table>
+
+<h2>Nodes</h2>
+<table>
+<tr><th>#</th><th>Source</th>{{range .Names}}<th>{{.}}</th>{{end}}</tr>
+{{range $node := .Result.Nodes}}<tr><td>{{$node.ID}}</td><td><pre>{{$node.Source}}</pre></td>{{range $i, $output := outputs $node $.Names}}{{$name := index1 $.Names $i}}<td{{if eq $name $node.Winner}} class="winner"{{end}}>{{if $output}}<div class="meta">{{label $output}}</div>{{if $output.Error}}<div class="error">{{$output.Error}}</div>{{else}}<pre>{{$output.Translation}}</pre>{{if $output.Score.JudgeReason}}<div class="meta">{{$output.Score.JudgeReason}}</div>{{end}}{{end}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML 写出 HTML 对比报告，每个节点一行，各变体的译文并排显示
+func WriteHTML(w io.Writer, result *Result) error {
+	return htmlReport.Execute(w, struct {
+		Result *Result
+		Names  []string
+	}{result, result.variantNames()})
+}
+
+// outputLabel 节点结果的评分和耗时说明
+func outputLabel(output *NodeOutput) string {
+	label := fmt.Sprintf("heuristic %.2f", output.Score.Heuristic)
+	if output.Score.Judged {
+		label += fmt.Sprintf(", judge %.0f/10", output.Score.Judge)
+	}
+	if len(output.Score.Issues) > 0 {
+		label += ", issues: " + strings.Join(output.Score.Issues, ", ")
+	}
+	return label + ", " + formatDuration(output.Latency)
+}
+
+// templatesLabel 变体使用的提示词模板目录
+func templatesLabel(v Variant) string {
+	if v.PromptTemplatesDir == "" {
+		return "default"
+	}
+	return v.PromptTemplatesDir
+}
+
+// judgeLabel 平均评审评分，未启用评审时为 "-"
+func judgeLabel(result *Result, score float64) string {
+	if result.Judge == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", score)
+}
+
+// winsLabel 胜出次数，未启用评审时为 "-"
+func winsLabel(result *Result, wins int) string {
+	if result.Judge == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%d", wins)
+}
+
+// costLabel 成本，按价格估算的成本加 "~" 前缀
+func costLabel(s *VariantSummary) string {
+	if s.Cost == 0 {
+		return "-"
+	}
+	if s.CostEstimated {
+		return fmt.Sprintf("~%.6f", s.Cost)
+	}
+	return fmt.Sprintf("%.6f", s.Cost)
+}
+
+// formatDuration 把耗时格式化为毫秒精度
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// markdownCell 转义 Markdown 表格单元格中的竖线
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// writeFenced 把文本写为代码块，文本中包含 ``` 时使用更长的围栏
+func writeFenced(b *strings.Builder, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s\n%s\n%s\n", fence, strings.TrimRight(text, "\n"), fence)
+}
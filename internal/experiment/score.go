@@ -0,0 +1,274 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/stats"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"go.uber.org/zap"
+)
+
+// 启发式评分发现的问题
+const (
+	IssueEmpty         = "empty"          // 没有译文
+	IssueTooSimilar    = "too_similar"    // 译文与原文几乎相同
+	IssueFormat        = "format_issues"  // 格式标记丢失
+	IssueReasoningTags = "reasoning_tags" // 译文中残留推理标记
+)
+
+// Score 节点译文的评分
+type Score struct {
+	Heuristic   float64  `json:"heuristic"` // 0-1，每发现一个问题扣分
+	Issues      []string `json:"issues,omitempty"`
+	Judge       float64  `json:"judge,omitempty"` // 评审评分 1-10
+	Judged      bool     `json:"judged,omitempty"`
+	JudgeReason string   `json:"judge_reason,omitempty"`
+}
+
+// HeuristicScore 用统计中间件的相似度和格式检查为译文评分
+func HeuristicScore(source, translated string) Score {
+	if strings.TrimSpace(translated) == "" {
+		return Score{Issues: []string{IssueEmpty}}
+	}
+
+	score := Score{Heuristic: 1}
+	if stats.TooSimilar(source, translated) {
+		score.Heuristic -= 0.5
+		score.Issues = append(score.Issues, IssueTooSimilar)
+	}
+	if stats.HasFormatIssues(source, translated) {
+		score.Heuristic -= 0.3
+		score.Issues = append(score.Issues, IssueFormat)
+	}
+	if translation.HasReasoningTags(translated) {
+		score.Heuristic -= 0.2
+		score.Issues = append(score.Issues, IssueReasoningTags)
+	}
+	return score
+}
+
+// Judge 用 LLM 并排比较同一节点的多个译文并打分
+type Judge struct {
+	provider       translation.TranslationProvider
+	sourceLanguage string
+	targetLanguage string
+}
+
+// NewJudge 创建评审
+func NewJudge(provider translation.TranslationProvider, sourceLanguage, targetLanguage string) *Judge {
+	return &Judge{provider: provider, sourceLanguage: sourceLanguage, targetLanguage: targetLanguage}
+}
+
+// newJudge 用配置中的模型创建评审
+func (r *Runner) newJudge(modelName string) (*Judge, error) {
+	if _, ok := r.config.ModelConfigs[modelName]; !ok {
+		return nil, fmt.Errorf("judge model '%s' not found in configuration", modelName)
+	}
+	providerManager := translation.NewProviderManager(translation.NewConfigFromGlobal(r.config), r.logger)
+	provider, err := providerManager.CreateProvider("", modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create judge provider: %w", err)
+	}
+	return NewJudge(provider, r.config.SourceLang, r.config.TargetLang), nil
+}
+
+// judgeNodes 评审每个节点中没有出错的译文，并记录评分最高的变体
+func (r *Runner) judgeNodes(ctx context.Context, judge *Judge, result *Result) {
+	names := result.variantNames()
+	for _, node := range result.Nodes {
+		var candidates []string
+		var judged []*NodeOutput
+		for _, output := range node.sortedOutputs(names) {
+			if output != nil && output.Error == "" && strings.TrimSpace(output.Translation) != "" {
+				candidates = append(candidates, output.Translation)
+				judged = append(judged, output)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		scores, err := judge.Score(ctx, node.Source, candidates)
+		if err != nil {
+			r.logger.Warn("judge failed to score node", zap.Int("node", node.ID), zap.Error(err))
+			continue
+		}
+		for i, output := range judged {
+			output.Score.Judge = scores[i].Score
+			output.Score.JudgeReason = scores[i].Reason
+			output.Score.Judged = true
+		}
+		node.Winner = bestVariant(node, names)
+	}
+}
+
+// JudgeScore 评审对一个候选译文的评分
+type JudgeScore struct {
+	Candidate string  `json:"candidate"`
+	Score     float64 `json:"score"`
+	Reason    string  `json:"reason"`
+}
+
+// Score 让评审给每个候选译文打 1-10 分，返回的评分与 candidates 顺序一致
+func (j *Judge) Score(ctx context.Context, source string, candidates []string) ([]JudgeScore, error) {
+	resp, err := j.provider.Translate(ctx, &translation.ProviderRequest{
+		Text:           j.buildPrompt(source, candidates),
+		SourceLanguage: j.sourceLanguage,
+		TargetLanguage: j.targetLanguage,
+		Metadata: map[string]interface{}{
+			providers.MetadataRawPrompt: true,
+			"instruction":               "You are an expert translation reviewer. Reply with JSON only.",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseJudgeScores(resp.Text, len(candidates))
+}
+
+// buildPrompt 构建评审提示词，候选译文依次标记为 A、B、C……
+func (j *Judge) buildPrompt(source string, candidates []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Compare the following %s translations of a %s text. ", j.targetLanguage, j.sourceLanguage)
+	b.WriteString("Score each candidate from 1 (unusable) to 10 (publication quality) for accuracy, fluency, terminology and preservation of formatting. ")
+	b.WriteString("Judge each candidate on its own merits; the order is arbitrary.\n\n")
+	fmt.Fprintf(&b, "===== SOURCE =====\n%s\n\n", source)
+	for i, candidate := range candidates {
+		fmt.Fprintf(&b, "===== CANDIDATE %s =====\n%s\n\n", candidateLabel(i), candidate)
+	}
+	b.WriteString(`Reply with a JSON object only, in this shape:
+{"scores": [{"candidate": "A", "score": 8, "reason": "one short sentence"}]}`)
+	return b.String()
+}
+
+// candidateLabel 候选译文的标记
+func candidateLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+// JudgeSegment 批量评审中的一个片段及其候选译文
+type JudgeSegment struct {
+	ID         int
+	Source     string
+	Candidates []string
+}
+
+// ScoreBatch 用一次请求评审多个片段的候选译文，返回片段 ID 到评分的映射，评分与候选顺序一致。
+// 回复中缺少或评分无效的片段不出现在结果中
+func (j *Judge) ScoreBatch(ctx context.Context, segments []JudgeSegment) (map[int][]JudgeScore, error) {
+	resp, err := j.provider.Translate(ctx, &translation.ProviderRequest{
+		Text:           j.buildBatchPrompt(segments),
+		SourceLanguage: j.sourceLanguage,
+		TargetLanguage: j.targetLanguage,
+		Metadata: map[string]interface{}{
+			providers.MetadataRawPrompt: true,
+			"instruction":               "You are an expert translation reviewer. Reply with JSON only.",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchJudgeScores(resp.Text, segments)
+}
+
+// buildBatchPrompt 构建批量评审提示词，每个片段按 ID 标记，候选译文依次标记为 A、B、C……
+func (j *Judge) buildBatchPrompt(segments []JudgeSegment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Compare the candidate %s translations of each of the following %s segments. ", j.targetLanguage, j.sourceLanguage)
+	b.WriteString("Score each candidate from 1 (unusable) to 10 (publication quality) for accuracy, fluency, terminology and preservation of formatting. ")
+	b.WriteString("Judge each candidate on its own merits; the order is arbitrary. Segments are independent.\n\n")
+	for _, segment := range segments {
+		fmt.Fprintf(&b, "===== SEGMENT %d =====\n----- SOURCE -----\n%s\n\n", segment.ID, segment.Source)
+		for i, candidate := range segment.Candidates {
+			fmt.Fprintf(&b, "----- CANDIDATE %s -----\n%s\n\n", candidateLabel(i), candidate)
+		}
+	}
+	b.WriteString(`Reply with a JSON object only, in this shape, with an entry for every segment:
+{"segments": [{"segment": 1, "scores": [{"candidate": "A", "score": 8, "reason": "one short sentence"}]}]}`)
+	return b.String()
+}
+
+// parseBatchJudgeScores 解析批量评审的 JSON 回复
+func parseBatchJudgeScores(response string, segments []JudgeSegment) (map[int][]JudgeScore, error) {
+	var reply struct {
+		Segments []struct {
+			Segment int          `json:"segment"`
+			Scores  []JudgeScore `json:"scores"`
+		} `json:"segments"`
+	}
+	if err := unmarshalJudgeReply(response, &reply); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int, len(segments))
+	for _, segment := range segments {
+		counts[segment.ID] = len(segment.Candidates)
+	}
+	result := make(map[int][]JudgeScore, len(segments))
+	for _, entry := range reply.Segments {
+		count, ok := counts[entry.Segment]
+		if !ok {
+			continue
+		}
+		if scores, err := validateJudgeScores(entry.Scores, count); err == nil {
+			result[entry.Segment] = scores
+		}
+	}
+	return result, nil
+}
+
+// unmarshalJudgeReply 解析评审回复中的 JSON 对象
+func unmarshalJudgeReply(response string, reply interface{}) error {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("judge response is not JSON: %q", response)
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), reply); err != nil {
+		return fmt.Errorf("invalid judge response: %w", err)
+	}
+	return nil
+}
+
+// parseJudgeScores 解析评审的 JSON 回复，每个候选都必须有 1-10 的评分
+func parseJudgeScores(response string, count int) ([]JudgeScore, error) {
+	var reply struct {
+		Scores []JudgeScore `json:"scores"`
+	}
+	if err := unmarshalJudgeReply(response, &reply); err != nil {
+		return nil, err
+	}
+	return validateJudgeScores(reply.Scores, count)
+}
+
+// validateJudgeScores 按候选标记整理评分，每个候选都必须有 1-10 的评分
+func validateJudgeScores(replyScores []JudgeScore, count int) ([]JudgeScore, error) {
+	scores := make([]JudgeScore, count)
+	found := make([]bool, count)
+	for _, s := range replyScores {
+		label := strings.ToUpper(strings.TrimSpace(s.Candidate))
+		if len(label) != 1 {
+			continue
+		}
+		i := int(label[0] - 'A')
+		if i < 0 || i >= count {
+			continue
+		}
+		if s.Score < 1 || s.Score > 10 {
+			return nil, fmt.Errorf("judge score %v for candidate %s is out of range", s.Score, label)
+		}
+		s.Candidate = label
+		scores[i] = s
+		found[i] = true
+	}
+	for i, ok := range found {
+		if !ok {
+			return nil, fmt.Errorf("judge response has no score for candidate %s", candidateLabel(i))
+		}
+	}
+	return scores, nil
+}
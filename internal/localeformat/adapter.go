@@ -0,0 +1,698 @@
+package localeformat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Options 本地化改写选项
+type Options struct {
+	SourceLocale    string
+	TargetLocale    string
+	ConvertUnits    bool // 在公制和英制之间换算计量单位
+	FullWidthDigits bool // 中日韩目标语言使用全角数字
+}
+
+// Adapter 把译文中从原文照搬的数字、日期、时间、计量单位和货币金额改写为目标区域设置的格式。
+// 只改写能在原文中找到的写法，代码、链接、标记和占位符保持原样，重复改写结果不变
+type Adapter struct {
+	opts   Options
+	source Conventions
+	target Conventions
+
+	numberPattern      *regexp.Regexp // 原文中带分组或小数的数字
+	currencyPattern    *regexp.Regexp // 原文中的货币金额
+	datePattern        *regexp.Regexp // 原文中的数字日期
+	time12Pattern      *regexp.Regexp // 原文中的 12 小时制时间
+	time24Pattern      *regexp.Regexp // 原文中的 24 小时制时间
+	measurementPattern *regexp.Regexp // 原文中需要换算的计量单位
+	conversions        map[string]*unitConversion
+}
+
+// entityKind 原文中识别出的内容类型
+type entityKind int
+
+const (
+	kindNumber entityKind = iota
+	kindCurrency
+	kindDate
+	kindTime12
+	kindTime24
+	kindMeasurement
+)
+
+// entity 原文中识别出的一处内容
+type entity struct {
+	kind     entityKind
+	raw      string // 原文中的写法
+	pattern  string // 在译文中查找的正则
+	rendered string // 目标区域设置的写法
+}
+
+const (
+	// amountSpace 数字与货币符号、单位之间可以出现的空格
+	amountSpace = `[ \x{00a0}\x{202f}]?`
+	// currencySymbols 识别的货币符号和代码
+	currencySymbols = `US\$|R\$|CHF|USD|EUR|GBP|JPY|CNY|\$|€|£|¥|￥|₹|₩|₽|₺`
+)
+
+// meridiemPattern 紧跟在时间后面的 AM/PM
+var meridiemPattern = regexp.MustCompile(`^[ \x{00a0}]?[AaPp]\.?[Mm]\b`)
+
+// protectedPattern 不改写的内容：代码块、行内代码、标签、链接、保护标记、公式和占位符
+var protectedPattern = regexp.MustCompile("```[\\s\\S]*?```|`[^`\\n]*`|<[^>]+>|https?://[^\\s)>\\]]+|@@[A-Z_]+\\d*@@|\\$\\$[\\s\\S]*?\\$\\$|\\\\\\(.*?\\\\\\)|\\{\\{.*?\\}\\}|\\{[A-Za-z_][\\w.]*\\}|%[-+#0]*\\d*(?:\\.\\d+)?[sdfvqx]|\\$[^$\\s][^$\\n]*?\\$")
+
+// NewAdapter 创建本地化改写器，源或目标区域设置不受支持时返回错误
+func NewAdapter(opts Options) (*Adapter, error) {
+	source, ok := ForLocale(opts.SourceLocale)
+	if !ok {
+		return nil, fmt.Errorf("unsupported source locale %q", opts.SourceLocale)
+	}
+	target, ok := ForLocale(opts.TargetLocale)
+	if !ok {
+		return nil, fmt.Errorf("unsupported target locale %q", opts.TargetLocale)
+	}
+
+	group := regexp.QuoteMeta(source.GroupSeparator)
+	switch source.GroupSeparator {
+	case nbsp, narrowNbsp:
+		group = `[ \x{00a0}\x{202f}]`
+	case "’":
+		group = `['’]`
+	}
+	decimal := regexp.QuoteMeta(source.DecimalSeparator)
+	grouped := `\d{1,3}(?:` + group + `\d{3})+(?:` + decimal + `\d+)?`
+	fractional := `\d+` + decimal + `\d+`
+	amount := `(?:` + grouped + `|` + fractional + `|\d+)`
+
+	a := &Adapter{
+		opts:            opts,
+		source:          source,
+		target:          target,
+		numberPattern:   regexp.MustCompile(grouped + `|` + fractional),
+		currencyPattern: regexp.MustCompile(`(` + currencySymbols + `)` + amountSpace + `(` + amount + `)|(` + amount + `)` + amountSpace + `(` + currencySymbols + `)`),
+		datePattern:     regexp.MustCompile(`(\d{1,4})([./-])(\d{1,2})([./-])(\d{1,4})`),
+		time12Pattern:   regexp.MustCompile(`(\d{1,2})(?::([0-5]\d))?[ \x{00a0}]?(?:([AaPp])[Mm]|([AaPp])\.[Mm]\.)`),
+		time24Pattern:   regexp.MustCompile(`([01]?\d|2[0-3]):([0-5]\d)`),
+	}
+
+	if opts.ConvertUnits {
+		table := imperialToMetric
+		if target.Imperial {
+			table = metricToImperial
+		}
+		a.conversions = make(map[string]*unitConversion)
+		var names []string
+		for i := range table {
+			for _, name := range table[i].names {
+				a.conversions[strings.ToLower(name)] = &table[i]
+				names = append(names, name)
+			}
+		}
+		a.measurementPattern = regexp.MustCompile(`([-−]?)(` + amount + `)` + amountSpace + `(?i:(` + alternation(names) + `))`)
+	}
+	return a, nil
+}
+
+// Adapt 按原文中识别出的内容改写译文
+func (a *Adapter) Adapt(source, translation string) string {
+	if a == nil || strings.TrimSpace(translation) == "" {
+		return translation
+	}
+
+	var entities []*entity
+	for _, gap := range unprotectedGaps(source) {
+		entities = append(entities, a.detect(toHalfWidth(gap))...)
+	}
+	replacer := newEntityReplacer(entities)
+
+	fullWidth := a.opts.FullWidthDigits && a.target.CJK
+	var out strings.Builder
+	last := 0
+	for _, span := range protectedSpans(translation) {
+		out.WriteString(a.adaptGap(translation[last:span[0]], replacer, fullWidth))
+		out.WriteString(translation[span[0]:span[1]])
+		last = span[1]
+	}
+	out.WriteString(a.adaptGap(translation[last:], replacer, fullWidth))
+	return out.String()
+}
+
+// adaptGap 改写译文中不受保护的一段
+func (a *Adapter) adaptGap(text string, replacer *entityReplacer, fullWidth bool) string {
+	if text == "" {
+		return text
+	}
+	text = replacer.replace(toHalfWidth(text))
+	if fullWidth {
+		text = toFullWidth(text)
+	}
+	return text
+}
+
+// detect 识别原文中需要改写的内容
+func (a *Adapter) detect(text string) []*entity {
+	var entities []*entity
+	var claimed [][2]int
+	add := func(e *entity, start, end int, claim bool) {
+		if e == nil || e.rendered == e.raw {
+			return
+		}
+		entities = append(entities, e)
+		if claim {
+			claimed = append(claimed, [2]int{start, end})
+		}
+	}
+	overlaps := func(start, end int) bool {
+		for _, span := range claimed {
+			if start < span[1] && end > span[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 日期和时间中的数字不作为普通数字改写
+	for _, m := range findAll(a.datePattern, text, kindDate) {
+		add(a.dateEntity(text, m), m[0], m[1], true)
+	}
+	if a.target.TimeFormat == time24 {
+		for _, m := range findAll(a.time12Pattern, text, kindTime12) {
+			add(a.time12Entity(text, m), m[0], m[1], true)
+		}
+	}
+	if a.source.TimeFormat == time24 && a.target.TimeFormat == time12 {
+		for _, m := range findAll(a.time24Pattern, text, kindTime24) {
+			if !overlaps(m[0], m[1]) {
+				add(a.time24Entity(text, m), m[0], m[1], true)
+			}
+		}
+	}
+	for _, m := range findAll(a.currencyPattern, text, kindCurrency) {
+		if !overlaps(m[0], m[1]) {
+			add(a.currencyEntity(text, m), m[0], m[1], false)
+		}
+	}
+	if a.measurementPattern != nil {
+		for _, m := range findAll(a.measurementPattern, text, kindMeasurement) {
+			if !overlaps(m[0], m[1]) {
+				add(a.measurementEntity(text, m), m[0], m[1], false)
+			}
+		}
+	}
+	for _, m := range findAll(a.numberPattern, text, kindNumber) {
+		if !overlaps(m[0], m[1]) {
+			raw := text[m[0]:m[1]]
+			add(&entity{kind: kindNumber, raw: raw, pattern: regexp.QuoteMeta(raw), rendered: a.formatAmount(raw)}, m[0], m[1], false)
+		}
+	}
+	return entities
+}
+
+// dateEntity 按源区域设置的日期顺序解析数字日期，ISO 8601 日期不改写
+func (a *Adapter) dateEntity(text string, m []int) *entity {
+	if text[m[4]:m[5]] != text[m[8]:m[9]] || text[m[4]:m[5]] != dateSeparator(a.source.DatePattern) {
+		return nil
+	}
+	parts := []string{text[m[2]:m[3]], text[m[6]:m[7]], text[m[10]:m[11]]}
+	if len(parts[0]) == 4 && text[m[4]:m[5]] == "-" {
+		return nil
+	}
+
+	var year, month, day string
+	for i, field := range dateOrder(a.source.DatePattern) {
+		switch field {
+		case 'y':
+			year = parts[i]
+		case 'M':
+			month = parts[i]
+		case 'd':
+			day = parts[i]
+		}
+	}
+	if len(year) != 4 || len(month) > 2 || len(day) > 2 {
+		return nil
+	}
+	y, _ := strconv.Atoi(year)
+	mo, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if mo < 1 || mo > 12 || d < 1 || d > 31 {
+		return nil
+	}
+
+	raw := text[m[0]:m[1]]
+	return &entity{kind: kindDate, raw: raw, pattern: regexp.QuoteMeta(raw), rendered: formatDate(a.target.DatePattern, y, mo, d)}
+}
+
+// time12Entity 把 12 小时制时间改写为 24 小时制
+func (a *Adapter) time12Entity(text string, m []int) *entity {
+	hour, _ := strconv.Atoi(text[m[2]:m[3]])
+	if hour < 1 || hour > 12 {
+		return nil
+	}
+	minute := 0
+	if m[4] >= 0 {
+		minute, _ = strconv.Atoi(text[m[4]:m[5]])
+	}
+	meridiem := ""
+	if m[6] >= 0 {
+		meridiem = text[m[6]:m[7]]
+	} else {
+		meridiem = text[m[8]:m[9]]
+	}
+	hour %= 12
+	if strings.EqualFold(meridiem, "p") {
+		hour += 12
+	}
+
+	raw := text[m[0]:m[1]]
+	return &entity{kind: kindTime12, raw: raw, pattern: regexp.QuoteMeta(raw), rendered: formatTime(a.target.TimePattern, hour, minute)}
+}
+
+// time24Entity 把 24 小时制时间改写为 12 小时制
+func (a *Adapter) time24Entity(text string, m []int) *entity {
+	hour, _ := strconv.Atoi(text[m[2]:m[3]])
+	minute, _ := strconv.Atoi(text[m[4]:m[5]])
+	raw := text[m[0]:m[1]]
+	return &entity{kind: kindTime24, raw: raw, pattern: regexp.QuoteMeta(raw), rendered: formatTime(a.target.TimePattern, hour, minute)}
+}
+
+// currencyEntity 按目标区域设置放置货币符号
+func (a *Adapter) currencyEntity(text string, m []int) *entity {
+	var symbol, amount string
+	if m[2] >= 0 {
+		symbol, amount = text[m[2]:m[3]], text[m[4]:m[5]]
+	} else {
+		amount, symbol = text[m[6]:m[7]], text[m[8]:m[9]]
+	}
+
+	space := a.target.CurrencySpace
+	if space == "" && isASCIILetter(rune(symbol[len(symbol)-1])) {
+		space = nbsp
+	}
+	rendered := symbol + space + a.formatAmount(amount)
+	if a.target.CurrencyAfter {
+		rendered = a.formatAmount(amount) + space + symbol
+	}
+
+	raw := text[m[0]:m[1]]
+	return &entity{kind: kindCurrency, raw: raw, pattern: regexp.QuoteMeta(raw), rendered: rendered}
+}
+
+// measurementEntity 换算计量单位。译文中的数字可以是原文写法或目标区域设置的写法，
+// 单位可以是原文写法或常见语言的译名
+func (a *Adapter) measurementEntity(text string, m []int) *entity {
+	conversion := a.conversions[strings.ToLower(text[m[6]:m[7]])]
+	if conversion == nil {
+		return nil
+	}
+	sign, amount := text[m[2]:m[3]], text[m[4]:m[5]]
+	intPart, frac := splitAmount(amount, a.source)
+	value, err := strconv.ParseFloat(intPart+"."+frac+"0", 64)
+	if err != nil {
+		return nil
+	}
+	if sign != "" {
+		value = -value
+	}
+
+	decimals := len(frac)
+	if !conversion.temperature && decimals == 0 {
+		decimals = 1
+	}
+	converted := strconv.FormatFloat(conversion.convert(value), 'f', decimals, 64)
+	negative := strings.HasPrefix(converted, "-")
+	intPart, frac, _ = strings.Cut(strings.TrimPrefix(converted, "-"), ".")
+	frac = strings.TrimRight(frac, "0")
+	rendered := formatDigits(intPart, frac, a.target)
+	if negative && strings.Trim(rendered, "0.,") != "" {
+		rendered = "-" + rendered
+	}
+	if !conversion.temperature {
+		rendered += " "
+	}
+	rendered += conversion.symbol
+
+	amounts := alternation([]string{sign + amount, sign + a.formatAmount(amount)})
+	units := alternation(append(append([]string{}, conversion.names...), conversion.aliases...))
+	return &entity{
+		kind:     kindMeasurement,
+		raw:      text[m[0]:m[1]],
+		pattern:  `(?:` + amounts + `)` + amountSpace + `(?i:` + units + `)`,
+		rendered: rendered,
+	}
+}
+
+// formatAmount 把源区域设置写法的数字改写为目标区域设置的写法
+func (a *Adapter) formatAmount(amount string) string {
+	intPart, frac := splitAmount(amount, a.source)
+	return formatDigits(intPart, frac, a.target)
+}
+
+// splitAmount 去掉分组分隔符，拆出整数和小数部分
+func splitAmount(amount string, c Conventions) (string, string) {
+	var intPart, frac strings.Builder
+	current := &intPart
+	for _, r := range amount {
+		switch {
+		case r >= '0' && r <= '9':
+			current.WriteRune(r)
+		case string(r) == c.DecimalSeparator:
+			current = &frac
+		}
+	}
+	return intPart.String(), frac.String()
+}
+
+// formatDigits 按区域设置的分组和小数分隔符书写数字
+func formatDigits(intPart, frac string, c Conventions) string {
+	minGrouping := c.MinGroupingDigits
+	if minGrouping < 1 {
+		minGrouping = 1
+	}
+	var out strings.Builder
+	if len(intPart) >= 3+minGrouping {
+		lead := len(intPart) % 3
+		if lead == 0 {
+			lead = 3
+		}
+		out.WriteString(intPart[:lead])
+		for i := lead; i < len(intPart); i += 3 {
+			out.WriteString(c.GroupSeparator)
+			out.WriteString(intPart[i : i+3])
+		}
+	} else {
+		out.WriteString(intPart)
+	}
+	if frac != "" {
+		out.WriteString(c.DecimalSeparator)
+		out.WriteString(frac)
+	}
+	return out.String()
+}
+
+// dateOrder 返回日期格式中年、月、日的顺序
+func dateOrder(pattern string) []byte {
+	var order []byte
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if (c == 'y' || c == 'M' || c == 'd') && (len(order) == 0 || order[len(order)-1] != c) {
+			order = append(order, c)
+		}
+	}
+	return order
+}
+
+// dateSeparator 返回日期格式中的分隔符
+func dateSeparator(pattern string) string {
+	for _, r := range pattern {
+		if r != 'y' && r != 'M' && r != 'd' {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+// formatDate 按日期格式书写日期
+func formatDate(pattern string, year, month, day int) string {
+	return expandPattern(pattern, func(field byte, width int) string {
+		switch field {
+		case 'y':
+			return strconv.Itoa(year)
+		case 'M':
+			return fmt.Sprintf("%0*d", width, month)
+		case 'd':
+			return fmt.Sprintf("%0*d", width, day)
+		}
+		return ""
+	})
+}
+
+// formatTime 按时间格式书写时间
+func formatTime(pattern string, hour, minute int) string {
+	return expandPattern(pattern, func(field byte, width int) string {
+		switch field {
+		case 'H':
+			return fmt.Sprintf("%0*d", width, hour)
+		case 'h':
+			h := hour % 12
+			if h == 0 {
+				h = 12
+			}
+			return fmt.Sprintf("%0*d", width, h)
+		case 'm':
+			return fmt.Sprintf("%02d", minute)
+		case 'a':
+			if hour < 12 {
+				return "AM"
+			}
+			return "PM"
+		}
+		return ""
+	})
+}
+
+// expandPattern 展开 CLDR 风格的格式，连续相同的字段字母为一个字段
+func expandPattern(pattern string, field func(field byte, width int) string) string {
+	var out strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if !strings.ContainsRune("yMdHhma", rune(c)) {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+		width := 1
+		for i+width < len(pattern) && pattern[i+width] == c {
+			width++
+		}
+		out.WriteString(field(c, width))
+		i += width
+	}
+	return out.String()
+}
+
+// entityReplacer 在译文中查找原文内容并替换为目标区域设置的写法
+type entityReplacer struct {
+	entities []*entity
+	pattern  *regexp.Regexp
+}
+
+// newEntityReplacer 按原文写法从长到短组合查找正则，较长的内容优先匹配
+func newEntityReplacer(entities []*entity) *entityReplacer {
+	seen := make(map[string]bool)
+	var unique []*entity
+	for _, e := range entities {
+		if !seen[e.pattern] {
+			seen[e.pattern] = true
+			unique = append(unique, e)
+		}
+	}
+	if len(unique) == 0 {
+		return &entityReplacer{}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return len(unique[i].raw) > len(unique[j].raw)
+	})
+
+	groups := make([]string, len(unique))
+	for i, e := range unique {
+		groups[i] = "(" + e.pattern + ")"
+	}
+	return &entityReplacer{entities: unique, pattern: regexp.MustCompile(strings.Join(groups, "|"))}
+}
+
+// replace 替换一段译文中的原文内容
+func (r *entityReplacer) replace(text string) string {
+	if r.pattern == nil {
+		return text
+	}
+	var out strings.Builder
+	pos := 0
+	for pos < len(text) {
+		m := r.pattern.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+		start, end := pos+m[0], pos+m[1]
+		var matched *entity
+		for i, e := range r.entities {
+			if m[2+2*i] >= 0 {
+				matched = e
+				break
+			}
+		}
+		if !atBoundary(text, start, end, matched.kind) {
+			_, size := utf8.DecodeRuneInString(text[start:])
+			out.WriteString(text[pos : start+size])
+			pos = start + size
+			continue
+		}
+		out.WriteString(text[pos:start])
+		out.WriteString(matched.rendered)
+		pos = end
+	}
+	out.WriteString(text[pos:])
+	return out.String()
+}
+
+// findAll 查找原文中完整的匹配，不完整的匹配（如版本号的一部分）向后移动一个字符重试
+func findAll(pattern *regexp.Regexp, text string, kind entityKind) [][]int {
+	var matches [][]int
+	pos := 0
+	for pos < len(text) {
+		m := pattern.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+		for i := range m {
+			if m[i] >= 0 {
+				m[i] += pos
+			}
+		}
+		if atBoundary(text, m[0], m[1], kind) {
+			matches = append(matches, m)
+			pos = m[1]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[m[0]:])
+		pos = m[0] + size
+	}
+	return matches
+}
+
+// separatorRunes 数字之间的分隔符
+const separatorRunes = ".,:/'’"
+
+// atBoundary 检查匹配前后没有紧挨着的数字、字母或数字分隔符，避免改写版本号、IP 地址、标识符等
+func atBoundary(text string, start, end int, kind entityKind) bool {
+	if start > 0 {
+		prev, size := utf8.DecodeLastRuneInString(text[:start])
+		if isDigit(prev) || isASCIILetter(prev) {
+			return false
+		}
+		if strings.ContainsRune(separatorRunes, prev) || prev == '-' {
+			if before, _ := utf8.DecodeLastRuneInString(text[:start-size]); isDigit(before) {
+				return false
+			}
+		}
+	}
+	if end < len(text) {
+		next, size := utf8.DecodeRuneInString(text[end:])
+		last, _ := utf8.DecodeLastRuneInString(text[start:end])
+		if isDigit(next) || isASCIILetter(next) && (isDigit(last) || isASCIILetter(last)) {
+			return false
+		}
+		if strings.ContainsRune(separatorRunes, next) || next == '-' {
+			if after, _ := utf8.DecodeRuneInString(text[end+size:]); isDigit(after) {
+				return false
+			}
+		}
+		if kind == kindTime24 && meridiemPattern.MatchString(text[end:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// protectedSpans 返回不改写的内容的位置。行内公式按 Pandoc 的规则识别，
+// 避免把两个美元金额之间的文字当作公式
+func protectedSpans(text string) [][2]int {
+	var spans [][2]int
+	pos := 0
+	for pos < len(text) {
+		loc := protectedPattern.FindStringIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		match := text[start:end]
+		if strings.HasPrefix(match, "$") && !strings.HasPrefix(match, "$$") {
+			closing, _ := utf8.DecodeLastRuneInString(match[:len(match)-1])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			if closing == ' ' || closing == '\t' || isDigit(after) {
+				pos = start + 1
+				continue
+			}
+		}
+		spans = append(spans, [2]int{start, end})
+		pos = end
+	}
+	return spans
+}
+
+// unprotectedGaps 返回不受保护的文本片段
+func unprotectedGaps(text string) []string {
+	var gaps []string
+	last := 0
+	for _, span := range protectedSpans(text) {
+		gaps = append(gaps, text[last:span[0]])
+		last = span[1]
+	}
+	return append(gaps, text[last:])
+}
+
+// toHalfWidth 把全角数字以及数字之间的全角小数点、逗号和冒号改为半角
+func toHalfWidth(text string) string {
+	if !strings.ContainsAny(text, "０１２３４５６７８９") {
+		return text
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if r >= '０' && r <= '９' {
+			runes[i] = r - '０' + '0'
+		}
+	}
+	for i := 1; i+1 < len(runes); i++ {
+		if isDigit(runes[i-1]) && isDigit(runes[i+1]) {
+			switch runes[i] {
+			case '．':
+				runes[i] = '.'
+			case '，':
+				runes[i] = ','
+			case '：':
+				runes[i] = ':'
+			}
+		}
+	}
+	return string(runes)
+}
+
+// toFullWidth 把半角数字改为全角
+func toFullWidth(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isDigit(r) {
+			return r - '0' + '０'
+		}
+		return r
+	}, text)
+}
+
+// alternation 把写法组合为正则分支，较长的写法在前
+func alternation(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	seen := make(map[string]bool)
+	quoted := make([]string, 0, len(sorted))
+	for _, value := range sorted {
+		if !seen[value] {
+			seen[value] = true
+			quoted = append(quoted, regexp.QuoteMeta(value))
+		}
+	}
+	return strings.Join(quoted, "|")
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
@@ -0,0 +1,133 @@
+package localeformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapt(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		source      string
+		translation string
+		expected    string
+	}{
+		{
+			name:        "numbers to German",
+			opts:        Options{SourceLocale: "en-US", TargetLocale: "de-DE"},
+			source:      "The file has 1,234,567 rows and a mean of 3.75.",
+			translation: "Die Datei hat 1,234,567 Zeilen und einen Mittelwert von 3.75.",
+			expected:    "Die Datei hat 1.234.567 Zeilen und einen Mittelwert von 3,75.",
+		},
+		{
+			name:        "versions, addresses and identifiers are left alone",
+			opts:        Options{SourceLocale: "en", TargetLocale: "fr"},
+			source:      "Upgrade to v3.5 or 1.2.3 on 10.0.0.1, build x2.5.",
+			translation: "Passez à v3.5 ou 1.2.3 sur 10.0.0.1, build x2.5.",
+			expected:    "Passez à v3.5 ou 1.2.3 sur 10.0.0.1, build x2.5.",
+		},
+		{
+			name:        "Spanish does not group four digits",
+			opts:        Options{SourceLocale: "en", TargetLocale: "es"},
+			source:      "It costs 1,500.50 or 12,000.",
+			translation: "Cuesta 1,500.50 o 12,000.",
+			expected:    "Cuesta 1500,50 o 12.000.",
+		},
+		{
+			name:        "dates and times",
+			opts:        Options{SourceLocale: "en-US", TargetLocale: "de"},
+			source:      "The launch is on 3/5/2024 at 3:30 PM, not 2024-03-05.",
+			translation: "Der Start ist am 3/5/2024 um 3:30 PM, nicht 2024-03-05.",
+			expected:    "Der Start ist am 05.03.2024 um 15:30, nicht 2024-03-05.",
+		},
+		{
+			name:        "24-hour times to American English",
+			opts:        Options{SourceLocale: "de", TargetLocale: "en"},
+			source:      "Beginn am 05.03.2024 um 09:15, Ende 17:45 Uhr.",
+			translation: "Starts on 05.03.2024 at 09:15, ends at 17:45.",
+			expected:    "Starts on 3/5/2024 at 9:15 AM, ends at 5:45 PM.",
+		},
+		{
+			name:        "currency placement",
+			opts:        Options{SourceLocale: "en", TargetLocale: "fr"},
+			source:      "The plan costs $1,299.99 per year or €120.",
+			translation: "L'abonnement coûte $1,299.99 par an ou €120.",
+			expected:    "L'abonnement coûte 1 299,99 $ par an ou 120 €.",
+		},
+		{
+			name:        "imperial to metric with translated unit names",
+			opts:        Options{SourceLocale: "en-US", TargetLocale: "zh-CN", ConvertUnits: true},
+			source:      "Run 26.2 miles at 72°F, carrying 5 lb and 6 ft of rope.",
+			translation: "在 72°F 下跑 26.2 英里，带上 5 磅和 6 ft 的绳子。",
+			expected:    "在 22°C 下跑 42.2 km，带上 2.3 kg和 1.8 m 的绳子。",
+		},
+		{
+			name:        "metric to imperial",
+			opts:        Options{SourceLocale: "de", TargetLocale: "en-US", ConvertUnits: true},
+			source:      "Die Strecke ist 10 km lang, bei -5 °C.",
+			translation: "The route is 10 km long, at -5 °C.",
+			expected:    "The route is 6.2 mi long, at 23°F.",
+		},
+		{
+			name:        "units are kept without conversion",
+			opts:        Options{SourceLocale: "en-US", TargetLocale: "de"},
+			source:      "Drive 1,200.5 miles.",
+			translation: "Fahren Sie 1,200.5 Meilen.",
+			expected:    "Fahren Sie 1.200,5 Meilen.",
+		},
+		{
+			name:        "code, links, markers and placeholders are exempt",
+			opts:        Options{SourceLocale: "en", TargetLocale: "de"},
+			source:      "Set `limit=1,000` (see https://x.io/a?n=1,000) to 1,000 for {count} and @@PRESERVE_1@@ with $x = 1,000$.",
+			translation: "Setzen Sie `limit=1,000` (siehe https://x.io/a?n=1,000) auf 1,000 für {count} und @@PRESERVE_1@@ mit $x = 1,000$.",
+			expected:    "Setzen Sie `limit=1,000` (siehe https://x.io/a?n=1,000) auf 1.000 für {count} und @@PRESERVE_1@@ mit $x = 1,000$.",
+		},
+		{
+			name:        "full-width digits are normalised for CJK",
+			opts:        Options{SourceLocale: "ja", TargetLocale: "zh-CN"},
+			source:      "価格は１，２００円です。",
+			translation: "价格为１，２００日元。",
+			expected:    "价格为1,200日元。",
+		},
+		{
+			name:        "full-width digits on request",
+			opts:        Options{SourceLocale: "en", TargetLocale: "ja", FullWidthDigits: true},
+			source:      "Released 3/5/2024.",
+			translation: "3/5/2024 にリリース。",
+			expected:    "２０２４/０３/０５ にリリース。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, err := NewAdapter(tt.opts)
+			require.NoError(t, err)
+			got := adapter.Adapt(tt.source, tt.translation)
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, got, adapter.Adapt(tt.source, got), "adapting twice must not change the result")
+		})
+	}
+}
+
+func TestForLocale(t *testing.T) {
+	c, ok := ForLocale("pt_br")
+	require.True(t, ok)
+	assert.Equal(t, "pt-BR", c.Locale)
+	assert.Equal(t, ",", c.DecimalSeparator)
+
+	c, ok = ForLocale("zh-Hant-TW")
+	require.True(t, ok)
+	assert.Empty(t, c.TimeFormat)
+
+	_, ok = ForLocale("xx")
+	assert.False(t, ok)
+
+	assert.Equal(t, "en-GB", ResolveLocale("English", "United Kingdom"))
+	assert.Equal(t, "zh-CN", ResolveLocale("Chinese", "Taiwan"))
+
+	_, err := NewAdapter(Options{SourceLocale: "en", TargetLocale: "xx"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Package localeformat 按目标区域设置的 CLDR 约定改写译文中的数字、日期、时间、
+// 计量单位和货币金额。改写是确定性的，不经过模型
+package localeformat
+
+import (
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+)
+
+// 时间格式
+const (
+	time24 = "24" // 24 小时制
+	time12 = "12" // 12 小时制，使用 AM/PM
+)
+
+// Conventions 一个区域设置的书写约定，取自 CLDR 的数字符号和短日期、短时间格式
+type Conventions struct {
+	Locale           string
+	DecimalSeparator string
+	GroupSeparator   string
+	// MinGroupingDigits 整数部分分组所需的最少位数减 3（CLDR minimumGroupingDigits），
+	// 为 2 时四位数不分组
+	MinGroupingDigits int
+	// DatePattern 短日期格式，由 y、M、MM、d、dd 和分隔符组成
+	DatePattern string
+	// TimeFormat 时钟类型：24、12 或空（保持原样）
+	TimeFormat string
+	// TimePattern 时间格式，由 H、HH、h、mm 和 a 组成
+	TimePattern string
+	// Imperial 是否使用英制单位
+	Imperial bool
+	// CurrencyAfter 货币符号在金额之后
+	CurrencyAfter bool
+	// CurrencySpace 货币符号与金额之间的空格，为空时紧挨着
+	CurrencySpace string
+	// CJK 中日韩文字，数字使用半角
+	CJK bool
+}
+
+// 不换行空格
+const (
+	nbsp       = "\u00a0"
+	narrowNbsp = "\u202f"
+)
+
+// conventions 支持的区域设置
+var conventions = map[string]Conventions{
+	"en":    {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "M/d/y", TimeFormat: time12, TimePattern: "h:mm a", Imperial: true},
+	"en-GB": {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "dd/MM/y", TimeFormat: time24, TimePattern: "HH:mm"},
+	"en-AU": {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "d/M/y", TimeFormat: time12, TimePattern: "h:mm a"},
+	"en-CA": {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "y-MM-dd", TimeFormat: time12, TimePattern: "h:mm a"},
+	"de":    {DecimalSeparator: ",", GroupSeparator: ".", DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"de-CH": {DecimalSeparator: ".", GroupSeparator: "’", DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "HH:mm", CurrencySpace: nbsp},
+	"fr":    {DecimalSeparator: ",", GroupSeparator: narrowNbsp, DatePattern: "dd/MM/y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"fr-CA": {DecimalSeparator: ",", GroupSeparator: nbsp, DatePattern: "y-MM-dd", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"es":    {DecimalSeparator: ",", GroupSeparator: ".", MinGroupingDigits: 2, DatePattern: "d/M/y", TimeFormat: time24, TimePattern: "H:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"it":    {DecimalSeparator: ",", GroupSeparator: ".", DatePattern: "dd/MM/y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"pt":    {DecimalSeparator: ",", GroupSeparator: ".", DatePattern: "dd/MM/y", TimeFormat: time24, TimePattern: "HH:mm", CurrencySpace: nbsp},
+	"pt-PT": {DecimalSeparator: ",", GroupSeparator: nbsp, MinGroupingDigits: 2, DatePattern: "dd/MM/y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"nl":    {DecimalSeparator: ",", GroupSeparator: ".", DatePattern: "dd-MM-y", TimeFormat: time24, TimePattern: "HH:mm", CurrencySpace: nbsp},
+	"ru":    {DecimalSeparator: ",", GroupSeparator: nbsp, DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"uk":    {DecimalSeparator: ",", GroupSeparator: nbsp, DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"pl":    {DecimalSeparator: ",", GroupSeparator: nbsp, MinGroupingDigits: 2, DatePattern: "d.MM.y", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"cs":    {DecimalSeparator: ",", GroupSeparator: nbsp, DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "H:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"sv":    {DecimalSeparator: ",", GroupSeparator: nbsp, DatePattern: "y-MM-dd", TimeFormat: time24, TimePattern: "HH:mm", CurrencyAfter: true, CurrencySpace: nbsp},
+	"tr":    {DecimalSeparator: ",", GroupSeparator: ".", DatePattern: "dd.MM.y", TimeFormat: time24, TimePattern: "HH:mm"},
+	"ja":    {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "y/MM/dd", TimeFormat: time24, TimePattern: "H:mm", CJK: true},
+	"zh":    {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "y/M/d", TimeFormat: time24, TimePattern: "HH:mm", CJK: true},
+	"zh-TW": {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "y/M/d", CJK: true},
+	"ko":    {DecimalSeparator: ".", GroupSeparator: ",", DatePattern: "y. M. d.", CJK: true},
+}
+
+// countryRegions 配置中的国家/地区名称对应的地区代码
+var countryRegions = map[string]string{
+	"united states":  "US",
+	"usa":            "US",
+	"united kingdom": "GB",
+	"uk":             "GB",
+	"australia":      "AU",
+	"canada":         "CA",
+	"germany":        "DE",
+	"austria":        "AT",
+	"switzerland":    "CH",
+	"france":         "FR",
+	"belgium":        "BE",
+	"spain":          "ES",
+	"mexico":         "MX",
+	"italy":          "IT",
+	"brazil":         "BR",
+	"portugal":       "PT",
+	"netherlands":    "NL",
+	"china":          "CN",
+	"taiwan":         "TW",
+	"hong kong":      "HK",
+	"japan":          "JP",
+	"korea":          "KR",
+	"south korea":    "KR",
+}
+
+// ResolveLocale 把语言名称或区域设置转换为 BCP 47 代码，没有地区时按国家/地区名称补上
+func ResolveLocale(lang, country string) string {
+	locale := document.NormalizeLocale(lang)
+	if locale == "" || strings.Contains(locale, "-") {
+		return locale
+	}
+	if region, ok := countryRegions[strings.ToLower(strings.TrimSpace(country))]; ok {
+		return locale + "-" + region
+	}
+	return locale
+}
+
+// ForLocale 返回区域设置的书写约定，先按完整代码查找，再按语言查找
+func ForLocale(locale string) (Conventions, bool) {
+	locale = document.NormalizeLocale(locale)
+	lookup := locale
+	if strings.HasPrefix(locale, "zh-Hant") || locale == "zh-HK" || locale == "zh-MO" {
+		lookup = "zh-TW"
+	}
+	c, ok := conventions[lookup]
+	if !ok {
+		base, _, _ := strings.Cut(lookup, "-")
+		c, ok = conventions[base]
+	}
+	c.Locale = locale
+	return c, ok
+}
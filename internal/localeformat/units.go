@@ -0,0 +1,132 @@
+package localeformat
+
+// unitConversion 一种计量单位到另一计量体系的换算
+type unitConversion struct {
+	names       []string // 原文中识别的写法
+	aliases     []string // 译文中可能出现的译名
+	symbol      string   // 换算后的单位符号
+	convert     func(float64) float64
+	temperature bool // 温度，单位符号紧跟数字，按原文的精度取整
+}
+
+func scale(factor float64) func(float64) float64 {
+	return func(v float64) float64 { return v * factor }
+}
+
+// imperialToMetric 英制单位换算为公制
+var imperialToMetric = []unitConversion{
+	{
+		names:   []string{"mph"},
+		aliases: []string{"英里/小时", "英里每小时", "マイル毎時"},
+		symbol:  "km/h",
+		convert: scale(1.609344),
+	},
+	{
+		names:   []string{"miles", "mile", "mi"},
+		aliases: []string{"英里", "英哩", "マイル", "마일", "Meilen", "Meile", "milles", "mille", "millas", "milla", "miglia", "miglio", "milhas", "milha", "миль", "мили", "миля", "mijl"},
+		symbol:  "km",
+		convert: scale(1.609344),
+	},
+	{
+		names:   []string{"yards", "yard", "yd"},
+		aliases: []string{"码", "碼", "ヤード", "야드", "Yards", "yardas", "iarde", "ярдов"},
+		symbol:  "m",
+		convert: scale(0.9144),
+	},
+	{
+		names:   []string{"feet", "foot", "ft"},
+		aliases: []string{"英尺", "呎", "フィート", "피트", "Fuß", "pieds", "pied", "pies", "pie", "piedi", "pés", "футов", "фута", "voet"},
+		symbol:  "m",
+		convert: scale(0.3048),
+	},
+	{
+		names:   []string{"inches", "inch"},
+		aliases: []string{"英寸", "吋", "インチ", "인치", "Zoll", "pouces", "pouce", "pulgadas", "pulgada", "pollici", "polegadas", "дюймов", "дюйма", "duim"},
+		symbol:  "cm",
+		convert: scale(2.54),
+	},
+	{
+		names:   []string{"lbs", "lb"},
+		aliases: []string{"磅", "ポンド", "파운드", "Pfund", "livres", "libras", "libbre", "фунтов", "pond"},
+		symbol:  "kg",
+		convert: scale(0.45359237),
+	},
+	{
+		names:   []string{"ounces", "ounce", "oz"},
+		aliases: []string{"盎司", "オンス", "온스", "Unzen", "onces", "onzas", "once", "унций", "ons"},
+		symbol:  "g",
+		convert: scale(28.349523125),
+	},
+	{
+		names:   []string{"gallons", "gallon", "gal"},
+		aliases: []string{"加仑", "加侖", "ガロン", "갤런", "Gallonen", "galones", "galloni", "galões", "галлонов"},
+		symbol:  "L",
+		convert: scale(3.785411784),
+	},
+	{
+		names:       []string{"°F", "℉"},
+		aliases:     []string{"华氏度", "華氏度", "°F"},
+		symbol:      "°C",
+		convert:     func(v float64) float64 { return (v - 32) * 5 / 9 },
+		temperature: true,
+	},
+}
+
+// metricToImperial 公制单位换算为英制
+var metricToImperial = []unitConversion{
+	{
+		names:   []string{"km/h", "kph"},
+		aliases: []string{"公里/小时", "公里每小时", "千米每小时", "キロメートル毎時"},
+		symbol:  "mph",
+		convert: scale(1 / 1.609344),
+	},
+	{
+		names:   []string{"kilometers", "kilometres", "kilometer", "kilometre", "km"},
+		aliases: []string{"公里", "千米", "キロメートル", "킬로미터", "Kilometer", "kilomètres", "kilómetros", "chilometri", "quilômetros", "км"},
+		symbol:  "mi",
+		convert: scale(1 / 1.609344),
+	},
+	{
+		names:   []string{"centimeters", "centimetres", "centimeter", "centimetre", "cm"},
+		aliases: []string{"厘米", "公分", "センチメートル", "センチ", "센티미터", "Zentimeter", "centimètres", "centímetros", "centimetri", "см"},
+		symbol:  "in",
+		convert: scale(1 / 2.54),
+	},
+	{
+		names:   []string{"millimeters", "millimetres", "millimeter", "millimetre", "mm"},
+		aliases: []string{"毫米", "ミリメートル", "ミリ", "밀리미터", "Millimeter", "millimètres", "milímetros", "millimetri", "мм"},
+		symbol:  "in",
+		convert: scale(1 / 25.4),
+	},
+	{
+		names:   []string{"meters", "metres", "meter", "metre", "m"},
+		aliases: []string{"米", "メートル", "미터", "Meter", "mètres", "metros", "metri", "м"},
+		symbol:  "ft",
+		convert: scale(1 / 0.3048),
+	},
+	{
+		names:   []string{"kilograms", "kilogrammes", "kilogram", "kilogramme", "kg"},
+		aliases: []string{"公斤", "千克", "キログラム", "キロ", "킬로그램", "Kilogramm", "kilogrammes", "kilogramos", "chilogrammi", "кг"},
+		symbol:  "lb",
+		convert: scale(1 / 0.45359237),
+	},
+	{
+		names:   []string{"grams", "grammes", "gram", "gramme", "g"},
+		aliases: []string{"克", "グラム", "그램", "Gramm", "grammes", "gramos", "grammi", "г"},
+		symbol:  "oz",
+		convert: scale(1 / 28.349523125),
+	},
+	{
+		names:   []string{"liters", "litres", "liter", "litre", "L"},
+		aliases: []string{"升", "公升", "リットル", "리터", "Liter", "litres", "litros", "litri", "л"},
+		symbol:  "gal",
+		convert: scale(1 / 3.785411784),
+	},
+	{
+		names:       []string{"°C", "℃"},
+		aliases:     []string{"摄氏度", "攝氏度", "°C", "℃"},
+		symbol:      "°F",
+		convert:     func(v float64) float64 { return v*9/5 + 32 },
+		temperature: true,
+	},
+}
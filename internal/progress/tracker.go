@@ -1,7 +1,10 @@
 package progress
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,6 +37,12 @@ type Session struct {
 	LastUpdateTime time.Time
 	Status         SessionStatus
 
+	// 源文件信息，恢复会话时用于重新解析并校验源文件未改变
+	SourcePath string // 用户指定的原始输入文件
+	InputPath  string // 实际翻译的输入文件（可能是预处理后的文件）
+	OutputPath string
+	InputHash  string // 输入文件内容的 SHA-256
+
 	// 统计信息
 	TotalNodes      int
 	CompletedNodes  int
@@ -67,10 +76,30 @@ type NodeProgress struct {
 	StartTime      time.Time
 	CompleteTime   time.Time
 	CharacterCount int
-	Error          error
+	Error          error `json:"-"`
+	ErrorMessage   string
 	RetryCount     int
+
+	// 翻译结果，恢复会话时已完成的节点直接使用
+	SourceHash     string            // 原文的 SHA-256，用于确认节点未改变
+	TranslatedText string            // 最终译文
+	StepOutputs    map[string]string // 翻译链各步骤对该节点的输出
+}
+
+// NodeResult 节点翻译结果
+type NodeResult struct {
+	NodeID         int
+	Status         document.NodeStatus
+	OriginalText   string
+	TranslatedText string
+	StepOutputs    map[string]string
+	RetryCount     int
+	Error          error
 }
 
+// ErrSessionNotFound 会话不存在
+var ErrSessionNotFound = errors.New("session not found")
+
 // ErrorInfo 错误信息
 type ErrorInfo struct {
 	Time    time.Time
@@ -160,6 +189,187 @@ func (t *Tracker) StopTracking(docID string) {
 	}
 }
 
+// PauseTracking 暂停跟踪，保存会话以便之后恢复（翻译被中断或出错时使用）
+func (t *Tracker) PauseTracking(docID string) {
+	t.mu.RLock()
+	session, exists := t.sessions[docID]
+	t.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	session.mu.Lock()
+	session.Status = StatusPaused
+	session.LastUpdateTime = time.Now()
+	session.mu.Unlock()
+
+	if t.backend != nil {
+		if err := t.backend.Save(session); err != nil {
+			t.logger.Warn("failed to save session",
+				zap.String("docID", docID),
+				zap.Error(err))
+		}
+	}
+
+	t.logger.Info("paused tracking", zap.String("docID", docID))
+}
+
+// PauseRunning 暂停并保存所有进行中的会话，强制退出前尽力保存进度时使用
+func (t *Tracker) PauseRunning() {
+	t.mu.RLock()
+	var running []string
+	for docID, session := range t.sessions {
+		session.mu.RLock()
+		if session.Status == StatusRunning {
+			running = append(running, docID)
+		}
+		session.mu.RUnlock()
+	}
+	t.mu.RUnlock()
+
+	for _, docID := range running {
+		t.PauseTracking(docID)
+	}
+}
+
+// ResumeTracking 从存储后端加载会话并继续跟踪
+func (t *Tracker) ResumeTracking(sessionID string) (*Session, error) {
+	if t.backend == nil {
+		return nil, fmt.Errorf("no backend configured")
+	}
+
+	session, err := t.backend.Load(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Status = StatusRunning
+	session.LastUpdateTime = time.Now()
+
+	t.mu.Lock()
+	t.sessions[sessionID] = session
+	t.mu.Unlock()
+
+	if t.autoSave {
+		go t.autoSaveSession(sessionID)
+	}
+
+	t.logger.Info("resumed tracking",
+		zap.String("docID", sessionID),
+		zap.String("fileName", session.FileName),
+		zap.Int("completedNodes", session.CompletedNodes))
+
+	return session, nil
+}
+
+// SetSessionSource 记录会话的源文件信息
+func (t *Tracker) SetSessionSource(docID, sourcePath, inputPath, outputPath, inputHash string) {
+	t.mu.RLock()
+	session, exists := t.sessions[docID]
+	t.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	session.mu.Lock()
+	session.SourcePath = sourcePath
+	session.InputPath = inputPath
+	session.OutputPath = outputPath
+	session.InputHash = inputHash
+	session.mu.Unlock()
+}
+
+// RecordNodeResult 记录节点的翻译结果，包括译文和各步骤输出
+func (t *Tracker) RecordNodeResult(docID string, result NodeResult) {
+	t.mu.RLock()
+	session, exists := t.sessions[docID]
+	t.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	nodeProgress, exists := session.NodeProgress[result.NodeID]
+	if !exists {
+		nodeProgress = &NodeProgress{
+			NodeID:    result.NodeID,
+			StartTime: time.Now(),
+		}
+		session.NodeProgress[result.NodeID] = nodeProgress
+	}
+
+	prevStatus := nodeProgress.Status
+	nodeProgress.Status = result.Status
+	nodeProgress.CharacterCount = len(result.OriginalText)
+	nodeProgress.SourceHash = HashText(result.OriginalText)
+	nodeProgress.RetryCount = result.RetryCount
+
+	switch result.Status {
+	case document.NodeStatusSuccess:
+		if prevStatus != document.NodeStatusSuccess {
+			session.CompletedNodes++
+			session.ProcessedChars += nodeProgress.CharacterCount
+		}
+		if prevStatus == document.NodeStatusFailed && session.FailedNodes > 0 {
+			session.FailedNodes--
+		}
+		nodeProgress.TranslatedText = result.TranslatedText
+		if result.StepOutputs != nil {
+			nodeProgress.StepOutputs = result.StepOutputs
+		}
+		nodeProgress.Error = nil
+		nodeProgress.ErrorMessage = ""
+		nodeProgress.CompleteTime = time.Now()
+
+	case document.NodeStatusFailed:
+		if prevStatus != document.NodeStatusFailed {
+			session.FailedNodes++
+		}
+		if prevStatus == document.NodeStatusSuccess && session.CompletedNodes > 0 {
+			session.CompletedNodes--
+			session.ProcessedChars -= nodeProgress.CharacterCount
+		}
+		nodeProgress.TranslatedText = ""
+		nodeProgress.Error = result.Error
+		if result.Error != nil {
+			nodeProgress.ErrorMessage = result.Error.Error()
+			session.Errors = append(session.Errors, ErrorInfo{
+				Time:   time.Now(),
+				NodeID: result.NodeID,
+				Error:  result.Error.Error(),
+			})
+		}
+	}
+
+	session.LastUpdateTime = time.Now()
+}
+
+// GetSession 获取跟踪中的会话
+func (t *Tracker) GetSession(sessionID string) (*Session, error) {
+	t.mu.RLock()
+	session, exists := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if exists {
+		return session, nil
+	}
+	if t.backend == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return t.backend.Load(sessionID)
+}
+
+// HashText 计算文本的 SHA-256
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
 // OnChunkStart 块开始处理
 func (t *Tracker) OnChunkStart(size int) {
 	// 由于是批量处理，这里主要用于记录
@@ -278,10 +488,15 @@ func (t *Tracker) UpdateNodeProgress(docID string, nodeID int, status document.N
 		nodeProgress.RetryCount++
 
 		// 记录错误
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		nodeProgress.ErrorMessage = errMsg
 		session.Errors = append(session.Errors, ErrorInfo{
 			Time:   time.Now(),
 			NodeID: nodeID,
-			Error:  err.Error(),
+			Error:  errMsg,
 		})
 	}
 
@@ -345,7 +560,13 @@ func (t *Tracker) autoSaveSession(docID string) {
 			session, exists := t.sessions[docID]
 			t.mu.RUnlock()
 
-			if !exists || session.Status == StatusCompleted || session.Status == StatusFailed {
+			if !exists {
+				return
+			}
+			session.mu.RLock()
+			status := session.Status
+			session.mu.RUnlock()
+			if status != StatusRunning {
 				return
 			}
 
@@ -414,7 +635,7 @@ func NewFileBackend(basePath string) *FileBackend {
 	return &FileBackend{basePath: basePath}
 }
 
-// Save 保存会话
+// Save 保存会话，先写入临时文件再重命名，进程中途崩溃也不会留下损坏的会话文件
 func (fb *FileBackend) Save(session *Session) error {
 	filePath := filepath.Join(fb.basePath, session.ID+".json")
 
@@ -426,7 +647,32 @@ func (fb *FileBackend) Save(session *Session) error {
 		return err
 	}
 
-	return os.WriteFile(filePath, data, 0o644)
+	tmpFile, err := os.CreateTemp(fb.basePath, session.ID+".json.tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
 
 // Load 加载会话
@@ -42,6 +42,23 @@ func TestProgressTracker(t *testing.T) {
 		assert.Equal(t, 1, progress.CompletedChunks)
 	})
 
+	t.Run("Pause Running Sessions", func(t *testing.T) {
+		tracker := NewTracker(logger, tmpDir)
+
+		tracker.StartTracking("running-session", "/test/running.md")
+		tracker.StartTracking("stopped-session", "/test/stopped.md")
+		tracker.StopTracking("stopped-session")
+
+		// 只暂停进行中的会话，并保存以便恢复
+		tracker.PauseRunning()
+		assert.Equal(t, StatusPaused, tracker.GetProgress("running-session").Status)
+		assert.Equal(t, StatusCompleted, tracker.GetProgress("stopped-session").Status)
+
+		session, err := NewFileBackend(tmpDir).Load("running-session")
+		require.NoError(t, err)
+		assert.Equal(t, StatusPaused, session.Status)
+	})
+
 	t.Run("Stop Tracking", func(t *testing.T) {
 		tracker := NewTracker(logger, tmpDir)
 
@@ -104,6 +121,63 @@ func TestProgressTracker(t *testing.T) {
 		assert.Equal(t, docID, progress.DocID)
 		assert.Equal(t, 1, progress.CompletedChunks)
 	})
+
+	t.Run("Record and Resume Node Results", func(t *testing.T) {
+		tracker := NewTracker(logger, tmpDir)
+
+		docID := "test-resume"
+		tracker.StartDocument(docID, "/test/resume.md", 3)
+		tracker.SetSessionSource(docID, "/test/resume.md", "/tmp/resume.md", "/test/resume.zh.md", "abc123")
+
+		tracker.RecordNodeResult(docID, NodeResult{
+			NodeID:         1,
+			Status:         document.NodeStatusSuccess,
+			OriginalText:   "Hello",
+			TranslatedText: "你好",
+			StepOutputs:    map[string]string{"initial_translation": "你好"},
+		})
+		tracker.RecordNodeResult(docID, NodeResult{
+			NodeID:       2,
+			Status:       document.NodeStatusFailed,
+			OriginalText: "World",
+			Error:        fmt.Errorf("timeout"),
+		})
+
+		// 模拟中断
+		tracker.PauseTracking(docID)
+
+		newTracker := NewTracker(logger, tmpDir)
+		session, err := newTracker.ResumeTracking(docID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRunning, session.Status)
+		assert.Equal(t, "/test/resume.md", session.SourcePath)
+		assert.Equal(t, "/tmp/resume.md", session.InputPath)
+		assert.Equal(t, "abc123", session.InputHash)
+		assert.Equal(t, 1, session.CompletedNodes)
+		assert.Equal(t, 1, session.FailedNodes)
+
+		node := session.NodeProgress[1]
+		require.NotNil(t, node)
+		assert.Equal(t, "你好", node.TranslatedText)
+		assert.Equal(t, HashText("Hello"), node.SourceHash)
+		assert.Equal(t, "你好", node.StepOutputs["initial_translation"])
+		assert.Equal(t, "timeout", session.NodeProgress[2].ErrorMessage)
+
+		// 失败节点重译成功后更新统计
+		newTracker.RecordNodeResult(docID, NodeResult{
+			NodeID:         2,
+			Status:         document.NodeStatusSuccess,
+			OriginalText:   "World",
+			TranslatedText: "世界",
+		})
+		newTracker.StopTracking(docID)
+
+		progress := newTracker.GetProgress(docID)
+		require.NotNil(t, progress)
+		assert.Equal(t, 2, progress.CompletedChunks)
+		assert.Equal(t, 0, progress.FailedChunks)
+		assert.Equal(t, StatusCompleted, progress.Status)
+	})
 }
 
 func TestFileBackend(t *testing.T) {
@@ -0,0 +1,269 @@
+package site
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+)
+
+// Generator 静态站点生成器
+type Generator string
+
+const (
+	GeneratorHugo       Generator = "hugo"
+	GeneratorDocusaurus Generator = "docusaurus"
+	GeneratorMkDocs     Generator = "mkdocs"
+)
+
+// generatorConfigFiles 用于识别生成器的配置文件，按检测顺序排列
+var generatorConfigFiles = []struct {
+	generator Generator
+	files     []string
+}{
+	{GeneratorDocusaurus, []string{"docusaurus.config.js", "docusaurus.config.ts", "docusaurus.config.mjs", "docusaurus.config.cjs"}},
+	{GeneratorMkDocs, []string{"mkdocs.yml", "mkdocs.yaml"}},
+	{GeneratorHugo, []string{"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json", "config.toml", "config.yaml", "config.yml"}},
+}
+
+var (
+	// localeSuffixPattern 匹配文件名中的语言后缀，如 page.zh.md、_index.pt-br.md
+	localeSuffixPattern = regexp.MustCompile(`^[a-z]{2}(?:[-_][A-Za-z]{2,4})?$`)
+
+	// docusaurusLocalesPattern 匹配 docusaurus.config.js 中 i18n.locales 数组
+	docusaurusLocalesPattern = regexp.MustCompile(`locales\s*:\s*\[([^\]]*)\]`)
+	// mkdocsLocalePattern 匹配 mkdocs-static-i18n 插件的 locale 配置
+	mkdocsLocalePattern = regexp.MustCompile(`(?m)^\s*-?\s*locale\s*:\s*['"]?([\w-]+)`)
+	// mkdocsDocsDirPattern 匹配 mkdocs.yml 中的 docs_dir
+	mkdocsDocsDirPattern = regexp.MustCompile(`(?m)^docs_dir\s*:\s*['"]?([^'"\s#]+)`)
+	// hugoLanguagePattern 匹配 Hugo TOML 配置中的 [languages.xx] 表
+	hugoLanguagePattern = regexp.MustCompile(`(?m)^\s*\[languages\.([\w-]+)\]`)
+	// quotedPattern 匹配引号中的字符串
+	quotedPattern = regexp.MustCompile(`['"]([^'"]+)['"]`)
+)
+
+// contentExtensions 站点模式翻译的内容文件扩展名
+var contentExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdx":      true,
+	".ipynb":    true,
+}
+
+// DetectGenerator 根据站点根目录下的配置文件识别静态站点生成器
+func DetectGenerator(root string) (Generator, error) {
+	for _, candidate := range generatorConfigFiles {
+		for _, name := range candidate.files {
+			if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+				return candidate.generator, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Hugo, Docusaurus or MkDocs configuration found in %s", root)
+}
+
+// contentTree 一棵源语言内容目录及其翻译的写入位置
+type contentTree struct {
+	// dir 源内容目录，相对站点根目录
+	dir string
+	// target 目录结构布局下翻译写入的目录，为空时在源文件名中加入语言后缀
+	target string
+}
+
+// Layout 描述生成器的本地化目录结构：哪些文件是源语言内容，翻译写到哪里
+type Layout struct {
+	Root         string
+	Generator    Generator
+	SourceLocale string
+	TargetLocale string
+	trees        []contentTree
+}
+
+// NewLayout 创建站点的本地化布局，区域设置按站点使用的写法给出（见 ResolveLocale）
+func NewLayout(root string, generator Generator, sourceLocale, targetLocale string) (*Layout, error) {
+	layout := &Layout{
+		Root:         root,
+		Generator:    generator,
+		SourceLocale: sourceLocale,
+		TargetLocale: targetLocale,
+	}
+	if layout.TargetLocale == "" {
+		return nil, fmt.Errorf("target locale is required")
+	}
+
+	switch generator {
+	case GeneratorHugo:
+		layout.trees = []contentTree{layout.languageTree("content")}
+	case GeneratorMkDocs:
+		docsDir := "docs"
+		if data, err := os.ReadFile(filepath.Join(root, "mkdocs.yml")); err == nil {
+			if match := mkdocsDocsDirPattern.FindSubmatch(data); match != nil {
+				docsDir = filepath.Clean(string(match[1]))
+			}
+		}
+		layout.trees = []contentTree{layout.languageTree(docsDir)}
+	case GeneratorDocusaurus:
+		i18nDir := filepath.Join("i18n", layout.TargetLocale)
+		layout.trees = []contentTree{
+			{dir: "docs", target: filepath.Join(i18nDir, "docusaurus-plugin-content-docs", "current")},
+			{dir: "blog", target: filepath.Join(i18nDir, "docusaurus-plugin-content-blog")},
+			{dir: filepath.Join("src", "pages"), target: filepath.Join(i18nDir, "docusaurus-plugin-content-pages")},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported site generator: %s", generator)
+	}
+	return layout, nil
+}
+
+// languageTree 源语言内容放在以语言命名的子目录中（如 content/en）时使用目录结构，
+// 否则使用文件名后缀
+func (l *Layout) languageTree(dir string) contentTree {
+	if l.SourceLocale != "" {
+		sourceDir := filepath.Join(dir, l.SourceLocale)
+		if info, err := os.Stat(filepath.Join(l.Root, sourceDir)); err == nil && info.IsDir() {
+			return contentTree{dir: sourceDir, target: filepath.Join(dir, l.TargetLocale)}
+		}
+	}
+	return contentTree{dir: dir}
+}
+
+// Sources 返回需要翻译的源语言内容文件，路径相对站点根目录。
+// 文件名带其他语言后缀的文件是已有的翻译，不作为源文件
+func (l *Layout) Sources() ([]string, error) {
+	var sources []string
+	for _, tree := range l.trees {
+		root := filepath.Join(l.Root, tree.dir)
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != root && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !contentExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			if locale := fileLocale(path); tree.target == "" && locale != "" && locale != l.SourceLocale {
+				return nil
+			}
+			rel, err := filepath.Rel(l.Root, path)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", tree.dir, err)
+		}
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// TargetPath 返回源文件翻译后的路径，相对站点根目录
+func (l *Layout) TargetPath(source string) string {
+	for _, tree := range l.trees {
+		rel, err := filepath.Rel(tree.dir, source)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if tree.target != "" {
+			return filepath.Join(tree.target, rel)
+		}
+		// 源文件带源语言后缀时（如 about.en.md）替换后缀
+		ext := filepath.Ext(source)
+		base := strings.TrimSuffix(source, ext)
+		if locale := fileLocale(source); locale != "" && locale == l.SourceLocale {
+			base = strings.TrimSuffix(base, "."+locale)
+		}
+		return base + "." + l.TargetLocale + ext
+	}
+	return ""
+}
+
+// fileLocale 返回文件名中的语言后缀，没有时返回空字符串
+func fileLocale(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	index := strings.LastIndexByte(name, '.')
+	if index < 0 || !localeSuffixPattern.MatchString(name[index+1:]) {
+		return ""
+	}
+	return name[index+1:]
+}
+
+// configuredLocales 读取站点配置中声明的区域设置
+func configuredLocales(root string, generator Generator) []string {
+	var locales []string
+	for _, candidate := range generatorConfigFiles {
+		if candidate.generator != generator {
+			continue
+		}
+		for _, name := range candidate.files {
+			data, err := os.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				continue
+			}
+			switch generator {
+			case GeneratorDocusaurus:
+				if match := docusaurusLocalesPattern.FindSubmatch(data); match != nil {
+					for _, locale := range quotedPattern.FindAllSubmatch(match[1], -1) {
+						locales = append(locales, string(locale[1]))
+					}
+				}
+			case GeneratorMkDocs:
+				for _, match := range mkdocsLocalePattern.FindAllSubmatch(data, -1) {
+					locales = append(locales, string(match[1]))
+				}
+			case GeneratorHugo:
+				for _, match := range hugoLanguagePattern.FindAllSubmatch(data, -1) {
+					locales = append(locales, string(match[1]))
+				}
+			}
+		}
+	}
+	return locales
+}
+
+// ResolveLocale 把语言名称或区域设置转换为站点使用的写法：优先使用站点配置中
+// 语言相同的区域设置（如 Docusaurus 的 zh-Hans），没有配置时 Hugo 和 MkDocs
+// 使用语言代码，Docusaurus 使用完整的区域设置
+func ResolveLocale(root string, generator Generator, locale string) string {
+	if strings.TrimSpace(locale) == "" {
+		return ""
+	}
+	configured := configuredLocales(root, generator)
+	normalized := document.NormalizeLocale(locale)
+	base, _, _ := strings.Cut(normalized, "-")
+
+	// 站点配置中完全相同的区域设置优先，其次是语言相同的
+	var sameLanguage string
+	for _, candidate := range configured {
+		candidateNormalized := document.NormalizeLocale(candidate)
+		if strings.EqualFold(candidateNormalized, normalized) {
+			return candidate
+		}
+		if candidateBase, _, _ := strings.Cut(candidateNormalized, "-"); sameLanguage == "" && strings.EqualFold(candidateBase, base) {
+			sameLanguage = candidate
+		}
+	}
+	if sameLanguage != "" {
+		return sameLanguage
+	}
+
+	if generator == GeneratorDocusaurus {
+		return normalized
+	}
+	return base
+}
@@ -0,0 +1,123 @@
+package site
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// inlineLinkPattern 匹配行内链接和图片的目标 [text](dest "title")
+	inlineLinkPattern = regexp.MustCompile(`\]\(\s*(<[^>\n]*>|[^)\s]+)`)
+	// referenceLinkPattern 匹配链接引用定义 [id]: dest
+	referenceLinkPattern = regexp.MustCompile(`^[ \t]{0,3}\[[^\]]+\]:[ \t]*(<[^>\n]*>|\S+)`)
+)
+
+// linkExtensions 需要改写链接的 Markdown 文件扩展名
+var linkExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdx":      true,
+}
+
+// rewriteLinks 对 Markdown 中代码块以外的链接目标调用 rewrite，尖括号包裹的目标保留尖括号
+func rewriteLinks(content []byte, rewrite func(string) string) []byte {
+	var result bytes.Buffer
+	fence := ""
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t>")
+		switch {
+		case fence != "":
+			if bytes.HasPrefix(trimmed, []byte(fence)) {
+				fence = ""
+			}
+			result.Write(line)
+			continue
+		case bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")):
+			fence = string(trimmed[:3])
+			result.Write(line)
+			continue
+		}
+
+		for _, pattern := range []*regexp.Regexp{referenceLinkPattern, inlineLinkPattern} {
+			line = replaceSubmatch(line, pattern, func(dest string) string {
+				if strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">") {
+					return "<" + rewrite(dest[1:len(dest)-1]) + ">"
+				}
+				return rewrite(dest)
+			})
+		}
+		result.Write(line)
+	}
+	return result.Bytes()
+}
+
+// replaceSubmatch 替换 pattern 每个匹配中第一个分组的内容
+func replaceSubmatch(line []byte, pattern *regexp.Regexp, replace func(string) string) []byte {
+	matches := pattern.FindAllSubmatchIndex(line, -1)
+	if matches == nil {
+		return line
+	}
+
+	var result []byte
+	cursor := 0
+	for _, match := range matches {
+		result = append(result, line[cursor:match[2]]...)
+		result = append(result, replace(string(line[match[2]:match[3]]))...)
+		cursor = match[3]
+	}
+	return append(result, line[cursor:]...)
+}
+
+// localizeLink 把源文件中的相对链接改写为从翻译文件出发的链接：指向已翻译页面的
+// 链接改为指向本地化页面，指向其他站点文件（如图片、未翻译的页面）的链接按新位置
+// 重新计算相对路径。外部链接、绝对路径、锚点和不存在的文件保持不变
+func (t *Translator) localizeLink(dest, source, target string) string {
+	if dest == "" || strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "#") ||
+		strings.Contains(dest, ":") || strings.Contains(dest, "{{") {
+		return dest
+	}
+
+	// 分离锚点和查询参数
+	path, suffix := dest, ""
+	if index := strings.IndexAny(dest, "#?"); index >= 0 {
+		path, suffix = dest[:index], dest[index:]
+	}
+	unescaped, err := url.PathUnescape(path)
+	if err != nil || unescaped == "" {
+		return dest
+	}
+
+	linked := filepath.Join(filepath.Dir(source), filepath.FromSlash(unescaped))
+	if linked == ".." || strings.HasPrefix(linked, ".."+string(filepath.Separator)) {
+		return dest
+	}
+	if _, err := os.Stat(filepath.Join(t.layout.Root, linked)); err != nil {
+		return dest
+	}
+	if t.sources[linked] {
+		localized := t.layout.TargetPath(linked)
+		if _, err := os.Stat(filepath.Join(t.layout.Root, localized)); err == nil {
+			linked = localized
+		}
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(target), linked)
+	if err != nil {
+		return dest
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasSuffix(path, "/") && !strings.HasSuffix(rel, "/") {
+		rel += "/"
+	}
+	if strings.HasPrefix(path, "./") && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	if unescaped != path {
+		rel = (&url.URL{Path: rel}).EscapedPath()
+	}
+	return rel + suffix
+}
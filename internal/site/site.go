@@ -0,0 +1,234 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/translator"
+	"go.uber.org/zap"
+)
+
+// manifestName 记录已翻译文件源内容哈希的清单文件，位于站点根目录
+const manifestName = ".translator-site.json"
+
+// TranslateFileFunc 翻译单个文件，路径为绝对路径
+type TranslateFileFunc func(ctx context.Context, inputPath, outputPath string) error
+
+// Options 站点翻译选项
+type Options struct {
+	Force  bool // 重新翻译所有文件，包括已是最新的和手工本地化的文件
+	DryRun bool // 只列出将要翻译的文件，不实际翻译
+}
+
+// Report 站点翻译结果，路径相对站点根目录
+type Report struct {
+	Translated []string         // 本次翻译的源文件（预演模式下为将要翻译的源文件）
+	UpToDate   []string         // 译文对应当前源文件、跳过的源文件
+	Localized  []string         // 已有未经本工具生成的本地化文件、跳过的源文件
+	Failed     map[string]error // 翻译失败的源文件
+}
+
+// manifest 站点翻译清单，按译文路径记录生成译文时源文件的哈希
+type manifest struct {
+	Version int                      `json:"version"`
+	Files   map[string]manifestEntry `json:"files"`
+}
+
+// manifestEntry 一个译文的清单记录
+type manifestEntry struct {
+	Source string `json:"source"`
+	// SourceHash 为空表示译文尚未完成（如翻译被中断）
+	SourceHash string `json:"source_hash"`
+}
+
+// Translator 按生成器的本地化目录结构翻译整个站点
+type Translator struct {
+	layout    *Layout
+	translate TranslateFileFunc
+	logger    *zap.Logger
+	sources   map[string]bool
+}
+
+// NewTranslator 创建站点翻译器
+func NewTranslator(layout *Layout, translate TranslateFileFunc, logger *zap.Logger) *Translator {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Translator{
+		layout:    layout,
+		translate: translate,
+		logger:    logger,
+	}
+}
+
+// Run 翻译站点中所有源语言内容文件。译文已存在时，只有源文件在上次翻译后发生变化才重新翻译；
+// 不是由本工具生成的译文视为手工本地化的文件，保持不变。翻译完成后把译文中的站内相对链接
+// 改写为指向本地化页面
+func (t *Translator) Run(ctx context.Context, options Options) (*Report, error) {
+	sources, err := t.layout.Sources()
+	if err != nil {
+		return nil, err
+	}
+	t.sources = make(map[string]bool, len(sources))
+	for _, source := range sources {
+		t.sources[source] = true
+	}
+
+	m, err := loadManifest(t.layout.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Failed: make(map[string]error)}
+	var runErr error
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			runErr = translator.ErrTranslationInterrupted
+			break
+		}
+
+		target := t.layout.TargetPath(source)
+		hash, err := hashFile(filepath.Join(t.layout.Root, source))
+		if err != nil {
+			report.Failed[source] = err
+			continue
+		}
+
+		key := filepath.ToSlash(target)
+		entry, recorded := m.Files[key]
+		_, statErr := os.Stat(filepath.Join(t.layout.Root, target))
+		exists := statErr == nil
+		switch {
+		case options.Force:
+		case exists && recorded && entry.SourceHash == hash:
+			report.UpToDate = append(report.UpToDate, source)
+			continue
+		case exists && !recorded:
+			// 记录当前源文件的哈希，源文件以后修改时再翻译
+			t.logger.Info("跳过已本地化的文件", zap.String("源文件", source), zap.String("译文", target))
+			m.Files[key] = manifestEntry{Source: filepath.ToSlash(source), SourceHash: hash}
+			report.Localized = append(report.Localized, source)
+			continue
+		}
+
+		if options.DryRun {
+			report.Translated = append(report.Translated, source)
+			continue
+		}
+
+		// 先记录未完成的条目，翻译中断留下的部分译文在下次运行时重新翻译
+		m.Files[key] = manifestEntry{Source: filepath.ToSlash(source)}
+		if err := m.save(t.layout.Root); err != nil {
+			return report, err
+		}
+
+		t.logger.Info("翻译站点文件", zap.String("源文件", source), zap.String("译文", target))
+		if err := t.translateFile(ctx, source, target); err != nil {
+			if errors.Is(err, translator.ErrTranslationInterrupted) || ctx.Err() != nil {
+				runErr = err
+				break
+			}
+			t.logger.Error("翻译站点文件失败", zap.String("源文件", source), zap.Error(err))
+			report.Failed[source] = err
+			continue
+		}
+
+		m.Files[key] = manifestEntry{Source: filepath.ToSlash(source), SourceHash: hash}
+		if err := m.save(t.layout.Root); err != nil {
+			return report, err
+		}
+		report.Translated = append(report.Translated, source)
+	}
+
+	if options.DryRun {
+		return report, runErr
+	}
+	if err := m.save(t.layout.Root); err != nil {
+		return report, err
+	}
+
+	// 所有文件翻译完成后再改写链接，链接只指向已存在的本地化页面
+	for _, source := range report.Translated {
+		if err := t.localizeLinks(source, t.layout.TargetPath(source)); err != nil {
+			t.logger.Warn("改写站内链接失败", zap.String("源文件", source), zap.Error(err))
+		}
+	}
+	return report, runErr
+}
+
+// translateFile 翻译单个文件，必要时创建译文所在目录
+func (t *Translator) translateFile(ctx context.Context, source, target string) error {
+	outputPath := filepath.Join(t.layout.Root, target)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", target, err)
+	}
+	return t.translate(ctx, filepath.Join(t.layout.Root, source), outputPath)
+}
+
+// localizeLinks 改写译文中的站内相对链接
+func (t *Translator) localizeLinks(source, target string) error {
+	if !linkExtensions[strings.ToLower(filepath.Ext(target))] {
+		return nil
+	}
+	path := filepath.Join(t.layout.Root, target)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rewritten := rewriteLinks(content, func(dest string) string {
+		return t.localizeLink(dest, source, target)
+	})
+	if bytes.Equal(rewritten, content) {
+		return nil
+	}
+	return os.WriteFile(path, rewritten, 0o644)
+}
+
+// hashFile 计算文件内容的 SHA-256 哈希
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadManifest 读取站点翻译清单，不存在时返回空清单
+func loadManifest(root string) (*manifest, error) {
+	m := &manifest{Version: 1, Files: make(map[string]manifestEntry)}
+	data, err := os.ReadFile(filepath.Join(root, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", manifestName, err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestName, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return m, nil
+}
+
+// save 写入站点翻译清单
+func (m *manifest) save(root string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(root, manifestName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", manifestName, err)
+	}
+	return nil
+}
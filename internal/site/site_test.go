@@ -0,0 +1,200 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在 root 下创建测试文件
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		generator Generator
+		locale    string
+		expected  map[string]string
+	}{
+		{
+			name: "Hugo suffix",
+			files: map[string]string{
+				"hugo.toml":            "[languages.en]\n[languages.zh-cn]\n",
+				"content/_index.md":    "",
+				"content/post/a.md":    "",
+				"content/post/a.fr.md": "",
+				"content/about.en.md":  "",
+			},
+			generator: GeneratorHugo,
+			locale:    "zh-cn",
+			expected: map[string]string{
+				"content/_index.md":   "content/_index.zh-cn.md",
+				"content/about.en.md": "content/about.zh-cn.md",
+				"content/post/a.md":   "content/post/a.zh-cn.md",
+			},
+		},
+		{
+			name: "Hugo directories",
+			files: map[string]string{
+				"config.toml":          "",
+				"content/en/post/a.md": "",
+			},
+			generator: GeneratorHugo,
+			locale:    "zh",
+			expected: map[string]string{
+				"content/en/post/a.md": "content/zh/post/a.md",
+			},
+		},
+		{
+			name: "Docusaurus",
+			files: map[string]string{
+				"docusaurus.config.js":      "i18n: {defaultLocale: 'en', locales: ['en', 'zh-Hans']}",
+				"docs/intro.md":             "",
+				"docs/guide/setup.mdx":      "",
+				"blog/2024-01-01-hello.md":  "",
+				"src/pages/index.md":        "",
+				"src/components/Button.tsx": "",
+			},
+			generator: GeneratorDocusaurus,
+			locale:    "zh-Hans",
+			expected: map[string]string{
+				"blog/2024-01-01-hello.md": "i18n/zh-Hans/docusaurus-plugin-content-blog/2024-01-01-hello.md",
+				"docs/guide/setup.mdx":     "i18n/zh-Hans/docusaurus-plugin-content-docs/current/guide/setup.mdx",
+				"docs/intro.md":            "i18n/zh-Hans/docusaurus-plugin-content-docs/current/intro.md",
+				"src/pages/index.md":       "i18n/zh-Hans/docusaurus-plugin-content-pages/index.md",
+			},
+		},
+		{
+			name: "MkDocs",
+			files: map[string]string{
+				"mkdocs.yml":            "docs_dir: documentation\nplugins:\n  - i18n:\n      languages:\n        - locale: en\n        - locale: zh\n",
+				"documentation/a.md":    "",
+				"documentation/a.zh.md": "",
+			},
+			generator: GeneratorMkDocs,
+			locale:    "zh",
+			expected: map[string]string{
+				"documentation/a.md": "documentation/a.zh.md",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+
+			generator, err := DetectGenerator(root)
+			if err != nil || generator != tt.generator {
+				t.Fatalf("Expected generator %s, got %s (%v)", tt.generator, generator, err)
+			}
+			if locale := ResolveLocale(root, generator, "Chinese"); locale != tt.locale {
+				t.Errorf("Expected locale %s, got %s", tt.locale, locale)
+			}
+
+			layout, err := NewLayout(root, generator, ResolveLocale(root, generator, "en"), tt.locale)
+			if err != nil {
+				t.Fatalf("Failed to create layout: %v", err)
+			}
+			sources, err := layout.Sources()
+			if err != nil {
+				t.Fatalf("Failed to list sources: %v", err)
+			}
+			got := make(map[string]string)
+			for _, source := range sources {
+				got[filepath.ToSlash(source)] = filepath.ToSlash(layout.TargetPath(source))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTranslatorRun(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"docusaurus.config.js": "",
+		"docs/intro.md":        "See [setup](./guide/setup.md#install), ![logo](img/logo.png) and [missing](nope.md).\n\n```\n[code](./guide/setup.md)\n```\n",
+		"docs/guide/setup.md":  "[Back](../intro.md) [site](https://example.com)\n\n[ref]: <../draft.md>\n",
+		"docs/draft.md":        "draft\n",
+		"docs/img/logo.png":    "png",
+		"blog/post.md":         "Read [the intro](../docs/intro.md).\n",
+	})
+
+	layout, err := NewLayout(root, GeneratorDocusaurus, "en", "zh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := "i18n/zh/docusaurus-plugin-content-docs/current/"
+	writeFiles(t, root, map[string]string{docs + "draft.md": "手工翻译\n"})
+
+	var calls []string
+	translate := func(ctx context.Context, inputPath, outputPath string) error {
+		rel, _ := filepath.Rel(root, inputPath)
+		calls = append(calls, filepath.ToSlash(rel))
+		data, err := os.ReadFile(inputPath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outputPath, []byte(strings.ReplaceAll(string(data), "draft\n", "草稿\n")), 0o644)
+	}
+
+	report, err := NewTranslator(layout, translate, nil).Run(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	expectedCalls := []string{"blog/post.md", "docs/guide/setup.md", "docs/intro.md"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("Expected translations %v, got %v", expectedCalls, calls)
+	}
+	if len(report.Localized) != 1 || filepath.ToSlash(report.Localized[0]) != "docs/draft.md" {
+		t.Errorf("Expected draft to be kept as localized, got %v", report.Localized)
+	}
+
+	expected := map[string]string{
+		docs + "intro.md":       "See [setup](./guide/setup.md#install), ![logo](../../../../docs/img/logo.png) and [missing](nope.md).\n\n```\n[code](./guide/setup.md)\n```\n",
+		docs + "guide/setup.md": "[Back](../intro.md) [site](https://example.com)\n\n[ref]: <../draft.md>\n",
+		docs + "draft.md":       "手工翻译\n",
+		"i18n/zh/docusaurus-plugin-content-blog/post.md": "Read [the intro](../docusaurus-plugin-content-docs/current/intro.md).\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("Missing %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s:\n%q\ngot:\n%q", name, content, string(data))
+		}
+	}
+
+	// 只重新翻译修改过的源文件
+	calls = nil
+	writeFiles(t, root, map[string]string{"docs/draft.md": "draft v2\n", "docs/intro.md": "Changed\n"})
+	report, err = NewTranslator(layout, translate, nil).Run(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	expectedCalls = []string{"docs/draft.md", "docs/intro.md"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("Expected translations %v, got %v", expectedCalls, calls)
+	}
+	if len(report.UpToDate) != 2 {
+		t.Errorf("Expected 2 up to date files, got %v", report.UpToDate)
+	}
+}
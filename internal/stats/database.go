@@ -15,6 +15,9 @@ import (
 const (
 	StatsDBVersion   = "1.0.0"
 	MaxRecentRecords = 100
+
+	// MaxEvaluationRecords 保留的评测记录数量
+	MaxEvaluationRecords = 1000
 )
 
 // Database 统计数据库
@@ -333,6 +336,36 @@ func (db *Database) GetRecentTranslations(limit int) []*TranslationRecord {
 	return sorted[:limit]
 }
 
+// AddEvaluationRecord 添加评测记录，超过数量限制时丢弃最早的记录
+func (db *Database) AddEvaluationRecord(record *EvaluationRecord) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.data.Evaluations = append(db.data.Evaluations, record)
+	sort.SliceStable(db.data.Evaluations, func(i, j int) bool {
+		return db.data.Evaluations[i].Timestamp.Before(db.data.Evaluations[j].Timestamp)
+	})
+	if len(db.data.Evaluations) > MaxEvaluationRecords {
+		db.data.Evaluations = db.data.Evaluations[len(db.data.Evaluations)-MaxEvaluationRecords:]
+	}
+
+	return db.saveUnsafe()
+}
+
+// GetEvaluations 获取评测记录（按时间从早到晚），model 或 stepSet 为空时不按该项过滤
+func (db *Database) GetEvaluations(model, stepSet string) []*EvaluationRecord {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	var records []*EvaluationRecord
+	for _, record := range db.data.Evaluations {
+		if (model == "" || record.Model == model) && (stepSet == "" || record.StepSet == stepSet) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
 // RecordCacheHit 记录缓存命中
 func (db *Database) RecordCacheHit() {
 	db.mutex.Lock()
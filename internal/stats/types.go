@@ -31,6 +31,9 @@ type StatisticsDB struct {
 
 	// 性能统计
 	PerformanceStats PerformanceStatistics `json:"performance_stats"`
+
+	// 参考译文评测记录
+	Evaluations []*EvaluationRecord `json:"evaluations,omitempty"`
 }
 
 // CacheStatistics 缓存统计信息
@@ -97,6 +100,29 @@ type TranslationRecord struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// EvaluationRecord 参考译文评测记录，按模型和步骤集跟踪译文质量的变化
+type EvaluationRecord struct {
+	ID             string    `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	OutputFile     string    `json:"output_file"`
+	ReferenceFile  string    `json:"reference_file"`
+	SourceLanguage string    `json:"source_language"`
+	TargetLanguage string    `json:"target_language"`
+	Format         string    `json:"format"`
+	Model          string    `json:"model"`
+	StepSet        string    `json:"step_set"`
+	Alignment      string    `json:"alignment"` // node 或 sentence
+	Segments       int       `json:"segments"`
+
+	// 语料级评分（0-100），TER 越低越好
+	BLEU float64 `json:"bleu"`
+	ChrF float64 `json:"chrf"`
+	TER  float64 `json:"ter"`
+
+	// 元数据
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
 // PerformanceStatistics 性能统计
 type PerformanceStatistics struct {
 	AverageTranslationSpeed float64       `json:"average_translation_speed"` // 字符/秒
@@ -182,6 +182,57 @@ func (v *Visualizer) ShowRecentTranslations(limit int) {
 	}
 }
 
+// ShowEvaluations 按模型和步骤集显示评测趋势，最新一次与上一次相比分数变差时标记为回退
+func (v *Visualizer) ShowEvaluations(limit int) {
+	records := v.db.GetEvaluations("", "")
+
+	title := color.New(color.FgMagenta, color.Bold)
+	title.Println("🎯 Reference Evaluations")
+	title.Println(strings.Repeat("=", 50))
+
+	if len(records) == 0 {
+		fmt.Println("No evaluations found. Run 'translator eval <output> <reference>' to add one.")
+		return
+	}
+
+	// 按模型和步骤集分组，记录已按时间排序
+	groups := make(map[string][]*EvaluationRecord)
+	var keys []string
+	for _, record := range records {
+		key := fmt.Sprintf("%s / %s", record.Model, record.StepSet)
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Println()
+		}
+		history := groups[key]
+		if limit > 0 && len(history) > limit {
+			history = history[len(history)-limit:]
+		}
+
+		data := make([][]string, 0, len(history))
+		for j, record := range history {
+			value := fmt.Sprintf("BLEU %.2f  chrF++ %.2f  TER %.2f  (%d segments, %s)",
+				record.BLEU, record.ChrF, record.TER, record.Segments, record.OutputFile)
+			if j > 0 {
+				previous := history[j-1]
+				if record.BLEU < previous.BLEU || record.ChrF < previous.ChrF || record.TER > previous.TER {
+					value += fmt.Sprintf("  ⚠️ regression (BLEU %+.2f, chrF++ %+.2f, TER %+.2f)",
+						record.BLEU-previous.BLEU, record.ChrF-previous.ChrF, record.TER-previous.TER)
+				}
+			}
+			data = append(data, []string{formatTime(record.Timestamp), value})
+		}
+		v.printSection("📈 "+key, data)
+	}
+}
+
 // printCacheStats 打印缓存统计
 func (v *Visualizer) printCacheStats(cache CacheStatistics) {
 	title := "💾 Cache Statistics"
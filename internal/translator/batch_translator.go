@@ -2,10 +2,12 @@ package translator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dlclark/regexp2"
@@ -15,6 +17,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// nodeSegmentPattern 匹配译文中一个节点的标记和内容，兼容不同的换行符和空格
+var nodeSegmentPattern = regexp2.MustCompile(`(?s)@@NODE_START_(\d+)@@\s*\r?\n(.*?)\r?\n\s*@@NODE_END_\1@@`, 0)
+
 // BatchTranslator 实现Translator接口，负责节点分组和并行翻译
 type BatchTranslator struct {
 	config             TranslatorConfig
@@ -24,17 +29,28 @@ type BatchTranslator struct {
 	smartSplitter      *translation.SmartNodeSplitter // 智能节点分割器
 	statsManager       *stats.StatsManager            // 统计管理器
 	documentProcessor  document.Processor             // 文档处理器，用于格式特定的内容保护
+	consistency        *ConsistencyStore              // 跨文件一致性记录，未启用时为 nil
+	candidates         *CandidateGenerator            // 多候选生成，未启用时为 nil
+
+	contextOnce      sync.Once // 只查询一次模型的上下文长度
+	contextChunkSize int       // 按上下文长度计算的分组大小上限，未知时为 0
 
 	// 详细翻译过程跟踪
 	translationRounds []*TranslationRoundResult // 每轮翻译的详细结果
 	mu                sync.Mutex                // 保护translationRounds的并发访问
 
 	// 进度回调
-	progressCallback ProgressCallback // 进度回调函数
+	progressCallback   ProgressCallback   // 进度回调函数
+	nodeResultCallback NodeResultCallback // 节点结果回调函数
 }
 
 // NewBatchTranslator 创建批量翻译器
 func NewBatchTranslator(cfg TranslatorConfig, service translation.Service, logger *zap.Logger, statsManager *stats.StatsManager, docProcessor document.Processor) *BatchTranslator {
+	var consistency *ConsistencyStore
+	if cfg.ConsistencyEnabled {
+		consistency = NewConsistencyStore(cfg.ConsistencyMaxSegmentLength, cfg.ConsistencyMaxTermWords, cfg.ConsistencyMaxPromptEntries)
+	}
+
 	return &BatchTranslator{
 		config:             cfg,
 		translationService: service,
@@ -43,6 +59,7 @@ func NewBatchTranslator(cfg TranslatorConfig, service translation.Service, logge
 		smartSplitter:      translation.NewSmartNodeSplitter(cfg.SmartSplitter, logger),
 		statsManager:       statsManager,
 		documentProcessor:  docProcessor,
+		consistency:        consistency,
 		translationRounds:  make([]*TranslationRoundResult, 0),
 	}
 }
@@ -54,11 +71,28 @@ func (bt *BatchTranslator) SetProgressCallback(callback ProgressCallback) {
 	bt.progressCallback = callback
 }
 
+// SetNodeResultCallback 设置节点结果回调函数，每个节点翻译成功或失败后调用
+func (bt *BatchTranslator) SetNodeResultCallback(callback NodeResultCallback) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.nodeResultCallback = callback
+}
+
 // SetDocumentProcessor 设置文档处理器，用于格式特定的内容保护
 func (bt *BatchTranslator) SetDocumentProcessor(processor document.Processor) {
 	bt.documentProcessor = processor
 }
 
+// SetCandidateGenerator 设置多候选生成器，为 nil 时每组节点只翻译一次
+func (bt *BatchTranslator) SetCandidateGenerator(generator *CandidateGenerator) {
+	bt.candidates = generator
+}
+
+// Consistency 返回跨文件一致性记录，未启用时返回 nil
+func (bt *BatchTranslator) Consistency() *ConsistencyStore {
+	return bt.consistency
+}
+
 // callProgressCallback 安全地调用进度回调
 func (bt *BatchTranslator) callProgressCallback(completed, total int, message string) {
 	bt.mu.Lock()
@@ -70,6 +104,27 @@ func (bt *BatchTranslator) callProgressCallback(completed, total int, message st
 	}
 }
 
+// reportNodeResults 报告组内实际翻译的节点结果（跳过上下文节点）
+func (bt *BatchTranslator) reportNodeResults(group *document.NodeGroup, nodeStepOutputs map[int]map[string]string) {
+	bt.mu.Lock()
+	callback := bt.nodeResultCallback
+	bt.mu.Unlock()
+
+	if callback == nil {
+		return
+	}
+
+	for _, node := range group.Nodes {
+		if isContext, ok := node.Metadata["is_context"].(bool); ok && isContext {
+			continue
+		}
+		if node.Status != document.NodeStatusSuccess && node.Status != document.NodeStatusFailed {
+			continue
+		}
+		callback(node, nodeStepOutputs[node.ID])
+	}
+}
+
 // TranslateNodes 翻译所有节点（并行版本，包含失败重试）
 func (bt *BatchTranslator) TranslateNodes(ctx context.Context, nodes []*document.NodeInfo) error {
 	// 重置翻译轮次记录
@@ -81,6 +136,7 @@ func (bt *BatchTranslator) TranslateNodes(ctx context.Context, nodes []*document
 	bt.logger.Info("starting batch translation", zap.Int("totalNodes", len(nodes)))
 
 	// 第一轮：分组翻译所有节点
+	bt.resolveContextChunkSize(ctx)
 	groups := bt.groupNodes(nodes)
 	bt.logger.Debug("initial grouping for translation",
 		zap.Int("totalGroups", len(groups)),
@@ -166,6 +222,13 @@ func (bt *BatchTranslator) TranslateNodes(ctx context.Context, nodes []*document
 
 	// 重试循环
 	for retry := 1; retry <= maxRetries; retry++ {
+		// 收到停止信号后不再开始新的重试轮次
+		if StopRequested(ctx) {
+			bt.logger.Warn("translation stopped, skipping retry rounds",
+				zap.Int("retryRound", retry))
+			break
+		}
+
 		// 收集失败节点
 		failedNodes := bt.collectFailedNodes(nodes)
 		if len(failedNodes) == 0 {
@@ -379,11 +442,18 @@ func (bt *BatchTranslator) processGroups(ctx context.Context, groups []*document
 
 	// 启动工作 goroutines
 	var wg sync.WaitGroup
+	var skippedGroups int32
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
 			for group := range groupChan {
+				// 收到停止信号后不再调度新的组，节点保持未翻译状态
+				if StopRequested(ctx) {
+					atomic.AddInt32(&skippedGroups, 1)
+					continue
+				}
+
 				bt.logger.Debug("worker processing group",
 					zap.Int("workerID", workerID),
 					zap.Int("groupSize", len(group.Nodes)))
@@ -425,10 +495,20 @@ func (bt *BatchTranslator) processGroups(ctx context.Context, groups []*document
 	wg.Wait()
 	close(errChan)
 	close(progressChan)
+
+	if skipped := atomic.LoadInt32(&skippedGroups); skipped > 0 {
+		bt.logger.Warn("translation stopped, remaining groups were not scheduled",
+			zap.Int32("skippedGroups", skipped),
+			zap.Int("totalGroups", len(groups)))
+	}
 }
 
 // translateGroup 翻译一个节点组
 func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.NodeGroup) error {
+	// 各节点在翻译链每个步骤中的输出
+	nodeStepOutputs := make(map[int]map[string]string)
+	defer bt.reportNodeResults(group, nodeStepOutputs)
+
 	if bt.translationService == nil {
 		// 模拟翻译
 		for _, node := range group.Nodes {
@@ -444,6 +524,8 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 
 	// 构建批量翻译文本
 	var builder strings.Builder
+	var contextNotes []string // 节点的翻译上下文（如 msgctxt、译者注释）
+	var sourceTexts []string  // 需要翻译的节点原文，用于查找已有译法
 	needsTranslation := false
 
 	for _, node := range group.Nodes {
@@ -508,6 +590,11 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 				builder.WriteString(fmt.Sprintf("@@NODE_START_%d@@\n", node.ID))
 				builder.WriteString(protectedText)
 				builder.WriteString(fmt.Sprintf("\n@@NODE_END_%d@@", node.ID))
+				sourceTexts = append(sourceTexts, node.OriginalText)
+
+				if nodeContext, ok := node.Metadata[document.NodeTranslationContextKey].(string); ok && nodeContext != "" {
+					contextNotes = append(contextNotes, fmt.Sprintf("- @@NODE_START_%d@@: %s", node.ID, nodeContext))
+				}
 			}
 		}
 	}
@@ -572,6 +659,34 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 			zap.Strings("markers", nodeMarkers))
 	}
 
+	// 节点的翻译上下文加入提示词说明
+	if len(contextNotes) > 0 {
+		ctx = document.WithTranslationNotes(ctx, "Context for the segments, by node marker (use it to disambiguate, do not translate it):\n"+
+			strings.Join(contextNotes, "\n"))
+	}
+
+	// 本次运行中已有的短片段和术语译法加入提示词说明
+	if notes := bt.consistency.Notes(sourceTexts); notes != "" {
+		ctx = document.WithTranslationNotes(ctx, notes)
+	}
+
+	// 记录翻译链各步骤的输出，用于按节点保存
+	var stepResults []translation.StepResult
+	ctx = translation.WithStepResultRecorder(ctx, func(step translation.StepResult) {
+		stepResults = append(stepResults, step)
+	})
+
+	// 启用多候选时，额外候选与步骤集原样翻译同时生成
+	var candidates *candidateRun
+	if bt.candidates != nil {
+		candidateCtx, cancel := context.WithCancel(ctx)
+		candidates = bt.candidates.start(candidateCtx, combinedText)
+		defer func() {
+			cancel()
+			bt.recordCandidateStats(candidates)
+		}()
+	}
+
 	// 执行翻译 - 使用简化的接口，无分块
 	startTime := time.Now()
 	translatedText, err := bt.translationService.TranslateText(ctx, combinedText)
@@ -706,8 +821,13 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 		}
 	}
 
+	// 逐节点从各候选中选出最佳译文
+	if candidates != nil {
+		translatedText = bt.candidates.selectWinners(ctx, group, translatedText, candidates, preserveManager)
+	}
+
 	// 解析翻译结果 - 使用更强健的正则表达式处理不同换行符和空格
-	pattern := regexp2.MustCompile(`(?s)@@NODE_START_(\d+)@@\s*\r?\n(.*?)\r?\n\s*@@NODE_END_\1@@`, 0)
+	pattern := nodeSegmentPattern
 
 	// 创建结果映射
 	translationMap := make(map[int]string)
@@ -775,6 +895,14 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 		match, _ = pattern.FindNextMatch(match)
 	}
 
+	// 按节点标记拆分各步骤的输出，采用额外候选的节点使用该候选的步骤输出
+	for nodeID, outputs := range splitStepOutputs(stepResults, preserveManager) {
+		nodeStepOutputs[nodeID] = outputs
+	}
+	if candidates != nil {
+		bt.candidates.attachWinnerSteps(group, candidates, preserveManager, nodeStepOutputs)
+	}
+
 	// 重用之前计算的nodeIDsToTranslate作为inputNodeIDs
 	inputNodeIDs := nodeIDsToTranslate
 
@@ -900,6 +1028,7 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 				node.Error = nil
 				// 增加重试计数
 				node.RetryCount++
+				bt.consistency.Record(sourceFileFromContext(ctx, ""), node)
 
 				// 在 verbose 模式下显示成功翻译的片段
 				if bt.config.Verbose {
@@ -950,6 +1079,66 @@ func (bt *BatchTranslator) translateGroup(ctx context.Context, group *document.N
 	return nil
 }
 
+// splitStepOutputs 按节点标记拆分各步骤的输出，返回节点 ID 到步骤名称和输出的映射。
+// 迭代审校的步骤按 "名称_轮次" 保存：审校的问题按片段所在的节点拆分为 JSON，编辑只保存改变了的节点
+func splitStepOutputs(stepResults []translation.StepResult, preserveManager *translation.PreserveManager) map[int]map[string]string {
+	nodeStepOutputs := make(map[int]map[string]string)
+	set := func(nodeID int, name, output string) {
+		if nodeStepOutputs[nodeID] == nil {
+			nodeStepOutputs[nodeID] = make(map[string]string)
+		}
+		nodeStepOutputs[nodeID][name] = output
+	}
+
+	// previous 最近一个带节点标记的步骤输出，审校问题按它定位节点
+	var previous map[int]string
+	for _, step := range stepResults {
+		name := step.Name
+		if step.Iteration > 0 {
+			name = fmt.Sprintf("%s_%d", step.Name, step.Iteration)
+		}
+
+		segments := parseNodeSegments(step.Output)
+		if len(segments) == 0 {
+			for nodeID, segment := range previous {
+				if issues := nodeReviewIssues(step.Issues, segment, preserveManager); issues != "" {
+					set(nodeID, name, issues)
+				}
+			}
+			continue
+		}
+
+		for nodeID, segment := range segments {
+			if step.Iteration > 0 && previous[nodeID] == segment {
+				continue
+			}
+			set(nodeID, name, translation.RemoveReasoningMarkers(preserveManager.Restore(segment)))
+		}
+		previous = segments
+	}
+	return nodeStepOutputs
+}
+
+// nodeReviewIssues 返回片段位于节点译文中的审校问题（还原保护内容后的 JSON），没有时返回空字符串
+func nodeReviewIssues(issues []translation.ReviewIssue, segment string, preserveManager *translation.PreserveManager) string {
+	var nodeIssues []translation.ReviewIssue
+	for _, issue := range issues {
+		if strings.Contains(segment, issue.Span) {
+			issue.Span = preserveManager.Restore(issue.Span)
+			issue.Suggestion = preserveManager.Restore(issue.Suggestion)
+			nodeIssues = append(nodeIssues, issue)
+		}
+	}
+	if len(nodeIssues) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(nodeIssues)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // recordGroupStats 记录组翻译统计信息
 func (bt *BatchTranslator) recordGroupStats(ctx context.Context, group *document.NodeGroup, nodeIDsToTranslate []int, translationErr error, latency time.Duration) {
 	if bt.statsManager == nil {
@@ -1066,6 +1255,9 @@ func (bt *BatchTranslator) groupNodes(nodes []*document.NodeInfo) []*document.No
 	if maxSize <= 0 {
 		maxSize = 1000
 	}
+	if bt.contextChunkSize > 0 && bt.contextChunkSize < maxSize {
+		maxSize = bt.contextChunkSize
+	}
 
 	for _, node := range processedNodes {
 		nodeSize := len(node.OriginalText)
@@ -1095,6 +1287,25 @@ func (bt *BatchTranslator) groupNodes(nodes []*document.NodeInfo) []*document.No
 	return groups
 }
 
+// resolveContextChunkSize 查询翻译服务报告的上下文长度（如 llama.cpp 的 n_ctx），
+// 据此限制分组大小，避免一组原文和译文超出本地模型的上下文
+func (bt *BatchTranslator) resolveContextChunkSize(ctx context.Context) {
+	bt.contextOnce.Do(func() {
+		reporter, ok := bt.translationService.(translation.ContextWindowReporter)
+		if !ok {
+			return
+		}
+		contextWindow := reporter.ContextWindow(ctx)
+		bt.contextChunkSize = translation.ChunkSizeForContextWindow(contextWindow)
+		if bt.contextChunkSize > 0 && bt.contextChunkSize < bt.config.ChunkSize {
+			bt.logger.Info("limiting group size to the model context window",
+				zap.Int("contextWindow", contextWindow),
+				zap.Int("chunkSize", bt.config.ChunkSize),
+				zap.Int("groupSize", bt.contextChunkSize))
+		}
+	})
+}
+
 // preprocessNodesWithSplitting 预处理节点，对超大节点进行智能分割
 func (bt *BatchTranslator) preprocessNodesWithSplitting(nodes []*document.NodeInfo) []*document.NodeInfo {
 	if !bt.config.SmartSplitter.EnableSmartSplitting {
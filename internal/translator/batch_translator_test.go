@@ -3,15 +3,57 @@ package translator
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/dlclark/regexp2"
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 	"github.com/nerdneilsfield/go-translator-agent/internal/document"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
+// recordingProvider 按原文查表翻译节点并记录请求的模拟提供商
+type recordingProvider struct {
+	mu           sync.Mutex
+	translations map[string]string
+	requests     []*translation.ProviderRequest
+}
+
+func (p *recordingProvider) Translate(ctx context.Context, req *translation.ProviderRequest) (*translation.ProviderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+
+	var parts []string
+	for id, text := range parseNodeSegments(req.Text) {
+		parts = append(parts, fmt.Sprintf("@@NODE_START_%d@@\n%s\n@@NODE_END_%d@@", id, p.translations[text], id))
+	}
+	return &translation.ProviderResponse{Text: strings.Join(parts, "\n\n")}, nil
+}
+
+func (p *recordingProvider) GetName() string     { return "recording" }
+func (p *recordingProvider) SupportsSteps() bool { return false }
+
+// newProviderService 创建只有一个提供商步骤的翻译服务
+func newProviderService(t *testing.T, provider translation.TranslationProvider) translation.Service {
+	t.Helper()
+	service, err := translation.New(&translation.Config{
+		SourceLanguage: "English",
+		TargetLanguage: "Chinese",
+		ChunkSize:      1000,
+		MaxConcurrency: 1,
+		Steps:          []translation.StepConfig{{Name: "initial", Provider: "recording"}},
+		ActiveStepSet:  "basic",
+		StepSets:       config.GetDefaultStepSetsV2(),
+	}, translation.WithSingleProvider("recording", provider))
+	require.NoError(t, err)
+	return service
+}
+
 func TestNodeMarkerRegex(t *testing.T) {
 	// 测试正则表达式是否正确匹配节点标记
 	pattern := regexp2.MustCompile(`(?s)@@NODE_START_(\d+)@@\s*\r?\n(.*?)\r?\n\s*@@NODE_END_\1@@`, 0)
@@ -243,3 +285,107 @@ func TestBatchTranslatorWithSimilarityCheck(t *testing.T) {
 
 	t.Logf("Translation results: %d success, %d failed", successCount, failedCount)
 }
+
+func TestBatchTranslatorStopSignal(t *testing.T) {
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 10, Concurrency: 2, RetryOnFailure: true, MaxRetries: 2}, nil, zap.NewNop(), nil, nil)
+
+	nodes := []*document.NodeInfo{
+		{ID: 1, OriginalText: "First paragraph.", Status: document.NodeStatusPending},
+		{ID: 2, OriginalText: "Second paragraph.", Status: document.NodeStatusPending},
+	}
+
+	// 停止信号已触发时不再调度任何节点组
+	stop := make(chan struct{})
+	close(stop)
+	ctx := WithStopSignal(context.Background(), stop)
+	assert.True(t, StopRequested(ctx))
+
+	err := bt.TranslateNodes(ctx, nodes)
+	assert.NoError(t, err)
+	for _, node := range nodes {
+		assert.Equal(t, document.NodeStatusPending, node.Status)
+		assert.Empty(t, node.TranslatedText)
+	}
+
+	assert.False(t, StopRequested(context.Background()))
+}
+
+// contextWindowService 报告上下文长度的模拟翻译服务
+type contextWindowService struct {
+	contextWindow int
+}
+
+func (s *contextWindowService) TranslateText(ctx context.Context, text string) (string, error) {
+	return text, nil
+}
+
+func (s *contextWindowService) ContextWindow(ctx context.Context) int {
+	return s.contextWindow
+}
+
+func TestGroupNodesRespectsContextWindow(t *testing.T) {
+	nodes := make([]*document.NodeInfo, 10)
+	for i := range nodes {
+		nodes[i] = &document.NodeInfo{ID: i + 1, OriginalText: strings.Repeat("x", 300)}
+	}
+
+	// 4096 token 的上下文：(4096-1024)/3*2 = 2048 字符一组
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 5000}, &contextWindowService{contextWindow: 4096}, zap.NewNop(), nil, nil)
+	bt.resolveContextChunkSize(context.Background())
+	groups := bt.groupNodes(nodes)
+	assert.Len(t, groups, 2)
+	for _, group := range groups {
+		assert.LessOrEqual(t, group.Size, 2048)
+	}
+
+	// 没有报告上下文长度时使用配置的分块大小
+	bt = NewBatchTranslator(TranslatorConfig{ChunkSize: 5000}, &contextWindowService{}, zap.NewNop(), nil, nil)
+	bt.resolveContextChunkSize(context.Background())
+	assert.Len(t, bt.groupNodes(nodes), 1)
+}
+
+func TestBatchTranslatorSendsMessageContextToProvider(t *testing.T) {
+	processor, err := document.NewI18nProcessor(document.ProcessorOptions{Metadata: map[string]interface{}{
+		"source_language": "English",
+		"target_language": "German",
+	}}, zap.NewNop(), document.FormatPO)
+	require.NoError(t, err)
+	doc, err := processor.Parse(context.Background(), strings.NewReader("#. Button label\nmsgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"\"\n"))
+	require.NoError(t, err)
+
+	coordinator := &TranslationCoordinator{logger: zap.NewNop()}
+	nodes := coordinator.extractNodesFromDocument(doc)
+	require.Len(t, nodes, 1)
+
+	provider := &recordingProvider{translations: map[string]string{"Open": "Öffnen"}}
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 1000, Concurrency: 1}, newProviderService(t, provider), zap.NewNop(), nil, nil)
+	require.NoError(t, bt.TranslateNodes(context.Background(), nodes))
+	assert.Equal(t, "Öffnen", nodes[0].TranslatedText)
+
+	// msgctxt 和译者注释作为节点上下文加入提供商请求的附加指令
+	require.Len(t, provider.requests, 1)
+	assert.Contains(t, provider.requests[0].Metadata["instruction"], `@@NODE_START_1@@: context "menu"; translator note: Button label`)
+}
+
+func TestSplitStepOutputsRecordsReviewIterations(t *testing.T) {
+	initial := "@@NODE_START_1@@\nThe cat sit.\n@@NODE_END_1@@\n\n@@NODE_START_2@@\nGood morning.\n@@NODE_END_2@@"
+	edited := strings.Replace(initial, "sit", "sits", 1)
+	steps := []translation.StepResult{
+		{Name: "initial", Output: initial},
+		{Name: "review", Iteration: 1, Output: `{"issues": [...]}`, Issues: []translation.ReviewIssue{
+			{Type: "grammar", Span: "sit", Severity: translation.SeverityMajor},
+		}},
+		{Name: "edit", Iteration: 1, Output: edited},
+		{Name: "review", Iteration: 2, Output: `{"issues": []}`},
+	}
+
+	outputs := splitStepOutputs(steps, translation.NewPreserveManager(translation.DefaultPreserveConfig))
+
+	// 审校问题记录在片段所在的节点，编辑只记录改变了的节点
+	assert.Equal(t, map[string]string{
+		"initial":  "The cat sit.",
+		"review_1": `[{"type":"grammar","span":"sit","severity":"major"}]`,
+		"edit_1":   "The cat sits.",
+	}, outputs[1])
+	assert.Equal(t, map[string]string{"initial": "Good morning."}, outputs[2])
+}
@@ -0,0 +1,547 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/internal/experiment"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/deepl"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/stats"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"go.uber.org/zap"
+)
+
+// 候选译文选择器
+const (
+	CandidateSelectorHeuristic = "heuristic" // 综合格式完整性、词汇表遵循和长度比例
+	CandidateSelectorLLM       = "llm"       // 由 LLM 并排比较打分
+)
+
+// 节点元数据中的多候选键
+const (
+	// NodeCandidatesKey 节点元数据：落选的候选译文（[]TranslationCandidate，按评分从高到低）
+	NodeCandidatesKey = "translation_candidates"
+
+	// NodeSelectedCandidateKey 节点元数据：采用的候选译文的名称
+	NodeSelectedCandidateKey = "selected_candidate"
+)
+
+// 启发式选择器发现的问题，其余问题沿用 experiment 包的启发式评分
+const (
+	IssueGlossary    = "glossary"     // 没有使用词汇表规定的译法
+	IssueLengthRatio = "length_ratio" // 长度比例与其他候选相差较大
+)
+
+const (
+	// candidateTemperatureStep 没有配置模型和温度时，每个额外候选提高的初始翻译温度
+	candidateTemperatureStep = 0.3
+	// maxCandidateTemperature 候选温度上限
+	maxCandidateTemperature = 2.0
+
+	// 启发式评分中各项的权重
+	candidateFormatWeight   = 0.5
+	candidateGlossaryWeight = 0.3
+	candidateLengthWeight   = 0.2
+
+	// minCandidateLengthScore 长度比例评分低于该值时记为问题
+	minCandidateLengthScore = 0.7
+)
+
+// TranslationCandidate 节点的一个候选译文
+type TranslationCandidate struct {
+	Variant     string   `json:"variant"` // 生成候选的模型，改变温度时为 "模型@温度"
+	Translation string   `json:"translation"`
+	Score       float64  `json:"score"` // 0-1
+	Issues      []string `json:"issues,omitempty"`
+	Reason      string   `json:"reason,omitempty"` // LLM 选择器给出的理由
+}
+
+// candidateSpec 额外候选的初始翻译模型和温度
+type candidateSpec struct {
+	name        string
+	model       string   // 为空时沿用步骤集的模型
+	temperature *float64 // 为 nil 时沿用模型配置的温度
+}
+
+// candidateVariant 额外候选使用的翻译服务
+type candidateVariant struct {
+	name     string
+	provider string // 初始翻译模型的 API 类型，用于记录统计
+	service  translation.Service
+}
+
+// CandidateGenerator 为每组节点并行生成多个候选译文，并逐节点选出最佳译文
+type CandidateGenerator struct {
+	primary  string // 按步骤集原样翻译的候选名称
+	variants []candidateVariant
+	glossary map[string]string
+	judge    *experiment.Judge // 为 nil 时使用启发式选择器
+	logger   *zap.Logger
+}
+
+// newCandidateGenerator 按活动步骤集的候选配置创建多候选生成器，未启用时返回 nil。
+// 额外候选的翻译服务不使用缓存，否则改变温度的候选会直接得到缓存的译文
+func newCandidateGenerator(cfg *config.Config, translationConfig *translation.Config, logger *zap.Logger) (*CandidateGenerator, error) {
+	stepSet, ok := cfg.StepSets[cfg.ActiveStepSet]
+	settings := stepSet.Candidates
+	if !ok || settings.Count < 2 || len(stepSet.Steps) == 0 {
+		return nil, nil
+	}
+
+	generator := &CandidateGenerator{
+		primary: candidateName(stepSet.Steps[0].ModelName, nil),
+		logger:  logger,
+	}
+	specs := candidateSpecs(stepSet, cfg.ModelConfigs)
+	if len(specs) == 0 {
+		logger.Warn("multi-candidate translation disabled: no candidate differs from the step set translation",
+			zap.String("step_set", cfg.ActiveStepSet))
+		return nil, nil
+	}
+	for _, spec := range specs {
+		service, err := translation.New(translationConfig.WithInitialStepVariant(spec.model, spec.temperature), translation.WithLogger(logger))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create translation service for candidate %s: %w", spec.name, err)
+		}
+		model := spec.model
+		if model == "" {
+			model = stepSet.Steps[0].ModelName
+		}
+		generator.variants = append(generator.variants, candidateVariant{
+			name:     spec.name,
+			provider: cfg.ModelConfigs[model].APIType,
+			service:  service,
+		})
+	}
+
+	if cfg.GlossaryPath != "" {
+		entries, err := deepl.LoadGlossaryEntries(cfg.GlossaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load glossary: %w", err)
+		}
+		generator.glossary = entries
+	}
+
+	switch settings.Selector {
+	case "", CandidateSelectorHeuristic:
+	case CandidateSelectorLLM:
+		if _, ok := cfg.ModelConfigs[settings.SelectorModel]; !ok {
+			return nil, fmt.Errorf("candidate selector model '%s' not found in configuration", settings.SelectorModel)
+		}
+		provider, err := translation.NewProviderManager(translationConfig, logger).CreateProvider("", settings.SelectorModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create candidate selector provider: %w", err)
+		}
+		generator.judge = experiment.NewJudge(provider, cfg.SourceLang, cfg.TargetLang)
+	default:
+		return nil, fmt.Errorf("unknown candidate selector: %s", settings.Selector)
+	}
+
+	names := []string{generator.primary}
+	for _, variant := range generator.variants {
+		names = append(names, variant.name)
+	}
+	logger.Info("multi-candidate translation enabled",
+		zap.Strings("candidates", names),
+		zap.Bool("llm_selector", generator.judge != nil))
+	return generator, nil
+}
+
+// candidateSpecs 列出额外候选的初始翻译模型和温度，最多 Count-1 个，且互不相同也不同于原样翻译。
+// 配置的模型和温度按组合依次使用，组合用完时不再增加候选；两者都为空时先使用步骤集中其他 LLM 模型，
+// 再逐个提高初始翻译模型的温度
+func candidateSpecs(stepSet config.StepSetConfigV2, modelConfigs map[string]config.ModelConfig) []candidateSpec {
+	settings := stepSet.Candidates
+	if settings.Count < 2 {
+		return nil
+	}
+	initial := stepSet.Steps[0]
+
+	var specs []candidateSpec
+	seen := map[string]bool{candidateName(initial.ModelName, nil): true}
+	// add 加入一个未出现过的候选，候选数已满时返回 false
+	add := func(spec candidateSpec) bool {
+		model := initial.ModelName
+		if spec.model != "" {
+			model = spec.model
+		}
+		spec.name = candidateName(model, spec.temperature)
+		if !seen[spec.name] {
+			seen[spec.name] = true
+			specs = append(specs, spec)
+		}
+		return len(specs) < settings.Count-1
+	}
+
+	if len(settings.Models) == 0 && len(settings.Temperatures) == 0 {
+		for _, step := range stepSet.Steps[1:] {
+			if modelConfigs[step.ModelName].IsLLM && !add(candidateSpec{model: step.ModelName}) {
+				return specs
+			}
+		}
+		base := modelConfigs[initial.ModelName].Temperature
+		for i := 1; i < settings.Count; i++ {
+			temperature := math.Min(base+candidateTemperatureStep*float64(i), maxCandidateTemperature)
+			temperature = math.Round(temperature*100) / 100
+			if !add(candidateSpec{temperature: &temperature}) {
+				break
+			}
+		}
+		return specs
+	}
+
+	models := settings.Models
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	temperatures := []*float64{nil}
+	if len(settings.Temperatures) > 0 {
+		temperatures = temperatures[:0]
+		for i := range settings.Temperatures {
+			temperatures = append(temperatures, &settings.Temperatures[i])
+		}
+	}
+	for _, temperature := range temperatures {
+		for _, model := range models {
+			if !add(candidateSpec{model: model, temperature: temperature}) {
+				return specs
+			}
+		}
+	}
+	return specs
+}
+
+// candidateName 候选的名称：模型名，改变温度时加上 "@温度"
+func candidateName(model string, temperature *float64) string {
+	if temperature == nil {
+		return model
+	}
+	return model + "@" + strconv.FormatFloat(*temperature, 'f', -1, 64)
+}
+
+// candidateRun 正在后台生成的额外候选
+type candidateRun struct {
+	wg        sync.WaitGroup
+	outputs   []string
+	errs      []error
+	steps     [][]translation.StepResult // 各额外候选的步骤结果
+	latencies []time.Duration
+}
+
+// start 在后台用各额外候选的翻译服务翻译组合文本，与步骤集原样翻译同时进行。
+// 各额外候选的步骤结果单独记录，不混入步骤集原样翻译的步骤输出
+func (g *CandidateGenerator) start(ctx context.Context, text string) *candidateRun {
+	run := &candidateRun{
+		outputs:   make([]string, len(g.variants)),
+		errs:      make([]error, len(g.variants)),
+		steps:     make([][]translation.StepResult, len(g.variants)),
+		latencies: make([]time.Duration, len(g.variants)),
+	}
+	for i, variant := range g.variants {
+		run.wg.Add(1)
+		go func(i int, variant candidateVariant) {
+			defer run.wg.Done()
+			variantCtx := translation.WithStepResultRecorder(ctx, func(step translation.StepResult) {
+				run.steps[i] = append(run.steps[i], step)
+			})
+			startTime := time.Now()
+			run.outputs[i], run.errs[i] = variant.service.TranslateText(variantCtx, text)
+			run.latencies[i] = time.Since(startTime)
+		}(i, variant)
+	}
+	return run
+}
+
+// attachWinnerSteps 采用额外候选的节点改用该候选的步骤输出
+func (g *CandidateGenerator) attachWinnerSteps(group *document.NodeGroup, run *candidateRun, preserveManager *translation.PreserveManager, nodeStepOutputs map[int]map[string]string) {
+	for i, variant := range g.variants {
+		var outputs map[int]map[string]string
+		for _, node := range group.Nodes {
+			if selected, _ := node.Metadata[NodeSelectedCandidateKey].(string); selected != variant.name {
+				continue
+			}
+			if outputs == nil {
+				outputs = splitStepOutputs(run.steps[i], preserveManager)
+			}
+			if nodeOutputs, ok := outputs[node.ID]; ok {
+				nodeStepOutputs[node.ID] = nodeOutputs
+			}
+		}
+	}
+}
+
+// recordCandidateStats 等待额外候选完成，把各额外候选的用量记入统计，模型名为候选名称。
+// 因原样翻译失败而取消的候选不记录
+func (bt *BatchTranslator) recordCandidateStats(run *candidateRun) {
+	run.wg.Wait()
+	if bt.statsManager == nil {
+		return
+	}
+
+	for i, variant := range bt.candidates.variants {
+		err := run.errs[i]
+		if errors.Is(err, context.Canceled) {
+			continue
+		}
+		result := stats.RequestResult{
+			Success: err == nil,
+			Latency: run.latencies[i],
+		}
+		for _, step := range run.steps[i] {
+			result.TokensIn += step.TokensIn
+			result.TokensOut += step.TokensOut
+			result.Cost += step.Cost
+		}
+		if err != nil {
+			result.ErrorType = bt.classifyTranslationError(err)
+		}
+		bt.statsManager.RecordRequest(variant.provider, variant.name, result)
+	}
+}
+
+// selectWinners 等待额外候选完成，逐节点选出最佳译文，返回由各节点最佳译文组成的节点标记文本。
+// 采用的候选名称和落选的候选记录到节点元数据中；没有任何节点得到候选时返回原译文
+func (g *CandidateGenerator) selectWinners(ctx context.Context, group *document.NodeGroup, primaryText string, run *candidateRun, preserveManager *translation.PreserveManager) string {
+	run.wg.Wait()
+
+	type candidateOutput struct {
+		name     string
+		segments map[int]string
+	}
+	outputs := []candidateOutput{{name: g.primary, segments: parseNodeSegments(primaryText)}}
+	for i, variant := range g.variants {
+		if run.errs[i] != nil {
+			g.logger.Warn("candidate translation failed",
+				zap.String("candidate", variant.name),
+				zap.Error(run.errs[i]))
+			continue
+		}
+		outputs = append(outputs, candidateOutput{name: variant.name, segments: parseNodeSegments(run.outputs[i])})
+	}
+
+	// 先为各节点收集候选并启发式评分，再对整组只调用一次 LLM 选择器
+	type nodeCandidates struct {
+		node       *document.NodeInfo
+		candidates []TranslationCandidate
+		segments   []string
+	}
+	var nodes []nodeCandidates
+	for _, node := range group.Nodes {
+		if isContext, ok := node.Metadata["is_context"].(bool); ok && isContext {
+			continue
+		}
+
+		entry := nodeCandidates{node: node}
+		for _, output := range outputs {
+			if segment, ok := output.segments[node.ID]; ok {
+				entry.candidates = append(entry.candidates, TranslationCandidate{
+					Variant:     output.name,
+					Translation: preserveManager.Restore(segment),
+				})
+				entry.segments = append(entry.segments, segment)
+			}
+		}
+		if len(entry.candidates) == 0 {
+			continue
+		}
+		scoreCandidates(node.OriginalText, entry.candidates, g.glossary)
+		nodes = append(nodes, entry)
+	}
+
+	if g.judge != nil {
+		judged := make(map[int][]TranslationCandidate, len(nodes))
+		sources := make(map[int]string, len(nodes))
+		for _, entry := range nodes {
+			judged[entry.node.ID] = entry.candidates
+			sources[entry.node.ID] = entry.node.OriginalText
+		}
+		g.judgeGroup(ctx, sources, judged)
+	}
+
+	var builder strings.Builder
+	for _, entry := range nodes {
+		node, candidates := entry.node, entry.candidates
+		order := rankCandidates(candidates)
+		winner := order[0]
+		runnersUp := make([]TranslationCandidate, 0, len(order)-1)
+		for _, i := range order[1:] {
+			runnersUp = append(runnersUp, candidates[i])
+		}
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]interface{})
+		}
+		node.Metadata[NodeSelectedCandidateKey] = candidates[winner].Variant
+		node.Metadata[NodeCandidatesKey] = runnersUp
+
+		g.logger.Debug("selected translation candidate",
+			zap.Int("nodeID", node.ID),
+			zap.String("candidate", candidates[winner].Variant),
+			zap.Float64("score", candidates[winner].Score),
+			zap.Int("candidates", len(candidates)))
+
+		if builder.Len() > 0 {
+			builder.WriteString("\n\n")
+		}
+		fmt.Fprintf(&builder, "@@NODE_START_%d@@\n%s\n@@NODE_END_%d@@", node.ID, entry.segments[winner], node.ID)
+	}
+
+	if builder.Len() == 0 {
+		return primaryText
+	}
+	return builder.String()
+}
+
+// rankCandidates 返回按评分从高到低排列的候选下标，评分相同时靠前的候选优先
+func rankCandidates(candidates []TranslationCandidate) []int {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return candidates[order[a]].Score > candidates[order[b]].Score
+	})
+	return order
+}
+
+// judgeGroup 让 LLM 用一次请求并排比较整组各节点的非空候选，评分换算为 0-1 后代替启发式评分。
+// 评审失败或回复中缺少某个节点时，该节点保留启发式评分
+func (g *CandidateGenerator) judgeGroup(ctx context.Context, sources map[int]string, nodeCandidates map[int][]TranslationCandidate) {
+	var segments []experiment.JudgeSegment
+	judged := make(map[int][]int)
+	for nodeID, candidates := range nodeCandidates {
+		segment := experiment.JudgeSegment{ID: nodeID, Source: sources[nodeID]}
+		for i, candidate := range candidates {
+			if strings.TrimSpace(candidate.Translation) != "" {
+				segment.Candidates = append(segment.Candidates, candidate.Translation)
+				judged[nodeID] = append(judged[nodeID], i)
+			}
+		}
+		if len(segment.Candidates) >= 2 {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return
+	}
+	sort.Slice(segments, func(a, b int) bool { return segments[a].ID < segments[b].ID })
+
+	scores, err := g.judge.ScoreBatch(ctx, segments)
+	if err != nil {
+		g.logger.Warn("candidate selector failed, using heuristic scores", zap.Error(err))
+		return
+	}
+	for _, segment := range segments {
+		nodeScores, ok := scores[segment.ID]
+		if !ok {
+			g.logger.Warn("candidate selector returned no valid scores for node, using heuristic scores",
+				zap.Int("nodeID", segment.ID))
+			continue
+		}
+		candidates := nodeCandidates[segment.ID]
+		for k, i := range judged[segment.ID] {
+			candidates[i].Score = nodeScores[k].Score / 10
+			candidates[i].Reason = nodeScores[k].Reason
+		}
+	}
+}
+
+// scoreCandidates 启发式评分：格式完整性（沿用 experiment 包的启发式评分）、词汇表遵循，
+// 以及长度比例与各候选中位数的接近程度，加权合计为 0-1
+func scoreCandidates(source string, candidates []TranslationCandidate, glossary map[string]string) {
+	sourceLength := utf8.RuneCountInString(strings.TrimSpace(source))
+	ratios := make([]float64, len(candidates))
+	var nonEmpty []float64
+	for i, candidate := range candidates {
+		if length := utf8.RuneCountInString(strings.TrimSpace(candidate.Translation)); length > 0 && sourceLength > 0 {
+			ratios[i] = float64(length) / float64(sourceLength)
+			nonEmpty = append(nonEmpty, ratios[i])
+		}
+	}
+	median := medianOf(nonEmpty)
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		format := experiment.HeuristicScore(source, candidate.Translation)
+		candidate.Issues = append([]string(nil), format.Issues...)
+		if strings.TrimSpace(candidate.Translation) == "" {
+			candidate.Score = 0
+			continue
+		}
+
+		glossaryScore, missing := glossaryAdherence(source, candidate.Translation, glossary)
+		if missing > 0 {
+			candidate.Issues = append(candidate.Issues, IssueGlossary)
+		}
+
+		lengthScore := 1.0
+		if ratios[i] > 0 && median > 0 {
+			lengthScore = math.Min(ratios[i], median) / math.Max(ratios[i], median)
+		}
+		if lengthScore < minCandidateLengthScore {
+			candidate.Issues = append(candidate.Issues, IssueLengthRatio)
+		}
+
+		candidate.Score = candidateFormatWeight*format.Heuristic +
+			candidateGlossaryWeight*glossaryScore +
+			candidateLengthWeight*lengthScore
+	}
+}
+
+// glossaryAdherence 返回原文中出现的词汇表术语在译文中使用规定译法的比例，以及没有使用的术语数。
+// 原文中没有词汇表术语时比例为 1
+func glossaryAdherence(source, translated string, glossary map[string]string) (float64, int) {
+	lowerSource := strings.ToLower(source)
+	lowerTranslated := strings.ToLower(translated)
+	applicable, matched := 0, 0
+	for term, target := range glossary {
+		if term == "" || target == "" || !strings.Contains(lowerSource, strings.ToLower(term)) {
+			continue
+		}
+		applicable++
+		if strings.Contains(lowerTranslated, strings.ToLower(target)) {
+			matched++
+		}
+	}
+	if applicable == 0 {
+		return 1, 0
+	}
+	return float64(matched) / float64(applicable), applicable - matched
+}
+
+// medianOf 返回中位数，没有数据时返回 0
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// parseNodeSegments 按节点标记拆分译文，返回节点 ID 到译文的映射
+func parseNodeSegments(text string) map[int]string {
+	segments := make(map[int]string)
+	match, _ := nodeSegmentPattern.FindStringMatch(text)
+	for match != nil {
+		groups := match.Groups()
+		if nodeID, err := strconv.Atoi(groups[1].String()); err == nil {
+			segments[nodeID] = strings.TrimSpace(groups[2].String())
+		}
+		match, _ = nodeSegmentPattern.FindNextMatch(match)
+	}
+	return segments
+}
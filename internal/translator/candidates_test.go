@@ -0,0 +1,243 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/internal/experiment"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/stats"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// candidateService 按节点 ID 返回预设译文的模拟翻译服务，没有预设译文的节点不出现在输出中
+type candidateService struct {
+	translations map[int]string
+}
+
+func (s *candidateService) TranslateText(ctx context.Context, text string) (string, error) {
+	var parts []string
+	for id := range parseNodeSegments(text) {
+		if translated, ok := s.translations[id]; ok {
+			parts = append(parts, fmt.Sprintf("@@NODE_START_%d@@\n%s\n@@NODE_END_%d@@", id, translated, id))
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func TestCandidateSpecs(t *testing.T) {
+	models := map[string]config.ModelConfig{
+		"gpt-4o":  {Temperature: 0.7, IsLLM: true},
+		"claude":  {Temperature: 0.5, IsLLM: true},
+		"deepl":   {},
+		"gpt-4o2": {IsLLM: true},
+	}
+	stepSet := config.StepSetConfigV2{Steps: []config.StepConfigV2{
+		{ModelName: "gpt-4o"}, {ModelName: "deepl"}, {ModelName: "claude"},
+	}}
+
+	names := func(specs []candidateSpec) []string {
+		var result []string
+		for _, spec := range specs {
+			result = append(result, spec.name)
+		}
+		return result
+	}
+
+	// 默认先使用步骤集中其他 LLM 模型，用完后提高初始翻译模型的温度
+	stepSet.Candidates = config.CandidatesConfig{Count: 3}
+	assert.Equal(t, []string{"claude", "gpt-4o@1"}, names(candidateSpecs(stepSet, models)))
+
+	// 没有其他 LLM 模型时逐个提高温度
+	stepSet.Steps = stepSet.Steps[:2]
+	specs := candidateSpecs(stepSet, models)
+	assert.Equal(t, []string{"gpt-4o@1", "gpt-4o@1.3"}, names(specs))
+	assert.Empty(t, specs[0].model)
+
+	// 温度达到上限后不再重复候选
+	stepSet.Candidates = config.CandidatesConfig{Count: 8}
+	assert.Equal(t, []string{"gpt-4o@1", "gpt-4o@1.3", "gpt-4o@1.6", "gpt-4o@1.9", "gpt-4o@2"}, names(candidateSpecs(stepSet, models)))
+
+	// 配置的模型和温度按组合使用，组合用完时不再增加候选
+	stepSet.Candidates = config.CandidatesConfig{Count: 4, Models: []string{"gpt-4o2"}, Temperatures: []float64{0.2, 0.9}}
+	assert.Equal(t, []string{"gpt-4o2@0.2", "gpt-4o2@0.9"}, names(candidateSpecs(stepSet, models)))
+
+	stepSet.Candidates = config.CandidatesConfig{Count: 4, Models: []string{"gpt-4o2", "claude"}, Temperatures: []float64{0.2, 0.9}}
+	assert.Equal(t, []string{"gpt-4o2@0.2", "claude@0.2", "gpt-4o2@0.9"}, names(candidateSpecs(stepSet, models)))
+
+	// 与原样翻译相同的候选被跳过
+	stepSet.Candidates = config.CandidatesConfig{Count: 3, Models: []string{"gpt-4o", "gpt-4o"}}
+	assert.Empty(t, candidateSpecs(stepSet, models))
+}
+
+func TestScoreCandidates(t *testing.T) {
+	source := "Click **Save** to store the file on the server."
+	candidates := []TranslationCandidate{
+		{Variant: "a", Translation: "点击保存以把档案存储到服务器。"},
+		{Variant: "b", Translation: "点击**保存**以把文件存储到服务器上。"},
+		{Variant: "c", Translation: ""},
+		{Variant: "d", Translation: "点击**保存**以把文件存储到服务器上，并且在完成后通知所有相关的用户和管理员。"},
+	}
+	scoreCandidates(source, candidates, map[string]string{"file": "文件", "server": "服务器", "cloud": "云"})
+
+	assert.Equal(t, []string{experiment.IssueFormat, IssueGlossary}, candidates[0].Issues)
+	assert.Empty(t, candidates[1].Issues)
+	assert.Equal(t, []string{experiment.IssueEmpty}, candidates[2].Issues)
+	assert.Zero(t, candidates[2].Score)
+	assert.Equal(t, []string{IssueLengthRatio}, candidates[3].Issues)
+	assert.Greater(t, candidates[1].Score, candidates[3].Score)
+	assert.Greater(t, candidates[3].Score, candidates[0].Score)
+
+	score, missing := glossaryAdherence(source, "把文件存储", map[string]string{"File": "文件", "Server": "服务器"})
+	assert.Equal(t, 0.5, score)
+	assert.Equal(t, 1, missing)
+}
+
+func TestBatchTranslatorSelectsCandidates(t *testing.T) {
+	primary := &candidateService{translations: map[int]string{
+		1: "点击保存以存储档案。",
+		2: "你今天过得怎么样，我的朋友？",
+	}}
+	variant := &candidateService{translations: map[int]string{
+		1: "点击**保存**以存储文件。",
+	}}
+
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 1000, Concurrency: 1}, primary, zap.NewNop(), nil, nil)
+	bt.SetCandidateGenerator(&CandidateGenerator{
+		primary:  "gpt-4o",
+		variants: []candidateVariant{{name: "gpt-4o@1", service: variant}},
+		glossary: map[string]string{"file": "文件"},
+		logger:   zap.NewNop(),
+	})
+
+	nodes := []*document.NodeInfo{
+		{ID: 1, OriginalText: "Click **Save** to store the file.", Status: document.NodeStatusPending},
+		{ID: 2, OriginalText: "How are you doing today, my friend?", Status: document.NodeStatusPending},
+	}
+	require.NoError(t, bt.TranslateNodes(context.Background(), nodes))
+
+	assert.Equal(t, document.NodeStatusSuccess, nodes[0].Status)
+	assert.Equal(t, "点击**保存**以存储文件。", nodes[0].TranslatedText)
+	assert.Equal(t, "gpt-4o@1", nodes[0].Metadata[NodeSelectedCandidateKey])
+	runnersUp, ok := nodes[0].Metadata[NodeCandidatesKey].([]TranslationCandidate)
+	require.True(t, ok)
+	require.Len(t, runnersUp, 1)
+	assert.Equal(t, "gpt-4o", runnersUp[0].Variant)
+	assert.Equal(t, "点击保存以存储档案。", runnersUp[0].Translation)
+	assert.Contains(t, runnersUp[0].Issues, IssueGlossary)
+
+	// 只有一个候选的节点直接采用
+	assert.Equal(t, "你今天过得怎么样，我的朋友？", nodes[1].TranslatedText)
+	assert.Equal(t, "gpt-4o", nodes[1].Metadata[NodeSelectedCandidateKey])
+	assert.Empty(t, nodes[1].Metadata[NodeCandidatesKey])
+}
+
+// judgeProvider 记录请求并返回预设评审回复的模拟提供商
+type judgeProvider struct {
+	reply    string
+	requests []*translation.ProviderRequest
+}
+
+func (p *judgeProvider) Translate(ctx context.Context, req *translation.ProviderRequest) (*translation.ProviderResponse, error) {
+	p.requests = append(p.requests, req)
+	return &translation.ProviderResponse{Text: p.reply}, nil
+}
+
+func (p *judgeProvider) GetName() string     { return "judge" }
+func (p *judgeProvider) SupportsSteps() bool { return false }
+
+func TestBatchTranslatorJudgesCandidatesPerGroup(t *testing.T) {
+	primary := &candidateService{translations: map[int]string{1: "保存文件。", 2: "打开文件。", 3: "关闭文件。"}}
+	variant := &candidateService{translations: map[int]string{1: "存储文件。", 2: "开启文件。", 3: "关掉文件。"}}
+	judge := &judgeProvider{reply: `{"segments": [
+		{"segment": 1, "scores": [{"candidate": "A", "score": 3}, {"candidate": "B", "score": 9, "reason": "more natural"}]},
+		{"segment": 2, "scores": [{"candidate": "A", "score": 8}, {"candidate": "B", "score": 5}]}
+	]}`}
+
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 1000, Concurrency: 1}, primary, zap.NewNop(), nil, nil)
+	bt.SetCandidateGenerator(&CandidateGenerator{
+		primary:  "gpt-4o",
+		variants: []candidateVariant{{name: "claude", service: variant}},
+		judge:    experiment.NewJudge(judge, "English", "Chinese"),
+		logger:   zap.NewNop(),
+	})
+
+	nodes := []*document.NodeInfo{
+		{ID: 1, OriginalText: "Save the file.", Status: document.NodeStatusPending},
+		{ID: 2, OriginalText: "Open the file.", Status: document.NodeStatusPending},
+		{ID: 3, OriginalText: "Close the file.", Status: document.NodeStatusPending},
+	}
+	require.NoError(t, bt.TranslateNodes(context.Background(), nodes))
+
+	// 整组只请求一次评审，各节点按 ID 标记
+	require.Len(t, judge.requests, 1)
+	assert.Contains(t, judge.requests[0].Text, "SEGMENT 1")
+	assert.Contains(t, judge.requests[0].Text, "SEGMENT 3")
+
+	assert.Equal(t, "存储文件。", nodes[0].TranslatedText)
+	assert.Equal(t, "claude", nodes[0].Metadata[NodeSelectedCandidateKey])
+	assert.Equal(t, "打开文件。", nodes[1].TranslatedText)
+	// 评审回复中缺少的节点保留启发式评分，评分相同时采用靠前的候选
+	assert.Equal(t, "gpt-4o", nodes[2].Metadata[NodeSelectedCandidateKey])
+}
+
+// usageProvider 在 recordingProvider 的回复中加上用量
+type usageProvider struct {
+	*recordingProvider
+}
+
+func (p usageProvider) Translate(ctx context.Context, req *translation.ProviderRequest) (*translation.ProviderResponse, error) {
+	resp, err := p.recordingProvider.Translate(ctx, req)
+	if resp != nil {
+		resp.TokensIn, resp.TokensOut = 10, 20
+	}
+	return resp, err
+}
+
+func TestBatchTranslatorRecordsCandidateUsage(t *testing.T) {
+	primary := &candidateService{translations: map[int]string{
+		1: "点击保存以存储档案。",
+		2: "你今天过得怎么样，我的朋友？",
+	}}
+	variant := newProviderService(t, usageProvider{&recordingProvider{translations: map[string]string{
+		"Click **Save** to store the file.":   "点击**保存**以存储文件。",
+		"How are you doing today, my friend?": "",
+	}}})
+	statsManager := stats.NewStatsManager("", zap.NewNop())
+
+	bt := NewBatchTranslator(TranslatorConfig{ChunkSize: 1000, Concurrency: 1}, primary, zap.NewNop(), statsManager, nil)
+	bt.SetCandidateGenerator(&CandidateGenerator{
+		primary:  "gpt-4o",
+		variants: []candidateVariant{{name: "claude@1", provider: "anthropic", service: variant}},
+		glossary: map[string]string{"file": "文件"},
+		logger:   zap.NewNop(),
+	})
+	stepOutputs := make(map[int]map[string]string)
+	bt.SetNodeResultCallback(func(node *document.NodeInfo, outputs map[string]string) {
+		stepOutputs[node.ID] = outputs
+	})
+
+	nodes := []*document.NodeInfo{
+		{ID: 1, OriginalText: "Click **Save** to store the file.", Status: document.NodeStatusPending},
+		{ID: 2, OriginalText: "How are you doing today, my friend?", Status: document.NodeStatusPending},
+	}
+	require.NoError(t, bt.TranslateNodes(context.Background(), nodes))
+
+	// 额外候选的用量按候选名称记入统计
+	usage := statsManager.GetStats("anthropic", "claude@1")
+	assert.Equal(t, int64(1), usage.TotalRequests)
+	assert.Equal(t, int64(10), usage.TotalTokensIn)
+	assert.Equal(t, int64(20), usage.TotalTokensOut)
+
+	// 采用额外候选的节点使用该候选的步骤输出，其余节点不受影响
+	assert.Equal(t, "claude@1", nodes[0].Metadata[NodeSelectedCandidateKey])
+	assert.Equal(t, map[string]string{"initial": "点击**保存**以存储文件。"}, stepOutputs[1])
+	assert.Equal(t, "gpt-4o", nodes[1].Metadata[NodeSelectedCandidateKey])
+	assert.Nil(t, stepOutputs[2])
+}
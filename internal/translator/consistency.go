@@ -0,0 +1,258 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+)
+
+const (
+	// maxTermLength 术语的最大字符数
+	maxTermLength = 40
+	// minTermLength 术语的最小字符数，更短的片段在其他文本中匹配时误报太多
+	minTermLength = 3
+)
+
+// 不一致的类型
+const (
+	InconsistencySegment = "segment" // 相同的短片段译法不同
+	InconsistencyTerm    = "term"    // 文本中的术语没有使用已有的译法
+)
+
+// ConsistencyStore 一次运行中所有文件共享的一致性记录。
+// 记录短片段（标题、界面标签、图注等）和术语的译法，为之后翻译的节点提供参考，
+// 并记录仍然出现的不一致译法
+type ConsistencyStore struct {
+	maxSegmentLength int
+	maxTermWords     int
+	maxPromptEntries int
+
+	mu              sync.Mutex
+	entries         map[string]*consistencyEntry
+	inconsistencies []*Inconsistency
+	reported        map[string]bool
+}
+
+// consistencyEntry 一个短片段第一次使用的译法
+type consistencyEntry struct {
+	source   string
+	target   string
+	location string
+	term     bool
+}
+
+// Inconsistency 未能避免的不一致译法
+type Inconsistency struct {
+	Kind             string `json:"kind"`
+	Source           string `json:"source"`
+	Expected         string `json:"expected"`          // 第一次使用的译法
+	ExpectedLocation string `json:"expected_location"` // 第一次出现的位置
+	Actual           string `json:"actual"`            // 本次的译文
+	Location         string `json:"location"`          // 本次出现的位置
+}
+
+// NewConsistencyStore 创建一致性记录，maxSegmentLength 不大于 0 时返回 nil（不启用）
+func NewConsistencyStore(maxSegmentLength, maxTermWords, maxPromptEntries int) *ConsistencyStore {
+	if maxSegmentLength <= 0 {
+		return nil
+	}
+	return &ConsistencyStore{
+		maxSegmentLength: maxSegmentLength,
+		maxTermWords:     maxTermWords,
+		maxPromptEntries: maxPromptEntries,
+		entries:          make(map[string]*consistencyEntry),
+		reported:         make(map[string]bool),
+	}
+}
+
+// Notes 返回与 texts 相关的已有译法，作为提示词说明：完全相同的短片段和其中出现的术语。
+// 没有相关译法时返回空字符串
+func (s *ConsistencyStore) Notes(texts []string) string {
+	if s == nil || s.maxPromptEntries <= 0 {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return ""
+	}
+
+	selected := make(map[string]bool)
+	var segments, terms []*consistencyEntry
+	for _, text := range texts {
+		key := normalizeSegment(text)
+		if entry, ok := s.entries[key]; ok && !selected[key] {
+			selected[key] = true
+			segments = append(segments, entry)
+		}
+	}
+	for key, entry := range s.entries {
+		if !entry.term || selected[key] {
+			continue
+		}
+		for _, text := range texts {
+			if containsTerm(text, entry.source) {
+				selected[key] = true
+				terms = append(terms, entry)
+				break
+			}
+		}
+	}
+	// 较长的术语更具体，优先保留
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i].source) != len(terms[j].source) {
+			return len(terms[i].source) > len(terms[j].source)
+		}
+		return terms[i].source < terms[j].source
+	})
+
+	entries := append(segments, terms...)
+	if len(entries) == 0 {
+		return ""
+	}
+	if len(entries) > s.maxPromptEntries {
+		entries = entries[:s.maxPromptEntries]
+	}
+
+	var notes strings.Builder
+	notes.WriteString("Translations already used elsewhere in this project. Translate the same text and terms the same way unless the context clearly requires otherwise:")
+	for _, entry := range entries {
+		fmt.Fprintf(&notes, "\n- %q → %q", entry.source, entry.target)
+	}
+	return notes.String()
+}
+
+// Record 记录成功翻译的节点：新的短片段记录其译法，已记录的短片段和其中的术语
+// 译法不同时记为不一致。location 为节点所在的文件
+func (s *ConsistencyStore) Record(location string, node *document.NodeInfo) {
+	if s == nil || node.Status != document.NodeStatusSuccess {
+		return
+	}
+	source := normalizeSegment(node.OriginalText)
+	target := normalizeSegment(node.TranslatedText)
+	if source == "" || target == "" {
+		return
+	}
+	if node.Path != "" {
+		location = fmt.Sprintf("%s (%s)", location, node.Path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[source]
+	switch {
+	case exists:
+		if entry.target != target {
+			s.report(&Inconsistency{
+				Kind:             InconsistencySegment,
+				Source:           source,
+				Expected:         entry.target,
+				ExpectedLocation: entry.location,
+				Actual:           target,
+				Location:         location,
+			})
+		}
+	case s.isSegment(node.OriginalText):
+		s.entries[source] = &consistencyEntry{
+			source:   source,
+			target:   target,
+			location: location,
+			term:     s.isTerm(source),
+		}
+	}
+
+	for key, term := range s.entries {
+		if !term.term || key == source || !containsTerm(source, term.source) {
+			continue
+		}
+		if !strings.Contains(target, term.target) {
+			s.report(&Inconsistency{
+				Kind:             InconsistencyTerm,
+				Source:           term.source,
+				Expected:         term.target,
+				ExpectedLocation: term.location,
+				Actual:           target,
+				Location:         location,
+			})
+		}
+	}
+}
+
+// Inconsistencies 返回记录的不一致译法
+func (s *ConsistencyStore) Inconsistencies() []*Inconsistency {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Inconsistency(nil), s.inconsistencies...)
+}
+
+// report 记录不一致，同一位置的同一片段只记录一次
+func (s *ConsistencyStore) report(inconsistency *Inconsistency) {
+	key := inconsistency.Kind + "\x00" + inconsistency.Source + "\x00" + inconsistency.Location
+	if s.reported[key] {
+		return
+	}
+	s.reported[key] = true
+	s.inconsistencies = append(s.inconsistencies, inconsistency)
+}
+
+// isSegment 判断原文是否为需要记录的短片段：单行且不超过最大长度
+func (s *ConsistencyStore) isSegment(text string) bool {
+	text = strings.TrimSpace(text)
+	return !strings.Contains(text, "\n") && utf8.RuneCountInString(text) <= s.maxSegmentLength
+}
+
+// isTerm 判断短片段是否可以作为术语在其他文本中匹配：词数和长度有限，
+// 不含标记、占位符和代码，且包含字母
+func (s *ConsistencyStore) isTerm(source string) bool {
+	length := utf8.RuneCountInString(source)
+	if length < minTermLength || length > maxTermLength || len(strings.Fields(source)) > s.maxTermWords {
+		return false
+	}
+	if strings.ContainsAny(source, "<>{}[]`$@|*_#") {
+		return false
+	}
+	return strings.IndexFunc(source, unicode.IsLetter) >= 0
+}
+
+// normalizeSegment 去掉首尾空白并合并连续空白
+func normalizeSegment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// containsTerm 判断 text 中是否出现 term，字母或数字开头、结尾的术语需要在词边界上
+func containsTerm(text, term string) bool {
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], term)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(term)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !(isWordRune(first) && isWordRune(before)) && !(isWordRune(last) && isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isWordRune 判断字符是否属于以空格分词的文字中的单词
+func isWordRune(r rune) bool {
+	if r == utf8.RuneError || unicode.Is(unicode.Han, r) || unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
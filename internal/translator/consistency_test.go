@@ -0,0 +1,84 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConsistencyStore(t *testing.T) {
+	store := NewConsistencyStore(80, 4, 10)
+
+	translated := func(source, target, path string) *document.NodeInfo {
+		return &document.NodeInfo{OriginalText: source, TranslatedText: target, Path: path, Status: document.NodeStatusSuccess}
+	}
+
+	// 第2章的标题和界面标签
+	store.Record("ch02.md", translated("Dependency Injection", "依赖注入", "h2"))
+	store.Record("ch02.md", translated("  Save   changes ", "保存更改", ""))
+	store.Record("ch02.md", translated("A long paragraph\nspanning lines.", "一段\n跨行的文字。", ""))
+	assert.Empty(t, store.Notes([]string{"A long paragraph\nspanning lines."}), "multi-line text is not recorded")
+
+	// 之后的节点获得相同片段和其中术语的已有译法
+	notes := store.Notes([]string{"Save changes", "We use Dependency Injection here.", "Dependency Injections"})
+	assert.Contains(t, notes, `"Save changes" → "保存更改"`)
+	assert.Contains(t, notes, `"Dependency Injection" → "依赖注入"`)
+	assert.Empty(t, store.Notes([]string{"Nothing related"}))
+	assert.Empty(t, store.Notes([]string{"MyDependency Injection"}), "terms match on word boundaries")
+
+	// 第14章沿用译法时没有不一致，不同译法被报告
+	store.Record("ch14.md", translated("Dependency Injection makes testing easy.", "依赖注入让测试变得容易。", ""))
+	store.Record("ch14.md", translated("Save changes", "保存修改", "button"))
+	store.Record("ch14.md", translated("Configure Dependency Injection first.", "首先配置依赖项注入。", ""))
+
+	inconsistencies := store.Inconsistencies()
+	if assert.Len(t, inconsistencies, 2) {
+		assert.Equal(t, &Inconsistency{
+			Kind:             InconsistencySegment,
+			Source:           "Save changes",
+			Expected:         "保存更改",
+			ExpectedLocation: "ch02.md",
+			Actual:           "保存修改",
+			Location:         "ch14.md (button)",
+		}, inconsistencies[0])
+		assert.Equal(t, InconsistencyTerm, inconsistencies[1].Kind)
+		assert.Equal(t, "Dependency Injection", inconsistencies[1].Source)
+		assert.Equal(t, "ch02.md (h2)", inconsistencies[1].ExpectedLocation)
+	}
+
+	// 未启用时所有方法都可以安全调用
+	var disabled *ConsistencyStore
+	disabled.Record("a.md", translated("Title", "标题", ""))
+	assert.Empty(t, disabled.Notes([]string{"Title"}))
+	assert.Nil(t, disabled.Inconsistencies())
+}
+
+func TestBatchTranslatorSendsConsistencyNotesToProvider(t *testing.T) {
+	provider := &recordingProvider{translations: map[string]string{
+		"Dependency Injection":              "依赖注入",
+		"We use Dependency Injection here.": "我们在这里使用依赖注入。",
+	}}
+	bt := NewBatchTranslator(TranslatorConfig{
+		ChunkSize:                   1000,
+		Concurrency:                 1,
+		ConsistencyEnabled:          true,
+		ConsistencyMaxSegmentLength: 80,
+		ConsistencyMaxTermWords:     4,
+		ConsistencyMaxPromptEntries: 10,
+	}, newProviderService(t, provider), zap.NewNop(), nil, nil)
+
+	title := []*document.NodeInfo{{ID: 1, OriginalText: "Dependency Injection", Status: document.NodeStatusPending}}
+	require.NoError(t, bt.TranslateNodes(context.Background(), title))
+	require.Equal(t, "依赖注入", title[0].TranslatedText)
+
+	body := []*document.NodeInfo{{ID: 2, OriginalText: "We use Dependency Injection here.", Status: document.NodeStatusPending}}
+	require.NoError(t, bt.TranslateNodes(context.Background(), body))
+
+	// 第二次请求的附加指令中带有第一次记录的术语译法
+	require.Len(t, provider.requests, 2)
+	assert.Contains(t, provider.requests[1].Metadata["instruction"], `"Dependency Injection" → "依赖注入"`)
+}
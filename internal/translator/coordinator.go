@@ -13,6 +13,7 @@ import (
 	"github.com/nerdneilsfield/go-translator-agent/internal/formatfix"
 	"github.com/nerdneilsfield/go-translator-agent/internal/formatfix/loader"
 	"github.com/nerdneilsfield/go-translator-agent/internal/formatter"
+	"github.com/nerdneilsfield/go-translator-agent/internal/localeformat"
 	"github.com/nerdneilsfield/go-translator-agent/internal/progress"
 	"github.com/nerdneilsfield/go-translator-agent/internal/stats"
 	providerStats "github.com/nerdneilsfield/go-translator-agent/pkg/providers/stats"
@@ -28,6 +29,49 @@ type CoordinatorConfig struct {
 	SourceLang         string // 源语言（用于文档处理元数据）
 	TargetLang         string // 目标语言（用于文档处理元数据）
 
+	// PPTX 处理配置
+	PPTXOverflowMode      string  // 译文溢出处理模式: "none"、"shrink" 或 "resize"
+	PPTXOverflowThreshold float64 // 触发溢出处理的宽度比例
+	PPTXMinFontScale      float64 // shrink 模式下的最小字号缩放比例
+
+	// XLSX 处理配置
+	XLSXSkipSheets          []string // 不翻译的工作表
+	XLSXSkipColumns         []string // 不翻译的列
+	XLSXColumnMappings      []string // 列映射（源列:目标列）
+	XLSXTranslateSheetNames bool     // 是否翻译工作表名称
+	XLSXTranslateComments   bool     // 是否翻译批注
+
+	// 字幕处理配置
+	SubtitleMergeSentences    bool    // 是否合并跨字幕的句子
+	SubtitleMaxMergeGap       float64 // 合并字幕之间的最大间隔（秒）
+	SubtitleMaxCharsPerLine   int     // 每行最大字符数
+	SubtitleMaxLines          int     // 每条字幕最大行数
+	SubtitleMaxCharsPerSecond float64 // 最大阅读速度（字符/秒）
+
+	// 本地化字符串文件处理配置
+	I18nSourceLocale string // 源区域设置
+	I18nTargetLocale string // 目标区域设置
+
+	// Markdown front matter 处理配置
+	FrontMatterTranslateKeys []string // 需要翻译的 front matter 键
+	FrontMatterLanguageKey   string   // 设置为目标语言的 front matter 键
+
+	// MDX 和 Jupyter notebook 处理配置
+	MDXTranslateProps             []string // 需要翻译的 JSX 字符串属性名
+	NotebookTranslateCodeComments bool     // 是否翻译代码单元格中的注释
+
+	// 文档简介配置
+	DocumentBriefEnabled        bool   // 是否在翻译前生成文档简介并加入提示词
+	DocumentBriefFile           string // 用户提供的简介文件，所有文档共用
+	DocumentBriefDir            string // 生成的简介文件保存目录，为空时保存在输入文件旁
+	DocumentBriefMaxSourceChars int    // 生成简介时最多发送的原文字符数
+
+	// 风格指南配置
+	StyleProfile  string                         // 默认使用的风格配置，名称或 YAML 文件路径
+	StyleByFormat map[string]string              // 按文件格式或扩展名选择风格配置
+	StyleProfiles map[string]config.StyleProfile // 命名的风格配置
+	StyleCheck    bool                           // 翻译后检查可以确定的风格违规
+
 	// 格式修复配置
 	EnableFormatFix      bool
 	FormatFixInteractive bool
@@ -94,6 +138,41 @@ func NewCoordinatorConfig(cfg *config.Config) CoordinatorConfig {
 		SourceLang:         cfg.SourceLang,
 		TargetLang:         cfg.TargetLang,
 
+		PPTXOverflowMode:      cfg.PPTX.OverflowMode,
+		PPTXOverflowThreshold: cfg.PPTX.OverflowThreshold,
+		PPTXMinFontScale:      cfg.PPTX.MinFontScale,
+
+		XLSXSkipSheets:          cfg.XLSX.SkipSheets,
+		XLSXSkipColumns:         cfg.XLSX.SkipColumns,
+		XLSXColumnMappings:      cfg.XLSX.ColumnMappings,
+		XLSXTranslateSheetNames: cfg.XLSX.TranslateSheetNames,
+		XLSXTranslateComments:   cfg.XLSX.TranslateComments,
+
+		SubtitleMergeSentences:    cfg.Subtitle.MergeSentences,
+		SubtitleMaxMergeGap:       cfg.Subtitle.MaxMergeGap,
+		SubtitleMaxCharsPerLine:   cfg.Subtitle.MaxCharsPerLine,
+		SubtitleMaxLines:          cfg.Subtitle.MaxLines,
+		SubtitleMaxCharsPerSecond: cfg.Subtitle.MaxCharsPerSecond,
+
+		I18nSourceLocale: cfg.I18n.SourceLocale,
+		I18nTargetLocale: cfg.I18n.TargetLocale,
+
+		FrontMatterTranslateKeys: cfg.FrontMatter.TranslateKeys,
+		FrontMatterLanguageKey:   cfg.FrontMatter.LanguageKey,
+
+		MDXTranslateProps:             cfg.MDX.TranslateProps,
+		NotebookTranslateCodeComments: cfg.Notebook.TranslateCodeComments,
+
+		DocumentBriefEnabled:        cfg.DocumentBrief.Enabled,
+		DocumentBriefFile:           cfg.DocumentBrief.File,
+		DocumentBriefDir:            cfg.DocumentBrief.Dir,
+		DocumentBriefMaxSourceChars: cfg.DocumentBrief.MaxSourceChars,
+
+		StyleProfile:  cfg.Style.Profile,
+		StyleByFormat: cfg.Style.ByFormat,
+		StyleProfiles: cfg.Style.Profiles,
+		StyleCheck:    cfg.Style.Check,
+
 		EnableFormatFix:      cfg.EnableFormatFix,
 		FormatFixInteractive: cfg.FormatFixInteractive,
 		PreTranslationFix:    cfg.PreTranslationFix,
@@ -131,6 +210,7 @@ type TranslationResult struct {
 	Metadata          map[string]interface{}      `json:"metadata,omitempty"`
 	FailedNodeDetails []*FailedNodeDetail         `json:"failed_node_details,omitempty"`
 	DetailedSummary   *DetailedTranslationSummary `json:"detailed_summary,omitempty"`
+	StyleViolations   []*StyleViolation           `json:"style_violations,omitempty"`
 }
 
 // TranslationCoordinator 翻译协调器，只负责文档解析、组装和工作流协调
@@ -143,6 +223,7 @@ type TranslationCoordinator struct {
 	formatManager        *formatter.Manager
 	formatFixRegistry    *formatfix.FixerRegistry
 	postProcessor        *TranslationPostProcessor
+	localeAdapter        *localeformat.Adapter // 区域格式改写器，未启用时为 nil
 	statsDB              *stats.Database
 	providerStatsManager *providerStats.StatsManager // Provider性能统计管理器
 	logger               *zap.Logger
@@ -296,6 +377,11 @@ func NewTranslationCoordinator(cfg *config.Config, logger *zap.Logger, progressP
 	// 创建节点翻译管理器
 	translatorConfig := NewTranslatorConfig(cfg)
 	translator := NewBatchTranslator(translatorConfig, translationService, logger, providerStatsManager, nil)
+	candidates, err := newCandidateGenerator(cfg, translationConfig, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create candidate generator: %w", err)
+	}
+	translator.SetCandidateGenerator(candidates)
 	logger.Info("translator initialized",
 		zap.Int("chunk_size", translatorConfig.ChunkSize),
 		zap.Int("concurrency", translatorConfig.Concurrency),
@@ -321,6 +407,7 @@ func NewTranslationCoordinator(cfg *config.Config, logger *zap.Logger, progressP
 		formatManager:        formatManager,
 		formatFixRegistry:    formatFixRegistry,
 		postProcessor:        postProcessor,
+		localeAdapter:        newLocaleAdapter(cfg, logger),
 		statsDB:              statsDB,
 		providerStatsManager: providerStatsManager,
 		logger:               logger,
@@ -339,6 +426,11 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 		zap.String("inputPath", inputPath),
 		zap.String("outputPath", outputPath))
 
+	return c.translateFile(ctx, docID, inputPath, outputPath, startTime, nil)
+}
+
+// translateFile 翻译文件，session 不为空时恢复该会话：跳过已完成的节点，只翻译未完成和失败的节点
+func (c *TranslationCoordinator) translateFile(ctx context.Context, docID, inputPath, outputPath string, startTime time.Time, session *progress.Session) (*TranslationResult, error) {
 	// 读取输入文件
 	contentStr, err := c.readFile(inputPath)
 	if err != nil {
@@ -367,16 +459,7 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 	}
 
 	// 使用完善的document processor替代简化解析
-	processorOpts := document.ProcessorOptions{
-		ChunkSize:    c.coordinatorConfig.ChunkSize,
-		ChunkOverlap: 100,
-		Metadata: map[string]interface{}{
-			"source_language":      c.coordinatorConfig.SourceLang,
-			"target_language":      c.coordinatorConfig.TargetLang,
-			"logger":               c.logger,
-			"html_processing_mode": c.coordinatorConfig.HTMLProcessingMode,
-		},
-	}
+	processorOpts := c.newProcessorOptions()
 
 	// 获取适当的document processor
 	processor, err := document.GetProcessorByExtension(inputPath, processorOpts)
@@ -388,6 +471,15 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 		zap.String("format", string(processor.GetFormat())),
 		zap.Int("chunk_size", processorOpts.ChunkSize))
 
+	// 按文件类型选择风格配置
+	styleProfile, err := c.styleConfig().ProfileFor(string(processor.GetFormat()), inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load style profile: %w", err)
+	}
+	if styleProfile != nil {
+		c.logger.Info("using style profile", zap.String("profile", styleProfile.Name))
+	}
+
 	// 为BatchTranslator设置文档处理器以支持格式特定的内容保护
 	if batchTranslator, ok := c.translator.(*BatchTranslator); ok {
 		batchTranslator.SetDocumentProcessor(processor)
@@ -409,12 +501,42 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 		if err != nil {
 			return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
 		}
+		if session != nil {
+			c.progressTracker.CompleteDocument(docID)
+		}
 		return c.createSuccessResult(docID, inputPath, outputPath, startTime, time.Now(), nodes), nil
 	}
 
+	// 记录会话，每个节点的译文都会持久化，中断后可以恢复
+	if session == nil {
+		inputHash, err := hashInputPath(inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash input file: %w", err)
+		}
+		c.progressTracker.StartDocument(docID, inputPath, len(nodes))
+		c.progressTracker.SetSessionSource(docID, sourceFileFromContext(ctx, inputPath), inputPath, outputPath, inputHash)
+	} else {
+		restored := c.restoreCompletedNodes(session, nodes)
+		c.logger.Info("restored completed nodes from session",
+			zap.String("docID", docID),
+			zap.Int("restoredNodes", restored),
+			zap.Int("pendingNodes", len(nodes)-restored))
+	}
+
+	// 恢复的译文同样加入一致性记录
+	consistency := c.Consistency()
+	var pendingNodes []*document.NodeInfo
+	for _, node := range nodes {
+		if node.Status != document.NodeStatusSuccess {
+			pendingNodes = append(pendingNodes, node)
+		} else {
+			consistency.Record(sourceFileFromContext(ctx, inputPath), node)
+		}
+	}
+
 	// 计算总字符数并创建进度条
 	totalChars := int64(0)
-	for _, node := range nodes {
+	for _, node := range pendingNodes {
 		totalChars += int64(len(node.OriginalText))
 	}
 
@@ -433,7 +555,7 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 			// 只有在有实际进度时才更新进度条数值
 			if completed > 0 && total > 0 {
 				// 根据完成的节点数量估算已处理的字符数
-				avgCharsPerNode := float64(totalChars) / float64(len(nodes))
+				avgCharsPerNode := float64(totalChars) / float64(len(pendingNodes))
 				processedChars := int64(float64(completed) * avgCharsPerNode)
 
 				// 更新进度条（但不超过总字符数）
@@ -445,17 +567,59 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 				}
 			}
 		})
+		batchTranslator.SetNodeResultCallback(func(node *document.NodeInfo, stepOutputs map[string]string) {
+			c.recordNodeResult(docID, node, stepOutputs)
+		})
+	}
+
+	// 处理器声明的说明和译文约束（如字幕行长、复数规则）加入提示词，
+	// 一致性记录按源文件记录译法出现的位置
+	translateCtx := WithSourceFile(ctx, sourceFileFromContext(ctx, inputPath))
+
+	// 文档简介加入每个请求的提示词
+	if len(pendingNodes) > 0 {
+		doc.Brief, err = c.loadDocumentBrief(ctx, inputPath, nodes)
+		if err != nil {
+			return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
+		}
+		translateCtx = document.WithDocumentBrief(translateCtx, doc.Brief)
+	}
+	translateCtx = translation.WithStyleProfile(translateCtx, styleProfile)
+	translateCtx = translation.WithDocumentFormat(translateCtx, c.detectFileFormat(inputPath))
+	if notesProvider, ok := processor.(document.TranslationNotesProvider); ok {
+		translateCtx = document.WithTranslationNotes(translateCtx, notesProvider.TranslationNotes())
 	}
+	checker, hasConstraints := processor.(document.TranslationConstraintChecker)
 
 	// 使用Translator进行节点分组和并行翻译
-	err = c.translator.TranslateNodes(ctx, nodes)
-	if err != nil {
-		return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
+	if len(pendingNodes) > 0 {
+		err = c.translator.TranslateNodes(translateCtx, pendingNodes)
+		if err != nil {
+			c.progressTracker.PauseTracking(docID)
+			return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
+		}
+	}
+
+	// 收到中断信号时不再重译，直接输出部分译文
+	interrupted := StopRequested(ctx)
+
+	// 校验译文约束，违反约束的节点更简洁地重译
+	if hasConstraints && !interrupted {
+		c.enforceTranslationConstraints(translateCtx, checker, doc, nodes)
 	}
 
-	// 重建文档结构并渲染
-	translatedContent, err := c.assembleDocumentWithProcessor(inputPath, doc, nodes)
+	// 按目标区域设置改写本次翻译的数字、日期、时间、计量单位和货币金额，恢复的节点已经改写过
+	c.adaptLocaleFormats(pendingNodes)
+
+	// 按最终译文更新会话（约束重译的结果可能被放弃）
+	for _, node := range nodes {
+		c.recordNodeResult(docID, node, nil)
+	}
+
+	// 重建文档结构并渲染，中断时未翻译的块保留原文并加上标记
+	translatedContent, err := c.assembleDocumentWithProcessor(inputPath, doc, nodes, interrupted)
 	if err != nil {
+		c.progressTracker.PauseTracking(docID)
 		return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
 	}
 
@@ -471,13 +635,33 @@ func (c *TranslationCoordinator) TranslateFile(ctx context.Context, inputPath, o
 	// 写入输出文件
 	err = c.writeFile(outputPath, translatedContent)
 	if err != nil {
+		c.progressTracker.PauseTracking(docID)
 		return c.createFailedResult(docID, inputPath, outputPath, startTime, err), err
 	}
 
+	// 翻译被中断时保存会话，之后可以恢复
+	if interrupted {
+		c.progressTracker.PauseTracking(docID)
+		result := c.createSuccessResult(docID, inputPath, outputPath, startTime, time.Now(), nodes)
+		result.Status = string(progress.StatusPaused)
+		c.logger.Warn("translation interrupted, partial output written",
+			zap.String("docID", docID),
+			zap.String("outputPath", outputPath),
+			zap.Int("completedNodes", result.CompletedNodes),
+			zap.Int("totalNodes", result.TotalNodes))
+		return result, ErrTranslationInterrupted
+	}
+	c.progressTracker.CompleteDocument(docID)
+
 	// 创建成功结果
 	endTime := time.Now()
 	result := c.createSuccessResult(docID, inputPath, outputPath, startTime, endTime, nodes)
 
+	// 检查译文是否违反风格配置
+	if c.coordinatorConfig.StyleCheck {
+		result.StyleViolations = CheckStyle(styleProfile, nodes)
+	}
+
 	// 记录统计数据
 	c.recordTranslationStats(result, nodes)
 
@@ -557,6 +741,24 @@ func (c *TranslationCoordinator) translateNode(ctx context.Context, node *docume
 	return nil
 }
 
+// styleConfig 返回风格指南配置
+func (c *TranslationCoordinator) styleConfig() config.StyleConfig {
+	return config.StyleConfig{
+		Profile:  c.coordinatorConfig.StyleProfile,
+		ByFormat: c.coordinatorConfig.StyleByFormat,
+		Profiles: c.coordinatorConfig.StyleProfiles,
+		Check:    c.coordinatorConfig.StyleCheck,
+	}
+}
+
+// Consistency 返回本次运行所有文件共享的一致性记录，未启用时返回 nil
+func (c *TranslationCoordinator) Consistency() *ConsistencyStore {
+	if batchTranslator, ok := c.translator.(*BatchTranslator); ok {
+		return batchTranslator.Consistency()
+	}
+	return nil
+}
+
 // GetProgress 获取翻译进度
 func (c *TranslationCoordinator) GetProgress(docID string) *progress.ProgressInfo {
 	return c.progressTracker.GetProgress(docID)
@@ -567,37 +769,63 @@ func (c *TranslationCoordinator) ListSessions() ([]*progress.SessionSummary, err
 	return c.progressTracker.ListSessions()
 }
 
-// ResumeSession 恢复翻译会话
+// ResumeSession 恢复中断的翻译会话：重新解析源文件并确认其未改变，
+// 已完成的节点直接使用保存的译文，只翻译未完成和失败的节点
 func (c *TranslationCoordinator) ResumeSession(ctx context.Context, sessionID string) (*TranslationResult, error) {
-	err := c.progressTracker.LoadSession(sessionID)
+	return c.ResumeSessionFrom(ctx, sessionID, "")
+}
+
+// ResumeSessionFrom 使用指定的输入文件恢复翻译会话，用于输入经过预处理、需要重新生成的情况。
+// inputPath 为空时使用会话记录的输入文件
+func (c *TranslationCoordinator) ResumeSessionFrom(ctx context.Context, sessionID, inputPath string) (*TranslationResult, error) {
+	session, err := c.progressTracker.GetSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load session: %w", err)
 	}
 
-	progressInfo := c.progressTracker.GetProgress(sessionID)
-	if progressInfo == nil {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+	if session.Status == progress.StatusCompleted {
+		return nil, fmt.Errorf("session %s is already completed", sessionID)
+	}
+	if session.InputPath == "" || session.InputHash == "" {
+		return nil, fmt.Errorf("session %s has no recorded input file and cannot be resumed", sessionID)
+	}
+	if inputPath == "" {
+		inputPath = session.InputPath
 	}
 
+	// 源文件改变后节点与保存的译文无法对应
+	inputHash, err := hashInputPath(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash input file: %w", err)
+	}
+	if inputHash != session.InputHash {
+		return nil, fmt.Errorf("input file %s has changed since session %s started", inputPath, sessionID)
+	}
+
+	session, err = c.progressTracker.ResumeTracking(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	c.progressTracker.SetSessionSource(sessionID, session.SourcePath, inputPath, session.OutputPath, inputHash)
+
 	c.logger.Info("resuming translation session",
 		zap.String("sessionID", sessionID),
-		zap.String("fileName", progressInfo.FileName),
-		zap.Float64("progress", progressInfo.Progress))
-
-	// 这里可以实现会话恢复逻辑
-	// 目前返回当前状态
-	result := &TranslationResult{
-		DocID:          sessionID,
-		InputFile:      progressInfo.FileName,
-		TotalNodes:     progressInfo.TotalChunks,
-		CompletedNodes: progressInfo.CompletedChunks,
-		FailedNodes:    progressInfo.FailedChunks,
-		Progress:       progressInfo.Progress,
-		Status:         string(progressInfo.Status),
-		StartTime:      progressInfo.StartTime,
-	}
+		zap.String("inputPath", inputPath),
+		zap.String("outputPath", session.OutputPath),
+		zap.Int("completedNodes", session.CompletedNodes),
+		zap.Int("totalNodes", session.TotalNodes))
 
-	return result, nil
+	return c.translateFile(ctx, sessionID, inputPath, session.OutputPath, time.Now(), session)
+}
+
+// PauseActiveSessions 暂停并保存所有进行中的会话，强制退出前调用，之后可以恢复
+func (c *TranslationCoordinator) PauseActiveSessions() {
+	c.progressTracker.PauseRunning()
+}
+
+// GetSession 获取翻译会话
+func (c *TranslationCoordinator) GetSession(sessionID string) (*progress.Session, error) {
+	return c.progressTracker.GetSession(sessionID)
 }
 
 // GetActiveSession 获取活跃会话
@@ -666,6 +894,9 @@ func (c *TranslationCoordinator) recordTranslationStats(result *TranslationResul
 			"chunk_size": c.coordinatorConfig.ChunkSize,
 		},
 	}
+	if reporter, ok := c.translationService.(translation.PromptTemplateReporter); ok {
+		record.Metadata["prompt_template_version"] = reporter.PromptTemplateVersion()
+	}
 
 	// 添加到统计数据库
 	if err := c.statsDB.AddTranslationRecord(record); err != nil {
@@ -686,6 +917,10 @@ func (c *TranslationCoordinator) detectFileFormat(filePath string) string {
 	switch ext {
 	case ".md", ".markdown":
 		return "markdown"
+	case ".mdx":
+		return "mdx"
+	case ".ipynb":
+		return "ipynb"
 	case ".txt":
 		return "text"
 	case ".html", ".htm":
@@ -694,6 +929,32 @@ func (c *TranslationCoordinator) detectFileFormat(filePath string) string {
 		return "epub"
 	case ".tex":
 		return "latex"
+	case ".docx", ".doc":
+		return "docx"
+	case ".pptx":
+		return "pptx"
+	case ".xlsx":
+		return "xlsx"
+	case ".odt":
+		return "odt"
+	case ".odp":
+		return "odp"
+	case ".srt":
+		return "srt"
+	case ".vtt":
+		return "vtt"
+	case ".ass", ".ssa":
+		return "ass"
+	case ".po", ".pot":
+		return "po"
+	case ".json":
+		return "i18n-json"
+	case ".yml", ".yaml":
+		return "i18n-yaml"
+	case ".strings":
+		return "strings"
+	case ".xcstrings":
+		return "xcstrings"
 	default:
 		return "text"
 	}
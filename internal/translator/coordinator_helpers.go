@@ -2,6 +2,8 @@ package translator
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -232,6 +234,9 @@ func (c *TranslationCoordinator) extractNodesFromDocument(doc *document.Document
 				"format":     string(doc.Format),
 			},
 		}
+		if translationContext, ok := block.GetMetadata().Attributes[document.TranslationContextAttribute].(string); ok && translationContext != "" {
+			node.Metadata[document.NodeTranslationContextKey] = translationContext
+		}
 		nodes = append(nodes, node)
 		nodeID++
 	}
@@ -245,8 +250,20 @@ func (c *TranslationCoordinator) extractNodesFromDocument(doc *document.Document
 	return nodes
 }
 
-// assembleDocumentWithProcessor 使用document processor重建并渲染文档
-func (c *TranslationCoordinator) assembleDocumentWithProcessor(inputPath string, doc *document.Document, nodes []*document.NodeInfo) (string, error) {
+// assembleDocumentWithProcessor 使用document processor重建并渲染文档。
+// markUntranslated 为 true 时（翻译被中断），未翻译的块保留原文并由处理器加上未翻译标记
+func (c *TranslationCoordinator) assembleDocumentWithProcessor(inputPath string, doc *document.Document, nodes []*document.NodeInfo, markUntranslated bool) (string, error) {
+	// 重新获取processor进行渲染
+	processorOpts := c.newProcessorOptions()
+
+	processor, err := document.GetProcessorByExtension(inputPath, processorOpts)
+	if err != nil {
+		c.logger.Warn("failed to get processor for rendering, using fallback", zap.Error(err))
+		// 使用简化的组装方法作为回退
+		return c.assembleDocument(inputPath, nodes)
+	}
+	marker, canMark := processor.(document.UntranslatedMarker)
+
 	// 创建节点映射，按BlockID索引
 	nodeMap := make(map[string]*document.NodeInfo)
 	for _, node := range nodes {
@@ -256,32 +273,25 @@ func (c *TranslationCoordinator) assembleDocumentWithProcessor(inputPath string,
 	// 更新文档中的块内容
 	for i, block := range doc.Blocks {
 		blockID := fmt.Sprintf("block-%d", i)
-		if node, exists := nodeMap[blockID]; exists && node.Status == document.NodeStatusSuccess {
+		node, exists := nodeMap[blockID]
+		if !exists {
+			continue
+		}
+		if node.Status == document.NodeStatusSuccess {
 			// 更新块内容为翻译后的文本
 			block.SetContent(node.TranslatedText)
+			// 翻译流程明确标记为低置信度的译文交给处理器标记；占位符和复数形式由处理器渲染时检查
+			if lowConfidence, _ := node.Metadata[document.LowConfidenceAttribute].(bool); lowConfidence {
+				if attributes := block.GetMetadata().Attributes; attributes != nil {
+					attributes[document.LowConfidenceAttribute] = true
+				}
+			}
+		} else if markUntranslated && canMark {
+			block.SetContent(marker.MarkUntranslated(block, block.GetContent()))
 		}
 		// 如果没有翻译或翻译失败，保留原始内容
 	}
 
-	// 重新获取processor进行渲染
-	processorOpts := document.ProcessorOptions{
-		ChunkSize:    c.coordinatorConfig.ChunkSize,
-		ChunkOverlap: 100,
-		Metadata: map[string]interface{}{
-			"source_language":      c.coordinatorConfig.SourceLang,
-			"target_language":      c.coordinatorConfig.TargetLang,
-			"logger":               c.logger,
-			"html_processing_mode": c.coordinatorConfig.HTMLProcessingMode,
-		},
-	}
-
-	processor, err := document.GetProcessorByExtension(inputPath, processorOpts)
-	if err != nil {
-		c.logger.Warn("failed to get processor for rendering, using fallback", zap.Error(err))
-		// 使用简化的组装方法作为回退
-		return c.assembleDocument(inputPath, nodes)
-	}
-
 	// 使用processor渲染文档
 	var buffer strings.Builder
 	err = processor.Render(context.Background(), doc, &buffer)
@@ -299,6 +309,91 @@ func (c *TranslationCoordinator) assembleDocumentWithProcessor(inputPath string,
 	return buffer.String(), nil
 }
 
+// enforceTranslationConstraints 按处理器的译文约束校验节点译文，对违反约束的节点要求更简洁地重译，
+// 重译结果更短时才采用
+func (c *TranslationCoordinator) enforceTranslationConstraints(ctx context.Context, checker document.TranslationConstraintChecker, doc *document.Document, nodes []*document.NodeInfo) {
+	blockNodes := make(map[int]*document.NodeInfo)
+	translations := make(map[int]string)
+	for _, node := range nodes {
+		if node.Status != document.NodeStatusSuccess {
+			continue
+		}
+		blockIndex, ok := node.Metadata["blockIndex"].(int)
+		if !ok || blockIndex < 0 || blockIndex >= len(doc.Blocks) {
+			continue
+		}
+		blockNodes[blockIndex] = node
+		translations[blockIndex] = node.TranslatedText
+	}
+
+	document.EnforceTranslationConstraints(ctx, checker, doc, translations, func(retryCtx context.Context, blockIndexes []int) map[int]string {
+		retrying := make([]*document.NodeInfo, 0, len(blockIndexes))
+		for _, blockIndex := range blockIndexes {
+			node := blockNodes[blockIndex]
+			node.Status = document.NodeStatusPending
+			node.TranslatedText = ""
+			node.Error = nil
+			retrying = append(retrying, node)
+		}
+		if err := c.translator.TranslateNodes(retryCtx, retrying); err != nil {
+			c.logger.Warn("constraint re-translation failed", zap.Error(err))
+		}
+
+		retried := make(map[int]string, len(blockIndexes))
+		for _, blockIndex := range blockIndexes {
+			if node := blockNodes[blockIndex]; node.Status == document.NodeStatusSuccess {
+				retried[blockIndex] = node.TranslatedText
+			}
+		}
+		return retried
+	}, c.logger)
+
+	// 采用的译文写回节点（未采用的重译恢复为之前的译文）
+	for blockIndex, node := range blockNodes {
+		node.TranslatedText = translations[blockIndex]
+		node.Status = document.NodeStatusSuccess
+		node.Error = nil
+	}
+}
+
+// newProcessorOptions 构建文档处理器选项
+func (c *TranslationCoordinator) newProcessorOptions() document.ProcessorOptions {
+	return document.ProcessorOptions{
+		ChunkSize:    c.coordinatorConfig.ChunkSize,
+		ChunkOverlap: 100,
+		Metadata: map[string]interface{}{
+			"source_language":         c.coordinatorConfig.SourceLang,
+			"target_language":         c.coordinatorConfig.TargetLang,
+			"logger":                  c.logger,
+			"html_processing_mode":    c.coordinatorConfig.HTMLProcessingMode,
+			"pptx_overflow_mode":      c.coordinatorConfig.PPTXOverflowMode,
+			"pptx_overflow_threshold": c.coordinatorConfig.PPTXOverflowThreshold,
+			"pptx_min_font_scale":     c.coordinatorConfig.PPTXMinFontScale,
+
+			"xlsx_skip_sheets":           c.coordinatorConfig.XLSXSkipSheets,
+			"xlsx_skip_columns":          c.coordinatorConfig.XLSXSkipColumns,
+			"xlsx_column_mappings":       c.coordinatorConfig.XLSXColumnMappings,
+			"xlsx_translate_sheet_names": c.coordinatorConfig.XLSXTranslateSheetNames,
+			"xlsx_translate_comments":    c.coordinatorConfig.XLSXTranslateComments,
+
+			"subtitle_merge_sentences":      c.coordinatorConfig.SubtitleMergeSentences,
+			"subtitle_max_merge_gap":        c.coordinatorConfig.SubtitleMaxMergeGap,
+			"subtitle_max_chars_per_line":   c.coordinatorConfig.SubtitleMaxCharsPerLine,
+			"subtitle_max_lines":            c.coordinatorConfig.SubtitleMaxLines,
+			"subtitle_max_chars_per_second": c.coordinatorConfig.SubtitleMaxCharsPerSecond,
+
+			"i18n_source_locale": c.coordinatorConfig.I18nSourceLocale,
+			"i18n_target_locale": c.coordinatorConfig.I18nTargetLocale,
+
+			"front_matter_translate_keys": c.coordinatorConfig.FrontMatterTranslateKeys,
+			"front_matter_language_key":   c.coordinatorConfig.FrontMatterLanguageKey,
+
+			"mdx_translate_props":              c.coordinatorConfig.MDXTranslateProps,
+			"notebook_translate_code_comments": c.coordinatorConfig.NotebookTranslateCodeComments,
+		},
+	}
+}
+
 // createFailedResult 创建失败结果
 func (c *TranslationCoordinator) createFailedResult(docID, inputFile, outputFile string, startTime time.Time, err error) *TranslationResult {
 	endTime := time.Now()
@@ -426,3 +521,95 @@ func getStepIndex(step string) int {
 		return -1
 	}
 }
+
+// sourceFileKey 上下文中原始输入文件路径的键
+type sourceFileKey struct{}
+
+// WithSourceFile 记录用户指定的原始输入文件。翻译的输入是预处理生成的临时文件时，
+// 恢复会话需要据此重新生成翻译输入
+func WithSourceFile(ctx context.Context, sourcePath string) context.Context {
+	return context.WithValue(ctx, sourceFileKey{}, sourcePath)
+}
+
+// sourceFileFromContext 返回上下文中记录的原始输入文件，没有时返回 inputPath
+func sourceFileFromContext(ctx context.Context, inputPath string) string {
+	if sourcePath, ok := ctx.Value(sourceFileKey{}).(string); ok && sourcePath != "" {
+		return sourcePath
+	}
+	return inputPath
+}
+
+// hashInputPath 计算输入文件内容的 SHA-256，目录（如 TextBundle）按路径顺序计算其中所有文件
+func hashInputPath(inputPath string) (string, error) {
+	hasher := sha256.New()
+
+	err := filepath.WalkDir(inputPath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(inputPath, path)
+		if err != nil {
+			return err
+		}
+		hasher.Write([]byte(filepath.ToSlash(relPath)))
+		hasher.Write([]byte{0})
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(hasher, file)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// restoreCompletedNodes 使用会话中保存的译文恢复已完成的节点，返回恢复的节点数。
+// 原文与保存时不一致的节点会重新翻译
+func (c *TranslationCoordinator) restoreCompletedNodes(session *progress.Session, nodes []*document.NodeInfo) int {
+	restored := 0
+	for _, node := range nodes {
+		nodeProgress, ok := session.NodeProgress[node.ID]
+		if !ok || nodeProgress.Status != document.NodeStatusSuccess {
+			continue
+		}
+		if nodeProgress.SourceHash != progress.HashText(node.OriginalText) {
+			c.logger.Warn("node changed since session was saved, translating it again",
+				zap.Int("nodeID", node.ID))
+			continue
+		}
+
+		node.TranslatedText = nodeProgress.TranslatedText
+		node.Status = document.NodeStatusSuccess
+		node.RetryCount = nodeProgress.RetryCount
+		restored++
+	}
+	return restored
+}
+
+// recordNodeResult 将节点的翻译结果保存到会话
+func (c *TranslationCoordinator) recordNodeResult(docID string, node *document.NodeInfo, stepOutputs map[string]string) {
+	if node.Status != document.NodeStatusSuccess && node.Status != document.NodeStatusFailed {
+		return
+	}
+
+	c.progressTracker.RecordNodeResult(docID, progress.NodeResult{
+		NodeID:         node.ID,
+		Status:         node.Status,
+		OriginalText:   node.OriginalText,
+		TranslatedText: node.TranslatedText,
+		StepOutputs:    stepOutputs,
+		RetryCount:     node.RetryCount,
+		Error:          node.Error,
+	})
+}
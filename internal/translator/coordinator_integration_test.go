@@ -2,8 +2,10 @@ package translator
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -179,28 +181,100 @@ func TestTranslationCoordinator_SessionManagement(t *testing.T) {
 	})
 
 	t.Run("Session Resume", func(t *testing.T) {
-		// 创建一个可恢复的会话
-		sessionID := "resumable-session"
-		fileName := "/test/resumable.md"
-
-		coordinator.progressReporter.StartDocument(sessionID, fileName, 3)
-		coordinator.progressReporter.UpdateNode(sessionID, 1, document.NodeStatusSuccess, 100, nil)
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "resumable.md")
+		outputFile := filepath.Join(tempDir, "resumable.zh.md")
+		inputContent := "First paragraph.\n\nSecond paragraph.\n\nThird paragraph.\n"
+		require.NoError(t, os.WriteFile(inputFile, []byte(inputContent), 0o644))
 
-		// 停止跟踪以保存会话（模拟中断）
-		coordinator.progressTracker.StopTracking(sessionID)
+		// 第一次翻译时第二段失败（模拟中断）
+		originalTranslator := coordinator.translator
+		defer func() { coordinator.translator = originalTranslator }()
+		coordinator.translator = &scriptedTranslator{failOn: "Second"}
 
-		// 尝试恢复会话
 		ctx := context.Background()
-		result, err := coordinator.ResumeSession(ctx, sessionID)
+		result, err := coordinator.TranslateFile(ctx, inputFile, outputFile)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.FailedNodes)
+		sessionID := result.DocID
+
+		// 恢复时只翻译失败的节点
+		resumeTranslator := &scriptedTranslator{}
+		coordinator.translator = resumeTranslator
+		result, err = coordinator.ResumeSession(ctx, sessionID)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
 		assert.Equal(t, sessionID, result.DocID)
-		assert.Equal(t, fileName, result.InputFile)
-		// 实际的值取决于 progress tracker 中的 TotalNodes
-		assert.Equal(t, 1, result.TotalNodes) // 只有一个节点被更新过
-		assert.Equal(t, 1, result.CompletedNodes)
-		assert.Equal(t, float64(100), result.Progress) // 1/1 * 100 = 100%
+		assert.Equal(t, 0, result.FailedNodes)
+		assert.Equal(t, []string{"Second paragraph."}, resumeTranslator.translated)
+
+		output, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(output), "[zh] First paragraph.")
+		assert.Contains(t, string(output), "[zh] Second paragraph.")
+		assert.Contains(t, string(output), "[zh] Third paragraph.")
+
+		// 已完成的会话不能再恢复
+		_, err = coordinator.ResumeSession(ctx, sessionID)
+		assert.Error(t, err)
+	})
+
+	t.Run("Resume Changed Source", func(t *testing.T) {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "changed.md")
+		outputFile := filepath.Join(tempDir, "changed.zh.md")
+		require.NoError(t, os.WriteFile(inputFile, []byte("One.\n\nTwo.\n"), 0o644))
+
+		originalTranslator := coordinator.translator
+		defer func() { coordinator.translator = originalTranslator }()
+		coordinator.translator = &scriptedTranslator{failOn: "Two"}
+
+		ctx := context.Background()
+		result, err := coordinator.TranslateFile(ctx, inputFile, outputFile)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(inputFile, []byte("One.\n\nTwo changed.\n"), 0o644))
+		_, err = coordinator.ResumeSession(ctx, result.DocID)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "has changed")
+	})
+
+	t.Run("Interrupted Translation", func(t *testing.T) {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "interrupted.md")
+		outputFile := filepath.Join(tempDir, "interrupted.zh.md")
+		require.NoError(t, os.WriteFile(inputFile, []byte("First paragraph.\n\nSecond paragraph.\n"), 0o644))
+
+		originalTranslator := coordinator.translator
+		defer func() { coordinator.translator = originalTranslator }()
+		coordinator.translator = &scriptedTranslator{failOn: "Second"}
+
+		// 模拟翻译过程中收到中断信号
+		stop := make(chan struct{})
+		close(stop)
+		ctx := WithStopSignal(context.Background(), stop)
+
+		result, err := coordinator.TranslateFile(ctx, inputFile, outputFile)
+		require.ErrorIs(t, err, ErrTranslationInterrupted)
+		require.NotNil(t, result)
+		assert.Equal(t, string(progress.StatusPaused), result.Status)
+
+		// 部分译文已写入，未翻译的块保留原文并加上标记
+		output, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(output), "[zh] First paragraph.")
+		assert.Contains(t, string(output), "<!-- UNTRANSLATED -->\nSecond paragraph.")
+
+		coordinator.translator = &scriptedTranslator{}
+		result, err = coordinator.ResumeSession(context.Background(), result.DocID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.FailedNodes)
+
+		output, err = os.ReadFile(outputFile)
+		require.NoError(t, err)
+		assert.NotContains(t, string(output), "UNTRANSLATED")
+		assert.Contains(t, string(output), "[zh] Second paragraph.")
 	})
 
 	t.Run("Resume Non-Existent Session", func(t *testing.T) {
@@ -332,3 +406,23 @@ func TestTranslationCoordinator_ConcurrentOperations(t *testing.T) {
 		}
 	})
 }
+
+// scriptedTranslator 测试用翻译器：给译文加上前缀，原文包含 failOn 的节点翻译失败
+type scriptedTranslator struct {
+	failOn     string
+	translated []string
+}
+
+func (s *scriptedTranslator) TranslateNodes(ctx context.Context, nodes []*document.NodeInfo) error {
+	for _, node := range nodes {
+		s.translated = append(s.translated, node.OriginalText)
+		if s.failOn != "" && strings.Contains(node.OriginalText, s.failOn) {
+			node.Status = document.NodeStatusFailed
+			node.Error = fmt.Errorf("translation failed")
+			continue
+		}
+		node.TranslatedText = "[zh] " + node.OriginalText
+		node.Status = document.NodeStatusSuccess
+	}
+	return nil
+}
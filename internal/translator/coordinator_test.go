@@ -4,12 +4,14 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 	"github.com/nerdneilsfield/go-translator-agent/internal/document"
 	"github.com/nerdneilsfield/go-translator-agent/internal/progress"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -99,6 +101,17 @@ func TestNewTranslationCoordinator(t *testing.T) {
 		require.NotNil(t, coordinator)
 		// 应该使用默认路径
 	})
+
+	t.Run("Accept Review Mode", func(t *testing.T) {
+		reviewCfg := createTestConfig()
+		stepSet := reviewCfg.StepSets[reviewCfg.ActiveStepSet]
+		stepSet.Mode = translation.ModeReview
+		reviewCfg.StepSets[reviewCfg.ActiveStepSet] = stepSet
+
+		coordinator, err := NewTranslationCoordinator(reviewCfg, logger, progressPath)
+		require.NoError(t, err)
+		assert.NotNil(t, coordinator)
+	})
 }
 
 func TestTranslationCoordinator_ProgressManagement(t *testing.T) {
@@ -299,3 +312,33 @@ func TestTranslationCoordinator_ResultCreation(t *testing.T) {
 		assert.Equal(t, testError.Error(), result.ErrorMessage)
 	})
 }
+
+func TestAssembleDocumentMarksOnlyLowConfidenceNodesFuzzy(t *testing.T) {
+	coordinator := &TranslationCoordinator{
+		coordinatorConfig: CoordinatorConfig{SourceLang: "English", TargetLang: "German"},
+		logger:            zap.NewNop(),
+	}
+	processor, err := document.GetProcessorByExtension("messages.po", coordinator.newProcessorOptions())
+	require.NoError(t, err)
+	doc, err := processor.Parse(context.Background(), strings.NewReader(
+		"msgid \"Open\"\nmsgstr \"\"\n\nmsgid \"Close\"\nmsgstr \"\"\n\nmsgid \"Save\"\nmsgstr \"\"\n"))
+	require.NoError(t, err)
+
+	nodes := coordinator.extractNodesFromDocument(doc)
+	require.Len(t, nodes, 3)
+	translations := []string{"Öffnen", "Schließen", "Speichern"}
+	for i, node := range nodes {
+		node.Status = document.NodeStatusSuccess
+		node.TranslatedText = translations[i]
+	}
+	// 重试次数不影响置信度，只有明确的低置信度标记才标为 fuzzy
+	nodes[0].RetryCount = 3
+	nodes[1].Metadata[document.LowConfidenceAttribute] = true
+
+	output, err := coordinator.assembleDocumentWithProcessor("messages.po", doc, nodes, false)
+	require.NoError(t, err)
+	assert.Contains(t, output, "msgid \"Open\"\nmsgstr \"Öffnen\"")
+	assert.Contains(t, output, "#, fuzzy\nmsgid \"Close\"")
+	assert.Contains(t, output, "msgid \"Save\"\nmsgstr \"Speichern\"")
+	assert.Equal(t, 1, strings.Count(output, "fuzzy"))
+}
@@ -0,0 +1,105 @@
+package translator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/translation"
+	"go.uber.org/zap"
+)
+
+// documentBriefSuffix 生成的文档简介文件的后缀
+const documentBriefSuffix = ".brief.txt"
+
+// loadDocumentBrief 返回文档简介：配置了简介文件时使用该文件；否则读取之前保存的简介文件
+// （用户可能已经修改过），不存在时让模型生成并保存，供用户检查和修改。
+// 未启用或生成失败时返回空字符串，翻译照常进行
+func (c *TranslationCoordinator) loadDocumentBrief(ctx context.Context, inputPath string, nodes []*document.NodeInfo) (string, error) {
+	cfg := c.coordinatorConfig
+	if !cfg.DocumentBriefEnabled {
+		return "", nil
+	}
+
+	if cfg.DocumentBriefFile != "" {
+		data, err := os.ReadFile(cfg.DocumentBriefFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read document brief: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	briefPath := c.documentBriefPath(inputPath)
+	if data, err := os.ReadFile(briefPath); err == nil && strings.TrimSpace(string(data)) != "" {
+		c.logger.Info("using saved document brief", zap.String("path", briefPath))
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	generator, ok := c.translationService.(translation.DocumentBriefGenerator)
+	if !ok {
+		c.logger.Warn("translation service cannot generate document briefs")
+		return "", nil
+	}
+	brief, err := generator.GenerateDocumentBrief(ctx, documentBriefSource(nodes, cfg.DocumentBriefMaxSourceChars))
+	if err != nil {
+		c.logger.Warn("failed to generate document brief, translating without it", zap.Error(err))
+		return "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(briefPath), 0o755); err == nil {
+		err = os.WriteFile(briefPath, []byte(brief+"\n"), 0o644)
+	}
+	if err != nil {
+		c.logger.Warn("failed to save document brief", zap.String("path", briefPath), zap.Error(err))
+	} else {
+		c.logger.Info("generated document brief, edit the file and rerun to change it", zap.String("path", briefPath))
+	}
+	return brief, nil
+}
+
+// documentBriefPath 返回文档简介文件的路径：默认在输入文件旁，配置了目录时保存在该目录，
+// 文件名加上输入路径的哈希以区分不同目录下的同名文件
+func (c *TranslationCoordinator) documentBriefPath(inputPath string) string {
+	if c.coordinatorConfig.DocumentBriefDir == "" {
+		return inputPath + documentBriefSuffix
+	}
+	absPath, err := filepath.Abs(inputPath)
+	if err != nil {
+		absPath = inputPath
+	}
+	sum := sha256.Sum256([]byte(absPath))
+	name := fmt.Sprintf("%s.%s%s", filepath.Base(inputPath), hex.EncodeToString(sum[:4]), documentBriefSuffix)
+	return filepath.Join(c.coordinatorConfig.DocumentBriefDir, name)
+}
+
+// documentBriefSource 拼接节点原文作为生成简介的输入，最多 maxChars 个字符（不大于 0 时不限制）
+func documentBriefSource(nodes []*document.NodeInfo, maxChars int) string {
+	var source strings.Builder
+	remaining := maxChars
+	for _, node := range nodes {
+		text := strings.TrimSpace(node.OriginalText)
+		if text == "" {
+			continue
+		}
+		if source.Len() > 0 {
+			source.WriteString("\n\n")
+		}
+		if maxChars > 0 {
+			if count := utf8.RuneCountInString(text); count > remaining {
+				text = string([]rune(text)[:remaining])
+			}
+			remaining -= utf8.RuneCountInString(text)
+		}
+		source.WriteString(text)
+		if maxChars > 0 && remaining <= 0 {
+			break
+		}
+	}
+	return source.String()
+}
@@ -0,0 +1,90 @@
+package translator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// briefService 记录生成简介请求的模拟翻译服务
+type briefService struct {
+	requests []string
+}
+
+func (s *briefService) TranslateText(ctx context.Context, text string) (string, error) {
+	return text, nil
+}
+
+func (s *briefService) GenerateDocumentBrief(ctx context.Context, text string) (string, error) {
+	s.requests = append(s.requests, text)
+	return "Topic: Kubernetes scheduling", nil
+}
+
+func TestLoadDocumentBrief(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "guide.md")
+	nodes := []*document.NodeInfo{{OriginalText: "Pods are scheduled"}, {OriginalText: " "}, {OriginalText: "on nodes."}}
+
+	service := &briefService{}
+	coordinator := &TranslationCoordinator{
+		coordinatorConfig:  CoordinatorConfig{DocumentBriefEnabled: true, DocumentBriefMaxSourceChars: 1000},
+		translationService: service,
+		logger:             zap.NewNop(),
+	}
+
+	// 第一次生成并保存在输入文件旁
+	brief, err := coordinator.loadDocumentBrief(context.Background(), inputPath, nodes)
+	require.NoError(t, err)
+	assert.Equal(t, "Topic: Kubernetes scheduling", brief)
+	assert.Equal(t, []string{"Pods are scheduled\n\non nodes."}, service.requests)
+	saved, err := os.ReadFile(inputPath + ".brief.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Topic: Kubernetes scheduling\n", string(saved))
+
+	// 用户修改后的简介文件不会被重新生成
+	require.NoError(t, os.WriteFile(inputPath+".brief.txt", []byte("Topic: edited\n"), 0o644))
+	brief, err = coordinator.loadDocumentBrief(context.Background(), inputPath, nodes)
+	require.NoError(t, err)
+	assert.Equal(t, "Topic: edited", brief)
+	assert.Len(t, service.requests, 1)
+
+	// 指定的简介文件用于所有文档
+	briefFile := filepath.Join(dir, "brief.txt")
+	require.NoError(t, os.WriteFile(briefFile, []byte("Audience: operators\n"), 0o644))
+	coordinator.coordinatorConfig.DocumentBriefFile = briefFile
+	brief, err = coordinator.loadDocumentBrief(context.Background(), filepath.Join(dir, "other.md"), nodes)
+	require.NoError(t, err)
+	assert.Equal(t, "Audience: operators", brief)
+
+	coordinator.coordinatorConfig.DocumentBriefFile = filepath.Join(dir, "missing.txt")
+	_, err = coordinator.loadDocumentBrief(context.Background(), inputPath, nodes)
+	assert.Error(t, err)
+
+	// 配置了目录时按输入路径区分同名文件
+	coordinator.coordinatorConfig.DocumentBriefDir = filepath.Join(dir, "briefs")
+	first := coordinator.documentBriefPath(filepath.Join(dir, "a", "index.md"))
+	second := coordinator.documentBriefPath(filepath.Join(dir, "b", "index.md"))
+	assert.NotEqual(t, first, second)
+	assert.True(t, strings.HasPrefix(filepath.Base(first), "index.md."))
+	assert.Equal(t, coordinator.coordinatorConfig.DocumentBriefDir, filepath.Dir(first))
+
+	// 未启用时不生成
+	coordinator.coordinatorConfig = CoordinatorConfig{}
+	brief, err = coordinator.loadDocumentBrief(context.Background(), inputPath, nodes)
+	require.NoError(t, err)
+	assert.Empty(t, brief)
+}
+
+func TestDocumentBriefSource(t *testing.T) {
+	nodes := []*document.NodeInfo{{OriginalText: "第一段文字"}, {OriginalText: "Second paragraph"}}
+	assert.Equal(t, "第一段文字\n\nSecond paragraph", documentBriefSource(nodes, 0))
+	assert.Equal(t, "第一段文字\n\nSec", documentBriefSource(nodes, 8))
+	assert.Equal(t, "第一段", documentBriefSource(nodes, 3))
+}
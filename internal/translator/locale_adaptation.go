@@ -0,0 +1,59 @@
+package translator
+
+import (
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/nerdneilsfield/go-translator-agent/internal/localeformat"
+	"go.uber.org/zap"
+)
+
+// newLocaleAdapter 按配置创建区域格式改写器，未启用或区域设置不受支持时返回 nil
+func newLocaleAdapter(cfg *config.Config, logger *zap.Logger) *localeformat.Adapter {
+	settings := cfg.LocaleAdaptation
+	if !settings.Enabled {
+		return nil
+	}
+
+	sourceLocale := settings.SourceLocale
+	if sourceLocale == "" {
+		sourceLocale = cfg.I18n.SourceLocale
+	}
+	if sourceLocale == "" {
+		sourceLocale = localeformat.ResolveLocale(cfg.SourceLang, "")
+	}
+	targetLocale := settings.TargetLocale
+	if targetLocale == "" {
+		targetLocale = cfg.I18n.TargetLocale
+	}
+	if targetLocale == "" {
+		targetLocale = localeformat.ResolveLocale(cfg.TargetLang, cfg.Country)
+	}
+
+	adapter, err := localeformat.NewAdapter(localeformat.Options{
+		SourceLocale:    sourceLocale,
+		TargetLocale:    targetLocale,
+		ConvertUnits:    settings.ConvertUnits,
+		FullWidthDigits: settings.FullWidthDigits,
+	})
+	if err != nil {
+		logger.Warn("locale adaptation disabled", zap.Error(err))
+		return nil
+	}
+	logger.Info("locale adaptation enabled",
+		zap.String("source_locale", sourceLocale),
+		zap.String("target_locale", targetLocale),
+		zap.Bool("convert_units", settings.ConvertUnits))
+	return adapter
+}
+
+// adaptLocaleFormats 按目标区域设置改写翻译成功的节点中的数字、日期、时间、计量单位和货币金额
+func (c *TranslationCoordinator) adaptLocaleFormats(nodes []*document.NodeInfo) {
+	if c.localeAdapter == nil {
+		return
+	}
+	for _, node := range nodes {
+		if node.Status == document.NodeStatusSuccess && node.TranslatedText != "" {
+			node.TranslatedText = c.localeAdapter.Adapt(node.OriginalText, node.TranslatedText)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAdaptLocaleFormats(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.SourceLang = "English"
+	cfg.TargetLang = "German"
+	cfg.Country = "Switzerland"
+
+	assert.Nil(t, newLocaleAdapter(cfg, zap.NewNop()), "disabled by default")
+
+	cfg.LocaleAdaptation.Enabled = true
+	adapter := newLocaleAdapter(cfg, zap.NewNop())
+	require.NotNil(t, adapter)
+
+	c := &TranslationCoordinator{localeAdapter: adapter}
+	nodes := []*document.NodeInfo{
+		{ID: 1, OriginalText: "It weighs 1,250.5 lb.", TranslatedText: "Es wiegt 1,250.5 lb.", Status: document.NodeStatusSuccess},
+		{ID: 2, OriginalText: "Total: 1,000", TranslatedText: "Total: 1,000", Status: document.NodeStatusFailed},
+	}
+	c.adaptLocaleFormats(nodes)
+	assert.Equal(t, "Es wiegt 567.2 kg.", nodes[0].TranslatedText, "de-CH uses . as the decimal separator")
+	assert.Equal(t, "Total: 1,000", nodes[1].TranslatedText)
+
+	cfg.LocaleAdaptation.TargetLocale = "tlh"
+	assert.Nil(t, newLocaleAdapter(cfg, zap.NewNop()), "unsupported locales disable the pass")
+}
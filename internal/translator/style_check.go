@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+)
+
+// 风格检查规则
+const (
+	StyleRuleQuoteMarks     = "quote_marks"     // 引号不符合风格配置
+	StyleRuleUnits          = "units"           // 计量单位没有换算
+	StyleRuleForbiddenTerms = "forbidden_terms" // 出现禁用词
+	StyleRuleKeepTerms      = "keep_terms"      // 应保持原样的名称被翻译或改动
+)
+
+// doubleQuoteMarks 检查的双引号字符，单引号与撇号无法区分，不检查
+const doubleQuoteMarks = "\"“”„«»「」『』"
+
+var (
+	// styleCheckSkipPattern 检查前去掉的标记、行内代码和链接
+	styleCheckSkipPattern = regexp.MustCompile("<[^>]*>|`[^`]*`|https?://\\S+|@@[A-Z_]+_\\d+@@")
+
+	// imperialUnitPattern 英制单位
+	imperialUnitPattern = regexp.MustCompile(`(?i)\d+(?:[.,]\d+)?\s*(?:(?:miles?|mi|feet|foot|ft|inch(?:es)?|pounds?|lbs?|ounces?|oz|gallons?|gal|yards?|yd|mph)\b|°F\b|英里|英尺|英寸|磅|盎司|加仑|码|华氏)`)
+
+	// metricUnitPattern 公制单位
+	metricUnitPattern = regexp.MustCompile(`(?i)\d+(?:[.,]\d+)?\s*(?:(?:km|kilomet(?:er|re)s?|m|met(?:er|re)s?|cm|mm|kg|kilograms?|g|grams?|l|lit(?:er|re)s?|km/h)\b|°C\b|公里|千米|厘米|毫米|米|千克|公斤|克|升|摄氏)`)
+)
+
+// StyleViolation 译文中可以确定的风格违规
+type StyleViolation struct {
+	Rule     string `json:"rule"`
+	Detail   string `json:"detail"`
+	Text     string `json:"text"`     // 违规的字符或片段
+	Location string `json:"location"` // 节点路径或编号
+}
+
+// CheckStyle 检查成功翻译的节点是否违反风格配置中可以确定的规则：引号、计量单位、禁用词和应保持原样的名称
+func CheckStyle(profile *config.StyleProfile, nodes []*document.NodeInfo) []*StyleViolation {
+	if profile == nil {
+		return nil
+	}
+
+	var violations []*StyleViolation
+	for _, node := range nodes {
+		if node.Status != document.NodeStatusSuccess || node.TranslatedText == "" {
+			continue
+		}
+		location := node.Path
+		if location == "" {
+			location = fmt.Sprintf("node %d", node.ID)
+		}
+		report := func(rule, detail, text string) {
+			violations = append(violations, &StyleViolation{Rule: rule, Detail: detail, Text: text, Location: location})
+		}
+
+		text := styleCheckSkipPattern.ReplaceAllString(node.TranslatedText, " ")
+
+		if profile.QuoteMarks != "" {
+			allowed := profile.QuoteMarks + profile.NestedQuoteMarks
+			for _, mark := range doubleQuoteMarks {
+				if strings.ContainsRune(text, mark) && !strings.ContainsRune(allowed, mark) {
+					report(StyleRuleQuoteMarks, fmt.Sprintf("use %s instead", profile.QuoteMarks), string(mark))
+				}
+			}
+		}
+
+		var unitPattern *regexp.Regexp
+		switch profile.Units {
+		case config.UnitsMetric:
+			unitPattern = imperialUnitPattern
+		case config.UnitsImperial:
+			unitPattern = metricUnitPattern
+		}
+		if unitPattern != nil {
+			for _, match := range unitPattern.FindAllString(text, -1) {
+				report(StyleRuleUnits, fmt.Sprintf("convert to %s units", profile.Units), match)
+			}
+		}
+
+		lowerText := strings.ToLower(text)
+		for _, term := range profile.ForbiddenTerms {
+			if term != "" && containsTerm(lowerText, strings.ToLower(term)) {
+				report(StyleRuleForbiddenTerms, "forbidden by the style profile", term)
+			}
+		}
+
+		for _, term := range profile.KeepTerms {
+			if term != "" && containsTerm(node.OriginalText, term) && !strings.Contains(node.TranslatedText, term) {
+				report(StyleRuleKeepTerms, "must be kept as written", term)
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,78 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/internal/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStyle(t *testing.T) {
+	profile := &config.StyleProfile{
+		Name:           "zh-formal",
+		QuoteMarks:     "“”",
+		Units:          config.UnitsMetric,
+		KeepTerms:      []string{"Acme Cloud"},
+		ForbiddenTerms: []string{"你"},
+	}
+	translated := func(id int, source, target string) *document.NodeInfo {
+		return &document.NodeInfo{ID: id, OriginalText: source, TranslatedText: target, Status: document.NodeStatusSuccess}
+	}
+	nodes := []*document.NodeInfo{
+		translated(1, `Click "Save" in Acme Cloud.`, "在 Acme Cloud 中点击“保存”。"),
+		translated(2, `Click "Save".`, `点击"保存"，<a href="x">链接</a> 和 `+"`\"code\"`"),
+		translated(3, "Drive 5 miles.", "你需要开5英里。"),
+		translated(4, "Acme Cloud is fast.", "顶点云很快。"),
+		{ID: 5, OriginalText: "Skipped 5 miles", Status: document.NodeStatusFailed},
+	}
+
+	var got []StyleViolation
+	for _, violation := range CheckStyle(profile, nodes) {
+		got = append(got, *violation)
+	}
+	assert.Equal(t, []StyleViolation{
+		{Rule: StyleRuleQuoteMarks, Detail: "use “” instead", Text: `"`, Location: "node 2"},
+		{Rule: StyleRuleUnits, Detail: "convert to metric units", Text: "5英里", Location: "node 3"},
+		{Rule: StyleRuleForbiddenTerms, Detail: "forbidden by the style profile", Text: "你", Location: "node 3"},
+		{Rule: StyleRuleKeepTerms, Detail: "must be kept as written", Text: "Acme Cloud", Location: "node 4"},
+	}, got)
+
+	assert.Nil(t, CheckStyle(nil, nodes))
+}
+
+func TestStyleConfigProfileFor(t *testing.T) {
+	dir := t.TempDir()
+	profilePath := filepath.Join(dir, "de-formal.yaml")
+	require.NoError(t, os.WriteFile(profilePath, []byte("formality: Sie\nquote_marks: „“\nforbidden_terms: [du]\n"), 0o644))
+
+	style := config.StyleConfig{
+		Profile:  "docs",
+		ByFormat: map[string]string{"markdown": profilePath, ".srt": "missing"},
+		Profiles: map[string]config.StyleProfile{"docs": {Formality: "neutral"}},
+	}
+
+	profile, err := style.ProfileFor("markdown", "guide.md")
+	require.NoError(t, err)
+	assert.Equal(t, "de-formal", profile.Name)
+	assert.Equal(t, "„“", profile.QuoteMarks)
+	assert.Equal(t, []string{"du"}, profile.ForbiddenTerms)
+
+	profile, err = style.ProfileFor("text", "notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "docs", profile.Name)
+
+	_, err = style.ProfileFor("subtitle", "movie.SRT")
+	assert.Error(t, err)
+
+	style.Profiles["docs"] = config.StyleProfile{QuoteMarks: "\""}
+	_, err = style.ProfileFor("text", "notes.txt")
+	assert.Error(t, err, "quote_marks needs an opening and a closing mark")
+
+	profile, err = config.StyleConfig{}.ProfileFor("markdown", "guide.md")
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+}
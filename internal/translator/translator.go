@@ -2,6 +2,7 @@ package translator
 
 import (
 	"context"
+	"errors"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 	"github.com/nerdneilsfield/go-translator-agent/internal/document"
@@ -46,12 +47,48 @@ func NewTranslatorConfig(cfg *config.Config) TranslatorConfig {
 		TargetLang:     cfg.TargetLang,
 		Verbose:        cfg.Verbose,
 		ShowStatsTable: cfg.ShowStatsTable,
+
+		ConsistencyEnabled:          cfg.Consistency.Enabled,
+		ConsistencyMaxSegmentLength: cfg.Consistency.MaxSegmentLength,
+		ConsistencyMaxTermWords:     cfg.Consistency.MaxTermWords,
+		ConsistencyMaxPromptEntries: cfg.Consistency.MaxPromptEntries,
+	}
+}
+
+// ErrTranslationInterrupted 翻译被中断，输出文件只包含部分译文
+var ErrTranslationInterrupted = errors.New("translation interrupted")
+
+// stopSignalKey 上下文中停止信号的键
+type stopSignalKey struct{}
+
+// WithStopSignal 设置停止信号：stop 关闭后不再调度新的节点组和重试轮次，进行中的请求继续完成
+func WithStopSignal(ctx context.Context, stop <-chan struct{}) context.Context {
+	return context.WithValue(ctx, stopSignalKey{}, stop)
+}
+
+// StopRequested 检查是否收到停止信号或上下文已取消
+func StopRequested(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	stop, ok := ctx.Value(stopSignalKey{}).(<-chan struct{})
+	if !ok {
+		return false
+	}
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
 	}
 }
 
 // ProgressCallback 进度回调函数
 type ProgressCallback func(completed, total int, message string)
 
+// NodeResultCallback 节点结果回调函数，stepOutputs 为翻译链各步骤对该节点的输出
+type NodeResultCallback func(node *document.NodeInfo, stepOutputs map[string]string)
+
 // TranslatorConfig Translator包专用配置，管理节点分组和并行相关功能
 type TranslatorConfig struct {
 	// 分组和并行配置
@@ -72,4 +109,10 @@ type TranslatorConfig struct {
 	Verbose        bool             // 详细模式
 	OnProgress     ProgressCallback // 进度回调
 	ShowStatsTable bool             // 是否显示统计表格
+
+	// 跨文件一致性配置
+	ConsistencyEnabled          bool // 是否在整个运行中记录并复用短片段和术语的译法
+	ConsistencyMaxSegmentLength int  // 记录的短片段的最大字符数
+	ConsistencyMaxTermWords     int  // 作为术语的短片段的最大词数
+	ConsistencyMaxPromptEntries int  // 每个请求的提示词中最多加入的已有译法数
 }
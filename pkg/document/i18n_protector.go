@@ -0,0 +1,64 @@
+package document
+
+// I18nProtector 软件本地化字符串（PO、i18next、Rails YAML、Android、Apple .strings）的内容保护器
+type I18nProtector struct {
+	*BaseProtector
+}
+
+// NewI18nProtector 创建本地化字符串保护器
+func NewI18nProtector() *I18nProtector {
+	return &I18nProtector{
+		BaseProtector: NewBaseProtector("i18n"),
+	}
+}
+
+// ProtectContent 保护本地化字符串中的占位符
+func (ip *I18nProtector) ProtectContent(text string, pp PatternProtector) string {
+	// === 本地化字符串特有保护（先于通用保护，避免占位符被拆开） ===
+
+	// 1. Android xliff:g 不翻译片段
+	text = pp.ProtectPattern(text, `(?s)<xliff:g[^>]*>.*?</xliff:g>`)
+
+	// 2. 模板变量：{{var}}（i18next、Handlebars）、$t(key) 嵌套、${var}
+	text = pp.ProtectPattern(text, `\{\{-?\s*[^{}]+?\s*\}\}`)
+	text = pp.ProtectPattern(text, `\$t\([^)]*\)`)
+	text = pp.ProtectPattern(text, `\$\{[^{}]+\}`)
+
+	// 3. ICU MessageFormat：复数/选择头部与简单参数（{name}、{count, number}）
+	text = pp.ProtectPattern(text, `\{\s*[A-Za-z_][\w.]*\s*,\s*(?:plural|select|selectordinal)\s*,(?:\s*offset:\d+)?`)
+	text = pp.ProtectPattern(text, `\{\s*[A-Za-z_0-9][\w.]*\s*(?:,\s*[a-z]+\s*(?:,\s*[^{}]+?)?)?\s*\}`)
+
+	// 4. Ruby/Python 风格插值：%{count}、%<count>d、%(name)s
+	text = pp.ProtectPattern(text, `%\{[A-Za-z_][\w]*\}`)
+	text = pp.ProtectPattern(text, `%<[A-Za-z_][\w]*>[-+ 0#]*\d*(?:\.\d+)?[a-zA-Z]`)
+	text = pp.ProtectPattern(text, `%\([A-Za-z_][\w]*\)[-+ 0#]*\d*(?:\.\d+)?[a-zA-Z]`)
+
+	// 5. printf 风格：%s、%d、%1$s、%.2f、%@（iOS）、%lld
+	text = pp.ProtectPattern(text, `%(?:\d+\$)?[-+ 0#']*\d*(?:\.\d+)?(?:hh|h|ll|l|q|z|t|j)?[sdifuxXoeEgGcCpaA@]`)
+
+	// 6. 内联标记（Android/iOS 文本中的 HTML 标签）
+	text = pp.ProtectPattern(text, `</?[a-zA-Z][\w:-]*(?:\s+[^<>]*)?/?>`)
+
+	// 最后应用通用保护
+	return ip.ProtectCommonContent(text, pp)
+}
+
+// RestoreContent 恢复保护的本地化字符串
+func (ip *I18nProtector) RestoreContent(text string, pp PatternProtector) string {
+	// 使用PatternProtector的Restore方法恢复所有占位符
+	return pp.Restore(text)
+}
+
+// GetProtectedPatterns 获取保护的模式列表
+func (ip *I18nProtector) GetProtectedPatterns() []string {
+	patterns := ip.GetCommonPatterns()
+	i18nPatterns := []string{
+		"Android xliff:g spans",
+		"Template variables ({{var}}, $t(key), ${var})",
+		"ICU MessageFormat arguments and plural/select headers",
+		"Ruby/Python interpolation (%{name}, %<name>d, %(name)s)",
+		"printf placeholders (%s, %1$d, %@)",
+		"Inline markup tags",
+	}
+	return append(patterns, i18nPatterns...)
+}
@@ -16,6 +16,8 @@ func GetProtectorForFormat(format string) ContentProtector {
 	case "epub":
 		// EPUB基本上是HTML，可以复用HTML保护器
 		return NewHTMLProtector()
+	case "i18n":
+		return NewI18nProtector()
 	default:
 		return NewDefaultProtector()
 	}
@@ -37,6 +39,11 @@ func GetProtectorForExtension(filename string) ContentProtector {
 	if strings.HasSuffix(filename, ".epub") {
 		return NewHTMLProtector() // EPUB复用HTML保护器
 	}
+	for _, ext := range []string{".po", ".pot", ".strings", ".xcstrings"} {
+		if strings.HasSuffix(filename, ext) {
+			return NewI18nProtector()
+		}
+	}
 	
 	return NewDefaultProtector()
 }
@@ -48,6 +55,7 @@ func ListAllProtectors() map[string]ContentProtector {
 		"html":     NewHTMLProtector(),
 		"text":     NewTextProtector(),
 		"epub":     NewHTMLProtector(),
+		"i18n":     NewI18nProtector(),
 		"default":  NewDefaultProtector(),
 	}
 }
\ No newline at end of file
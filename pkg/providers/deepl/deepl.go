@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/retry"
@@ -18,14 +19,29 @@ type Config struct {
 	providers.BaseConfig
 	UseFreeAPI  bool              `json:"use_free_api"` // 是否使用免费API
 	RetryConfig retry.RetryConfig `json:"retry_config"`
+
+	// 词汇表：从词汇表文件创建 DeepL 词汇表，按内容哈希复用，翻译时自动传入 glossary_id
+	GlossaryPath    string            `json:"glossary_path,omitempty"`    // 词汇表文件路径（JSON/YAML/TSV/CSV）
+	GlossaryEntries map[string]string `json:"glossary_entries,omitempty"` // 直接提供的术语，设置后不读取词汇表文件
+	GlossaryKey     string            `json:"glossary_key,omitempty"`     // 区分共享账户中各配置创建的词汇表，为空时使用词汇表文件绝对路径的哈希
+
+	// MaxTextsPerRequest 每个请求最多发送的文本数，DeepL 的上限为 50
+	MaxTextsPerRequest int `json:"max_texts_per_request"`
 }
 
+// DeepL /translate 请求的限制
+const (
+	maxTextsPerRequest = 50         // 每个请求最多 50 个 text 参数
+	maxRequestBytes    = 128 * 1024 // 请求体最大 128 KiB
+)
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() Config {
 	config := Config{
-		BaseConfig:  providers.DefaultConfig(),
-		UseFreeAPI:  false,
-		RetryConfig: retry.DefaultRetryConfig(),
+		BaseConfig:         providers.DefaultConfig(),
+		UseFreeAPI:         false,
+		RetryConfig:        retry.DefaultRetryConfig(),
+		MaxTextsPerRequest: maxTextsPerRequest,
 	}
 	config.APIEndpoint = "https://api.deepl.com/v2"
 	return config
@@ -36,6 +52,13 @@ type Provider struct {
 	config      Config
 	httpClient  *http.Client
 	retryClient *retry.RetryableHTTPClient
+
+	// 术语和已同步的词汇表（名称 -> ID）
+	entriesOnce sync.Once
+	entries     map[string]string
+	entriesErr  error
+	glossaryMu  sync.Mutex
+	glossaries  map[string]string
 }
 
 // 确保 Provider 实现 providers.TranslationProvider 接口
@@ -63,6 +86,7 @@ func New(config Config) *Provider {
 		config:      config,
 		httpClient:  httpClient,
 		retryClient: retryClient,
+		glossaries:  make(map[string]string),
 	}
 }
 
@@ -76,13 +100,58 @@ func (p *Provider) Configure(config interface{}) error {
 	return nil
 }
 
-// Translate 执行翻译
+// Translate 执行翻译。批量翻译的节点标记之间的文本作为多个 text 参数在同一个请求中发送，
+// 标记保持原样
 func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest) (*providers.ProviderResponse, error) {
-	// 构建请求参数
+	params, err := p.requestParams(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 执行翻译
+	segments := providers.SplitNodeSegments(req.Text)
+	translations, detected, err := p.translateTexts(ctx, segments.Texts(), params)
+	if err != nil {
+		return nil, err
+	}
+	text := segments.Join(translations)
+
+	// 返回响应
+	metadata := make(map[string]interface{})
+	if detected != "" {
+		metadata["detected_source"] = detected
+	}
+	if glossaryID := params.Get("glossary_id"); glossaryID != "" {
+		metadata["glossary_id"] = glossaryID
+	}
+
+	return &providers.ProviderResponse{
+		Text:     text,
+		Metadata: metadata,
+	}, nil
+}
+
+// TranslateTexts 翻译多个文本，每个请求最多发送 MaxTextsPerRequest 个文本，配置了词汇表时自动使用
+func (p *Provider) TranslateTexts(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	params, err := p.requestParams(ctx, &providers.ProviderRequest{SourceLanguage: sourceLang, TargetLanguage: targetLang})
+	if err != nil {
+		return nil, err
+	}
+	translations, _, err := p.translateTexts(ctx, texts, params)
+	return translations, err
+}
+
+// requestParams 构建请求参数：语言、可选的 formality、preserve_formatting、tag_handling 和 glossary_id。
+// 元数据中的 glossary_id 优先于配置的词汇表
+func (p *Provider) requestParams(ctx context.Context, req *providers.ProviderRequest) (url.Values, error) {
 	params := url.Values{}
-	params.Set("text", req.Text)
-	params.Set("source_lang", normalizeLanguageCode(req.SourceLanguage, true))
-	params.Set("target_lang", normalizeLanguageCode(req.TargetLanguage, false))
+	sourceLang := normalizeLanguageCode(req.SourceLanguage, true)
+	targetLang := normalizeLanguageCode(req.TargetLanguage, false)
+	params.Set("source_lang", sourceLang)
+	params.Set("target_lang", targetLang)
 
 	// 可选参数
 	if req.Metadata != nil {
@@ -101,28 +170,66 @@ func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest
 				params.Set("tag_handling", tagHandlingStr)
 			}
 		}
+		if glossaryID, ok := req.Metadata["glossary_id"].(string); ok && glossaryID != "" {
+			params.Set("glossary_id", glossaryID)
+		}
 	}
 
-	// 执行翻译
-	resp, err := p.translate(ctx, params)
-	if err != nil {
-		return nil, err
+	if params.Get("glossary_id") == "" {
+		glossaryID, err := p.glossaryFor(ctx, sourceLang, targetLang)
+		if err != nil {
+			return nil, err
+		}
+		if glossaryID != "" {
+			params.Set("glossary_id", glossaryID)
+		}
 	}
+	return params, nil
+}
 
-	if len(resp.Translations) == 0 {
-		return nil, fmt.Errorf("no translation returned")
+// translateTexts 按 DeepL 的文本数和请求大小限制分批翻译，返回译文和检测到的源语言
+func (p *Provider) translateTexts(ctx context.Context, texts []string, params url.Values) ([]string, string, error) {
+	limit := p.config.MaxTextsPerRequest
+	if limit <= 0 || limit > maxTextsPerRequest {
+		limit = maxTextsPerRequest
 	}
+	baseSize := len(params.Encode())
+
+	translations := make([]string, 0, len(texts))
+	detected := ""
+	for start := 0; start < len(texts); {
+		end, size := start, baseSize
+		for end < len(texts) && end-start < limit {
+			textSize := len("&text=") + len(url.QueryEscape(texts[end]))
+			if end > start && size+textSize > maxRequestBytes {
+				break
+			}
+			size += textSize
+			end++
+		}
 
-	// 返回响应
-	metadata := make(map[string]interface{})
-	if resp.Translations[0].DetectedSourceLanguage != "" {
-		metadata["detected_source"] = resp.Translations[0].DetectedSourceLanguage
-	}
+		batch := make(url.Values, len(params)+1)
+		for key, values := range params {
+			batch[key] = values
+		}
+		batch["text"] = texts[start:end]
 
-	return &providers.ProviderResponse{
-		Text:     resp.Translations[0].Text,
-		Metadata: metadata,
-	}, nil
+		resp, err := p.translate(ctx, batch)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(resp.Translations) != end-start {
+			return nil, "", fmt.Errorf("expected %d translations, got %d", end-start, len(resp.Translations))
+		}
+		for _, translation := range resp.Translations {
+			translations = append(translations, translation.Text)
+			if detected == "" {
+				detected = translation.DetectedSourceLanguage
+			}
+		}
+		start = end
+	}
+	return translations, detected, nil
 }
 
 // GetName 获取提供商名称
@@ -207,6 +314,51 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ListLanguages 通过 /languages 获取支持的源语言和目标语言
+func (p *Provider) ListLanguages(ctx context.Context) (source, target []providers.Language, err error) {
+	if source, err = p.languages(ctx, "source"); err != nil {
+		return nil, nil, err
+	}
+	if target, err = p.languages(ctx, "target"); err != nil {
+		return nil, nil, err
+	}
+	return source, target, nil
+}
+
+// languages 获取指定类型（source 或 target）的语言列表
+func (p *Provider) languages(ctx context.Context, languageType string) ([]providers.Language, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		p.config.APIEndpoint+"/languages?type="+languageType, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+p.config.APIKey)
+
+	resp, err := p.retryClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("languages request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list %s languages: %s", languageType, resp.Status)
+	}
+
+	var languages []struct {
+		Language string `json:"language"`
+		Name     string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&languages); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := make([]providers.Language, len(languages))
+	for i, lang := range languages {
+		result[i] = providers.Language{Code: lang.Language, Name: lang.Name}
+	}
+	return result, nil
+}
+
 // translate 执行翻译请求
 func (p *Provider) translate(ctx context.Context, params url.Values) (*TranslateResponse, error) {
 	// 创建请求
@@ -0,0 +1,300 @@
+package deepl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDeepL 模拟 DeepL 的 translate 和 glossaries 接口
+type mockDeepL struct {
+	mu         sync.Mutex
+	glossaries map[string]GlossaryInfo
+	nextID     int
+	requests   []string // 方法和路径
+	translates []url.Values
+}
+
+func newMockDeepL(t *testing.T) (*mockDeepL, *httptest.Server) {
+	mock := &mockDeepL{glossaries: make(map[string]GlossaryInfo)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DeepL-Auth-Key test-key", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseForm())
+
+		mock.mu.Lock()
+		defer mock.mu.Unlock()
+		mock.requests = append(mock.requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.URL.Path == "/translate":
+			mock.translates = append(mock.translates, r.PostForm)
+			var resp TranslateResponse
+			for _, text := range r.PostForm["text"] {
+				translated := strings.ToUpper(text)
+				if id := r.PostForm.Get("glossary_id"); id != "" {
+					translated = "[" + id + "] " + translated
+				}
+				resp.Translations = append(resp.Translations, struct {
+					DetectedSourceLanguage string `json:"detected_source_language"`
+					Text                   string `json:"text"`
+				}{DetectedSourceLanguage: "EN", Text: translated})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case r.URL.Path == "/glossaries" && r.Method == http.MethodGet:
+			var list []GlossaryInfo
+			for _, glossary := range mock.glossaries {
+				list = append(list, glossary)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"glossaries": list})
+		case r.URL.Path == "/glossaries" && r.Method == http.MethodPost:
+			if r.PostForm.Get("target_lang") == "xx" {
+				http.Error(w, `{"message":"Unsupported glossary language pair"}`, http.StatusBadRequest)
+				return
+			}
+			assert.Equal(t, "tsv", r.PostForm.Get("entries_format"))
+			mock.nextID++
+			glossary := GlossaryInfo{
+				GlossaryID: fmt.Sprintf("g%d", mock.nextID),
+				Name:       r.PostForm.Get("name"),
+				Ready:      true,
+				SourceLang: r.PostForm.Get("source_lang"),
+				TargetLang: r.PostForm.Get("target_lang"),
+				EntryCount: strings.Count(r.PostForm.Get("entries"), "\n"),
+			}
+			mock.glossaries[glossary.GlossaryID] = glossary
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(glossary)
+		case r.URL.Path == "/languages":
+			languages := []map[string]string{{"language": "EN", "name": "English"}, {"language": "DE", "name": "German"}}
+			if r.URL.Query().Get("type") == "target" {
+				languages = []map[string]string{{"language": "EN-US", "name": "English (American)"}, {"language": "DE", "name": "German"}, {"language": "ZH", "name": "Chinese"}}
+			}
+			_ = json.NewEncoder(w).Encode(languages)
+		case strings.HasPrefix(r.URL.Path, "/glossaries/") && r.Method == http.MethodDelete:
+			delete(mock.glossaries, strings.TrimPrefix(r.URL.Path, "/glossaries/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return mock, server
+}
+
+// reset 清空请求记录
+func (m *mockDeepL) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = nil
+	m.translates = nil
+}
+
+func newTestProvider(endpoint string, configure func(*Config)) *Provider {
+	config := DefaultConfig()
+	config.APIKey = "test-key"
+	config.APIEndpoint = endpoint
+	config.RetryConfig.MaxRetries = 0
+	config.RetryConfig.NetworkMaxRetries = 0
+	if configure != nil {
+		configure(&config)
+	}
+	return New(config)
+}
+
+func TestTranslateBatchesNodes(t *testing.T) {
+	mock, server := newMockDeepL(t)
+	provider := newTestProvider(server.URL, nil)
+
+	text := "@@NODE_START_1@@\nhello\n@@NODE_END_1@@\n\n@@NODE_START_2@@\nworld\n@@NODE_END_2@@\n\n@@NODE_START_3@@\nagain\n@@NODE_END_3@@"
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:           text,
+		SourceLanguage: "English",
+		TargetLanguage: "German",
+		Metadata:       map[string]interface{}{"tag_handling": "xml"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "@@NODE_START_1@@\nHELLO\n@@NODE_END_1@@\n\n@@NODE_START_2@@\nWORLD\n@@NODE_END_2@@\n\n@@NODE_START_3@@\nAGAIN\n@@NODE_END_3@@", resp.Text)
+	assert.Equal(t, "EN", resp.Metadata["detected_source"])
+	require.Len(t, mock.translates, 1, "one HTTP call per group")
+	assert.Equal(t, []string{"hello", "world", "again"}, mock.translates[0]["text"])
+	assert.Equal(t, "EN", mock.translates[0].Get("source_lang"))
+	assert.Equal(t, "DE", mock.translates[0].Get("target_lang"))
+	assert.Equal(t, "xml", mock.translates[0].Get("tag_handling"))
+
+	// 没有节点标记时整体作为一个文本
+	mock.reset()
+	resp, err = provider.Translate(context.Background(), &providers.ProviderRequest{Text: "plain text", SourceLanguage: "en", TargetLanguage: "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "PLAIN TEXT", resp.Text)
+	assert.Equal(t, []string{"plain text"}, mock.translates[0]["text"])
+}
+
+func TestTranslateTextsRespectsRequestLimit(t *testing.T) {
+	mock, server := newMockDeepL(t)
+	provider := newTestProvider(server.URL, func(c *Config) { c.MaxTextsPerRequest = 2 })
+
+	translations, err := provider.TranslateTexts(context.Background(), []string{"a", "b", "c", "d", "e"}, "en", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B", "C", "D", "E"}, translations)
+	require.Len(t, mock.translates, 3)
+	assert.Equal(t, []string{"e"}, mock.translates[2]["text"])
+}
+
+func TestGlossarySync(t *testing.T) {
+	mock, server := newMockDeepL(t)
+	dir := t.TempDir()
+	glossaryPath := filepath.Join(dir, "terms.yaml")
+	require.NoError(t, os.WriteFile(glossaryPath, []byte(`terms:
+  - source: Cloud
+    target: Wolke
+  - source: 'v\d+'
+    target: Version
+    match_type: regex
+categories:
+  ui:
+    - source: Save
+      target: Speichern
+`), 0o644))
+
+	translate := func(provider *Provider, metadata map[string]interface{}) string {
+		resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+			Text: "Cloud", SourceLanguage: "English", TargetLanguage: "German", Metadata: metadata,
+		})
+		require.NoError(t, err)
+		return resp.Text
+	}
+
+	// 第一次运行创建词汇表，之后的请求使用缓存的 ID
+	first := newTestProvider(server.URL, func(c *Config) { c.GlossaryPath = glossaryPath })
+	assert.Equal(t, "[g1] CLOUD", translate(first, nil))
+	assert.Equal(t, "[g1] CLOUD", translate(first, nil))
+	assert.Equal(t, []string{"GET /glossaries", "POST /glossaries", "POST /translate", "POST /translate"}, mock.requests)
+	created := mock.glossaries["g1"]
+	assert.Equal(t, "en", created.SourceLang)
+	assert.Equal(t, "de", created.TargetLang)
+	assert.Equal(t, 2, created.EntryCount, "regex terms are skipped")
+	owner := first.glossaryOwner()
+	assert.Len(t, owner, 8)
+	assert.True(t, strings.HasPrefix(created.Name, "go-translator-agent-terms-"+owner+"-en-de-"))
+
+	// 新的运行按内容哈希复用已有的词汇表
+	mock.reset()
+	second := newTestProvider(server.URL, func(c *Config) { c.GlossaryPath = glossaryPath })
+	assert.Equal(t, "[g1] CLOUD", translate(second, nil))
+	assert.Equal(t, []string{"GET /glossaries", "POST /translate"}, mock.requests)
+
+	// 元数据中的 glossary_id 优先
+	assert.Equal(t, "[custom] CLOUD", translate(second, map[string]interface{}{"glossary_id": "custom"}))
+
+	// 共享账户中另一个项目的同名词汇表文件，以及手动创建的同前缀词汇表
+	otherPath := filepath.Join(t.TempDir(), "terms.yaml")
+	require.NoError(t, os.WriteFile(otherPath, []byte("terms:\n  - source: Cloud\n    target: Wolken\n"), 0o644))
+	other := newTestProvider(server.URL, func(c *Config) { c.GlossaryPath = otherPath })
+	assert.Equal(t, "[g2] CLOUD", translate(other, nil))
+	mock.glossaries["manual"] = GlossaryInfo{GlossaryID: "manual", Name: "go-translator-agent-terms-" + owner + "-en-de-manual"}
+
+	// 词汇表文件变化后创建新词汇表，只删除本配置创建的旧版本
+	require.NoError(t, os.WriteFile(glossaryPath, []byte("terms:\n  - source: Cloud\n    target: Rechnerwolke\n"), 0o644))
+	mock.reset()
+	third := newTestProvider(server.URL, func(c *Config) { c.GlossaryPath = glossaryPath })
+	assert.Equal(t, "[g3] CLOUD", translate(third, nil))
+	assert.Equal(t, []string{"GET /glossaries", "POST /glossaries", "DELETE /glossaries/g1", "POST /translate"}, mock.requests)
+	assert.Len(t, mock.glossaries, 3)
+	assert.Contains(t, mock.glossaries, "g2")
+	assert.Contains(t, mock.glossaries, "manual")
+
+	// 配置的 GlossaryKey 代替路径哈希
+	keyed := newTestProvider(server.URL, func(c *Config) { c.GlossaryPath = glossaryPath; c.GlossaryKey = "docs-site" })
+	assert.Equal(t, "docs-site", keyed.glossaryOwner())
+}
+
+func TestGlossaryEntriesKeepOtherGlossaries(t *testing.T) {
+	mock, server := newMockDeepL(t)
+	translate := func(entries map[string]string) {
+		provider := newTestProvider(server.URL, func(c *Config) { c.GlossaryEntries = entries })
+		_, err := provider.Translate(context.Background(), &providers.ProviderRequest{Text: "Cloud", SourceLanguage: "en", TargetLanguage: "de"})
+		require.NoError(t, err)
+	}
+
+	// 直接提供术语且没有 GlossaryKey 时无法区分所属配置，不删除任何词汇表
+	translate(map[string]string{"Cloud": "Wolke"})
+	translate(map[string]string{"Cloud": "Wolken"})
+	assert.Len(t, mock.glossaries, 2)
+	assert.NotContains(t, mock.requests, "DELETE /glossaries/g1")
+}
+
+func TestGlossaryUnsupportedLanguagePair(t *testing.T) {
+	mock, server := newMockDeepL(t)
+	provider := newTestProvider(server.URL, func(c *Config) {
+		c.GlossaryEntries = map[string]string{"Cloud": "Nube"}
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{Text: "Cloud", SourceLanguage: "en", TargetLanguage: "xx"})
+		require.NoError(t, err)
+		assert.Equal(t, "CLOUD", resp.Text)
+	}
+	assert.Equal(t, []string{"GET /glossaries", "POST /glossaries", "POST /translate", "POST /translate"}, mock.requests)
+}
+
+func TestLoadGlossaryEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	tsvPath := filepath.Join(dir, "terms.tsv")
+	require.NoError(t, os.WriteFile(tsvPath, []byte("Cloud\tNuage\nSave\tEnregistrer\nbroken\n"), 0o644))
+	entries, err := LoadGlossaryEntries(tsvPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Cloud": "Nuage", "Save": "Enregistrer"}, entries)
+
+	jsonPath := filepath.Join(dir, "terms.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"terms":[{"source":"Cloud","target":"云"},{"source":"empty","target":""}]}`), 0o644))
+	entries, err = LoadGlossaryEntries(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Cloud": "云"}, entries)
+
+	assert.Equal(t, "Cloud\t云\nSave\t保存\n", glossaryEntriesTSV(map[string]string{"Save": "保存", "Cloud": "云"}))
+
+	_, err = LoadGlossaryEntries(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestListLanguages(t *testing.T) {
+	_, server := newMockDeepL(t)
+	provider := newTestProvider(server.URL, nil)
+
+	source, target, err := provider.ListLanguages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []providers.Language{{Code: "EN", Name: "English"}, {Code: "DE", Name: "German"}}, source)
+	require.Len(t, target, 3)
+
+	assert.True(t, providers.SupportsLanguage(source, "English"))
+	assert.True(t, providers.SupportsLanguage(target, "en"), "en matches EN-US")
+	assert.True(t, providers.SupportsLanguage(target, "zh_CN"))
+	assert.True(t, providers.SupportsLanguage(target, "Chinese"))
+	assert.False(t, providers.SupportsLanguage(source, "Chinese"), "ZH is only a target language")
+	assert.False(t, providers.SupportsLanguage(target, "Japanese"))
+
+	// 挪威语对应 DeepL 列出的 NB
+	norwegian := []providers.Language{{Code: "NB", Name: "Norwegian Bokmål"}}
+	assert.True(t, providers.SupportsLanguage(norwegian, "Norwegian"))
+	assert.True(t, providers.SupportsLanguage(norwegian, "no"))
+
+	code, ok := providers.LanguageCode("pt_BR")
+	assert.True(t, ok)
+	assert.Equal(t, "pt-br", code)
+	_, ok = providers.LanguageCode("Elvish")
+	assert.False(t, ok)
+}
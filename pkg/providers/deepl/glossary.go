@@ -0,0 +1,325 @@
+package deepl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// glossaryNamePrefix 本工具创建的 DeepL 词汇表名称前缀
+const glossaryNamePrefix = "go-translator-agent"
+
+// GlossaryInfo DeepL 词汇表信息
+type GlossaryInfo struct {
+	GlossaryID string `json:"glossary_id"`
+	Name       string `json:"name"`
+	Ready      bool   `json:"ready"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	EntryCount int    `json:"entry_count"`
+}
+
+// glossaryFile 词汇表文件中使用的字段，与翻译后处理的词汇表格式相同
+type glossaryFile struct {
+	Terms      []glossaryTerm            `json:"terms" yaml:"terms"`
+	Categories map[string][]glossaryTerm `json:"categories" yaml:"categories"`
+}
+
+type glossaryTerm struct {
+	Source    string `json:"source" yaml:"source"`
+	Target    string `json:"target" yaml:"target"`
+	MatchType string `json:"match_type" yaml:"match_type"`
+}
+
+// LoadGlossaryEntries 读取词汇表文件中的术语。支持 JSON/YAML 格式的词汇表（terms 和 categories），
+// 以及每行“原文<Tab>译文”或“原文,译文”的 TSV/CSV 文件。正则和模糊匹配的术语无法作为 DeepL 词汇表使用，会被跳过
+func LoadGlossaryEntries(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary file: %w", err)
+	}
+
+	entries := make(map[string]string)
+	add := func(source, target string) {
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if source == "" || target == "" || strings.ContainsAny(source+target, "\t\r\n") {
+			return
+		}
+		entries[source] = target
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".csv":
+		reader := csv.NewReader(strings.NewReader(string(data)))
+		reader.FieldsPerRecord = -1
+		if strings.EqualFold(filepath.Ext(path), ".tsv") {
+			reader.Comma = '\t'
+			reader.LazyQuotes = true
+		}
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse glossary file: %w", err)
+		}
+		for _, record := range records {
+			if len(record) >= 2 {
+				add(record[0], record[1])
+			}
+		}
+	default:
+		var file glossaryFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			if yamlErr := yaml.Unmarshal(data, &file); yamlErr != nil {
+				return nil, fmt.Errorf("failed to parse glossary file: %w", yamlErr)
+			}
+		}
+		terms := file.Terms
+		for _, category := range file.Categories {
+			terms = append(terms, category...)
+		}
+		for _, term := range terms {
+			if term.MatchType == "" || term.MatchType == "exact" {
+				add(term.Source, term.Target)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// glossaryEntriesTSV 按原文排序，把术语编码为 DeepL 的 TSV 格式
+func glossaryEntriesTSV(entries map[string]string) string {
+	sources := make([]string, 0, len(entries))
+	for source := range entries {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var tsv strings.Builder
+	for _, source := range sources {
+		tsv.WriteString(source)
+		tsv.WriteByte('\t')
+		tsv.WriteString(entries[source])
+		tsv.WriteByte('\n')
+	}
+	return tsv.String()
+}
+
+// glossaryLanguage 返回 DeepL 词汇表使用的语言代码（不带地区，如 en、pt）
+func glossaryLanguage(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return strings.ToLower(base)
+}
+
+// glossaryHashLength 词汇表名称中内容哈希的长度
+const glossaryHashLength = 16
+
+// glossaryOwner 返回区分本配置创建的词汇表的标识：配置的 GlossaryKey，否则为词汇表文件绝对路径的哈希。
+// 直接提供术语且没有配置 GlossaryKey 时返回空字符串，此时无法确定哪些旧版本属于本配置
+func (p *Provider) glossaryOwner() string {
+	if p.config.GlossaryKey != "" {
+		return p.config.GlossaryKey
+	}
+	if p.config.GlossaryEntries != nil || p.config.GlossaryPath == "" {
+		return ""
+	}
+	path, err := filepath.Abs(p.config.GlossaryPath)
+	if err != nil {
+		path = p.config.GlossaryPath
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// glossaryName 返回词汇表在 DeepL 上的名称：前缀、词汇表文件名、配置标识、语言对和内容哈希。
+// 名称相同说明内容相同，可以直接复用；前缀相同而哈希不同的是本配置创建的旧版本
+func (p *Provider) glossaryName(sourceLang, targetLang, tsv string) (prefix, name string) {
+	source := p.config.GlossaryPath
+	if source == "" || p.config.GlossaryEntries != nil {
+		source = "entries"
+	}
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	if owner := p.glossaryOwner(); owner != "" {
+		base += "-" + owner
+	}
+	prefix = fmt.Sprintf("%s-%s-%s-%s-", glossaryNamePrefix, base, sourceLang, targetLang)
+
+	sum := sha256.Sum256([]byte(sourceLang + "\n" + targetLang + "\n" + tsv))
+	return prefix, prefix + hex.EncodeToString(sum[:])[:glossaryHashLength]
+}
+
+// isStaleGlossary 判断词汇表是否为本配置创建的旧版本：名称为前缀加内容哈希，且本配置有标识
+func (p *Provider) isStaleGlossary(name, prefix string) bool {
+	if p.glossaryOwner() == "" || !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	hash := strings.TrimPrefix(name, prefix)
+	if len(hash) != glossaryHashLength {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// glossaryEntries 返回配置的术语，首次调用时读取词汇表文件
+func (p *Provider) glossaryEntries() (map[string]string, error) {
+	p.entriesOnce.Do(func() {
+		p.entries = p.config.GlossaryEntries
+		if p.entries == nil && p.config.GlossaryPath != "" {
+			p.entries, p.entriesErr = LoadGlossaryEntries(p.config.GlossaryPath)
+		}
+	})
+	return p.entries, p.entriesErr
+}
+
+// glossaryFor 返回语言对使用的 DeepL 词汇表 ID，没有术语时返回空字符串。
+// 按内容哈希复用 DeepL 上已有的词汇表；内容变化时创建新词汇表，并删除本配置（同一词汇表文件或 GlossaryKey）
+// 创建的旧版本，共享账户中其他项目的词汇表不受影响。
+// DeepL 不支持该语言对的词汇表时不使用词汇表
+func (p *Provider) glossaryFor(ctx context.Context, sourceLang, targetLang string) (string, error) {
+	entries, err := p.glossaryEntries()
+	if err != nil || len(entries) == 0 {
+		return "", err
+	}
+	sourceLang, targetLang = glossaryLanguage(sourceLang), glossaryLanguage(targetLang)
+	if sourceLang == "" || sourceLang == "auto" || sourceLang == targetLang {
+		return "", nil
+	}
+
+	tsv := glossaryEntriesTSV(entries)
+	prefix, name := p.glossaryName(sourceLang, targetLang, tsv)
+
+	p.glossaryMu.Lock()
+	defer p.glossaryMu.Unlock()
+	if id, ok := p.glossaries[name]; ok {
+		return id, nil
+	}
+
+	existing, err := p.ListGlossaries(ctx)
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	var stale []string
+	for _, glossary := range existing {
+		switch {
+		case glossary.Name == name && id == "":
+			id = glossary.GlossaryID
+		case p.isStaleGlossary(glossary.Name, prefix):
+			stale = append(stale, glossary.GlossaryID)
+		}
+	}
+
+	if id == "" {
+		created, err := p.CreateGlossary(ctx, name, sourceLang, targetLang, tsv)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.statusCode == http.StatusBadRequest {
+			p.glossaries[name] = ""
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		id = created.GlossaryID
+	}
+	for _, staleID := range stale {
+		// 删除失败不影响翻译，下次同步时重试
+		_ = p.DeleteGlossary(ctx, staleID)
+	}
+
+	p.glossaries[name] = id
+	return id, nil
+}
+
+// ListGlossaries 列出账户中的词汇表
+func (p *Provider) ListGlossaries(ctx context.Context) ([]GlossaryInfo, error) {
+	var resp struct {
+		Glossaries []GlossaryInfo `json:"glossaries"`
+	}
+	if err := p.doJSON(ctx, http.MethodGet, "/glossaries", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list glossaries: %w", err)
+	}
+	return resp.Glossaries, nil
+}
+
+// CreateGlossary 创建词汇表，entries 为 TSV 格式
+func (p *Provider) CreateGlossary(ctx context.Context, name, sourceLang, targetLang, entries string) (*GlossaryInfo, error) {
+	params := url.Values{}
+	params.Set("name", name)
+	params.Set("source_lang", sourceLang)
+	params.Set("target_lang", targetLang)
+	params.Set("entries", entries)
+	params.Set("entries_format", "tsv")
+
+	var glossary GlossaryInfo
+	if err := p.doJSON(ctx, http.MethodPost, "/glossaries", params, &glossary); err != nil {
+		return nil, fmt.Errorf("failed to create glossary: %w", err)
+	}
+	return &glossary, nil
+}
+
+// DeleteGlossary 删除词汇表
+func (p *Provider) DeleteGlossary(ctx context.Context, glossaryID string) error {
+	if err := p.doJSON(ctx, http.MethodDelete, "/glossaries/"+url.PathEscape(glossaryID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete glossary: %w", err)
+	}
+	return nil
+}
+
+// apiError DeepL API 返回的错误状态
+type apiError struct {
+	statusCode int
+	message    string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// doJSON 发送表单请求并解析 JSON 响应，out 为 nil 时忽略响应体
+func (p *Provider) doJSON(ctx context.Context, method, path string, params url.Values, out interface{}) error {
+	var body io.Reader
+	if params != nil {
+		body = strings.NewReader(params.Encode())
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.config.APIEndpoint+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if params != nil {
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	httpReq.Header.Set("Authorization", "DeepL-Auth-Key "+p.config.APIKey)
+	for k, v := range p.config.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.retryClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return &apiError{statusCode: resp.StatusCode, message: fmt.Sprintf("API error: %s %s", resp.Status, strings.TrimSpace(string(errBody)))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
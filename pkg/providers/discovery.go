@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// ModelLister 可选接口：列出服务端可用的模型（如 OpenAI 的 /models、Ollama 的 /api/tags）
+type ModelLister interface {
+	// ListModels 返回模型 ID 列表
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// LanguageLister 可选接口：列出服务端支持的语言（如 DeepL、LibreTranslate 的 /languages）
+type LanguageLister interface {
+	// ListLanguages 分别返回支持的源语言和目标语言
+	ListLanguages(ctx context.Context) (source, target []Language, err error)
+}
+
+// ProbeResult 提供商探测结果
+type ProbeResult struct {
+	// Name 提供商名称
+	Name string
+	// HealthError 健康检查错误，为 nil 表示服务可用
+	HealthError error
+	// Models 服务端可用的模型，提供商不支持列出模型时为 nil
+	Models []string
+	// ModelsError 列出模型失败的原因
+	ModelsError error
+	// SourceLanguages / TargetLanguages 服务端支持的语言，提供商不支持列出语言时为 nil
+	SourceLanguages []Language
+	TargetLanguages []Language
+	// LanguagesError 列出语言失败的原因
+	LanguagesError error
+	// ContextWindow 模型的上下文长度（token 数），未知时为 0
+	ContextWindow int
+	// Capabilities 用探测到的语言更新后的提供商能力
+	Capabilities Capabilities
+}
+
+// Healthy 服务是否可用
+func (r *ProbeResult) Healthy() bool {
+	return r.HealthError == nil
+}
+
+// Probe 探测提供商：执行健康检查，并通过可选接口获取模型、语言和上下文长度
+func Probe(ctx context.Context, provider TranslationProvider) *ProbeResult {
+	result := &ProbeResult{Name: provider.GetName()}
+
+	if p, ok := provider.(interface{ GetCapabilities() Capabilities }); ok {
+		result.Capabilities = p.GetCapabilities()
+	}
+	if p, ok := provider.(interface{ HealthCheck(context.Context) error }); ok {
+		result.HealthError = p.HealthCheck(ctx)
+	}
+	if p, ok := provider.(ModelLister); ok {
+		result.Models, result.ModelsError = p.ListModels(ctx)
+	}
+	if p, ok := provider.(LanguageLister); ok {
+		result.SourceLanguages, result.TargetLanguages, result.LanguagesError = p.ListLanguages(ctx)
+		if result.LanguagesError == nil {
+			result.Capabilities.SupportedLanguages = mergeLanguages(result.SourceLanguages, result.TargetLanguages)
+		}
+	}
+	if p, ok := provider.(ContextWindowProvider); ok {
+		if n, err := p.ContextWindow(ctx); err == nil {
+			result.ContextWindow = n
+		}
+	}
+	return result
+}
+
+// mergeLanguages 合并语言列表，按代码去重
+func mergeLanguages(lists ...[]Language) []Language {
+	seen := make(map[string]bool)
+	var merged []Language
+	for _, list := range lists {
+		for _, lang := range list {
+			code := strings.ToLower(lang.Code)
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			merged = append(merged, lang)
+		}
+	}
+	return merged
+}
+
+// languageCodes 常见语言名称到 ISO 639-1 代码的映射
+var languageCodes = map[string]string{
+	"arabic":              "ar",
+	"chinese":             "zh",
+	"simplified chinese":  "zh",
+	"traditional chinese": "zh",
+	"czech":               "cs",
+	"danish":              "da",
+	"dutch":               "nl",
+	"english":             "en",
+	"finnish":             "fi",
+	"french":              "fr",
+	"german":              "de",
+	"greek":               "el",
+	"hindi":               "hi",
+	"hungarian":           "hu",
+	"indonesian":          "id",
+	"italian":             "it",
+	"japanese":            "ja",
+	"korean":              "ko",
+	"norwegian":           "nb", // DeepL 和 LibreTranslate 列出的是书面挪威语 NB
+	"norwegian bokmal":    "nb",
+	"norwegian bokmål":    "nb",
+	"no":                  "nb", // 挪威语的宏语言代码
+	"polish":              "pl",
+	"portuguese":          "pt",
+	"romanian":            "ro",
+	"russian":             "ru",
+	"spanish":             "es",
+	"swedish":             "sv",
+	"thai":                "th",
+	"turkish":             "tr",
+	"ukrainian":           "uk",
+	"vietnamese":          "vi",
+}
+
+// languageCodePattern 语言代码，如 "zh"、"pt-br"、"zh-hans"
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// LanguageCode 把语言名称（"Norwegian"）或代码（"pt_BR"）规范为小写的语言代码（"nb"、"pt-br"）。
+// 既不是已知的语言名称也不像语言代码时 ok 为 false
+func LanguageCode(lang string) (code string, ok bool) {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+	if mapped, exists := languageCodes[code]; exists {
+		return mapped, true
+	}
+	return code, languageCodePattern.MatchString(code)
+}
+
+// SupportsLanguage 判断语言列表是否包含 lang。lang 可以是语言名称（"Chinese"）
+// 或代码（"zh"、"pt_BR"），代码按主语言匹配，如 "en" 匹配 DeepL 的 "EN-US"
+func SupportsLanguage(languages []Language, lang string) bool {
+	code, _ := LanguageCode(lang)
+	base := baseLanguage(code)
+
+	for _, l := range languages {
+		if strings.EqualFold(l.Name, lang) {
+			return true
+		}
+		candidate := strings.ToLower(strings.ReplaceAll(l.Code, "_", "-"))
+		if candidate == code || baseLanguage(candidate) == base {
+			return true
+		}
+	}
+	return false
+}
+
+// baseLanguage 返回语言代码的主语言部分
+func baseLanguage(code string) string {
+	if i := strings.Index(code, "-"); i > 0 {
+		return code[:i]
+	}
+	return code
+}
@@ -0,0 +1,167 @@
+package google
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cloudTranslationScope Cloud Translation API 的 OAuth 范围
+	cloudTranslationScope = "https://www.googleapis.com/auth/cloud-translation"
+	// defaultTokenURI 服务账号密钥中没有 token_uri 时使用的令牌端点
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+)
+
+// ServiceAccount Google Cloud 服务账号密钥（JSON 密钥文件中使用的字段）
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// LoadServiceAccount 读取服务账号密钥，参数可以是 JSON 密钥文件路径或 JSON 内容本身
+func LoadServiceAccount(pathOrJSON string) (*ServiceAccount, error) {
+	data := []byte(pathOrJSON)
+	if !strings.HasPrefix(strings.TrimSpace(pathOrJSON), "{") {
+		var err error
+		data, err = os.ReadFile(pathOrJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account key: %w", err)
+		}
+	}
+
+	var account ServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("service account key must contain client_email and private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+	return &account, nil
+}
+
+// tokenSource 用服务账号在本地签名 JWT 换取访问令牌，令牌过期前复用
+type tokenSource struct {
+	account *ServiceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newTokenSource 解析服务账号的 PEM 私钥
+func newTokenSource(account *ServiceAccount, client *http.Client) (*tokenSource, error) {
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account private key is not PEM encoded")
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("service account private key is not an RSA key")
+		}
+		key = rsaKey
+	} else if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = rsaKey
+	} else {
+		return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	return &tokenSource{account: account, key: key, client: client}, nil
+}
+
+// Token 返回有效的访问令牌，过期前一分钟刷新
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Add(time.Minute).Before(s.expires) {
+		return s.token, nil
+	}
+
+	assertion, err := s.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request access token: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid access token response: %s", strings.TrimSpace(string(body)))
+	}
+	s.token = token.AccessToken
+	s.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// signJWT 生成用 RS256 签名的 JWT 断言，有效期一小时
+func (s *tokenSource) signJWT(now time.Time) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.account.PrivateKeyID}
+	claims := map[string]interface{}{
+		"iss":   s.account.ClientEmail,
+		"scope": cloudTranslationScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+
+	var parts []string
+	for _, part := range []interface{}{header, claims} {
+		data, err := json.Marshal(part)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, base64.RawURLEncoding.EncodeToString(data))
+	}
+	signingInput := strings.Join(parts, ".")
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
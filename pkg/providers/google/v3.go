@@ -0,0 +1,413 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/retry"
+)
+
+const (
+	// defaultV3Endpoint Cloud Translation v3 REST 端点
+	defaultV3Endpoint = "https://translation.googleapis.com/v3"
+	// maxContentsPerRequest translateText 单次请求的最大文本数
+	maxContentsPerRequest = 1024
+	// maxCodepointsPerRequest translateText 单次请求建议的最大字符数（按码点计算）
+	maxCodepointsPerRequest = 30000
+)
+
+// ConfigV3 Google Cloud Translation v3 配置
+type ConfigV3 struct {
+	providers.BaseConfig
+	// CredentialsFile 服务账号 JSON 密钥文件路径
+	CredentialsFile string `json:"credentials_file,omitempty"`
+	// CredentialsJSON 服务账号 JSON 密钥内容，优先于 CredentialsFile
+	CredentialsJSON string `json:"credentials_json,omitempty"`
+	// AccessToken 固定的访问令牌，设置后不使用服务账号
+	AccessToken string `json:"access_token,omitempty"`
+	// ProjectID 项目 ID，为空时使用服务账号中的 project_id
+	ProjectID string `json:"project_id,omitempty"`
+	// Location 区域，默认 global；词汇表和自定义模型需要使用具体区域（如 us-central1）
+	Location string `json:"location"`
+	// Model 模型：nmt、llm、自定义模型 ID 或完整的资源名，为空时由服务端选择
+	Model string `json:"model,omitempty"`
+	// Glossary 词汇表 ID 或完整的资源名
+	Glossary string `json:"glossary,omitempty"`
+	// GlossaryIgnoreCase 词汇表匹配时忽略大小写
+	GlossaryIgnoreCase bool `json:"glossary_ignore_case,omitempty"`
+	// AdaptiveDatasets 自适应翻译数据集，键为“源语言-目标语言”（如 en-de）或目标语言，
+	// 值为数据集 ID 或完整的资源名。匹配时使用 adaptiveMtTranslate
+	AdaptiveDatasets map[string]string `json:"adaptive_datasets,omitempty"`
+	// MimeType 文本类型 text/plain 或 text/html，元数据 format=html 时使用 text/html
+	MimeType    string            `json:"mime_type,omitempty"`
+	RetryConfig retry.RetryConfig `json:"retry_config"`
+}
+
+// DefaultConfigV3 返回默认配置
+func DefaultConfigV3() ConfigV3 {
+	config := ConfigV3{
+		BaseConfig:  providers.DefaultConfig(),
+		Location:    "global",
+		MimeType:    "text/plain",
+		RetryConfig: retry.DefaultRetryConfig(),
+	}
+	config.APIEndpoint = defaultV3Endpoint
+	return config
+}
+
+// ProviderV3 Google Cloud Translation v3 提供商
+type ProviderV3 struct {
+	config      ConfigV3
+	httpClient  *http.Client
+	retryClient *retry.RetryableHTTPClient
+	tokens      *tokenSource
+}
+
+// NewV3 创建 Google Cloud Translation v3 提供商。未设置 AccessToken 时读取服务账号密钥，
+// 访问令牌通过本地签名的 JWT 获取
+func NewV3(config ConfigV3) (*ProviderV3, error) {
+	if config.APIEndpoint == "" {
+		config.APIEndpoint = defaultV3Endpoint
+	}
+	config.APIEndpoint = strings.TrimRight(config.APIEndpoint, "/")
+	if config.Location == "" {
+		config.Location = "global"
+	}
+
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+	networkRetrier := retry.NewNetworkRetrier(config.RetryConfig)
+
+	provider := &ProviderV3{
+		config:      config,
+		httpClient:  httpClient,
+		retryClient: networkRetrier.WrapHTTPClient(httpClient),
+	}
+
+	if config.AccessToken == "" {
+		credentials := config.CredentialsJSON
+		if credentials == "" {
+			credentials = config.CredentialsFile
+		}
+		if credentials == "" {
+			return nil, fmt.Errorf("google v3 requires a service account key or an access token")
+		}
+		account, err := LoadServiceAccount(credentials)
+		if err != nil {
+			return nil, err
+		}
+		provider.tokens, err = newTokenSource(account, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		if provider.config.ProjectID == "" {
+			provider.config.ProjectID = account.ProjectID
+		}
+	}
+	if provider.config.ProjectID == "" {
+		return nil, fmt.Errorf("google v3 requires a project ID")
+	}
+
+	return provider, nil
+}
+
+// Configure 配置提供商
+func (p *ProviderV3) Configure(config interface{}) error {
+	cfg, ok := config.(ConfigV3)
+	if !ok {
+		return fmt.Errorf("invalid config type: expected ConfigV3")
+	}
+	updated, err := NewV3(cfg)
+	if err != nil {
+		return err
+	}
+	*p = *updated
+	return nil
+}
+
+// Translate 执行翻译。批量翻译的节点标记之间的文本作为多个 contents 在一次请求中发送
+func (p *ProviderV3) Translate(ctx context.Context, req *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	mimeType := p.config.MimeType
+	if format, ok := req.Metadata["format"].(string); ok && format == "html" {
+		mimeType = "text/html"
+	}
+	sourceLang := normalizeLanguageCode(req.SourceLanguage)
+	targetLang := normalizeLanguageCode(req.TargetLanguage)
+
+	segments := providers.SplitNodeSegments(req.Text)
+	translations, metadata, err := p.TranslateTexts(ctx, segments.Texts(), sourceLang, targetLang, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &providers.ProviderResponse{
+		Text:     segments.Join(translations),
+		Metadata: metadata,
+	}, nil
+}
+
+// TranslateTexts 翻译多个文本，按数量和字符数分成多次请求，返回与输入顺序一致的译文
+func (p *ProviderV3) TranslateTexts(ctx context.Context, texts []string, sourceLang, targetLang, mimeType string) ([]string, map[string]interface{}, error) {
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	metadata := map[string]interface{}{"model": "google-translate-v3"}
+	if sourceLang == "auto" {
+		sourceLang = ""
+	}
+	dataset := p.adaptiveDataset(sourceLang, targetLang)
+
+	translations := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); {
+		end, codepoints := start, 0
+		for end < len(texts) && end-start < maxContentsPerRequest {
+			size := utf8.RuneCountInString(texts[end])
+			if end > start && codepoints+size > maxCodepointsPerRequest {
+				break
+			}
+			codepoints += size
+			end++
+		}
+
+		var (
+			chunk []string
+			err   error
+		)
+		if dataset != "" {
+			chunk, err = p.adaptiveTranslate(ctx, dataset, texts[start:end], sourceLang, targetLang, metadata)
+		} else {
+			chunk, err = p.translateText(ctx, texts[start:end], sourceLang, targetLang, mimeType, metadata)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(chunk) != end-start {
+			return nil, nil, fmt.Errorf("expected %d translations, got %d", end-start, len(chunk))
+		}
+		translations = append(translations, chunk...)
+		start = end
+	}
+	return translations, metadata, nil
+}
+
+// translateText 调用 translateText 接口，使用词汇表时返回词汇表译文
+func (p *ProviderV3) translateText(ctx context.Context, texts []string, sourceLang, targetLang, mimeType string, metadata map[string]interface{}) ([]string, error) {
+	body := v3TranslateRequest{
+		Contents:           texts,
+		MimeType:           mimeType,
+		SourceLanguageCode: sourceLang,
+		TargetLanguageCode: targetLang,
+		Model:              p.modelPath(),
+	}
+	if p.config.Glossary != "" && sourceLang != "" {
+		body.GlossaryConfig = &v3GlossaryConfig{
+			Glossary:   p.resourcePath("glossaries", p.config.Glossary),
+			IgnoreCase: p.config.GlossaryIgnoreCase,
+		}
+	}
+
+	var resp v3TranslateResponse
+	if err := p.post(ctx, p.locationPath()+":translateText", body, &resp); err != nil {
+		return nil, err
+	}
+
+	translations := resp.Translations
+	if body.GlossaryConfig != nil && len(resp.GlossaryTranslations) > 0 {
+		translations = resp.GlossaryTranslations
+		metadata["glossary"] = body.GlossaryConfig.Glossary
+	}
+	result := make([]string, len(translations))
+	for i, translation := range translations {
+		result[i] = translation.TranslatedText
+		if translation.Model != "" {
+			metadata["model"] = translation.Model
+		}
+		if translation.DetectedLanguageCode != "" {
+			metadata["detected_source"] = translation.DetectedLanguageCode
+		}
+	}
+	return result, nil
+}
+
+// adaptiveTranslate 调用 adaptiveMtTranslate 接口，用数据集中的示例句对调整译文
+func (p *ProviderV3) adaptiveTranslate(ctx context.Context, dataset string, texts []string, sourceLang, targetLang string, metadata map[string]interface{}) ([]string, error) {
+	body := v3AdaptiveRequest{
+		Dataset: dataset,
+		Content: texts,
+	}
+
+	var resp v3AdaptiveResponse
+	if err := p.post(ctx, p.locationPath()+":adaptiveMtTranslate", body, &resp); err != nil {
+		return nil, err
+	}
+
+	metadata["model"] = "adaptive-mt"
+	metadata["adaptive_dataset"] = dataset
+	result := make([]string, len(resp.Translations))
+	for i, translation := range resp.Translations {
+		result[i] = translation.TranslatedText
+	}
+	return result, nil
+}
+
+// adaptiveDataset 返回语言对匹配的自适应翻译数据集资源名，没有匹配时返回空字符串
+func (p *ProviderV3) adaptiveDataset(sourceLang, targetLang string) string {
+	if len(p.config.AdaptiveDatasets) == 0 || sourceLang == "" {
+		return ""
+	}
+	for _, key := range []string{sourceLang + "-" + targetLang, targetLang} {
+		for configured, dataset := range p.config.AdaptiveDatasets {
+			if strings.EqualFold(configured, key) {
+				return p.resourcePath("adaptiveMtDatasets", dataset)
+			}
+		}
+	}
+	return ""
+}
+
+// locationPath 返回 projects/{project}/locations/{location}
+func (p *ProviderV3) locationPath() string {
+	return fmt.Sprintf("projects/%s/locations/%s", p.config.ProjectID, p.config.Location)
+}
+
+// resourcePath 把 ID 扩展为当前区域下的资源名，已经是完整资源名时原样返回
+func (p *ProviderV3) resourcePath(collection, id string) string {
+	if strings.HasPrefix(id, "projects/") {
+		return id
+	}
+	return p.locationPath() + "/" + collection + "/" + id
+}
+
+// modelPath 返回模型资源名：nmt 和 llm 对应通用模型，其他 ID 视为自定义模型
+func (p *ProviderV3) modelPath() string {
+	switch strings.ToLower(p.config.Model) {
+	case "":
+		return ""
+	case "nmt", "general/nmt":
+		return p.locationPath() + "/models/general/nmt"
+	case "llm", "translation-llm", "general/translation-llm":
+		return p.locationPath() + "/models/general/translation-llm"
+	default:
+		return p.resourcePath("models", p.config.Model)
+	}
+}
+
+// post 发送带访问令牌的 JSON 请求并解析响应
+func (p *ProviderV3) post(ctx context.Context, path string, body, out interface{}) error {
+	token := p.config.AccessToken
+	if token == "" {
+		var err error
+		if token, err = p.tokens.Token(ctx); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.APIEndpoint+"/"+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("x-goog-user-project", p.config.ProjectID)
+	for k, v := range p.config.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.retryClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		var apiErr APIError
+		if json.Unmarshal(errBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("Google API error: %s", apiErr.Error.Message)
+		}
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// GetName 获取提供商名称
+func (p *ProviderV3) GetName() string {
+	return "google-v3"
+}
+
+// SupportsSteps 不支持多步骤翻译
+func (p *ProviderV3) SupportsSteps() bool {
+	return false
+}
+
+// GetCapabilities 获取提供商能力，支持的语言与 v2 相同
+func (p *ProviderV3) GetCapabilities() providers.Capabilities {
+	capabilities := (&Provider{}).GetCapabilities()
+	capabilities.MaxTextLength = maxCodepointsPerRequest
+	return capabilities
+}
+
+// HealthCheck 健康检查
+func (p *ProviderV3) HealthCheck(ctx context.Context) error {
+	_, _, err := p.TranslateTexts(ctx, []string{"Hello"}, "en", "es", "text/plain")
+	return err
+}
+
+// v3TranslateRequest translateText 请求
+type v3TranslateRequest struct {
+	Contents           []string          `json:"contents"`
+	MimeType           string            `json:"mimeType,omitempty"`
+	SourceLanguageCode string            `json:"sourceLanguageCode,omitempty"`
+	TargetLanguageCode string            `json:"targetLanguageCode"`
+	Model              string            `json:"model,omitempty"`
+	GlossaryConfig     *v3GlossaryConfig `json:"glossaryConfig,omitempty"`
+}
+
+// v3GlossaryConfig 词汇表设置
+type v3GlossaryConfig struct {
+	Glossary   string `json:"glossary"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+}
+
+// v3Translation 单个文本的译文
+type v3Translation struct {
+	TranslatedText       string `json:"translatedText"`
+	Model                string `json:"model,omitempty"`
+	DetectedLanguageCode string `json:"detectedLanguageCode,omitempty"`
+}
+
+// v3TranslateResponse translateText 响应
+type v3TranslateResponse struct {
+	Translations         []v3Translation `json:"translations"`
+	GlossaryTranslations []v3Translation `json:"glossaryTranslations,omitempty"`
+}
+
+// v3AdaptiveRequest adaptiveMtTranslate 请求
+type v3AdaptiveRequest struct {
+	Dataset string   `json:"dataset"`
+	Content []string `json:"content"`
+}
+
+// v3AdaptiveResponse adaptiveMtTranslate 响应
+type v3AdaptiveResponse struct {
+	Translations []struct {
+		TranslatedText string `json:"translatedText"`
+	} `json:"translations"`
+	LanguageCode string `json:"languageCode"`
+}
@@ -0,0 +1,247 @@
+package google
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockV3 模拟 OAuth 令牌端点和 Cloud Translation v3 接口
+type mockV3 struct {
+	mu          sync.Mutex
+	tokenCalls  int
+	paths       []string
+	translates  []v3TranslateRequest
+	adaptations []v3AdaptiveRequest
+}
+
+func newMockV3(t *testing.T, key *rsa.PrivateKey) (*mockV3, *httptest.Server) {
+	mock := &mockV3{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mock.mu.Lock()
+		defer mock.mu.Unlock()
+
+		if r.URL.Path == "/token" {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			claims := verifyJWT(t, r.PostForm.Get("assertion"), &key.PublicKey)
+			assert.Equal(t, "translator@test-project.iam.gserviceaccount.com", claims["iss"])
+			assert.Equal(t, cloudTranslationScope, claims["scope"])
+			mock.tokenCalls++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token-1", "expires_in": 3600, "token_type": "Bearer"})
+			return
+		}
+
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		mock.paths = append(mock.paths, r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":translateText"):
+			var req v3TranslateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mock.translates = append(mock.translates, req)
+			var resp v3TranslateResponse
+			for _, text := range req.Contents {
+				resp.Translations = append(resp.Translations, v3Translation{TranslatedText: strings.ToUpper(text), Model: req.Model})
+				if req.GlossaryConfig != nil {
+					resp.GlossaryTranslations = append(resp.GlossaryTranslations, v3Translation{TranslatedText: "[glossary] " + strings.ToUpper(text)})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case strings.HasSuffix(r.URL.Path, ":adaptiveMtTranslate"):
+			var req v3AdaptiveRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mock.adaptations = append(mock.adaptations, req)
+			var resp v3AdaptiveResponse
+			for _, text := range req.Content {
+				resp.Translations = append(resp.Translations, struct {
+					TranslatedText string `json:"translatedText"`
+				}{TranslatedText: "[adaptive] " + text})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return mock, server
+}
+
+// verifyJWT 校验 RS256 签名并返回声明
+func verifyJWT(t *testing.T, token string, key *rsa.PublicKey) map[string]interface{} {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	return claims
+}
+
+func newTestProviderV3(t *testing.T, configure func(*ConfigV3)) (*ProviderV3, *mockV3) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	mock, server := newMockV3(t, key)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	credentials, err := json.Marshal(ServiceAccount{
+		Type:         "service_account",
+		ProjectID:    "test-project",
+		PrivateKeyID: "key-1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "translator@test-project.iam.gserviceaccount.com",
+		TokenURI:     server.URL + "/token",
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0o600))
+
+	config := DefaultConfigV3()
+	config.APIEndpoint = server.URL + "/v3"
+	config.CredentialsFile = path
+	config.RetryConfig.MaxRetries = 0
+	config.RetryConfig.NetworkMaxRetries = 0
+	if configure != nil {
+		configure(&config)
+	}
+	provider, err := NewV3(config)
+	require.NoError(t, err)
+	return provider, mock
+}
+
+func TestV3TranslateBatchesNodes(t *testing.T) {
+	provider, mock := newTestProviderV3(t, func(c *ConfigV3) { c.Model = "nmt" })
+
+	text := "@@NODE_START_1@@\nhello\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n<b>world</b>\n@@NODE_END_2@@"
+	for i := 0; i < 2; i++ {
+		resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+			Text:           text,
+			SourceLanguage: "English",
+			TargetLanguage: "German",
+			Metadata:       map[string]interface{}{"format": "html"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "@@NODE_START_1@@\nHELLO\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n<B>WORLD</B>\n@@NODE_END_2@@", resp.Text)
+		assert.Equal(t, "projects/test-project/locations/global/models/general/nmt", resp.Metadata["model"])
+	}
+
+	assert.Equal(t, 1, mock.tokenCalls, "access token is cached")
+	require.Len(t, mock.translates, 2)
+	req := mock.translates[0]
+	assert.Equal(t, []string{"hello", "<b>world</b>"}, req.Contents)
+	assert.Equal(t, "text/html", req.MimeType)
+	assert.Equal(t, "en", req.SourceLanguageCode)
+	assert.Equal(t, "de", req.TargetLanguageCode)
+	assert.Equal(t, "/v3/projects/test-project/locations/global:translateText", mock.paths[0])
+}
+
+func TestV3Glossary(t *testing.T) {
+	provider, mock := newTestProviderV3(t, func(c *ConfigV3) {
+		c.Location = "us-central1"
+		c.Glossary = "terms"
+		c.GlossaryIgnoreCase = true
+	})
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{Text: "cloud", SourceLanguage: "en", TargetLanguage: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "[glossary] CLOUD", resp.Text)
+	assert.Equal(t, "text/plain", mock.translates[0].MimeType)
+	require.NotNil(t, mock.translates[0].GlossaryConfig)
+	assert.Equal(t, "projects/test-project/locations/us-central1/glossaries/terms", mock.translates[0].GlossaryConfig.Glossary)
+	assert.True(t, mock.translates[0].GlossaryConfig.IgnoreCase)
+
+	// 自动检测源语言时无法使用词汇表
+	_, err = provider.Translate(context.Background(), &providers.ProviderRequest{Text: "cloud", SourceLanguage: "auto", TargetLanguage: "fr"})
+	require.NoError(t, err)
+	assert.Nil(t, mock.translates[1].GlossaryConfig)
+	assert.Empty(t, mock.translates[1].SourceLanguageCode)
+}
+
+func TestV3AdaptiveDataset(t *testing.T) {
+	provider, mock := newTestProviderV3(t, func(c *ConfigV3) {
+		c.Location = "us-central1"
+		c.AdaptiveDatasets = map[string]string{"en-de": "legal"}
+	})
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{Text: "contract", SourceLanguage: "en", TargetLanguage: "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "[adaptive] contract", resp.Text)
+	assert.Equal(t, "projects/test-project/locations/us-central1/adaptiveMtDatasets/legal", resp.Metadata["adaptive_dataset"])
+	require.Len(t, mock.adaptations, 1)
+	assert.Equal(t, []string{"contract"}, mock.adaptations[0].Content)
+
+	// 其他语言对使用普通模型
+	resp, err = provider.Translate(context.Background(), &providers.ProviderRequest{Text: "contract", SourceLanguage: "en", TargetLanguage: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "CONTRACT", resp.Text)
+	assert.Len(t, mock.translates, 1)
+}
+
+func TestV3TranslateTextsChunking(t *testing.T) {
+	provider, mock := newTestProviderV3(t, nil)
+
+	texts := make([]string, maxContentsPerRequest+1)
+	for i := range texts {
+		texts[i] = "a"
+	}
+	texts[0] = strings.Repeat("b", maxCodepointsPerRequest)
+
+	translations, _, err := provider.TranslateTexts(context.Background(), texts, "en", "de", "")
+	require.NoError(t, err)
+	assert.Len(t, translations, len(texts))
+	require.Len(t, mock.translates, 2)
+	assert.Len(t, mock.translates[0].Contents, 1, "codepoint limit splits the first text off")
+	assert.Len(t, mock.translates[1].Contents, maxContentsPerRequest)
+}
+
+func TestModelPath(t *testing.T) {
+	provider := &ProviderV3{config: ConfigV3{ProjectID: "p", Location: "us-central1"}}
+	cases := map[string]string{
+		"":                         "",
+		"llm":                      "projects/p/locations/us-central1/models/general/translation-llm",
+		"NMT":                      "projects/p/locations/us-central1/models/general/nmt",
+		"custom-model":             "projects/p/locations/us-central1/models/custom-model",
+		"projects/x/locations/y/m": "projects/x/locations/y/m",
+	}
+	for model, expected := range cases {
+		provider.config.Model = model
+		assert.Equal(t, expected, provider.modelPath(), model)
+	}
+}
+
+func TestNewV3RequiresCredentials(t *testing.T) {
+	_, err := NewV3(DefaultConfigV3())
+	assert.Error(t, err)
+
+	config := DefaultConfigV3()
+	config.AccessToken = "static"
+	_, err = NewV3(config)
+	assert.Error(t, err, "project ID is required without a service account")
+
+	config.ProjectID = "p"
+	_, err = NewV3(config)
+	assert.NoError(t, err)
+}
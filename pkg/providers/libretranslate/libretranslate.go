@@ -178,6 +178,35 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 	return p.fetchLanguages(ctx)
 }
 
+// ListLanguages 通过 /languages 获取支持的语言，目标语言取各语言 targets 的并集
+func (p *Provider) ListLanguages(ctx context.Context) (source, target []providers.Language, err error) {
+	if err := p.fetchLanguages(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	names := make(map[string]string, len(p.languages))
+	for _, lang := range p.languages {
+		names[lang.Code] = lang.Name
+		source = append(source, providers.Language{Code: lang.Code, Name: lang.Name})
+	}
+
+	seen := make(map[string]bool)
+	for _, lang := range p.languages {
+		for _, code := range lang.Targets {
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			target = append(target, providers.Language{Code: code, Name: names[code]})
+		}
+	}
+	// 旧版本服务端不返回 targets，此时所有语言都可作为目标语言
+	if len(seen) == 0 {
+		target = source
+	}
+	return source, target, nil
+}
+
 // translate 执行翻译请求
 func (p *Provider) translate(ctx context.Context, req TranslateRequest) (*TranslateResponse, error) {
 	// 编码请求
@@ -328,8 +357,9 @@ func getDefaultLanguages() []Language {
 
 // Language 语言信息
 type Language struct {
-	Code string `json:"code"`
-	Name string `json:"name"`
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Targets []string `json:"targets,omitempty"` // 可以翻译成的目标语言代码
 }
 
 // TranslateRequest 翻译请求
@@ -0,0 +1,65 @@
+package llamacpp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nodeTextRule 节点内容：任意文本，但不能出现 "@@NODE"，防止模型在节点内部伪造标记。
+// 其他 @@ 占位符（如 @@PRESERVE_0@@）不受影响，也可以出现在节点末尾：
+// 末尾的 "@"、"@@" 等后面紧跟结束标记前的换行，由最后的可选项匹配
+const nodeTextRule = `node-text ::= ( [^@] | "@" [^@] | "@@" [^N] | "@@N" [^O] | "@@NO" [^D] | "@@NOD" [^E] )* ( "@" | "@@" | "@@N" | "@@NO" | "@@NOD" )?`
+
+// NodeMarkerGrammar 生成 GBNF 语法，要求输出按给定顺序包含每个节点的
+// @@NODE_START_n@@ / @@NODE_END_n@@ 标记（与 translation.DefaultBatchConfig 的格式一致），
+// 节点之间只能是空行
+func NodeMarkerGrammar(ids []int) string {
+	var root strings.Builder
+	var rules strings.Builder
+	defined := make(map[int]bool)
+
+	root.WriteString(`root ::= "\n"?`)
+	for i, id := range ids {
+		if i > 0 {
+			root.WriteString(` "\n"+`)
+		}
+		fmt.Fprintf(&root, " node-%d", id)
+		if defined[id] {
+			continue
+		}
+		defined[id] = true
+		fmt.Fprintf(&rules, "node-%d ::= \"@@NODE_START_%d@@\\n\" node-text \"\\n@@NODE_END_%d@@\"\n", id, id, id)
+	}
+	root.WriteString(` "\n"?`)
+
+	return root.String() + "\n" + rules.String() + nodeTextRule + "\n"
+}
+
+// jsonRules JSON 字符串和空白
+const jsonRules = `string ::= "\"" ( [^"\\\x00-\x1f] | "\\" ( ["\\/bfnrt] | "u" [0-9a-fA-F]{4} ) )* "\""
+ws ::= [ \t\n]{0,20}
+`
+
+// BatchJSONGrammar 生成 GBNF 语法，要求输出为 {"translations": [{"id": n, "translation": "..."}]}，
+// 按给定顺序包含每个节点 ID（对应 providers.StructuredBatch 的 Schema）
+func BatchJSONGrammar(ids []int) string {
+	var root strings.Builder
+	var rules strings.Builder
+	defined := make(map[int]bool)
+
+	root.WriteString(`root ::= "{" ws "\"translations\"" ws ":" ws "[" ws`)
+	for i, id := range ids {
+		if i > 0 {
+			root.WriteString(` "," ws`)
+		}
+		fmt.Fprintf(&root, " item-%d ws", id)
+		if defined[id] {
+			continue
+		}
+		defined[id] = true
+		fmt.Fprintf(&rules, "item-%d ::= \"{\" ws \"\\\"id\\\"\" ws \":\" ws \"%d\" ws \",\" ws \"\\\"translation\\\"\" ws \":\" ws string ws \"}\"\n", id, id)
+	}
+	root.WriteString(` "]" ws "}"`)
+
+	return root.String() + "\n" + rules.String() + jsonRules
+}
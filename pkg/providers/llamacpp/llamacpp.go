@@ -0,0 +1,430 @@
+package llamacpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/retry"
+)
+
+const (
+	// EndpointCompletion 使用原生的 /completion 接口
+	EndpointCompletion = "completion"
+	// EndpointChat 使用 OpenAI 兼容的 /v1/chat/completions 接口，由服务端套用模型的对话模板
+	EndpointChat = "chat"
+)
+
+// Config llama.cpp server 配置
+type Config struct {
+	providers.BaseConfig
+	// Model 模型名称，llama.cpp server 只加载一个模型，仅用于记录和 chat 接口
+	Model       string  `json:"model"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	// Endpoint 使用的接口：completion 或 chat
+	Endpoint string `json:"endpoint"`
+	// UseGrammar 批量翻译时用 GBNF 语法约束输出，保证节点标记或 JSON 批量响应的结构
+	UseGrammar bool `json:"use_grammar"`
+	// ContextSize 上下文长度，为 0 时从 /props 读取服务端的 n_ctx
+	ContextSize int               `json:"context_size,omitempty"`
+	RetryConfig retry.RetryConfig `json:"retry_config"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	config := Config{
+		BaseConfig:  providers.DefaultConfig(),
+		Model:       "local",
+		Temperature: 0.3,
+		MaxTokens:   4096,
+		Endpoint:    EndpointCompletion,
+		UseGrammar:  true,
+		RetryConfig: retry.DefaultRetryConfig(),
+	}
+	config.APIEndpoint = "http://localhost:8080"
+	return config
+}
+
+// Provider llama.cpp server 提供商，用于无法访问外网的机器
+type Provider struct {
+	config      Config
+	httpClient  *http.Client
+	retryClient *retry.RetryableHTTPClient
+
+	contextMu   sync.Mutex
+	contextSize int
+}
+
+// New 创建新的 llama.cpp server 提供商
+func New(config Config) *Provider {
+	if config.APIEndpoint == "" {
+		config.APIEndpoint = "http://localhost:8080"
+	}
+	config.APIEndpoint = strings.TrimRight(config.APIEndpoint, "/")
+	if config.Endpoint == "" {
+		config.Endpoint = EndpointCompletion
+	}
+
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+
+	// 创建网络重试器
+	networkRetrier := retry.NewNetworkRetrier(config.RetryConfig)
+	retryClient := networkRetrier.WrapHTTPClient(httpClient)
+
+	return &Provider{
+		config:      config,
+		httpClient:  httpClient,
+		retryClient: retryClient,
+		contextSize: config.ContextSize,
+	}
+}
+
+// Configure 配置提供商
+func (p *Provider) Configure(config interface{}) error {
+	cfg, ok := config.(Config)
+	if !ok {
+		return fmt.Errorf("invalid config type: expected Config")
+	}
+	*p = *New(cfg)
+	return nil
+}
+
+// Translate 执行翻译
+func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	instruction, _ := req.Metadata["instruction"].(string)
+
+	var prompt, grammar string
+	if req.IsRawPrompt() || isFullPrompt(req.Text) {
+		prompt = req.Text
+	} else {
+		prompt = fmt.Sprintf("Translate the following text from %s to %s. Please only return the translated text without any additional explanations:\n\n%s",
+			req.SourceLanguage, req.TargetLanguage, req.Text)
+
+		// 批量翻译时约束输出只能是按顺序排列的节点
+		if p.config.UseGrammar {
+			if batch := req.StructuredBatch(); batch != nil {
+				grammar = BatchJSONGrammar(batch.IDs)
+			} else if ids := providers.SplitNodeSegments(req.Text).IDs(); len(ids) > 0 {
+				grammar = NodeMarkerGrammar(ids)
+			}
+		}
+	}
+
+	var (
+		result *completionResult
+		err    error
+	)
+	if p.config.Endpoint == EndpointChat {
+		result, err = p.chat(ctx, instruction, prompt, grammar)
+	} else {
+		if instruction != "" {
+			prompt = instruction + "\n\n" + prompt
+		}
+		result, err = p.complete(ctx, prompt, grammar)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"model":   result.model,
+		"grammar": grammar != "",
+	}
+	if result.contextSize > 0 {
+		metadata["n_ctx"] = result.contextSize
+		p.contextMu.Lock()
+		if p.contextSize == 0 {
+			p.contextSize = result.contextSize
+		}
+		p.contextMu.Unlock()
+	}
+	if result.truncated {
+		metadata["truncated"] = true
+	}
+
+	return &providers.ProviderResponse{
+		Text:      result.text,
+		TokensIn:  result.tokensIn,
+		TokensOut: result.tokensOut,
+		Metadata:  metadata,
+	}, nil
+}
+
+// ContextWindow 返回服务端的上下文长度（n_ctx），首次调用时从 /props 读取
+func (p *Provider) ContextWindow(ctx context.Context) (int, error) {
+	p.contextMu.Lock()
+	defer p.contextMu.Unlock()
+	if p.contextSize > 0 {
+		return p.contextSize, nil
+	}
+
+	var props PropsResponse
+	if err := p.do(ctx, http.MethodGet, "/props", nil, &props); err != nil {
+		return 0, err
+	}
+	p.contextSize = props.DefaultGenerationSettings.NCtx
+	return p.contextSize, nil
+}
+
+// GetName 获取提供商名称
+func (p *Provider) GetName() string {
+	return "llamacpp"
+}
+
+// SupportsSteps 支持多步骤翻译
+func (p *Provider) SupportsSteps() bool {
+	return true
+}
+
+// SupportsStructuredOutput 启用语法约束时支持 JSON 批量协议
+func (p *Provider) SupportsStructuredOutput() bool {
+	return p.config.UseGrammar
+}
+
+// GetCapabilities 获取提供商能力
+func (p *Provider) GetCapabilities() providers.Capabilities {
+	return providers.Capabilities{
+		SupportedLanguages: []providers.Language{
+			{Code: "en", Name: "English"},
+			{Code: "zh", Name: "Chinese"},
+			{Code: "ja", Name: "Japanese"},
+			{Code: "ko", Name: "Korean"},
+			{Code: "es", Name: "Spanish"},
+			{Code: "fr", Name: "French"},
+			{Code: "de", Name: "German"},
+			{Code: "ru", Name: "Russian"},
+			{Code: "pt", Name: "Portuguese"},
+			{Code: "it", Name: "Italian"},
+			// 支持的语言取决于加载的模型
+		},
+		MaxTextLength:      8000, // 取决于 n_ctx，批量翻译会按 ContextWindow 调整分组
+		SupportsBatch:      false,
+		SupportsFormatting: true,
+		RequiresAPIKey:     false,
+	}
+}
+
+// HealthCheck 健康检查
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/health", nil, &health); err != nil {
+		return err
+	}
+	if health.Status != "" && health.Status != "ok" {
+		return fmt.Errorf("llama.cpp server is not ready: %s", health.Status)
+	}
+	return nil
+}
+
+// completionResult 两种接口的统一结果
+type completionResult struct {
+	text        string
+	model       string
+	tokensIn    int
+	tokensOut   int
+	contextSize int
+	truncated   bool
+}
+
+// complete 调用 /completion 接口
+func (p *Provider) complete(ctx context.Context, prompt, grammar string) (*completionResult, error) {
+	req := CompletionRequest{
+		Prompt:      prompt,
+		Temperature: p.config.Temperature,
+		NPredict:    p.config.MaxTokens,
+		Grammar:     grammar,
+		CachePrompt: true,
+	}
+	if req.NPredict <= 0 {
+		req.NPredict = -1
+	}
+
+	var resp CompletionResponse
+	if err := p.do(ctx, http.MethodPost, "/completion", req, &resp); err != nil {
+		return nil, err
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = p.config.Model
+	}
+	return &completionResult{
+		text:        resp.Content,
+		model:       model,
+		tokensIn:    resp.TokensEvaluated,
+		tokensOut:   resp.TokensPredicted,
+		contextSize: resp.GenerationSettings.NCtx,
+		truncated:   resp.Truncated,
+	}, nil
+}
+
+// chat 调用 /v1/chat/completions 接口，grammar 是 llama.cpp 对 OpenAI 格式的扩展字段
+func (p *Provider) chat(ctx context.Context, instruction, prompt, grammar string) (*completionResult, error) {
+	var messages []ChatMessage
+	if instruction != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: instruction})
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+
+	req := ChatRequest{
+		Model:       p.config.Model,
+		Messages:    messages,
+		Temperature: p.config.Temperature,
+		MaxTokens:   p.config.MaxTokens,
+		Grammar:     grammar,
+	}
+
+	var resp ChatResponse
+	if err := p.do(ctx, http.MethodPost, "/v1/chat/completions", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = p.config.Model
+	}
+	return &completionResult{
+		text:      resp.Choices[0].Message.Content,
+		model:     model,
+		tokensIn:  resp.Usage.PromptTokens,
+		tokensOut: resp.Usage.CompletionTokens,
+		truncated: resp.Choices[0].FinishReason == "length",
+	}, nil
+}
+
+// do 发送请求并解析 JSON 响应，body 为 nil 时不发送请求体
+func (p *Provider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.config.APIEndpoint+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	// llama.cpp server 用 --api-key 启动时需要认证
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	for k, v := range p.config.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	// 执行请求，使用智能重试
+	resp, err := p.retryClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		var apiErr APIError
+		if json.Unmarshal(errBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("llama.cpp server error: %s", apiErr.Error.Message)
+		}
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// isFullPrompt 检查是否为完整的预构建提示词
+func isFullPrompt(text string) bool {
+	return strings.Contains(text, "You are a professional translator") &&
+		strings.Contains(text, "🚨 CRITICAL INSTRUCTION")
+}
+
+// CompletionRequest /completion 请求
+type CompletionRequest struct {
+	Prompt      string  `json:"prompt"`
+	Temperature float32 `json:"temperature"`
+	NPredict    int     `json:"n_predict"`
+	Grammar     string  `json:"grammar,omitempty"`
+	CachePrompt bool    `json:"cache_prompt"`
+}
+
+// GenerationSettings 服务端的生成设置
+type GenerationSettings struct {
+	NCtx int `json:"n_ctx"`
+}
+
+// CompletionResponse /completion 响应
+type CompletionResponse struct {
+	Content            string             `json:"content"`
+	Model              string             `json:"model"`
+	Stop               bool               `json:"stop"`
+	Truncated          bool               `json:"truncated"`
+	TokensEvaluated    int                `json:"tokens_evaluated"`
+	TokensPredicted    int                `json:"tokens_predicted"`
+	TokensCached       int                `json:"tokens_cached"`
+	GenerationSettings GenerationSettings `json:"generation_settings"`
+}
+
+// ChatMessage 对话消息
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest /v1/chat/completions 请求
+type ChatRequest struct {
+	Model       string        `json:"model,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature float32       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Grammar     string        `json:"grammar,omitempty"`
+}
+
+// ChatResponse /v1/chat/completions 响应
+type ChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// PropsResponse /props 响应
+type PropsResponse struct {
+	DefaultGenerationSettings GenerationSettings `json:"default_generation_settings"`
+	TotalSlots                int                `json:"total_slots"`
+}
+
+// APIError API错误
+type APIError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
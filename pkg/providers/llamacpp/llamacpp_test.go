@@ -0,0 +1,275 @@
+package llamacpp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockServer 模拟 llama.cpp server 的 /completion、/v1/chat/completions 和 /props 接口
+type mockServer struct {
+	mu          sync.Mutex
+	completions []CompletionRequest
+	chats       []ChatRequest
+	propsCalls  int
+	reply       string
+}
+
+func newMockServer(t *testing.T, reply string) (*mockServer, *httptest.Server) {
+	mock := &mockServer{reply: reply}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mock.mu.Lock()
+		defer mock.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/completion":
+			var req CompletionRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mock.completions = append(mock.completions, req)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"content":             mock.reply,
+				"model":               "qwen2.5-7b-instruct-q4_k_m.gguf",
+				"tokens_evaluated":    120,
+				"tokens_predicted":    45,
+				"generation_settings": map[string]interface{}{"n_ctx": 8192},
+			})
+		case "/v1/chat/completions":
+			var req ChatRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mock.chats = append(mock.chats, req)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"model": "local",
+				"choices": []map[string]interface{}{
+					{"message": map[string]string{"role": "assistant", "content": mock.reply}, "finish_reason": "stop"},
+				},
+				"usage": map[string]int{"prompt_tokens": 30, "completion_tokens": 10},
+			})
+		case "/props":
+			mock.propsCalls++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"default_generation_settings": map[string]interface{}{"n_ctx": 4096},
+				"total_slots":                 1,
+			})
+		case "/health":
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"File Not Found","type":"not_found_error"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return mock, server
+}
+
+func newTestProvider(endpoint string, configure func(*Config)) *Provider {
+	config := DefaultConfig()
+	config.APIEndpoint = endpoint
+	config.RetryConfig.MaxRetries = 0
+	config.RetryConfig.NetworkMaxRetries = 0
+	if configure != nil {
+		configure(&config)
+	}
+	return New(config)
+}
+
+func TestTranslateCompletionWithGrammar(t *testing.T) {
+	reply := "@@NODE_START_3@@\nHallo\n@@NODE_END_3@@\n\n@@NODE_START_7@@\nWelt\n@@NODE_END_7@@"
+	mock, server := newMockServer(t, reply)
+	provider := newTestProvider(server.URL, nil)
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:           "@@NODE_START_3@@\nHello\n@@NODE_END_3@@\n\n@@NODE_START_7@@\nWorld\n@@NODE_END_7@@",
+		SourceLanguage: "English",
+		TargetLanguage: "German",
+		Metadata:       map[string]interface{}{"instruction": "Use formal register."},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, reply, resp.Text)
+	assert.Equal(t, 120, resp.TokensIn, "tokens_evaluated")
+	assert.Equal(t, 45, resp.TokensOut, "tokens_predicted")
+	assert.Equal(t, 8192, resp.Metadata["n_ctx"])
+
+	require.Len(t, mock.completions, 1)
+	req := mock.completions[0]
+	assert.True(t, strings.HasPrefix(req.Prompt, "Use formal register.\n\n"))
+	assert.True(t, req.CachePrompt)
+	assert.Contains(t, req.Grammar, `root ::= "\n"? node-3 "\n"+ node-7 "\n"?`)
+	assert.Contains(t, req.Grammar, `node-7 ::= "@@NODE_START_7@@\n" node-text "\n@@NODE_END_7@@"`)
+
+	// n_ctx 从响应中记录，不再请求 /props
+	nCtx, err := provider.ContextWindow(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 8192, nCtx)
+	assert.Zero(t, mock.propsCalls)
+}
+
+func TestTranslatePlainTextWithoutGrammar(t *testing.T) {
+	mock, server := newMockServer(t, "Bonjour")
+	provider := newTestProvider(server.URL, nil)
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{Text: "Hello", SourceLanguage: "en", TargetLanguage: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", resp.Text)
+	assert.Empty(t, mock.completions[0].Grammar)
+
+	// 原始提示词不套用翻译模板，也不约束输出
+	_, err = provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:     "Summarize @@NODE_START_1@@\nx\n@@NODE_END_1@@",
+		Metadata: map[string]interface{}{providers.MetadataRawPrompt: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize @@NODE_START_1@@\nx\n@@NODE_END_1@@", mock.completions[1].Prompt)
+	assert.Empty(t, mock.completions[1].Grammar)
+}
+
+func TestTranslateChatEndpoint(t *testing.T) {
+	mock, server := newMockServer(t, "@@NODE_START_1@@\nHola\n@@NODE_END_1@@")
+	provider := newTestProvider(server.URL, func(c *Config) { c.Endpoint = EndpointChat })
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:           "@@NODE_START_1@@\nHello\n@@NODE_END_1@@",
+		SourceLanguage: "English",
+		TargetLanguage: "Spanish",
+		Metadata:       map[string]interface{}{"instruction": "Be concise."},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "@@NODE_START_1@@\nHola\n@@NODE_END_1@@", resp.Text)
+	assert.Equal(t, 30, resp.TokensIn)
+	assert.Equal(t, 10, resp.TokensOut)
+
+	require.Len(t, mock.chats, 1)
+	require.Len(t, mock.chats[0].Messages, 2)
+	assert.Equal(t, "system", mock.chats[0].Messages[0].Role)
+	assert.Equal(t, "Be concise.", mock.chats[0].Messages[0].Content)
+	assert.NotEmpty(t, mock.chats[0].Grammar)
+	assert.Empty(t, mock.completions)
+}
+
+func TestContextWindow(t *testing.T) {
+	mock, server := newMockServer(t, "")
+	provider := newTestProvider(server.URL, nil)
+
+	for i := 0; i < 2; i++ {
+		nCtx, err := provider.ContextWindow(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 4096, nCtx)
+	}
+	assert.Equal(t, 1, mock.propsCalls, "n_ctx is cached")
+
+	// 配置的上下文长度优先
+	configured := newTestProvider(server.URL, func(c *Config) { c.ContextSize = 2048 })
+	nCtx, err := configured.ContextWindow(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2048, nCtx)
+	assert.Equal(t, 1, mock.propsCalls)
+
+	assert.NoError(t, provider.HealthCheck(context.Background()))
+}
+
+func TestTranslateStructuredBatchGrammar(t *testing.T) {
+	mock, server := newMockServer(t, `{"translations":[{"id":4,"translation":"Hallo"}]}`)
+	provider := newTestProvider(server.URL, nil)
+	assert.True(t, provider.SupportsStructuredOutput())
+
+	_, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:           `[{"id":4,"text":"Hello"}]`,
+		SourceLanguage: "English",
+		TargetLanguage: "German",
+		Metadata:       map[string]interface{}{providers.MetadataStructuredBatch: providers.NewStructuredBatch([]int{4})},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, BatchJSONGrammar([]int{4}), mock.completions[0].Grammar)
+
+	assert.False(t, newTestProvider(server.URL, func(c *Config) { c.UseGrammar = false }).SupportsStructuredOutput())
+}
+
+func TestBatchJSONGrammar(t *testing.T) {
+	grammar := BatchJSONGrammar([]int{2, 5})
+	assert.Contains(t, grammar, `root ::= "{" ws "\"translations\"" ws ":" ws "[" ws item-2 ws "," ws item-5 ws "]" ws "}"`)
+	assert.Contains(t, grammar, `item-5 ::= "{" ws "\"id\"" ws ":" ws "5" ws "," ws "\"translation\"" ws ":" ws string ws "}"`)
+	assert.Contains(t, grammar, "string ::= ")
+}
+
+func TestNodeMarkerGrammar(t *testing.T) {
+	grammar := NodeMarkerGrammar([]int{1, 2, 1})
+	assert.Equal(t, `root ::= "\n"? node-1 "\n"+ node-2 "\n"+ node-1 "\n"?
+node-1 ::= "@@NODE_START_1@@\n" node-text "\n@@NODE_END_1@@"
+node-2 ::= "@@NODE_START_2@@\n" node-text "\n@@NODE_END_2@@"
+`+nodeTextRule+"\n", grammar)
+}
+
+// grammarRegexp 把只用到字符串、字符类、分组、| * ? + 的 GBNF 规则转换为等价的正则表达式，
+// 规则中引用的其他规则按 rules 展开
+func grammarRegexp(t *testing.T, rule string, rules map[string]string) *regexp.Regexp {
+	t.Helper()
+	var convert func(body string) string
+	convert = func(body string) string {
+		var b strings.Builder
+		for i := 0; i < len(body); {
+			switch c := body[i]; {
+			case c == ' ':
+				i++
+			case c == '"':
+				end := strings.Index(body[i+1:], `"`) + i + 1
+				text, err := strconv.Unquote(body[i : end+1])
+				require.NoError(t, err)
+				b.WriteString(regexp.QuoteMeta(text))
+				i = end + 1
+			case c == '[':
+				end := strings.Index(body[i:], "]") + i
+				b.WriteString(body[i : end+1])
+				i = end + 1
+			case strings.ContainsRune("()|*?+", rune(c)):
+				b.WriteByte(c)
+				i++
+			default:
+				end := i
+				for end < len(body) && body[end] != ' ' {
+					end++
+				}
+				name := body[i:end]
+				sub, ok := rules[name]
+				require.True(t, ok, "unknown rule %s", name)
+				b.WriteString("(" + convert(sub) + ")")
+				i = end
+			}
+		}
+		return b.String()
+	}
+	return regexp.MustCompile(`^(?:` + convert(rule) + `)$`)
+}
+
+func TestNodeMarkerGrammarAcceptsTrailingPlaceholders(t *testing.T) {
+	textRule := strings.TrimPrefix(nodeTextRule, "node-text ::= ")
+	node := grammarRegexp(t, `"@@NODE_START_1@@\n" node-text "\n@@NODE_END_1@@"`, map[string]string{"node-text": textRule})
+
+	for _, text := range []string{
+		"普通译文",
+		"保留 @@PRESERVE_3@@",
+		"@@PRESERVE_0@@ 在开头",
+		"以 @ 结尾 @",
+		"以 @@N 结尾 @@N",
+		"多行\n@@PRESERVE_1@@\n@@PRESERVE_2@@",
+	} {
+		assert.True(t, node.MatchString("@@NODE_START_1@@\n"+text+"\n@@NODE_END_1@@"), text)
+	}
+
+	// 节点内部不能出现伪造的节点标记
+	for _, text := range []string{
+		"前一半\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n后一半",
+		"@@NODE",
+	} {
+		assert.False(t, node.MatchString("@@NODE_START_1@@\n"+text+"\n@@NODE_END_1@@"), text)
+	}
+}
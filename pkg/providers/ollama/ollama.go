@@ -79,7 +79,12 @@ func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest
 	var prompt string
 
 	// 检查是否有预构建的完整提示词（优先使用）
-	if p.isFullPrompt(req.Text) {
+	if req.IsRawPrompt() {
+		prompt = req.Text
+		if instruction, ok := req.Metadata["instruction"].(string); ok && instruction != "" {
+			prompt = instruction + "\n\n" + prompt
+		}
+	} else if p.isFullPrompt(req.Text) {
 		prompt = req.Text
 	} else {
 		// 否则使用传统方式构建
@@ -111,6 +116,11 @@ func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest
 		generateReq.Options["num_predict"] = p.config.MaxTokens
 	}
 
+	// JSON 批量请求用 format 传入 JSON Schema 约束输出
+	if batch := req.StructuredBatch(); batch != nil {
+		generateReq.Format = batch.Schema
+	}
+
 	// 执行请求
 	resp, err := p.generate(ctx, generateReq)
 	if err != nil {
@@ -141,6 +151,11 @@ func (p *Provider) SupportsSteps() bool {
 	return true
 }
 
+// SupportsStructuredOutput 支持 format 参数的 JSON Schema 结构化输出
+func (p *Provider) SupportsStructuredOutput() bool {
+	return true
+}
+
 // GetCapabilities 获取提供商能力
 func (p *Provider) GetCapabilities() providers.Capabilities {
 	return providers.Capabilities{
@@ -185,6 +200,38 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 	return err
 }
 
+// ListModels 通过 /api/tags 列出本地已拉取的模型
+func (p *Provider) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range p.config.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.retryClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list models: %s", resp.Status)
+	}
+
+	var tags TagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
+
 // generate 执行生成请求
 func (p *Provider) generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
 	// 编码请求
@@ -249,6 +296,7 @@ type GenerateRequest struct {
 	Model   string                 `json:"model"`
 	Prompt  string                 `json:"prompt"`
 	Stream  bool                   `json:"stream"`
+	Format  interface{}            `json:"format,omitempty"` // "json" 或 JSON Schema
 	Options map[string]interface{} `json:"options,omitempty"`
 }
 
@@ -266,6 +314,15 @@ type GenerateResponse struct {
 	EvalDuration       int64     `json:"eval_duration"`
 }
 
+// TagsResponse /api/tags 响应
+type TagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+		Size  int64  `json:"size"`
+	} `json:"models"`
+}
+
 // APIError API错误
 type APIError struct {
 	ErrorMsg string `json:"error"`
@@ -195,6 +195,52 @@ func TestTranslateWithMetadata(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTranslateWithStructuredBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		// JSON 批量请求把 Schema 作为 format 发送
+		format, ok := req["format"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "object", format["type"])
+		assert.Contains(t, format["properties"], "translations")
+
+		json.NewEncoder(w).Encode(GenerateResponse{Model: "llama2", Response: `{"translations":[{"id":1,"translation":"你好"}]}`, Done: true})
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.APIEndpoint = server.URL
+	provider := New(config)
+	assert.True(t, provider.SupportsStructuredOutput())
+
+	resp, err := provider.Translate(context.Background(), &providers.ProviderRequest{
+		Text:           `[{"id":1,"text":"Hello"}]`,
+		SourceLanguage: "English",
+		TargetLanguage: "Chinese",
+		Metadata:       map[string]interface{}{providers.MetadataStructuredBatch: providers.NewStructuredBatch([]int{1})},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"translations":[{"id":1,"translation":"你好"}]}`, resp.Text)
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		w.Write([]byte(`{"models":[{"name":"llama2:latest","model":"llama2:latest","size":3825819519},{"name":"qwen2.5:7b","model":"qwen2.5:7b"}]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.APIEndpoint = server.URL
+	provider := New(config)
+
+	models, err := provider.ListModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"llama2:latest", "qwen2.5:7b"}, models)
+}
+
 func TestTranslateWithMaxTokens(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req GenerateRequest
@@ -77,7 +77,22 @@ func (p *Provider) Translate(ctx context.Context, req *providers.ProviderRequest
 	var messages []Message
 
 	// 如果Text看起来像是完整的提示词（包含系统指令），直接使用
-	if contains, systemPart, userPart := p.parseFullPrompt(req.Text); contains {
+	if req.IsRawPrompt() {
+		instruction, _ := req.Metadata["instruction"].(string)
+		if instruction == "" {
+			instruction = "You are a helpful assistant."
+		}
+		messages = []Message{
+			{
+				Role:    "system",
+				Content: instruction,
+			},
+			{
+				Role:    "user",
+				Content: req.Text,
+			},
+		}
+	} else if contains, systemPart, userPart := p.parseFullPrompt(req.Text); contains {
 		messages = []Message{
 			{
 				Role:    "system",
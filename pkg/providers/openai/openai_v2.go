@@ -8,6 +8,7 @@ import (
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/retry"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
 )
 
 // 辅助函数
@@ -143,6 +144,18 @@ func (p *ProviderV2) Translate(ctx context.Context, req *providers.ProviderReque
 		}
 	}
 
+	// 完整的提示词直接发送
+	if req.IsRawPrompt() {
+		instruction, _ := req.Metadata["instruction"].(string)
+		if instruction == "" {
+			instruction = "You are a helpful assistant."
+		}
+		messages = []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(instruction),
+			openai.UserMessage(req.Text),
+		}
+	}
+
 	// 创建聊天完成请求
 	params := openai.ChatCompletionNewParams{
 		Messages: messages,
@@ -157,6 +170,19 @@ func (p *ProviderV2) Translate(ctx context.Context, req *providers.ProviderReque
 		params.MaxTokens = openai.Int(int64(p.config.MaxTokens))
 	}
 
+	// JSON 批量请求使用严格模式的结构化输出
+	if batch := req.StructuredBatch(); batch != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "batch_translation",
+					Strict: openai.Bool(true),
+					Schema: batch.Schema,
+				},
+			},
+		}
+	}
+
 	// 执行请求
 	completion, err := p.client.Chat.Completions.New(ctx, params)
 	if err != nil {
@@ -191,6 +217,11 @@ func (p *ProviderV2) SupportsSteps() bool {
 	return true
 }
 
+// SupportsStructuredOutput 支持 response_format 的 JSON Schema 结构化输出
+func (p *ProviderV2) SupportsStructuredOutput() bool {
+	return true
+}
+
 // GetCapabilities 获取提供商能力
 func (p *ProviderV2) GetCapabilities() providers.Capabilities {
 	return providers.Capabilities{
@@ -244,6 +275,21 @@ func (p *ProviderV2) HealthCheck(ctx context.Context) error {
 	return err
 }
 
+// ListModels 通过 /models 列出账户可用的模型
+func (p *ProviderV2) ListModels(ctx context.Context) ([]string, error) {
+	page, err := p.client.Models.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// /models 不分页，一次返回全部模型
+	models := make([]string, 0, len(page.Data))
+	for _, model := range page.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
 // // LLMClientV2 实现 translation.LLMClient 接口（使用官方SDK）
 // type LLMClientV2 struct {
 // 	provider *ProviderV2
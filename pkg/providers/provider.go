@@ -59,6 +59,13 @@ type Provider interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// ContextWindowProvider 可选接口：报告模型的上下文长度（token 数），
+// 批量翻译据此限制每组的大小
+type ContextWindowProvider interface {
+	// ContextWindow 返回上下文长度，未知时返回 0
+	ContextWindow(ctx context.Context) (int, error)
+}
+
 // Capabilities 提供商能力
 type Capabilities struct {
 	// 支持的语言
@@ -138,6 +145,16 @@ type ProviderRequest struct {
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// MetadataRawPrompt 请求元数据键：值为 true 时 Text 是完整的提示词（如生成文档简介），
+// LLM 提供商直接发送而不包装为翻译请求，元数据中的 "instruction" 作为系统指令
+const MetadataRawPrompt = "raw_prompt"
+
+// IsRawPrompt 判断请求的 Text 是否为完整的提示词
+func (r *ProviderRequest) IsRawPrompt() bool {
+	raw, _ := r.Metadata[MetadataRawPrompt].(bool)
+	return raw
+}
+
 // ProviderResponse 提供商响应
 type ProviderResponse struct {
 	Text         string                 `json:"text"`
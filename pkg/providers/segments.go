@@ -0,0 +1,62 @@
+package providers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nodeMarkerPattern 批量翻译时节点的起止标记
+var nodeMarkerPattern = regexp.MustCompile(`(?s)@@NODE_START_(\d+)@@\n(.*?)\n@@NODE_END_(\d+)@@`)
+
+// NodeSegments 批量翻译文本中节点标记之间的文本。支持一次请求翻译多个文本的机器翻译提供商
+// 把每段作为单独的文本发送，标记不经过翻译
+type NodeSegments struct {
+	text    string
+	matches [][]int
+}
+
+// SplitNodeSegments 拆出文本中节点标记之间的文本，没有标记时整个文本作为一段
+func SplitNodeSegments(text string) *NodeSegments {
+	return &NodeSegments{text: text, matches: nodeMarkerPattern.FindAllStringSubmatchIndex(text, -1)}
+}
+
+// Texts 返回需要翻译的文本
+func (s *NodeSegments) Texts() []string {
+	if len(s.matches) == 0 {
+		return []string{s.text}
+	}
+	texts := make([]string, len(s.matches))
+	for i, m := range s.matches {
+		texts[i] = s.text[m[4]:m[5]]
+	}
+	return texts
+}
+
+// IDs 返回各段的节点 ID，没有标记时返回 nil
+func (s *NodeSegments) IDs() []int {
+	if len(s.matches) == 0 {
+		return nil
+	}
+	ids := make([]int, len(s.matches))
+	for i, m := range s.matches {
+		ids[i], _ = strconv.Atoi(s.text[m[2]:m[3]])
+	}
+	return ids
+}
+
+// Join 用译文替换各段文本，保留标记和段之间的内容
+func (s *NodeSegments) Join(translations []string) string {
+	if len(s.matches) == 0 {
+		return translations[0]
+	}
+	var builder strings.Builder
+	last := 0
+	for i, m := range s.matches {
+		builder.WriteString(s.text[last:m[4]])
+		builder.WriteString(translations[i])
+		last = m[5]
+	}
+	builder.WriteString(s.text[last:])
+	return builder.String()
+}
@@ -96,13 +96,13 @@ func (sm *StatisticsMiddleware) analyzeResponse(req *providers.ProviderRequest,
 		result.NodeMarkersLost = features.expectedNodeMarkers - actualNodeMarkers
 
 		// 检查格式问题
-		result.HasFormatIssues = features.hasFormatting && sm.hasFormatIssues(req.Text, resp.Text)
+		result.HasFormatIssues = features.hasFormatting && HasFormatIssues(req.Text, resp.Text)
 
 		// 检查推理标记
 		result.HasReasoningTags = translation.HasReasoningTags(resp.Text)
 
 		// 检查翻译相似度
-		result.SimilarityTooHigh = sm.checkSimilarity(req.Text, resp.Text)
+		result.SimilarityTooHigh = TooSimilar(req.Text, resp.Text)
 	}
 
 	return result
@@ -145,8 +145,8 @@ func (sm *StatisticsMiddleware) hasFormattingContent(text string) bool {
 	return false
 }
 
-// hasFormatIssues 检查格式是否有问题
-func (sm *StatisticsMiddleware) hasFormatIssues(original, translated string) bool {
+// HasFormatIssues 检查译文的格式标记（粗体、代码、标题、公式、HTML 标签）是否明显少于原文
+func HasFormatIssues(original, translated string) bool {
 	// 检查基本的格式标记是否保持
 	formatChecks := []struct {
 		pattern string
@@ -176,8 +176,8 @@ func (sm *StatisticsMiddleware) hasFormatIssues(original, translated string) boo
 	return false
 }
 
-// checkSimilarity 检查翻译相似度
-func (sm *StatisticsMiddleware) checkSimilarity(original, translated string) bool {
+// TooSimilar 检查译文与原文是否几乎相同（相似度超过 95%，通常说明没有翻译）
+func TooSimilar(original, translated string) bool {
 	// 简单的相似度检查：如果翻译后的文本与原文相似度过高
 	originalClean := strings.ToLower(strings.TrimSpace(original))
 	translatedClean := strings.ToLower(strings.TrimSpace(translated))
@@ -0,0 +1,55 @@
+package providers
+
+// MetadataStructuredBatch 请求元数据键：值为 *StructuredBatch 时 Text 是 [{id, text}] 形式的 JSON 批量请求，
+// 支持结构化输出的提供商应按 Schema（或按节点 ID 生成的语法）约束输出
+const MetadataStructuredBatch = "structured_batch"
+
+// StructuredOutputProvider 可选接口：提供商能按 JSON Schema 或语法约束输出
+// （如 OpenAI 的 response_format、Ollama 的 format、llama.cpp 的 grammar）
+type StructuredOutputProvider interface {
+	// SupportsStructuredOutput 是否支持结构化输出
+	SupportsStructuredOutput() bool
+}
+
+// StructuredBatch JSON 批量翻译请求的输出约束
+type StructuredBatch struct {
+	// IDs 请求中的节点 ID，响应必须按相同顺序包含每个 ID
+	IDs []int
+	// Schema 响应的 JSON Schema：{"translations": [{"id": ..., "translation": ...}]}
+	Schema map[string]interface{}
+}
+
+// NewStructuredBatch 为给定的节点 ID 创建输出约束。OpenAI 的严格模式要求根节点是对象，
+// 所以 [{id, translation}] 数组放在 translations 字段中
+func NewStructuredBatch(ids []int) *StructuredBatch {
+	enum := make([]interface{}, len(ids))
+	for i, id := range ids {
+		enum[i] = id
+	}
+	item := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":          map[string]interface{}{"type": "integer", "enum": enum},
+			"translation": map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"id", "translation"},
+		"additionalProperties": false,
+	}
+	return &StructuredBatch{
+		IDs: ids,
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"translations": map[string]interface{}{"type": "array", "items": item},
+			},
+			"required":             []interface{}{"translations"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// StructuredBatch 返回请求的 JSON 批量输出约束，不是 JSON 批量请求时返回 nil
+func (r *ProviderRequest) StructuredBatch() *StructuredBatch {
+	batch, _ := r.Metadata[MetadataStructuredBatch].(*StructuredBatch)
+	return batch
+}
@@ -26,6 +26,28 @@ var DefaultBatchConfig = BatchTranslationConfig{
 	AddMarkerProtection: true,
 }
 
+const (
+	// contextPromptReserve 上下文中为指令、上下文节点等提示词预留的 token 数
+	contextPromptReserve = 1024
+	// contextCharsPerToken 按字符估算 token 时每个 token 的字符数，取偏小的值以适应中日韩文本
+	contextCharsPerToken = 2
+	// minContextChunkSize 按上下文长度计算的分组大小下限（字符数）
+	minContextChunkSize = 200
+)
+
+// ChunkSizeForContextWindow 根据上下文长度计算一组批量翻译文本的最大字符数。
+// 扣除提示词预留后，原文占三分之一，译文和节点标记占其余部分
+func ChunkSizeForContextWindow(contextWindow int) int {
+	if contextWindow <= 0 {
+		return 0
+	}
+	size := (contextWindow - contextPromptReserve) / 3 * contextCharsPerToken
+	if size < minContextChunkSize {
+		return minContextChunkSize
+	}
+	return size
+}
+
 // AddBatchMarkerProtection 在prompt中添加批量翻译标记保护说明
 func AddBatchMarkerProtection(prompt string, config BatchTranslationConfig) string {
 	if !config.AddMarkerProtection {
@@ -9,6 +9,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -54,6 +55,23 @@ func NewChain(opts ...ChainOption) Chain {
 	}
 }
 
+// stepResultRecorderKey 上下文中步骤结果记录函数的键
+type stepResultRecorderKey struct{}
+
+// WithStepResultRecorder 在上下文中设置步骤结果记录函数，翻译链每执行完一个步骤都会调用它，
+// ThreeStepTranslator 的迭代审校模式也用它记录每一轮的审校和编辑
+func WithStepResultRecorder(ctx context.Context, recorder func(StepResult)) context.Context {
+	return context.WithValue(ctx, stepResultRecorderKey{}, recorder)
+}
+
+// stepResultRecorderFromContext 读取上下文中的步骤结果记录函数，没有设置时返回空操作
+func stepResultRecorderFromContext(ctx context.Context) func(StepResult) {
+	if recorder, ok := ctx.Value(stepResultRecorderKey{}).(func(StepResult)); ok && recorder != nil {
+		return recorder
+	}
+	return func(StepResult) {}
+}
+
 // Execute 执行翻译链
 func (c *chain) Execute(ctx context.Context, input string) (*ChainResult, error) {
 	c.mu.RLock()
@@ -62,6 +80,9 @@ func (c *chain) Execute(ctx context.Context, input string) (*ChainResult, error)
 	if len(c.steps) == 0 {
 		return nil, ErrNoSteps
 	}
+	if c.options.review != nil && len(c.steps) >= 2 {
+		return c.executeReview(ctx, input)
+	}
 
 	// TRACE: 记录翻译链开始执行
 	c.traceLog("translation_chain_start",
@@ -82,11 +103,16 @@ func (c *chain) Execute(ctx context.Context, input string) (*ChainResult, error)
 	c.executionState.originalText = input
 	c.executionState.results = make([]StepResult, 0, len(c.steps))
 
+	recorder, _ := ctx.Value(stepResultRecorderKey{}).(func(StepResult))
+
 	// 执行每个步骤
 	for i, step := range c.steps {
 		stepResult, err := c.executeStep(ctx, step, currentInput, i)
 		result.Steps = append(result.Steps, *stepResult)
 		c.executionState.results = append(c.executionState.results, *stepResult)
+		if recorder != nil {
+			recorder(*stepResult)
+		}
 
 		if err != nil {
 			result.Success = false
@@ -135,6 +161,101 @@ func (c *chain) GetSteps() []Step {
 }
 
 // executeStep 执行单个步骤
+// executeReview 迭代审校：第一个步骤给出初始译文，之后第二个步骤审校列出问题片段，
+// 第三个步骤只修改这些片段；没有第三个步骤时直接采用审校的修改建议。每一轮的步骤结果都交给记录函数
+func (c *chain) executeReview(ctx context.Context, input string) (*ChainResult, error) {
+	startTime := time.Now()
+	result := &ChainResult{FinalOutput: input, Success: true}
+
+	record := stepResultRecorderFromContext(ctx)
+	ctx = WithStepResultRecorder(ctx, func(step StepResult) {
+		result.Steps = append(result.Steps, step)
+		record(step)
+	})
+
+	c.executionState.originalText = input
+	c.executionState.results = nil
+	initial, err := c.executeStep(ctx, c.steps[0], input, 0)
+	if err != nil {
+		stepResultRecorderFromContext(ctx)(*initial)
+		result.Success = false
+		result.Error = err
+		result.TotalDuration = time.Since(startTime)
+		return result, err
+	}
+	c.executionState.results = append(c.executionState.results, *initial)
+
+	reviewer := c.steps[1]
+	var editor Step
+	if len(c.steps) > 2 {
+		editor = c.steps[2]
+	}
+
+	result.FinalOutput = runReviewLoop(ctx, *c.options.review, *initial, reviewSteps{
+		review: func(ctx context.Context, translation string, iteration int) ([]ReviewIssue, StepResult) {
+			prompt := reviewPromptBuilder(ctx, reviewer.GetConfig()).BuildReviewPrompt(input, translation)
+			output, stepResult := completeStep(ctx, reviewer, "review", prompt)
+			return finishReview(output, translation, stepResult, iteration)
+		},
+		edit: func(ctx context.Context, translation string, issues []ReviewIssue, iteration int) (string, StepResult) {
+			if editor == nil {
+				return finishEdit(translation, issues, suggestedEdits(issues), StepResult{Name: "edit"}, iteration)
+			}
+			prompt := reviewPromptBuilder(ctx, editor.GetConfig()).BuildEditPrompt(input, translation, issues)
+			output, stepResult := completeStep(ctx, editor, "edit", prompt)
+			return finishEditResponse(output, translation, issues, stepResult, iteration)
+		},
+	})
+	result.TotalDuration = time.Since(startTime)
+
+	c.traceLog("translation_review_complete",
+		zap.String("original_text", input),
+		zap.String("final_output", result.FinalOutput),
+		zap.Duration("total_duration", result.TotalDuration),
+		zap.Int("steps_executed", len(result.Steps)))
+
+	return result, nil
+}
+
+// reviewPromptBuilder 按步骤配置和上下文中的文档格式、风格和简介创建审校和编辑的提示词构建器
+func reviewPromptBuilder(ctx context.Context, cfg *StepConfig) *PromptBuilder {
+	return NewPromptBuilder(cfg.Variables["source_language"], cfg.Variables["target_language"], cfg.Variables["country"]).
+		WithTemplates(cfg.Templates, cfg.StepSet, documentFormatFromContext(ctx)).
+		WithStyleProfile(styleProfileFromContext(ctx)).
+		WithDocumentBrief(documentBriefFromContext(ctx))
+}
+
+// promptStep 能直接发送完整提示词的步骤，迭代审校用它发送审校和编辑提示词
+type promptStep interface {
+	Step
+	complete(ctx context.Context, prompt string) (*StepOutput, error)
+}
+
+// completeStep 用步骤发送完整提示词，返回响应和步骤结果
+func completeStep(ctx context.Context, s Step, name, prompt string) (string, StepResult) {
+	cfg := s.GetConfig()
+	startTime := time.Now()
+	result := StepResult{Name: name, Model: cfg.Model}
+
+	ps, ok := s.(promptStep)
+	if !ok {
+		result.Error = fmt.Sprintf("step '%s' cannot send review prompts", s.GetName())
+		return "", result
+	}
+	output, err := ps.complete(ctx, prompt)
+	result.Duration = time.Since(startTime)
+	if err != nil {
+		result.Error = err.Error()
+		return "", result
+	}
+
+	result.Output = output.Text
+	result.TokensIn = output.TokensIn
+	result.TokensOut = output.TokensOut
+	result.Cost = estimateCost(*cfg, output.TokensIn, output.TokensOut)
+	return output.Text, result
+}
+
 func (c *chain) executeStep(ctx context.Context, step Step, input string, index int) (*StepResult, error) {
 	stepConfig := step.GetConfig()
 
@@ -162,6 +283,9 @@ func (c *chain) executeStep(ctx context.Context, step Step, input string, index
 	stepInput.Context["text"] = input
 	stepInput.Context["source_language"] = stepConfig.Variables["source_language"]
 	stepInput.Context["target_language"] = stepConfig.Variables["target_language"]
+	if country := stepConfig.Variables["country"]; country != "" {
+		stepInput.Context["country"] = country
+	}
 
 	// 从 context 中提取元数据标记
 	if ctx.Value("_is_batch") != nil {
@@ -170,6 +294,18 @@ func (c *chain) executeStep(ctx context.Context, step Step, input string, index
 	if ctx.Value("_preserve_enabled") != nil {
 		stepInput.Context["_preserve_enabled"] = fmt.Sprintf("%v", ctx.Value("_preserve_enabled"))
 	}
+	if notes, ok := ctx.Value("_additional_notes").(string); ok && notes != "" {
+		stepInput.Context["additional_notes"] = notes
+	}
+	if brief := documentBriefFromContext(ctx); brief != "" {
+		stepInput.Context["document_brief"] = brief
+	}
+	if guide := RenderStyleGuide(styleProfileFromContext(ctx)); guide != "" {
+		stepInput.Context["style_guide"] = guide
+	}
+	if format := documentFormatFromContext(ctx); format != "" {
+		stepInput.Context["format"] = format
+	}
 
 	// 根据步骤类型添加特定的上下文
 	if index == 0 {
@@ -223,6 +359,7 @@ func (c *chain) executeStep(ctx context.Context, step Step, input string, index
 			result.Output = output.Text
 			result.TokensIn = output.TokensIn
 			result.TokensOut = output.TokensOut
+			result.Cost = estimateCost(*stepConfig, output.TokensIn, output.TokensOut)
 
 			// TRACE: 记录步骤执行成功的详细信息
 			c.traceLog("translation_step_success",
@@ -335,8 +472,35 @@ func (s *step) executeWithProvider(ctx context.Context, input StepInput) (*StepO
 	for k, v := range s.config.Variables {
 		metadata[k] = v
 	}
+	// 风格指南和文档简介作为 LLM 提供商的附加指令
+	var instructions []string
+	if guide := input.Context["style_guide"]; guide != "" {
+		instructions = append(instructions, guide)
+	}
+	if brief := input.Context["document_brief"]; brief != "" {
+		instructions = append(instructions, FormatDocumentBrief(brief))
+	}
+	if notes := s.providerNotes(input); notes != "" {
+		instructions = append(instructions, "Additional Notes:\n"+notes)
+	}
+	if len(instructions) > 0 {
+		metadata["instruction"] = strings.Join(instructions, "\n\n")
+	}
+	// LLM 提供商使用模板目录中匹配的模板，渲染结果作为完整提示词发送
+	text := input.Text
+	if s.config.IsLLM {
+		prompt, ok, err := s.templatePrompt(input)
+		if err != nil {
+			return nil, WrapError(err, ErrCodeConfig, fmt.Sprintf("failed to render prompt for step '%s'", s.config.Name))
+		}
+		if ok {
+			text = prompt
+			metadata[providers.MetadataRawPrompt] = true
+			metadata["instruction"] = s.getSystemRole()
+		}
+	}
 	req := &ProviderRequest{
-		Text:           input.Text,
+		Text:           text,
 		SourceLanguage: input.SourceLanguage,
 		TargetLanguage: input.TargetLanguage,
 		Metadata:       metadata,
@@ -350,7 +514,7 @@ func (s *step) executeWithProvider(ctx context.Context, input StepInput) (*StepO
 	}
 
 	// 调用提供商
-	resp, err := s.provider.Translate(ctx, req)
+	resp, err := s.translateWithProvider(ctx, req)
 	if err != nil {
 		// 创建详细的错误信息
 		providerName := s.provider.GetName()
@@ -386,10 +550,87 @@ func (s *step) executeWithProvider(ctx context.Context, input StepInput) (*StepO
 	return output, nil
 }
 
+// translateWithProvider 调用提供商。使用 JSON 批量协议且提供商支持结构化输出时，
+// 节点按 [{id, text}] 发送并校验返回的 [{id, translation}]，再还原为节点标记格式；
+// 响应无效时改用节点标记重新请求
+func (s *step) translateWithProvider(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
+	if s.config.BatchProtocol != BatchProtocolJSON || req.IsRawPrompt() || !supportsStructuredOutput(s.provider) {
+		return s.provider.Translate(ctx, req)
+	}
+	segments := providers.SplitNodeSegments(req.Text)
+	ids := segments.IDs()
+	if len(ids) == 0 || hasDuplicateIDs(ids) {
+		return s.provider.Translate(ctx, req)
+	}
+
+	text, err := EncodeBatchJSON(ids, segments.Texts())
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]interface{}, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	instruction := structuredBatchInstruction
+	if existing, ok := metadata["instruction"].(string); ok && existing != "" {
+		instruction = existing + "\n\n" + instruction
+	}
+	metadata["instruction"] = instruction
+	metadata[providers.MetadataStructuredBatch] = providers.NewStructuredBatch(ids)
+
+	resp, err := s.provider.Translate(ctx, &ProviderRequest{
+		Text:           text,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		Metadata:       metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	translations, decodeErr := DecodeBatchJSON(RemoveReasoningMarkers(resp.Text), ids)
+	if decodeErr != nil {
+		fallback, err := s.provider.Translate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		fallback.TokensIn += resp.TokensIn
+		fallback.TokensOut += resp.TokensOut
+		if fallback.Metadata == nil {
+			fallback.Metadata = make(map[string]interface{})
+		}
+		fallback.Metadata["batch_protocol"] = BatchProtocolMarkers
+		fallback.Metadata["structured_error"] = decodeErr.Error()
+		return fallback, nil
+	}
+
+	resp.Text = segments.Join(translations)
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]interface{})
+	}
+	resp.Metadata["batch_protocol"] = BatchProtocolJSON
+	return resp, nil
+}
+
+// hasDuplicateIDs 判断节点 ID 是否重复
+func hasDuplicateIDs(ids []int) bool {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
 // executeWithLLM 使用 LLM 执行
 func (s *step) executeWithLLM(ctx context.Context, input StepInput) (*StepOutput, error) {
 	// 准备提示词
-	prompt := s.preparePrompt(input)
+	prompt, err := s.preparePrompt(input)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeConfig, fmt.Sprintf("failed to render prompt for step '%s'", s.config.Name))
+	}
 
 	// 检查缓存
 	if s.cache != nil {
@@ -455,6 +696,77 @@ func (s *step) executeWithLLM(ctx context.Context, input StepInput) (*StepOutput
 	return output, nil
 }
 
+// complete 把完整提示词原样发送给提供商或 LLM，结果按提示词缓存
+func (s *step) complete(ctx context.Context, prompt string) (*StepOutput, error) {
+	cacheKey := s.getCacheKey(prompt)
+	if s.cache != nil {
+		if cached, found := s.cache.Get(cacheKey); found {
+			return &StepOutput{
+				Text:  cached,
+				Model: s.config.Model,
+			}, nil
+		}
+	}
+
+	// 设置超时
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	var output *StepOutput
+	switch {
+	case s.provider != nil:
+		resp, err := s.provider.Translate(ctx, &ProviderRequest{
+			Text:           prompt,
+			SourceLanguage: s.config.Variables["source_language"],
+			TargetLanguage: s.config.Variables["target_language"],
+			Metadata: map[string]interface{}{
+				providers.MetadataRawPrompt: true,
+				"instruction":               s.getSystemRole(),
+			},
+		})
+		if err != nil {
+			errorMsg := fmt.Sprintf("provider '%s' failed for step '%s'", s.provider.GetName(), s.config.Name)
+			return nil, WrapError(err, ErrCodeLLM, errorMsg)
+		}
+		output = &StepOutput{
+			Text:      RemoveReasoningMarkers(resp.Text),
+			Model:     s.config.Model,
+			TokensIn:  resp.TokensIn,
+			TokensOut: resp.TokensOut,
+		}
+	case s.llmClient != nil:
+		resp, err := s.llmClient.Chat(ctx, &ChatRequest{
+			Messages: []ChatMessage{
+				{Role: "system", Content: s.getSystemRole()},
+				{Role: "user", Content: prompt},
+			},
+			Model:       s.config.Model,
+			Temperature: s.config.Temperature,
+			MaxTokens:   s.config.MaxTokens,
+		})
+		if err != nil {
+			errorMsg := fmt.Sprintf("LLM call failed for step '%s' with model '%s'", s.config.Name, s.config.Model)
+			return nil, WrapError(err, ErrCodeLLM, errorMsg)
+		}
+		output = &StepOutput{
+			Text:      RemoveReasoningMarkers(resp.Message.Content),
+			Model:     resp.Model,
+			TokensIn:  resp.TokensIn,
+			TokensOut: resp.TokensOut,
+		}
+	default:
+		return nil, ErrNoLLMClient
+	}
+
+	if s.cache != nil {
+		_ = s.cache.Set(cacheKey, output.Text)
+	}
+	return output, nil
+}
+
 // GetName 获取步骤名称
 func (s *step) GetName() string {
 	return s.config.Name
@@ -470,6 +782,18 @@ func (s *step) getAdditionalNotes() string {
 	return s.config.AdditionalNotes
 }
 
+// providerNotes 合并步骤配置的附加说明和上下文中的附加说明，供提供商路径使用
+func (s *step) providerNotes(input StepInput) string {
+	var notes []string
+	if additionalNotes := s.getAdditionalNotes(); additionalNotes != "" {
+		notes = append(notes, additionalNotes)
+	}
+	if contextNotes := input.Context["additional_notes"]; contextNotes != "" {
+		notes = append(notes, contextNotes)
+	}
+	return strings.Join(notes, "\n")
+}
+
 // getSystemRole 根据步骤名称自动生成系统角色
 func (s *step) getSystemRole() string {
 	stepName := strings.ToLower(s.config.Name)
@@ -484,17 +808,23 @@ func (s *step) getSystemRole() string {
 	}
 }
 
-// preparePrompt 准备提示词
-func (s *step) preparePrompt(input StepInput) string {
+// preparePrompt 准备提示词，模板目录中匹配的模板渲染失败时返回错误
+func (s *step) preparePrompt(input StepInput) (string, error) {
+	// 模板目录中有匹配的模板时优先使用
+	if prompt, ok, err := s.templatePrompt(input); err != nil {
+		return "", err
+	} else if ok {
+		return prompt, nil
+	}
+
 	// 根据步骤名称自动选择内置模板
 	var templateType TemplateType
-	stepName := strings.ToLower(s.config.Name)
-
-	if strings.Contains(stepName, "reflection") || strings.Contains(stepName, "review") {
+	switch promptKind(s.config.Name) {
+	case PromptReflection:
 		templateType = TemplateTypeReflection
-	} else if strings.Contains(stepName, "improvement") || strings.Contains(stepName, "polish") {
+	case PromptImprovement:
 		templateType = TemplateTypeImprovement
-	} else {
+	default:
 		// 默认使用标准翻译模板
 		templateType = TemplateTypeStandard
 	}
@@ -516,8 +846,11 @@ func (s *step) preparePrompt(input StepInput) string {
 		data[k] = v
 	}
 
-	// 添加步骤配置的 additional_notes
+	// 添加步骤配置的 additional_notes，与上下文中的附加说明合并
 	if additionalNotes := s.getAdditionalNotes(); additionalNotes != "" {
+		if contextNotes := input.Context["additional_notes"]; contextNotes != "" {
+			additionalNotes += "\n" + contextNotes
+		}
 		data["additional_notes"] = additionalNotes
 	}
 
@@ -525,17 +858,28 @@ func (s *step) preparePrompt(input StepInput) string {
 	tmpl, err := template.New("prompt").Parse(promptTemplate)
 	if err != nil {
 		// 如果模板解析失败，回退到简单字符串替换（向后兼容）
-		return s.fallbackStringReplace(promptTemplate, data)
+		return s.fallbackStringReplace(promptTemplate, data), nil
 	}
 
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, data)
 	if err != nil {
 		// 如果模板执行失败，回退到简单字符串替换（向后兼容）
-		return s.fallbackStringReplace(promptTemplate, data)
+		return s.fallbackStringReplace(promptTemplate, data), nil
 	}
 
-	return buf.String()
+	return buf.String(), nil
+}
+
+// templatePrompt 用模板目录中匹配的模板渲染提示词，没有匹配的模板时返回 false
+func (s *step) templatePrompt(input StepInput) (string, bool, error) {
+	tmpl, _ := s.config.Templates.Lookup(s.config.StepSet, input.Context["format"],
+		strings.ToLower(s.config.Name), promptKind(s.config.Name))
+	if tmpl == nil {
+		return "", false, nil
+	}
+	prompt, err := s.config.Templates.Render(tmpl, promptTemplateData(s.config, input))
+	return prompt, true, err
 }
 
 // fallbackStringReplace 回退到简单字符串替换（向后兼容）
@@ -568,7 +912,11 @@ func (s *step) fallbackStringReplace(promptTemplate string, data map[string]inte
 // getCacheKey 生成缓存键
 func (s *step) getCacheKey(prompt string) string {
 	// 简单的缓存键生成，实际使用中可能需要更复杂的逻辑
-	return fmt.Sprintf("translation:%s:%s:%x", s.config.Name, s.config.Model, hash(prompt))
+	key := fmt.Sprintf("translation:%s:%s:%x", s.config.Name, s.config.Model, hash(prompt))
+	if tag := s.config.Templates.cacheTag(); tag != "" {
+		key += ":" + tag
+	}
+	return key
 }
 
 // getCacheKeyForProvider 为提供商生成缓存键
@@ -580,6 +928,20 @@ func (s *step) getCacheKeyForProvider(input StepInput) string {
 		input.TargetLanguage,
 		hash(input.Text),
 	)
+	if brief := input.Context["document_brief"]; brief != "" {
+		key += fmt.Sprintf(":%x", hash(brief))
+	}
+	if guide := input.Context["style_guide"]; guide != "" {
+		key += fmt.Sprintf(":%x", hash(guide))
+	}
+	// 附加说明（如精简重译、一致性术语）改变译文，不能命中未带说明的缓存
+	if notes := s.providerNotes(input); notes != "" {
+		key += fmt.Sprintf(":n%x", hash(notes))
+	}
+	// 模板渲染的提示词还取决于文档格式、原文和反思结果
+	if tag := s.config.Templates.cacheTag(); tag != "" {
+		key += fmt.Sprintf(":%s:%x", tag, hash(input.Context["format"]+"\x00"+input.Context["original_text"]+"\x00"+input.Context["feedback"]))
+	}
 	return key
 }
 
@@ -101,7 +101,10 @@ Please provide an improved translation.`,
 			}
 
 			// 准备提示词
-			result := step.preparePrompt(input)
+			result, err := step.preparePrompt(input)
+			if err != nil {
+				t.Fatalf("preparePrompt() error = %v", err)
+			}
 
 			// 打印结果
 			fmt.Printf("\n=== %s ===\n", cfg.name)
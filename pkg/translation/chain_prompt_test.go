@@ -78,6 +78,26 @@ func TestPreparePromptWithBuiltinTemplates(t *testing.T) {
 				"Output ONLY the improved translation, nothing else",
 			},
 		},
+		{
+			name:     "style guide and document brief in translation prompt",
+			stepName: "initial_translation",
+			context: map[string]string{
+				"text":            "Pods are scheduled on nodes.",
+				"source_language": "English",
+				"target_language": "Chinese",
+				"document_brief":  "Topic: Kubernetes scheduling\nAudience: cluster operators",
+				"style_guide":     "Style Guide (apply to every part of the translation):\n- Quotation marks: use “…”",
+			},
+			wantContains: []string{
+				"- Quotation marks: use “…”\n\nDocument Context",
+				"Document Context",
+				"Topic: Kubernetes scheduling\nAudience: cluster operators",
+				"Pods are scheduled on nodes.",
+			},
+			wantNotContains: []string{
+				"Additional Notes",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,7 +122,10 @@ func TestPreparePromptWithBuiltinTemplates(t *testing.T) {
 			}
 
 			// 准备提示词
-			result := step.preparePrompt(input)
+			result, err := step.preparePrompt(input)
+			if err != nil {
+				t.Fatalf("preparePrompt() error = %v", err)
+			}
 
 			// 检查包含的内容
 			for _, want := range tt.wantContains {
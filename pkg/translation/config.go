@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/deepl"
 )
 
 // Config 翻译服务专用配置，管理所有translation相关的功能
@@ -37,6 +38,18 @@ type Config struct {
 	EnableCache bool   `json:"enable_cache"`
 	CacheDir    string `json:"cache_dir"`
 
+	// 词汇表文件，支持词汇表的提供商（如 DeepL）据此创建服务端词汇表
+	GlossaryPath string `json:"glossary_path,omitempty"`
+
+	// 批量翻译协议：markers 或 json，json 只用于支持结构化输出的提供商
+	BatchProtocol string `json:"batch_protocol,omitempty"`
+
+	// 目标国家/地区，提示词据此选择用语
+	Country string `json:"country,omitempty"`
+
+	// 提示词模板目录，为空时使用内置提示词
+	PromptTemplatesDir string `json:"prompt_templates_dir,omitempty"`
+
 	// 元数据
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -52,6 +65,11 @@ type StepConfig struct {
 	AdditionalNotes string            `json:"additional_notes"` // 用户自定义说明
 	Variables       map[string]string `json:"variables"`        // 提示词变量（保留用于兼容）
 	IsLLM           bool              `json:"is_llm"`           // 是否是LLM模型（支持复杂推理和对话）
+	BatchProtocol   string            `json:"batch_protocol"`   // 批量翻译协议，为空时使用服务配置
+	StepSet         string            `json:"step_set"`         // 所属步骤集，用于选择按步骤集覆盖的提示词模板
+	Templates       *PromptTemplates  `json:"-"`                // 提示词模板，为 nil 时使用内置提示词
+	InputPrice      float64           `json:"input_price"`      // 每 1M 输入 token 的价格，提供商没有返回成本时用于估算
+	OutputPrice     float64           `json:"output_price"`     // 每 1M 输出 token 的价格
 }
 
 // DefaultConfig 返回默认配置
@@ -116,6 +134,11 @@ func (c *Config) Validate() error {
 	if len(c.Steps) == 0 {
 		return errors.New("at least one translation step is required")
 	}
+	switch c.BatchProtocol {
+	case "", BatchProtocolMarkers, BatchProtocolJSON:
+	default:
+		return errors.New("batch protocol must be markers or json")
+	}
 
 	// 验证步骤集配置
 	if c.ActiveStepSet == "" {
@@ -190,35 +213,85 @@ func (c *Config) Clone() *Config {
 	return &clone
 }
 
+// WithInitialStepVariant 返回初始翻译步骤改用指定模型和温度的配置副本，用于生成候选译文。
+// model 为空时沿用原模型，temperature 为 nil 时沿用模型配置的温度。
+// 提供商使用模型配置中的温度，因此副本中该模型的配置也一并修改
+func (c *Config) WithInitialStepVariant(model string, temperature *float64) *Config {
+	clone := c.Clone()
+	stepSet, ok := c.StepSets[c.ActiveStepSet]
+	if !ok || len(stepSet.Steps) == 0 || len(clone.Steps) == 0 {
+		return clone
+	}
+
+	clone.ModelConfigs = make(map[string]config.ModelConfig, len(c.ModelConfigs))
+	for name, modelConfig := range c.ModelConfigs {
+		clone.ModelConfigs[name] = modelConfig
+	}
+	clone.StepSets = make(map[string]config.StepSetConfigV2, len(c.StepSets))
+	for name, set := range c.StepSets {
+		clone.StepSets[name] = set
+	}
+	stepSet.Steps = append([]config.StepConfigV2(nil), stepSet.Steps...)
+	initial := &stepSet.Steps[0]
+
+	if model != "" && model != initial.ModelName {
+		initial.ModelName = model
+		if modelConfig, ok := clone.ModelConfigs[model]; ok && modelConfig.APIType != "" {
+			initial.Provider = modelConfig.APIType
+		}
+	}
+	if temperature != nil {
+		initial.Temperature = *temperature
+		if modelConfig, ok := clone.ModelConfigs[initial.ModelName]; ok {
+			modelConfig.Temperature = *temperature
+			clone.ModelConfigs[initial.ModelName] = modelConfig
+		}
+	}
+	clone.StepSets[c.ActiveStepSet] = stepSet
+
+	clone.Steps[0].Provider = initial.Provider
+	clone.Steps[0].Model = initial.ModelName
+	clone.Steps[0].Temperature = float32(initial.Temperature)
+	clone.Steps[0].IsLLM = clone.ModelConfigs[initial.ModelName].IsLLM
+	return clone
+}
+
 // NewConfigFromGlobal 从全局配置创建 Translation 配置
 func NewConfigFromGlobal(globalCfg *config.Config) *Config {
 	translationCfg := &Config{
-		SourceLanguage: globalCfg.SourceLang,
-		TargetLanguage: globalCfg.TargetLang,
-		ChunkSize:      globalCfg.ChunkSize,
-		ChunkOverlap:   100, // 默认重叠大小
-		MaxConcurrency: globalCfg.Concurrency,
-		MaxRetries:     globalCfg.MaxRetries,
-		RetryDelay:     time.Second,
-		Timeout:        time.Duration(globalCfg.TranslationTimeout) * time.Second,
-		EnableCache:    globalCfg.UseCache,
-		CacheDir:       globalCfg.CacheDir,
-		ModelConfigs:   globalCfg.ModelConfigs,
-		ActiveStepSet:  globalCfg.ActiveStepSet,
-		StepSets:       globalCfg.StepSets,
-		Metadata:       globalCfg.Metadata,
+		SourceLanguage:     globalCfg.SourceLang,
+		TargetLanguage:     globalCfg.TargetLang,
+		ChunkSize:          globalCfg.ChunkSize,
+		ChunkOverlap:       100, // 默认重叠大小
+		MaxConcurrency:     globalCfg.Concurrency,
+		MaxRetries:         globalCfg.MaxRetries,
+		RetryDelay:         time.Second,
+		Timeout:            time.Duration(globalCfg.TranslationTimeout) * time.Second,
+		EnableCache:        globalCfg.UseCache,
+		CacheDir:           globalCfg.CacheDir,
+		GlossaryPath:       globalCfg.GlossaryPath,
+		BatchProtocol:      globalCfg.BatchProtocol,
+		Country:            globalCfg.Country,
+		PromptTemplatesDir: globalCfg.PromptTemplatesDir,
+		ModelConfigs:       globalCfg.ModelConfigs,
+		ActiveStepSet:      globalCfg.ActiveStepSet,
+		StepSets:           globalCfg.StepSets,
+		Metadata:           globalCfg.Metadata,
 	}
 
 	// 从活动步骤集生成Steps配置
 	if stepSet, exists := globalCfg.StepSets[globalCfg.ActiveStepSet]; exists {
 		translationCfg.Steps = make([]StepConfig, len(stepSet.Steps))
 		for i, step := range stepSet.Steps {
-			// 获取模型的 IsLLM 信息
+			// 获取模型的 IsLLM 信息和价格
 			var isLLM bool
+			var inputPrice, outputPrice float64
 			if step.ModelName == "raw" || step.ModelName == "none" {
 				isLLM = false // 特殊选项不是 LLM
 			} else if modelConfig, exists := globalCfg.ModelConfigs[step.ModelName]; exists {
 				isLLM = modelConfig.IsLLM
+				inputPrice = modelConfig.InputTokenPrice
+				outputPrice = modelConfig.OutputTokenPrice
 			}
 
 			translationCfg.Steps[i] = StepConfig{
@@ -231,6 +304,8 @@ func NewConfigFromGlobal(globalCfg *config.Config) *Config {
 				AdditionalNotes: step.AdditionalNotes,
 				Variables:       make(map[string]string), // 初始化空的 variables
 				IsLLM:           isLLM,
+				InputPrice:      inputPrice,
+				OutputPrice:     outputPrice,
 			}
 		}
 	}
@@ -276,6 +351,30 @@ type StepSetConfig struct {
 	Name        string     `json:"name"`
 	Description string     `json:"description"`
 	Initial     StepConfig `json:"initial"`
-	Reflection  StepConfig `json:"reflection"`
-	Improvement StepConfig `json:"improvement"`
+	Reflection  StepConfig `json:"reflection"`  // 迭代审校模式中作为审校步骤
+	Improvement StepConfig `json:"improvement"` // 迭代审校模式中作为编辑步骤
+
+	// Mode 翻译模式：为空或 "three_step" 时固定执行一次初始翻译、反思和改进，"review" 时迭代审校
+	Mode   string       `json:"mode"`
+	Review ReviewConfig `json:"review"`
+}
+
+// LoadPromptTemplates 加载配置的提示词模板目录，并用词汇表填充 glossary 变量。
+// 未配置模板目录时返回 nil，使用内置提示词
+func (c *Config) LoadPromptTemplates() (*PromptTemplates, error) {
+	if c.PromptTemplatesDir == "" {
+		return nil, nil
+	}
+	templates, err := LoadPromptTemplates(c.PromptTemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	if c.GlossaryPath != "" {
+		entries, err := deepl.LoadGlossaryEntries(c.GlossaryPath)
+		if err != nil {
+			return nil, err
+		}
+		templates.WithGlossary(entries)
+	}
+	return templates, nil
 }
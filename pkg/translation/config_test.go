@@ -0,0 +1,44 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithInitialStepVariant(t *testing.T) {
+	cfg := NewConfigFromGlobal(&config.Config{
+		ActiveStepSet: "quality",
+		ModelConfigs: map[string]config.ModelConfig{
+			"gpt-4o": {APIType: "openai", Temperature: 0.3, IsLLM: true},
+			"qwen":   {APIType: "ollama", Temperature: 0.5, IsLLM: true},
+		},
+		StepSets: map[string]config.StepSetConfigV2{
+			"quality": {Steps: []config.StepConfigV2{
+				{Name: "initial", Provider: "openai", ModelName: "gpt-4o", Temperature: 0.3},
+				{Name: "reflection", Provider: "openai", ModelName: "gpt-4o", Temperature: 0.3},
+			}},
+		},
+	})
+
+	temperature := 0.9
+	variant := cfg.WithInitialStepVariant("qwen", &temperature)
+	assert.Equal(t, "ollama", variant.Steps[0].Provider)
+	assert.Equal(t, "qwen", variant.Steps[0].Model)
+	assert.InDelta(t, 0.9, variant.Steps[0].Temperature, 1e-6)
+	assert.Equal(t, "qwen", variant.StepSets["quality"].Steps[0].ModelName)
+	assert.Equal(t, 0.9, variant.ModelConfigs["qwen"].Temperature)
+	assert.Equal(t, "gpt-4o", variant.Steps[1].Model)
+
+	// 原配置不受影响
+	assert.Equal(t, "gpt-4o", cfg.Steps[0].Model)
+	assert.Equal(t, "gpt-4o", cfg.StepSets["quality"].Steps[0].ModelName)
+	assert.Equal(t, 0.5, cfg.ModelConfigs["qwen"].Temperature)
+
+	// 只改变温度
+	variant = cfg.WithInitialStepVariant("", &temperature)
+	assert.Equal(t, "openai", variant.Steps[0].Provider)
+	assert.Equal(t, 0.9, variant.ModelConfigs["gpt-4o"].Temperature)
+	assert.Equal(t, 0.3, cfg.ModelConfigs["gpt-4o"].Temperature)
+}
@@ -13,6 +13,24 @@ type Service interface {
 	TranslateText(ctx context.Context, text string) (string, error)
 }
 
+// DocumentBriefGenerator 可选接口：翻译前为整篇文档生成简介
+type DocumentBriefGenerator interface {
+	// GenerateDocumentBrief 概括文档的主题、领域、语气、读者和关键实体
+	GenerateDocumentBrief(ctx context.Context, text string) (string, error)
+}
+
+// ContextWindowReporter 可选接口：报告翻译步骤所用模型中最小的上下文长度（token 数）
+type ContextWindowReporter interface {
+	// ContextWindow 返回上下文长度，没有提供商报告时返回 0
+	ContextWindow(ctx context.Context) int
+}
+
+// PromptTemplateReporter 可选接口：报告使用的提示词模板版本
+type PromptTemplateReporter interface {
+	// PromptTemplateVersion 返回模板版本，未配置模板目录时为 builtin
+	PromptTemplateVersion() string
+}
+
 // Chain 翻译链接口
 type Chain interface {
 	// Execute 执行翻译链
@@ -169,6 +169,7 @@ type chainOptions struct {
 	maxRetries      int
 	parallelSteps   bool
 	logger          *zap.Logger // 新增：日志记录器
+	review          *ReviewConfig
 }
 
 // WithSkipCache 跳过缓存
@@ -199,6 +200,13 @@ func WithParallelSteps(parallel bool) ChainOption {
 	}
 }
 
+// WithReviewMode 使用迭代审校模式：第一个步骤初始翻译，第二个步骤审校，第三个步骤（可选）编辑
+func WithReviewMode(config ReviewConfig) ChainOption {
+	return func(o *chainOptions) {
+		o.review = &config
+	}
+}
+
 // WithChainLogger 设置翻译链日志记录器
 func WithChainLogger(logger *zap.Logger) ChainOption {
 	return func(o *chainOptions) {
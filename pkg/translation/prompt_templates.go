@@ -0,0 +1,319 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// 提示词模板名称，步骤按名称推断使用哪个模板
+const (
+	PromptInitial     = "initial"     // 初始翻译
+	PromptReflection  = "reflection"  // 反思
+	PromptImprovement = "improvement" // 改进
+	PromptDirect      = "direct"      // 直接翻译（快速模式）
+
+	PromptReviewIssues = "review_issues" // 迭代审校：找出问题片段
+	PromptReviewEdit   = "review_edit"   // 迭代审校：只修改问题片段
+)
+
+// BuiltinPromptVersion 未配置模板目录时的提示词版本
+const BuiltinPromptVersion = "builtin"
+
+// promptTemplateExt 模板文件扩展名
+const promptTemplateExt = ".tmpl"
+
+// PromptTemplates 从目录加载的提示词模板。模板使用 text/template 语法，可用的变量：
+//
+//	source_language / source   源语言
+//	target_language / target   目标语言
+//	country                    目标国家/地区
+//	chunk / text               本步骤要处理的文本（初始翻译时为原文片段）
+//	original_text              原文片段
+//	translation                初始翻译（反思、改进步骤）
+//	feedback / reflection      反思结果（改进步骤）
+//	issues                     审校发现的问题（JSON，review_edit 模板）
+//	context / document_brief   文档简介
+//	glossary                   词汇表中出现在原文片段里的术语，每行 "原文 => 译文"
+//	style / style_guide        风格指南
+//	additional_notes           步骤配置和文档格式的附加说明
+//	format                     文档格式（如 markdown、html）
+//	step / step_set            步骤名称和步骤集名称
+//
+// 目录结构（越具体的位置优先）：
+//
+//	VERSION                                   可选，模板版本，记录到缓存键和统计中
+//	initial.tmpl                              默认模板
+//	<format>/initial.tmpl                     按文档格式覆盖
+//	step_sets/<step_set>/initial.tmpl         按步骤集覆盖
+//	step_sets/<step_set>/<format>/initial.tmpl 按步骤集和文档格式覆盖
+//
+// 同一位置中以步骤名称命名的模板（如 polish.tmpl）优先于按步骤类型推断的模板
+type PromptTemplates struct {
+	dir       string
+	version   string
+	digest    string
+	templates map[string]*template.Template // 相对路径（不含扩展名）-> 模板
+	glossary  map[string]string
+}
+
+// LoadPromptTemplates 加载目录中的所有 .tmpl 模板，解析或试渲染失败时返回错误
+func LoadPromptTemplates(dir string) (*PromptTemplates, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prompt template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("prompt template path %s is not a directory", dir)
+	}
+
+	t := &PromptTemplates{dir: dir, templates: make(map[string]*template.Template)}
+	var names []string
+	contents := make(map[string][]byte)
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != promptTemplateExt {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), promptTemplateExt)
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse prompt template %s: %w", rel, err)
+		}
+		if err := tmpl.Execute(&bytes.Buffer{}, map[string]string{}); err != nil {
+			return fmt.Errorf("failed to render prompt template %s: %w", rel, err)
+		}
+		t.templates[name] = tmpl
+		names = append(names, name)
+		contents[name] = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(t.templates) == 0 {
+		return nil, fmt.Errorf("no %s files found in prompt template directory %s", promptTemplateExt, dir)
+	}
+
+	// 内容摘要：模板修改后缓存自动失效
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, contents[name])
+	}
+	t.digest = hex.EncodeToString(h.Sum(nil))[:12]
+
+	t.version = "sha256:" + t.digest
+	if data, err := os.ReadFile(filepath.Join(dir, "VERSION")); err == nil {
+		if version := strings.TrimSpace(string(data)); version != "" {
+			t.version = version
+		}
+	}
+	return t, nil
+}
+
+// WithGlossary 设置词汇表，渲染时把出现在原文片段中的术语填入 glossary 变量
+func (t *PromptTemplates) WithGlossary(entries map[string]string) *PromptTemplates {
+	t.glossary = entries
+	return t
+}
+
+// Dir 模板目录
+func (t *PromptTemplates) Dir() string {
+	if t == nil {
+		return ""
+	}
+	return t.dir
+}
+
+// Version 模板版本：VERSION 文件的内容，没有时为模板内容的摘要；未加载模板时为 builtin
+func (t *PromptTemplates) Version() string {
+	if t == nil {
+		return BuiltinPromptVersion
+	}
+	return t.version
+}
+
+// cacheTag 缓存键中的模板标识，包含内容摘要以免修改模板后忘记更新 VERSION 时命中旧缓存
+func (t *PromptTemplates) cacheTag() string {
+	if t == nil {
+		return ""
+	}
+	return t.version + "@" + t.digest
+}
+
+// Lookup 按步骤集、文档格式和模板名称（依次尝试）查找模板，返回模板和它的相对路径
+func (t *PromptTemplates) Lookup(stepSet, format string, names ...string) (*template.Template, string) {
+	if t == nil {
+		return nil, ""
+	}
+
+	var dirs []string
+	if stepSet != "" && format != "" {
+		dirs = append(dirs, path.Join("step_sets", stepSet, format))
+	}
+	if stepSet != "" {
+		dirs = append(dirs, path.Join("step_sets", stepSet))
+	}
+	if format != "" {
+		dirs = append(dirs, format)
+	}
+	dirs = append(dirs, "")
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			key := path.Join(dir, name)
+			if tmpl, ok := t.templates[key]; ok {
+				return tmpl, key + promptTemplateExt
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Render 用变量渲染模板，补充 glossary 变量
+func (t *PromptTemplates) Render(tmpl *template.Template, data map[string]string) (string, error) {
+	if data["glossary"] == "" && len(t.glossary) > 0 {
+		source := data["original_text"]
+		if source == "" {
+			source = data["text"]
+		}
+		data["glossary"] = FormatGlossary(t.glossary, source)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// FormatGlossary 列出出现在文本中的术语（不区分大小写），每行 "原文 => 译文"，按原文排序
+func FormatGlossary(entries map[string]string, text string) string {
+	lower := strings.ToLower(text)
+	var lines []string
+	for source, target := range entries {
+		if source != "" && strings.Contains(lower, strings.ToLower(source)) {
+			lines = append(lines, source+" => "+target)
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// promptKind 根据步骤名称推断提示词类型
+func promptKind(stepName string) string {
+	name := strings.ToLower(stepName)
+	switch {
+	case strings.Contains(name, "reflection") || strings.Contains(name, "review"):
+		return PromptReflection
+	case strings.Contains(name, "improvement") || strings.Contains(name, "polish"):
+		return PromptImprovement
+	default:
+		return PromptInitial
+	}
+}
+
+// promptTemplateData 由步骤输入生成模板变量，补充别名变量
+func promptTemplateData(cfg *StepConfig, input StepInput) map[string]string {
+	data := map[string]string{
+		"source_language": input.SourceLanguage,
+		"target_language": input.TargetLanguage,
+		"text":            input.Text,
+		"step":            cfg.Name,
+		"step_set":        cfg.StepSet,
+	}
+	for k, v := range input.Context {
+		data[k] = v
+	}
+	if cfg.AdditionalNotes != "" {
+		notes := cfg.AdditionalNotes
+		if contextNotes := input.Context["additional_notes"]; contextNotes != "" {
+			notes += "\n" + contextNotes
+		}
+		data["additional_notes"] = notes
+	}
+	if data["original_text"] == "" {
+		data["original_text"] = input.Text
+	}
+	addPromptAliases(data)
+	return data
+}
+
+// addPromptAliases 补充别名变量，两个名称中只设置了一个时互相填充
+func addPromptAliases(data map[string]string) {
+	aliases := map[string]string{
+		"source":         "source_language",
+		"target":         "target_language",
+		"chunk":          "text",
+		"context":        "document_brief",
+		"style":          "style_guide",
+		"reflection":     "feedback",
+		"feedback":       "reflection",
+		"document_brief": "context",
+		"style_guide":    "style",
+	}
+	for alias, key := range aliases {
+		if data[alias] == "" {
+			data[alias] = data[key]
+		}
+	}
+}
+
+// RenderStepPrompt 返回步骤实际发送给模型的提示词，以及提示词的来源：
+// 模板的相对路径、"builtin:<类型>"（LLM 客户端步骤的内置模板），
+// 或 "provider"（非 LLM 或没有匹配模板的提供商步骤，直接发送文本，由提供商套用自己的提示词）
+func RenderStepPrompt(cfg *StepConfig, input StepInput) (prompt, source string, err error) {
+	// 提供商步骤只有 LLM 模型才使用模板，其他提供商（如 DeepL）直接翻译文本
+	if cfg.Provider != "" && !cfg.IsLLM {
+		return input.Text, "provider", nil
+	}
+	kind := promptKind(cfg.Name)
+	if tmpl, rel := cfg.Templates.Lookup(cfg.StepSet, input.Context["format"], strings.ToLower(cfg.Name), kind); tmpl != nil {
+		prompt, err = cfg.Templates.Render(tmpl, promptTemplateData(cfg, input))
+		return prompt, rel, err
+	}
+	if cfg.Provider != "" {
+		return input.Text, "provider", nil
+	}
+	s := &step{config: cfg}
+	prompt, err = s.preparePrompt(input)
+	return prompt, "builtin:" + kind, err
+}
+
+// documentFormatKey 上下文中文档格式的键
+type documentFormatKey struct{}
+
+// WithDocumentFormat 在上下文中设置文档格式（如 markdown、html），用于选择按格式覆盖的提示词模板
+func WithDocumentFormat(ctx context.Context, format string) context.Context {
+	if format == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, documentFormatKey{}, format)
+}
+
+// documentFormatFromContext 从上下文中读取文档格式
+func documentFormatFromContext(ctx context.Context) string {
+	format, _ := ctx.Value(documentFormatKey{}).(string)
+	return format
+}
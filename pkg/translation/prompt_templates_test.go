@@ -0,0 +1,217 @@
+package translation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePromptTemplates 在临时目录中写入模板文件，返回目录
+func writePromptTemplates(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestPromptTemplatesLookup(t *testing.T) {
+	dir := writePromptTemplates(t, map[string]string{
+		"initial.tmpl":                        "root",
+		"reflection.tmpl":                     "reflection",
+		"markdown/initial.tmpl":               "markdown",
+		"step_sets/quality/initial.tmpl":      "quality",
+		"step_sets/quality/html/initial.tmpl": "quality html",
+		"step_sets/quality/polish.tmpl":       "polish",
+		"notes.txt":                           "ignored",
+	})
+	templates, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		stepSet, format string
+		names           []string
+		want            string
+	}{
+		{"", "", []string{"initial"}, "initial.tmpl"},
+		{"", "markdown", []string{"initial"}, "markdown/initial.tmpl"},
+		{"basic", "html", []string{"initial"}, "initial.tmpl"},
+		{"quality", "markdown", []string{"initial"}, "step_sets/quality/initial.tmpl"},
+		{"quality", "html", []string{"initial"}, "step_sets/quality/html/initial.tmpl"},
+		{"quality", "", []string{"polish", "improvement"}, "step_sets/quality/polish.tmpl"},
+		{"quality", "", []string{"review", "reflection"}, "reflection.tmpl"},
+		{"", "", []string{"improvement"}, ""},
+	}
+	for _, tt := range tests {
+		tmpl, rel := templates.Lookup(tt.stepSet, tt.format, tt.names...)
+		assert.Equal(t, tt.want, rel, "%s/%s %v", tt.stepSet, tt.format, tt.names)
+		assert.Equal(t, tt.want != "", tmpl != nil)
+	}
+
+	var none *PromptTemplates
+	tmpl, _ := none.Lookup("quality", "html", "initial")
+	assert.Nil(t, tmpl)
+}
+
+func TestPromptTemplatesVersion(t *testing.T) {
+	var none *PromptTemplates
+	assert.Equal(t, BuiltinPromptVersion, none.Version())
+	assert.Empty(t, none.cacheTag())
+
+	dir := writePromptTemplates(t, map[string]string{"initial.tmpl": "{{.chunk}}"})
+	templates, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{12}$`, templates.Version())
+
+	// 修改模板内容后摘要改变
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "initial.tmpl"), []byte("Translate: {{.chunk}}"), 0o644))
+	changed, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, templates.Version(), changed.Version())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte("v2\n"), 0o644))
+	versioned, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", versioned.Version())
+	assert.Equal(t, "v2@"+changed.digest, versioned.cacheTag())
+}
+
+func TestLoadPromptTemplatesErrors(t *testing.T) {
+	_, err := LoadPromptTemplates(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+
+	_, err = LoadPromptTemplates(t.TempDir())
+	assert.ErrorContains(t, err, "no .tmpl files")
+
+	_, err = LoadPromptTemplates(writePromptTemplates(t, map[string]string{"initial.tmpl": "{{.chunk"}))
+	assert.ErrorContains(t, err, "failed to parse prompt template initial.tmpl")
+}
+
+func TestRenderStepPrompt(t *testing.T) {
+	dir := writePromptTemplates(t, map[string]string{
+		"initial.tmpl": "{{.source}} -> {{.target}} ({{.country}}, {{.format}})\n{{.glossary}}\n{{if .context}}Context: {{.context}}\n{{end}}{{.chunk}}",
+	})
+	templates, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+	templates.WithGlossary(map[string]string{"API": "接口", "Kubernetes": "Kubernetes", "cluster": "集群"})
+
+	input := StepInput{
+		Text:           "Call the api on the cluster.",
+		SourceLanguage: "English",
+		TargetLanguage: "Chinese",
+		Context:        map[string]string{"country": "China", "format": "markdown", "document_brief": "Ops guide"},
+	}
+
+	prompt, source, err := RenderStepPrompt(&StepConfig{Name: "initial", Templates: templates}, input)
+	require.NoError(t, err)
+	assert.Equal(t, "initial.tmpl", source)
+	assert.Equal(t, "English -> Chinese (China, markdown)\nAPI => 接口\ncluster => 集群\nContext: Ops guide\nCall the api on the cluster.", prompt)
+
+	// 非 LLM 提供商直接发送文本
+	prompt, source, err = RenderStepPrompt(&StepConfig{Name: "initial", Provider: "deepl", Templates: templates}, input)
+	require.NoError(t, err)
+	assert.Equal(t, "provider", source)
+	assert.Equal(t, input.Text, prompt)
+
+	// 没有匹配模板时使用内置模板
+	prompt, source, err = RenderStepPrompt(&StepConfig{Name: "reflection", Templates: templates}, input)
+	require.NoError(t, err)
+	assert.Equal(t, "builtin:reflection", source)
+	assert.NotEmpty(t, prompt)
+}
+
+func TestProviderStepUsesPromptTemplate(t *testing.T) {
+	dir := writePromptTemplates(t, map[string]string{
+		"initial.tmpl":      "Translate into {{.target}}:\n{{.chunk}}",
+		"html/initial.tmpl": "Translate this HTML into {{.target}}:\n{{.chunk}}",
+	})
+	templates, err := LoadPromptTemplates(dir)
+	require.NoError(t, err)
+
+	provider := &structuredProvider{replies: []string{"你好", "<p>你好</p>"}}
+	step := NewProviderStep(&StepConfig{Name: "initial", Provider: "ollama", IsLLM: true, Templates: templates}, provider, nil)
+
+	_, err = step.Execute(context.Background(), StepInput{Text: "Hello", SourceLanguage: "English", TargetLanguage: "Chinese"})
+	require.NoError(t, err)
+	req := provider.requests[0]
+	assert.Equal(t, "Translate into Chinese:\nHello", req.Text)
+	assert.Equal(t, true, req.Metadata[providers.MetadataRawPrompt])
+
+	_, err = step.Execute(context.Background(), StepInput{
+		Text: "<p>Hello</p>", SourceLanguage: "English", TargetLanguage: "Chinese",
+		Context: map[string]string{"format": "html"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Translate this HTML into Chinese:\n<p>Hello</p>", provider.requests[1].Text)
+}
+
+func TestPromptTemplatesChangeCacheKey(t *testing.T) {
+	templates, err := LoadPromptTemplates(writePromptTemplates(t, map[string]string{"initial.tmpl": "{{.chunk}}"}))
+	require.NoError(t, err)
+
+	input := StepInput{Text: "Hello", SourceLanguage: "en", TargetLanguage: "zh", Context: map[string]string{}}
+	builtin := &step{config: &StepConfig{Name: "initial"}, provider: &structuredProvider{}}
+	templated := &step{config: &StepConfig{Name: "initial", Templates: templates}, provider: &structuredProvider{}}
+
+	assert.NotEqual(t, builtin.getCacheKeyForProvider(input), templated.getCacheKeyForProvider(input))
+	assert.NotEqual(t, builtin.getCacheKey("prompt"), templated.getCacheKey("prompt"))
+	assert.Contains(t, templated.getCacheKey("prompt"), templates.Version())
+}
+
+func TestPromptBuilderUsesTemplates(t *testing.T) {
+	templates, err := LoadPromptTemplates(writePromptTemplates(t, map[string]string{
+		"initial.tmpl":             "{{.source}} to {{.target}} for {{.country}}: {{.chunk}}",
+		"markdown/reflection.tmpl": "Review {{.translation}} against {{.original_text}}",
+	}))
+	require.NoError(t, err)
+
+	builder := NewPromptBuilder("English", "Chinese", "Taiwan").WithTemplates(templates, "", "markdown")
+	assert.Equal(t, "English to Chinese for Taiwan: Hello", builder.BuildInitialTranslationPrompt("Hello"))
+	assert.Equal(t, "English to Chinese for Taiwan: Hello", builder.BuildDirectTranslationPrompt("Hello"))
+	assert.Equal(t, "Review 你好 against Hello", builder.BuildReflectionPrompt("Hello", "你好"))
+	// 没有改进模板时使用内置提示词
+	assert.Contains(t, builder.BuildImprovementPrompt("Hello", "你好", "ok"), "Reflection/Issues identified:")
+}
+
+// chatClient 返回固定回复并记录请求数的模拟 LLM 客户端
+type chatClient struct {
+	calls int
+}
+
+func (c *chatClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return nil, nil
+}
+
+func (c *chatClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	c.calls++
+	return &ChatResponse{Message: ChatMessage{Role: "assistant", Content: "你好"}}, nil
+}
+
+func (c *chatClient) GetModel() string                      { return "chat" }
+func (c *chatClient) HealthCheck(ctx context.Context) error { return nil }
+
+func TestLLMStepReturnsTemplateRenderError(t *testing.T) {
+	templates, err := LoadPromptTemplates(writePromptTemplates(t, map[string]string{
+		"initial.tmpl": `{{if .chunk}}{{template "missing" .}}{{end}}`,
+	}))
+	require.NoError(t, err)
+
+	client := &chatClient{}
+	step := NewStep(&StepConfig{Name: "initial", Templates: templates}, client, nil)
+
+	// 模板渲染失败时不能回退到内置模板
+	_, err = step.Execute(context.Background(), StepInput{Text: "Hello", SourceLanguage: "English", TargetLanguage: "Chinese"})
+	require.Error(t, err)
+	var translationErr *TranslationError
+	require.ErrorAs(t, err, &translationErr)
+	assert.Equal(t, ErrCodeConfig, translationErr.Code)
+	assert.Contains(t, err.Error(), "failed to render prompt for step 'initial'")
+	assert.Zero(t, client.calls)
+}
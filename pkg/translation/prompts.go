@@ -1,9 +1,12 @@
 package translation
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 )
 
 // PromptBuilder 提示词构建器
@@ -18,6 +21,15 @@ type PromptBuilder struct {
 	PreserveConfig PreserveConfig
 	// 额外的指令
 	ExtraInstructions []string
+	// 文档简介（可选），加入所有提示词
+	DocumentBrief string
+	// 风格配置（可选），渲染为风格指南加入所有提示词
+	StyleProfile *config.StyleProfile
+	// 提示词模板（可选），有匹配的模板时代替内置提示词
+	Templates *PromptTemplates
+	// 选择模板时使用的步骤集和文档格式
+	StepSet string
+	Format  string
 }
 
 // NewPromptBuilder 创建提示词构建器
@@ -37,6 +49,26 @@ func (pb *PromptBuilder) WithPreserveConfig(config PreserveConfig) *PromptBuilde
 	return pb
 }
 
+// WithDocumentBrief 设置文档简介
+func (pb *PromptBuilder) WithDocumentBrief(brief string) *PromptBuilder {
+	pb.DocumentBrief = strings.TrimSpace(brief)
+	return pb
+}
+
+// WithStyleProfile 设置风格配置
+func (pb *PromptBuilder) WithStyleProfile(profile *config.StyleProfile) *PromptBuilder {
+	pb.StyleProfile = profile
+	return pb
+}
+
+// WithTemplates 设置提示词模板，以及选择模板时使用的步骤集和文档格式
+func (pb *PromptBuilder) WithTemplates(templates *PromptTemplates, stepSet, format string) *PromptBuilder {
+	pb.Templates = templates
+	pb.StepSet = stepSet
+	pb.Format = format
+	return pb
+}
+
 // AddInstruction 添加额外指令
 func (pb *PromptBuilder) AddInstruction(instruction string) *PromptBuilder {
 	pb.ExtraInstructions = append(pb.ExtraInstructions, instruction)
@@ -45,6 +77,10 @@ func (pb *PromptBuilder) AddInstruction(instruction string) *PromptBuilder {
 
 // BuildInitialTranslationPrompt 构建初始翻译提示词
 func (pb *PromptBuilder) BuildInitialTranslationPrompt(text string) string {
+	if prompt, ok := pb.renderTemplate(map[string]string{"text": text}, PromptInitial); ok {
+		return prompt
+	}
+
 	prompt := fmt.Sprintf(`This is a translation task from %s to %s.
 
 Formatting Rules:
@@ -70,6 +106,9 @@ Formatting Rules:
 		}
 	}
 
+	// 添加风格指南和文档简介
+	prompt = pb.appendDocumentContext(prompt)
+
 	// 添加保护块说明
 	prompt = AppendPreservePrompt(prompt, pb.PreserveConfig)
 
@@ -108,6 +147,14 @@ TRANSLATE ONLY THE CONTENT BETWEEN MARKERS - NEVER THE MARKERS THEMSELVES!`
 
 // BuildReflectionPrompt 构建反思提示词
 func (pb *PromptBuilder) BuildReflectionPrompt(sourceText, initialTranslation string) string {
+	if prompt, ok := pb.renderTemplate(map[string]string{
+		"text":          initialTranslation,
+		"original_text": sourceText,
+		"translation":   initialTranslation,
+	}, PromptReflection); ok {
+		return prompt
+	}
+
 	prompt := fmt.Sprintf(`You are reviewing a translation from %s to %s.
 
 Original text:
@@ -132,6 +179,9 @@ Please analyze this translation and identify any issues. Consider:
 		prompt += fmt.Sprintf("\n6. Regional appropriateness: Is the language appropriate for %s?", pb.Country)
 	}
 
+	// 添加风格指南和文档简介
+	prompt = pb.appendDocumentContext(prompt)
+
 	// 添加保护块说明
 	preservePrompt := GetPreservePrompt(pb.PreserveConfig)
 	if preservePrompt != "" {
@@ -146,6 +196,15 @@ Please analyze this translation and identify any issues. Consider:
 
 // BuildImprovementPrompt 构建改进提示词
 func (pb *PromptBuilder) BuildImprovementPrompt(sourceText, initialTranslation, reflection string) string {
+	if prompt, ok := pb.renderTemplate(map[string]string{
+		"text":          initialTranslation,
+		"original_text": sourceText,
+		"translation":   initialTranslation,
+		"feedback":      reflection,
+	}, PromptImprovement); ok {
+		return prompt
+	}
+
 	prompt := fmt.Sprintf(`You are improving a translation from %s to %s based on the following reflection.
 
 Original text:
@@ -173,6 +232,9 @@ Please provide an improved translation that addresses all the issues mentioned i
 		prompt += fmt.Sprintf("\n5. Using language appropriate for %s", pb.Country)
 	}
 
+	// 添加风格指南和文档简介
+	prompt = pb.appendDocumentContext(prompt)
+
 	// 添加保护块说明
 	prompt = AppendPreservePrompt(prompt, pb.PreserveConfig)
 
@@ -204,8 +266,97 @@ TRANSLATE ONLY THE CONTENT BETWEEN MARKERS - NEVER THE MARKERS THEMSELVES!`
 	return prompt
 }
 
+// BuildReviewPrompt 构建迭代审校的审校提示词，要求以 JSON 列出译文中的问题片段
+func (pb *PromptBuilder) BuildReviewPrompt(sourceText, translation string) string {
+	if prompt, ok := pb.renderTemplate(map[string]string{
+		"text":          translation,
+		"original_text": sourceText,
+		"translation":   translation,
+	}, PromptReviewIssues); ok {
+		return prompt
+	}
+
+	prompt := fmt.Sprintf(`You are reviewing a translation from %s to %s.
+
+Original text:
+%s
+
+Translation:
+%s
+
+List the concrete problems in the translation. For each problem give:
+- "type": one of mistranslation, omission, addition, terminology, grammar, fluency, style, formatting
+- "span": the problematic text copied exactly from the translation, as short as possible (a word or phrase)
+- "severity": "critical" (meaning lost or reversed, broken formatting), "major" (an error a careful reader would notice) or "minor" (a stylistic improvement)
+- "suggestion": the corrected text for the span`,
+		pb.SourceLang, pb.TargetLang,
+		sourceText,
+		translation)
+
+	if pb.Country != "" {
+		prompt += fmt.Sprintf("\nThe translation is intended for readers in %s.", pb.Country)
+	}
+
+	prompt = pb.appendDocumentContext(prompt)
+
+	if preservePrompt := GetPreservePrompt(pb.PreserveConfig); preservePrompt != "" {
+		prompt += "\n\n" + preservePrompt
+	}
+
+	prompt += `
+
+Only report real problems; do not rewrite correct text. Reply with JSON only, in this shape:
+{"issues": [{"type": "mistranslation", "span": "...", "severity": "major", "suggestion": "..."}]}
+If there are no problems, reply with {"issues": []}.`
+
+	return prompt
+}
+
+// BuildEditPrompt 构建迭代审校的编辑提示词，要求只给出问题片段的替换文本
+func (pb *PromptBuilder) BuildEditPrompt(sourceText, translation string, issues []ReviewIssue) string {
+	issuesJSON, _ := json.MarshalIndent(issues, "", "  ")
+	if prompt, ok := pb.renderTemplate(map[string]string{
+		"text":          translation,
+		"original_text": sourceText,
+		"translation":   translation,
+		"issues":        string(issuesJSON),
+	}, PromptReviewEdit); ok {
+		return prompt
+	}
+
+	prompt := fmt.Sprintf(`You are correcting specific problems in a translation from %s to %s.
+
+Original text:
+%s
+
+Translation:
+%s
+
+A reviewer flagged these spans of the translation:
+%s
+
+For each flagged span, write the text that should replace it. Fix only the flagged spans; everything else in the translation stays unchanged. Keep any formatting, markup and preserve markers inside a span.`,
+		pb.SourceLang, pb.TargetLang,
+		sourceText,
+		translation,
+		issuesJSON)
+
+	prompt = pb.appendDocumentContext(prompt)
+
+	prompt += `
+
+Reply with JSON only, in this shape, copying each span exactly as given:
+{"edits": [{"span": "...", "replacement": "..."}]}`
+
+	return prompt
+}
+
 // BuildDirectTranslationPrompt 构建直接翻译提示词（用于快速模式）
 func (pb *PromptBuilder) BuildDirectTranslationPrompt(text string) string {
+	if prompt, ok := pb.renderTemplate(map[string]string{"text": text}, PromptDirect, PromptInitial); ok {
+		return prompt
+	}
+
 	prompt := fmt.Sprintf(`Translate the following text from %s to %s.
 
 Rules:
@@ -219,6 +370,9 @@ Rules:
 		prompt += fmt.Sprintf("\n4. Use language appropriate for %s", pb.Country)
 	}
 
+	// 添加风格指南和文档简介
+	prompt = pb.appendDocumentContext(prompt)
+
 	// 添加保护块说明
 	prompt = AppendPreservePrompt(prompt, pb.PreserveConfig)
 
@@ -250,6 +404,67 @@ TRANSLATE ONLY THE CONTENT BETWEEN MARKERS - NEVER THE MARKERS THEMSELVES!`
 	return prompt
 }
 
+// BuildDocumentBriefPrompt 构建文档简介提示词，要求模型概括整篇文档以便翻译各个片段
+func (pb *PromptBuilder) BuildDocumentBriefPrompt(text string) string {
+	return fmt.Sprintf(`The following %s document will be translated into %s piece by piece. Write a short brief of the whole document that will be given to the translator of every piece.
+
+Cover, in at most 150 words:
+- Topic: what the document is about
+- Domain: the field and the kind of text (e.g. API reference, marketing page, legal notice)
+- Tone: the register and style to keep
+- Audience: who the document is written for
+- Key entities: names, products and terms that recur, with how they should be handled
+
+Write the brief in English as plain text with one line per item. Do not translate the document and do not add any other text.
+
+===== DOCUMENT BEGIN =====
+%s
+===== DOCUMENT END =====`, pb.SourceLang, pb.TargetLang, text)
+}
+
+// FormatDocumentBrief 把文档简介格式化为提示词中的说明
+func FormatDocumentBrief(brief string) string {
+	return "Document Context (a brief of the whole document this text comes from; use it to choose domain terminology, tone and register):\n" + strings.TrimSpace(brief)
+}
+
+// appendDocumentContext 在提示词后添加风格指南和文档简介
+func (pb *PromptBuilder) appendDocumentContext(prompt string) string {
+	if guide := RenderStyleGuide(pb.StyleProfile); guide != "" {
+		prompt += "\n\n" + guide
+	}
+	if pb.DocumentBrief != "" {
+		prompt += "\n\n" + FormatDocumentBrief(pb.DocumentBrief)
+	}
+	return prompt
+}
+
+// renderTemplate 用匹配的模板（按名称依次查找）渲染提示词，没有匹配的模板或渲染失败时返回 false
+func (pb *PromptBuilder) renderTemplate(data map[string]string, names ...string) (string, bool) {
+	tmpl, _ := pb.Templates.Lookup(pb.StepSet, pb.Format, names...)
+	if tmpl == nil {
+		return "", false
+	}
+
+	data["source_language"] = pb.SourceLang
+	data["target_language"] = pb.TargetLang
+	data["country"] = pb.Country
+	data["format"] = pb.Format
+	data["step_set"] = pb.StepSet
+	data["document_brief"] = pb.DocumentBrief
+	data["style_guide"] = RenderStyleGuide(pb.StyleProfile)
+	data["additional_notes"] = strings.Join(pb.ExtraInstructions, "\n")
+	if data["original_text"] == "" {
+		data["original_text"] = data["text"]
+	}
+	addPromptAliases(data)
+
+	prompt, err := pb.Templates.Render(tmpl, data)
+	if err != nil {
+		return "", false
+	}
+	return prompt, true
+}
+
 // ExtractTranslationFromResponse 从 LLM 响应中提取翻译结果
 // 有些模型可能会在翻译前后添加额外的说明，这个函数负责提取纯翻译内容
 func ExtractTranslationFromResponse(response string) string {
@@ -3,6 +3,7 @@ package translation
 import (
 	"testing"
 
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -97,6 +98,53 @@ func TestPromptBuilder(t *testing.T) {
 		assert.Contains(t, prompt, "2. Avoid slang")
 	})
 
+	t.Run("With Document Brief", func(t *testing.T) {
+		pb := NewPromptBuilder("English", "Chinese", "").WithDocumentBrief("  Topic: Kubernetes scheduling\n")
+
+		for _, prompt := range []string{
+			pb.BuildInitialTranslationPrompt("test"),
+			pb.BuildReflectionPrompt("test", "测试"),
+			pb.BuildImprovementPrompt("test", "测试", "ok"),
+			pb.BuildDirectTranslationPrompt("test"),
+		} {
+			assert.Contains(t, prompt, "Document Context")
+			assert.Contains(t, prompt, "\nTopic: Kubernetes scheduling")
+		}
+
+		// 没有简介时不添加
+		prompt := NewPromptBuilder("English", "Chinese", "").BuildInitialTranslationPrompt("test")
+		assert.NotContains(t, prompt, "Document Context")
+	})
+
+	t.Run("With Style Profile", func(t *testing.T) {
+		pb := NewPromptBuilder("English", "French", "").WithStyleProfile(&config.StyleProfile{
+			Formality:        "formal, use vous",
+			QuoteMarks:       "«»",
+			NestedQuoteMarks: "“”",
+			DecimalSeparator: ",",
+			Units:            config.UnitsMetric,
+			KeepTerms:        []string{"Acme Cloud"},
+			Rules:            []string{"Put a narrow space before : ; ! ?"},
+		})
+
+		for _, prompt := range []string{
+			pb.BuildInitialTranslationPrompt("test"),
+			pb.BuildReflectionPrompt("test", "test"),
+			pb.BuildImprovementPrompt("test", "test", "ok"),
+			pb.BuildDirectTranslationPrompt("test"),
+		} {
+			assert.Contains(t, prompt, "Style Guide")
+			assert.Contains(t, prompt, "- Register and form of address: formal, use vous")
+			assert.Contains(t, prompt, "- Quotation marks: use «…», and “…” for quotes inside quotes")
+			assert.Contains(t, prompt, `- Numbers: use "," as the decimal separator`)
+			assert.Contains(t, prompt, "convert imperial measurements")
+			assert.Contains(t, prompt, "do not translate them: Acme Cloud")
+			assert.Contains(t, prompt, "- Put a narrow space before : ; ! ?")
+		}
+
+		assert.Empty(t, RenderStyleGuide(&config.StyleProfile{Name: "empty"}))
+	})
+
 	t.Run("Without Country", func(t *testing.T) {
 		pb := NewPromptBuilder("English", "French", "")
 		prompt := pb.BuildInitialTranslationPrompt("test")
@@ -2,6 +2,8 @@ package translation
 
 import (
 	"context"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
 )
 
 // llmClientAdapter 将 LLMClient 适配为 TranslationProvider
@@ -137,3 +139,48 @@ func WithProvider(provider TranslationProvider) Option {
 		// }
 	}
 }
+
+// providerAdapter 把 TranslationProvider 适配为 ThreeStepTranslator 使用的 Provider 接口
+type providerAdapter struct {
+	provider TranslationProvider
+}
+
+// NewProviderAdapter 把提供商适配为 Provider 接口。ThreeStepTranslator 自己构建完整的提示词，
+// 因此请求作为原始提示词发送
+func NewProviderAdapter(provider TranslationProvider) Provider {
+	return &providerAdapter{provider: provider}
+}
+
+// Name 返回提供商名称
+func (a *providerAdapter) Name() string {
+	return a.provider.GetName()
+}
+
+// Translate 把请求作为原始提示词发送给提供商
+func (a *providerAdapter) Translate(ctx context.Context, req *Request) (*Response, error) {
+	metadata := map[string]interface{}{providers.MetadataRawPrompt: true}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+
+	resp, err := a.provider.Translate(ctx, &ProviderRequest{
+		Text:           req.Text,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		Metadata:       metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Text:           resp.Text,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		Usage: Usage{
+			InputTokens:  resp.TokensIn,
+			OutputTokens: resp.TokensOut,
+			Cost:         resp.Cost,
+		},
+	}, nil
+}
@@ -2,6 +2,8 @@ package translation
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/nerdneilsfield/go-translator-agent/internal/config"
@@ -10,6 +12,7 @@ import (
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/deeplx"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/google"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/libretranslate"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/llamacpp"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/ollama"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/openai"
 	"github.com/nerdneilsfield/go-translator-agent/pkg/providers/raw"
@@ -142,10 +145,14 @@ func (pm *ProviderManager) createProvider(providerType string, modelConfig confi
 		return pm.createDeepLXProvider(modelConfig)
 	case "google":
 		return pm.createGoogleProvider(modelConfig)
+	case "google-v3":
+		return pm.createGoogleV3Provider(modelConfig)
 	case "libretranslate":
 		return pm.createLibreTranslateProvider(modelConfig)
 	case "ollama":
 		return pm.createOllamaProvider(modelConfig)
+	case "llamacpp", "llama.cpp":
+		return pm.createLlamaCppProvider(modelConfig)
 	case "raw", "none":
 		return pm.createRawProvider(modelConfig)
 	default:
@@ -189,7 +196,9 @@ func (pm *ProviderManager) createDeepLProvider(modelConfig config.ModelConfig) (
 			RetryDelay:  time.Second,
 			Headers:     make(map[string]string),
 		},
-		UseFreeAPI: false,
+		UseFreeAPI:         false,
+		GlossaryPath:       pm.config.GlossaryPath,
+		MaxTextsPerRequest: 50,
 	}
 
 	// 如果没有设置 BaseURL，使用默认值
@@ -245,6 +254,49 @@ func (pm *ProviderManager) createGoogleProvider(modelConfig config.ModelConfig)
 	return provider, nil
 }
 
+// createGoogleV3Provider 创建 Google Cloud Translation v3 提供商。Key 为服务账号 JSON 密钥文件路径
+// 或密钥内容，其他设置来自 Options：project_id、location、glossary、glossary_ignore_case、
+// adaptive_datasets（如 "en-de=legal,en-fr=legal-fr"）和 mime_type
+func (pm *ProviderManager) createGoogleV3Provider(modelConfig config.ModelConfig) (TranslationProvider, error) {
+	config := google.DefaultConfigV3()
+	config.BaseConfig = providers.BaseConfig{
+		APIEndpoint: modelConfig.BaseURL,
+		Timeout:     30 * time.Second,
+		MaxRetries:  3,
+		RetryDelay:  time.Second,
+		Headers:     make(map[string]string),
+	}
+	if strings.HasPrefix(strings.TrimSpace(modelConfig.Key), "{") {
+		config.CredentialsJSON = modelConfig.Key
+	} else {
+		config.CredentialsFile = modelConfig.Key
+	}
+	config.Model = modelConfig.ModelID
+
+	options := modelConfig.Options
+	config.ProjectID = options["project_id"]
+	if location := options["location"]; location != "" {
+		config.Location = location
+	}
+	config.Glossary = options["glossary"]
+	config.GlossaryIgnoreCase = options["glossary_ignore_case"] == "true"
+	if mimeType := options["mime_type"]; mimeType != "" {
+		config.MimeType = mimeType
+	}
+	if datasets := options["adaptive_datasets"]; datasets != "" {
+		config.AdaptiveDatasets = make(map[string]string)
+		for _, pair := range strings.Split(datasets, ",") {
+			languages, dataset, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid adaptive dataset %q, expected <lang>=<dataset>", pair)
+			}
+			config.AdaptiveDatasets[strings.TrimSpace(languages)] = strings.TrimSpace(dataset)
+		}
+	}
+
+	return google.NewV3(config)
+}
+
 // createLibreTranslateProvider 创建 LibreTranslate 提供商
 func (pm *ProviderManager) createLibreTranslateProvider(modelConfig config.ModelConfig) (TranslationProvider, error) {
 	config := libretranslate.Config{
@@ -293,6 +345,45 @@ func (pm *ProviderManager) createOllamaProvider(modelConfig config.ModelConfig)
 	return provider, nil
 }
 
+// createLlamaCppProvider 创建 llama.cpp server 提供商。Options 中 endpoint 选择 completion 或 chat 接口，
+// grammar=false 关闭批量翻译的语法约束，n_ctx 覆盖从服务端读取的上下文长度
+func (pm *ProviderManager) createLlamaCppProvider(modelConfig config.ModelConfig) (TranslationProvider, error) {
+	config := llamacpp.DefaultConfig()
+	config.BaseConfig = providers.BaseConfig{
+		APIKey:      modelConfig.Key, // 服务端用 --api-key 启动时需要
+		APIEndpoint: modelConfig.BaseURL,
+		Timeout:     5 * time.Minute, // 本地模型在 CPU 上可能很慢
+		MaxRetries:  3,
+		RetryDelay:  time.Second,
+		Headers:     make(map[string]string),
+	}
+	if modelConfig.ModelID != "" {
+		config.Model = modelConfig.ModelID
+	}
+	config.Temperature = float32(modelConfig.Temperature)
+	config.MaxTokens = modelConfig.MaxOutputTokens
+
+	options := modelConfig.Options
+	if endpoint := options["endpoint"]; endpoint != "" {
+		if endpoint != llamacpp.EndpointCompletion && endpoint != llamacpp.EndpointChat {
+			return nil, fmt.Errorf("invalid llama.cpp endpoint %q, expected completion or chat", endpoint)
+		}
+		config.Endpoint = endpoint
+	}
+	if options["grammar"] == "false" {
+		config.UseGrammar = false
+	}
+	if nCtx := options["n_ctx"]; nCtx != "" {
+		size, err := strconv.Atoi(nCtx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid llama.cpp n_ctx %q: %w", nCtx, err)
+		}
+		config.ContextSize = size
+	}
+
+	return llamacpp.New(config), nil
+}
+
 // createRawProvider 创建 Raw 提供商（raw 和 none 都使用相同的实现）
 func (pm *ProviderManager) createRawProvider(modelConfig config.ModelConfig) (TranslationProvider, error) {
 	config := raw.DefaultConfig()
@@ -349,6 +440,15 @@ func (pm *ProviderManager) getProviderCapabilities(providerType string) Provider
 			RequiresAPIKey:      true,
 			DefaultModel:        "google-translate",
 		}
+	case "google-v3":
+		return ProviderCapabilities{
+			SupportsPrompts:     false,
+			SupportsSystemRole:  false,
+			SupportsTemperature: false,
+			SupportsMultiStep:   false,
+			RequiresAPIKey:      true,
+			DefaultModel:        "nmt",
+		}
 	case "libretranslate":
 		return ProviderCapabilities{
 			SupportsPrompts:     false,
@@ -367,6 +467,15 @@ func (pm *ProviderManager) getProviderCapabilities(providerType string) Provider
 			RequiresAPIKey:      false, // Ollama本地部署通常不需要API密钥
 			DefaultModel:        "llama2",
 		}
+	case "llamacpp", "llama.cpp":
+		return ProviderCapabilities{
+			SupportsPrompts:     true,
+			SupportsSystemRole:  true, // chat 接口由服务端套用模型的对话模板
+			SupportsTemperature: true,
+			SupportsMultiStep:   true,
+			RequiresAPIKey:      false,
+			DefaultModel:        "local",
+		}
 	case "raw", "none":
 		return ProviderCapabilities{
 			SupportsPrompts:     false,
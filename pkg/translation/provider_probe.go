@@ -0,0 +1,239 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"go.uber.org/zap"
+)
+
+// ProviderProbe 一个提供商/模型组合的探测结果
+type ProviderProbe struct {
+	Provider string   // 提供商类型
+	Model    string   // 模型配置名称
+	ModelID  string   // 请求时使用的模型 ID
+	Steps    []string // 使用该组合的步骤，格式为 "步骤集/步骤"
+	Result   *providers.ProbeResult
+	Err      error // 创建提供商失败的原因
+}
+
+// ModelAvailable 服务端是否提供配置的模型。提供商不支持列出模型时返回 true
+func (p *ProviderProbe) ModelAvailable() bool {
+	if p.Result == nil || p.Result.Models == nil || p.ModelID == "" {
+		return true
+	}
+	for _, model := range p.Result.Models {
+		// Ollama 的模型名省略标签时默认为 latest
+		if model == p.ModelID || model == p.ModelID+":latest" {
+			return true
+		}
+	}
+	return false
+}
+
+// LanguageIssue 步骤集配置的语言不在步骤的提供商列出的语言中
+type LanguageIssue struct {
+	StepSet  string
+	Step     string
+	Provider string
+	Language string
+	Target   bool // true 表示目标语言，false 表示源语言
+	Unknown  bool // 语言名称无法映射为语言代码，不能确定提供商是否支持
+}
+
+func (i LanguageIssue) String() string {
+	role := "source"
+	if i.Target {
+		role = "target"
+	}
+	if i.Unknown {
+		return fmt.Sprintf("step set '%s' step '%s': cannot map %s language '%s' to a language code, unable to check provider '%s'",
+			i.StepSet, i.Step, role, i.Language, i.Provider)
+	}
+	return fmt.Sprintf("step set '%s' step '%s': provider '%s' does not support %s language '%s'",
+		i.StepSet, i.Step, i.Provider, role, i.Language)
+}
+
+// ProbeReport 提供商探测报告
+type ProbeReport struct {
+	Providers      []*ProviderProbe
+	LanguageIssues []LanguageIssue
+}
+
+// probeStep 步骤集中需要探测的一个步骤
+type probeStep struct {
+	stepSet string
+	step    config.StepConfigV2
+}
+
+// Probe 探测步骤集（为空时为全部步骤集）用到的每个提供商：健康检查、模型和语言列表，
+// 并检查源语言和目标语言是否被支持
+func (pm *ProviderManager) Probe(ctx context.Context, stepSets ...string) *ProbeReport {
+	report := &ProbeReport{}
+	probes := make(map[string]*ProviderProbe)
+
+	for _, ps := range pm.probeSteps(stepSets) {
+		key := ps.step.Provider + "/" + ps.step.ModelName
+		probe, exists := probes[key]
+		if !exists {
+			probe = pm.probeProvider(ctx, ps.step)
+			probes[key] = probe
+			report.Providers = append(report.Providers, probe)
+		}
+		probe.Steps = append(probe.Steps, ps.stepSet+"/"+ps.step.Name)
+
+		if probe.Result != nil && probe.Result.LanguagesError == nil {
+			report.LanguageIssues = append(report.LanguageIssues,
+				pm.checkLanguages(ps, probe.Result.SourceLanguages, probe.Result.TargetLanguages)...)
+		}
+	}
+	return report
+}
+
+// ValidateLanguages 在翻译开始前检查步骤集的源语言和目标语言是否被各步骤的提供商支持。
+// 只查询能列出语言的提供商，只有确定不支持时才返回错误；获取语言列表失败或语言名称无法映射为代码时只记录警告
+func (pm *ProviderManager) ValidateLanguages(ctx context.Context, stepSet string) error {
+	type languages struct{ source, target []providers.Language }
+	listed := make(map[string]*languages)
+
+	var issues []string
+	for _, ps := range pm.probeSteps([]string{stepSet}) {
+		key := ps.step.Provider + "/" + ps.step.ModelName
+		langs, exists := listed[key]
+		if !exists {
+			listed[key] = nil
+			provider, _, err := pm.createStepProvider(ps.step)
+			if err != nil {
+				continue
+			}
+			lister, ok := provider.(providers.LanguageLister)
+			if !ok {
+				continue
+			}
+			source, target, err := lister.ListLanguages(ctx)
+			if err != nil {
+				pm.logger.Warn("获取提供商支持的语言失败，跳过语言检查",
+					zap.String("provider", ps.step.Provider),
+					zap.Error(err))
+				continue
+			}
+			langs = &languages{source: source, target: target}
+			listed[key] = langs
+		}
+		if langs == nil {
+			continue
+		}
+		for _, issue := range pm.checkLanguages(ps, langs.source, langs.target) {
+			if issue.Unknown {
+				pm.logger.Warn("无法把语言名称映射为语言代码，跳过该语言的检查",
+					zap.String("provider", issue.Provider),
+					zap.String("language", issue.Language))
+				continue
+			}
+			issues = append(issues, issue.String())
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("unsupported languages: %s", strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+// probeSteps 返回步骤集（为空时为全部步骤集，按名称排序）中使用提供商的步骤
+func (pm *ProviderManager) probeSteps(stepSets []string) []probeStep {
+	if len(stepSets) == 0 {
+		for name := range pm.config.StepSets {
+			stepSets = append(stepSets, name)
+		}
+		sort.Strings(stepSets)
+	}
+
+	var steps []probeStep
+	for _, name := range stepSets {
+		stepSet, exists := pm.config.StepSets[name]
+		if !exists {
+			continue
+		}
+		for _, step := range stepSet.Steps {
+			if step.ModelName == "raw" || step.ModelName == "none" {
+				continue
+			}
+			steps = append(steps, probeStep{stepSet: name, step: step})
+		}
+	}
+	return steps
+}
+
+// createStepProvider 为步骤创建提供商，同时返回步骤使用的模型配置
+func (pm *ProviderManager) createStepProvider(step config.StepConfigV2) (TranslationProvider, config.ModelConfig, error) {
+	modelConfig, exists := pm.config.ModelConfigs[step.ModelName]
+	if !exists {
+		return nil, modelConfig, fmt.Errorf("model '%s' not found in configuration", step.ModelName)
+	}
+	provider, err := pm.createProvider(step.Provider, modelConfig)
+	return provider, modelConfig, err
+}
+
+// CreateProvider 为配置中的模型创建提供商，providerType 为空时使用模型的 api_type
+func (pm *ProviderManager) CreateProvider(providerType, modelName string) (TranslationProvider, error) {
+	if providerType == "" {
+		providerType = pm.config.ModelConfigs[modelName].APIType
+	}
+	provider, _, err := pm.createStepProvider(config.StepConfigV2{Provider: providerType, ModelName: modelName})
+	return provider, err
+}
+
+// probeProvider 创建并探测步骤的提供商
+func (pm *ProviderManager) probeProvider(ctx context.Context, step config.StepConfigV2) *ProviderProbe {
+	probe := &ProviderProbe{Provider: step.Provider, Model: step.ModelName}
+
+	provider, modelConfig, err := pm.createStepProvider(step)
+	probe.ModelID = modelConfig.ModelID
+	if err != nil {
+		probe.Err = err
+		return probe
+	}
+
+	probe.Result = providers.Probe(ctx, provider)
+	// 上下文长度决定了每组节点的最大长度
+	if probe.Result.ContextWindow > 0 {
+		probe.Result.Capabilities.MaxTextLength = ChunkSizeForContextWindow(probe.Result.ContextWindow)
+	}
+	return probe
+}
+
+// checkLanguages 检查步骤集的源语言和目标语言，语言列表为空表示未知，不做检查
+func (pm *ProviderManager) checkLanguages(ps probeStep, source, target []providers.Language) []LanguageIssue {
+	var issues []LanguageIssue
+	if len(source) > 0 && pm.config.SourceLanguage != "" && !providers.SupportsLanguage(source, pm.config.SourceLanguage) {
+		issues = append(issues, LanguageIssue{
+			StepSet:  ps.stepSet,
+			Step:     ps.step.Name,
+			Provider: ps.step.Provider,
+			Language: pm.config.SourceLanguage,
+			Unknown:  !knownLanguage(pm.config.SourceLanguage),
+		})
+	}
+	if len(target) > 0 && pm.config.TargetLanguage != "" && !providers.SupportsLanguage(target, pm.config.TargetLanguage) {
+		issues = append(issues, LanguageIssue{
+			StepSet:  ps.stepSet,
+			Step:     ps.step.Name,
+			Provider: ps.step.Provider,
+			Language: pm.config.TargetLanguage,
+			Target:   true,
+			Unknown:  !knownLanguage(pm.config.TargetLanguage),
+		})
+	}
+	return issues
+}
+
+// knownLanguage 语言名称或代码能否映射为语言代码
+func knownLanguage(lang string) bool {
+	_, ok := providers.LanguageCode(lang)
+	return ok
+}
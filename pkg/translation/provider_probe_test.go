@@ -0,0 +1,120 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newProbeServer 模拟 DeepL 和 Ollama 的探测接口
+func newProbeServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/usage":
+			_ = json.NewEncoder(w).Encode(map[string]int{"character_count": 10, "character_limit": 500000})
+		case "/languages":
+			languages := []map[string]string{{"language": "EN", "name": "English"}}
+			if r.URL.Query().Get("type") == "target" {
+				languages = append(languages, map[string]string{"language": "DE", "name": "German"})
+			}
+			_ = json.NewEncoder(w).Encode(languages)
+		case "/api/generate":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"model": "llama2", "response": "Hi", "done": true})
+		case "/api/tags":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"models": []map[string]string{{"name": "llama2:latest"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newProbeConfig(baseURL, targetLanguage string) *Config {
+	return &Config{
+		SourceLanguage: "English",
+		TargetLanguage: targetLanguage,
+		ModelConfigs: map[string]config.ModelConfig{
+			"deepl":  {Name: "deepl", ModelID: "deepl", APIType: "deepl", BaseURL: baseURL, Key: "test-key"},
+			"llama2": {Name: "llama2", ModelID: "llama2", APIType: "ollama", BaseURL: baseURL},
+			"qwen":   {Name: "qwen", ModelID: "qwen2.5:7b", APIType: "ollama", BaseURL: baseURL},
+		},
+		ActiveStepSet: "deepl",
+		StepSets: map[string]config.StepSetConfigV2{
+			"deepl": {
+				ID: "deepl",
+				Steps: []config.StepConfigV2{
+					{Name: "initial", Provider: "deepl", ModelName: "deepl"},
+					{Name: "reflection", Provider: "none", ModelName: "none"},
+				},
+			},
+			"ollama": {
+				ID: "ollama",
+				Steps: []config.StepConfigV2{
+					{Name: "initial", Provider: "ollama", ModelName: "llama2"},
+					{Name: "improvement", Provider: "ollama", ModelName: "qwen"},
+				},
+			},
+		},
+	}
+}
+
+func TestProviderManagerProbe(t *testing.T) {
+	server := newProbeServer(t)
+	pm := NewProviderManager(newProbeConfig(server.URL, "Chinese"), zap.NewNop())
+
+	report := pm.Probe(context.Background())
+	require.Len(t, report.Providers, 3)
+
+	deepl := report.Providers[0]
+	assert.Equal(t, "deepl", deepl.Provider)
+	assert.Equal(t, []string{"deepl/initial"}, deepl.Steps)
+	require.NoError(t, deepl.Err)
+	assert.True(t, deepl.Result.Healthy())
+	assert.Len(t, deepl.Result.Capabilities.SupportedLanguages, 2, "languages replace the built-in list")
+
+	llama := report.Providers[1]
+	assert.Equal(t, []string{"llama2:latest"}, llama.Result.Models)
+	assert.True(t, llama.ModelAvailable(), "llama2 matches llama2:latest")
+	assert.False(t, report.Providers[2].ModelAvailable(), "qwen2.5:7b is not pulled")
+
+	require.Len(t, report.LanguageIssues, 1)
+	issue := report.LanguageIssues[0]
+	assert.Equal(t, "deepl", issue.StepSet)
+	assert.Equal(t, "Chinese", issue.Language)
+	assert.True(t, issue.Target)
+
+	// 只探测指定的步骤集
+	report = pm.Probe(context.Background(), "ollama")
+	assert.Len(t, report.Providers, 2)
+	assert.Empty(t, report.LanguageIssues)
+}
+
+func TestProviderManagerValidateLanguages(t *testing.T) {
+	server := newProbeServer(t)
+
+	err := NewProviderManager(newProbeConfig(server.URL, "Chinese"), zap.NewNop()).ValidateLanguages(context.Background(), "deepl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support target language 'Chinese'")
+
+	assert.NoError(t, NewProviderManager(newProbeConfig(server.URL, "German"), zap.NewNop()).ValidateLanguages(context.Background(), "deepl"))
+	// 不能列出语言的提供商不做检查
+	assert.NoError(t, NewProviderManager(newProbeConfig(server.URL, "Chinese"), zap.NewNop()).ValidateLanguages(context.Background(), "ollama"))
+
+	// 无法映射为语言代码的语言名称只记录警告，能映射但不在列表中的语言仍然报错
+	assert.NoError(t, NewProviderManager(newProbeConfig(server.URL, "Elvish"), zap.NewNop()).ValidateLanguages(context.Background(), "deepl"))
+	err = NewProviderManager(newProbeConfig(server.URL, "Norwegian"), zap.NewNop()).ValidateLanguages(context.Background(), "deepl")
+	assert.ErrorContains(t, err, "does not support target language 'Norwegian'")
+
+	report := NewProviderManager(newProbeConfig(server.URL, "Elvish"), zap.NewNop()).Probe(context.Background(), "deepl")
+	require.Len(t, report.LanguageIssues, 1)
+	assert.True(t, report.LanguageIssues[0].Unknown)
+	assert.Contains(t, report.LanguageIssues[0].String(), "cannot map target language 'Elvish'")
+}
@@ -0,0 +1,366 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 翻译模式
+const (
+	ModeThreeStep = "three_step" // 固定执行一次初始翻译、反思和改进
+	ModeReview    = "review"     // 迭代审校：审校列出问题片段，编辑只修改这些片段
+)
+
+// 审校问题的严重程度
+const (
+	SeverityMinor    = "minor"
+	SeverityMajor    = "major"
+	SeverityCritical = "critical"
+)
+
+// DefaultReviewIterations 迭代审校的默认最大轮数
+const DefaultReviewIterations = 3
+
+// nodeMarkerPrefix 批量翻译中节点标记的前缀，编辑不能增删节点标记
+const nodeMarkerPrefix = "@@NODE_"
+
+// ReviewConfig 迭代审校配置
+type ReviewConfig struct {
+	MaxIterations int     `json:"max_iterations"` // 最大审校轮数，默认 3
+	MaxCost       float64 `json:"max_cost"`       // 累计成本上限，0 表示不限制
+	MaxTokens     int     `json:"max_tokens"`     // 累计 token 上限（输入加输出），0 表示不限制
+}
+
+// ReviewIssue 审校发现的问题
+type ReviewIssue struct {
+	Type       string `json:"type"`
+	Span       string `json:"span"`     // 译文中有问题的片段，与译文完全一致
+	Severity   string `json:"severity"` // minor、major 或 critical
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// IsMajor 判断问题是否为 major 或 critical
+func (i ReviewIssue) IsMajor() bool {
+	return i.Severity == SeverityMajor || i.Severity == SeverityCritical
+}
+
+// ReviewEdit 编辑对一个问题片段的替换
+type ReviewEdit struct {
+	Span        string `json:"span"`
+	Replacement string `json:"replacement"`
+}
+
+// reviewBudget 迭代审校的累计用量
+type reviewBudget struct {
+	config ReviewConfig
+	tokens int
+	cost   float64
+}
+
+// add 累加步骤的用量
+func (b *reviewBudget) add(result StepResult) {
+	b.tokens += result.TokensIn + result.TokensOut
+	b.cost += result.Cost
+}
+
+// exhausted 判断是否已达到成本或 token 上限
+func (b *reviewBudget) exhausted() bool {
+	return (b.config.MaxCost > 0 && b.cost >= b.config.MaxCost) ||
+		(b.config.MaxTokens > 0 && b.tokens >= b.config.MaxTokens)
+}
+
+// reviewSteps 迭代审校中一轮的审校和编辑
+type reviewSteps struct {
+	// review 审校译文，返回译文中确实存在的问题片段和步骤结果
+	review func(ctx context.Context, translation string, iteration int) ([]ReviewIssue, StepResult)
+	// edit 只替换问题片段，返回修改后的译文和步骤结果
+	edit func(ctx context.Context, translation string, issues []ReviewIssue, iteration int) (string, StepResult)
+}
+
+// runReviewLoop 从初始译文开始迭代审校：审校以 JSON 列出问题片段，编辑只替换这些片段。
+// 审校失败或没有发现问题、只发现 minor 问题（修正后结束）、编辑失败或没有改变译文，
+// 或达到轮数、成本和 token 上限时结束。每个步骤都交给 WithStepResultRecorder 设置的记录函数，
+// 便于审计译文为何改变
+func runReviewLoop(ctx context.Context, config ReviewConfig, initial StepResult, steps reviewSteps) string {
+	record := stepResultRecorderFromContext(ctx)
+	maxIterations := config.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultReviewIterations
+	}
+	budget := &reviewBudget{config: config}
+	budget.add(initial)
+	record(initial)
+
+	current := initial.Output
+	for iteration := 1; iteration <= maxIterations && !budget.exhausted(); iteration++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// 审校失败或没有发现问题时保留当前译文
+		issues, review := steps.review(ctx, current, iteration)
+		budget.add(review)
+		record(review)
+		if review.Error != "" || len(issues) == 0 || budget.exhausted() {
+			break
+		}
+
+		edited, edit := steps.edit(ctx, current, issues, iteration)
+		budget.add(edit)
+		record(edit)
+		if edit.Error != "" || edited == current {
+			break
+		}
+		current = edited
+
+		if !hasMajorIssue(issues) {
+			break
+		}
+	}
+	return current
+}
+
+// translateReview 迭代审校流程，审校和编辑分别使用反思和改进步骤的配置
+func (t *ThreeStepTranslator) translateReview(ctx context.Context, text string) (string, error) {
+	startTime := time.Now()
+	current, response, err := t.initialTranslationWithResponse(ctx, text)
+	if err != nil {
+		return "", fmt.Errorf("initial translation failed: %w", err)
+	}
+	initial := newStepResult(t.stepSet.Initial, "initial", current, response, startTime)
+
+	return runReviewLoop(ctx, t.stepSet.Review, initial, reviewSteps{
+		review: func(ctx context.Context, translation string, iteration int) ([]ReviewIssue, StepResult) {
+			return t.reviewIssues(ctx, text, translation, iteration)
+		},
+		edit: func(ctx context.Context, translation string, issues []ReviewIssue, iteration int) (string, StepResult) {
+			return t.editSpans(ctx, text, translation, issues, iteration)
+		},
+	}), nil
+}
+
+// reviewIssues 执行审校步骤，返回译文中确实存在的问题片段
+func (t *ThreeStepTranslator) reviewIssues(ctx context.Context, sourceText, translation string, iteration int) ([]ReviewIssue, StepResult) {
+	cfg := t.stepSet.Reflection
+	prompt := t.promptBuilderFor(ctx).BuildReviewPrompt(sourceText, translation)
+	output, result := t.callStep(ctx, cfg, "review", sourceText, translation, prompt)
+	return finishReview(output, translation, result, iteration)
+}
+
+// editSpans 执行编辑步骤，只替换审校标出的片段。没有配置编辑步骤时直接采用审校的修改建议
+func (t *ThreeStepTranslator) editSpans(ctx context.Context, sourceText, translation string, issues []ReviewIssue, iteration int) (string, StepResult) {
+	cfg := t.stepSet.Improvement
+	if cfg.Provider == "" {
+		return finishEdit(translation, issues, suggestedEdits(issues), StepResult{Name: "edit"}, iteration)
+	}
+
+	issuesJSON, _ := json.Marshal(issues)
+	prompt := t.promptBuilderFor(ctx).BuildEditPrompt(sourceText, translation, issues)
+	output, result := t.callStep(ctx, cfg, "edit", sourceText, translation+"|issues:"+string(issuesJSON), prompt)
+	return finishEditResponse(output, translation, issues, result, iteration)
+}
+
+// finishReview 解析审校回复，记录到步骤结果中。审校失败或回复无效时没有问题
+func finishReview(output, translation string, result StepResult, iteration int) ([]ReviewIssue, StepResult) {
+	result.Iteration = iteration
+	if result.Error != "" {
+		return nil, result
+	}
+
+	issues, err := parseReviewIssues(output, translation)
+	if err != nil {
+		result.Error = err.Error()
+		return nil, result
+	}
+	result.Issues = issues
+	return issues, result
+}
+
+// finishEditResponse 解析编辑回复并替换问题片段，编辑失败或回复无效时保留原译文
+func finishEditResponse(output, translation string, issues []ReviewIssue, result StepResult, iteration int) (string, StepResult) {
+	var edits []ReviewEdit
+	if result.Error == "" {
+		var err error
+		if edits, err = parseReviewEdits(output); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return finishEdit(translation, issues, edits, result, iteration)
+}
+
+// finishEdit 替换问题片段，步骤结果的输出为修改后的译文，问题为已修正的问题。步骤失败时保留原译文
+func finishEdit(translation string, issues []ReviewIssue, edits []ReviewEdit, result StepResult, iteration int) (string, StepResult) {
+	result.Iteration = iteration
+	if result.Error != "" {
+		result.Output = translation
+		return translation, result
+	}
+
+	edited, applied := applyReviewEdits(translation, issues, edits)
+	result.Output = edited
+	result.Issues = applied
+	return edited, result
+}
+
+// suggestedEdits 没有编辑步骤时直接采用审校的修改建议
+func suggestedEdits(issues []ReviewIssue) []ReviewEdit {
+	var edits []ReviewEdit
+	for _, issue := range issues {
+		if issue.Suggestion != "" {
+			edits = append(edits, ReviewEdit{Span: issue.Span, Replacement: issue.Suggestion})
+		}
+	}
+	return edits
+}
+
+// callStep 用步骤配置发送提示词，结果按原文和上下文缓存。返回去除推理标记的响应和步骤结果
+func (t *ThreeStepTranslator) callStep(ctx context.Context, cfg StepConfig, stepName, sourceText, cacheContext, prompt string) (string, StepResult) {
+	startTime := time.Now()
+	cacheKey := GenerateCacheKey(CacheKeyComponents{
+		Step:        stepName,
+		Provider:    cfg.Provider,
+		Model:       cfg.Model,
+		SourceLang:  t.config.SourceLanguage,
+		TargetLang:  t.config.TargetLanguage,
+		Text:        sourceText,
+		Context:     cacheContext,
+		Temperature: cfg.Temperature,
+		MaxTokens:   cfg.MaxTokens,
+	})
+	if t.cache != nil {
+		if cached, ok := t.cache.Get(cacheKey); ok {
+			return cached, newStepResult(cfg, stepName, cached, nil, startTime)
+		}
+	}
+
+	provider, ok := t.providers[cfg.Provider]
+	if !ok {
+		result := newStepResult(cfg, stepName, "", nil, startTime)
+		result.Error = fmt.Sprintf("provider not found: %s", cfg.Provider)
+		return "", result
+	}
+
+	response, err := provider.Translate(ctx, &Request{
+		Text:        prompt,
+		Model:       cfg.Model,
+		Temperature: cfg.Temperature,
+		MaxTokens:   cfg.MaxTokens,
+		Metadata: map[string]interface{}{
+			"step": stepName,
+		},
+	})
+	if err != nil {
+		result := newStepResult(cfg, stepName, "", nil, startTime)
+		result.Error = err.Error()
+		return "", result
+	}
+
+	output := RemoveReasoningMarkers(response.Text)
+	if t.cache != nil {
+		_ = t.cache.Set(cacheKey, output)
+	}
+	return output, newStepResult(cfg, stepName, output, response, startTime)
+}
+
+// newStepResult 创建步骤结果，提供商没有返回成本时按步骤配置的价格估算
+func newStepResult(cfg StepConfig, name, output string, response *Response, startTime time.Time) StepResult {
+	result := StepResult{
+		Name:     name,
+		Output:   output,
+		Duration: time.Since(startTime),
+		Model:    cfg.Model,
+	}
+	if response != nil {
+		result.TokensIn = response.Usage.InputTokens
+		result.TokensOut = response.Usage.OutputTokens
+		result.Cost = response.Usage.Cost
+		if result.Cost == 0 {
+			result.Cost = estimateCost(cfg, result.TokensIn, result.TokensOut)
+		}
+	}
+	return result
+}
+
+// estimateCost 按步骤配置的每 1M token 价格估算成本
+func estimateCost(cfg StepConfig, tokensIn, tokensOut int) float64 {
+	return (float64(tokensIn)*cfg.InputPrice + float64(tokensOut)*cfg.OutputPrice) / 1e6
+}
+
+// parseReviewIssues 解析审校的 JSON 回复，丢弃片段不在译文中的问题，未知的严重程度按 minor 处理
+func parseReviewIssues(response, translation string) ([]ReviewIssue, error) {
+	var reply struct {
+		Issues []ReviewIssue `json:"issues"`
+	}
+	if err := decodeJSONObject(response, &reply); err != nil {
+		return nil, fmt.Errorf("invalid review response: %w", err)
+	}
+
+	var issues []ReviewIssue
+	for _, issue := range reply.Issues {
+		if issue.Span == "" || !strings.Contains(translation, issue.Span) {
+			continue
+		}
+		issue.Severity = strings.ToLower(strings.TrimSpace(issue.Severity))
+		if issue.Severity != SeverityMajor && issue.Severity != SeverityCritical {
+			issue.Severity = SeverityMinor
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// parseReviewEdits 解析编辑的 JSON 回复
+func parseReviewEdits(response string) ([]ReviewEdit, error) {
+	var reply struct {
+		Edits []ReviewEdit `json:"edits"`
+	}
+	if err := decodeJSONObject(response, &reply); err != nil {
+		return nil, fmt.Errorf("invalid edit response: %w", err)
+	}
+	return reply.Edits, nil
+}
+
+// applyReviewEdits 依次替换被标出的片段（每个片段只替换第一处），忽略没有被标出的片段，
+// 以及增删节点标记的替换，返回修改后的译文和已修正的问题
+func applyReviewEdits(translation string, issues []ReviewIssue, edits []ReviewEdit) (string, []ReviewIssue) {
+	flagged := make(map[string]ReviewIssue, len(issues))
+	for _, issue := range issues {
+		flagged[issue.Span] = issue
+	}
+
+	var applied []ReviewIssue
+	for _, edit := range edits {
+		issue, ok := flagged[edit.Span]
+		if !ok || edit.Replacement == edit.Span || !strings.Contains(translation, edit.Span) ||
+			strings.Count(edit.Span, nodeMarkerPrefix) != strings.Count(edit.Replacement, nodeMarkerPrefix) {
+			continue
+		}
+		translation = strings.Replace(translation, edit.Span, edit.Replacement, 1)
+		applied = append(applied, issue)
+		delete(flagged, edit.Span)
+	}
+	return translation, applied
+}
+
+// hasMajorIssue 判断是否有 major 或 critical 问题
+func hasMajorIssue(issues []ReviewIssue) bool {
+	for _, issue := range issues {
+		if issue.IsMajor() {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeJSONObject 从回复中取出第一个 "{" 到最后一个 "}" 之间的 JSON 对象并解析，容忍代码块和说明文字
+func decodeJSONObject(response string, v interface{}) error {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object in response: %q", response)
+	}
+	return json.Unmarshal([]byte(response[start:end+1]), v)
+}
@@ -0,0 +1,202 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider 按步骤依次返回预设的响应，并记录收到的提示词
+type scriptedProvider struct {
+	responses map[string][]string
+	prompts   map[string][]string
+}
+
+func newScriptedProvider(responses map[string][]string) *scriptedProvider {
+	return &scriptedProvider{responses: responses, prompts: make(map[string][]string)}
+}
+
+func (p *scriptedProvider) Name() string {
+	return "scripted"
+}
+
+func (p *scriptedProvider) Translate(ctx context.Context, req *Request) (*Response, error) {
+	step, _ := req.Metadata["step"].(string)
+	p.prompts[step] = append(p.prompts[step], req.Text)
+	if len(p.responses[step]) == 0 {
+		return nil, fmt.Errorf("unexpected %s request", step)
+	}
+	text := p.responses[step][0]
+	p.responses[step] = p.responses[step][1:]
+	return &Response{Text: text, Usage: Usage{InputTokens: 100, OutputTokens: 20}}, nil
+}
+
+func newReviewTranslator(provider Provider, review ReviewConfig, withEditor bool) *ThreeStepTranslator {
+	step := StepConfig{Provider: "scripted", Model: "m", InputPrice: 1, OutputPrice: 2}
+	stepSet := &StepSetConfig{Initial: step, Reflection: step, Mode: ModeReview, Review: review}
+	if withEditor {
+		stepSet.Improvement = step
+	}
+	return NewThreeStepTranslator(&Config{
+		SourceLanguage: "English",
+		TargetLanguage: "English",
+		Metadata:       map[string]interface{}{"fast_mode_threshold": 0},
+	}, map[string]Provider{"scripted": provider}, stepSet)
+}
+
+func translateWithSteps(t *testing.T, translator *ThreeStepTranslator) (string, []StepResult) {
+	var steps []StepResult
+	ctx := WithStepResultRecorder(context.Background(), func(step StepResult) {
+		steps = append(steps, step)
+	})
+	output, err := translator.TranslateText(ctx, "Le chat est assis sur le tapis.")
+	require.NoError(t, err)
+	return output, steps
+}
+
+func TestReviewLoopFixesOnlyFlaggedSpans(t *testing.T) {
+	provider := newScriptedProvider(map[string][]string{
+		"initial": {"The cat sit on teh mat."},
+		"review": {
+			`{"issues": [
+				{"type": "grammar", "span": "sit", "severity": "Major", "suggestion": "sits"},
+				{"type": "fluency", "span": "teh", "severity": "minor", "suggestion": "the"},
+				{"type": "omission", "span": "not in the translation", "severity": "critical"}
+			]}`,
+			"```json\n{\"issues\": []}\n```",
+		},
+		"edit": {`{"edits": [
+			{"span": "sit", "replacement": "sits"},
+			{"span": "teh", "replacement": "the"},
+			{"span": "The cat", "replacement": "A dog"}
+		]}`},
+	})
+
+	output, steps := translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{}, true))
+	assert.Equal(t, "The cat sits on the mat.", output, "the unflagged span must not change")
+
+	require.Len(t, steps, 4)
+	assert.Equal(t, []string{"initial", "review", "edit", "review"}, []string{steps[0].Name, steps[1].Name, steps[2].Name, steps[3].Name})
+	assert.Equal(t, []int{0, 1, 1, 2}, []int{steps[0].Iteration, steps[1].Iteration, steps[2].Iteration, steps[3].Iteration})
+
+	review := steps[1]
+	require.Len(t, review.Issues, 2, "issues whose span is not in the translation are dropped")
+	assert.Equal(t, SeverityMajor, review.Issues[0].Severity)
+	assert.Equal(t, "The cat sits on the mat.", steps[2].Output)
+	assert.Len(t, steps[2].Issues, 2)
+	assert.Empty(t, steps[3].Issues)
+	assert.InDelta(t, 140e-6, steps[0].Cost, 1e-12, "100 tokens in at 1/M plus 20 tokens out at 2/M")
+
+	assert.Contains(t, provider.prompts["review"][0], `{"issues": []}`)
+	assert.Contains(t, provider.prompts["edit"][0], `"span": "teh"`)
+	assert.Contains(t, provider.prompts["review"][1], "The cat sits on the mat.")
+}
+
+func TestReviewLoopStopsAfterMinorIssues(t *testing.T) {
+	provider := newScriptedProvider(map[string][]string{
+		"initial": {"The cat sits on teh mat."},
+		"review":  {`{"issues": [{"type": "fluency", "span": "teh", "severity": "minor", "suggestion": "the"}]}`},
+		"edit":    {`{"edits": [{"span": "teh", "replacement": "the"}]}`},
+	})
+
+	output, steps := translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{}, true))
+	assert.Equal(t, "The cat sits on the mat.", output)
+	assert.Len(t, steps, 3, "no second review after fixing only minor issues")
+}
+
+func TestReviewLoopBounds(t *testing.T) {
+	majorIssue := `{"issues": [{"type": "mistranslation", "span": "cat", "severity": "major", "suggestion": "dog"}]}`
+
+	// 达到最大轮数
+	provider := newScriptedProvider(map[string][]string{
+		"initial": {"The cat sits."},
+		"review":  {majorIssue},
+		"edit":    {`{"edits": [{"span": "cat", "replacement": "dog"}]}`},
+	})
+	output, steps := translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{MaxIterations: 1}, true))
+	assert.Equal(t, "The dog sits.", output)
+	assert.Len(t, steps, 3)
+
+	// 初始翻译已用完 token 上限
+	provider = newScriptedProvider(map[string][]string{"initial": {"The cat sits."}})
+	output, steps = translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{MaxTokens: 100}, true))
+	assert.Equal(t, "The cat sits.", output)
+	assert.Len(t, steps, 1)
+
+	// 审校之后达到成本上限，不再编辑
+	provider = newScriptedProvider(map[string][]string{
+		"initial": {"The cat sits."},
+		"review":  {majorIssue},
+	})
+	output, steps = translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{MaxCost: 200e-6}, true))
+	assert.Equal(t, "The cat sits.", output)
+	assert.Len(t, steps, 2)
+}
+
+func TestReviewLoopWithoutEditorAppliesSuggestions(t *testing.T) {
+	provider := newScriptedProvider(map[string][]string{
+		"initial": {"The cat sit on the mat."},
+		"review": {
+			`{"issues": [{"type": "grammar", "span": "sit", "severity": "major", "suggestion": "sits"}]}`,
+			`{"issues": []}`,
+		},
+	})
+
+	output, steps := translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{}, false))
+	assert.Equal(t, "The cat sits on the mat.", output)
+	require.Len(t, steps, 4)
+	assert.Equal(t, "edit", steps[2].Name)
+	assert.Zero(t, steps[2].TokensIn)
+}
+
+func TestReviewLoopKeepsTranslationWhenReviewFails(t *testing.T) {
+	provider := newScriptedProvider(map[string][]string{
+		"initial": {"The cat sits."},
+		"review":  {"Looks good to me!"},
+	})
+
+	output, steps := translateWithSteps(t, newReviewTranslator(provider, ReviewConfig{}, true))
+	assert.Equal(t, "The cat sits.", output)
+	require.Len(t, steps, 2)
+	assert.Contains(t, steps[1].Error, "invalid review response")
+}
+
+func TestChainReviewMode(t *testing.T) {
+	provider := &structuredProvider{replies: []string{
+		"The cat sit on teh mat.",
+		`{"issues": [{"type": "grammar", "span": "sit", "severity": "major"}, {"type": "fluency", "span": "teh", "severity": "minor"}]}`,
+		`{"edits": [{"span": "sit", "replacement": "sits"}, {"span": "teh", "replacement": "the"}]}`,
+		`{"issues": []}`,
+	}}
+	chain := NewChain(WithReviewMode(ReviewConfig{})).
+		AddStep(NewProviderStep(&StepConfig{Name: "initial"}, provider, nil)).
+		AddStep(NewProviderStep(&StepConfig{Name: "review", InputPrice: 1, OutputPrice: 2}, provider, nil)).
+		AddStep(NewProviderStep(&StepConfig{Name: "edit"}, provider, nil))
+
+	var recorded []StepResult
+	ctx := WithStepResultRecorder(context.Background(), func(step StepResult) {
+		recorded = append(recorded, step)
+	})
+	result, err := chain.Execute(ctx, "Le chat est assis sur le tapis.")
+	require.NoError(t, err)
+	assert.Equal(t, "The cat sits on the mat.", result.FinalOutput)
+
+	// 每一轮的审校和编辑都交给记录函数
+	require.Len(t, recorded, 4)
+	assert.Equal(t, result.Steps, recorded)
+	assert.Equal(t, []string{"initial", "review", "edit", "review"}, []string{recorded[0].Name, recorded[1].Name, recorded[2].Name, recorded[3].Name})
+	assert.Equal(t, []int{0, 1, 1, 2}, []int{recorded[0].Iteration, recorded[1].Iteration, recorded[2].Iteration, recorded[3].Iteration})
+	assert.Len(t, recorded[1].Issues, 2)
+	assert.Equal(t, "The cat sits on the mat.", recorded[2].Output)
+	assert.InDelta(t, 20e-6, recorded[1].Cost, 1e-12, "10 tokens in at 1/M plus 5 tokens out at 2/M")
+
+	// 审校和编辑的提示词作为完整提示词发送
+	require.Len(t, provider.requests, 4)
+	assert.Equal(t, true, provider.requests[1].Metadata[providers.MetadataRawPrompt])
+	assert.Contains(t, provider.requests[1].Text, "The cat sit on teh mat.")
+	assert.Contains(t, provider.requests[2].Text, `"span": "teh"`)
+}
@@ -8,14 +8,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"go.uber.org/zap"
 )
 
 // service 翻译服务实现
 type service struct {
-	config  *Config
-	options serviceOptions
-	chain   Chain
-	mu      sync.RWMutex
+	config    *Config
+	options   serviceOptions
+	chain     Chain
+	templates *PromptTemplates
+	mu        sync.RWMutex
 }
 
 // New 创建新的翻译服务
@@ -57,10 +60,17 @@ func New(config *Config, opts ...Option) (Service, error) {
 		options.chunker = NewSmartChunker(config.ChunkSize, config.ChunkOverlap)
 	}
 
+	// 加载提示词模板
+	templates, err := config.LoadPromptTemplates()
+	if err != nil {
+		return nil, WrapError(err, ErrCodeConfig, fmt.Sprintf("failed to load prompt templates: %v", err))
+	}
+
 	// 创建服务实例
 	s := &service{
-		config:  config.Clone(),
-		options: options,
+		config:    config.Clone(),
+		options:   options,
+		templates: templates,
 	}
 
 	// 构建翻译链
@@ -73,7 +83,15 @@ func New(config *Config, opts ...Option) (Service, error) {
 
 // buildChain 构建翻译链
 func (s *service) buildChain() error {
-	s.chain = NewChain(WithChainLogger(s.options.logger))
+	chainOpts := []ChainOption{WithChainLogger(s.options.logger)}
+	if stepSet, ok := s.config.StepSets[s.config.ActiveStepSet]; ok && stepSet.Mode == ModeReview {
+		chainOpts = append(chainOpts, WithReviewMode(ReviewConfig{
+			MaxIterations: stepSet.Review.MaxIterations,
+			MaxCost:       stepSet.Review.MaxCost,
+			MaxTokens:     stepSet.Review.MaxTokens,
+		}))
+	}
+	s.chain = NewChain(chainOpts...)
 
 	// 为每个配置的步骤创建 Step
 	for _, stepConfig := range s.config.Steps {
@@ -86,6 +104,14 @@ func (s *service) buildChain() error {
 		}
 		cfg.Variables["source_language"] = s.config.SourceLanguage
 		cfg.Variables["target_language"] = s.config.TargetLanguage
+		if s.config.Country != "" {
+			cfg.Variables["country"] = s.config.Country
+		}
+		cfg.Templates = s.templates
+		cfg.StepSet = s.config.ActiveStepSet
+		if cfg.BatchProtocol == "" {
+			cfg.BatchProtocol = s.config.BatchProtocol
+		}
 
 		// 根据配置选择使用提供商还是 LLM
 		var step Step
@@ -326,6 +352,97 @@ func (s *service) TranslateText(ctx context.Context, text string) (string, error
 	return chainResult.FinalOutput, nil
 }
 
+// ContextWindow 返回各步骤提供商报告的最小上下文长度，没有提供商报告时返回 0
+func (s *service) ContextWindow(ctx context.Context) int {
+	contextWindow := 0
+	for _, stepConfig := range s.config.Steps {
+		provider, ok := s.options.providers[stepConfig.Provider].(providers.ContextWindowProvider)
+		if !ok {
+			continue
+		}
+		size, err := provider.ContextWindow(ctx)
+		if err != nil {
+			if s.options.logger != nil {
+				s.options.logger.Debug("failed to get context window",
+					zap.String("provider", stepConfig.Provider), zap.Error(err))
+			}
+			continue
+		}
+		if size > 0 && (contextWindow == 0 || size < contextWindow) {
+			contextWindow = size
+		}
+	}
+	return contextWindow
+}
+
+// PromptTemplateVersion 返回使用的提示词模板版本，未配置模板目录时为 builtin
+func (s *service) PromptTemplateVersion() string {
+	return s.templates.Version()
+}
+
+// GenerateDocumentBrief 使用第一个步骤的模型为整篇文档生成简介
+func (s *service) GenerateDocumentBrief(ctx context.Context, text string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", ErrEmptyText
+	}
+	if len(s.config.Steps) == 0 {
+		return "", ErrNoSteps
+	}
+
+	stepConfig := s.config.Steps[0]
+	prompt := NewPromptBuilder(s.config.SourceLanguage, s.config.TargetLanguage, "").BuildDocumentBriefPrompt(text)
+	instruction := "You are an experienced editor who prepares briefs for translators."
+
+	if stepConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stepConfig.Timeout)
+		defer cancel()
+	}
+
+	var brief string
+	if provider, ok := s.options.providers[stepConfig.Provider]; ok && stepConfig.Provider != "" {
+		// 专业翻译服务和 raw 提供商无法回答提示词
+		if !provider.SupportsSteps() || stepConfig.Provider == "raw" || stepConfig.Provider == "none" {
+			return "", fmt.Errorf("provider '%s' cannot generate a document brief", stepConfig.Provider)
+		}
+		resp, err := provider.Translate(ctx, &ProviderRequest{
+			Text:           prompt,
+			SourceLanguage: s.config.SourceLanguage,
+			TargetLanguage: s.config.TargetLanguage,
+			Metadata: map[string]interface{}{
+				providers.MetadataRawPrompt: true,
+				"instruction":               instruction,
+			},
+		})
+		if err != nil {
+			return "", WrapError(err, ErrCodeLLM, fmt.Sprintf("provider '%s' failed to generate document brief", stepConfig.Provider))
+		}
+		brief = resp.Text
+	} else if s.options.llmClient != nil {
+		resp, err := s.options.llmClient.Chat(ctx, &ChatRequest{
+			Messages: []ChatMessage{
+				{Role: "system", Content: instruction},
+				{Role: "user", Content: prompt},
+			},
+			Model:       stepConfig.Model,
+			Temperature: stepConfig.Temperature,
+			MaxTokens:   stepConfig.MaxTokens,
+		})
+		if err != nil {
+			return "", WrapError(err, ErrCodeLLM, "failed to generate document brief")
+		}
+		brief = resp.Message.Content
+	} else {
+		return "", ErrNoLLMClient
+	}
+
+	brief = strings.TrimSpace(RemoveReasoningMarkers(brief))
+	if brief == "" {
+		return "", fmt.Errorf("model returned an empty document brief")
+	}
+	return brief, nil
+}
+
 // TranslateBatch 批量翻译
 func (s *service) TranslateBatch(ctx context.Context, reqs []*Request) ([]*Response, error) {
 	if len(reqs) == 0 {
@@ -0,0 +1,123 @@
+package translation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+)
+
+const (
+	// BatchProtocolMarkers 用 @@NODE_START_n@@ / @@NODE_END_n@@ 标记打包节点
+	BatchProtocolMarkers = "markers"
+	// BatchProtocolJSON 支持结构化输出的提供商发送 [{id, text}]，要求返回 [{id, translation}]
+	BatchProtocolJSON = "json"
+)
+
+// structuredBatchInstruction JSON 批量协议的输出说明
+const structuredBatchInstruction = `The input is a JSON array of segments, each with an "id" and a "text".
+Translate every "text" independently and respond with a JSON object {"translations": [{"id": <id>, "translation": "<translated text>"}]}.
+- Include every id exactly once, in the same order as the input
+- Do not translate, merge or split ids
+- Keep placeholders such as @@PRESERVE_0@@, line breaks and markup exactly as they appear in the text`
+
+// batchSegment JSON 批量请求中的一个节点
+type batchSegment struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// batchTranslation JSON 批量响应中的一个节点
+type batchTranslation struct {
+	ID          *int    `json:"id"`
+	Translation *string `json:"translation"`
+}
+
+// EncodeBatchJSON 把节点编码为 [{id, text}] 形式的 JSON 数组
+func EncodeBatchJSON(ids []int, texts []string) (string, error) {
+	if len(ids) != len(texts) {
+		return "", fmt.Errorf("got %d ids for %d texts", len(ids), len(texts))
+	}
+	segments := make([]batchSegment, len(ids))
+	for i := range ids {
+		segments[i] = batchSegment{ID: ids[i], Text: texts[i]}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(segments); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// DecodeBatchJSON 解析并校验 JSON 批量响应，返回与 ids 顺序一致的译文。
+// 响应可以是 {"translations": [...]} 或直接的数组；缺少、重复或多出的 ID 都视为无效响应
+func DecodeBatchJSON(response string, ids []int) ([]string, error) {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```json")
+		response = strings.TrimPrefix(response, "```")
+		response = strings.TrimSuffix(strings.TrimSpace(response), "```")
+		response = strings.TrimSpace(response)
+	}
+
+	var items []batchTranslation
+	if strings.HasPrefix(response, "[") {
+		if err := json.Unmarshal([]byte(response), &items); err != nil {
+			return nil, fmt.Errorf("invalid JSON batch response: %w", err)
+		}
+	} else {
+		var wrapper struct {
+			Translations []batchTranslation `json:"translations"`
+		}
+		if err := json.Unmarshal([]byte(response), &wrapper); err != nil {
+			return nil, fmt.Errorf("invalid JSON batch response: %w", err)
+		}
+		if wrapper.Translations == nil {
+			return nil, fmt.Errorf("JSON batch response has no translations")
+		}
+		items = wrapper.Translations
+	}
+
+	expected := make(map[int]int, len(ids))
+	for i, id := range ids {
+		expected[id] = i
+	}
+	translations := make([]string, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for i, item := range items {
+		if item.ID == nil || item.Translation == nil {
+			return nil, fmt.Errorf("JSON batch item %d must have an integer id and a string translation", i)
+		}
+		index, ok := expected[*item.ID]
+		if !ok {
+			return nil, fmt.Errorf("JSON batch response has unknown id %d", *item.ID)
+		}
+		if seen[*item.ID] {
+			return nil, fmt.Errorf("JSON batch response has duplicate id %d", *item.ID)
+		}
+		seen[*item.ID] = true
+		translations[index] = *item.Translation
+	}
+	if len(seen) != len(expected) {
+		var missing []string
+		for _, id := range ids {
+			if !seen[id] {
+				missing = append(missing, fmt.Sprint(id))
+			}
+		}
+		return nil, fmt.Errorf("JSON batch response is missing ids %s", strings.Join(missing, ", "))
+	}
+	return translations, nil
+}
+
+// supportsStructuredOutput 判断提供商是否支持结构化输出
+func supportsStructuredOutput(provider TranslationProvider) bool {
+	structured, ok := provider.(providers.StructuredOutputProvider)
+	return ok && structured.SupportsStructuredOutput()
+}
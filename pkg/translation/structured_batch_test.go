@@ -0,0 +1,181 @@
+package translation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nerdneilsfield/go-translator-agent/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// structuredProvider 按顺序返回预设响应并记录请求的模拟提供商
+type structuredProvider struct {
+	structured bool
+	replies    []string
+	requests   []*ProviderRequest
+}
+
+func (p *structuredProvider) Translate(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
+	p.requests = append(p.requests, req)
+	reply := p.replies[len(p.requests)-1]
+	return &ProviderResponse{Text: reply, TokensIn: 10, TokensOut: 5}, nil
+}
+
+func (p *structuredProvider) GetName() string                { return "structured" }
+func (p *structuredProvider) SupportsSteps() bool            { return false }
+func (p *structuredProvider) SupportsStructuredOutput() bool { return p.structured }
+
+const structuredBatchInput = "@@NODE_START_1@@\nHello\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n<b>World</b>\n@@NODE_END_2@@"
+
+func TestEncodeBatchJSON(t *testing.T) {
+	text, err := EncodeBatchJSON([]int{1, 2}, []string{"Hello", "<b>World</b>"})
+	require.NoError(t, err)
+	assert.Contains(t, text, `"id": 1`)
+	assert.Contains(t, text, `"text": "<b>World</b>"`, "HTML is not escaped")
+
+	_, err = EncodeBatchJSON([]int{1}, []string{"a", "b"})
+	assert.Error(t, err)
+}
+
+func TestDecodeBatchJSON(t *testing.T) {
+	ids := []int{1, 2}
+	tests := []struct {
+		name     string
+		response string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "wrapped object",
+			response: `{"translations":[{"id":1,"translation":"你好"},{"id":2,"translation":"世界"}]}`,
+			want:     []string{"你好", "世界"},
+		},
+		{
+			name:     "bare array in code fence, out of order",
+			response: "```json\n[{\"id\":2,\"translation\":\"世界\"},{\"id\":1,\"translation\":\"你好\"}]\n```",
+			want:     []string{"你好", "世界"},
+		},
+		{
+			name:     "missing id",
+			response: `[{"id":1,"translation":"你好"}]`,
+			wantErr:  "missing ids 2",
+		},
+		{
+			name:     "duplicate id",
+			response: `[{"id":1,"translation":"你好"},{"id":1,"translation":"世界"}]`,
+			wantErr:  "duplicate id 1",
+		},
+		{
+			name:     "unknown id",
+			response: `[{"id":1,"translation":"你好"},{"id":3,"translation":"世界"}]`,
+			wantErr:  "unknown id 3",
+		},
+		{
+			name:     "missing translation",
+			response: `[{"id":1,"translation":"你好"},{"id":2}]`,
+			wantErr:  "must have an integer id and a string translation",
+		},
+		{
+			name:     "not JSON",
+			response: "@@NODE_START_1@@\n你好\n@@NODE_END_1@@",
+			wantErr:  "invalid JSON batch response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeBatchJSON(tt.response, ids)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProviderStepJSONBatchProtocol(t *testing.T) {
+	provider := &structuredProvider{
+		structured: true,
+		replies:    []string{`{"translations":[{"id":1,"translation":"你好"},{"id":2,"translation":"<b>世界</b>"}]}`},
+	}
+	step := NewProviderStep(&StepConfig{Name: "initial", BatchProtocol: BatchProtocolJSON}, provider, nil)
+
+	output, err := step.Execute(context.Background(), StepInput{
+		Text:           structuredBatchInput,
+		SourceLanguage: "English",
+		TargetLanguage: "Chinese",
+		Context:        map[string]string{"style_guide": "Use simplified Chinese."},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "@@NODE_START_1@@\n你好\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n<b>世界</b>\n@@NODE_END_2@@", output.Text)
+
+	require.Len(t, provider.requests, 1)
+	req := provider.requests[0]
+	require.NotNil(t, req.StructuredBatch())
+	assert.Equal(t, []int{1, 2}, req.StructuredBatch().IDs)
+	assert.Contains(t, req.Text, `"text": "Hello"`)
+	assert.NotContains(t, req.Text, "@@NODE_START_")
+	assert.Contains(t, req.Metadata["instruction"], "Use simplified Chinese.")
+	assert.Contains(t, req.Metadata["instruction"], `"translations"`)
+}
+
+func TestProviderStepJSONBatchFallsBackToMarkers(t *testing.T) {
+	markers := "@@NODE_START_1@@\n你好\n@@NODE_END_1@@\n\n@@NODE_START_2@@\n世界\n@@NODE_END_2@@"
+	provider := &structuredProvider{
+		structured: true,
+		replies:    []string{`{"translations":[{"id":1,"translation":"你好"}]}`, markers},
+	}
+	step := NewProviderStep(&StepConfig{Name: "initial", BatchProtocol: BatchProtocolJSON}, provider, nil)
+
+	output, err := step.Execute(context.Background(), StepInput{Text: structuredBatchInput, SourceLanguage: "en", TargetLanguage: "zh"})
+	require.NoError(t, err)
+	assert.Equal(t, markers, output.Text)
+	assert.Equal(t, 20, output.TokensIn, "tokens of both requests are counted")
+
+	require.Len(t, provider.requests, 2)
+	assert.Equal(t, structuredBatchInput, provider.requests[1].Text)
+	assert.Nil(t, provider.requests[1].StructuredBatch())
+}
+
+func TestProviderStepJSONBatchRequiresStructuredOutput(t *testing.T) {
+	markers := "@@NODE_START_1@@\n你好\n@@NODE_END_1@@"
+	provider := &structuredProvider{replies: []string{markers}}
+	step := NewProviderStep(&StepConfig{Name: "initial", BatchProtocol: BatchProtocolJSON}, provider, nil)
+
+	output, err := step.Execute(context.Background(), StepInput{Text: structuredBatchInput, SourceLanguage: "en", TargetLanguage: "zh"})
+	require.NoError(t, err)
+	assert.Equal(t, markers, output.Text)
+	require.Len(t, provider.requests, 1)
+	assert.Equal(t, structuredBatchInput, provider.requests[0].Text)
+	assert.Nil(t, provider.requests[0].StructuredBatch())
+
+	// 结构化批量约束的 Schema 只允许请求中的 ID
+	batch := providers.NewStructuredBatch([]int{1, 2})
+	items := batch.Schema["properties"].(map[string]interface{})["translations"].(map[string]interface{})["items"].(map[string]interface{})
+	id := items["properties"].(map[string]interface{})["id"].(map[string]interface{})
+	assert.Equal(t, []interface{}{1, 2}, id["enum"])
+}
+
+func TestProviderStepAdditionalNotes(t *testing.T) {
+	provider := &structuredProvider{replies: []string{"你好", "你好！", "嗨"}}
+	chain := NewChain().AddStep(NewProviderStep(&StepConfig{
+		Name:            "initial",
+		AdditionalNotes: "Keep it short.",
+	}, provider, NewMemoryCache()))
+
+	_, err := chain.Execute(context.Background(), "Hello")
+	require.NoError(t, err)
+	_, err = chain.Execute(context.WithValue(context.Background(), "_additional_notes", "Translate 'Hello' as 你好！"), "Hello")
+	require.NoError(t, err)
+
+	// 上下文中的附加说明和步骤配置的附加说明都作为提供商的附加指令发送，且不命中无说明的缓存
+	require.Len(t, provider.requests, 2)
+	assert.Contains(t, provider.requests[0].Metadata["instruction"], "Keep it short.")
+	assert.NotContains(t, provider.requests[0].Metadata["instruction"], "你好！")
+	assert.Contains(t, provider.requests[1].Metadata["instruction"], "Keep it short.")
+	assert.Contains(t, provider.requests[1].Metadata["instruction"], "Translate 'Hello' as 你好！")
+}
@@ -0,0 +1,84 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nerdneilsfield/go-translator-agent/internal/config"
+)
+
+// styleProfileKey 上下文中风格配置的键
+type styleProfileKey struct{}
+
+// WithStyleProfile 在上下文中设置风格配置，翻译链会把它渲染到每个步骤的提示词中
+func WithStyleProfile(ctx context.Context, profile *config.StyleProfile) context.Context {
+	if profile == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, styleProfileKey{}, profile)
+}
+
+// styleProfileFromContext 从上下文中读取风格配置
+func styleProfileFromContext(ctx context.Context) *config.StyleProfile {
+	profile, _ := ctx.Value(styleProfileKey{}).(*config.StyleProfile)
+	return profile
+}
+
+// RenderStyleGuide 把风格配置渲染为提示词中的风格指南，没有任何规则时返回空字符串
+func RenderStyleGuide(profile *config.StyleProfile) string {
+	if profile == nil {
+		return ""
+	}
+
+	var rules []string
+	if profile.Formality != "" {
+		rules = append(rules, fmt.Sprintf("Register and form of address: %s", profile.Formality))
+	}
+	if profile.QuoteMarks != "" {
+		quotes := []rune(profile.QuoteMarks)
+		rule := fmt.Sprintf("Quotation marks: use %c…%c", quotes[0], quotes[1])
+		if profile.NestedQuoteMarks != "" {
+			nested := []rune(profile.NestedQuoteMarks)
+			rule += fmt.Sprintf(", and %c…%c for quotes inside quotes", nested[0], nested[1])
+		}
+		rules = append(rules, rule+"; never straight quotes in running text")
+	}
+	if profile.DecimalSeparator != "" || profile.ThousandsSeparator != "" {
+		var parts []string
+		if profile.DecimalSeparator != "" {
+			parts = append(parts, fmt.Sprintf("%q as the decimal separator", profile.DecimalSeparator))
+		}
+		if profile.ThousandsSeparator != "" {
+			parts = append(parts, fmt.Sprintf("%q as the thousands separator", profile.ThousandsSeparator))
+		}
+		rules = append(rules, "Numbers: use "+strings.Join(parts, " and "))
+	}
+	if profile.DateFormat != "" {
+		rules = append(rules, fmt.Sprintf("Dates: write dates as %s", profile.DateFormat))
+	}
+	switch profile.Units {
+	case config.UnitsMetric:
+		rules = append(rules, "Units: convert imperial measurements (miles, feet, inches, pounds, °F, …) to metric units")
+	case config.UnitsImperial:
+		rules = append(rules, "Units: convert metric measurements (kilometres, metres, kilograms, °C, …) to imperial units")
+	}
+	if len(profile.KeepTerms) > 0 {
+		rules = append(rules, fmt.Sprintf("Keep these names exactly as written, do not translate them: %s", strings.Join(profile.KeepTerms, ", ")))
+	}
+	if len(profile.ForbiddenTerms) > 0 {
+		rules = append(rules, fmt.Sprintf("Never use these words: %s", strings.Join(profile.ForbiddenTerms, ", ")))
+	}
+	rules = append(rules, profile.Rules...)
+	if len(rules) == 0 {
+		return ""
+	}
+
+	var guide strings.Builder
+	guide.WriteString("Style Guide (apply to every part of the translation):")
+	for _, rule := range rules {
+		guide.WriteString("\n- ")
+		guide.WriteString(rule)
+	}
+	return guide.String()
+}
@@ -23,7 +23,12 @@ Content Protection Rules:
 4. Do not translate URLs, file paths, or code blocks
 5. Preserve any special node markers or processing instructions
 6. Use terminology and expressions appropriate for {{.country}}
-{{if .additional_notes}}
+{{if .style_guide}}
+
+{{.style_guide}}{{end}}{{if .document_brief}}
+
+Document Context (a brief of the whole document this text comes from; use it to choose domain terminology, tone and register):
+{{.document_brief}}{{end}}{{if .additional_notes}}
 
 Additional Notes:
 {{.additional_notes}}
@@ -44,7 +49,10 @@ CRITICAL OUTPUT REQUIREMENT:
 - Provide ONLY the translated text
 - Do NOT include any explanations or additional text
 
-{{if .additional_notes}}
+{{if .style_guide}}{{.style_guide}}
+{{end}}{{if .document_brief}}Document Context (a brief of the whole document this text comes from; use it to choose domain terminology, tone and register):
+{{.document_brief}}
+{{end}}{{if .additional_notes}}
 Additional Notes: {{.additional_notes}}
 {{end}}
 
@@ -71,7 +79,12 @@ Please analyze this translation considering:
 4. Formatting: Is all original formatting preserved?
 5. Consistency: Is the translation consistent throughout?
 6. Cultural appropriateness: Is the language suitable for {{.country}}?
-{{if .additional_notes}}
+{{if .style_guide}}
+
+{{.style_guide}}{{end}}{{if .document_brief}}
+
+Document Context (a brief of the whole document this text comes from; use it to choose domain terminology, tone and register):
+{{.document_brief}}{{end}}{{if .additional_notes}}
 
 Additional Notes: {{.additional_notes}}
 {{end}}
@@ -102,7 +115,12 @@ Requirements for the improved translation:
 3. Ensure natural fluency in {{.target_language}}
 4. Preserve all original formatting
 5. Use appropriate terminology and expressions for {{.country}}
-{{if .additional_notes}}
+{{if .style_guide}}
+
+{{.style_guide}}{{end}}{{if .document_brief}}
+
+Document Context (a brief of the whole document this text comes from; use it to choose domain terminology, tone and register):
+{{.document_brief}}{{end}}{{if .additional_notes}}
 
 Additional Notes: {{.additional_notes}}
 {{end}}
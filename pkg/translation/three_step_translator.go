@@ -83,6 +83,9 @@ func (t *ThreeStepTranslator) TranslateText(ctx context.Context, text string) (s
 
 	if fastMode || len(text) < fastModeThreshold {
 		translatedText, err = t.translateDirect(ctx, protectedText)
+	} else if t.stepSet.Mode == ModeReview {
+		// 使用迭代审校流程
+		translatedText, err = t.translateReview(ctx, protectedText)
 	} else {
 		// 使用三步翻译流程
 		translatedText, err = t.translateThreeStep(ctx, protectedText)
@@ -108,6 +111,7 @@ func (t *ThreeStepTranslator) translateDirect(ctx context.Context, text string)
 		SourceLang:  t.config.SourceLanguage,
 		TargetLang:  t.config.TargetLanguage,
 		Text:        text,
+		Context:     documentBriefFromContext(ctx) + RenderStyleGuide(styleProfileFromContext(ctx)), // 文档简介和风格指南改变提示词
 		Temperature: t.stepSet.Initial.Temperature,
 		MaxTokens:   t.stepSet.Initial.MaxTokens,
 	})
@@ -124,7 +128,7 @@ func (t *ThreeStepTranslator) translateDirect(ctx context.Context, text string)
 	}
 
 	// 构建提示词
-	prompt := t.promptBuilder.BuildDirectTranslationPrompt(text)
+	prompt := t.promptBuilderFor(ctx).BuildDirectTranslationPrompt(text)
 
 	// 执行翻译
 	request := &Request{
@@ -189,6 +193,12 @@ func (t *ThreeStepTranslator) translateThreeStep(ctx context.Context, text strin
 
 // initialTranslation 执行初始翻译
 func (t *ThreeStepTranslator) initialTranslation(ctx context.Context, text string) (string, error) {
+	translation, _, err := t.initialTranslationWithResponse(ctx, text)
+	return translation, err
+}
+
+// initialTranslationWithResponse 执行初始翻译，同时返回提供商的响应（命中缓存时为 nil）用于统计用量
+func (t *ThreeStepTranslator) initialTranslationWithResponse(ctx context.Context, text string) (string, *Response, error) {
 	// 检查缓存
 	cacheKey := GenerateCacheKey(CacheKeyComponents{
 		Step:        "initial",
@@ -197,23 +207,24 @@ func (t *ThreeStepTranslator) initialTranslation(ctx context.Context, text strin
 		SourceLang:  t.config.SourceLanguage,
 		TargetLang:  t.config.TargetLanguage,
 		Text:        text,
+		Context:     documentBriefFromContext(ctx) + RenderStyleGuide(styleProfileFromContext(ctx)), // 文档简介和风格指南改变提示词
 		Temperature: t.stepSet.Initial.Temperature,
 		MaxTokens:   t.stepSet.Initial.MaxTokens,
 	})
 	if t.cache != nil {
 		if cached, ok := t.cache.Get(cacheKey); ok {
-			return cached, nil
+			return cached, nil, nil
 		}
 	}
 
 	// 获取提供者
 	provider, ok := t.providers[t.stepSet.Initial.Provider]
 	if !ok {
-		return "", fmt.Errorf("provider not found: %s", t.stepSet.Initial.Provider)
+		return "", nil, fmt.Errorf("provider not found: %s", t.stepSet.Initial.Provider)
 	}
 
 	// 构建提示词
-	prompt := t.promptBuilder.BuildInitialTranslationPrompt(text)
+	prompt := t.promptBuilderFor(ctx).BuildInitialTranslationPrompt(text)
 
 	// 执行翻译
 	systemPrompt := "You are a professional translator. Follow the instructions carefully."
@@ -231,7 +242,7 @@ func (t *ThreeStepTranslator) initialTranslation(ctx context.Context, text strin
 
 	response, err := provider.Translate(ctx, request)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	translation := ExtractTranslationFromResponse(response.Text)
@@ -244,7 +255,7 @@ func (t *ThreeStepTranslator) initialTranslation(ctx context.Context, text strin
 		_ = t.cache.Set(cacheKey, translation)
 	}
 
-	return translation, nil
+	return translation, response, nil
 }
 
 // reflection 执行反思步骤
@@ -274,7 +285,7 @@ func (t *ThreeStepTranslator) reflection(ctx context.Context, sourceText, transl
 	}
 
 	// 构建提示词
-	prompt := t.promptBuilder.BuildReflectionPrompt(sourceText, translation)
+	prompt := t.promptBuilderFor(ctx).BuildReflectionPrompt(sourceText, translation)
 
 	// 执行反思
 	systemPrompt := "You are a professional translation reviewer. Analyze the translation carefully."
@@ -336,7 +347,7 @@ func (t *ThreeStepTranslator) improvement(ctx context.Context, sourceText, trans
 	}
 
 	// 构建提示词
-	prompt := t.promptBuilder.BuildImprovementPrompt(sourceText, translation, reflection)
+	prompt := t.promptBuilderFor(ctx).BuildImprovementPrompt(sourceText, translation, reflection)
 
 	// 执行改进
 	systemPrompt := "You are a professional translator. Improve the translation based on the feedback."
@@ -394,6 +405,33 @@ func (t *ThreeStepTranslator) GetPromptBuilder() *PromptBuilder {
 	return t.promptBuilder
 }
 
+// promptBuilderFor 返回带有上下文中文档简介和风格配置的提示词构建器
+func (t *ThreeStepTranslator) promptBuilderFor(ctx context.Context) *PromptBuilder {
+	brief := documentBriefFromContext(ctx)
+	profile := styleProfileFromContext(ctx)
+	format := documentFormatFromContext(ctx)
+	if brief == "" && profile == nil && format == "" {
+		return t.promptBuilder
+	}
+	builder := *t.promptBuilder
+	if format != "" {
+		builder.Format = format
+	}
+	if profile != nil {
+		builder.WithStyleProfile(profile)
+	}
+	if brief != "" {
+		builder.WithDocumentBrief(brief)
+	}
+	return &builder
+}
+
+// documentBriefFromContext 从上下文中读取文档简介
+func documentBriefFromContext(ctx context.Context) string {
+	brief, _ := ctx.Value("_document_brief").(string)
+	return brief
+}
+
 // applyProtection 应用保护块
 func (t *ThreeStepTranslator) applyProtection(text string) (string, error) {
 	if !t.preserveManager.config.Enabled {
@@ -65,7 +65,12 @@ type StepResult struct {
 	Model     string        `json:"model"`
 	TokensIn  int           `json:"tokens_in"`
 	TokensOut int           `json:"tokens_out"`
+	Cost      float64       `json:"cost,omitempty"`
 	Error     string        `json:"error,omitempty"`
+
+	// 迭代审校模式：第几轮，以及审校发现（review）或编辑已修正（edit）的问题
+	Iteration int           `json:"iteration,omitempty"`
+	Issues    []ReviewIssue `json:"issues,omitempty"`
 }
 
 // ChainResult 翻译链结果
@@ -219,6 +224,7 @@ type Progress struct {
 
 // Usage 使用情况
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost,omitempty"` // 提供商返回的成本，没有时为 0
 }